@@ -0,0 +1,464 @@
+package agent
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// StateLabel is a pane state as labeled in the detection corpus and
+// predicted by the Classifier.
+type StateLabel string
+
+const (
+	LabelIdle               StateLabel = "idle"
+	LabelWorking            StateLabel = "working"
+	LabelError              StateLabel = "error"
+	LabelRateLimited        StateLabel = "rate_limited"
+	LabelCompacting         StateLabel = "compacting"
+	LabelAwaitingPermission StateLabel = "awaiting_permission"
+)
+
+// StateLabels lists the labels in canonical order.
+var StateLabels = []StateLabel{
+	LabelIdle, LabelWorking, LabelError, LabelRateLimited, LabelCompacting, LabelAwaitingPermission,
+}
+
+// ParseStateLabel parses a label name; hyphens are accepted for underscores.
+func ParseStateLabel(s string) (StateLabel, error) {
+	l := StateLabel(strings.ReplaceAll(strings.ToLower(strings.TrimSpace(s)), "-", "_"))
+	for _, known := range StateLabels {
+		if l == known {
+			return l, nil
+		}
+	}
+	return "", fmt.Errorf("unknown state label %q (want one of %s)", s, joinLabels(StateLabels))
+}
+
+func joinLabels(labels []StateLabel) string {
+	parts := make([]string, len(labels))
+	for i, l := range labels {
+		parts[i] = string(l)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ClassifierVersion is the model format written by Train.
+const ClassifierVersion = 1
+
+// Classifier is a multinomial logistic regression over word n-grams of a
+// pane's recent output. It is trained offline on the labeled corpus and
+// stored as JSON; the regex detectors remain authoritative and the
+// classifier only adjusts their confidence.
+type Classifier struct {
+	Version   int                  `json:"version"`
+	Labels    []StateLabel         `json:"labels"`
+	TailLines int                  `json:"tail_lines"` // Output lines the features are drawn from
+	Bias      []float64            `json:"bias"`
+	Weights   map[string][]float64 `json:"weights"` // Feature -> weight per label
+	Samples   int                  `json:"samples"` // Corpus size at training time
+	TrainedAt time.Time            `json:"trained_at"`
+}
+
+// Prediction is a classifier's answer for one capture.
+type Prediction struct {
+	Label      StateLabel             `json:"label"`
+	Confidence float64                `json:"confidence"` // Probability of Label
+	Scores     map[StateLabel]float64 `json:"scores"`     // Probability per label
+}
+
+// DefaultTailLines is how many trailing lines of output features are drawn from.
+const DefaultTailLines = 40
+
+// classifierTailFeatureLines are the last lines whose tokens also count as
+// tail features: prompts, spinners and dialogs live at the bottom.
+const classifierTailFeatureLines = 3
+
+// features extracts the classifier's features from output: word unigrams
+// and bigrams over the last tailLines lines, plus unigrams of the last few
+// lines. Text is lowercased and digits folded so counters and timers share
+// features. The vector is L2-normalized.
+func features(output string, tailLines int) map[string]float64 {
+	if tailLines <= 0 {
+		tailLines = DefaultTailLines
+	}
+	var lines []string
+	for _, line := range strings.Split(stripANSICodes(output), "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > tailLines {
+		lines = lines[len(lines)-tailLines:]
+	}
+
+	f := make(map[string]float64)
+	for i, line := range lines {
+		toks := tokenize(line)
+		tail := i >= len(lines)-classifierTailFeatureLines
+		for j, tok := range toks {
+			f["w:"+tok] = 1
+			if j > 0 {
+				f["b:"+toks[j-1]+" "+tok] = 1
+			}
+			if tail {
+				f["t:"+tok] = 1
+			}
+		}
+	}
+	if len(f) == 0 {
+		return f
+	}
+	norm := 1 / math.Sqrt(float64(len(f)))
+	for k := range f {
+		f[k] = norm
+	}
+	return f
+}
+
+// tokenize splits a line into lowercase words (digits folded to 0) and
+// single symbol runes.
+func tokenize(line string) []string {
+	var (
+		toks []string
+		word strings.Builder
+	)
+	flush := func() {
+		if word.Len() > 0 {
+			toks = append(toks, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range line {
+		switch {
+		case unicode.IsDigit(r):
+			word.WriteRune('0')
+		case unicode.IsLetter(r) || r == '\'':
+			word.WriteRune(unicode.ToLower(r))
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			flush()
+			toks = append(toks, string(r))
+		}
+	}
+	flush()
+	return toks
+}
+
+// Predict classifies a capture of pane output.
+func (c *Classifier) Predict(output string) Prediction {
+	scores := make([]float64, len(c.Labels))
+	copy(scores, c.Bias)
+	for feat, v := range features(output, c.TailLines) {
+		if w, ok := c.Weights[feat]; ok {
+			for k := range scores {
+				scores[k] += w[k] * v
+			}
+		}
+	}
+	probs := softmax(scores)
+
+	p := Prediction{Scores: make(map[StateLabel]float64, len(c.Labels))}
+	for k, l := range c.Labels {
+		p.Scores[l] = probs[k]
+		if probs[k] > p.Confidence {
+			p.Label, p.Confidence = l, probs[k]
+		}
+	}
+	return p
+}
+
+func softmax(scores []float64) []float64 {
+	out := make([]float64, len(scores))
+	if len(scores) == 0 {
+		return out
+	}
+	max := scores[0]
+	for _, s := range scores[1:] {
+		max = math.Max(max, s)
+	}
+	var sum float64
+	for k, s := range scores {
+		out[k] = math.Exp(s - max)
+		sum += out[k]
+	}
+	for k := range out {
+		out[k] /= sum
+	}
+	return out
+}
+
+// TrainOptions tunes Train.
+type TrainOptions struct {
+	Epochs       int     // Full-batch gradient steps (default 300)
+	LearningRate float64 // Step size (default 1.0)
+	L2           float64 // Weight decay (default 1e-3)
+	MinWeight    float64 // Features whose weights all fall below this are dropped (default 1e-3)
+	TailLines    int     // Default DefaultTailLines
+}
+
+// DefaultTrainOptions returns the options the shipped model is trained with.
+func DefaultTrainOptions() TrainOptions {
+	return TrainOptions{Epochs: 300, LearningRate: 1.0, L2: 1e-3, MinWeight: 1e-3, TailLines: DefaultTailLines}
+}
+
+type sparseFeature struct {
+	index int
+	value float64
+}
+
+// Train fits a classifier to labeled samples. Training is deterministic:
+// the same corpus and options produce the same weights.
+func Train(samples []Sample, opts TrainOptions) (*Classifier, error) {
+	def := DefaultTrainOptions()
+	if opts.Epochs <= 0 {
+		opts.Epochs = def.Epochs
+	}
+	if opts.LearningRate <= 0 {
+		opts.LearningRate = def.LearningRate
+	}
+	if opts.L2 < 0 {
+		opts.L2 = 0
+	}
+	if opts.MinWeight <= 0 {
+		opts.MinWeight = def.MinWeight
+	}
+	if opts.TailLines <= 0 {
+		opts.TailLines = def.TailLines
+	}
+
+	present := make(map[StateLabel]bool)
+	for _, s := range samples {
+		present[s.Label] = true
+	}
+	var labels []StateLabel
+	for _, l := range StateLabels {
+		if present[l] {
+			labels = append(labels, l)
+		}
+	}
+	if len(labels) < 2 {
+		return nil, errors.New("training needs samples of at least two labels")
+	}
+	labelIndex := make(map[StateLabel]int, len(labels))
+	for k, l := range labels {
+		labelIndex[l] = k
+	}
+
+	// Build a sorted vocabulary so feature order, and so the model, is stable.
+	featureMaps := make([]map[string]float64, len(samples))
+	vocabSet := make(map[string]bool)
+	for i, s := range samples {
+		if _, ok := labelIndex[s.Label]; !ok {
+			return nil, fmt.Errorf("sample %s: unknown label %q", s.Name, s.Label)
+		}
+		featureMaps[i] = features(s.Text, opts.TailLines)
+		for f := range featureMaps[i] {
+			vocabSet[f] = true
+		}
+	}
+	vocab := make([]string, 0, len(vocabSet))
+	for f := range vocabSet {
+		vocab = append(vocab, f)
+	}
+	sort.Strings(vocab)
+	vocabIndex := make(map[string]int, len(vocab))
+	for i, f := range vocab {
+		vocabIndex[f] = i
+	}
+	xs := make([][]sparseFeature, len(samples))
+	for i, fm := range featureMaps {
+		for f, v := range fm {
+			xs[i] = append(xs[i], sparseFeature{vocabIndex[f], v})
+		}
+		sort.Slice(xs[i], func(a, b int) bool { return xs[i][a].index < xs[i][b].index })
+	}
+
+	nl := len(labels)
+	w := make([][]float64, len(vocab))
+	for i := range w {
+		w[i] = make([]float64, nl)
+	}
+	bias := make([]float64, nl)
+	gw := make([][]float64, len(vocab))
+	for i := range gw {
+		gw[i] = make([]float64, nl)
+	}
+	gb := make([]float64, nl)
+	scores := make([]float64, nl)
+	n := float64(len(samples))
+
+	for epoch := 0; epoch < opts.Epochs; epoch++ {
+		for i := range gw {
+			clear(gw[i])
+		}
+		clear(gb)
+		for i, x := range xs {
+			copy(scores, bias)
+			for _, f := range x {
+				for k := range scores {
+					scores[k] += w[f.index][k] * f.value
+				}
+			}
+			probs := softmax(scores)
+			target := labelIndex[samples[i].Label]
+			for k := range probs {
+				g := probs[k]
+				if k == target {
+					g--
+				}
+				gb[k] += g
+				for _, f := range x {
+					gw[f.index][k] += g * f.value
+				}
+			}
+		}
+		for k := range bias {
+			bias[k] -= opts.LearningRate * gb[k] / n
+		}
+		for i := range w {
+			for k := range w[i] {
+				w[i][k] -= opts.LearningRate * (gw[i][k]/n + opts.L2*w[i][k])
+			}
+		}
+	}
+
+	c := &Classifier{
+		Version:   ClassifierVersion,
+		Labels:    labels,
+		TailLines: opts.TailLines,
+		Bias:      roundWeights(bias),
+		Weights:   make(map[string][]float64),
+		Samples:   len(samples),
+		TrainedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	for i, f := range vocab {
+		for _, v := range w[i] {
+			if math.Abs(v) >= opts.MinWeight {
+				c.Weights[f] = roundWeights(w[i])
+				break
+			}
+		}
+	}
+	return c, nil
+}
+
+// DefaultFolds is the number of folds CrossValidate splits a corpus into.
+const DefaultFolds = 5
+
+// CrossValidate estimates how Train with opts does on captures it has not
+// seen. Samples are split into folds stratified by label; each fold is
+// scored by a model trained on the others, and the report covers every
+// sample once. Splits are deterministic, so repeated runs agree.
+func CrossValidate(detector string, samples []Sample, folds int, opts TrainOptions) (Report, error) {
+	if folds < 2 {
+		return Report{}, fmt.Errorf("cross-validation needs at least 2 folds, got %d", folds)
+	}
+	fold := make([]int, len(samples))
+	seen := make(map[StateLabel]int)
+	for i, s := range samples {
+		fold[i] = seen[s.Label] % folds
+		seen[s.Label]++
+	}
+	got := make([]StateLabel, len(samples))
+	for f := 0; f < folds; f++ {
+		var train []Sample
+		for i, s := range samples {
+			if fold[i] != f {
+				train = append(train, s)
+			}
+		}
+		c, err := Train(train, opts)
+		if err != nil {
+			return Report{}, fmt.Errorf("fold %d: %w", f+1, err)
+		}
+		for i, s := range samples {
+			if fold[i] == f {
+				got[i] = c.Predict(s.Text).Label
+			}
+		}
+	}
+	return scorePredictions(detector, samples, got), nil
+}
+
+// roundWeights rounds to 4 decimal places, keeping saved models compact and
+// their diffs readable.
+func roundWeights(ws []float64) []float64 {
+	out := make([]float64, len(ws))
+	for k, v := range ws {
+		out[k] = math.Round(v*1e4) / 1e4
+	}
+	return out
+}
+
+// validate checks a decoded model is usable.
+func (c *Classifier) validate() error {
+	if c.Version != ClassifierVersion {
+		return fmt.Errorf("unsupported classifier version %d", c.Version)
+	}
+	if len(c.Labels) < 2 || len(c.Bias) != len(c.Labels) {
+		return errors.New("classifier has inconsistent labels")
+	}
+	for f, w := range c.Weights {
+		if len(w) != len(c.Labels) {
+			return fmt.Errorf("classifier feature %q has %d weights, want %d", f, len(w), len(c.Labels))
+		}
+	}
+	return nil
+}
+
+// ParseClassifier decodes a model saved as JSON.
+func ParseClassifier(data []byte) (*Classifier, error) {
+	var c Classifier
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parse classifier: %w", err)
+	}
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// LoadClassifier reads a model from path.
+func LoadClassifier(path string) (*Classifier, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseClassifier(data)
+}
+
+// Save writes the model to path as compact JSON.
+func (c *Classifier) Save(path string) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+//go:embed classifier_model.json
+var defaultClassifierJSON []byte
+
+var (
+	defaultClassifierOnce sync.Once
+	defaultClassifier     *Classifier
+)
+
+// DefaultClassifier returns the model shipped with ntm, trained on
+// testdata/corpus, or nil if it cannot be decoded.
+func DefaultClassifier() *Classifier {
+	defaultClassifierOnce.Do(func() {
+		defaultClassifier, _ = ParseClassifier(defaultClassifierJSON)
+	})
+	return defaultClassifier
+}
@@ -0,0 +1,237 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	got := tokenize("✻ Thinking… (12s · esc to interrupt)")
+	want := []string{"✻", "thinking", "…", "(", "00s", "·", "esc", "to", "interrupt", ")"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tokenize = %q, want %q", got, want)
+	}
+}
+
+func TestFeatures(t *testing.T) {
+	f := features("first line\n\nYou've hit your limit\n", 1)
+	if _, ok := f["w:first"]; ok {
+		t.Error("feature from outside the tail window")
+	}
+	for _, k := range []string{"w:you've", "b:hit your", "t:limit"} {
+		if _, ok := f[k]; !ok {
+			t.Errorf("missing feature %q in %v", k, f)
+		}
+	}
+	var sum float64
+	for _, v := range f {
+		sum += v * v
+	}
+	if sum < 0.999 || sum > 1.001 {
+		t.Errorf("features not normalized: |f|^2 = %f", sum)
+	}
+	if len(features("  \n", 0)) != 0 {
+		t.Error("blank output has features")
+	}
+}
+
+func TestParseStateLabel(t *testing.T) {
+	if l, err := ParseStateLabel("Rate-Limited"); err != nil || l != LabelRateLimited {
+		t.Errorf("ParseStateLabel(Rate-Limited) = %q, %v", l, err)
+	}
+	if _, err := ParseStateLabel("sleepy"); err == nil {
+		t.Error("unknown label accepted")
+	}
+}
+
+func TestTrainPredictSaveLoad(t *testing.T) {
+	samples := []Sample{
+		{Label: LabelIdle, Text: "Done.\n> "},
+		{Label: LabelIdle, Text: "All tests pass.\n> "},
+		{Label: LabelWorking, Text: "Thinking (3s · esc to interrupt)"},
+		{Label: LabelWorking, Text: "Working (40s · esc to interrupt)"},
+		{Label: LabelRateLimited, Text: "You've hit your limit · resets 4pm"},
+		{Label: LabelRateLimited, Text: "Rate limit exceeded, try again later"},
+	}
+	c, err := Train(samples, TrainOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Labels) != 3 || c.Samples != len(samples) {
+		t.Fatalf("labels = %v, samples = %d", c.Labels, c.Samples)
+	}
+	if p := c.Predict("Generating (7s · esc to interrupt)"); p.Label != LabelWorking {
+		t.Errorf("Predict = %+v, want working", p)
+	}
+
+	again, _ := Train(samples, TrainOptions{})
+	if !reflect.DeepEqual(c.Weights, again.Weights) || !reflect.DeepEqual(c.Bias, again.Bias) {
+		t.Error("training is not deterministic")
+	}
+
+	path := filepath.Join(t.TempDir(), "model.json")
+	if err := c.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadClassifier(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded.Weights, c.Weights) {
+		t.Error("weights changed across save and load")
+	}
+
+	if _, err := Train(samples[:2], TrainOptions{}); err == nil {
+		t.Error("training on a single label succeeded")
+	}
+	if _, err := ParseClassifier([]byte(`{"version":1,"labels":["idle","working"],"bias":[0]}`)); err == nil {
+		t.Error("inconsistent model accepted")
+	}
+}
+
+func TestCorpusRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path, err := SaveSample(dir, Sample{Label: LabelError, Agent: AgentTypeCodex, Name: "boom", Text: "\x1b[31merror: boom\x1b[0m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "error", "cod_boom.txt"); path != want {
+		t.Errorf("path = %s, want %s", path, want)
+	}
+	if _, err := SaveSample(dir, Sample{Label: LabelError, Agent: AgentTypeCodex, Name: "boom", Text: "again"}); err == nil {
+		t.Error("existing sample overwritten")
+	}
+	if _, err := SaveSample(dir, Sample{Label: LabelError, Name: "../x"}); err == nil {
+		t.Error("path in sample name accepted")
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "notes"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	samples, err := LoadCorpus(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 1 {
+		t.Fatalf("got %d samples", len(samples))
+	}
+	s := samples[0]
+	if s.Label != LabelError || s.Agent != AgentTypeCodex || s.Name != "boom" || s.Text != "error: boom\n" {
+		t.Errorf("sample = %+v (%q)", s, s.Text)
+	}
+
+	if _, err := LoadCorpus(t.TempDir()); err == nil {
+		t.Error("empty corpus loaded")
+	}
+}
+
+func TestScore(t *testing.T) {
+	samples := []Sample{
+		{Label: LabelIdle, Path: "a"},
+		{Label: LabelIdle, Path: "b"},
+		{Label: LabelWorking, Path: "c"},
+	}
+	r := Score("fixed", samples, func(Sample) StateLabel { return LabelIdle })
+	if r.Correct != 2 || r.Total != 3 || len(r.Misses) != 1 || r.Misses[0].Path != "c" {
+		t.Fatalf("report = %+v", r)
+	}
+	if r.Labels[0].Label != LabelIdle || r.Labels[0].Precision < 0.66 || r.Labels[0].Recall != 1 {
+		t.Errorf("idle score = %+v", r.Labels[0])
+	}
+	if r.Labels[1].Label != LabelWorking || r.Labels[1].Recall != 0 {
+		t.Errorf("working score = %+v", r.Labels[1])
+	}
+}
+
+func TestCrossValidate(t *testing.T) {
+	samples, err := LoadCorpus(filepath.Join("testdata", "corpus"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CrossValidate("classifier", samples, 1, DefaultTrainOptions()); err == nil {
+		t.Error("1 fold: want error")
+	}
+	opts := DefaultTrainOptions()
+	opts.Epochs = 20
+	a, err := CrossValidate("classifier", samples, 3, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Total != len(samples) || a.Detector != "classifier" {
+		t.Errorf("report = %d samples from %q, want %d", a.Total, a.Detector, len(samples))
+	}
+	b, err := CrossValidate("classifier", samples, 3, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Correct != b.Correct || len(a.Misses) != len(b.Misses) {
+		t.Errorf("runs disagree: %d vs %d correct", a.Correct, b.Correct)
+	}
+}
+
+// TestDefaultClassifier_Corpus guards the shipped model: it must decode,
+// match the checked-in corpus, and its training recipe must hold up on
+// captures it has not seen. Retrain with
+// 'ntm detect train --out internal/agent/classifier_model.json' after
+// adding captures.
+func TestDefaultClassifier_Corpus(t *testing.T) {
+	c := DefaultClassifier()
+	if c == nil {
+		t.Fatal("embedded model does not decode")
+	}
+	samples, err := LoadCorpus(filepath.Join("testdata", "corpus"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Samples != len(samples) {
+		t.Errorf("model trained on %d samples, corpus has %d; retrain it", c.Samples, len(samples))
+	}
+	// Score on held-out folds: the shipped model has seen every sample, so
+	// its accuracy on the corpus says nothing about unseen captures.
+	r, err := CrossValidate("classifier", samples, DefaultFolds, DefaultTrainOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Accuracy < 0.85 {
+		var misses []string
+		for _, m := range r.Misses {
+			misses = append(misses, m.Path+" -> "+string(m.Predicted))
+		}
+		t.Errorf("cross-validated accuracy = %.2f\n%s", r.Accuracy, strings.Join(misses, "\n"))
+	}
+}
+
+func TestCalculateConfidence_Classifier(t *testing.T) {
+	p := &parserImpl{config: ParserConfig{ClassifierWeight: 0.5}}
+	base := &AgentState{Type: AgentTypeClaudeCode, IsIdle: true}
+	if got := p.calculateConfidence(base); got != 0.5 {
+		t.Fatalf("without classifier = %f, want 0.5", got)
+	}
+
+	agree := &AgentState{Type: AgentTypeClaudeCode, IsIdle: true, Classified: LabelIdle, ClassifiedConfidence: 0.8}
+	if got := p.calculateConfidence(agree); got != 0.7 {
+		t.Errorf("agreeing classifier = %f, want 0.7", got)
+	}
+	disagree := &AgentState{Type: AgentTypeClaudeCode, IsIdle: true, Classified: LabelWorking, ClassifiedConfidence: 0.8}
+	if got := p.calculateConfidence(disagree); got != 0.3 {
+		t.Errorf("disagreeing classifier = %f, want 0.3", got)
+	}
+	// With no regex reading there is nothing to agree with.
+	unread := &AgentState{Type: AgentTypeClaudeCode, Classified: LabelWorking, ClassifiedConfidence: 0.8}
+	if got := p.calculateConfidence(unread); got != 0.5 {
+		t.Errorf("no regex reading = %f, want 0.5", got)
+	}
+}
+
+func TestParseWithHint_Classified(t *testing.T) {
+	state, err := NewParser().ParseWithHint("⏺ Done.\n\n  ⎿  You've hit your limit · resets 4pm\n", AgentTypeClaudeCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Classified != LabelRateLimited || state.ClassifiedConfidence <= 0 {
+		t.Errorf("Classified = %q (%f), want rate_limited", state.Classified, state.ClassifiedConfidence)
+	}
+}
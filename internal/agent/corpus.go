@@ -0,0 +1,183 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// The detection corpus is a directory with one subdirectory per state label,
+// each holding plain-text pane captures named <agent>_<name>.txt:
+//
+//	testdata/corpus/idle/cc_after-refactor.txt
+//	testdata/corpus/rate_limited/cod_billing-period.txt
+//
+// A capture is the pane output as 'ntm detect label' saved it, ANSI
+// sequences stripped.
+
+// Sample is one labeled capture in the corpus.
+type Sample struct {
+	Label StateLabel `json:"label"`
+	Agent AgentType  `json:"agent"`
+	Name  string     `json:"name"` // File name without the agent prefix and extension
+	Path  string     `json:"path,omitempty"`
+	Text  string     `json:"-"`
+}
+
+// LoadCorpus reads every sample under dir, ordered by label and file name.
+// Subdirectories that are not state labels are ignored.
+func LoadCorpus(dir string) ([]Sample, error) {
+	var samples []Sample
+	for _, label := range StateLabels {
+		labelDir := filepath.Join(dir, string(label))
+		entries, err := os.ReadDir(labelDir)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if e.IsDir() || filepath.Ext(e.Name()) != ".txt" {
+				continue
+			}
+			path := filepath.Join(labelDir, e.Name())
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			agentType, name := splitSampleName(strings.TrimSuffix(e.Name(), ".txt"))
+			samples = append(samples, Sample{Label: label, Agent: agentType, Name: name, Path: path, Text: string(data)})
+		}
+	}
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no samples in corpus %s", dir)
+	}
+	return samples, nil
+}
+
+func splitSampleName(base string) (AgentType, string) {
+	prefix, name, ok := strings.Cut(base, "_")
+	if !ok {
+		return AgentTypeUnknown, base
+	}
+	if t := AgentType(prefix); t.IsValid() {
+		return t, name
+	}
+	return AgentTypeUnknown, base
+}
+
+// SaveSample writes s into the corpus under dir and returns its path. An
+// existing sample of the same name is not overwritten.
+func SaveSample(dir string, s Sample) (string, error) {
+	if _, err := ParseStateLabel(string(s.Label)); err != nil {
+		return "", err
+	}
+	if s.Name == "" || strings.ContainsAny(s.Name, `/\`) {
+		return "", fmt.Errorf("invalid sample name %q", s.Name)
+	}
+	agentType := s.Agent
+	if agentType == "" {
+		agentType = AgentTypeUnknown
+	}
+	labelDir := filepath.Join(dir, string(s.Label))
+	if err := os.MkdirAll(labelDir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(labelDir, string(agentType)+"_"+s.Name+".txt")
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return "", err
+	}
+	text := stripANSICodes(s.Text)
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+		return "", err
+	}
+	return path, f.Close()
+}
+
+// LabelScore is one label's results in an accuracy Report.
+type LabelScore struct {
+	Label     StateLabel `json:"label"`
+	Support   int        `json:"support"`   // Samples with this label
+	Predicted int        `json:"predicted"` // Samples predicted as this label
+	Correct   int        `json:"correct"`
+	Precision float64    `json:"precision"`
+	Recall    float64    `json:"recall"`
+}
+
+// Report scores a detector's predictions against corpus labels.
+type Report struct {
+	Detector string       `json:"detector"`
+	Total    int          `json:"total"`
+	Correct  int          `json:"correct"`
+	Accuracy float64      `json:"accuracy"`
+	Labels   []LabelScore `json:"labels"`
+	Misses   []Miss       `json:"misses,omitempty"`
+}
+
+// Miss is a sample a detector got wrong.
+type Miss struct {
+	Path      string     `json:"path"`
+	Label     StateLabel `json:"label"`
+	Predicted StateLabel `json:"predicted"` // Empty when the detector gave no answer
+}
+
+// Score runs predict over samples and reports accuracy overall and per label.
+func Score(detector string, samples []Sample, predict func(Sample) StateLabel) Report {
+	got := make([]StateLabel, len(samples))
+	for i, s := range samples {
+		got[i] = predict(s)
+	}
+	return scorePredictions(detector, samples, got)
+}
+
+// scorePredictions reports how predictions, got[i] for samples[i], match
+// the corpus labels.
+func scorePredictions(detector string, samples []Sample, got []StateLabel) Report {
+	r := Report{Detector: detector, Total: len(samples)}
+	scores := make(map[StateLabel]*LabelScore)
+	score := func(l StateLabel) *LabelScore {
+		if scores[l] == nil {
+			scores[l] = &LabelScore{Label: l}
+		}
+		return scores[l]
+	}
+	for i, s := range samples {
+		score(s.Label).Support++
+		if got[i] != "" {
+			score(got[i]).Predicted++
+		}
+		if got[i] == s.Label {
+			r.Correct++
+			score(s.Label).Correct++
+			continue
+		}
+		r.Misses = append(r.Misses, Miss{Path: s.Path, Label: s.Label, Predicted: got[i]})
+	}
+	if r.Total > 0 {
+		r.Accuracy = float64(r.Correct) / float64(r.Total)
+	}
+	for _, ls := range scores {
+		if ls.Predicted > 0 {
+			ls.Precision = float64(ls.Correct) / float64(ls.Predicted)
+		}
+		if ls.Support > 0 {
+			ls.Recall = float64(ls.Correct) / float64(ls.Support)
+		}
+		r.Labels = append(r.Labels, *ls)
+	}
+	order := make(map[StateLabel]int, len(StateLabels))
+	for i, l := range StateLabels {
+		order[l] = i
+	}
+	sort.Slice(r.Labels, func(i, j int) bool { return order[r.Labels[i].Label] < order[r.Labels[j].Label] })
+	return r
+}
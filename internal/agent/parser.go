@@ -52,7 +52,12 @@ func (p *parserImpl) ParseWithHint(output string, hint AgentType) (*AgentState,
 	// Step 3: Detect state flags
 	p.detectStateFlags(cleanOutput, state)
 
-	// Step 4: Calculate confidence
+	// Step 4: Calculate confidence, consulting the classifier if configured
+	if c := p.config.Classifier; c != nil {
+		pred := c.Predict(cleanOutput)
+		state.Classified = pred.Label
+		state.ClassifiedConfidence = pred.Confidence
+	}
 	state.Confidence = p.calculateConfidence(state)
 
 	// Step 5: Keep sample for debugging (last N chars)
@@ -185,6 +190,14 @@ func (p *parserImpl) extractMetrics(output string, state *AgentState) {
 
 // detectStateFlags sets qualitative state flags based on output patterns.
 func (p *parserImpl) detectStateFlags(output string, state *AgentState) {
+	// A permission prompt at the bottom of the pane blocks the agent until it
+	// is answered, whatever the scrollback above it says.
+	if req := DetectPermissionPrompt(output, state.Type); req != nil {
+		state.IsAwaitingPermission = true
+		state.Permission = req
+		return
+	}
+
 	// Rate limit detection (highest priority - agent is blocked)
 	state.IsRateLimited = p.detectRateLimit(output, state.Type)
 	if state.IsRateLimited {
@@ -419,6 +432,11 @@ func (p *parserImpl) calculateConfidence(state *AgentState) float64 {
 		confidence += 0.2
 	}
 
+	// Boost for a parsed permission prompt (requires question and options)
+	if state.IsAwaitingPermission {
+		confidence += 0.3
+	}
+
 	// Penalty for unknown agent type
 	if state.Type == AgentTypeUnknown {
 		confidence -= 0.3
@@ -437,6 +455,19 @@ func (p *parserImpl) calculateConfidence(state *AgentState) float64 {
 		confidence = 0.0
 	}
 
+	// Blend in the classifier: agreement moves confidence toward 1 and
+	// disagreement toward 0, in proportion to how sure the classifier is.
+	if w := p.config.ClassifierWeight; w > 0 && state.Classified != "" {
+		if label := state.RegexLabel(); label != "" {
+			nudge := w * state.ClassifiedConfidence
+			if state.Classified == label {
+				confidence += nudge * (1 - confidence)
+			} else {
+				confidence -= nudge * confidence
+			}
+		}
+	}
+
 	return confidence
 }
 
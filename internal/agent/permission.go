@@ -0,0 +1,291 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+// PermissionKind classifies what an agent is asking permission for.
+type PermissionKind string
+
+const (
+	PermissionCommand PermissionKind = "command" // run a shell command
+	PermissionEdit    PermissionKind = "edit"    // create or modify a file
+	PermissionFetch   PermissionKind = "fetch"   // fetch a URL
+	PermissionTool    PermissionKind = "tool"    // any other tool call
+)
+
+// PermissionRequest is a tool-permission prompt an agent is blocked on, such
+// as Claude Code's "Do you want to proceed?" or Codex's "Allow command?".
+type PermissionRequest struct {
+	// ID identifies this prompt: it is derived from its content, so the same
+	// prompt re-read from the pane keeps its ID and a new prompt gets another.
+	ID       string         `json:"id"`
+	Agent    AgentType      `json:"agent_type"`
+	Kind     PermissionKind `json:"kind"`
+	Tool     string         `json:"tool,omitempty"` // e.g. Bash, Update, Shell
+	Command  string         `json:"command,omitempty"`
+	File     string         `json:"file,omitempty"`
+	URL      string         `json:"url,omitempty"`
+	Question string         `json:"question"`
+}
+
+// Target returns what the request is about: the command, file or URL.
+func (r *PermissionRequest) Target() string {
+	switch {
+	case r.Command != "":
+		return r.Command
+	case r.File != "":
+		return r.File
+	case r.URL != "":
+		return r.URL
+	}
+	return r.Tool
+}
+
+// PolicySubject returns the string checked against policy rules. Commands
+// are checked as-is, so existing command rules apply; other requests are
+// prefixed with their kind, e.g. "edit internal/db/schema.go" or
+// "fetch https://example.com", so rules can target them explicitly.
+func (r *PermissionRequest) PolicySubject() string {
+	if r.Kind == PermissionCommand {
+		return r.Command
+	}
+	return strings.TrimSpace(string(r.Kind) + " " + r.Target())
+}
+
+// PermissionKey returns the tmux key that answers a permission prompt from
+// agent type t. Approving picks the prompt's "yes, once" option; denying
+// sends Escape, which every supported agent treats as "no".
+func PermissionKey(t AgentType, approve bool) string {
+	if !approve {
+		return "Escape"
+	}
+	if t == AgentTypeCodex {
+		return "y"
+	}
+	return "1"
+}
+
+const (
+	// permissionScanLines bounds how far up the prompt's details are looked
+	// for; Claude Code's edit prompts include a diff.
+	permissionScanLines = 80
+	// permissionTailLines is how close to the bottom of the pane the prompt's
+	// options must be for it to still be waiting.
+	permissionTailLines = 8
+)
+
+var (
+	ccPermissionDeny     = regexp.MustCompile(`No, and tell Claude what to do differently`)
+	ccPermissionQuestion = regexp.MustCompile(`^Do you want to .+\?$`)
+	ccPermissionEditTo   = regexp.MustCompile(`^Do you want to (?:make this edit to|create) (.+)\?$`)
+	ccToolCall           = regexp.MustCompile(`^⏺\s*([A-Za-z]+)\((.*)\)$`)
+
+	codPermissionDeny     = regexp.MustCompile(`No, (?:provide feedback|and tell Codex what to do differently)`)
+	codPermissionQuestion = regexp.MustCompile(`^(?:Allow command\?|Would you like to run the following command\?|Allow Codex to apply proposed code changes\?|Would you like to make the following edits\?)`)
+	codPatchFile          = regexp.MustCompile(`patch to (\S+)`)
+
+	gmiPermissionDeny     = regexp.MustCompile(`No, suggest changes`)
+	gmiPermissionQuestion = regexp.MustCompile(`^(?:Allow execution of.*\?|Apply this change\?|Do you want to proceed\?)$`)
+	gmiToolHeader         = regexp.MustCompile(`^\?\s+(\S+)\s+(.+)$`)
+)
+
+// DetectPermissionPrompt reports the permission prompt waiting at the bottom
+// of output, or nil when there is none. hint selects the agent's prompt
+// format; with AgentTypeUnknown every known format is tried.
+func DetectPermissionPrompt(output string, hint AgentType) *PermissionRequest {
+	lines := permissionLines(stripANSICodes(output))
+	var req *PermissionRequest
+	switch hint {
+	case AgentTypeClaudeCode:
+		req = detectClaudePermission(lines)
+	case AgentTypeCodex:
+		req = detectCodexPermission(lines)
+	case AgentTypeGemini:
+		req = detectGeminiPermission(lines)
+	default:
+		if req = detectClaudePermission(lines); req == nil {
+			if req = detectCodexPermission(lines); req == nil {
+				req = detectGeminiPermission(lines)
+			}
+		}
+	}
+	if req != nil {
+		req.ID = permissionID(req)
+	}
+	return req
+}
+
+// permissionLines returns the last non-empty lines of output with the
+// borders of (possibly nested) boxes trimmed.
+func permissionLines(output string) []string {
+	var lines []string
+	for _, l := range strings.Split(output, "\n") {
+		l = strings.Trim(l, " \t\r│┃▌╭╮╰╯─")
+		if l != "" {
+			lines = append(lines, l)
+		}
+	}
+	if len(lines) > permissionScanLines {
+		lines = lines[len(lines)-permissionScanLines:]
+	}
+	return lines
+}
+
+// findDeny returns the index of the prompt's "no" option when it is among
+// the last lines, or -1.
+func findDeny(lines []string, deny *regexp.Regexp) int {
+	for i := len(lines) - 1; i >= 0 && i >= len(lines)-permissionTailLines; i-- {
+		if deny.MatchString(lines[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// findBefore returns the index of the last line before end matching re,
+// looking back at most limit lines, or -1.
+func findBefore(lines []string, end, limit int, re *regexp.Regexp) int {
+	for i := end - 1; i >= 0 && i >= end-limit; i-- {
+		if re.MatchString(lines[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+func detectClaudePermission(lines []string) *PermissionRequest {
+	deny := findDeny(lines, ccPermissionDeny)
+	if deny < 0 {
+		return nil
+	}
+	q := findBefore(lines, deny, 6, ccPermissionQuestion)
+	if q < 0 {
+		return nil
+	}
+	req := &PermissionRequest{Agent: AgentTypeClaudeCode, Kind: PermissionTool, Question: lines[q]}
+
+	// The box title names the tool; a Bash box shows the command under it.
+	for i := q - 1; i >= 0 && i >= q-permissionScanLines; i-- {
+		switch lines[i] {
+		case "Bash command":
+			// The command, which may span several lines, is followed by a
+			// one-line description when there is one.
+			req.Kind, req.Tool = PermissionCommand, "Bash"
+			end := q
+			if q-i > 2 {
+				end--
+			}
+			req.Command = strings.Join(lines[i+1:end], "\n")
+		case "Edit file", "Create file":
+			req.Kind = PermissionEdit
+		case "Fetch":
+			req.Kind, req.Tool = PermissionFetch, "Fetch"
+		default:
+			continue
+		}
+		break
+	}
+
+	// The transcript line above the box names the call, e.g. Update(path).
+	if i := findBefore(lines, q, permissionScanLines, ccToolCall); i >= 0 {
+		m := ccToolCall.FindStringSubmatch(lines[i])
+		req.Tool = m[1]
+		switch m[1] {
+		case "Bash":
+			req.Kind = PermissionCommand
+			if req.Command == "" {
+				req.Command = m[2]
+			}
+		case "Update", "Edit", "MultiEdit", "Write", "NotebookEdit":
+			req.Kind, req.File = PermissionEdit, m[2]
+		case "Fetch", "WebFetch":
+			req.Kind, req.URL = PermissionFetch, m[2]
+		}
+	}
+	if req.File == "" {
+		if m := ccPermissionEditTo.FindStringSubmatch(req.Question); m != nil {
+			req.Kind, req.File = PermissionEdit, m[1]
+		}
+	}
+	return req
+}
+
+func detectCodexPermission(lines []string) *PermissionRequest {
+	deny := findDeny(lines, codPermissionDeny)
+	if deny < 0 {
+		return nil
+	}
+	q := findBefore(lines, deny, 8, codPermissionQuestion)
+	if q < 0 {
+		return nil
+	}
+	req := &PermissionRequest{Agent: AgentTypeCodex, Kind: PermissionEdit, Tool: "apply_patch", Question: lines[q]}
+	if strings.Contains(req.Question, "command") {
+		req.Kind, req.Tool = PermissionCommand, "shell"
+		// Keep every line of the command up to the options, so a command
+		// continued on later lines is checked as a whole.
+		for i, l := range lines[q+1 : deny] {
+			if strings.HasPrefix(l, "$ ") {
+				cmd := append([]string{strings.TrimPrefix(l, "$ ")}, lines[q+2+i:deny]...)
+				req.Command = strings.TrimSpace(strings.Join(cmd, "\n"))
+				break
+			}
+		}
+		return req
+	}
+	for i := q - 1; i >= 0 && i >= q-permissionScanLines; i-- {
+		if m := codPatchFile.FindStringSubmatch(lines[i]); m != nil {
+			req.File = m[1]
+			break
+		}
+	}
+	return req
+}
+
+func detectGeminiPermission(lines []string) *PermissionRequest {
+	deny := findDeny(lines, gmiPermissionDeny)
+	if deny < 0 {
+		return nil
+	}
+	q := findBefore(lines, deny, 10, gmiPermissionQuestion)
+	if q < 0 {
+		return nil
+	}
+	req := &PermissionRequest{Agent: AgentTypeGemini, Kind: PermissionTool, Question: lines[q]}
+	h := findBefore(lines, q, permissionScanLines, gmiToolHeader)
+	if h < 0 {
+		return req
+	}
+	m := gmiToolHeader.FindStringSubmatch(lines[h])
+	req.Tool = m[1]
+	switch m[1] {
+	case "Shell":
+		req.Kind = PermissionCommand
+		// The header may append the directory or a description.
+		cmd := m[2]
+		if i := strings.Index(cmd, " ["); i > 0 {
+			cmd = cmd[:i]
+		}
+		req.Command = strings.TrimSpace(cmd)
+	case "Edit", "WriteFile", "Write":
+		req.Kind = PermissionEdit
+		file, _, _ := strings.Cut(m[2], ":")
+		req.File = strings.TrimSpace(file)
+	case "WebFetch":
+		req.Kind, req.URL = PermissionFetch, strings.TrimSpace(m[2])
+	}
+	return req
+}
+
+func permissionID(r *PermissionRequest) string {
+	h := sha256.New()
+	for _, s := range []string{string(r.Agent), string(r.Kind), r.Tool, r.Command, r.File, r.URL, r.Question} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
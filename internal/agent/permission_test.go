@@ -0,0 +1,160 @@
+package agent
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectPermissionPrompt_Corpus(t *testing.T) {
+	samples, err := LoadCorpus(filepath.Join("testdata", "corpus"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]PermissionRequest{
+		"cc_bash":         {Kind: PermissionCommand, Tool: "Bash", Command: "rm -rf ./build && make release"},
+		"cc_bash-tests":   {Kind: PermissionCommand, Tool: "Bash", Command: "docker compose up -d postgres"},
+		"cc_edit":         {Kind: PermissionEdit, Tool: "Update", File: "internal/handler/users.go"},
+		"cc_write":        {Kind: PermissionEdit, Tool: "Write", File: "internal/webhook/verify.go"},
+		"cc_fetch":        {Kind: PermissionFetch, Tool: "Fetch", URL: "https://pkg.go.dev/net/http"},
+		"cc_web-fetch":    {Kind: PermissionFetch, Tool: "Fetch", URL: "https://docs.stripe.com/webhooks/signatures"},
+		"cod_command":     {Kind: PermissionCommand, Tool: "shell", Command: "make migrate-up"},
+		"cod_git":         {Kind: PermissionCommand, Tool: "shell", Command: "git rebase origin/main"},
+		"cod_network":     {Kind: PermissionCommand, Tool: "shell", Command: "go get github.com/redis/go-redis/v9"},
+		"cod_patch":       {Kind: PermissionEdit, Tool: "apply_patch", File: "internal/billing/invoice.go"},
+		"cod_patch-tests": {Kind: PermissionEdit, Tool: "apply_patch", File: "internal/api/middleware_test.go"},
+		"gmi_edit":        {Kind: PermissionEdit, Tool: "Edit", File: "src/products/repository.py"},
+		"gmi_write":       {Kind: PermissionEdit, Tool: "WriteFile", File: "src/emails/welcome.py"},
+		"gmi_shell":       {Kind: PermissionCommand, Tool: "Shell", Command: "npm install --save-dev vitest"},
+		"gmi_pytest":      {Kind: PermissionCommand, Tool: "Shell", Command: "pytest -x tests/test_signup.py"},
+	}
+
+	for _, s := range samples {
+		key := string(s.Agent) + "_" + s.Name
+		for _, hint := range []AgentType{s.Agent, AgentTypeUnknown} {
+			got := DetectPermissionPrompt(s.Text, hint)
+			if s.Label != LabelAwaitingPermission {
+				if got != nil {
+					t.Errorf("%s/%s (hint %s): unexpected prompt %+v", s.Label, key, hint, got)
+				}
+				continue
+			}
+			w, ok := want[key]
+			if !ok {
+				t.Fatalf("no expectation for %s", key)
+			}
+			if got == nil {
+				t.Errorf("%s (hint %s): prompt not detected", key, hint)
+				continue
+			}
+			if got.Agent != s.Agent || got.Kind != w.Kind || got.Tool != w.Tool ||
+				got.Command != w.Command || got.File != w.File || got.URL != w.URL ||
+				got.Question == "" || len(got.ID) != 12 {
+				t.Errorf("%s (hint %s) = %+v, want %+v", key, hint, got, w)
+			}
+		}
+	}
+}
+
+func TestDetectPermissionPrompt_AnsweredPromptIgnored(t *testing.T) {
+	prompt := `▌ Allow command?
+▌
+▌ $ make migrate-up
+▌
+▌ › Yes   Always   No, provide feedback
+▌
+▌ Press Enter to confirm or Esc to cancel`
+	if DetectPermissionPrompt(prompt, AgentTypeCodex) == nil {
+		t.Fatal("live prompt not detected")
+	}
+
+	// Once answered, the agent keeps going and the prompt scrolls up.
+	answered := prompt + `
+• Ran make migrate-up
+  └ applied 3 migrations
+• Migrations are up to date.
+• Next I will update the repository layer.
+• Reading internal/db/repo.go
+• Editing internal/db/repo.go
+• Running go test ./internal/db/...
+• Working (12s • esc to interrupt)`
+	if got := DetectPermissionPrompt(answered, AgentTypeCodex); got != nil {
+		t.Errorf("answered prompt detected: %+v", got)
+	}
+}
+
+func TestDetectPermissionPrompt_MultiLineCommand(t *testing.T) {
+	claude := `╭──────────────────────────────────────────╮
+│ Bash command                             │
+│                                          │
+│   git status                             │
+│   && rm -rf ~                            │
+│   Show the working tree status           │
+│                                          │
+│ Do you want to proceed?                  │
+│ ❯ 1. Yes                                 │
+│   3. No, and tell Claude what to do differently (esc) │
+╰──────────────────────────────────────────╯`
+	codex := `▌ Allow command?
+▌
+▌ $ git status
+▌ && rm -rf ~
+▌
+▌ › Yes   Always   No, provide feedback`
+	for _, tt := range []struct {
+		output string
+		hint   AgentType
+	}{{claude, AgentTypeClaudeCode}, {codex, AgentTypeCodex}} {
+		got := DetectPermissionPrompt(tt.output, tt.hint)
+		if got == nil || got.Command != "git status\n&& rm -rf ~" {
+			t.Errorf("%s: prompt = %+v", tt.hint, got)
+		}
+	}
+}
+
+func TestPermissionRequest_IDAndSubject(t *testing.T) {
+	a := &PermissionRequest{Agent: AgentTypeClaudeCode, Kind: PermissionCommand, Command: "make test", Question: "Do you want to proceed?"}
+	b := *a
+	b.Command = "make lint"
+	if permissionID(a) != permissionID(a) || permissionID(a) == permissionID(&b) {
+		t.Error("permission IDs are not content-derived")
+	}
+	if got := a.PolicySubject(); got != "make test" {
+		t.Errorf("command subject = %q", got)
+	}
+	edit := &PermissionRequest{Kind: PermissionEdit, File: "go.mod"}
+	if got := edit.PolicySubject(); got != "edit go.mod" {
+		t.Errorf("edit subject = %q", got)
+	}
+	if PermissionKey(AgentTypeCodex, true) != "y" || PermissionKey(AgentTypeGemini, true) != "1" || PermissionKey(AgentTypeClaudeCode, false) != "Escape" {
+		t.Error("unexpected permission keys")
+	}
+}
+
+func TestParser_PermissionPromptState(t *testing.T) {
+	out := `⏺ Bash(go test ./...)
+
+╭────────────────────────────────────────────╮
+│ Bash command                               │
+│                                            │
+│   go test ./...                            │
+│                                            │
+│ Do you want to proceed?                    │
+│ ❯ 1. Yes                                   │
+│   2. Yes, and don't ask again for go test  │
+│   3. No, and tell Claude what to do differently (esc) │
+╰────────────────────────────────────────────╯`
+	state, err := NewParser().ParseWithHint(out, AgentTypeClaudeCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.IsAwaitingPermission || state.Permission == nil || state.Permission.Command != "go test ./..." {
+		t.Fatalf("state = %+v", state)
+	}
+	if state.IsWorking || state.IsIdle {
+		t.Errorf("working=%v idle=%v while awaiting permission", state.IsWorking, state.IsIdle)
+	}
+	if state.GetRecommendation() != RecommendAwaitingPermission || state.RegexLabel() != LabelAwaitingPermission {
+		t.Errorf("recommendation %s, label %s", state.GetRecommendation(), state.RegexLabel())
+	}
+}
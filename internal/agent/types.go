@@ -112,6 +112,10 @@ type AgentState struct {
 	IsIdle        bool `json:"is_idle"`      // Waiting for user input (safe to restart)
 	IsInError     bool `json:"is_in_error"`  // Error state detected
 
+	// Tool-permission prompt the agent is blocked on, if any
+	IsAwaitingPermission bool               `json:"awaiting_permission,omitempty"`
+	Permission           *PermissionRequest `json:"permission,omitempty"`
+
 	// Evidence for debugging and confidence calculation
 	WorkIndicators  []string `json:"work_indicators,omitempty"`  // Patterns that indicate working
 	LimitIndicators []string `json:"limit_indicators,omitempty"` // Patterns that indicate rate limiting
@@ -120,6 +124,30 @@ type AgentState struct {
 	// Confidence in this assessment (0.0-1.0)
 	// Higher confidence means more pattern matches or explicit indicators
 	Confidence float64 `json:"confidence"`
+
+	// Learned classifier's reading of the same output, when a model is
+	// configured. It only adjusts Confidence; the flags above stay regex-based.
+	Classified           StateLabel `json:"classified_state,omitempty"`
+	ClassifiedConfidence float64    `json:"classified_confidence,omitempty"`
+}
+
+// RegexLabel maps the regex-detected flags to a classifier label, or ""
+// when none is set. Permission prompts, rate limits and errors outrank
+// working and idle.
+func (s *AgentState) RegexLabel() StateLabel {
+	switch {
+	case s.IsAwaitingPermission:
+		return LabelAwaitingPermission
+	case s.IsRateLimited:
+		return LabelRateLimited
+	case s.IsInError:
+		return LabelError
+	case s.IsWorking:
+		return LabelWorking
+	case s.IsIdle:
+		return LabelIdle
+	}
+	return ""
 }
 
 // Recommendation represents the recommended action based on agent state.
@@ -144,29 +172,39 @@ const (
 	// RecommendErrorState means the agent is in an error state and may need intervention.
 	RecommendErrorState Recommendation = "ERROR_STATE"
 
+	// RecommendAwaitingPermission means the agent is blocked on a tool-permission
+	// prompt that someone (or the policy) must answer.
+	RecommendAwaitingPermission Recommendation = "AWAITING_PERMISSION"
+
 	// RecommendUnknown means we couldn't determine the agent state with confidence.
 	RecommendUnknown Recommendation = "UNKNOWN"
 )
 
 // GetRecommendation derives the recommended action from the current state.
 // Priority order is carefully chosen:
-//  1. Rate limited -> wait (highest priority, nothing we can do)
-//  2. Error state -> handle error
-//  3. Working -> DO NOT INTERRUPT (critical user requirement)
-//  4. Idle -> safe to restart
-//  5. Unknown -> be cautious
+//  1. Awaiting permission -> answer the prompt (the agent is blocked on it)
+//  2. Rate limited -> wait (nothing we can do)
+//  3. Error state -> handle error
+//  4. Working -> DO NOT INTERRUPT (critical user requirement)
+//  5. Idle -> safe to restart
+//  6. Unknown -> be cautious
 func (s *AgentState) GetRecommendation() Recommendation {
-	// Priority 1: Rate limited - must wait, nothing else matters
+	// Priority 1: Permission prompt - nothing happens until it is answered
+	if s.IsAwaitingPermission {
+		return RecommendAwaitingPermission
+	}
+
+	// Priority 2: Rate limited - must wait
 	if s.IsRateLimited {
 		return RecommendRateLimitedWait
 	}
 
-	// Priority 2: Error state - needs attention
+	// Priority 3: Error state - needs attention
 	if s.IsInError {
 		return RecommendErrorState
 	}
 
-	// Priority 3: Working - NEVER interrupt useful work
+	// Priority 4: Working - NEVER interrupt useful work
 	if s.IsWorking {
 		if s.IsContextLow {
 			// Working but running low - let finish, then restart
@@ -175,7 +213,7 @@ func (s *AgentState) GetRecommendation() Recommendation {
 		return RecommendDoNotInterrupt
 	}
 
-	// Priority 4: Idle - safe to take action
+	// Priority 5: Idle - safe to take action
 	if s.IsIdle {
 		return RecommendSafeToRestart
 	}
@@ -237,6 +275,16 @@ type ParserConfig struct {
 	// SampleLength is the number of characters to keep in RawSample for debugging.
 	// Default: 500
 	SampleLength int
+
+	// Classifier, when set, is consulted alongside the regexes; agreement
+	// raises confidence and disagreement lowers it.
+	// Default: the model embedded in ntm
+	Classifier *Classifier
+
+	// ClassifierWeight is the share of the final confidence taken from the
+	// classifier's agreement with the regex reading (0 disables blending).
+	// Default: 0.25
+	ClassifierWeight float64
 }
 
 // DefaultParserConfig returns the default parser configuration.
@@ -244,5 +292,7 @@ func DefaultParserConfig() ParserConfig {
 	return ParserConfig{
 		ContextLowThreshold: 20.0,
 		SampleLength:        500,
+		Classifier:          DefaultClassifier(),
+		ClassifierWeight:    0.25,
 	}
 }
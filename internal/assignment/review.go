@@ -0,0 +1,140 @@
+package assignment
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// ReviewStatus is the state of one review round
+type ReviewStatus string
+
+const (
+	ReviewPending          ReviewStatus = "pending"           // Sent to the reviewer, no answer yet
+	ReviewApproved         ReviewStatus = "approved"          // Reviewer approved the work
+	ReviewChangesRequested ReviewStatus = "changes_requested" // Sent back to the author
+	ReviewExpired          ReviewStatus = "expired"           // Reviewer did not answer in time
+)
+
+// ReviewComment is one structured comment from a reviewer.
+type ReviewComment struct {
+	File       string `json:"file"`
+	Line       int    `json:"line,omitempty"`
+	Severity   string `json:"severity"` // blocker, major, minor, nit
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// Review records one round of cross-agent review of an assignment's work.
+type Review struct {
+	ID           string          `json:"id"`
+	Round        int             `json:"round"`
+	ReviewerPane int             `json:"reviewer_pane"`
+	ReviewerType string          `json:"reviewer_type"`
+	Worktree     string          `json:"worktree,omitempty"` // Author's worktree agent name, e.g. cc_1
+	WorkDir      string          `json:"work_dir,omitempty"`
+	Base         string          `json:"base"`           // Commit the diff is taken against
+	Head         string          `json:"head,omitempty"` // Author's HEAD when the diff was packaged
+	Files        []string        `json:"files,omitempty"`
+	PatchPath    string          `json:"patch_path"`
+	ResultPath   string          `json:"result_path"`
+	Status       ReviewStatus    `json:"status"`
+	Summary      string          `json:"summary,omitempty"`
+	Comments     []ReviewComment `json:"comments,omitempty"`
+	RequestedAt  time.Time       `json:"requested_at"`
+	CompletedAt  *time.Time      `json:"completed_at,omitempty"`
+}
+
+// LatestReview returns the most recent review round, or nil.
+func (a *Assignment) LatestReview() *Review {
+	if len(a.Reviews) == 0 {
+		return nil
+	}
+	return &a.Reviews[len(a.Reviews)-1]
+}
+
+// ReviewState returns the status of the latest review round, or "" when the
+// work has not been sent for review.
+func (a *Assignment) ReviewState() ReviewStatus {
+	if r := a.LatestReview(); r != nil {
+		return r.Status
+	}
+	return ""
+}
+
+// StartReview opens a new review round on a working or completed
+// assignment. Round and RequestedAt are filled in; any pending round is
+// expired.
+func (s *AssignmentStore) StartReview(beadID string, r Review) (*Review, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	assignment, ok := s.Assignments[beadID]
+	if !ok {
+		return nil, fmt.Errorf("[ASSIGN] Assignment not found: %s", beadID)
+	}
+	if assignment.Status != StatusWorking && assignment.Status != StatusCompleted {
+		return nil, fmt.Errorf("[ASSIGN] Cannot review %s in status %s", beadID, assignment.Status)
+	}
+
+	now := time.Now().UTC()
+	if prev := assignment.LatestReview(); prev != nil && prev.Status == ReviewPending {
+		prev.Status = ReviewExpired
+		prev.CompletedAt = &now
+	}
+	r.Round = len(assignment.Reviews) + 1
+	r.Status = ReviewPending
+	if r.RequestedAt.IsZero() {
+		r.RequestedAt = now
+	}
+	assignment.Reviews = append(assignment.Reviews, r)
+
+	if err := s.saveLocked(); err != nil {
+		slog.Warn("failed to persist assignment store", "error", err)
+	}
+	return assignment.LatestReview(), nil
+}
+
+// FinishReview closes the pending review round reviewID with the
+// reviewer's verdict. Requested changes re-open a completed assignment so
+// it stays with its author until a later round approves it.
+func (s *AssignmentStore) FinishReview(beadID, reviewID string, status ReviewStatus, summary string, comments []ReviewComment) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	assignment, ok := s.Assignments[beadID]
+	if !ok {
+		return fmt.Errorf("[ASSIGN] Assignment not found: %s", beadID)
+	}
+	r := assignment.LatestReview()
+	if r == nil || r.ID != reviewID {
+		return fmt.Errorf("[ASSIGN] Review %s is not the latest for %s", reviewID, beadID)
+	}
+	if r.Status != ReviewPending {
+		return fmt.Errorf("[ASSIGN] Review %s is already %s", reviewID, r.Status)
+	}
+	if status == ReviewPending {
+		return fmt.Errorf("[ASSIGN] Cannot finish review %s as %s", reviewID, status)
+	}
+
+	now := time.Now().UTC()
+	r.Status = status
+	r.Summary = summary
+	r.Comments = comments
+	r.CompletedAt = &now
+
+	prevStatus := assignment.Status
+	if status == ReviewChangesRequested && prevStatus == StatusCompleted {
+		assignment.Status = StatusWorking
+		assignment.StartedAt = &now
+		assignment.CompletedAt = nil
+	}
+
+	if err := s.saveLocked(); err != nil {
+		slog.Warn("failed to persist assignment store", "error", err)
+	}
+	if assignment.Status != prevStatus {
+		emitAssignmentStatusEvent(s.SessionName, assignment, assignment.Status, "")
+	}
+	return nil
+}
@@ -48,6 +48,31 @@ type Assignment struct {
 	FailureReason string           `json:"failure_reason,omitempty"` // Detailed failure reason
 	RetryCount    int              `json:"retry_count,omitempty"`    // Number of retry attempts
 	PromptSent    string           `json:"prompt_sent,omitempty"`    // The actual prompt sent
+	Verifications []Verification   `json:"verifications,omitempty"`  // Verification gate runs, oldest first
+	Reviews       []Review         `json:"reviews,omitempty"`        // Cross-agent review rounds, oldest first
+}
+
+// Verification records one run of a verification command against an
+// assignment's work before it is accepted as complete.
+type Verification struct {
+	Command    string    `json:"command"`
+	WorkDir    string    `json:"work_dir,omitempty"`
+	ExitCode   int       `json:"exit_code"`
+	Passed     bool      `json:"passed"`
+	Duration   int64     `json:"duration_ms"`
+	LogExcerpt string    `json:"log_excerpt,omitempty"`
+	RanAt      time.Time `json:"ran_at"`
+}
+
+// FailedVerifications returns how many verification runs have failed.
+func (a *Assignment) FailedVerifications() int {
+	n := 0
+	for _, v := range a.Verifications {
+		if !v.Passed {
+			n++
+		}
+	}
+	return n
 }
 
 // AssignmentStore manages bead-to-agent assignments for a session
@@ -449,6 +474,47 @@ func (s *AssignmentStore) MarkFailed(beadID, reason string) error {
 	return nil
 }
 
+// RecordVerification appends verification evidence to an active assignment.
+// A failed verification re-opens the assignment: an assignment still in
+// StatusAssigned moves to StatusWorking so it stays with the same agent
+// until its work passes.
+func (s *AssignmentStore) RecordVerification(beadID string, runs ...Verification) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	assignment, ok := s.Assignments[beadID]
+	if !ok {
+		return fmt.Errorf("[ASSIGN] Assignment not found: %s", beadID)
+	}
+	if assignment.Status != StatusAssigned && assignment.Status != StatusWorking {
+		return fmt.Errorf("[ASSIGN] Cannot record verification for %s in status %s", beadID, assignment.Status)
+	}
+
+	passed := true
+	for _, v := range runs {
+		if v.RanAt.IsZero() {
+			v.RanAt = time.Now().UTC()
+		}
+		assignment.Verifications = append(assignment.Verifications, v)
+		passed = passed && v.Passed
+	}
+
+	prevStatus := assignment.Status
+	if !passed && prevStatus == StatusAssigned {
+		now := time.Now().UTC()
+		assignment.Status = StatusWorking
+		assignment.StartedAt = &now
+	}
+
+	if err := s.saveLocked(); err != nil {
+		slog.Warn("failed to persist assignment store", "error", err)
+	}
+	if assignment.Status != prevStatus {
+		emitAssignmentStatusEvent(s.SessionName, assignment, assignment.Status, "")
+	}
+	return nil
+}
+
 // Reassign moves an assignment to a different agent
 func (s *AssignmentStore) Reassign(beadID string, newPane int, newAgentType, newAgentName string) (*Assignment, error) {
 	s.mutex.Lock()
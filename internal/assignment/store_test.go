@@ -589,3 +589,94 @@ func TestPersistenceErrorTypes(t *testing.T) {
 		t.Error("expected non-empty error string")
 	}
 }
+
+func TestRecordVerification(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	store := NewStore("test-session")
+	if _, err := store.Assign("bd-1", "Title", 1, "claude", "", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	err := store.RecordVerification("bd-1", Verification{Command: "go test ./...", ExitCode: 1})
+	if err != nil {
+		t.Fatalf("RecordVerification() error: %v", err)
+	}
+	a := store.Get("bd-1")
+	if a.Status != StatusWorking {
+		t.Errorf("status = %s, want working after failed verification", a.Status)
+	}
+	if a.FailedVerifications() != 1 || a.Verifications[0].RanAt.IsZero() {
+		t.Errorf("verifications = %+v", a.Verifications)
+	}
+
+	if err := store.RecordVerification("bd-1", Verification{Command: "go test ./...", Passed: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.MarkCompleted("bd-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.RecordVerification("bd-1", Verification{Command: "late"}); err == nil {
+		t.Error("expected error recording verification on completed assignment")
+	}
+	if err := store.RecordVerification("missing", Verification{}); err == nil {
+		t.Error("expected error for unknown bead")
+	}
+}
+
+func TestReviewRounds(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	store := NewStore("test-session")
+	if _, err := store.Assign("bd-1", "Title", 1, "claude", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.StartReview("bd-1", Review{ID: "bd-1-r1"}); err == nil {
+		t.Error("expected error reviewing an assignment that has not started")
+	}
+	if err := store.MarkWorking("bd-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.MarkCompleted("bd-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := store.StartReview("bd-1", Review{ID: "bd-1-r1", ReviewerType: "codex"})
+	if err != nil {
+		t.Fatalf("StartReview() error: %v", err)
+	}
+	if r.Round != 1 || r.Status != ReviewPending || r.RequestedAt.IsZero() {
+		t.Errorf("review = %+v", r)
+	}
+
+	comments := []ReviewComment{{File: "main.go", Line: 3, Severity: "blocker", Message: "nil deref"}}
+	if err := store.FinishReview("bd-1", "bd-1-r1", ReviewChangesRequested, "needs work", comments); err != nil {
+		t.Fatalf("FinishReview() error: %v", err)
+	}
+	a := store.Get("bd-1")
+	if a.Status != StatusWorking || a.CompletedAt != nil {
+		t.Errorf("status = %s, want working after requested changes", a.Status)
+	}
+	if a.ReviewState() != ReviewChangesRequested || len(a.LatestReview().Comments) != 1 {
+		t.Errorf("latest review = %+v", a.LatestReview())
+	}
+	if err := store.FinishReview("bd-1", "bd-1-r1", ReviewApproved, "", nil); err == nil {
+		t.Error("expected error finishing a closed review")
+	}
+
+	if err := store.MarkCompleted("bd-1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.StartReview("bd-1", Review{ID: "bd-1-r2"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.FinishReview("bd-1", "bd-1-r2", ReviewApproved, "lgtm", nil); err != nil {
+		t.Fatal(err)
+	}
+	a = store.Get("bd-1")
+	if a.Status != StatusCompleted || a.ReviewState() != ReviewApproved || a.LatestReview().Round != 2 {
+		t.Errorf("after approval: status %s, review %+v", a.Status, a.LatestReview())
+	}
+}
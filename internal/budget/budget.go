@@ -0,0 +1,121 @@
+// Package budget enforces spend budgets before ntm gives agents more work.
+// Every path that types prompts into agent panes checks it, so a hard budget
+// holds however a prompt is sent.
+package budget
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/approval"
+	"github.com/shahbajlive/ntm/internal/audit"
+	"github.com/shahbajlive/ntm/internal/config"
+	"github.com/shahbajlive/ntm/internal/cost"
+	"github.com/shahbajlive/ntm/internal/state"
+)
+
+const (
+	// ApprovalAction is the approval action filed when a hard budget is
+	// reached with hard_action = "approve".
+	ApprovalAction = "budget_override"
+	// OverrideTTL is how long an approved override, or a denial, stands
+	// before a new request is filed.
+	OverrideTTL = 24 * time.Hour
+)
+
+var (
+	budgetsMu sync.RWMutex
+	budgets   = config.DefaultBudgetsConfig()
+)
+
+// SetConfig installs the budget configuration Enforce checks against.
+func SetConfig(bc config.BudgetsConfig) {
+	budgetsMu.Lock()
+	defer budgetsMu.Unlock()
+	budgets = bc
+}
+
+// Config returns the budget configuration installed with SetConfig.
+func Config() config.BudgetsConfig {
+	budgetsMu.RLock()
+	defer budgetsMu.RUnlock()
+	return budgets
+}
+
+// Enforce checks session's budgets before action spends more. At a hard
+// threshold the action is refused, or with hard_action = "approve" refused
+// until an override is approved with 'ntm approve'. agentTypes limits which
+// agent budgets apply. The budgets over their soft threshold are returned
+// for the caller to warn about.
+//
+// Budgets are best-effort: without a state store nothing is enforced.
+func Enforce(session string, agentTypes []string, action string) ([]cost.BudgetStatus, error) {
+	bc := Config()
+	list := bc.Budgets()
+	if len(list) == 0 {
+		return nil, nil
+	}
+	store, err := state.Open("")
+	if err != nil {
+		return nil, nil
+	}
+	defer store.Close()
+	if err := store.Migrate(); err != nil {
+		return nil, nil
+	}
+
+	statuses, err := cost.Evaluate(list, session, agentTypes, state.NewSpendStore(store).BudgetSpend)
+	if err != nil {
+		return nil, nil
+	}
+	hard := cost.Exceeded(statuses, cost.LevelHard)
+	if len(hard) == 0 {
+		return cost.Exceeded(statuses, cost.LevelSoft), nil
+	}
+
+	payload := map[string]interface{}{"action": action, "budget": hard[0].Name, "used": hard[0].Used, "limit": hard[0].Hard}
+	if bc.HardAction != config.BudgetApprove {
+		_ = audit.LogEvent(session, audit.EventTypeCommand, audit.ActorSystem, "budget.refused", payload, nil)
+		return nil, fmt.Errorf("%s refused: over hard %s; raise the limit under [budgets] in config, or set hard_action = \"approve\"", action, hard[0])
+	}
+	return nil, requireApproval(store, session, action, hard[0], payload)
+}
+
+// requireApproval lets action proceed if an override for session was
+// approved within OverrideTTL, and otherwise files (or points at) an
+// approval request and refuses.
+func requireApproval(store *state.Store, session, action string, over cost.BudgetStatus, payload map[string]interface{}) error {
+	resource := "session:" + session
+	appr, err := store.LatestApproval(ApprovalAction, resource)
+	if err != nil {
+		return err
+	}
+	if appr != nil {
+		switch {
+		case appr.Status == state.ApprovalApproved && appr.ApprovedAt != nil && time.Since(*appr.ApprovedAt) < OverrideTTL:
+			return nil
+		case appr.Status == state.ApprovalPending && time.Now().Before(appr.ExpiresAt):
+			return fmt.Errorf("%s held: over hard %s; waiting for approval: ntm approve %s", action, over, appr.ID)
+		case appr.Status == state.ApprovalDenied && time.Since(appr.CreatedAt) < OverrideTTL:
+			return fmt.Errorf("%s refused: over hard %s; override denied: %s", action, over, appr.DeniedReason)
+		}
+	}
+
+	engine := approval.New(store, nil, nil, approval.DefaultConfig())
+	appr, err = engine.Request(context.Background(), approval.RequestParams{
+		Action:        ApprovalAction,
+		Resource:      resource,
+		Reason:        "over hard " + over.String(),
+		RequestedBy:   "ntm " + action,
+		CorrelationID: session,
+		ExpiresIn:     OverrideTTL,
+	})
+	if err != nil {
+		return fmt.Errorf("%s refused: over hard %s; filing approval request: %w", action, over, err)
+	}
+	payload["approval_id"] = appr.ID
+	_ = audit.LogEvent(session, audit.EventTypeCommand, audit.ActorSystem, "budget.approval_requested", payload, nil)
+	return fmt.Errorf("%s held: over hard %s; approve an override with: ntm approve %s", action, over, appr.ID)
+}
@@ -0,0 +1,75 @@
+package budget
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shahbajlive/ntm/internal/config"
+	"github.com/shahbajlive/ntm/internal/cost"
+	"github.com/shahbajlive/ntm/internal/state"
+)
+
+// spend records tokens of spend for session in a state store under a
+// temporary home directory.
+func spend(t *testing.T, session string, tokens int) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	store, err := state.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if err := store.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	u := cost.Usage{Session: session, Pane: "%1", AgentType: "cc", InputTokens: tokens, Source: "prompt"}
+	if err := state.NewSpendStore(store).RecordUsage(u); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func setConfig(t *testing.T, bc config.BudgetsConfig) {
+	t.Helper()
+	SetConfig(bc)
+	t.Cleanup(func() { SetConfig(config.DefaultBudgetsConfig()) })
+}
+
+func TestEnforce(t *testing.T) {
+	spend(t, "proj", 500)
+
+	if soft, err := Enforce("proj", nil, "send"); err != nil || soft != nil {
+		t.Fatalf("disabled budgets: soft %v, err %v", soft, err)
+	}
+
+	setConfig(t, config.BudgetsConfig{Enabled: true, HardAction: config.BudgetRefuse,
+		Session: config.BudgetLimitConfig{SoftTokens: 100, HardTokens: 1000}})
+	soft, err := Enforce("proj", nil, "send")
+	if err != nil || len(soft) != 1 {
+		t.Fatalf("over soft: soft %v, err %v", soft, err)
+	}
+
+	spend(t, "proj", 5000)
+	_, err = Enforce("proj", nil, "send")
+	if err == nil || !strings.Contains(err.Error(), "send refused: over hard") {
+		t.Fatalf("over hard: err %v", err)
+	}
+	if _, err := Enforce("other", nil, "send"); err != nil {
+		t.Errorf("another session's spend refused: %v", err)
+	}
+}
+
+func TestEnforceApprove(t *testing.T) {
+	spend(t, "proj", 5000)
+	setConfig(t, config.BudgetsConfig{Enabled: true, HardAction: config.BudgetApprove,
+		Session: config.BudgetLimitConfig{HardTokens: 1000}})
+
+	_, err := Enforce("proj", nil, "send")
+	if err == nil || !strings.Contains(err.Error(), "ntm approve") {
+		t.Fatalf("first refusal: %v", err)
+	}
+	// The pending request is pointed at rather than filed again.
+	_, err = Enforce("proj", nil, "send")
+	if err == nil || !strings.Contains(err.Error(), "waiting for approval") {
+		t.Errorf("second refusal: %v", err)
+	}
+}
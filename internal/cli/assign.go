@@ -24,8 +24,10 @@ import (
 	"github.com/shahbajlive/ntm/internal/bv"
 	"github.com/shahbajlive/ntm/internal/completion"
 	"github.com/shahbajlive/ntm/internal/config"
+	"github.com/shahbajlive/ntm/internal/coordinator"
 	"github.com/shahbajlive/ntm/internal/events"
 	"github.com/shahbajlive/ntm/internal/robot"
+	"github.com/shahbajlive/ntm/internal/state"
 	"github.com/shahbajlive/ntm/internal/tmux"
 	"github.com/shahbajlive/ntm/internal/tui/theme"
 	"github.com/shahbajlive/ntm/internal/webhook"
@@ -99,6 +101,8 @@ Strategies:
   quality     - Prioritize agent-task match quality
   dependency  - Prioritize unblocking downstream work
   round-robin - Deterministic even distribution
+  optimal     - Globally optimal matching (min-cost flow) honoring file
+                reservations and dependencies; --verbose compares to greedy
 
 Prompt Templates:
   impl   - "Work on bead {BEAD_ID}: {TITLE}. Check dependencies first."
@@ -128,6 +132,11 @@ Watch Mode (Dependency-Aware Auto-Assignment):
   Use --watch to enable continuous monitoring for task completions and automatic
   reassignment of newly unblocked beads to idle agents.
 
+  If .ntm/config.toml has a [verify] section, its commands (tests, lint, build)
+  run in the agent's worktree whenever completion is detected. Failures keep the
+  bead with the agent and send it the failing output; passes are recorded as
+  evidence on the assignment and in bead history.
+
   ntm assign myproject --watch                      # Watch mode with auto-reassignment
   ntm assign myproject --watch --strategy=dependency # Watch with dependency-first strategy
   ntm assign myproject --watch --limit=2            # Limit to 2 assignments per cycle
@@ -179,7 +188,7 @@ Examples:
 
 	// Core flags
 	cmd.Flags().BoolVar(&assignAuto, "auto", false, "Execute assignments without confirmation")
-	cmd.Flags().StringVar(&assignStrategy, "strategy", "balanced", "Assignment strategy: balanced, speed, quality, dependency, round-robin")
+	cmd.Flags().StringVar(&assignStrategy, "strategy", "balanced", "Assignment strategy: balanced, speed, quality, dependency, round-robin, optimal")
 	cmd.Flags().StringVar(&assignBeads, "beads", "", "Comma-separated list of specific bead IDs to assign")
 	cmd.Flags().IntVar(&assignLimit, "limit", 0, "Maximum number of assignments (0 = unlimited)")
 
@@ -1540,6 +1549,10 @@ func generateAssignmentsEnhanced(agents []assignAgentInfo, beads []bv.BeadPrevie
 			}
 		}
 
+	case "optimal":
+		// Optimal: one global matching over all agent × bead scores
+		assignments = generateOptimalAssignments(agents, beads, opts, assignedAt, defaultStatus)
+
 	default: // balanced
 		// Balanced: spread work evenly, considering existing load from AssignmentStore
 		agentAssignCounts := make(map[int]int)
@@ -1629,6 +1642,175 @@ func generateAssignmentsEnhanced(agents []assignAgentInfo, beads []bv.BeadPrevie
 	return assignments
 }
 
+// generateOptimalAssignments solves the assignment as a min-cost flow via the
+// coordinator and maps the result back onto panes. In verbose mode the
+// greedy baseline and the beads that could not be placed are printed.
+func generateOptimalAssignments(agents []assignAgentInfo, beads []bv.BeadPreview, opts *AssignCommandOptions, assignedAt, status string) []AssignmentItem {
+	active, activeByName := activeAssignmentsByPane(opts.Session)
+	reservations := loadOptimalReservations(agents, opts.Session, activeByName)
+
+	byPaneID := make(map[string]*assignAgentInfo, len(agents))
+	states := make([]*coordinator.AgentState, 0, len(agents))
+	for i := range agents {
+		a := &agents[i]
+		byPaneID[a.pane.ID] = a
+		states = append(states, &coordinator.AgentState{
+			PaneID:       a.pane.ID,
+			PaneIndex:    a.pane.Index,
+			AgentType:    a.agentType,
+			Status:       robot.StateWaiting,
+			Healthy:      true,
+			Assignments:  active[a.pane.Index],
+			Reservations: reservations[a.pane.ID],
+		})
+	}
+
+	// Dependency edges come from triage; the previews handed in only carry
+	// what the other strategies need.
+	triage := make(map[string]bv.TriageRecommendation)
+	wd, _ := os.Getwd()
+	if triageRecs, err := bv.GetTriageRecommendations(wd, 100); err == nil {
+		for _, r := range triageRecs {
+			triage[r.ID] = r
+		}
+	}
+
+	recs := make([]*bv.TriageRecommendation, 0, len(beads))
+	for _, b := range beads {
+		priority := parsePriorityString(b.Priority)
+		t := triage[b.ID]
+		recs = append(recs, &bv.TriageRecommendation{
+			ID:          b.ID,
+			Title:       b.Title,
+			Type:        triageTypeFromTaskType(inferTaskTypeFromBead(b)),
+			Status:      "open",
+			Priority:    priority,
+			Score:       1.0 - 0.2*float64(priority),
+			BlockedBy:   t.BlockedBy,
+			UnblocksIDs: t.UnblocksIDs,
+		})
+	}
+
+	result := coordinator.AssignTasksOptimal(recs, states, reservations, coordinator.DefaultOptimalOptions())
+
+	if opts.Verbose {
+		fmt.Fprintf(os.Stderr, "Optimal matching: total score %.2f (greedy %.2f, %+d assigned)\n",
+			result.TotalScore, result.GreedyTotalScore, result.Improvement.ExtraAssigned)
+		for _, id := range result.Improvement.OnlyGreedy {
+			fmt.Fprintf(os.Stderr, "  greedy would also assign %s\n", id)
+		}
+		for _, ex := range result.Excluded {
+			fmt.Fprintf(os.Stderr, "  %s not assigned: %s\n", ex.BeadID, ex.Reason)
+		}
+	}
+
+	var items []AssignmentItem
+	for _, a := range result.Assignments {
+		agent := byPaneID[a.Agent.PaneID]
+		if agent == nil {
+			continue
+		}
+		items = append(items, AssignmentItem{
+			BeadID:     a.Bead.ID,
+			BeadTitle:  a.Bead.Title,
+			Pane:       agent.pane.Index,
+			AgentType:  agent.agentType,
+			AgentName:  assignmentAgentName(opts.Session, agent.agentType, agent.pane.Index),
+			Status:     status,
+			PromptSent: false,
+			AssignedAt: assignedAt,
+			Score:      a.Score,
+			Reasoning:  a.Reason,
+		})
+	}
+	return items
+}
+
+// activeAssignmentsByPane counts the session's active assignments per pane
+// and maps each assigned agent name to its pane.
+func activeAssignmentsByPane(session string) (map[int]int, map[string]int) {
+	counts := make(map[int]int)
+	byName := make(map[string]int)
+	if session == "" {
+		return counts, byName
+	}
+	store, err := assignment.LoadStore(session)
+	if err != nil || store == nil {
+		return counts, byName
+	}
+	for _, a := range store.ListActive() {
+		counts[a.Pane]++
+		if a.AgentName != "" {
+			byName[a.AgentName] = a.Pane
+		}
+	}
+	return counts, byName
+}
+
+// loadOptimalReservations returns every live Agent Mail file reservation in
+// the project. Reservations held by the given agents are keyed by pane ID;
+// holders are matched by their assignment agent name or by the name recorded
+// on an active assignment. Any other holder is keyed by its agent name, so
+// the solver treats its files as taken by an unavailable agent. Nothing is
+// returned when Agent Mail is unavailable.
+func loadOptimalReservations(agents []assignAgentInfo, session string, activeByName map[string]int) map[string][]string {
+	if session == "" || len(agents) == 0 {
+		return nil
+	}
+	projectKey, _ := os.Getwd()
+	amClient := agentmail.NewClient(agentmail.WithProjectKey(projectKey))
+	if !amClient.IsAvailable() {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	all, err := amClient.ListReservations(ctx, projectKey, "", true)
+	if err != nil {
+		return nil
+	}
+
+	paneByName := make(map[string]string, len(agents))
+	paneByIndex := make(map[int]string, len(agents))
+	for _, a := range agents {
+		paneByName[assignmentAgentName(session, a.agentType, a.pane.Index)] = a.pane.ID
+		paneByIndex[a.pane.Index] = a.pane.ID
+	}
+	for name, idx := range activeByName {
+		if id, ok := paneByIndex[idx]; ok {
+			if _, seen := paneByName[name]; !seen {
+				paneByName[name] = id
+			}
+		}
+	}
+
+	now := time.Now()
+	reservations := make(map[string][]string)
+	for _, r := range all {
+		if r.ReleasedTS != nil || now.After(r.ExpiresTS.Time) {
+			continue
+		}
+		holder := r.AgentName
+		if paneID, ok := paneByName[r.AgentName]; ok {
+			holder = paneID
+		}
+		reservations[holder] = append(reservations[holder], r.PathPattern)
+	}
+	return reservations
+}
+
+// triageTypeFromTaskType maps an inferred task type onto the bead types the
+// coordinator's complexity estimate understands.
+func triageTypeFromTaskType(taskType string) string {
+	switch taskType {
+	case "feature", "bug":
+		return taskType
+	case "documentation", "testing":
+		return "chore"
+	default:
+		return "task"
+	}
+}
+
 // inferTaskTypeFromBead determines task type from bead metadata
 func inferTaskTypeFromBead(bead bv.BeadPreview) string {
 	title := strings.ToLower(bead.Title)
@@ -4021,6 +4203,11 @@ func (w *WatchLoop) Run(ctx context.Context) error {
 	}
 	w.detector = completion.NewWithConfig(w.session, w.store, detectorCfg)
 
+	// Gate completions on the project's verification commands, if any
+	if closeVerify := w.configureVerification(); closeVerify != nil {
+		defer closeVerify()
+	}
+
 	// Start watching for completions
 	watchCtx, watchCancel := context.WithCancel(ctx)
 	defer watchCancel()
@@ -4083,6 +4270,14 @@ func (w *WatchLoop) handleCompletion(event completion.CompletionEvent) error {
 
 	duration := event.Duration.Round(time.Second)
 
+	if event.Reopened {
+		w.logf("Verification failed: %s by pane %d (%s) - sent back to agent", event.BeadID, event.Pane, event.Verification.Summary())
+		return nil
+	}
+	if event.Verification != nil && !event.IsFailed {
+		w.logf("Verified: %s - %s", event.BeadID, event.Verification.Summary())
+	}
+
 	if event.IsFailed {
 		w.totalFailed++
 		w.logf("Failed: %s by pane %d (%s) - %s", event.BeadID, event.Pane, event.AgentType, event.FailReason)
@@ -4140,6 +4335,57 @@ func (w *WatchLoop) handleCompletion(event completion.CompletionEvent) error {
 	return nil
 }
 
+// configureVerification attaches a verifier built from the project's
+// [verify] config to the detector. Commands run in each agent pane's current
+// directory (its worktree when spawned with --worktrees). The returned func
+// releases the state store used for bead history.
+func (w *WatchLoop) configureVerification() func() {
+	wd, _ := os.Getwd()
+	projectDir, projectCfg, err := config.FindProjectConfig(wd)
+	if err != nil || projectCfg == nil || !projectCfg.Verify.Enabled() {
+		return nil
+	}
+	vc := projectCfg.Verify
+
+	verifier := &completion.Verifier{
+		Commands:     vc.Commands,
+		BeadCommands: vc.Beads,
+		ExcerptLines: vc.ExcerptLines,
+		WorkDir: func(a *assignment.Assignment) string {
+			target := fmt.Sprintf("%s.%d", w.session, a.Pane)
+			if dir, err := tmux.DefaultClient.Run("display-message", "-p", "-t", target, "#{pane_current_path}"); err == nil && strings.TrimSpace(dir) != "" {
+				return strings.TrimSpace(dir)
+			}
+			return projectDir
+		},
+	}
+	if vc.Timeout != "" {
+		if d, err := time.ParseDuration(vc.Timeout); err == nil {
+			verifier.Timeout = d
+		} else {
+			w.logf("Warning: invalid verify timeout %q: %v", vc.Timeout, err)
+		}
+	}
+	w.detector.Verifier = verifier
+	if vc.MaxFailures > 0 {
+		w.detector.Config.MaxVerifyFailures = vc.MaxFailures
+	}
+	w.logf("Verification gate enabled: %s", strings.Join(vc.Commands, "; "))
+
+	store, err := state.Open("")
+	if err != nil {
+		w.logf("Warning: bead history unavailable: %v", err)
+		return nil
+	}
+	if err := store.Migrate(); err != nil {
+		w.logf("Warning: bead history unavailable: %v", err)
+		store.Close()
+		return nil
+	}
+	w.detector.History = store
+	return func() { store.Close() }
+}
+
 // shouldStop checks if watch mode should exit
 func (w *WatchLoop) shouldStop() bool {
 	// Check if there are any active assignments
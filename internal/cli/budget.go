@@ -0,0 +1,454 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shahbajlive/ntm/internal/audit"
+	"github.com/shahbajlive/ntm/internal/budget"
+	"github.com/shahbajlive/ntm/internal/config"
+	"github.com/shahbajlive/ntm/internal/cost"
+	"github.com/shahbajlive/ntm/internal/notify"
+	"github.com/shahbajlive/ntm/internal/output"
+	"github.com/shahbajlive/ntm/internal/pipeline"
+	"github.com/shahbajlive/ntm/internal/state"
+	"github.com/shahbajlive/ntm/internal/tmux"
+)
+
+// BudgetStatusOutput is the JSON output for budget status.
+type BudgetStatusOutput struct {
+	output.TimestampedResponse
+	Session    string              `json:"session"`
+	Enabled    bool                `json:"enabled"`
+	HardAction string              `json:"hard_action,omitempty"`
+	Level      cost.BudgetLevel    `json:"level"`
+	Budgets    []cost.BudgetStatus `json:"budgets"`
+	Spend      state.SpendTotals   `json:"spend"`
+	Today      state.SpendTotals   `json:"today"`
+}
+
+// BudgetReportOutput is the JSON output for budget report.
+type BudgetReportOutput struct {
+	output.TimestampedResponse
+	Month      string                 `json:"month"`
+	Total      state.SpendTotals      `json:"total"`
+	Sessions   []BudgetReportLine     `json:"sessions"`
+	AgentTypes []BudgetReportLine     `json:"agent_types"`
+	Days       []BudgetReportLine     `json:"days"`
+	Rows       []state.SpendRollupRow `json:"rows"`
+}
+
+// BudgetReportLine is one group in a spend report.
+type BudgetReportLine struct {
+	Key string `json:"key"`
+	state.SpendTotals
+}
+
+// spendMeterInterval is how often the session monitor meters agent output.
+const spendMeterInterval = 30 * time.Second
+
+// openSpend opens the state store's spend ledger. The caller closes the
+// returned store.
+func openSpend() (*state.Store, *state.SpendStore, error) {
+	store, err := openTranscriptStore()
+	if err != nil {
+		return nil, nil, err
+	}
+	return store, state.NewSpendStore(store), nil
+}
+
+var (
+	spendTrackerOnce sync.Once
+	spendTrackerInst *cost.CostTracker
+)
+
+// spendTracker returns the process's cost tracker, which records into the
+// spend ledger, or nil if the state store cannot be opened. The store stays
+// open for the life of the process.
+func spendTracker() *cost.CostTracker {
+	spendTrackerOnce.Do(func() {
+		_, ss, err := openSpend()
+		if err != nil {
+			return
+		}
+		t := cost.NewCostTracker("")
+		t.SetSink(func(u cost.Usage) {
+			if err := ss.RecordUsage(u); err != nil && !IsJSONOutput() {
+				fmt.Fprintf(os.Stderr, "Warning: recording spend: %v\n", err)
+			}
+		})
+		spendTrackerInst = t
+	})
+	return spendTrackerInst
+}
+
+// paneCostModel returns the model a pane's usage is priced with.
+func paneCostModel(p tmux.Pane) string {
+	if p.Variant != "" {
+		return p.Variant
+	}
+	if cfg == nil {
+		return ""
+	}
+	switch p.Type {
+	case tmux.AgentClaude:
+		return cfg.Models.DefaultClaude
+	case tmux.AgentCodex:
+		return cfg.Models.DefaultCodex
+	case tmux.AgentGemini:
+		return cfg.Models.DefaultGemini
+	}
+	return ""
+}
+
+// recordPromptSpend records a prompt typed into an agent pane as input
+// tokens. Best-effort: without a state store nothing is recorded.
+func recordPromptSpend(session string, p tmux.Pane, prompt string) {
+	if p.Type == tmux.AgentUser {
+		return
+	}
+	if t := spendTracker(); t != nil {
+		t.RecordUsage(cost.Usage{Session: session, Pane: p.ID, AgentType: string(p.Type), Model: paneCostModel(p),
+			InputTokens: cost.EstimateTokens(prompt), Source: "prompt"})
+	}
+}
+
+// paneAgentTypes returns the distinct agent types of panes, for limiting
+// which agent budgets a send is checked against.
+func paneAgentTypes(panes []tmux.Pane) []string {
+	var types []string
+	for _, p := range panes {
+		if p.Type == tmux.AgentUser || slices.Contains(types, string(p.Type)) {
+			continue
+		}
+		types = append(types, string(p.Type))
+	}
+	return types
+}
+
+// budgetsConfig returns the loaded budget configuration.
+func budgetsConfig() config.BudgetsConfig {
+	if cfg == nil {
+		return config.DefaultBudgetsConfig()
+	}
+	return cfg.Budgets
+}
+
+// enforceSpendBudget checks session's budgets before action spends more
+// (see budget.Enforce), warning on stderr about soft thresholds crossed.
+func enforceSpendBudget(session string, agentTypes []string, action string) error {
+	soft, err := budget.Enforce(session, agentTypes, action)
+	if err != nil {
+		return err
+	}
+	if !IsJSONOutput() {
+		for _, s := range soft {
+			fmt.Fprintf(os.Stderr, "⚠ Over soft %s\n", s)
+		}
+	}
+	return nil
+}
+
+// attachSpendBudget has a pipeline check session budgets before each step's
+// prompt and record the prompts it sends.
+func attachSpendBudget(cfg *pipeline.ExecutorConfig) {
+	session := cfg.Session
+	cfg.Budget = func(agentType string) error {
+		return enforceSpendBudget(session, []string{agentType}, "pipeline step")
+	}
+	cfg.Costs = spendTracker()
+}
+
+// startSpendMeter records agents' new output as output tokens until ctx is
+// done, and raises a budget.alert notification when a budget crosses a
+// threshold. Output already on screen when metering starts is not counted.
+// It reports whether metering started.
+func startSpendMeter(ctx context.Context, session string) bool {
+	tracker := spendTracker()
+	if tracker == nil {
+		return false
+	}
+	var notifier *notify.Notifier
+	if cfg != nil {
+		notifier = notify.New(cfg.Notifications)
+	}
+	go func() {
+		prev := make(map[string]string)
+		levels := make(map[string]cost.BudgetLevel)
+		ticker := time.NewTicker(spendMeterInterval)
+		defer ticker.Stop()
+		for {
+			meterPaneOutput(session, tracker, prev)
+			alertBudgetLevels(session, notifier, levels)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return true
+}
+
+func meterPaneOutput(session string, tracker *cost.CostTracker, prev map[string]string) {
+	panes, err := tmux.GetPanes(session)
+	if err != nil {
+		return
+	}
+	for _, p := range panes {
+		if p.Type == tmux.AgentUser {
+			continue
+		}
+		out, err := tmux.CapturePaneOutput(p.ID, 200)
+		if err != nil {
+			continue
+		}
+		last, seen := prev[p.ID]
+		prev[p.ID] = out
+		if !seen {
+			continue
+		}
+		if delta := cost.OutputDelta(last, out); delta != "" {
+			tracker.RecordUsage(cost.Usage{Session: session, Pane: p.ID, AgentType: string(p.Type), Model: paneCostModel(p),
+				OutputTokens: cost.EstimateTokens(delta), Source: "response"})
+		}
+	}
+}
+
+// alertBudgetLevels notifies when a budget's level rises since the last check.
+func alertBudgetLevels(session string, notifier *notify.Notifier, levels map[string]cost.BudgetLevel) {
+	budgets := budgetsConfig().Budgets()
+	if len(budgets) == 0 {
+		return
+	}
+	store, ss, err := openSpend()
+	if err != nil {
+		return
+	}
+	defer store.Close()
+	statuses, err := cost.Evaluate(budgets, session, nil, ss.BudgetSpend)
+	if err != nil {
+		return
+	}
+	for _, s := range statuses {
+		key := s.Name + "/" + string(s.Unit)
+		was, seen := levels[key]
+		levels[key] = s.Level
+		if s.Level == cost.LevelOK || (seen && s.Level == was) || (seen && was == cost.LevelHard) {
+			continue
+		}
+		msg := fmt.Sprintf("Over %s %s", s.Level, s)
+		fmt.Printf("Budget alert (%s): %s\n", session, msg)
+		_ = audit.LogEvent(session, audit.EventTypeCommand, audit.ActorSystem, "budget.alert",
+			map[string]interface{}{"budget": s.Name, "level": s.Level, "used": s.Used, "percent": s.Percent}, nil)
+		if notifier != nil {
+			_ = notifier.Notify(notify.NewBudgetAlertEvent(session, s.Name, string(s.Level), msg))
+		}
+	}
+}
+
+func newBudgetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "budget",
+		Short: "Show spend against budgets and monthly spend reports",
+		Long: `Spend is recorded in the state database as prompts are sent and as the
+session monitor sees agents respond. Budgets configured under [budgets] are
+checked before send, pipeline steps and swarm spawns: soft thresholds warn, hard
+thresholds refuse, or with hard_action = "approve" wait for 'ntm approve'.
+
+  [budgets]
+  enabled = true
+  hard_action = "refuse"          # or "approve"
+  [budgets.session]
+  soft_usd = 20.0
+  hard_usd = 25.0
+  [budgets.daily]                 # All sessions, today
+  hard_usd = 100.0
+  [budgets.agents.cc]             # Claude panes within a session
+  hard_tokens = 5000000
+
+Examples:
+  ntm budget status myproject
+  ntm budget report --month 2026-10`,
+	}
+
+	cmd.AddCommand(newBudgetStatusCmd(), newBudgetReportCmd())
+	return cmd
+}
+
+func newBudgetStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status [session]",
+		Short: "Show a session's spend against its budgets",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// A named session need not be running: its spend stays in the ledger.
+			var session string
+			if len(args) > 0 {
+				session = args[0]
+			} else {
+				res, err := ResolveSession("", cmd.ErrOrStderr())
+				if err != nil {
+					return err
+				}
+				if res.Session == "" {
+					return fmt.Errorf("session is required")
+				}
+				res.ExplainIfInferred(cmd.ErrOrStderr())
+				session = res.Session
+			}
+
+			store, ss, err := openSpend()
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			bc := budgetsConfig()
+			statuses, err := cost.Evaluate(bc.Budgets(), session, nil, ss.BudgetSpend)
+			if err != nil {
+				return err
+			}
+			out := BudgetStatusOutput{
+				TimestampedResponse: output.NewTimestamped(),
+				Session:             session,
+				Enabled:             bc.Enabled,
+				HardAction:          bc.HardAction,
+				Level:               cost.WorstLevel(statuses),
+				Budgets:             statuses,
+			}
+			if out.Budgets == nil {
+				out.Budgets = []cost.BudgetStatus{}
+			}
+			if out.Spend, err = ss.Totals(state.SpendFilter{SessionName: session}); err != nil {
+				return err
+			}
+			if out.Today, err = ss.Totals(state.SpendFilter{SessionName: session, Day: time.Now().Format(state.SpendDayLayout)}); err != nil {
+				return err
+			}
+			if IsJSONOutput() {
+				return output.PrintJSON(out)
+			}
+
+			fmt.Printf("Session %s: %s spent (%d tokens), %s today\n", session,
+				cost.FormatCost(out.Spend.CostUSD), out.Spend.Tokens(), cost.FormatCost(out.Today.CostUSD))
+			if !bc.Enabled {
+				output.PrintInfof("Budgets are disabled; set [budgets] enabled = true in config")
+				return nil
+			}
+			if len(statuses) == 0 {
+				output.PrintInfof("No budget limits configured")
+				return nil
+			}
+			for _, s := range statuses {
+				fmt.Printf("  %-5s %s\n", s.Level, s)
+			}
+			return nil
+		},
+	}
+}
+
+func newBudgetReportCmd() *cobra.Command {
+	var month, session string
+
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Roll up a month's spend by session, agent type and day",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if month == "" {
+				month = time.Now().Format("2006-01")
+			}
+			if _, err := time.Parse("2006-01", month); err != nil {
+				return fmt.Errorf("invalid --month %q (want YYYY-MM)", month)
+			}
+			store, ss, err := openSpend()
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			rows, err := ss.Rollup(state.SpendFilter{Month: month, SessionName: session})
+			if err != nil {
+				return err
+			}
+			out := buildBudgetReport(month, rows)
+			if IsJSONOutput() {
+				return output.PrintJSON(out)
+			}
+			if len(rows) == 0 {
+				output.PrintInfof("No spend recorded for %s", month)
+				return nil
+			}
+
+			fmt.Printf("Spend for %s: %s (%d tokens)\n", month, cost.FormatCost(out.Total.CostUSD), out.Total.Tokens())
+			for _, group := range []struct {
+				title string
+				lines []BudgetReportLine
+			}{{"By session", out.Sessions}, {"By agent type", out.AgentTypes}, {"By day", out.Days}} {
+				fmt.Printf("\n%s:\n", group.title)
+				for _, l := range group.lines {
+					fmt.Printf("  %-24s %10s %12d tokens\n", truncateString(l.Key, 24), cost.FormatCost(l.CostUSD), l.Tokens())
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&month, "month", "", "Month to report, YYYY-MM (default: this month)")
+	cmd.Flags().StringVarP(&session, "session", "s", "", "Only this session")
+	return cmd
+}
+
+// buildBudgetReport groups rollup rows by session, agent type and day.
+// Sessions and agent types are ordered by cost, days by date.
+func buildBudgetReport(month string, rows []state.SpendRollupRow) BudgetReportOutput {
+	out := BudgetReportOutput{TimestampedResponse: output.NewTimestamped(), Month: month, Rows: rows}
+	if out.Rows == nil {
+		out.Rows = []state.SpendRollupRow{}
+	}
+	group := func(key func(state.SpendRollupRow) string, byCost bool) []BudgetReportLine {
+		index := make(map[string]int)
+		lines := []BudgetReportLine{}
+		for _, r := range rows {
+			k := key(r)
+			i, ok := index[k]
+			if !ok {
+				i = len(lines)
+				index[k] = i
+				lines = append(lines, BudgetReportLine{Key: k})
+			}
+			lines[i].InputTokens += r.InputTokens
+			lines[i].OutputTokens += r.OutputTokens
+			lines[i].CostUSD += r.CostUSD
+		}
+		sort.SliceStable(lines, func(a, b int) bool {
+			if byCost {
+				return lines[a].CostUSD > lines[b].CostUSD
+			}
+			return lines[a].Key < lines[b].Key
+		})
+		return lines
+	}
+	out.Sessions = group(func(r state.SpendRollupRow) string { return r.SessionName }, true)
+	out.AgentTypes = group(func(r state.SpendRollupRow) string {
+		if r.AgentType == "" {
+			return "unknown"
+		}
+		return r.AgentType
+	}, true)
+	out.Days = group(func(r state.SpendRollupRow) string { return r.Day }, false)
+	for _, l := range out.Sessions {
+		out.Total.InputTokens += l.InputTokens
+		out.Total.OutputTokens += l.OutputTokens
+		out.Total.CostUSD += l.CostUSD
+	}
+	return out
+}
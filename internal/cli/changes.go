@@ -55,7 +55,8 @@ func newConflictsCmd() *cobra.Command {
 		Examples:
 		  ntm conflicts
 		  ntm conflicts myproject
-		  ntm conflicts --since 6h --limit 10`,
+		  ntm conflicts --since 6h --limit 10
+		  ntm conflicts resolve internal/api/handler.go`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			session := ""
@@ -67,6 +68,7 @@ func newConflictsCmd() *cobra.Command {
 	}
 	cmd.Flags().StringVar(&since, "since", "24h", "Look back window (e.g. 6h, 30m)")
 	cmd.Flags().IntVar(&limit, "limit", 50, "Maximum conflicts to display (0 = no limit)")
+	cmd.AddCommand(newConflictsResolveCmd())
 	return cmd
 }
 
@@ -102,6 +104,10 @@ func runChanges(sessionFilter string) error {
 	for _, c := range filtered {
 		age := formatAge(c.Timestamp)
 		agents := strings.Join(c.Agents, ", ")
+		if agents == "" && len(c.Candidates) > 0 {
+			// Filesystem attribution could not single out the writer
+			agents = "one of " + strings.Join(c.Candidates, ", ")
+		}
 
 		changeType := ""
 		switch c.Change.Type {
@@ -0,0 +1,487 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+
+	"github.com/shahbajlive/ntm/internal/assignment"
+	"github.com/shahbajlive/ntm/internal/coordinator"
+	"github.com/shahbajlive/ntm/internal/events"
+	"github.com/shahbajlive/ntm/internal/output"
+	"github.com/shahbajlive/ntm/internal/resolve"
+	"github.com/shahbajlive/ntm/internal/tmux"
+	"github.com/shahbajlive/ntm/internal/worktrees"
+)
+
+// ConflictResolveOutput is the JSON output for conflicts resolve.
+type ConflictResolveOutput struct {
+	output.TimestampedResponse
+	Session   string             `json:"session"`
+	Merge     *resolve.Merge     `json:"merge"`
+	Method    string             `json:"method,omitempty"`
+	Picks     []resolve.Pick     `json:"picks,omitempty"`
+	Delegate  string             `json:"delegate,omitempty"`
+	Workspace *resolve.Workspace `json:"workspace,omitempty"`
+	Winner    string             `json:"winner,omitempty"`
+	AppliedTo string             `json:"applied_to,omitempty"`
+	Released  []string           `json:"released,omitempty"`
+}
+
+type conflictResolveOptions struct {
+	session   string
+	from      []string
+	pick      string
+	delegate  string
+	wait      time.Duration
+	use       string
+	into      string
+	release   string
+	noRelease bool
+	show      bool
+}
+
+func newConflictsResolveCmd() *cobra.Command {
+	var opts conflictResolveOptions
+
+	cmd := &cobra.Command{
+		Use:   "resolve <path>",
+		Short: "Merge two agents' edits to the same file",
+		Long: `Resolve a file two agents both changed. Each agent's version comes from
+its worktree (uncommitted edits included) or, once the worktree is gone, its
+branch ntm/<session>/<agent>; a version can also be rebuilt from a checkpoint
+with checkpoint:<id>, or <agent>=checkpoint:<id> to name its author. With
+--from omitted, the two worktrees whose copy of the file differs from HEAD
+are used.
+
+The versions are merged against their common base. Changes only one side
+made are kept; for each remaining conflict the base, A and B lines are shown
+and you pick a, b, ab, ba (both, in that order) or base. --pick applies one
+choice to every conflict. --delegate hands the merge to an agent instead:
+the versions are written under .ntm/conflicts/ and the agent is asked to
+write the merged file there, which is applied once it appears.
+
+The result is written to the file in the project (or --into an agent's
+worktree). When every conflict went to one side, the other agent's Agent
+Mail reservations on the file are released. The resolution is recorded as a
+conflict_resolved event.
+
+Examples:
+  ntm conflicts resolve internal/api/handler.go
+  ntm conflicts resolve internal/api/handler.go --from cc_1,cod_1 --pick a
+  ntm conflicts resolve README.md --from cc_1,gmi_1=checkpoint:20250101-120000-pre
+  ntm conflicts resolve internal/api/handler.go --delegate gmi_1
+  ntm conflicts resolve internal/api/handler.go --show --json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConflictsResolve(cmd, args[0], opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.session, "session", "", "Session whose worktrees and checkpoints hold the versions (default: current)")
+	cmd.Flags().StringSliceVar(&opts.from, "from", nil, "The two sides: agent names or checkpoint:<id>")
+	cmd.Flags().StringVar(&opts.pick, "pick", "", "Resolve every conflict the same way: a, b, ab, ba or base")
+	cmd.Flags().StringVar(&opts.delegate, "delegate", "", "Ask this agent (name like cod_1 or pane index) to merge the file")
+	cmd.Flags().DurationVar(&opts.wait, "wait", 15*time.Minute, "How long to wait for a delegated merge (0 = don't wait)")
+	cmd.Flags().StringVar(&opts.use, "use", "", "Apply this file as the resolution, e.g. a delegated merge that finished later")
+	cmd.Flags().StringVar(&opts.into, "into", "", "Write the result into this agent's worktree instead of the project")
+	cmd.Flags().StringVar(&opts.release, "release", "", "Agent Mail name whose reservations on the file to release")
+	cmd.Flags().BoolVar(&opts.noRelease, "no-release", false, "Do not release any reservation")
+	cmd.Flags().BoolVar(&opts.show, "show", false, "Only show the three-way view")
+	return cmd
+}
+
+func runConflictsResolve(cmd *cobra.Command, path string, opts conflictResolveOptions) error {
+	root := GetProjectRoot()
+	if root == "" {
+		return fmt.Errorf("getting project root failed")
+	}
+	rel, err := projectRelPath(root, path)
+	if err != nil {
+		return err
+	}
+	session := opts.session
+	if session == "" {
+		if session = tmux.GetCurrentSession(); session == "" {
+			session = filepath.Base(root)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	from := opts.from
+	if len(from) == 0 {
+		if from, err = changedWorktrees(ctx, root, session, rel); err != nil {
+			return err
+		}
+	}
+	if len(from) != 2 {
+		return fmt.Errorf("--from needs exactly two sides, got %d", len(from))
+	}
+	a, err := resolve.LoadSide(ctx, root, session, from[0], rel)
+	if err != nil {
+		return fmt.Errorf("side A: %w", err)
+	}
+	b, err := resolve.LoadSide(ctx, root, session, from[1], rel)
+	if err != nil {
+		return fmt.Errorf("side B: %w", err)
+	}
+	base, err := resolve.Base(ctx, root, rel, a, b)
+	if err != nil {
+		return err
+	}
+	m, err := resolve.ThreeWay(ctx, rel, base, a, b)
+	if err != nil {
+		return err
+	}
+	out := ConflictResolveOutput{Session: session, Merge: m}
+
+	if opts.show {
+		if IsJSONOutput() {
+			out.TimestampedResponse = output.NewTimestamped()
+			return output.PrintJSON(out)
+		}
+		printThreeWay(os.Stdout, m)
+		return nil
+	}
+
+	// Decide the merged content
+	var merged []byte
+	switch {
+	case opts.use != "":
+		if merged, err = os.ReadFile(opts.use); err != nil {
+			return err
+		}
+		if resolve.HasMarkers(merged) {
+			return fmt.Errorf("%s still contains conflict markers", opts.use)
+		}
+		out.Method = "file"
+	case m.Clean():
+		merged, _ = m.Resolve(nil)
+		out.Method = "clean"
+	case opts.pick != "":
+		p, err := resolve.ParsePick(opts.pick)
+		if err != nil {
+			return err
+		}
+		for range m.Conflicts {
+			out.Picks = append(out.Picks, p)
+		}
+		out.Method = "pick"
+	case opts.delegate != "":
+		out.Method, out.Delegate = "delegate", opts.delegate
+		if out.Workspace, merged, err = delegateMerge(session, root, m, opts); err != nil {
+			return err
+		}
+		if merged == nil {
+			if IsJSONOutput() {
+				out.TimestampedResponse = output.NewTimestamped()
+				return output.PrintJSON(out)
+			}
+			output.PrintInfof("Asked %s to merge %s; apply its result with: ntm conflicts resolve %s --from %s --use %s",
+				opts.delegate, rel, rel, strings.Join(from, ","), out.Workspace.Resolved)
+			return nil
+		}
+	case IsJSONOutput() || !isatty.IsTerminal(os.Stdin.Fd()):
+		return fmt.Errorf("%d conflicts in %s: choose with --pick or --delegate, or run interactively", len(m.Conflicts), rel)
+	default:
+		if out.Picks, err = promptPicks(os.Stdin, os.Stdout, m); err != nil {
+			return err
+		}
+		out.Method = "pick"
+	}
+	if out.Picks != nil {
+		if merged, err = m.Resolve(out.Picks); err != nil {
+			return err
+		}
+	}
+
+	// Apply it
+	target := filepath.Join(root, rel)
+	if opts.into != "" {
+		info, err := worktrees.NewManager(root, session).GetWorktreeForAgent(opts.into)
+		if err != nil {
+			return err
+		}
+		if !info.Created {
+			return fmt.Errorf("no worktree found for agent: %s", opts.into)
+		}
+		target = filepath.Join(info.Path, rel)
+	}
+	if err := writePreservingMode(target, merged); err != nil {
+		return err
+	}
+	out.AppliedTo = target
+
+	// Release the losing side's reservations on the file
+	loser := ""
+	if w := m.Winner(out.Picks); w != nil {
+		out.Winner = w.Label
+		if w == a {
+			loser = b.Agent
+		} else {
+			loser = a.Agent
+		}
+	}
+	if !opts.noRelease && (opts.release != "" || loser != "") {
+		released, err := releaseConflictReservations(root, session, rel, loser, opts.release)
+		if err != nil && !IsJSONOutput() {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: reservations not released: %v\n", err)
+		}
+		out.Released = released
+	}
+
+	picks := make([]string, len(out.Picks))
+	for i, p := range out.Picks {
+		picks[i] = string(p)
+	}
+	events.Emit(events.EventConflictResolved, session, events.ConflictResolutionData{
+		Path:      rel,
+		SideA:     a.Label,
+		SideB:     b.Label,
+		Conflicts: len(m.Conflicts),
+		Method:    out.Method,
+		Picks:     picks,
+		Delegate:  out.Delegate,
+		Winner:    out.Winner,
+		AppliedTo: target,
+		Released:  out.Released,
+	})
+
+	if IsJSONOutput() {
+		out.TimestampedResponse = output.NewTimestamped()
+		return output.PrintJSON(out)
+	}
+	output.PrintInfof("Resolved %s (%d conflicts, %s) into %s", rel, len(m.Conflicts), out.Method, target)
+	if len(out.Released) > 0 {
+		output.PrintInfof("Released reservations of %s", strings.Join(out.Released, ", "))
+	}
+	return nil
+}
+
+// projectRelPath returns path relative to the project root.
+func projectRelPath(root, path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("%s is outside the project %s", path, root)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// changedWorktrees returns the agents whose worktree copy of path differs
+// from the project's HEAD, when there are exactly two.
+func changedWorktrees(ctx context.Context, root, session, path string) ([]string, error) {
+	list, err := worktrees.NewManager(root, session).ListWorktrees()
+	if err != nil {
+		return nil, err
+	}
+	base, err := resolve.AtCommit(ctx, root, "HEAD", path)
+	if err != nil {
+		return nil, err
+	}
+	var changed []string
+	for _, wt := range list {
+		side, err := resolve.WorktreeSide(ctx, root, session, wt.AgentName, path)
+		if err != nil {
+			continue
+		}
+		if side.Exists != base.Exists || !bytes.Equal(side.Content, base.Content) {
+			changed = append(changed, wt.AgentName)
+		}
+	}
+	if len(changed) != 2 {
+		return nil, fmt.Errorf("%d worktrees changed %s (%s); choose two sides with --from",
+			len(changed), path, strings.Join(changed, ", "))
+	}
+	return changed, nil
+}
+
+// printThreeWay prints each conflict's base, A and B lines.
+func printThreeWay(w io.Writer, m *resolve.Merge) {
+	fmt.Fprintf(w, "%s: A = %s (%s), B = %s (%s), base %s\n", m.Path,
+		m.A.Label, m.A.Source, m.B.Label, m.B.Source, m.Base.Commit[:min(12, len(m.Base.Commit))])
+	if m.Clean() {
+		fmt.Fprintln(w, "The changes merge cleanly.")
+		return
+	}
+	for _, h := range m.Conflicts {
+		printHunk(w, m, h)
+	}
+}
+
+func printHunk(w io.Writer, m *resolve.Merge, h *resolve.Hunk) {
+	fmt.Fprintf(w, "\nConflict %d/%d (line %d of the marked merge)\n", h.Index, len(m.Conflicts), h.Line)
+	for _, part := range []struct {
+		name  string
+		lines []string
+	}{
+		{"base", h.Base},
+		{"a: " + m.A.Label, h.A},
+		{"b: " + m.B.Label, h.B},
+	} {
+		fmt.Fprintf(w, "  ── %s\n", part.name)
+		if len(part.lines) == 0 {
+			fmt.Fprintln(w, "    (nothing)")
+		}
+		for _, l := range part.lines {
+			fmt.Fprintf(w, "    │ %s\n", strings.TrimRight(l, "\r\n"))
+		}
+	}
+}
+
+// promptPicks asks for a pick for each conflict of m.
+func promptPicks(in io.Reader, w io.Writer, m *resolve.Merge) ([]resolve.Pick, error) {
+	printThreeWay(w, m)
+	reader := bufio.NewReader(in)
+	picks := make([]resolve.Pick, 0, len(m.Conflicts))
+	for _, h := range m.Conflicts {
+		for {
+			fmt.Fprintf(w, "Conflict %d: keep a, b, ab, ba or base (q to abort)? ", h.Index)
+			line, err := reader.ReadString('\n')
+			if err != nil && line == "" {
+				return nil, fmt.Errorf("aborted")
+			}
+			answer := strings.TrimSpace(line)
+			if answer == "q" {
+				return nil, fmt.Errorf("aborted")
+			}
+			p, perr := resolve.ParsePick(answer)
+			if perr == nil {
+				picks = append(picks, p)
+				break
+			}
+			fmt.Fprintln(w, perr)
+		}
+	}
+	return picks, nil
+}
+
+// delegateMerge asks opts.delegate to merge m and waits up to opts.wait
+// for its result. It returns a nil result when the wait ends first. A
+// result is applied only once two reads in a row agree, so a file the
+// agent is still writing in place is not taken half done.
+func delegateMerge(session, root string, m *resolve.Merge, opts conflictResolveOptions) (*resolve.Workspace, []byte, error) {
+	pane, err := findAgentPane(session, opts.delegate)
+	if err != nil {
+		return nil, nil, err
+	}
+	ws, err := resolve.WriteWorkspace(root, m, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := tmux.PasteKeys(pane.ID, ws.Prompt(m), true); err != nil {
+		return nil, nil, fmt.Errorf("sending merge request: %w", err)
+	}
+	if opts.wait <= 0 {
+		return ws, nil, nil
+	}
+	if !IsJSONOutput() {
+		output.PrintInfof("Waiting up to %s for %s to write %s", opts.wait, opts.delegate, ws.Resolved)
+	}
+	deadline := time.Now().Add(opts.wait)
+	var last []byte
+	for time.Now().Before(deadline) {
+		data, err := ws.ReadResolved()
+		if err != nil {
+			return ws, nil, err
+		}
+		if data != nil && last != nil && bytes.Equal(data, last) {
+			return ws, data, nil
+		}
+		last = data
+		time.Sleep(2 * time.Second)
+	}
+	return ws, nil, nil
+}
+
+// findAgentPane finds a pane of session by agent name (cod_1) or index.
+func findAgentPane(session, name string) (*tmux.Pane, error) {
+	panes, err := tmux.GetPanes(session)
+	if err != nil {
+		return nil, err
+	}
+	index, indexErr := strconv.Atoi(name)
+	for i, p := range panes {
+		if fmt.Sprintf("%s_%d", p.Type, p.NTMIndex) == name || (indexErr == nil && p.Index == index) {
+			return &panes[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no agent %s in session %s", name, session)
+}
+
+// releaseConflictReservations releases the Agent Mail reservations on path
+// held by agent (an ntm agent name, matched against the names its pane may
+// hold reservations under) or by the explicit holder name.
+func releaseConflictReservations(root, session, path, agent, holder string) ([]string, error) {
+	names := make(map[string]bool)
+	if holder != "" {
+		names[holder] = true
+	}
+	if agent != "" {
+		names[agent] = true
+		if p, err := findAgentPane(session, agent); err == nil {
+			names[session+"_"+p.ID] = true
+			if store, err := assignment.LoadStore(session); err == nil {
+				for _, a := range store.List() {
+					if a.Pane == p.Index && a.AgentName != "" {
+						names[a.AgentName] = true
+					}
+				}
+			}
+		}
+	}
+
+	client := newAgentMailClient(root)
+	if !client.IsAvailable() {
+		return nil, fmt.Errorf("agent mail server unavailable")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	reservations, err := client.ListReservations(ctx, root, "", true)
+	if err != nil {
+		return nil, err
+	}
+	byHolder := make(map[string][]int)
+	for _, r := range reservations {
+		if r.ReleasedTS != nil || time.Now().After(r.ExpiresTS.Time) || !names[r.AgentName] {
+			continue
+		}
+		if coordinator.MatchesPattern(path, r.PathPattern) {
+			byHolder[r.AgentName] = append(byHolder[r.AgentName], r.ID)
+		}
+	}
+	var released []string
+	for name, ids := range byHolder {
+		if err := client.ReleaseReservations(ctx, root, name, nil, ids); err != nil {
+			return released, err
+		}
+		released = append(released, name)
+	}
+	return released, nil
+}
+
+// writePreservingMode writes data to path, keeping its permissions when it
+// already exists.
+func writePreservingMode(path string, data []byte) error {
+	mode := os.FileMode(0644)
+	if fi, err := os.Stat(path); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, mode)
+}
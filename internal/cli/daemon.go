@@ -0,0 +1,426 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shahbajlive/ntm/internal/daemon"
+	"github.com/shahbajlive/ntm/internal/output"
+	"github.com/shahbajlive/ntm/internal/process"
+	"github.com/shahbajlive/ntm/internal/resilience"
+	"github.com/shahbajlive/ntm/internal/tmux"
+)
+
+const (
+	// daemonStartTimeout bounds how long ensureDaemon waits for a daemon it
+	// started to answer.
+	daemonStartTimeout = 5 * time.Second
+	// daemonStopTimeout bounds how long stop waits for the daemon to exit.
+	daemonStopTimeout = 15 * time.Second
+	// daemonQueryTimeout bounds status queries made on behalf of other
+	// commands, such as the dashboard checking who runs its watchers.
+	daemonQueryTimeout = 500 * time.Millisecond
+)
+
+// DaemonStatusOutput is the JSON output for daemon status.
+type DaemonStatusOutput struct {
+	output.TimestampedResponse
+	Running bool           `json:"running"`
+	Enabled bool           `json:"enabled"`
+	Socket  string         `json:"socket"`
+	Log     string         `json:"log"`
+	Daemon  *daemon.Status `json:"daemon,omitempty"`
+}
+
+// daemonEnabled reports whether sessions are handed to ntmd.
+func daemonEnabled() bool {
+	return cfg == nil || cfg.Daemon.Enabled
+}
+
+// discoverDaemonSessions lists the sessions ntmd should host: those with a
+// spawn manifest whose tmux session is still running and whose loops are not
+// already run by a live per-session monitor.
+func discoverDaemonSessions() []string {
+	sessions, err := resilience.ListManifests()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Listing session manifests: %v\n", err)
+		return nil
+	}
+	var out []string
+	for _, s := range sessions {
+		if !tmux.SessionExists(s) {
+			continue
+		}
+		if m, err := resilience.LoadManifest(s); err == nil && process.IsAlive(m.MonitorPID) {
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// startDaemonProcess launches 'ntm daemon run' detached from this process,
+// logging to daemon.LogPath().
+func startDaemonProcess() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("locate ntm binary: %w", err)
+	}
+	logPath := daemon.LogPath()
+	if err := os.MkdirAll(filepath.Dir(logPath), 0o700); err != nil {
+		return fmt.Errorf("create daemon dir: %w", err)
+	}
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("open daemon log: %w", err)
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(exe, "daemon", "run")
+	cmd.Stdout, cmd.Stderr = logFile, logFile
+	setDetachedProcess(cmd)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start ntmd: %w", err)
+	}
+	// Reap the child if it exits early (e.g. another daemon won the race);
+	// otherwise it runs on after this process exits.
+	go func() { _ = cmd.Wait() }()
+	return nil
+}
+
+// ensureDaemon returns a client for a running daemon, starting one if none
+// answers.
+func ensureDaemon(ctx context.Context) (*daemon.Client, *daemon.Status, error) {
+	c := daemon.NewClient("")
+	st, err := c.Status(ctx)
+	if err == nil {
+		return c, st, nil
+	}
+	if !errors.Is(err, daemon.ErrNotRunning) {
+		return nil, nil, err
+	}
+	if err := startDaemonProcess(); err != nil {
+		return nil, nil, err
+	}
+	st, err = c.WaitReady(ctx, daemonStartTimeout)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ntmd did not start (see %s): %w", daemon.LogPath(), err)
+	}
+	return c, st, nil
+}
+
+// attachSessionToDaemon hands session's loops to ntmd, starting it if
+// needed. Callers fall back to a per-session monitor on error.
+func attachSessionToDaemon(session string) (*daemon.Status, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*daemonStartTimeout)
+	defer cancel()
+	c, st, err := ensureDaemon(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.Attach(ctx, session); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// daemonSessionStatus returns the loops a running ntmd hosts for session,
+// or nil when it does not host it.
+func daemonSessionStatus(session string) *daemon.SessionStatus {
+	if !daemonEnabled() {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), daemonQueryTimeout)
+	defer cancel()
+	st, err := daemon.NewClient("").Status(ctx)
+	if err != nil {
+		return nil
+	}
+	for i := range st.Sessions {
+		if st.Sessions[i].Session == session {
+			return &st.Sessions[i]
+		}
+	}
+	return nil
+}
+
+func newDaemonCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Manage ntmd, the background daemon hosting session monitors",
+		Long: `ntmd is a per-user background process that runs the long-lived loops of
+every session: the resilience monitor, archiver, prompt queue, schedules,
+spend meter, permission prompt watcher, quota forecaster, cross-agent
+review, periodic checkpoints, file reservation watcher and, with
+[daemon] coordinator = true, the session coordinator.
+
+'ntm spawn' starts ntmd on demand and hands it the new session; ntmd also
+picks up any running session with a spawn manifest whose loops are not
+already run by a per-session monitor, and leaves a session detached through
+its API alone until it is attached again. Loops stop when their session ends. The CLI, dashboard and 'ntm serve' reach it on a Unix socket
+(default ~/.ntm/daemon/ntmd.sock, or $NTM_DAEMON_SOCKET).
+
+  [daemon]
+  enabled = true          # false: one 'internal-monitor' process per session
+  coordinator = false
+  scan_interval_sec = 10
+
+Examples:
+  ntm daemon status
+  ntm daemon restart
+  ntm daemon logs -f`,
+	}
+
+	cmd.AddCommand(
+		newDaemonRunCmd(),
+		newDaemonStartCmd(),
+		newDaemonStopCmd(),
+		newDaemonRestartCmd(),
+		newDaemonStatusCmd(),
+		newDaemonLogsCmd(),
+	)
+	return cmd
+}
+
+func newDaemonRunCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "run",
+		Short: "Run ntmd in the foreground",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			interval := daemon.DefaultScanInterval
+			if cfg != nil && cfg.Daemon.ScanIntervalSec > 0 {
+				interval = time.Duration(cfg.Daemon.ScanIntervalSec) * time.Second
+			}
+			srv := daemon.NewServer(daemon.Options{
+				Version:      Version,
+				Run:          runSessionLoops,
+				Discover:     discoverDaemonSessions,
+				ScanInterval: interval,
+			})
+			err := srv.ListenAndServe(ctx)
+			if errors.Is(err, os.ErrExist) {
+				// Another invocation started a daemon first; use that one.
+				fmt.Fprintln(os.Stderr, err)
+				return nil
+			}
+			return err
+		},
+	}
+}
+
+func newDaemonStartCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "start",
+		Short: "Start ntmd in the background if it is not running",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*daemonStartTimeout)
+			defer cancel()
+			_, st, err := ensureDaemon(ctx)
+			if err != nil {
+				return err
+			}
+			return printDaemonStatus(st)
+		},
+	}
+}
+
+// stopDaemon asks a running daemon to exit and waits for it. It reports
+// whether one was running.
+func stopDaemon(ctx context.Context) (bool, error) {
+	c := daemon.NewClient("")
+	if err := c.Shutdown(ctx); err != nil {
+		if errors.Is(err, daemon.ErrNotRunning) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, c.WaitStopped(ctx, daemonStopTimeout)
+}
+
+func newDaemonStopCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "stop",
+		Short: "Stop ntmd and the session loops it hosts",
+		Long: `Stop ntmd. Sessions keep running, but their monitors, queues and watchers
+stop until ntmd is started again, which 'ntm spawn' and 'ntm daemon start' do.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithTimeout(context.Background(), daemonStopTimeout+time.Second)
+			defer cancel()
+			wasRunning, err := stopDaemon(ctx)
+			if err != nil {
+				return err
+			}
+			if IsJSONOutput() {
+				return output.PrintJSON(DaemonStatusOutput{
+					TimestampedResponse: output.NewTimestamped(),
+					Enabled:             daemonEnabled(),
+					Socket:              daemon.SocketPath(),
+					Log:                 daemon.LogPath(),
+				})
+			}
+			if !wasRunning {
+				output.PrintInfof("ntmd is not running")
+				return nil
+			}
+			fmt.Println("ntmd stopped")
+			return nil
+		},
+	}
+}
+
+func newDaemonRestartCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "restart",
+		Short: "Restart ntmd, e.g. after upgrading ntm or changing config",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithTimeout(context.Background(), daemonStopTimeout+2*daemonStartTimeout)
+			defer cancel()
+			if _, err := stopDaemon(ctx); err != nil {
+				return err
+			}
+			_, st, err := ensureDaemon(ctx)
+			if err != nil {
+				return err
+			}
+			return printDaemonStatus(st)
+		},
+	}
+}
+
+func newDaemonStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show whether ntmd is running and the sessions it hosts",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			st, err := daemon.NewClient("").Status(ctx)
+			if err != nil && !errors.Is(err, daemon.ErrNotRunning) {
+				return err
+			}
+			return printDaemonStatus(st)
+		},
+	}
+}
+
+// printDaemonStatus prints st, or that the daemon is not running when st
+// is nil.
+func printDaemonStatus(st *daemon.Status) error {
+	out := DaemonStatusOutput{
+		TimestampedResponse: output.NewTimestamped(),
+		Running:             st != nil,
+		Enabled:             daemonEnabled(),
+		Socket:              daemon.SocketPath(),
+		Log:                 daemon.LogPath(),
+		Daemon:              st,
+	}
+	if IsJSONOutput() {
+		return output.PrintJSON(out)
+	}
+
+	if st == nil {
+		fmt.Printf("ntmd is not running (socket %s)\n", out.Socket)
+		if !out.Enabled {
+			output.PrintInfof("The daemon is disabled; sessions use per-session monitors ([daemon] enabled = false)")
+		}
+		return nil
+	}
+	fmt.Printf("ntmd running: pid %d, version %s, up %s\n", st.PID, st.Version, time.Since(st.StartedAt).Round(time.Second))
+	fmt.Printf("  socket: %s\n  log:    %s\n", st.Socket, out.Log)
+	if len(st.Sessions) == 0 {
+		fmt.Println("  No sessions hosted")
+		return nil
+	}
+	fmt.Printf("  %d session(s):\n", len(st.Sessions))
+	for _, s := range st.Sessions {
+		fmt.Printf("    %-24s up %-10s %s\n", s.Session, time.Since(s.StartedAt).Round(time.Second), strings.Join(s.Loops, ", "))
+	}
+	return nil
+}
+
+func newDaemonLogsCmd() *cobra.Command {
+	var (
+		lines  int
+		follow bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "logs",
+		Short: "Show ntmd's log",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return tailDaemonLog(ctx, cmd.OutOrStdout(), daemon.LogPath(), lines, follow)
+		},
+	}
+
+	cmd.Flags().IntVarP(&lines, "lines", "n", 50, "Number of most recent lines to show (0 for all)")
+	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "Keep printing lines as they are written")
+	return cmd
+}
+
+// tailDaemonLog writes the last n lines of path to w and, with follow,
+// keeps copying what is appended until ctx is done.
+func tailDaemonLog(ctx context.Context, w io.Writer, path string, n int, follow bool) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no daemon log at %s; ntmd has not been started", path)
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var tail []string
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		tail = append(tail, sc.Text())
+		if n > 0 && len(tail) > n {
+			tail = tail[1:]
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	for _, line := range tail {
+		fmt.Fprintln(w, line)
+	}
+	if !follow {
+		return nil
+	}
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if _, err := io.Copy(w, f); err != nil {
+				return err
+			}
+		}
+	}
+}
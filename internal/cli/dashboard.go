@@ -7,6 +7,7 @@ import (
 	"io"
 	"log"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -14,7 +15,9 @@ import (
 
 	"github.com/shahbajlive/ntm/internal/agentmail"
 	"github.com/shahbajlive/ntm/internal/config"
+	"github.com/shahbajlive/ntm/internal/daemon"
 	"github.com/shahbajlive/ntm/internal/tmux"
+	"github.com/shahbajlive/ntm/internal/tracker"
 	"github.com/shahbajlive/ntm/internal/tui/dashboard"
 	"github.com/shahbajlive/ntm/internal/watcher"
 )
@@ -23,6 +26,7 @@ func newDashboardCmd() *cobra.Command {
 	var noTUI bool
 	var jsonOutput bool
 	var debug bool
+	var fleetView bool
 
 	cmd := &cobra.Command{
 		Use:     "dashboard [session-name]",
@@ -43,6 +47,7 @@ Flags:
   --no-tui    Plain text output (no interactive UI)
   --json      JSON output (implies --no-tui)
   --debug     Enable debug mode with state inspection
+  --fleet     Overview of every session (same as ntm fleet)
 
 Environment:
   CI=1              Auto-selects plain mode
@@ -55,6 +60,7 @@ Examples:
   ntm dash                  # Auto-detect session
   ntm dashboard --no-tui    # Plain text output for scripting
   ntm dashboard --json      # JSON output for automation
+  ntm dashboard --fleet     # All sessions at once
   CI=1 ntm dashboard        # Auto-detects plain mode in CI`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -63,6 +69,10 @@ Examples:
 				session = args[0]
 			}
 
+			if fleetView {
+				return runFleet(cmd.OutOrStdout(), cmd.ErrOrStderr(), jsonOutput, noTUI)
+			}
+
 			// JSON implies no-tui
 			if jsonOutput {
 				noTUI = true
@@ -91,6 +101,7 @@ Examples:
 	cmd.Flags().BoolVar(&noTUI, "no-tui", false, "Plain text output (no interactive UI)")
 	cmd.Flags().BoolVar(&jsonOutput, "json", false, "JSON output (implies --no-tui)")
 	cmd.Flags().BoolVar(&debug, "debug", false, "Enable debug mode with state inspection")
+	cmd.Flags().BoolVar(&fleetView, "fleet", false, "Show the fleet overview of every session")
 	cmd.ValidArgsFunction = completeSessionArgs
 
 	return cmd
@@ -270,6 +281,20 @@ func runDashboard(w io.Writer, errW io.Writer, session string, debug bool) error
 		return fmt.Errorf("session '%s' not found", session)
 	}
 
+	action, err := runSessionDashboard(errW, session)
+	if err != nil {
+		return err
+	}
+	if action != nil && action.AttachSession != "" {
+		return tmux.AttachOrSwitch(action.AttachSession)
+	}
+	return nil
+}
+
+// runSessionDashboard runs the per-session dashboard TUI, with the file
+// reservation and attribution watchers when enabled and ntmd is not already
+// running them, and returns the user's post-quit action.
+func runSessionDashboard(errW io.Writer, session string) (*dashboard.PostQuitAction, error) {
 	projectDir := ""
 	if cfg != nil {
 		projectDir = cfg.GetProjectDir(session)
@@ -285,69 +310,162 @@ func runDashboard(w io.Writer, errW io.Writer, session string, debug bool) error
 		fmt.Fprintf(errW, "Check your projects_base setting in config: ntm config show\n\n")
 	}
 
-	// Start FileReservationWatcher if enabled and Agent Mail is available
+	detectFrom := reservationDetectFrom()
+
+	// ntmd runs the reservation and attribution watchers for the sessions it
+	// hosts; second ones here would poll Agent Mail and reserve the same
+	// edits again, and walk the process table twice.
+	var loops []string
+	hosted := false
+	if st := daemonSessionStatus(session); st != nil {
+		hosted, loops = true, st.Loops
+	}
 	var reservationWatcher *watcher.FileReservationWatcher
-	if cfg != nil && cfg.FileReservation.Enabled && cfg.AgentMail.Enabled {
-		// Create Agent Mail client with config options
-		amOpts := []agentmail.Option{
-			agentmail.WithBaseURL(cfg.AgentMail.URL),
-			agentmail.WithProjectKey(projectDir),
-		}
-		if cfg.AgentMail.Token != "" {
-			amOpts = append(amOpts, agentmail.WithToken(cfg.AgentMail.Token))
+	if !hosted {
+		reservationWatcher = startReservationWatcher(context.Background(), session, projectDir, detectFrom)
+		if reservationWatcher != nil {
+			defer reservationWatcher.Stop()
 		}
-		amClient := agentmail.NewClient(amOpts...)
-
-		// Check if Agent Mail is reachable
-		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-		if _, err := amClient.HealthCheck(ctx); err == nil {
-			// Convert config to watcher config values
-			cfgValues := watcher.FileReservationConfigValues{
-				Enabled:               cfg.FileReservation.Enabled,
-				AutoReserve:           cfg.FileReservation.AutoReserve,
-				AutoReleaseIdleMin:    cfg.FileReservation.AutoReleaseIdleMin,
-				NotifyOnConflict:      cfg.FileReservation.NotifyOnConflict,
-				ExtendOnActivity:      cfg.FileReservation.ExtendOnActivity,
-				DefaultTTLMin:         cfg.FileReservation.DefaultTTLMin,
-				PollIntervalSec:       cfg.FileReservation.PollIntervalSec,
-				CaptureLinesForDetect: cfg.FileReservation.CaptureLinesForDetect,
-				Debug:                 cfg.FileReservation.Debug,
-			}
+	}
 
-			// Create conflict callback for notifications
-			conflictCallback := func(conflict watcher.FileConflict) {
-				if cfg.FileReservation.Debug {
-					log.Printf("[FileReservation] Conflict: %s requested by %s, held by %v",
-						conflict.Path, conflict.RequestorAgent, conflict.Holders)
+	// Attribute file changes to agent panes for the files panel, conflict
+	// detection and reservations.
+	if slices.Contains(loops, attributionLoop) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go followDaemonChanges(ctx, session)
+	} else if detectFrom != watcher.DetectFromOutput {
+		debug := cfg != nil && cfg.FileReservation.Debug
+		attribution := watcher.NewFileAttributionWatcher(session, projectDir,
+			watcher.WithAttributionDebug(debug),
+			watcher.WithAttributionHandler(func(changes []watcher.FileAttribution) {
+				if reservationWatcher != nil {
+					reservationWatcher.OnAttributedChanges(context.Background(), changes)
 				}
-				// TODO: Integrate with dashboard notification system
+			}),
+		)
+		if err := attribution.Start(context.Background()); err != nil {
+			if debug {
+				log.Printf("[FileAttribution] Not started: %v", err)
 			}
+		} else {
+			defer attribution.Stop()
+		}
+	}
 
-			reservationWatcher = watcher.NewFileReservationWatcherFromConfig(
-				cfgValues,
-				amClient,
-				projectDir,
-				session, // Use session name as agent name
-				conflictCallback,
-			)
-
-			if reservationWatcher != nil {
-				reservationWatcher.Start(context.Background())
-				defer reservationWatcher.Stop()
-				if cfg.FileReservation.Debug {
-					log.Printf("[FileReservation] Watcher started for session %s", session)
+	return dashboard.Run(session, projectDir)
+}
+
+// attributionLoop names the file attribution watcher among a session's
+// loops.
+const attributionLoop = "attribution"
+
+// daemonChangesInterval is how often a dashboard fetches the file changes
+// ntmd attributed in its session.
+const daemonChangesInterval = 2 * time.Second
+
+// followDaemonChanges copies the file changes ntmd attributes in session
+// into the local change store until ctx is done.
+func followDaemonChanges(ctx context.Context, session string) {
+	client := daemon.NewClient("")
+	var since time.Time
+	ticker := time.NewTicker(daemonChangesInterval)
+	defer ticker.Stop()
+	for {
+		qctx, cancel := context.WithTimeout(ctx, daemonQueryTimeout)
+		changes, err := client.Changes(qctx, session, since)
+		cancel()
+		if err == nil {
+			for _, c := range changes {
+				tracker.GlobalFileChanges.Add(c)
+				if c.Timestamp.After(since) {
+					since = c.Timestamp
 				}
 			}
 		}
-		cancel()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
 	}
+}
+
+// reservationDetectFrom returns how edits are attributed to agents: by
+// scanning pane output and, where /proc is available, from filesystem
+// events.
+func reservationDetectFrom() string {
+	detectFrom := watcher.DetectFromBoth
+	if cfg != nil && cfg.FileReservation.DetectFrom != "" {
+		detectFrom = cfg.FileReservation.DetectFrom
+	}
+	if detectFrom != watcher.DetectFromOutput && !watcher.ProcessAttributionAvailable() {
+		detectFrom = watcher.DetectFromOutput
+	}
+	return detectFrom
+}
+
+// startReservationWatcher starts the file reservation watcher for session
+// if it is enabled and Agent Mail is reachable, and returns nil otherwise.
+// The caller stops the returned watcher.
+func startReservationWatcher(ctx context.Context, session, projectDir, detectFrom string) *watcher.FileReservationWatcher {
+	if cfg == nil || !cfg.FileReservation.Enabled || !cfg.AgentMail.Enabled {
+		return nil
+	}
+	// Create Agent Mail client with config options
+	amOpts := []agentmail.Option{
+		agentmail.WithBaseURL(cfg.AgentMail.URL),
+		agentmail.WithProjectKey(projectDir),
+	}
+	if cfg.AgentMail.Token != "" {
+		amOpts = append(amOpts, agentmail.WithToken(cfg.AgentMail.Token))
+	}
+	amClient := agentmail.NewClient(amOpts...)
 
-	action, err := dashboard.Run(session, projectDir)
+	// Check if Agent Mail is reachable
+	healthCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	_, err := amClient.HealthCheck(healthCtx)
+	cancel()
 	if err != nil {
-		return err
+		return nil
 	}
-	if action != nil && action.AttachSession != "" {
-		return tmux.AttachOrSwitch(action.AttachSession)
+
+	// Convert config to watcher config values
+	cfgValues := watcher.FileReservationConfigValues{
+		Enabled:               cfg.FileReservation.Enabled,
+		AutoReserve:           cfg.FileReservation.AutoReserve,
+		AutoReleaseIdleMin:    cfg.FileReservation.AutoReleaseIdleMin,
+		NotifyOnConflict:      cfg.FileReservation.NotifyOnConflict,
+		ExtendOnActivity:      cfg.FileReservation.ExtendOnActivity,
+		DefaultTTLMin:         cfg.FileReservation.DefaultTTLMin,
+		PollIntervalSec:       cfg.FileReservation.PollIntervalSec,
+		CaptureLinesForDetect: cfg.FileReservation.CaptureLinesForDetect,
+		DetectFrom:            detectFrom,
+		Debug:                 cfg.FileReservation.Debug,
 	}
-	return nil
+
+	// Create conflict callback for notifications
+	conflictCallback := func(conflict watcher.FileConflict) {
+		if cfg.FileReservation.Debug {
+			log.Printf("[FileReservation] Conflict: %s requested by %s, held by %v",
+				conflict.Path, conflict.RequestorAgent, conflict.Holders)
+		}
+		// TODO: Integrate with dashboard notification system
+	}
+
+	w := watcher.NewFileReservationWatcherFromConfig(
+		cfgValues,
+		amClient,
+		projectDir,
+		session, // Use session name as agent name
+		conflictCallback,
+	)
+	if w == nil {
+		return nil
+	}
+	w.Start(ctx)
+	if cfg.FileReservation.Debug {
+		log.Printf("[FileReservation] Watcher started for session %s", session)
+	}
+	return w
 }
@@ -0,0 +1,396 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shahbajlive/ntm/internal/agent"
+	"github.com/shahbajlive/ntm/internal/output"
+	"github.com/shahbajlive/ntm/internal/status"
+	"github.com/shahbajlive/ntm/internal/tmux"
+	"github.com/shahbajlive/ntm/internal/util"
+)
+
+// repoCorpusDir is the corpus checked in with ntm's source. When ntm runs
+// from a checkout, captures are labeled straight into it.
+const repoCorpusDir = "internal/agent/testdata/corpus"
+
+// DetectLabelOutput is the JSON output for detect label.
+type DetectLabelOutput struct {
+	output.TimestampedResponse
+	Session    string           `json:"session"`
+	Pane       int              `json:"pane"`
+	Label      agent.StateLabel `json:"label"`
+	Path       string           `json:"path"`
+	Prediction agent.Prediction `json:"prediction"`
+}
+
+// DetectTrainOutput is the JSON output for detect train.
+type DetectTrainOutput struct {
+	output.TimestampedResponse
+	Corpus   string  `json:"corpus"`
+	Model    string  `json:"model"`
+	Samples  int     `json:"samples"`
+	Features int     `json:"features"`
+	Folds    int     `json:"folds"`
+	Accuracy float64 `json:"accuracy"` // Cross-validated on held-out folds
+}
+
+// DetectReportOutput is the JSON output for detect report.
+type DetectReportOutput struct {
+	output.TimestampedResponse
+	Corpus  string         `json:"corpus"`
+	Folds   int            `json:"folds"` // Classifier scored by k-fold cross-validation
+	Samples int            `json:"samples"`
+	Reports []agent.Report `json:"reports"`
+}
+
+// defaultCorpusDir returns the checked-in corpus when run from an ntm
+// checkout, and ~/.ntm/detect/corpus otherwise.
+func defaultCorpusDir() (string, error) {
+	if fi, err := os.Stat(repoCorpusDir); err == nil && fi.IsDir() {
+		return repoCorpusDir, nil
+	}
+	dir, err := util.NTMDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "detect", "corpus"), nil
+}
+
+// regexStateLabel is the label the regex detectors give a capture:
+// compaction patterns first, then the parser's state flags. The regexes
+// have no notion of a permission prompt.
+func regexStateLabel(parser agent.Parser, s agent.Sample) agent.StateLabel {
+	if status.HasCompaction(s.Text, string(s.Agent)) {
+		return agent.LabelCompacting
+	}
+	hint := s.Agent
+	if !hint.IsValid() {
+		hint = agent.AgentTypeUnknown
+	}
+	st, err := parser.ParseWithHint(s.Text, hint)
+	if err != nil {
+		return ""
+	}
+	return st.RegexLabel()
+}
+
+func newDetectCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "detect",
+		Short: "Label pane captures and train the agent-state classifier",
+		Long: `Agent state (idle, working, error, rate limited, ...) is detected with
+per-agent regexes. A small classifier trained on labeled pane captures is
+consulted alongside them: when it agrees with the regexes the confidence of
+the reading goes up, when it disagrees it goes down.
+
+The corpus is a directory of captures, one subdirectory per label:
+
+  <corpus>/<label>/<agent>_<name>.txt
+
+Labels: ` + strings.Join(labelNames(), ", ") + `
+
+Examples:
+  ntm detect label myproject:2 --label rate_limited
+  ntm detect train --out internal/agent/classifier_model.json
+  ntm detect report`,
+	}
+
+	cmd.AddCommand(newDetectLabelCmd(), newDetectTrainCmd(), newDetectReportCmd())
+	return cmd
+}
+
+func labelNames() []string {
+	names := make([]string, len(agent.StateLabels))
+	for i, l := range agent.StateLabels {
+		names[i] = string(l)
+	}
+	return names
+}
+
+func newDetectLabelCmd() *cobra.Command {
+	var (
+		label  string
+		name   string
+		corpus string
+		lines  int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "label <session:pane>",
+		Short: "Capture a pane and save it to the corpus under a label",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			l, err := agent.ParseStateLabel(label)
+			if err != nil {
+				return err
+			}
+			if lines <= 0 {
+				return fmt.Errorf("--lines must be positive")
+			}
+			if corpus == "" {
+				if corpus, err = defaultCorpusDir(); err != nil {
+					return err
+				}
+			}
+
+			session, selector, ok := strings.Cut(args[0], ":")
+			if !ok || selector == "" {
+				return fmt.Errorf("pane is required: use session:pane")
+			}
+			if err := tmux.EnsureInstalled(); err != nil {
+				return err
+			}
+			if !tmux.SessionExists(session) {
+				return fmt.Errorf("session '%s' not found", session)
+			}
+			panes, err := tmux.GetPanes(session)
+			if err != nil {
+				return err
+			}
+			var pane *tmux.Pane
+			for i := range panes {
+				if paneMatchesSelector(panes[i], selector) {
+					pane = &panes[i]
+					break
+				}
+			}
+			if pane == nil {
+				return fmt.Errorf("pane %q not found in session '%s'", selector, session)
+			}
+
+			text, err := tmux.CapturePaneOutput(pane.ID, lines)
+			if err != nil {
+				return fmt.Errorf("capture pane %d: %w", pane.Index, err)
+			}
+			if name == "" {
+				name = fmt.Sprintf("%s-%d-%s", session, pane.Index, time.Now().Format("20060102-150405"))
+			}
+			path, err := agent.SaveSample(corpus, agent.Sample{Label: l, Agent: pane.Type, Name: name, Text: text})
+			if err != nil {
+				return err
+			}
+
+			out := DetectLabelOutput{
+				TimestampedResponse: output.NewTimestamped(),
+				Session:             session,
+				Pane:                pane.Index,
+				Label:               l,
+				Path:                path,
+			}
+			if c := agent.DefaultClassifier(); c != nil {
+				out.Prediction = c.Predict(text)
+			}
+			if IsJSONOutput() {
+				return output.PrintJSON(out)
+			}
+			fmt.Printf("Saved %s capture of %s:%d to %s\n", l, session, pane.Index, path)
+			if p := out.Prediction; p.Label != "" && p.Label != l {
+				output.PrintInfof("The current model reads this capture as %s (%.0f%%); retrain with 'ntm detect train'", p.Label, p.Confidence*100)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&label, "label", "", "State label: "+strings.Join(labelNames(), ", "))
+	cmd.Flags().StringVar(&name, "name", "", "Sample name (default: session, pane and time)")
+	cmd.Flags().StringVar(&corpus, "corpus", "", "Corpus directory (default: the checked-in corpus, else ~/.ntm/detect/corpus)")
+	cmd.Flags().IntVarP(&lines, "lines", "l", agent.DefaultTailLines, "Number of most recent lines to capture")
+	_ = cmd.MarkFlagRequired("label")
+	cmd.ValidArgsFunction = completeSessionColonPane
+	return cmd
+}
+
+func newDetectTrainCmd() *cobra.Command {
+	var (
+		corpus string
+		out    string
+		epochs int
+		folds  int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "train",
+		Short: "Train the classifier on the corpus and write the model as JSON",
+		Long: `Train a logistic regression over word n-grams of the corpus captures.
+Training is deterministic: the same corpus produces the same model. The
+reported accuracy is k-fold cross-validated: each fold is scored by a model
+trained without it. To ship a retrained model, write it over
+internal/agent/classifier_model.json.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			if corpus == "" {
+				if corpus, err = defaultCorpusDir(); err != nil {
+					return err
+				}
+			}
+			if out == "" {
+				dir, err := util.NTMDir()
+				if err != nil {
+					return err
+				}
+				if err := os.MkdirAll(filepath.Join(dir, "detect"), 0o755); err != nil {
+					return err
+				}
+				out = filepath.Join(dir, "detect", "classifier.json")
+			}
+
+			samples, err := agent.LoadCorpus(corpus)
+			if err != nil {
+				return err
+			}
+			opts := agent.DefaultTrainOptions()
+			if epochs > 0 {
+				opts.Epochs = epochs
+			}
+			c, err := agent.Train(samples, opts)
+			if err != nil {
+				return err
+			}
+			if err := c.Save(out); err != nil {
+				return err
+			}
+			report, err := agent.CrossValidate("classifier", samples, folds, opts)
+			if err != nil {
+				return err
+			}
+
+			result := DetectTrainOutput{
+				TimestampedResponse: output.NewTimestamped(),
+				Corpus:              corpus,
+				Model:               out,
+				Samples:             len(samples),
+				Features:            len(c.Weights),
+				Folds:               folds,
+				Accuracy:            report.Accuracy,
+			}
+			if IsJSONOutput() {
+				return output.PrintJSON(result)
+			}
+			fmt.Printf("Trained on %d samples (%d features), %.0f%% accurate in %d-fold cross-validation\n",
+				result.Samples, result.Features, result.Accuracy*100, result.Folds)
+			fmt.Printf("Model written to %s\n", out)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&corpus, "corpus", "", "Corpus directory (default: the checked-in corpus, else ~/.ntm/detect/corpus)")
+	cmd.Flags().StringVarP(&out, "out", "o", "", "Model path (default: ~/.ntm/detect/classifier.json)")
+	cmd.Flags().IntVar(&epochs, "epochs", 0, "Training epochs (default 300)")
+	cmd.Flags().IntVar(&folds, "folds", agent.DefaultFolds, "Cross-validation folds for the reported accuracy")
+	return cmd
+}
+
+func newDetectReportCmd() *cobra.Command {
+	var (
+		corpus string
+		folds  int
+		misses bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Compare regex and classifier accuracy on the corpus",
+		Long: `Score the regex detectors and the classifier against the corpus labels.
+The classifier is scored by k-fold cross-validation: the corpus is split into
+folds by label, and each fold is predicted by a model trained on the rest, so
+no capture is scored by a model that has seen it.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			if corpus == "" {
+				if corpus, err = defaultCorpusDir(); err != nil {
+					return err
+				}
+			}
+			samples, err := agent.LoadCorpus(corpus)
+			if err != nil {
+				return err
+			}
+			classifier, err := agent.CrossValidate("classifier", samples, folds, agent.DefaultTrainOptions())
+			if err != nil {
+				return err
+			}
+
+			// The regex reading must not be blended with the classifier's.
+			cfg := agent.DefaultParserConfig()
+			cfg.Classifier = nil
+			parser := agent.NewParserWithConfig(cfg)
+
+			out := DetectReportOutput{
+				TimestampedResponse: output.NewTimestamped(),
+				Corpus:              corpus,
+				Folds:               folds,
+				Samples:             len(samples),
+				Reports: []agent.Report{
+					agent.Score("regex", samples, func(s agent.Sample) agent.StateLabel {
+						return regexStateLabel(parser, s)
+					}),
+					classifier,
+				},
+			}
+			if IsJSONOutput() {
+				return output.PrintJSON(out)
+			}
+
+			fmt.Printf("Corpus %s: %d samples, classifier %d-fold cross-validated\n\n", corpus, len(samples), folds)
+			fmt.Printf("%-20s", "LABEL")
+			for _, r := range out.Reports {
+				fmt.Printf("  %-22s", strings.ToUpper(r.Detector)+" P/R")
+			}
+			fmt.Println()
+			for _, l := range agent.StateLabels {
+				fmt.Printf("%-20s", l)
+				for _, r := range out.Reports {
+					fmt.Printf("  %-22s", formatLabelScore(r, l))
+				}
+				fmt.Println()
+			}
+			fmt.Printf("%-20s", "accuracy")
+			for _, r := range out.Reports {
+				fmt.Printf("  %-22s", fmt.Sprintf("%.0f%% (%d/%d)", r.Accuracy*100, r.Correct, r.Total))
+			}
+			fmt.Println()
+
+			if misses {
+				for _, r := range out.Reports {
+					if len(r.Misses) == 0 {
+						continue
+					}
+					fmt.Printf("\n%s misses:\n", r.Detector)
+					for _, m := range r.Misses {
+						predicted := string(m.Predicted)
+						if predicted == "" {
+							predicted = "(none)"
+						}
+						fmt.Printf("  %-60s %s -> %s\n", m.Path, m.Label, predicted)
+					}
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&corpus, "corpus", "", "Corpus directory (default: the checked-in corpus, else ~/.ntm/detect/corpus)")
+	cmd.Flags().IntVar(&folds, "folds", agent.DefaultFolds, "Cross-validation folds for the classifier")
+	cmd.Flags().BoolVar(&misses, "misses", false, "List misclassified samples")
+	return cmd
+}
+
+// formatLabelScore renders a label's precision and recall, or "-" when the
+// label has no samples and no predictions.
+func formatLabelScore(r agent.Report, l agent.StateLabel) string {
+	for _, ls := range r.Labels {
+		if ls.Label == l {
+			return fmt.Sprintf("%.2f / %.2f (%d)", ls.Precision, ls.Recall, ls.Support)
+		}
+	}
+	return "-"
+}
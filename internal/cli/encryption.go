@@ -0,0 +1,332 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shahbajlive/ntm/internal/config"
+	"github.com/shahbajlive/ntm/internal/encryption"
+	"github.com/shahbajlive/ntm/internal/events"
+	"github.com/shahbajlive/ntm/internal/history"
+	"github.com/shahbajlive/ntm/internal/output"
+	"github.com/shahbajlive/ntm/internal/redaction"
+	"github.com/shahbajlive/ntm/internal/util"
+)
+
+// EncryptionRotateResponse is the JSON output for encryption rotate.
+type EncryptionRotateResponse struct {
+	output.TimestampedResponse
+	KeyID       string                   `json:"key_id"`
+	Resumed     bool                     `json:"resumed"`
+	Files       []encryption.RotatedFile `json:"files"`
+	Records     int                      `json:"records"`
+	Failed      map[string]string        `json:"failed,omitempty"`
+	RetiredKeys []string                 `json:"retired_keys,omitempty"`
+	StatePath   string                   `json:"state_path,omitempty"`
+}
+
+func newEncryptionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "encryption",
+		Short: "Manage encryption at rest",
+	}
+	cmd.AddCommand(newEncryptionRotateCmd())
+	return cmd
+}
+
+func newEncryptionRotateCmd() *cobra.Command {
+	var (
+		keyID     string
+		keyFile   string
+		paths     []string
+		resume    bool
+		retireOld bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "rotate",
+		Short: "Rotate the encryption key and re-encrypt stored artifacts",
+		Long: `Rotate the active encryption key and re-encrypt everything ntm has stored.
+
+A new key is generated (or adopted with --key-file), added to
+[encryption.keyring] of the config file in use and made active. The event
+log, prompt history and secret vault are then re-encrypted in place; each
+file is replaced atomically and every record is verified to decrypt with
+the new key. Checkpoints and transcript archives are stored unencrypted and
+are left alone.
+Use --path to include other encrypted files.
+
+Progress is saved after each file. If the rotation is interrupted or a file
+fails, fix the problem and run 'ntm encryption rotate --resume'.
+
+Old keys stay in the keyring so anything not covered by the rotation remains
+readable. --retire-old removes them once every file has been verified.
+
+Stop running sessions and monitors first: files written by other ntm
+processes during the rotation may keep using the old key.
+
+Examples:
+  ntm encryption rotate
+  ntm encryption rotate --key-id 2026q4 --path ~/backups/ntm-archive.jsonl
+  ntm encryption rotate --resume --retire-old`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runEncryptionRotate(keyID, keyFile, paths, resume, retireOld)
+		},
+	}
+
+	cmd.Flags().StringVar(&keyID, "key-id", "", "ID for the new key (default: k<timestamp>)")
+	cmd.Flags().StringVar(&keyFile, "key-file", "", "Adopt the key in this file instead of generating one")
+	cmd.Flags().StringSliceVar(&paths, "path", nil, "Additional file or directory to re-encrypt (repeatable)")
+	cmd.Flags().BoolVar(&resume, "resume", false, "Resume an interrupted rotation")
+	cmd.Flags().BoolVar(&retireOld, "retire-old", false, "Remove old keys from the keyring after a verified rotation")
+	return cmd
+}
+
+// encryptionConfigPath is the config file rotation reads and updates: the
+// --config file when given, else the default.
+func encryptionConfigPath() string {
+	if cfgFile != "" {
+		return cfgFile
+	}
+	return config.DefaultPath()
+}
+
+// encryptionRotationStatePath is where rotation progress is kept, next to
+// the config file holding the keyring.
+func encryptionRotationStatePath() string {
+	return filepath.Join(filepath.Dir(encryptionConfigPath()), "encryption-rotation.json")
+}
+
+// encryptionRotationTargets lists the artifacts ntm encrypts.
+func encryptionRotationTargets(extra []string) []string {
+	vaultPath := redaction.DefaultVaultPath()
+	if cfg != nil && cfg.Redaction.VaultPath != "" {
+		vaultPath = util.ExpandPath(cfg.Redaction.VaultPath)
+	}
+	targets := []string{
+		filepath.Dir(util.ExpandPath(events.DefaultLogPath)),
+		history.StoragePath(),
+		vaultPath,
+	}
+	for _, p := range extra {
+		targets = append(targets, util.ExpandPath(p))
+	}
+	return targets
+}
+
+func encryptionKeyConfig(ec config.EncryptionConfig) encryption.KeyConfig {
+	return encryption.KeyConfig{
+		KeySource:   ec.KeySource,
+		KeyEnv:      ec.KeyEnv,
+		KeyFile:     ec.KeyFile,
+		KeyCommand:  ec.KeyCommand,
+		KeyFormat:   ec.KeyFormat,
+		ActiveKeyID: ec.ActiveKeyID,
+		Keyring:     ec.Keyring,
+	}
+}
+
+func runEncryptionRotate(keyID, keyFile string, paths []string, resume, retireOld bool) error {
+	if cfg == nil || !cfg.Encryption.Enabled {
+		return fmt.Errorf("encryption is not enabled: set [encryption] enabled = true first")
+	}
+	ec := cfg.Encryption
+	keyCfg := encryptionKeyConfig(ec)
+
+	statePath := encryptionRotationStatePath()
+	st, err := encryption.LoadRotationState(statePath)
+	if err != nil {
+		return err
+	}
+
+	var newKey []byte
+	var oldKeys [][]byte
+	switch {
+	case resume:
+		if st == nil {
+			return fmt.Errorf("no rotation in progress (%s not found)", statePath)
+		}
+		if ec.ActiveKeyID != st.KeyID {
+			return fmt.Errorf("active key is %q but the interrupted rotation was to %q", ec.ActiveKeyID, st.KeyID)
+		}
+		if newKey, err = encryption.ResolveKey(keyCfg); err != nil {
+			return fmt.Errorf("resolving active key: %w", err)
+		}
+		if oldKeys, err = encryption.ResolveKeyring(keyCfg); err != nil {
+			return fmt.Errorf("resolving keyring: %w", err)
+		}
+		for _, p := range paths {
+			st.Targets = append(st.Targets, util.ExpandPath(p))
+		}
+
+	case st != nil:
+		return fmt.Errorf("a rotation to key %q is already in progress; run 'ntm encryption rotate --resume'", st.KeyID)
+
+	default:
+		if keyID == "" {
+			keyID = "k" + time.Now().UTC().Format("20060102150405")
+		}
+		if _, exists := ec.Keyring[keyID]; exists {
+			return fmt.Errorf("key id %q already exists in the keyring", keyID)
+		}
+
+		if keyFile != "" {
+			newKey, err = encryption.ResolveKey(encryption.KeyConfig{KeySource: "file", KeyFile: util.ExpandPath(keyFile), KeyFormat: ec.KeyFormat})
+		} else {
+			newKey, err = encryption.GenerateKey()
+		}
+		if err != nil {
+			return err
+		}
+		encoded, err := encryption.EncodeKey(newKey, ec.KeyFormat)
+		if err != nil {
+			return err
+		}
+
+		current, err := encryption.ResolveKey(keyCfg)
+		if err != nil {
+			return fmt.Errorf("resolving current key: %w", err)
+		}
+		if oldKeys, err = encryption.ResolveKeyring(keyCfg); err != nil {
+			return fmt.Errorf("resolving keyring: %w", err)
+		}
+
+		// Persist the new key before touching any data so an interruption
+		// never leaves records encrypted with a key that is not on disk.
+		add := map[string]string{keyID: encoded}
+		if !containsKey(oldKeys, current) || len(ec.Keyring) == 0 {
+			// The current key comes from key_source, not the keyring; keep
+			// it readable once the keyring takes over.
+			legacy, err := encryption.EncodeKey(current, ec.KeyFormat)
+			if err != nil {
+				return err
+			}
+			add[unusedKeyID(ec.Keyring, "legacy")] = legacy
+			if !containsKey(oldKeys, current) {
+				oldKeys = append(oldKeys, current)
+			}
+		}
+		if err := config.SetEncryptionKeyring(encryptionConfigPath(), keyID, add, nil); err != nil {
+			return err
+		}
+
+		st = &encryption.RotationState{
+			KeyID:     keyID,
+			StartedAt: time.Now().UTC(),
+			Targets:   encryptionRotationTargets(paths),
+		}
+		if err := encryption.SaveRotationState(statePath, st); err != nil {
+			return err
+		}
+		if !IsJSONOutput() {
+			output.PrintInfof("New key %q is active (config: %s)", keyID, encryptionConfigPath())
+		}
+	}
+
+	// Anything this process writes from here on uses the new key.
+	allKeys := append([][]byte{newKey}, oldKeys...)
+	history.SetEncryptionConfig(&history.EncryptionConfig{Enabled: true, EncryptKey: newKey, DecryptKeys: allKeys})
+	events.SetEncryptionConfig(&events.EncryptionConfig{Enabled: true, EncryptKey: newKey, DecryptKeys: allKeys})
+
+	rotator := &encryption.Rotator{
+		OldKeys:   oldKeys,
+		NewKey:    newKey,
+		StatePath: statePath,
+		Progress: func(p encryption.RotationProgress) {
+			if IsJSONOutput() {
+				return
+			}
+			status := fmt.Sprintf("%d record(s) re-encrypted", p.File.Records)
+			switch {
+			case p.Err != nil:
+				status = "FAILED: " + p.Err.Error()
+			case p.Skipped:
+				status = "already done"
+			case p.File.Records == 0:
+				status = "no encrypted content"
+			}
+			fmt.Printf("  [%d/%d] %s: %s\n", p.Index, p.Total, p.File.Path, status)
+		},
+	}
+
+	runErr := rotator.Run(st)
+
+	resp := EncryptionRotateResponse{
+		TimestampedResponse: output.NewTimestamped(),
+		KeyID:               st.KeyID,
+		Resumed:             resume,
+		Failed:              st.Failed,
+	}
+	for _, f := range st.Files {
+		resp.Files = append(resp.Files, f)
+		resp.Records += f.Records
+	}
+	sort.Slice(resp.Files, func(i, j int) bool { return resp.Files[i].Path < resp.Files[j].Path })
+
+	if runErr != nil {
+		resp.StatePath = statePath
+		if IsJSONOutput() {
+			_ = output.PrintJSON(resp)
+		}
+		return fmt.Errorf("rotation incomplete (%d file(s) failed); fix and run 'ntm encryption rotate --resume':\n%w", len(st.Failed), runErr)
+	}
+
+	if retireOld {
+		// Reload: the keyring may have gained a legacy entry above.
+		current, err := config.Load(encryptionConfigPath())
+		if err != nil {
+			return fmt.Errorf("reloading config: %w", err)
+		}
+		var retire []string
+		for id := range current.Encryption.Keyring {
+			if id != st.KeyID {
+				retire = append(retire, id)
+			}
+		}
+		sort.Strings(retire)
+		if err := config.SetEncryptionKeyring(encryptionConfigPath(), st.KeyID, nil, retire); err != nil {
+			return err
+		}
+		resp.RetiredKeys = retire
+	}
+
+	if err := os.Remove(statePath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing rotation state: %w", err)
+	}
+
+	if IsJSONOutput() {
+		return output.PrintJSON(resp)
+	}
+	output.PrintSuccessf("Rotated to key %q: %d record(s) in %d file(s) verified", st.KeyID, resp.Records, len(resp.Files))
+	if len(resp.RetiredKeys) > 0 {
+		fmt.Printf("Retired keys: %s\n", strings.Join(resp.RetiredKeys, ", "))
+	}
+	return nil
+}
+
+func containsKey(keys [][]byte, key []byte) bool {
+	for _, k := range keys {
+		if bytes.Equal(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func unusedKeyID(keyring map[string]string, base string) string {
+	id := base
+	for n := 2; ; n++ {
+		if _, ok := keyring[id]; !ok {
+			return id
+		}
+		id = fmt.Sprintf("%s%d", base, n)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/shahbajlive/ntm/internal/clipboard"
 	"github.com/shahbajlive/ntm/internal/codeblock"
 	"github.com/shahbajlive/ntm/internal/output"
+	"github.com/shahbajlive/ntm/internal/segment"
 	"github.com/shahbajlive/ntm/internal/tmux"
 )
 
@@ -32,6 +33,7 @@ func newExtractCmd() *cobra.Command {
 		copyFlag   bool
 		applyFlag  bool
 		selectFlag int
+		diffsFlag  bool
 	)
 
 	cmd := &cobra.Command{
@@ -47,6 +49,7 @@ Examples:
   ntm extract myproject --copy       # Copy all blocks to clipboard
   ntm extract myproject --copy -s 1  # Copy specific block (1-indexed)
   ntm extract myproject --apply      # Apply blocks to detected files
+  ntm extract myproject cc_1 --diffs # Diffs and edits the agent made instead
   ntm extract myproject --json       # Output as JSON`,
 		Args: cobra.RangeArgs(1, 2),
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -57,7 +60,10 @@ Examples:
 				paneIndex = args[1]
 			}
 
-			return runExtract(sessionName, paneIndex, language, lastPane, lines, copyFlag, applyFlag, selectFlag)
+			if diffsFlag && applyFlag {
+				return fmt.Errorf("--apply cannot be used with --diffs")
+			}
+			return runExtract(sessionName, paneIndex, language, lastPane, lines, copyFlag, applyFlag, selectFlag, diffsFlag)
 		},
 	}
 
@@ -67,11 +73,12 @@ Examples:
 	cmd.Flags().BoolVarP(&copyFlag, "copy", "c", false, "Copy extracted code to clipboard")
 	cmd.Flags().BoolVarP(&applyFlag, "apply", "a", false, "Apply code blocks to detected files")
 	cmd.Flags().IntVarP(&selectFlag, "select", "s", 0, "Select specific block by number (1-indexed)")
+	cmd.Flags().BoolVar(&diffsFlag, "diffs", false, "Extract diffs and edits from the agent's tool calls instead of code blocks")
 
 	return cmd
 }
 
-func runExtract(sessionName, paneIndex, language string, lastPane bool, lines int, copyFlag, applyFlag bool, selectBlock int) error {
+func runExtract(sessionName, paneIndex, language string, lastPane bool, lines int, copyFlag, applyFlag bool, selectBlock int, diffs bool) error {
 	// Check session exists
 	if !tmux.SessionExists(sessionName) {
 		if IsJSONOutput() {
@@ -145,7 +152,12 @@ func runExtract(sessionName, paneIndex, language string, lastPane bool, lines in
 		}
 
 		// Parse code blocks
-		blocks := parser.Parse(captured)
+		var blocks []codeblock.CodeBlock
+		if diffs {
+			blocks = extractDiffs(string(pane.Type), captured)
+		} else {
+			blocks = parser.Parse(captured)
+		}
 
 		// Add source pane info
 		for i := range blocks {
@@ -231,6 +243,21 @@ func runExtract(sessionName, paneIndex, language string, lastPane bool, lines in
 	return nil
 }
 
+// extractDiffs returns the diffs in a pane's output as code blocks.
+func extractDiffs(agentType, captured string) []codeblock.CodeBlock {
+	var blocks []codeblock.CodeBlock
+	for _, b := range segment.Filter(segment.Parse(agentType, captured), segment.KindDiff) {
+		blocks = append(blocks, codeblock.CodeBlock{
+			Language:  "diff",
+			Content:   b.Text,
+			StartLine: b.StartLine,
+			EndLine:   b.EndLine,
+			FilePath:  b.File,
+		})
+	}
+	return blocks
+}
+
 // handleExtractCopy copies code blocks to clipboard
 func handleExtractCopy(blocks []codeblock.CodeBlock) error {
 	if len(blocks) == 0 {
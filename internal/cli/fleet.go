@@ -0,0 +1,334 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shahbajlive/ntm/internal/fleet"
+	"github.com/shahbajlive/ntm/internal/state"
+	"github.com/shahbajlive/ntm/internal/tmux"
+	fleettui "github.com/shahbajlive/ntm/internal/tui/fleet"
+)
+
+func newFleetCmd() *cobra.Command {
+	var noTUI bool
+
+	cmd := &cobra.Command{
+		Use:   "fleet",
+		Short: "Overview of every ntm session",
+		Long: `Show every ntm session at once: agents by state, context pressure,
+estimated prompt spend, active alerts, pipeline runs and bead throughput.
+
+In the interactive view, Enter opens the per-session dashboard for the
+selected session (quitting it returns to the fleet), and bulk actions apply
+to every session:
+  p   pause all sends (ntm send refuses paused sessions)
+  u   resume sends
+  c   checkpoint every session
+  b   broadcast a prompt to every idle Claude agent
+
+Examples:
+  ntm fleet                       # Interactive fleet overview
+  ntm fleet --json                # Snapshot for automation
+  ntm fleet pause                 # Pause sends to every session
+  ntm fleet resume myproject      # Resume one session
+  ntm fleet broadcast "git pull and rerun the tests"`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runFleet(cmd.OutOrStdout(), cmd.ErrOrStderr(), jsonOutput, noTUI)
+		},
+	}
+	cmd.Flags().BoolVar(&noTUI, "no-tui", false, "Plain text output (no interactive UI)")
+
+	cmd.AddCommand(
+		newFleetPauseCmd(),
+		newFleetResumeCmd(),
+		newFleetCheckpointCmd(),
+		newFleetBroadcastCmd(),
+	)
+	return cmd
+}
+
+func newFleetPauseCmd() *cobra.Command {
+	var reason string
+	cmd := &cobra.Command{
+		Use:   "pause [session...]",
+		Short: "Pause prompt delivery to sessions (default: all)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			names, err := fleetTargets(args)
+			if err != nil {
+				return err
+			}
+			return printFleetResults(cmd.OutOrStdout(), "pause sends", fleet.PauseSends(names, reason))
+		},
+	}
+	cmd.Flags().StringVar(&reason, "reason", fleet.PauseReason, "Reason recorded with the pause")
+	cmd.ValidArgsFunction = completeSessionArgs
+	return cmd
+}
+
+func newFleetResumeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "resume [session...]",
+		Short: "Resume prompt delivery to sessions (default: all)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			names, err := fleetTargets(args)
+			if err != nil {
+				return err
+			}
+			return printFleetResults(cmd.OutOrStdout(), "resume sends", fleet.ResumeSends(names))
+		},
+	}
+	cmd.ValidArgsFunction = completeSessionArgs
+	return cmd
+}
+
+func newFleetCheckpointCmd() *cobra.Command {
+	var description string
+	cmd := &cobra.Command{
+		Use:   "checkpoint [session...]",
+		Short: "Checkpoint sessions (default: all)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			names, err := fleetTargets(args)
+			if err != nil {
+				return err
+			}
+			return printFleetResults(cmd.OutOrStdout(), "checkpoint", fleet.CheckpointAll(names, description))
+		},
+	}
+	cmd.Flags().StringVarP(&description, "message", "m", "fleet checkpoint", "Checkpoint description")
+	cmd.ValidArgsFunction = completeSessionArgs
+	return cmd
+}
+
+func newFleetBroadcastCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "broadcast <prompt>",
+		Short: "Send a prompt to every idle Claude agent in every session",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := tmux.EnsureInstalled(); err != nil {
+				return err
+			}
+			ov, err := collectFleet(context.Background())
+			if err != nil {
+				return err
+			}
+			results := broadcastIdle(ov, strings.Join(args, " "))
+			if jsonOutput {
+				// Each session's send already wrote its JSON result.
+				return nil
+			}
+			return printFleetResults(cmd.OutOrStdout(), "broadcast", results)
+		},
+	}
+}
+
+// fleetTargets returns the named sessions, or every session in the fleet.
+func fleetTargets(args []string) ([]string, error) {
+	if len(args) > 0 {
+		for _, name := range args {
+			if !tmux.SessionExists(name) {
+				return nil, fmt.Errorf("session '%s' not found", name)
+			}
+		}
+		return args, nil
+	}
+	if err := tmux.EnsureInstalled(); err != nil {
+		return nil, err
+	}
+	ov, err := collectFleet(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(ov.Sessions))
+	for _, s := range ov.Sessions {
+		names = append(names, s.Name)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no ntm sessions running")
+	}
+	return names, nil
+}
+
+func fleetSources() (fleet.Sources, func()) {
+	var store *state.Store
+	if s, err := state.Open(""); err == nil {
+		if err := s.Migrate(); err == nil {
+			store = s
+		} else {
+			s.Close()
+		}
+	}
+	cleanup := func() {
+		if store != nil {
+			store.Close()
+		}
+	}
+	return fleet.DefaultSources(cfg, store), cleanup
+}
+
+func collectFleet(ctx context.Context) (*fleet.Overview, error) {
+	src, cleanup := fleetSources()
+	defer cleanup()
+	return fleet.Collect(ctx, src)
+}
+
+func runFleet(w, errW io.Writer, asJSON, noTUI bool) error {
+	if err := tmux.EnsureInstalled(); err != nil {
+		return err
+	}
+	if asJSON {
+		ov, err := collectFleet(context.Background())
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(ov)
+	}
+	if noTUI || shouldUsePlainMode() {
+		ov, err := collectFleet(context.Background())
+		if err != nil {
+			return err
+		}
+		printFleetPlain(w, ov)
+		return nil
+	}
+
+	src, cleanup := fleetSources()
+	defer cleanup()
+	opts := fleettui.Options{
+		Collect: func(ctx context.Context) (*fleet.Overview, error) { return fleet.Collect(ctx, src) },
+	}
+	// Drill-down and broadcast leave the fleet view; it reopens afterwards
+	// with the same session selected.
+	for {
+		result, err := fleettui.Run(opts)
+		if err != nil || result == nil {
+			return err
+		}
+		opts.Flash = ""
+		switch {
+		case result.DrillDown != "":
+			opts.Select = result.DrillDown
+			action, err := runSessionDashboard(errW, result.DrillDown)
+			if err != nil {
+				opts.Flash = fmt.Sprintf("dashboard %s: %v", result.DrillDown, err)
+				continue
+			}
+			if action != nil && action.AttachSession != "" {
+				return tmux.AttachOrSwitch(action.AttachSession)
+			}
+		case result.Broadcast != "":
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			ov, err := fleet.Collect(ctx, src)
+			cancel()
+			if err != nil {
+				opts.Flash = "broadcast: " + err.Error()
+				continue
+			}
+			results := broadcastIdle(ov, result.Broadcast)
+			opts.Flash = fmt.Sprintf("broadcast: %d session(s), %d failed", len(results), fleet.Failed(results))
+		}
+	}
+}
+
+// broadcastIdle sends prompt to the idle Claude agents of every session
+// through the normal send path, so redaction, hooks, history and send
+// pauses all apply. Paused sessions and sessions with no idle Claude agent
+// are skipped.
+func broadcastIdle(ov *fleet.Overview, prompt string) []fleet.ActionResult {
+	src := fleet.DefaultSources(cfg, nil)
+
+	var results []fleet.ActionResult
+	for _, s := range ov.Sessions {
+		r := fleet.ActionResult{Session: s.Name}
+		if s.SendsPaused {
+			r.Detail = "skipped: sends paused"
+			results = append(results, r)
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		panes, err := fleet.IdlePanes(ctx, src, s.Name, tmux.AgentClaude)
+		cancel()
+		if err != nil {
+			r.Error = err.Error()
+			results = append(results, r)
+			continue
+		}
+		if len(panes) == 0 {
+			r.Detail = "skipped: no idle cc agents"
+			results = append(results, r)
+			continue
+		}
+		indices := make([]int, 0, len(panes))
+		for _, p := range panes {
+			indices = append(indices, p.Index)
+		}
+		err = runSendWithTargets(SendOptions{
+			Session:        s.Name,
+			Prompt:         prompt,
+			PromptSource:   "fleet",
+			PaneIndex:      -1,
+			Panes:          indices,
+			PanesSpecified: true,
+		})
+		if err != nil {
+			r.Error = err.Error()
+		} else {
+			r.Detail = fmt.Sprintf("sent to %d idle cc agent(s)", len(indices))
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+func printFleetResults(w io.Writer, label string, results []fleet.ActionResult) error {
+	if jsonOutput {
+		if err := json.NewEncoder(w).Encode(results); err != nil {
+			return err
+		}
+	} else {
+		for _, r := range results {
+			if r.Error != "" {
+				fmt.Fprintf(w, "✗ %s: %s\n", r.Session, r.Error)
+			} else {
+				fmt.Fprintf(w, "✓ %s: %s\n", r.Session, r.Detail)
+			}
+		}
+	}
+	if n := fleet.Failed(results); n > 0 {
+		return fmt.Errorf("%s failed for %d of %d session(s)", label, n, len(results))
+	}
+	return nil
+}
+
+func printFleetPlain(w io.Writer, ov *fleet.Overview) {
+	if len(ov.Sessions) == 0 {
+		fmt.Fprintln(w, "No ntm sessions running.")
+		return
+	}
+	tot := ov.Totals
+	fmt.Fprintf(w, "Fleet: %d sessions, %d agents, %d idle cc, est. $%.2f, %d alerts\n",
+		tot.Sessions, tot.Agents, tot.IdleClaude, tot.CostUSD, tot.Alerts)
+	fmt.Fprintln(w, strings.Repeat("-", 60))
+	for _, s := range ov.Sessions {
+		sends := "on"
+		if s.SendsPaused {
+			sends = "paused"
+		}
+		fmt.Fprintf(w, "%s: agents=%d working=%d idle=%d error=%d ctx=%.0f%% cost=$%.2f alerts=%d pipelines=%d beads=%d/1h,%d/24h sends=%s\n",
+			s.Name, s.Agents, s.States["working"], s.States["idle"], s.States["error"]+s.States[fleet.StateRateLimited],
+			s.ContextMax, s.CostUSD, s.Alerts, s.PipelinesRunning, s.BeadsCompletedHour, s.BeadsCompletedDay, sends)
+		if s.Error != "" {
+			fmt.Fprintf(w, "  warning: %s\n", s.Error)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/notify"
+	"github.com/shahbajlive/ntm/internal/output"
+	"github.com/shahbajlive/ntm/internal/promptqueue"
+	"github.com/shahbajlive/ntm/internal/quota"
+	"github.com/shahbajlive/ntm/internal/ratelimit"
+	"github.com/shahbajlive/ntm/internal/state"
+	"github.com/shahbajlive/ntm/internal/status"
+	"github.com/shahbajlive/ntm/internal/swarm"
+	"github.com/shahbajlive/ntm/internal/tmux"
+)
+
+// QuotaForecastOutput is the JSON output for quota --forecast.
+type QuotaForecastOutput struct {
+	output.TimestampedResponse
+	Session   string           `json:"session"`
+	Forecasts []quota.Forecast `json:"forecasts"`
+}
+
+// forecastConfig returns the forecast settings from [rotation.forecast].
+func forecastConfig() quota.ForecastConfig {
+	if cfg == nil {
+		return quota.DefaultForecastConfig()
+	}
+	return quota.ForecastConfigFor(cfg.Rotation.Forecast.WindowHours, cfg.Rotation.Forecast.PreemptMinutes)
+}
+
+// recentLimitTimes returns when provider's agents in projectDir last hit a
+// rate limit.
+func recentLimitTimes(projectDir string, provider quota.Provider) []time.Time {
+	return ratelimit.RecentLimitTimes(projectDir, string(provider))
+}
+
+// openQuotaStore opens the state store's quota samples. The caller closes the
+// returned store.
+func openQuotaStore() (*state.Store, *state.QuotaStore, error) {
+	store, err := openTranscriptStore()
+	if err != nil {
+		return nil, nil, err
+	}
+	return store, state.NewQuotaStore(store), nil
+}
+
+// startQuotaForecaster forecasts each account's time to limit every
+// [rotation.forecast] interval, from sends and recent rate limits and, with
+// sample = true, from /usage typed into an idle agent. When the account in
+// use is about to run out and rotation is enabled, idle agents are moved to
+// the account with the most headroom; busy agents are left to finish their
+// task and picked up on a later round. It reports whether the forecaster
+// started.
+func startQuotaForecaster(ctx context.Context, session, projectDir string) bool {
+	if cfg == nil || !cfg.Rotation.Forecast.Enabled {
+		return false
+	}
+	store, qs, err := openQuotaStore()
+	if err != nil {
+		return false
+	}
+	interval := time.Duration(cfg.Rotation.Forecast.IntervalMinutes) * time.Minute
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+	var notifier *notify.Notifier
+	if cfg.Notifications.Enabled {
+		notifier = notify.New(cfg.Notifications)
+	}
+
+	f := &quotaForecaster{
+		session:    session,
+		projectDir: projectDir,
+		quotas:     qs,
+		detector:   status.NewDetector(),
+		fetcher:    &quota.PTYFetcher{CommandTimeout: 5 * time.Second},
+		rotator:    swarm.NewAccountRotator(),
+		sample:     cfg.Rotation.Forecast.Sample,
+		notifier:   notifier,
+		warned:     make(map[string]bool),
+	}
+	go func() {
+		defer store.Close()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				f.round(ctx)
+			}
+		}
+	}()
+	return true
+}
+
+type quotaForecaster struct {
+	session    string
+	projectDir string
+	quotas     *state.QuotaStore
+	detector   *status.UnifiedDetector
+	fetcher    *quota.PTYFetcher
+	rotator    *swarm.AccountRotator
+	notifier   *notify.Notifier
+	sample     bool            // Type /usage into an idle agent each round
+	warned     map[string]bool // provider/account already announced as at risk
+}
+
+// round samples, forecasts and, if needed, rotates each provider in use.
+func (f *quotaForecaster) round(ctx context.Context) {
+	panes, err := tmux.GetPanes(f.session)
+	if err != nil {
+		return
+	}
+	for _, provider := range quota.Providers {
+		var agents, idle []tmux.Pane
+		for _, p := range panes {
+			if string(p.Type) != provider.AgentType() {
+				continue
+			}
+			agents = append(agents, p)
+			if f.isIdle(p) {
+				idle = append(idle, p)
+			}
+		}
+		if len(agents) == 0 {
+			continue
+		}
+		f.forecast(ctx, provider, idle)
+	}
+}
+
+func (f *quotaForecaster) forecast(ctx context.Context, provider quota.Provider, idle []tmux.Pane) {
+	agentType := provider.AgentType()
+	current := f.rotator.CurrentAccount(agentType)
+
+	if info := f.sampleIdle(ctx, provider, idle); info != nil {
+		account := current
+		if account == "" {
+			account = info.AccountID
+		}
+		if account == "" {
+			account = "default"
+		}
+		current = account
+		sample := &state.QuotaSample{
+			Provider:     string(provider),
+			Account:      account,
+			SessionName:  f.session,
+			UsagePercent: info.HighestUsage(),
+			IsLimited:    info.IsLimited,
+		}
+		if !info.ResetTime.IsZero() {
+			sample.ResetAt = &info.ResetTime
+		}
+		if err := f.quotas.Record(sample); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: recording quota sample: %v\n", err)
+		}
+	}
+
+	fc := forecastConfig()
+	now := time.Now()
+	if current == "" {
+		// Without caam the account sampled last is taken to be in use.
+		if samples, err := f.quotas.Samples(string(provider), now.Add(-fc.Window)); err == nil && len(samples) > 0 {
+			current = samples[len(samples)-1].Account
+		}
+	}
+	forecasts, err := f.quotas.Forecasts(provider, current, recentLimitTimes(f.projectDir, provider), fc, now)
+	if err != nil {
+		return
+	}
+	var at *quota.Forecast
+	for i := range forecasts {
+		if forecasts[i].Account == current {
+			at = &forecasts[i]
+		}
+	}
+	key := string(provider) + "/" + current
+	if at == nil || !at.AtRisk {
+		delete(f.warned, key)
+		return
+	}
+
+	if !f.warned[key] {
+		f.warned[key] = true
+		fmt.Printf("Quota forecast: %s account %s at %.0f%%, %s\n", provider, at.Account, at.Usage, at.Summary())
+		if f.notifier != nil {
+			_ = f.notifier.Notify(notify.NewLimitForecastEvent(f.session, agentType, at.Account, at.Summary()))
+		}
+	}
+	if !cfg.Rotation.Enabled || len(idle) == 0 {
+		return
+	}
+
+	respawner := swarm.NewAutoRespawner().
+		WithAccountRotator(f.rotator).
+		WithProjectPathLookup(func(string) string { return f.projectDir })
+	for _, p := range idle {
+		// The pane may have been given work since the round began; claim
+		// it so the prompt queue does not deliver into the restart.
+		release, ok := promptqueue.ClaimPane(f.session, p.ID)
+		if !ok {
+			continue
+		}
+		if !f.isIdle(p) {
+			release()
+			continue
+		}
+		res := respawner.Preempt(swarm.ForecastEvent{
+			SessionPane: p.ID,
+			AgentType:   agentType,
+			Project:     f.projectDir,
+			DetectedAt:  time.Now(),
+			Forecast:    *at,
+			Candidates:  forecasts,
+		})
+		release()
+		if res.Error != "" {
+			fmt.Printf("Quota forecast: not rotating %s: %s\n", p.Title, res.Error)
+			continue
+		}
+		fmt.Printf("Quota forecast: moved idle %s from %s to %s\n", p.Title, res.PreviousAccount, res.NewAccount)
+	}
+}
+
+// isIdle reports whether p's agent is idle right now.
+func (f *quotaForecaster) isIdle(p tmux.Pane) bool {
+	st, err := f.detector.Detect(p.ID)
+	return err == nil && st.State == status.StateIdle
+}
+
+// sampleIdle reads provider's quota through the first of idle that is
+// still idle once claimed: /usage is typed into the pane, so only an idle
+// agent is asked. It returns nil when sampling is off or no pane answers.
+func (f *quotaForecaster) sampleIdle(ctx context.Context, provider quota.Provider, idle []tmux.Pane) *quota.QuotaInfo {
+	if !f.sample {
+		return nil
+	}
+	for _, p := range idle {
+		release, ok := promptqueue.ClaimPane(f.session, p.ID)
+		if !ok {
+			continue
+		}
+		if !f.isIdle(p) {
+			release()
+			continue
+		}
+		info, err := f.fetcher.FetchQuota(ctx, p.ID, provider)
+		release()
+		if err != nil || info.Error != "" {
+			return nil
+		}
+		return info
+	}
+	return nil
+}
+
+func runQuotaForecast(session string) error {
+	store, qs, err := openQuotaStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	projectDir := cfg.GetProjectDir(session)
+
+	limits := func(p quota.Provider) []time.Time { return recentLimitTimes(projectDir, p) }
+	forecasts, err := qs.ActiveForecasts(limits, forecastConfig(), time.Now())
+	if err != nil {
+		return err
+	}
+	out := QuotaForecastOutput{Session: session, Forecasts: forecasts}
+
+	if IsJSONOutput() {
+		out.TimestampedResponse = output.NewTimestamped()
+		return output.PrintJSON(out)
+	}
+	if len(out.Forecasts) == 0 {
+		output.PrintInfof("No quota history yet; the session monitor records sends and rate limits as agents work")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Provider\tAccount\tUsage\tSends/h\tBasis\tForecast")
+	fmt.Fprintln(w, "────────\t───────\t─────\t───────\t─────\t────────")
+	for _, f := range out.Forecasts {
+		basis := f.Basis
+		if basis == "" {
+			basis = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%.0f%%\t%.1f\t%s\t%s\n", f.Provider, f.Account, f.Usage, f.SendsPerHour, basis, f.Summary())
+	}
+	return w.Flush()
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/shahbajlive/ntm/internal/agentmail"
+	"github.com/shahbajlive/ntm/internal/mailserver"
 	"github.com/shahbajlive/ntm/internal/output"
 )
 
@@ -184,39 +185,10 @@ func runGuardsInstall(projectKey string, force bool) error {
 	return nil
 }
 
+// installFallbackGuard writes the guard hook directly when Agent Mail cannot
+// install it. The hook checks reservations via "ntm mail guard-check".
 func installFallbackGuard(hookPath, projectKey, repoPath string) error {
-	// Ensure hooks directory exists
-	hookDir := filepath.Dir(hookPath)
-	if err := os.MkdirAll(hookDir, 0755); err != nil {
-		return fmt.Errorf("creating hooks directory: %w", err)
-	}
-
-	// Sanitize paths for shell comments (replace newlines and special chars)
-	safeProjectKey := sanitizeForShellComment(projectKey)
-	safeRepoPath := sanitizeForShellComment(repoPath)
-
-	script := fmt.Sprintf(`#!/bin/bash
-# ntm-precommit-guard
-# Installed by: ntm guards install
-# Project: %s
-# Repository: %s
-
-set -e
-
-# Check for ntm command
-if ! command -v ntm &> /dev/null; then
-    exit 0  # NTM not installed, allow commit
-fi
-
-# Check for active file reservations that might conflict
-# This is a fallback check - the full check requires Agent Mail MCP
-
-# For now, just log and pass (full implementation via Agent Mail)
-echo "[ntm-guard] Pre-commit check passed"
-exit 0
-`, safeProjectKey, safeRepoPath)
-
-	return os.WriteFile(hookPath, []byte(script), 0755)
+	return mailserver.WriteGuardHook(hookPath, projectKey, repoPath)
 }
 
 func newGuardsUninstallCmd() *cobra.Command {
@@ -472,11 +444,3 @@ func findGitRoot(startPath string) (string, error) {
 	}
 	return strings.TrimSpace(string(out)), nil
 }
-
-// sanitizeForShellComment sanitizes a string for safe inclusion in a shell comment.
-// Replaces newlines with spaces and removes control characters that could break script structure.
-func sanitizeForShellComment(s string) string {
-	s = strings.ReplaceAll(s, "\n", " ")
-	s = strings.ReplaceAll(s, "\r", " ")
-	return s
-}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shahbajlive/ntm/internal/headless"
+	"github.com/shahbajlive/ntm/internal/tmux"
+)
+
+// paneBackend is the --backend flag: "tmux" (default) or "pty".
+var paneBackend string
+
+// configureBackend installs the pane backend selected by --backend or
+// $NTM_BACKEND. With "pty", agents run as child processes of a background
+// headless server instead of tmux panes, for CI machines without tmux.
+func configureBackend() error {
+	name := paneBackend
+	if name == "" {
+		name = os.Getenv("NTM_BACKEND")
+	}
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "tmux":
+		return nil
+	case headless.BackendName:
+		if sshHost != "" {
+			return errors.New("--backend=pty cannot be combined with --ssh")
+		}
+		if err := headless.Available(); err != nil {
+			return err
+		}
+		exe, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("locating ntm executable for headless server: %w", err)
+		}
+		// Child ntm processes (session monitor, hooks) inherit the choice.
+		os.Setenv("NTM_BACKEND", headless.BackendName)
+		socket := headless.SocketPath()
+		os.Setenv(headless.SocketEnv, socket)
+		tmux.DefaultClient = tmux.NewClientWithBackend(headless.NewClient(socket, exe, "headless-server", "--socket", socket))
+		return nil
+	default:
+		return fmt.Errorf("unknown backend %q (expected tmux or pty)", name)
+	}
+}
+
+func newHeadlessServerCmd() *cobra.Command {
+	var socket string
+	cmd := &cobra.Command{
+		Use:    "headless-server",
+		Short:  "Host headless agent panes for --backend=pty (internal use)",
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if socket == "" {
+				socket = headless.SocketPath()
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			err := headless.NewServer(socket).ListenAndServe(ctx)
+			if errors.Is(err, os.ErrExist) {
+				// Another invocation started a server first; use that one.
+				return nil
+			}
+			return err
+		},
+	}
+	cmd.Flags().StringVar(&socket, "socket", "", "Unix socket to listen on (default $NTM_HEADLESS_SOCKET or a per-user runtime path)")
+	return cmd
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/shahbajlive/ntm/internal/agentmail"
+	"github.com/shahbajlive/ntm/internal/config"
 	"github.com/shahbajlive/ntm/internal/hooks"
 	"github.com/shahbajlive/ntm/internal/tui/theme"
 )
@@ -278,6 +279,7 @@ func newHooksRunCmd() *cobra.Command {
 		verbose       bool
 		failOnWarning bool
 		timeout       int
+		sarifFiles    []string
 	)
 
 	cmd := &cobra.Command{
@@ -285,30 +287,34 @@ func newHooksRunCmd() *cobra.Command {
 		Short: "Run a hook manually",
 		Long: `Run a hook manually without committing. Useful for testing.
 
-This is also called by the installed hook script.`,
+This is also called by the installed hook script.
+
+The pre-commit hook also gates on SARIF reports from other tools, listed with
+--sarif or under scanner.sarif in the project's .ntm.yaml.`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runHooksRun(args[0], verbose, failOnWarning, timeout)
+			return runHooksRun(args[0], verbose, failOnWarning, timeout, sarifFiles)
 		},
 	}
 
 	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output")
 	cmd.Flags().BoolVar(&failOnWarning, "fail-on-warning", true, "Fail on warnings")
 	cmd.Flags().IntVar(&timeout, "timeout", 60, "Timeout in seconds")
+	cmd.Flags().StringSliceVar(&sarifFiles, "sarif", nil, "SARIF report to gate on in addition to UBS (repeatable)")
 
 	return cmd
 }
 
-func runHooksRun(hookType string, verbose, failOnWarning bool, timeout int) error {
+func runHooksRun(hookType string, verbose, failOnWarning bool, timeout int, sarifFiles []string) error {
 	switch hookType {
 	case "pre-commit":
-		return runPreCommitHook(verbose, failOnWarning, timeout)
+		return runPreCommitHook(verbose, failOnWarning, timeout, sarifFiles)
 	default:
 		return fmt.Errorf("unknown hook type: %s", hookType)
 	}
 }
 
-func runPreCommitHook(verbose, failOnWarning bool, timeout int) error {
+func runPreCommitHook(verbose, failOnWarning bool, timeout int, sarifFiles []string) error {
 	ctx := context.Background()
 
 	config := hooks.DefaultPreCommitConfig()
@@ -332,6 +338,8 @@ func runPreCommitHook(verbose, failOnWarning bool, timeout int) error {
 		return err
 	}
 
+	config.SARIFFiles = append(sarifFiles, projectSARIFReports(mgr.RepoRoot())...)
+
 	result, err := hooks.RunPreCommit(ctx, mgr.RepoRoot(), config)
 	if err != nil {
 		if jsonOutput {
@@ -352,6 +360,16 @@ func runPreCommitHook(verbose, failOnWarning bool, timeout int) error {
 	return nil
 }
 
+// projectSARIFReports returns the SARIF reports listed under scanner.sarif in
+// the project's .ntm.yaml.
+func projectSARIFReports(repoRoot string) []string {
+	projCfg, err := config.LoadProjectScannerConfig(repoRoot)
+	if err != nil {
+		return nil
+	}
+	return projCfg.SARIF
+}
+
 func newHooksGuardCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "guard",
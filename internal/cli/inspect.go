@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shahbajlive/ntm/internal/output"
+	"github.com/shahbajlive/ntm/internal/segment"
+	"github.com/shahbajlive/ntm/internal/tmux"
+)
+
+// InspectResponse is the JSON output for ntm inspect.
+type InspectResponse struct {
+	output.TimestampedResponse
+	Session  string          `json:"session"`
+	Pane     string          `json:"pane"`
+	Agent    string          `json:"agent"`
+	Lines    int             `json:"lines"`
+	Latest   segment.Latest  `json:"latest"`
+	Segments []segment.Block `json:"segments,omitempty"`
+}
+
+func newInspectCmd() *cobra.Command {
+	var (
+		lines    int
+		segments bool
+		kinds    []string
+		full     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "inspect [session] <pane>",
+		Short: "Show an agent's last prompt, tool call and diff",
+		Long: `Parse a pane's output into prompts, assistant prose, tool calls, tool
+results, diffs, errors and status lines, using the grammar of the agent CLI
+running in it, and show the latest of each.
+
+You can specify the pane by Index (e.g. 1) or Title (e.g. cc_1).
+If session is omitted, it will be inferred from the current tmux session or project directory.
+
+Examples:
+  ntm inspect myproject cc_1              # Last prompt, tool call, diff and error
+  ntm inspect cc_1 --segments             # Every block, one per line
+  ntm inspect cc_1 --segments --kind=diff --full   # Full text of each diff
+  ntm inspect cc_1 --segments --json      # Blocks as JSON`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var session, pane string
+			if len(args) == 2 {
+				session, pane = args[0], args[1]
+			} else {
+				pane = args[0]
+			}
+			return runInspect(session, pane, lines, segments, kinds, full)
+		},
+	}
+
+	cmd.Flags().IntVarP(&lines, "lines", "n", 500, "Number of lines to capture from the pane")
+	cmd.Flags().BoolVar(&segments, "segments", false, "List every block, not just the latest of each kind")
+	cmd.Flags().StringSliceVar(&kinds, "kind", nil, "Only list blocks of these kinds (prompt, prose, tool_call, tool_result, diff, error, status)")
+	cmd.Flags().BoolVar(&full, "full", false, "Print each listed block's full text")
+
+	return cmd
+}
+
+func runInspect(session, paneID string, lines int, listSegments bool, kinds []string, full bool) error {
+	if err := tmux.EnsureInstalled(); err != nil {
+		return err
+	}
+
+	opts := SessionResolveOptions{}
+	if IsJSONOutput() {
+		opts.TreatAsJSON = true
+	}
+	res, err := ResolveSessionWithOptions(session, nil, opts)
+	if err != nil {
+		return err
+	}
+	if res.Session == "" {
+		return fmt.Errorf("session is required")
+	}
+	session = res.Session
+
+	filter := make([]segment.Kind, 0, len(kinds))
+	for _, k := range kinds {
+		kind := segment.Kind(strings.TrimSpace(k))
+		switch kind {
+		case segment.KindPrompt, segment.KindProse, segment.KindToolCall, segment.KindToolResult,
+			segment.KindDiff, segment.KindError, segment.KindStatus:
+			filter = append(filter, kind)
+		default:
+			return fmt.Errorf("unknown block kind %q", k)
+		}
+	}
+
+	p, err := resolvePane(session, paneID)
+	if err != nil {
+		return err
+	}
+	captured, err := tmux.CapturePaneOutput(p.ID, lines)
+	if err != nil {
+		return fmt.Errorf("capturing pane %s: %w", paneID, err)
+	}
+
+	blocks := segment.Parse(string(p.Type), captured)
+	latest := segment.Summarize(blocks)
+	if len(filter) > 0 {
+		blocks = segment.Filter(blocks, filter...)
+	}
+
+	if IsJSONOutput() {
+		resp := InspectResponse{
+			TimestampedResponse: output.NewTimestamped(),
+			Session:             session,
+			Pane:                p.Title,
+			Agent:               string(p.Type),
+			Lines:               len(strings.Split(strings.TrimRight(captured, "\n"), "\n")),
+			Latest:              latest,
+		}
+		if listSegments {
+			resp.Segments = blocks
+		}
+		return output.PrintJSON(resp)
+	}
+
+	fmt.Printf("%s (%s): %d turn(s)\n", p.Title, p.Type, latest.Turns)
+	for _, row := range []struct {
+		label string
+		block *segment.Block
+	}{
+		{"Last prompt", latest.Prompt},
+		{"Last tool call", latest.ToolCall},
+		{"Last result", latest.ToolResult},
+		{"Last diff", latest.Diff},
+		{"Last error", latest.Error},
+	} {
+		if row.block == nil {
+			continue
+		}
+		fmt.Printf("  %-15s %s  (lines %d-%d)\n", row.label+":", truncateString(row.block.Headline(), 70), row.block.StartLine, row.block.EndLine)
+	}
+
+	if !listSegments {
+		return nil
+	}
+	fmt.Println()
+	if len(blocks) == 0 {
+		fmt.Println("No blocks found")
+		return nil
+	}
+	for i, b := range blocks {
+		fmt.Printf("%3d  %4d-%-4d  turn %-3d %-11s %s\n", i+1, b.StartLine, b.EndLine, b.Turn, b.Kind, truncateString(b.Headline(), 60))
+		if full {
+			for _, line := range strings.Split(b.Text, "\n") {
+				fmt.Printf("       │ %s\n", line)
+			}
+		}
+	}
+	return nil
+}
@@ -36,15 +36,21 @@ in Agent Mail. Messages sent this way:
 
 This is the CLI equivalent of the Agent Mail web UI at /mail/{project}/overseer/compose
 
+When the external Agent Mail server is not installed, "ntm mail serve" runs a
+built-in replacement backed by the ntm state store.
+
 Examples:
   ntm mail send myproject --to GreenCastle "Please review the API changes"
-  ntm mail send myproject --all "Checkpoint: sync and report status"`,
+  ntm mail send myproject --all "Checkpoint: sync and report status"
+  ntm mail serve`,
 	}
 
 	cmd.AddCommand(newMailSendCmd())
 	cmd.AddCommand(newMailInboxCmdReal())
 	cmd.AddCommand(newMailReadCmd())
 	cmd.AddCommand(newMailAckCmd())
+	cmd.AddCommand(newMailServeCmd())
+	cmd.AddCommand(newMailGuardCheckCmd())
 
 	return cmd
 }
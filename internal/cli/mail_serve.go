@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shahbajlive/ntm/internal/mailserver"
+	"github.com/shahbajlive/ntm/internal/state"
+)
+
+func newMailServeCmd() *cobra.Command {
+	var (
+		host   string
+		port   int
+		dbPath string
+		token  string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run the built-in Agent Mail server",
+		Long: `Run a local Agent Mail server backed by the ntm state store.
+
+It speaks the same MCP-over-HTTP protocol as the external Agent Mail server
+for the tools ntm uses (messaging, inbox, contacts, file reservations and
+pre-commit guards), so ntm mail, ntm lock and ntm guards work without it.
+ntm monitor starts it automatically when the "am" command is not installed.
+
+Endpoints:
+  POST /mcp/                          JSON-RPC (tools/call, resources/read)
+  POST /mail/{project}/overseer/send  Human Overseer messages
+  GET  /health/liveness               Health check
+
+Examples:
+  ntm mail serve                      # Listen on 127.0.0.1:8765
+  ntm mail serve --port 9000
+  ntm mail serve --token $AGENT_MAIL_TOKEN`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if token == "" {
+				token = os.Getenv("AGENT_MAIL_TOKEN")
+			}
+			return runMailServe(host, port, dbPath, token)
+		},
+	}
+
+	cmd.Flags().StringVar(&host, "host", "127.0.0.1", "HTTP bind host")
+	cmd.Flags().IntVar(&port, "port", mailserver.DefaultPort, "HTTP server port")
+	cmd.Flags().StringVar(&dbPath, "db", "", "State database path (default ~/.config/ntm/state.db)")
+	cmd.Flags().StringVar(&token, "token", "", "Require this bearer token (default $AGENT_MAIL_TOKEN)")
+
+	return cmd
+}
+
+func runMailServe(host string, port int, dbPath, token string) error {
+	store, err := state.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("open state store: %w", err)
+	}
+	defer store.Close()
+	if err := store.Migrate(); err != nil {
+		return fmt.Errorf("apply migrations: %w", err)
+	}
+
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mailserver.New(store, mailserver.WithToken(token), mailserver.WithVersion(Version)),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		fmt.Fprintf(os.Stderr, "Agent Mail (built-in) listening on http://%s/mcp/ (db: %s)\n", addr, store.Path())
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
+
+func newMailGuardCheckCmd() *cobra.Command {
+	var projectKey string
+
+	cmd := &cobra.Command{
+		Use:    "guard-check",
+		Short:  "Check staged files against file reservations (pre-commit hook)",
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runMailGuardCheck(cmd, projectKey, os.Getenv("AGENT_NAME"))
+		},
+	}
+	cmd.Flags().StringVar(&projectKey, "project", "", "Agent Mail project key (default: repository root)")
+
+	return cmd
+}
+
+// runMailGuardCheck fails when a staged file falls under another agent's
+// exclusive reservation. It fails open when Agent Mail is unreachable so a
+// stopped server never blocks commits.
+func runMailGuardCheck(cmd *cobra.Command, projectKey, self string) error {
+	out, err := exec.Command("git", "diff", "--cached", "--name-only", "-z").Output()
+	if err != nil {
+		return fmt.Errorf("listing staged files: %w", err)
+	}
+	var staged []string
+	for _, p := range strings.Split(string(out), "\x00") {
+		if p != "" {
+			staged = append(staged, p)
+		}
+	}
+	if len(staged) == 0 {
+		return nil
+	}
+
+	if projectKey == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		if projectKey, err = findGitRoot(cwd); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	reservations, err := newAgentMailClient(projectKey).ListReservations(ctx, projectKey, "", true)
+	if err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "[ntm-guard] skipping reservation check: %v\n", err)
+		return nil
+	}
+
+	var blocked []string
+	for _, r := range reservations {
+		if !r.Exclusive || (self != "" && strings.EqualFold(r.AgentName, self)) {
+			continue
+		}
+		pattern := r.PathPattern
+		if rel, err := filepath.Rel(projectKey, pattern); err == nil && filepath.IsAbs(pattern) {
+			pattern = rel
+		}
+		for _, file := range staged {
+			if mailserver.PatternsOverlap(pattern, file) {
+				blocked = append(blocked, fmt.Sprintf("  %s (reserved by %s: %s)", file, r.AgentName, r.PathPattern))
+			}
+		}
+	}
+	if len(blocked) == 0 {
+		return nil
+	}
+
+	cmd.SilenceUsage = true
+	fmt.Fprintln(cmd.ErrOrStderr(), "[ntm-guard] commit touches files reserved by other agents:")
+	for _, line := range blocked {
+		fmt.Fprintln(cmd.ErrOrStderr(), line)
+	}
+	fmt.Fprintln(cmd.ErrOrStderr(), "[ntm-guard] coordinate via ntm mail, or set NTM_GUARD_BYPASS=1 to override")
+	return fmt.Errorf("%d staged file(s) conflict with file reservations", len(blocked))
+}
@@ -8,19 +8,25 @@ import (
 	"os/signal"
 	"path/filepath"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/shahbajlive/ntm/internal/agentmail"
 	"github.com/shahbajlive/ntm/internal/archive"
+	"github.com/shahbajlive/ntm/internal/checkpoint"
 	"github.com/shahbajlive/ntm/internal/config"
+	"github.com/shahbajlive/ntm/internal/coordinator"
+	"github.com/shahbajlive/ntm/internal/daemon"
 	"github.com/shahbajlive/ntm/internal/heartbeat"
 	"github.com/shahbajlive/ntm/internal/plugins"
 	"github.com/shahbajlive/ntm/internal/resilience"
 	"github.com/shahbajlive/ntm/internal/summary"
 	"github.com/shahbajlive/ntm/internal/supervisor"
 	"github.com/shahbajlive/ntm/internal/tmux"
+	"github.com/shahbajlive/ntm/internal/watcher"
 )
 
 func newMonitorCmd() *cobra.Command {
@@ -35,7 +41,62 @@ func newMonitorCmd() *cobra.Command {
 	}
 }
 
+// runMonitor runs session's loops in this process until the session ends
+// or the process is signalled. It backs the per-session monitor started
+// when ntmd is disabled or cannot be reached.
 func runMonitor(session string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Record this process in the manifest so ntmd leaves the session to it
+	if manifest, err := resilience.LoadManifest(session); err == nil {
+		manifest.MonitorPID = os.Getpid()
+		if err := resilience.SaveManifest(manifest); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to record monitor pid: %v\n", err)
+		}
+		defer clearMonitorPID(session)
+	}
+	return runSessionLoops(ctx, session, &daemon.Loops{})
+}
+
+// clearMonitorPID removes this process from session's manifest, if the
+// manifest still exists and names it, so ntmd can adopt the session.
+func clearMonitorPID(session string) {
+	manifest, err := resilience.LoadManifest(session)
+	if err != nil || manifest.MonitorPID != os.Getpid() {
+		return
+	}
+	manifest.MonitorPID = 0
+	_ = resilience.SaveManifest(manifest)
+}
+
+// agentPluginsOnce guards loading agent plugins into the shared config:
+// ntmd runs the loops of many sessions in one process.
+var agentPluginsOnce sync.Once
+
+func loadAgentPlugins() {
+	agentPluginsOnce.Do(func() {
+		configDir := filepath.Dir(config.DefaultPath())
+		pluginsDir := filepath.Join(configDir, "agents")
+		if loadedPlugins, err := plugins.LoadAgentPlugins(pluginsDir); err == nil {
+			if cfg.Agents.Plugins == nil {
+				cfg.Agents.Plugins = make(map[string]string)
+			}
+			for _, p := range loadedPlugins {
+				cfg.Agents.Plugins[p.Name] = p.Command
+			}
+		}
+	})
+}
+
+// runSessionLoops runs the long-lived loops for session: daemon supervisor,
+// resilience monitor, archiver, prompt queue, schedules, spend meter,
+// permission prompt watcher, quota forecaster and, when configured, the
+// review loop, checkpoint worker, file reservation watcher and coordinator.
+// It returns when ctx is done or the session ends; when the session ends a
+// summary is saved and its manifest removed. Each loop started is recorded
+// in loops.
+func runSessionLoops(ctx context.Context, session string, loops *daemon.Loops) error {
 	// Load manifest
 	manifest, err := resilience.LoadManifest(session)
 	if err != nil {
@@ -67,19 +128,11 @@ func runMonitor(session string) error {
 			}
 		}
 		defer sup.Shutdown()
+		loops.Add("supervisor")
 	}
 
 	// Load plugins to populate config
-	configDir := filepath.Dir(config.DefaultPath())
-	pluginsDir := filepath.Join(configDir, "agents")
-	if loadedPlugins, err := plugins.LoadAgentPlugins(pluginsDir); err == nil {
-		if cfg.Agents.Plugins == nil {
-			cfg.Agents.Plugins = make(map[string]string)
-		}
-		for _, p := range loadedPlugins {
-			cfg.Agents.Plugins[p.Name] = p.Command
-		}
-	}
+	loadAgentPlugins()
 
 	// Initialize resilience monitor
 	monitor := resilience.NewMonitor(session, manifest.ProjectDir, cfg, manifest.AutoRestart)
@@ -95,10 +148,11 @@ func runMonitor(session string) error {
 	}
 
 	// Start monitoring
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	monitor.Start(ctx)
+	loops.Add("resilience")
 
 	// Initialize archiver for background CASS capture
 	archiverOpts := archive.DefaultArchiverOptions(session)
@@ -113,11 +167,90 @@ func runMonitor(session string) error {
 			}
 		}()
 		defer archiver.Close()
+		loops.Add("archiver")
+	}
+
+	// Deliver prompts queued with 'ntm send --queue' as panes go idle
+	if startPromptQueueDispatcher(ctx, session) {
+		loops.Add("prompt-queue")
+	}
+
+	// Fire jobs added with 'ntm schedule add'
+	if startScheduleRunner(ctx, session) {
+		loops.Add("schedule")
+	}
+
+	// Meter agent output into the spend ledger and alert on budgets
+	if startSpendMeter(ctx, session) {
+		loops.Add("spend-meter")
 	}
 
-	// Wait for termination signal or session end
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	// Answer or announce agents' tool-permission prompts
+	if startPermissionWatcher(ctx, session, manifest.ProjectDir) {
+		loops.Add("permissions")
+	}
+
+	// Forecast accounts' time to limit and rotate idle agents ahead of it
+	if startQuotaForecaster(ctx, session, manifest.ProjectDir) {
+		loops.Add("forecast")
+	}
+
+	// Cross-agent review of completed assignments ([review] enabled = true)
+	if startReviewLoop(ctx, session, manifest.ProjectDir) {
+		loops.Add("review")
+	}
+
+	// Periodic auto-checkpoints ([checkpoints] interval_minutes)
+	if cfg.Checkpoints.Enabled && cfg.Checkpoints.IntervalMinutes > 0 {
+		worker := checkpoint.NewBackgroundWorker(session, checkpoint.AutoCheckpointConfig{
+			Enabled:         true,
+			IntervalMinutes: cfg.Checkpoints.IntervalMinutes,
+			MaxCheckpoints:  cfg.Checkpoints.MaxAutoCheckpoints,
+			OnRotation:      cfg.Checkpoints.OnRotation,
+			OnError:         cfg.Checkpoints.OnError,
+			ScrollbackLines: cfg.Checkpoints.ScrollbackLines,
+			IncludeGit:      cfg.Checkpoints.IncludeGit,
+		})
+		worker.Start(ctx)
+		defer worker.Stop()
+		loops.Add("checkpoint")
+	}
+
+	// File reservations via Agent Mail, fed by pane output and filesystem
+	// attribution. The attributed changes also serve the dashboards open on
+	// the session.
+	detectFrom := reservationDetectFrom()
+	rw := startReservationWatcher(ctx, session, manifest.ProjectDir, detectFrom)
+	if rw != nil {
+		defer rw.Stop()
+		loops.Add("reservations")
+	}
+	if detectFrom != watcher.DetectFromOutput {
+		attribution := watcher.NewFileAttributionWatcher(session, manifest.ProjectDir,
+			watcher.WithAttributionDebug(cfg.FileReservation.Debug),
+			watcher.WithAttributionHandler(func(changes []watcher.FileAttribution) {
+				if rw != nil {
+					rw.OnAttributedChanges(ctx, changes)
+				}
+			}),
+		)
+		if err := attribution.Start(ctx); err == nil {
+			defer attribution.Stop()
+			loops.Add(attributionLoop)
+		}
+	}
+
+	// Session coordinator ([daemon] coordinator = true)
+	if cfg.Daemon.Coordinator {
+		mailClient := agentmail.NewClient(agentmail.WithProjectKey(manifest.ProjectDir))
+		coord := coordinator.New(session, manifest.ProjectDir, mailClient, "NTM-Coordinator")
+		if err := coord.Start(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to start coordinator: %v\n", err)
+		} else {
+			defer coord.Stop()
+			loops.Add("coordinator")
+		}
+	}
 
 	// Poll for session existence periodically to exit if session is killed
 	ticker := time.NewTicker(5 * time.Second)
@@ -132,11 +265,9 @@ func runMonitor(session string) error {
 
 	for {
 		select {
-		case <-sigChan:
-			fmt.Println("Monitor stopping...")
+		case <-ctx.Done():
+			fmt.Printf("Monitor for session '%s' stopping...\n", session)
 			monitor.Stop()
-			// Try to generate summary on signal too
-			generateEndSessionSummary(session, lastOutputs, manifest)
 			return nil
 		case <-ticker.C:
 			if !tmux.SessionExists(session) {
@@ -0,0 +1,235 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shahbajlive/ntm/internal/notify"
+	"github.com/shahbajlive/ntm/internal/output"
+	"github.com/shahbajlive/ntm/internal/permissions"
+	"github.com/shahbajlive/ntm/internal/policy"
+)
+
+// PermissionPromptOutput is one waiting prompt with the policy's verdict.
+type PermissionPromptOutput struct {
+	permissions.Prompt
+	Policy permissions.Verdict `json:"policy"`
+}
+
+// PermissionsListOutput is the JSON output for permissions ls.
+type PermissionsListOutput struct {
+	output.TimestampedResponse
+	Session string                   `json:"session"`
+	Prompts []PermissionPromptOutput `json:"prompts"`
+}
+
+// PermissionAnswerOutput is the JSON output for permissions approve/deny.
+type PermissionAnswerOutput struct {
+	output.TimestampedResponse
+	Session  string               `json:"session"`
+	Decision permissions.Decision `json:"decision"`
+	Prompt   *permissions.Prompt  `json:"prompt"`
+}
+
+// startPermissionWatcher watches session's panes for permission prompts
+// until ctx is done, answering those the project policy decides and
+// announcing the rest. It reports whether the watcher started.
+func startPermissionWatcher(ctx context.Context, session, projectDir string) bool {
+	if cfg == nil || !cfg.Permissions.Enabled {
+		return false
+	}
+	var notifier *notify.Notifier
+	if cfg.Notifications.Enabled {
+		notifier = notify.New(cfg.Notifications)
+	}
+	wcfg := permissions.Config{
+		Interval: time.Duration(cfg.Permissions.PollIntervalSec) * time.Second,
+		OnPrompt: func(p permissions.Prompt, v permissions.Verdict, err error) {
+			switch {
+			case err != nil:
+				fmt.Printf("Permission prompt in %s: failed to %s %q: %v\n", p.PaneID, v.Decision, p.Target(), err)
+			case v.Decision == permissions.DecisionAsk:
+				fmt.Printf("Permission prompt in %s (%s): %s\n", p.PaneID, p.ID, p.Target())
+				if notifier != nil {
+					_ = notifier.Notify(notify.NewPermissionEvent(session, p.PaneID, string(p.Agent), p.ID, p.Target()))
+				}
+			default:
+				fmt.Printf("Permission prompt in %s: policy %s %q (%s)\n", p.PaneID, v.Decision, p.Target(), v.Reason)
+			}
+		},
+	}
+	if cfg.Permissions.AutoAnswer {
+		wcfg.Policy = func() *policy.Policy {
+			p, _, err := policy.LoadForDir(projectDir)
+			if err != nil {
+				// A broken policy file answers nothing; prompts wait for a human.
+				return nil
+			}
+			return p
+		}
+	}
+	go permissions.New(wcfg).Run(ctx, session)
+	return true
+}
+
+func newPermissionsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "permissions",
+		Short: "List and answer agents' tool-permission prompts",
+		Long: `Agents stop on their own permission prompts ("Do you want to proceed?",
+"Allow command?") until someone answers. The session monitor watches for
+them and raises an agent.permission notification for each one a human
+has to answer. With auto_answer on, a prompt whose command matches a
+blocked rule in .ntm/policy.yaml anywhere is denied, and one an allowed
+rule matches in full is approved unless it chains commands with ; & | $(
+or backticks.
+
+Commands are checked against policy rules as-is; edits and fetches are
+checked as "edit <file>" and "fetch <url>".
+
+  [permissions]
+  enabled = true
+  auto_answer = false     # true: answer prompts from policy
+  poll_interval_sec = 3
+
+Examples:
+  ntm permissions ls myproject
+  ntm permissions approve myproject 2
+  ntm permissions deny myproject 2 --id 3f2a9c0d41be`,
+	}
+
+	cmd.AddCommand(
+		newPermissionsListCmd(),
+		newPermissionsAnswerCmd(true),
+		newPermissionsAnswerCmd(false),
+	)
+	return cmd
+}
+
+// resolvePermissionsSession resolves an optional session argument.
+func resolvePermissionsSession(cmd *cobra.Command, session string) (string, error) {
+	res, err := ResolveSession(session, cmd.ErrOrStderr())
+	if err != nil {
+		return "", err
+	}
+	if res.Session == "" {
+		return "", fmt.Errorf("session is required")
+	}
+	res.ExplainIfInferred(cmd.ErrOrStderr())
+	return res.Session, nil
+}
+
+func newPermissionsListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "ls [session]",
+		Aliases: []string{"list"},
+		Short:   "List panes waiting on a permission prompt",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var session string
+			if len(args) > 0 {
+				session = args[0]
+			}
+			session, err := resolvePermissionsSession(cmd, session)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			defer cancel()
+			prompts, err := permissions.New(permissions.Config{}).Scan(ctx, session)
+			if err != nil {
+				return err
+			}
+
+			p, _, _ := policy.LoadForDir(cfg.GetProjectDir(session))
+			out := PermissionsListOutput{Session: session, Prompts: []PermissionPromptOutput{}}
+			for _, pr := range prompts {
+				out.Prompts = append(out.Prompts, PermissionPromptOutput{Prompt: pr, Policy: permissions.Evaluate(p, &pr.PermissionRequest)})
+			}
+			if IsJSONOutput() {
+				out.TimestampedResponse = output.NewTimestamped()
+				return output.PrintJSON(out)
+			}
+			if len(out.Prompts) == 0 {
+				output.PrintInfof("No agents waiting on a permission prompt in %s", session)
+				return nil
+			}
+			fmt.Printf("%-5s %-12s %-6s %-8s %-6s %s\n", "PANE", "ID", "AGENT", "KIND", "POLICY", "REQUEST")
+			for _, pr := range out.Prompts {
+				fmt.Printf("%-5d %-12s %-6s %-8s %-6s %s\n", pr.PaneIndex, pr.ID, pr.Agent, pr.Kind, pr.Policy.Decision,
+					truncateString(pr.Target(), 70))
+			}
+			return nil
+		},
+	}
+}
+
+func newPermissionsAnswerCmd(approve bool) *cobra.Command {
+	var (
+		id     string
+		reason string
+	)
+	use, short := "approve [session] <pane>", "Approve the permission prompt in a pane"
+	if !approve {
+		use, short = "deny [session] <pane>", "Deny the permission prompt in a pane"
+	}
+
+	cmd := &cobra.Command{
+		Use:   use,
+		Short: short,
+		Long: short + `. The pane is given by index or
+tmux pane ID. With --id the answer is only sent if the pane still shows that
+prompt, as listed by 'ntm permissions ls'.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var session string
+			if len(args) == 2 {
+				session = args[0]
+			}
+			session, err := resolvePermissionsSession(cmd, session)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			defer cancel()
+			pr, err := permissions.New(permissions.Config{}).Answer(ctx, session, permissions.Answer{
+				Pane:      args[len(args)-1],
+				RequestID: id,
+				Approve:   approve,
+				By:        "cli",
+				Reason:    reason,
+			})
+			if err != nil {
+				return err
+			}
+
+			decision := permissions.DecisionDeny
+			if approve {
+				decision = permissions.DecisionAllow
+			}
+			if IsJSONOutput() {
+				return output.PrintJSON(PermissionAnswerOutput{
+					TimestampedResponse: output.NewTimestamped(),
+					Session:             session,
+					Decision:            decision,
+					Prompt:              pr,
+				})
+			}
+			verb := "Denied"
+			if approve {
+				verb = "Approved"
+			}
+			fmt.Printf("%s %s request in pane %d: %s\n", verb, pr.Agent.ProfileName(), pr.PaneIndex, pr.Target())
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&id, "id", "", "Only answer if the pane still shows the prompt with this ID")
+	if !approve {
+		cmd.Flags().StringVar(&reason, "reason", "", "Reason recorded in the audit log")
+	}
+	return cmd
+}
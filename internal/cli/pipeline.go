@@ -33,6 +33,9 @@ Subcommands:
   list     List all tracked pipelines
   cancel   Cancel a running pipeline
   cleanup  Remove old pipeline state files
+  runs     Browse the history of pipeline runs
+  diff     Compare the steps of two runs
+  view     Show a run's step graph with live status
 
 Quick ad-hoc pipeline:
   ntm pipeline exec <session> --stage "cc: prompt" --stage "cod: prompt"
@@ -57,7 +60,14 @@ Examples:
   ntm pipeline resume run-20241230-123456-abcd
 
   # Cleanup old state files
-  ntm pipeline cleanup --older=7d`,
+  ntm pipeline cleanup --older=7d
+
+  # Browse run history and compare two runs
+  ntm pipeline runs --workflow review --status failed
+  ntm pipeline diff run-20241230-123456-abcd run-20241231-090000-ef01
+
+  # Watch a run's step graph
+  ntm pipeline view run-20241230-123456-abcd`,
 	}
 
 	cmd.AddCommand(
@@ -67,6 +77,9 @@ Examples:
 		newPipelineCancelCmd(),
 		newPipelineResumeCmd(),
 		newPipelineCleanupCmd(),
+		newPipelineRunsCmd(),
+		newPipelineDiffCmd(),
+		newPipelineViewCmd(),
 		newPipelineExecCmd(), // Backward-compatible stage-based execution
 	)
 
@@ -210,6 +223,8 @@ Examples:
 			execCfg.DryRun = dryRun
 			execCfg.ProjectDir = projectDir
 			execCfg.WorkflowFile = workflowPath
+			defer attachRunIndex(&execCfg)()
+			attachSpendBudget(&execCfg)
 			executor := pipeline.NewExecutor(execCfg)
 
 			// Create progress channel
@@ -507,6 +522,8 @@ Examples:
 			execCfg.RunID = state.RunID
 			execCfg.ProjectDir = projectDir
 			execCfg.WorkflowFile = workflowFile
+			defer attachRunIndex(&execCfg)()
+			attachSpendBudget(&execCfg)
 			executor := pipeline.NewExecutor(execCfg)
 
 			state.Session = session
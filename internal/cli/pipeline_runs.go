@@ -0,0 +1,270 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shahbajlive/ntm/internal/cost"
+	"github.com/shahbajlive/ntm/internal/output"
+	"github.com/shahbajlive/ntm/internal/pipeline"
+	"github.com/shahbajlive/ntm/internal/state"
+)
+
+// PipelineRunsOutput is the JSON output for pipeline runs.
+type PipelineRunsOutput struct {
+	output.TimestampedResponse
+	Runs []state.PipelineRun `json:"runs"`
+}
+
+// PipelineDiffOutput is the JSON output for pipeline diff.
+type PipelineDiffOutput struct {
+	output.TimestampedResponse
+	*pipeline.RunDiff
+}
+
+// openPipelineRunIndex opens the run history index and brings it up to date
+// with the run states persisted under projectDir.
+func openPipelineRunIndex(projectDir string) (*state.Store, *state.PipelineRunStore, error) {
+	store, err := state.Open("")
+	if err != nil {
+		return nil, nil, fmt.Errorf("open state store: %w", err)
+	}
+	if err := store.Migrate(); err != nil {
+		store.Close()
+		return nil, nil, fmt.Errorf("migrate state store: %w", err)
+	}
+	idx := state.NewPipelineRunStore(store)
+	if _, err := pipeline.SyncRunIndex(idx, projectDir); err != nil {
+		store.Close()
+		return nil, nil, fmt.Errorf("sync pipeline run index: %w", err)
+	}
+	return store, idx, nil
+}
+
+// attachRunIndex points an executor config at the run history index so runs
+// are recorded as they progress. The index is best-effort: runs still persist
+// their JSON state when the database is unavailable. The returned func closes
+// the store.
+func attachRunIndex(cfg *pipeline.ExecutorConfig) func() {
+	store, err := state.Open("")
+	if err != nil {
+		return func() {}
+	}
+	if err := store.Migrate(); err != nil {
+		store.Close()
+		return func() {}
+	}
+	cfg.RunIndex = state.NewPipelineRunStore(store)
+	return func() { store.Close() }
+}
+
+func newPipelineRunsCmd() *cobra.Command {
+	var (
+		f     state.PipelineRunFilter
+		since string
+		all   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "runs",
+		Short: "Browse the history of pipeline runs",
+		Long: `List past and in-flight pipeline runs recorded in the run history index,
+most recent first, with their status, duration, retries and estimated cost.
+
+Examples:
+  ntm pipeline runs
+  ntm pipeline runs --workflow review --status failed
+  ntm pipeline runs --since 7d --limit 50
+  ntm pipeline runs --all-projects --json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projectDir, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+			if since != "" {
+				d, err := parseDuration(since)
+				if err != nil {
+					return fmt.Errorf("invalid --since: %w", err)
+				}
+				f.Since = time.Now().Add(-d)
+			}
+			if !all {
+				f.ProjectDir = projectDir
+			}
+
+			store, idx, err := openPipelineRunIndex(projectDir)
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			runs, err := idx.List(f)
+			if err != nil {
+				return err
+			}
+			if IsJSONOutput() {
+				if runs == nil {
+					runs = []state.PipelineRun{}
+				}
+				return output.PrintJSON(PipelineRunsOutput{TimestampedResponse: output.NewTimestamped(), Runs: runs})
+			}
+
+			if len(runs) == 0 {
+				output.PrintInfof("No pipeline runs recorded; start one with 'ntm pipeline run'")
+				return nil
+			}
+			fmt.Printf("%-34s %-20s %-10s %9s %6s %7s %9s  %s\n",
+				"RUN", "WORKFLOW", "STATUS", "DURATION", "STEPS", "RETRIES", "COST", "STARTED")
+			for _, r := range runs {
+				steps := fmt.Sprintf("%d", r.StepCount)
+				if r.FailedSteps > 0 {
+					steps = fmt.Sprintf("%d/%d✗", r.FailedSteps, r.StepCount)
+				}
+				fmt.Printf("%-34s %-20s %-10s %9s %6s %7d %9s  %s\n",
+					r.RunID, truncateString(r.WorkflowID, 20), r.Status,
+					formatDuration(time.Duration(r.DurationMs)*time.Millisecond), steps, r.Retries,
+					cost.FormatCost(r.CostUSD), r.StartedAt.Local().Format("2006-01-02 15:04"))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&f.WorkflowID, "workflow", "", "Only runs of this workflow")
+	cmd.Flags().StringVar(&f.Status, "status", "", "Only runs with this status (running, completed, failed, cancelled)")
+	cmd.Flags().StringVarP(&f.SessionName, "session", "s", "", "Only runs in this tmux session")
+	cmd.Flags().StringVar(&since, "since", "", "Only runs started within this duration (e.g. 24h, 7d)")
+	cmd.Flags().IntVar(&f.Limit, "limit", 20, "Maximum runs to list (0 for all)")
+	cmd.Flags().BoolVar(&all, "all-projects", false, "Include runs from every project, not just the current directory")
+	return cmd
+}
+
+func newPipelineDiffCmd() *cobra.Command {
+	var (
+		showOutput bool
+		changed    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "diff <run-a> <run-b>",
+		Short: "Compare the steps of two pipeline runs",
+		Long: `Compare two pipeline runs step by step: status, retries, timing and
+output. Useful for seeing what changed between a passing and a failing run
+of the same workflow.
+
+Examples:
+  ntm pipeline diff run-20260301-101500-ab12 run-20260302-091200-cd34
+  ntm pipeline diff <run-a> <run-b> --output
+  ntm pipeline diff <run-a> <run-b> --json`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projectDir, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+			store, idx, err := openPipelineRunIndex(projectDir)
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			var runs [2]*state.PipelineRun
+			for i, id := range args {
+				run, err := idx.Get(id)
+				if err != nil {
+					return err
+				}
+				if run == nil {
+					return fmt.Errorf("pipeline run %q not found (use 'ntm pipeline runs' to list runs)", id)
+				}
+				runs[i] = run
+			}
+
+			d := pipeline.DiffRuns(runs[0], runs[1])
+			if IsJSONOutput() {
+				return output.PrintJSON(PipelineDiffOutput{TimestampedResponse: output.NewTimestamped(), RunDiff: d})
+			}
+			printRunDiff(d, showOutput, changed)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&showOutput, "output", false, "Show line diffs of changed step outputs")
+	cmd.Flags().BoolVar(&changed, "changed", false, "Only list steps whose status, retries or output differ")
+	return cmd
+}
+
+func printRunDiff(d *pipeline.RunDiff, showOutput, changedOnly bool) {
+	fmt.Printf("A: %s  %s  %s\n", d.RunA, d.WorkflowA, d.StatusA)
+	fmt.Printf("B: %s  %s  %s\n", d.RunB, d.WorkflowB, d.StatusB)
+	fmt.Printf("Duration: %s   Cost: %s\n", formatDurationDelta(d.DurationDelta), formatCostDelta(d.CostDelta))
+
+	if len(d.VarChanges) > 0 {
+		fmt.Println("\nVariables:")
+		keys := make([]string, 0, len(d.VarChanges))
+		for k := range d.VarChanges {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			v := d.VarChanges[k]
+			fmt.Printf("  %s: %v → %v\n", k, orDash(v[0]), orDash(v[1]))
+		}
+	}
+
+	fmt.Println("\nSteps:")
+	for _, s := range d.Steps {
+		if changedOnly && !s.Changed() {
+			continue
+		}
+		marker := " "
+		if s.Changed() {
+			marker = "~"
+		}
+		status := s.StatusA
+		if s.StatusA != s.StatusB {
+			status = fmt.Sprintf("%s → %s", orDash(s.StatusA), orDash(s.StatusB))
+		}
+		line := fmt.Sprintf("%s %-24s %-24s %s", marker, s.StepID, status, formatDurationDelta(s.DurationDelta))
+		if s.AttemptsA != s.AttemptsB {
+			line += fmt.Sprintf("  attempts %d → %d", s.AttemptsA, s.AttemptsB)
+		}
+		if s.OutputChanged {
+			line += fmt.Sprintf("  output %.0f%% similar", s.Similarity*100)
+		}
+		fmt.Println(line)
+		if showOutput && s.OutputDiff != "" {
+			for _, l := range strings.Split(strings.TrimSuffix(s.OutputDiff, "\n"), "\n") {
+				fmt.Printf("      %s\n", l)
+			}
+		}
+	}
+}
+
+// formatDelta renders a millisecond difference with an explicit sign.
+func formatDurationDelta(ms int64) string {
+	d := time.Duration(ms) * time.Millisecond
+	if d < 0 {
+		return "-" + (-d).Round(100*time.Millisecond).String()
+	}
+	return "+" + d.Round(100*time.Millisecond).String()
+}
+
+func formatCostDelta(usd float64) string {
+	if usd < 0 {
+		return "-" + cost.FormatCost(-usd)
+	}
+	return "+" + cost.FormatCost(usd)
+}
+
+func orDash(v any) any {
+	if v == nil || v == "" {
+		return "—"
+	}
+	return v
+}
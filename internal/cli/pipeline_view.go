@@ -0,0 +1,273 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"github.com/shahbajlive/ntm/internal/output"
+	"github.com/shahbajlive/ntm/internal/pipeline"
+	"github.com/shahbajlive/ntm/internal/state"
+	"github.com/shahbajlive/ntm/internal/tui/theme"
+)
+
+// pipelineViewStep is one node of the DAG view.
+type pipelineViewStep struct {
+	ID        string   `json:"id"`
+	Status    string   `json:"status"`
+	DependsOn []string `json:"depends_on,omitempty"`
+	Agent     string   `json:"agent,omitempty"`
+	Attempts  int      `json:"attempts,omitempty"`
+	Duration  string   `json:"duration,omitempty"`
+}
+
+// PipelineViewOutput is the JSON output for pipeline view.
+type PipelineViewOutput struct {
+	output.TimestampedResponse
+	RunID    string                      `json:"run_id"`
+	Workflow string                      `json:"workflow"`
+	Status   string                      `json:"status"`
+	Live     bool                        `json:"live"`
+	Levels   [][]string                  `json:"levels"`
+	Steps    map[string]pipelineViewStep `json:"steps"`
+}
+
+// loadPipelineView assembles the DAG of a run. Statuses come from the run's
+// persisted state while it exists (so in-flight runs are live) and from the
+// history index otherwise. The DAG comes from the workflow file when it can
+// still be parsed; without it every step is drawn on a single level.
+func loadPipelineView(projectDir, runID string, idx *state.PipelineRunStore) (*PipelineViewOutput, error) {
+	view := &PipelineViewOutput{RunID: runID, Steps: make(map[string]pipelineViewStep)}
+	var workflowFile string
+
+	if st, err := pipeline.LoadState(projectDir, runID); err == nil {
+		view.Workflow, view.Status, workflowFile = st.WorkflowID, string(st.Status), st.WorkflowFile
+		view.Live = st.Status == pipeline.StatusRunning || st.Status == pipeline.StatusPaused
+		for id, res := range st.Steps {
+			step := pipelineViewStep{ID: id, Status: string(res.Status), Agent: res.AgentType, Attempts: res.Attempts}
+			switch {
+			case !res.FinishedAt.IsZero() && !res.StartedAt.IsZero():
+				step.Duration = res.FinishedAt.Sub(res.StartedAt).Round(time.Second).String()
+			case res.Status == pipeline.StatusRunning && !res.StartedAt.IsZero():
+				step.Duration = time.Since(res.StartedAt).Round(time.Second).String()
+			}
+			view.Steps[id] = step
+		}
+	} else if idx != nil {
+		run, err := idx.Get(runID)
+		if err != nil {
+			return nil, err
+		}
+		if run == nil {
+			return nil, fmt.Errorf("pipeline run %q not found (use 'ntm pipeline runs' to list runs)", runID)
+		}
+		view.Workflow, view.Status, workflowFile = run.WorkflowID, run.Status, run.WorkflowFile
+		for _, s := range run.Steps {
+			view.Steps[s.StepID] = pipelineViewStep{
+				ID: s.StepID, Status: s.Status, Agent: s.AgentType, Attempts: s.Attempts,
+				Duration: (time.Duration(s.DurationMs) * time.Millisecond).Round(time.Second).String(),
+			}
+		}
+	} else {
+		return nil, err
+	}
+
+	if workflowFile != "" {
+		if wf, err := pipeline.ParseFile(workflowFile); err == nil {
+			graph := pipeline.NewDependencyGraph(wf)
+			plan := graph.Resolve()
+			if plan.Valid {
+				view.Levels = plan.Levels
+				for _, level := range plan.Levels {
+					for _, id := range level {
+						step := view.Steps[id]
+						step.ID = id
+						if step.Status == "" {
+							step.Status = string(pipeline.StatusPending)
+						}
+						step.DependsOn = graph.GetDependencies(id)
+						view.Steps[id] = step
+					}
+				}
+			}
+		}
+	}
+	if view.Levels == nil {
+		ids := make([]string, 0, len(view.Steps))
+		for id := range view.Steps {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		view.Levels = [][]string{ids}
+	}
+	return view, nil
+}
+
+type pipelineViewModel struct {
+	projectDir string
+	runID      string
+	idx        *state.PipelineRunStore
+	view       *PipelineViewOutput
+	err        error
+	width      int
+	theme      theme.Theme
+}
+
+type pipelineViewTickMsg struct{}
+
+func pipelineViewTick() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg { return pipelineViewTickMsg{} })
+}
+
+func (m pipelineViewModel) Init() tea.Cmd {
+	if m.view != nil && m.view.Live {
+		return pipelineViewTick()
+	}
+	return nil
+}
+
+func (m pipelineViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return m, tea.Quit
+		case "r":
+			m.view, m.err = loadPipelineView(m.projectDir, m.runID, m.idx)
+		}
+	case pipelineViewTickMsg:
+		m.view, m.err = loadPipelineView(m.projectDir, m.runID, m.idx)
+		if m.err == nil && m.view.Live {
+			return m, pipelineViewTick()
+		}
+	}
+	return m, nil
+}
+
+func (m pipelineViewModel) statusStyle(status string) (lipgloss.Style, string) {
+	t := m.theme
+	style := lipgloss.NewStyle()
+	switch pipeline.ExecutionStatus(status) {
+	case pipeline.StatusCompleted:
+		return style.Foreground(t.Green), "✓"
+	case pipeline.StatusFailed:
+		return style.Foreground(t.Red), "✗"
+	case pipeline.StatusRunning:
+		return style.Foreground(t.Blue).Bold(true), "▶"
+	case pipeline.StatusPaused:
+		return style.Foreground(t.Yellow), "‖"
+	case pipeline.StatusSkipped, pipeline.StatusCancelled:
+		return style.Foreground(t.Overlay), "⊘"
+	}
+	return style.Foreground(t.Subtext), "○"
+}
+
+func (m pipelineViewModel) View() string {
+	t := m.theme
+	var b strings.Builder
+	if m.err != nil {
+		b.WriteString(lipgloss.NewStyle().Foreground(t.Red).Render("Error: " + m.err.Error()))
+		b.WriteString("\n\n")
+	}
+	if m.view == nil {
+		return b.String()
+	}
+	v := m.view
+
+	runStyle, runIcon := m.statusStyle(v.Status)
+	b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(t.Primary).Render(v.Workflow))
+	b.WriteString("  " + lipgloss.NewStyle().Foreground(t.Subtext).Render(v.RunID) + "  ")
+	b.WriteString(runStyle.Render(runIcon + " " + v.Status))
+	if v.Live {
+		b.WriteString(lipgloss.NewStyle().Foreground(t.Overlay).Render("  (live)"))
+	}
+	b.WriteString("\n\n")
+
+	box := lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
+	labelStyle := lipgloss.NewStyle().Foreground(t.Overlay).Width(4)
+	for i, level := range v.Levels {
+		var boxes []string
+		for _, id := range level {
+			step := v.Steps[id]
+			style, icon := m.statusStyle(step.Status)
+			lines := []string{style.Render(icon + " " + id)}
+			var meta []string
+			if step.Agent != "" {
+				meta = append(meta, step.Agent)
+			}
+			if step.Duration != "" && step.Duration != "0s" {
+				meta = append(meta, step.Duration)
+			}
+			if step.Attempts > 1 {
+				meta = append(meta, fmt.Sprintf("×%d", step.Attempts))
+			}
+			if len(meta) > 0 {
+				lines = append(lines, lipgloss.NewStyle().Foreground(t.Subtext).Render(strings.Join(meta, " · ")))
+			}
+			if len(step.DependsOn) > 0 {
+				lines = append(lines, lipgloss.NewStyle().Foreground(t.Overlay).Render("← "+strings.Join(step.DependsOn, ", ")))
+			}
+			boxes = append(boxes, box.BorderForeground(style.GetForeground()).Render(strings.Join(lines, "\n")))
+		}
+		row := lipgloss.JoinHorizontal(lipgloss.Top, boxes...)
+		if m.width > 0 && lipgloss.Width(row) > m.width-4 {
+			row = lipgloss.JoinVertical(lipgloss.Left, boxes...)
+		}
+		b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, labelStyle.Render(fmt.Sprintf("L%d", i+1)), row))
+		b.WriteString("\n")
+		if i < len(v.Levels)-1 {
+			b.WriteString(lipgloss.NewStyle().Foreground(t.Overlay).Render("     ↓"))
+			b.WriteString("\n")
+		}
+	}
+	b.WriteString("\n" + lipgloss.NewStyle().Foreground(t.Overlay).Render("r refresh · q quit"))
+	return lipgloss.NewStyle().Padding(1, 2).Render(b.String())
+}
+
+func newPipelineViewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "view <run-id>",
+		Short: "Show a run's step graph with live or recorded status",
+		Long: `Draw the dependency graph of a pipeline run level by level, colouring each
+step by its status. Runs still in progress refresh every second; finished
+runs are shown from their saved state or the run history index.
+
+Examples:
+  ntm pipeline view run-20260301-101500-ab12
+  ntm pipeline view run-20260301-101500-ab12 --json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projectDir, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+			var idx *state.PipelineRunStore
+			if store, runIndex, err := openPipelineRunIndex(projectDir); err == nil {
+				defer store.Close()
+				idx = runIndex
+			}
+
+			view, err := loadPipelineView(projectDir, args[0], idx)
+			if err != nil {
+				return err
+			}
+			if IsJSONOutput() {
+				view.TimestampedResponse = output.NewTimestamped()
+				return output.PrintJSON(view)
+			}
+
+			model := pipelineViewModel{projectDir: projectDir, runID: args[0], idx: idx, view: view, theme: theme.Current()}
+			_, err = tea.NewProgram(model, tea.WithAltScreen()).Run()
+			return err
+		},
+	}
+	return cmd
+}
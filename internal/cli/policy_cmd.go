@@ -30,7 +30,8 @@ The policy system controls:
 Use 'ntm policy show' to see the current policy.
 Use 'ntm policy validate' to check policy file syntax.
 Use 'ntm policy reset' to reset to defaults.
-Use 'ntm policy edit' to open in your editor.`,
+Use 'ntm policy edit' to open in your editor.
+Use 'ntm policy serve' to run the decision service queried by agent hooks.`,
 	}
 
 	cmd.AddCommand(
@@ -39,6 +40,9 @@ Use 'ntm policy edit' to open in your editor.`,
 		newPolicyResetCmd(),
 		newPolicyEditCmd(),
 		newPolicyAutomationCmd(),
+		newPolicyServeCmd(),
+		newPolicyHookCmd(),
+		newPolicyInstallHooksCmd(),
 	)
 
 	return cmd
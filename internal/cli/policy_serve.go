@@ -0,0 +1,430 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shahbajlive/ntm/internal/approval"
+	"github.com/shahbajlive/ntm/internal/audit"
+	"github.com/shahbajlive/ntm/internal/notify"
+	"github.com/shahbajlive/ntm/internal/output"
+	"github.com/shahbajlive/ntm/internal/policy"
+	"github.com/shahbajlive/ntm/internal/tmux"
+)
+
+func newPolicyServeCmd() *cobra.Command {
+	var (
+		socketPath      string
+		approvalTimeout time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run the policy decision service for agent hooks",
+		Long: `Run a policy decision service on a Unix socket.
+
+Agent hooks ('ntm policy hook <agent>') and the PATH wrappers installed by
+'ntm safety install' send every shell command here before it runs. The service
+evaluates the nearest .ntm/policy.yaml for the command's working directory,
+identifies the calling pane from $TMUX_PANE, and records each decision in the
+audit log.
+
+Commands matching approval_required block until the request is resolved with
+'ntm approve' or 'ntm approve deny', or until --approval-timeout passes.
+
+Examples:
+  ntm policy serve
+  ntm policy serve --approval-timeout 30m`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPolicyServe(socketPath, approvalTimeout)
+		},
+	}
+
+	cmd.Flags().StringVar(&socketPath, "socket", "", "Socket path (default ~/.ntm/policy.sock)")
+	cmd.Flags().DurationVar(&approvalTimeout, "approval-timeout", policy.DefaultApprovalTimeout, "How long approval_required commands wait for a decision")
+
+	return cmd
+}
+
+func runPolicyServe(socketPath string, approvalTimeout time.Duration) error {
+	if socketPath == "" {
+		socketPath = policy.DefaultSocketPath()
+	}
+
+	// Reuse the shared state database; the engine is rebuilt with a notifier.
+	_, store, err := getApprovalEngine()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	var notifier *notify.Notifier
+	if cfg != nil {
+		notifier = notify.New(cfg.Notifications)
+	}
+	engine := approval.New(store, notifier, nil, approval.DefaultConfig())
+
+	blockedLog, err := policy.NewBlockedLogger("")
+	if err != nil {
+		blockedLog = nil
+	} else {
+		defer blockedLog.Close()
+	}
+
+	srv := policy.NewServer(policy.ServerConfig{
+		Approver:        policyApprover{engine: engine, timeout: approvalTimeout},
+		ApprovalTimeout: approvalTimeout,
+		Identify:        identifyPolicyPane,
+		Record: func(req policy.Request, d policy.Decision) {
+			recordPolicyDecision(blockedLog, req, d)
+		},
+	})
+
+	ln, err := policy.Listen(socketPath)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if !IsJSONOutput() {
+		fmt.Printf("Policy service listening on %s\n", socketPath)
+		fmt.Println("Press Ctrl+C to stop.")
+	}
+	return srv.Serve(ctx, ln)
+}
+
+// policyApprover files approval_required commands with the approval engine.
+// Decisions made by 'ntm approve' in another process land in the shared
+// store, so status is read back through Check rather than in-process waiters.
+type policyApprover struct {
+	engine  *approval.Engine
+	timeout time.Duration
+}
+
+func (a policyApprover) RequestApproval(ctx context.Context, req policy.Request, id policy.Identity, m *policy.Match) (string, error) {
+	requestedBy := id.PaneTitle
+	if requestedBy == "" {
+		requestedBy = firstNonEmpty(req.Agent, "agent")
+	}
+	appr, err := a.engine.Request(ctx, approval.RequestParams{
+		Action:        "command",
+		Resource:      req.Command,
+		Reason:        firstNonEmpty(m.Reason, "matches approval_required pattern "+m.Pattern),
+		RequestedBy:   requestedBy,
+		CorrelationID: id.Session,
+		RequiresSLB:   m.SLB,
+		ExpiresIn:     a.timeout,
+	})
+	if err != nil {
+		return "", err
+	}
+	return appr.ID, nil
+}
+
+func (a policyApprover) ApprovalStatus(ctx context.Context, approvalID string) (string, string, error) {
+	appr, err := a.engine.Check(ctx, approvalID)
+	if err != nil {
+		return "", "", err
+	}
+	return string(appr.Status), appr.DeniedReason, nil
+}
+
+// identifyPolicyPane maps a tmux pane id to its session, title and agent type.
+func identifyPolicyPane(paneID string) policy.Identity {
+	session, err := tmux.DefaultClient.Run("display-message", "-p", "-t", paneID, "#{session_name}")
+	if err != nil {
+		return policy.Identity{}
+	}
+	id := policy.Identity{Session: strings.TrimSpace(session)}
+	panes, err := tmux.GetPanes(id.Session)
+	if err != nil {
+		return id
+	}
+	for _, p := range panes {
+		if p.ID == paneID {
+			id.PaneTitle = p.Title
+			id.AgentType = string(p.Type)
+			break
+		}
+	}
+	return id
+}
+
+func recordPolicyDecision(blockedLog *policy.BlockedLogger, req policy.Request, d policy.Decision) {
+	payload := map[string]interface{}{
+		"command": req.Command,
+		"action":  string(d.Action),
+		"allowed": d.Allowed,
+		"agent":   req.Agent,
+	}
+	if req.Tool != "" {
+		payload["tool"] = req.Tool
+	}
+	if req.Pane != "" {
+		payload["pane"] = req.Pane
+	}
+	if d.Identity.PaneTitle != "" {
+		payload["pane_title"] = d.Identity.PaneTitle
+	}
+	if d.Pattern != "" {
+		payload["pattern"] = d.Pattern
+	}
+	if d.Reason != "" {
+		payload["reason"] = d.Reason
+	}
+	if d.ApprovalID != "" {
+		payload["approval_id"] = d.ApprovalID
+		payload["approval_status"] = d.ApprovalStatus
+	}
+	if d.PolicyPath != "" {
+		payload["policy"] = d.PolicyPath
+	}
+	_ = audit.LogEvent(d.Identity.Session, audit.EventTypeCommand, audit.ActorAgent, "policy.decision", payload, nil)
+
+	if !d.Allowed && blockedLog != nil {
+		agent := firstNonEmpty(d.Identity.PaneTitle, req.Agent)
+		_ = blockedLog.LogBlocked(d.Identity.Session, agent, req.Command, d.Pattern, policy.DenyMessage(d))
+	}
+}
+
+func newPolicyHookCmd() *cobra.Command {
+	var (
+		socketPath string
+		timeout    time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "hook <claude|codex|gemini|wrapper> [-- command...]",
+		Short: "Check an agent tool call against the policy service",
+		Long: `Adapter between an agent's pre-tool hook and the policy service.
+
+  claude   Claude Code PreToolUse hook (reads the hook JSON on stdin)
+  gemini   Gemini CLI BeforeTool hook (reads the hook JSON on stdin)
+  codex    Codex command check (JSON with a "command" argv on stdin, or the
+           command as arguments)
+  wrapper  PATH wrappers; the command is passed as arguments
+
+Denied commands exit 2 with the reason on stderr (1 for wrappers), as do hook
+input that cannot be parsed and requests the service accepted but did not
+answer. When the service is not running the policy is evaluated locally and
+approval_required commands are denied.
+
+Use 'ntm policy install-hooks' to register the hooks with each agent.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPolicyHook(policy.HookAgent(args[0]), args[1:], socketPath, timeout)
+		},
+	}
+
+	cmd.Flags().StringVar(&socketPath, "socket", "", "Socket path (default ~/.ntm/policy.sock)")
+	cmd.Flags().DurationVar(&timeout, "timeout", policy.DefaultApprovalTimeout+time.Minute, "Maximum time to wait for a decision")
+
+	return cmd
+}
+
+func runPolicyHook(agent policy.HookAgent, args []string, socketPath string, timeout time.Duration) error {
+	if !isHookAgent(agent) {
+		return fmt.Errorf("unknown hook agent %q (want one of %s)", agent, hookAgentList())
+	}
+
+	// A request that cannot be read is denied rather than returned as an
+	// error: Claude Code treats any exit status but 2 as a non-blocking
+	// failure and would run the tool anyway.
+	var stdin []byte
+	if agent != policy.HookWrapper && len(args) == 0 {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return writeHookResult(agent, hookInputDenied(err))
+		}
+		stdin = data
+	}
+
+	req, check, err := policy.ParseHookInput(agent, stdin, args)
+	if err != nil {
+		return writeHookResult(agent, hookInputDenied(err))
+	}
+	if !check {
+		res := policy.FormatHookResult(agent, policy.Decision{Allowed: true, Action: policy.ActionAllow})
+		fmt.Fprint(os.Stdout, res.Stdout)
+		return nil
+	}
+	if req.Cwd == "" {
+		req.Cwd, _ = os.Getwd()
+	}
+	req.Pane = os.Getenv("TMUX_PANE")
+	req.Session = os.Getenv("NTM_SESSION")
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	d, err := policy.Query(ctx, socketPath, req)
+	if err != nil && !errors.Is(err, policy.ErrServerNotRunning) {
+		// The service took the request but no decision came back.
+		return writeHookResult(agent, policy.Decision{Action: policy.ActionBlock, Reason: "no decision from the policy service", Error: err.Error()})
+	}
+	if err != nil {
+		d = policy.EvaluateLocal(req)
+		if agent == policy.HookWrapper && d.ApprovalStatus == "unavailable" {
+			// PATH wrappers have always let approval_required commands through
+			// when nobody can approve them; keep that until a service runs.
+			fmt.Fprintf(os.Stderr, "NTM policy: %s requires approval (%s); proceeding because the policy service is not running\n", req.Command, d.Reason)
+			return nil
+		}
+		if !d.Allowed {
+			if blockedLog, lerr := policy.NewBlockedLogger(""); lerr == nil {
+				_ = blockedLog.LogBlocked(req.Session, req.Agent, req.Command, d.Pattern, policy.DenyMessage(d))
+				blockedLog.Close()
+			}
+		}
+	}
+
+	return writeHookResult(agent, d)
+}
+
+// hookInputDenied is the decision for a hook request that could not be read.
+func hookInputDenied(err error) policy.Decision {
+	return policy.Decision{Action: policy.ActionBlock, Reason: "malformed hook input", Error: err.Error()}
+}
+
+// writeHookResult reports d in agent's hook format and exits with its status.
+func writeHookResult(agent policy.HookAgent, d policy.Decision) error {
+	res := policy.FormatHookResult(agent, d)
+	fmt.Fprint(os.Stdout, res.Stdout)
+	fmt.Fprint(os.Stderr, res.Stderr)
+	if res.ExitCode != 0 {
+		os.Exit(res.ExitCode)
+	}
+	return nil
+}
+
+func isHookAgent(agent policy.HookAgent) bool {
+	for _, a := range policy.HookAgents {
+		if a == agent {
+			return true
+		}
+	}
+	return false
+}
+
+func hookAgentList() string {
+	names := make([]string, len(policy.HookAgents))
+	for i, a := range policy.HookAgents {
+		names[i] = string(a)
+	}
+	return strings.Join(names, ", ")
+}
+
+// PolicyHookInstallResult is the JSON output for policy install-hooks.
+type PolicyHookInstallResult struct {
+	output.TimestampedResponse
+	Installed []PolicyHookInstall `json:"installed"`
+}
+
+// PolicyHookInstall describes one agent's hook registration.
+type PolicyHookInstall struct {
+	Agent    string `json:"agent"`
+	Settings string `json:"settings,omitempty"`
+	Changed  bool   `json:"changed"`
+	Note     string `json:"note,omitempty"`
+}
+
+func newPolicyInstallHooksCmd() *cobra.Command {
+	var agents []string
+
+	cmd := &cobra.Command{
+		Use:   "install-hooks",
+		Short: "Register policy hooks with Claude Code and Gemini CLI",
+		Long: `Register 'ntm policy hook' as a pre-tool hook in each agent's user settings.
+
+  claude  ~/.claude/settings.json  PreToolUse, matcher Bash
+  gemini  ~/.gemini/settings.json  BeforeTool, matcher run_shell_command
+  codex   Codex has no pre-tool hook setting; its commands are covered by
+          the PATH wrappers from 'ntm safety install', which query the
+          policy service.
+
+Existing settings are preserved and the command is idempotent.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPolicyInstallHooks(agents)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&agents, "agent", []string{"claude", "gemini", "codex"}, "Agents to install hooks for")
+
+	return cmd
+}
+
+func runPolicyInstallHooks(agents []string) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("getting home directory: %w", err)
+	}
+
+	result := PolicyHookInstallResult{TimestampedResponse: output.NewTimestamped()}
+	for _, name := range agents {
+		agent := policy.HookAgent(strings.TrimSpace(name))
+		if !isHookAgent(agent) || agent == policy.HookWrapper {
+			return fmt.Errorf("unknown agent %q (want claude, gemini or codex)", name)
+		}
+
+		settingsPath := policy.HookSettingsPath(home, agent)
+		if settingsPath == "" {
+			result.Installed = append(result.Installed, PolicyHookInstall{
+				Agent: string(agent),
+				Note:  "covered by PATH wrappers; run 'ntm safety install'",
+			})
+			continue
+		}
+
+		data, err := os.ReadFile(settingsPath)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("reading %s: %w", settingsPath, err)
+		}
+		merged, changed, err := policy.MergeHookSettings(data, agent, "ntm policy hook "+string(agent))
+		if err != nil {
+			return fmt.Errorf("%s: %w", settingsPath, err)
+		}
+		if changed {
+			if err := os.MkdirAll(filepath.Dir(settingsPath), 0755); err != nil {
+				return fmt.Errorf("creating %s: %w", filepath.Dir(settingsPath), err)
+			}
+			if err := os.WriteFile(settingsPath, merged, 0644); err != nil {
+				return fmt.Errorf("writing %s: %w", settingsPath, err)
+			}
+		}
+		result.Installed = append(result.Installed, PolicyHookInstall{
+			Agent:    string(agent),
+			Settings: settingsPath,
+			Changed:  changed,
+		})
+	}
+
+	if IsJSONOutput() {
+		return output.PrintJSON(result)
+	}
+
+	for _, inst := range result.Installed {
+		switch {
+		case inst.Note != "":
+			fmt.Printf("  %-7s %s\n", inst.Agent, inst.Note)
+		case inst.Changed:
+			fmt.Printf("  %-7s hook added to %s\n", inst.Agent, inst.Settings)
+		default:
+			fmt.Printf("  %-7s already installed in %s\n", inst.Agent, inst.Settings)
+		}
+	}
+	fmt.Println()
+	fmt.Println("Start the decision service with: ntm policy serve")
+	return nil
+}
@@ -145,7 +145,7 @@ func runPreflight(prompt string, strict bool) (*PreflightResult, error) {
 		case redaction.ModeOff:
 			// Redaction mode off explicitly disables scanning; keep lint in sync.
 			ruleSet.Disable(lint.RuleSecretDetected)
-		case redaction.ModeWarn, redaction.ModeRedact:
+		case redaction.ModeWarn, redaction.ModeRedact, redaction.ModePseudonymize:
 			// Warn + redact modes should not block sends on secrets.
 			ruleSet.SetSeverity(lint.RuleSecretDetected, lint.SeverityWarning)
 		case redaction.ModeBlock:
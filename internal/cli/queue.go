@@ -0,0 +1,406 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shahbajlive/ntm/internal/output"
+	"github.com/shahbajlive/ntm/internal/promptqueue"
+	sessionPkg "github.com/shahbajlive/ntm/internal/session"
+	"github.com/shahbajlive/ntm/internal/state"
+	"github.com/shahbajlive/ntm/internal/tmux"
+)
+
+// QueueListOutput is the JSON output for queue ls.
+type QueueListOutput struct {
+	output.TimestampedResponse
+	Prompts []state.QueuedPrompt `json:"prompts"`
+}
+
+// QueueEnqueueOutput is the JSON output for send --queue.
+type QueueEnqueueOutput struct {
+	output.TimestampedResponse
+	Session    string                 `json:"session"`
+	Queued     []state.QueuedPrompt   `json:"queued"`
+	Duplicates []int64                `json:"duplicates,omitempty"` // Existing prompts that matched --dedup
+	Delivered  []promptqueue.Delivery `json:"delivered,omitempty"`  // Delivered at once to idle panes
+}
+
+// openPromptQueue opens the state store's prompt queue. The caller closes the
+// returned store.
+func openPromptQueue() (*state.Store, *state.PromptQueueStore, error) {
+	store, err := openTranscriptStore()
+	if err != nil {
+		return nil, nil, err
+	}
+	return store, state.NewPromptQueueStore(store), nil
+}
+
+// newPromptDispatcher returns a dispatcher that types prompts the way 'ntm
+// send' does. Prompts for a paused session, or one over its spend budget,
+// stay queued until sends are allowed again.
+func newPromptDispatcher(qs *state.PromptQueueStore) *promptqueue.Dispatcher {
+	hold := func(session string, p tmux.Pane) error {
+		if err := sessionPkg.CheckSends(session); err != nil {
+			return err
+		}
+		return enforceSpendBudget(session, []string{string(p.Type)}, "queued prompt")
+	}
+	return promptqueue.New(promptqueue.Config{Store: qs, Send: sendPromptToPane, Hold: hold})
+}
+
+// startPromptQueueDispatcher delivers queued prompts for session until ctx is
+// done. The queue is best-effort: if the state store cannot be opened the
+// session simply runs without one, and false is returned.
+func startPromptQueueDispatcher(ctx context.Context, session string) bool {
+	store, qs, err := openPromptQueue()
+	if err != nil {
+		return false
+	}
+	go func() {
+		defer store.Close()
+		newPromptDispatcher(qs).Run(ctx, session)
+	}()
+	return true
+}
+
+// enqueueSendPrompt queues prompt for the selected panes instead of sending
+// it, then delivers at once to any that are already idle.
+func enqueueSendPrompt(opts SendOptions, panes []tmux.Pane, prompt string) error {
+	if len(panes) == 0 {
+		return fmt.Errorf("no matching panes found")
+	}
+	store, qs, err := openPromptQueue()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	var expires *time.Time
+	if opts.QueueTTL > 0 {
+		t := time.Now().Add(opts.QueueTTL).UTC()
+		expires = &t
+	}
+
+	var items []state.QueuedPrompt
+	if opts.QueueAnyIdle {
+		seen := make(map[tmux.AgentType]bool)
+		for _, p := range panes {
+			if p.Type == tmux.AgentUser || seen[p.Type] {
+				continue
+			}
+			seen[p.Type] = true
+			items = append(items, state.QueuedPrompt{AgentType: string(p.Type)})
+		}
+		if len(items) == 0 {
+			return fmt.Errorf("--first-idle needs at least one agent pane")
+		}
+	} else {
+		for _, p := range panes {
+			idx := p.Index
+			items = append(items, state.QueuedPrompt{PaneID: p.ID, PaneIndex: &idx, AgentType: string(p.Type)})
+		}
+	}
+
+	result := QueueEnqueueOutput{Session: opts.Session, Queued: []state.QueuedPrompt{}}
+	for _, item := range items {
+		item.SessionName = opts.Session
+		item.Prompt = prompt
+		item.Priority = opts.QueuePriority
+		item.DedupKey = opts.QueueDedup
+		item.Source = "cli"
+		item.ExpiresAt = expires
+		q, created, err := qs.Enqueue(&item)
+		if err != nil {
+			return err
+		}
+		if !created {
+			result.Duplicates = append(result.Duplicates, q.ID)
+			continue
+		}
+		result.Queued = append(result.Queued, *q)
+	}
+
+	// Idle panes needn't wait for the session monitor's next poll.
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if deliveries, err := newPromptDispatcher(qs).DispatchOnce(ctx, opts.Session); err == nil {
+		result.Delivered = deliveries
+	}
+
+	if IsJSONOutput() {
+		result.TimestampedResponse = output.NewTimestamped()
+		return output.PrintJSON(result)
+	}
+	delivered := 0
+	for _, d := range result.Delivered {
+		if d.Error == "" {
+			delivered++
+		}
+	}
+	fmt.Printf("Queued %d prompt(s); %d delivered to idle panes, %d waiting\n",
+		len(result.Queued), delivered, len(result.Queued)-delivered)
+	if len(result.Duplicates) > 0 {
+		fmt.Printf("Skipped %d target(s) already queued with dedup key %q\n", len(result.Duplicates), opts.QueueDedup)
+	}
+	return nil
+}
+
+func newQueueCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "queue",
+		Short: "Inspect and manage prompts waiting for idle agents",
+		Long: `Prompts sent with 'ntm send --queue' wait in a per-pane queue and are typed
+into the pane when the status detector sees it go idle. Prompts sent with
+--first-idle go to whichever pane of their agent type is idle first.
+
+Queues are ordered by priority (0 first) and then by the order prompts were
+added. The session monitor delivers them while the session runs.
+
+Examples:
+  ntm queue ls myproject
+  ntm queue ls myproject --status all
+  ntm queue rm 12 13
+  ntm queue reorder 14 1
+  ntm queue dispatch myproject`,
+	}
+
+	cmd.AddCommand(
+		newQueueListCmd(),
+		newQueueRemoveCmd(),
+		newQueueReorderCmd(),
+		newQueueDispatchCmd(),
+	)
+	return cmd
+}
+
+func newQueueListCmd() *cobra.Command {
+	var (
+		f    state.PromptQueueFilter
+		pane int
+	)
+
+	cmd := &cobra.Command{
+		Use:     "ls [session]",
+		Aliases: []string{"list"},
+		Short:   "List queued prompts in delivery order",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				f.SessionName = args[0]
+			}
+			store, qs, err := openPromptQueue()
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			if pane >= 0 {
+				if f.SessionName == "" {
+					return fmt.Errorf("--pane requires a session")
+				}
+				panes, err := tmux.GetPanes(f.SessionName)
+				if err != nil {
+					return err
+				}
+				for _, p := range panes {
+					if p.Index == pane {
+						f.PaneID = p.ID
+					}
+				}
+				if f.PaneID == "" {
+					return fmt.Errorf("pane %d not found in session %s", pane, f.SessionName)
+				}
+			}
+
+			prompts, err := qs.List(f)
+			if err != nil {
+				return err
+			}
+			if IsJSONOutput() {
+				if prompts == nil {
+					prompts = []state.QueuedPrompt{}
+				}
+				return output.PrintJSON(QueueListOutput{TimestampedResponse: output.NewTimestamped(), Prompts: prompts})
+			}
+			if len(prompts) == 0 {
+				output.PrintInfof("No queued prompts")
+				return nil
+			}
+			fmt.Printf("%-5s %-16s %-10s %3s %-10s %8s  %s\n", "ID", "SESSION", "TARGET", "PRI", "STATUS", "AGE", "PROMPT")
+			for _, q := range prompts {
+				age := formatDuration(time.Since(q.CreatedAt).Truncate(time.Second))
+				fmt.Printf("%-5d %-16s %-10s %3d %-10s %8s  %s\n", q.ID, truncateString(q.SessionName, 16),
+					queueTargetLabel(q), q.Priority, q.Status, age, truncateString(strings.Join(strings.Fields(q.Prompt), " "), 60))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVarP(&pane, "pane", "p", -1, "Only prompts for this pane index")
+	cmd.Flags().StringVar(&f.AgentType, "agent", "", "Only prompts for this agent type (cc, cod, gmi)")
+	cmd.Flags().StringVar(&f.Status, "status", "", "Status to list: pending, delivering, delivered, failed, expired or all (default: waiting)")
+	cmd.Flags().IntVar(&f.Limit, "limit", 0, "Maximum prompts to list (0 for all)")
+	return cmd
+}
+
+func newQueueRemoveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rm <id>...",
+		Short: "Remove queued prompts",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := parseQueueIDs(args)
+			if err != nil {
+				return err
+			}
+			store, qs, err := openPromptQueue()
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			var missing []int64
+			for _, id := range ids {
+				ok, err := qs.Remove(id)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					missing = append(missing, id)
+				}
+			}
+			if IsJSONOutput() {
+				return output.PrintJSON(map[string]any{"removed": len(ids) - len(missing), "not_found": missing})
+			}
+			fmt.Printf("Removed %d prompt(s)\n", len(ids)-len(missing))
+			if len(missing) > 0 {
+				return fmt.Errorf("not found: %v", missing)
+			}
+			return nil
+		},
+	}
+}
+
+func newQueueReorderCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "reorder <id> <position>",
+		Short: "Move a waiting prompt to a position in its session's queue",
+		Long: `Move a pending prompt to a 1-based position among its session's pending
+prompts. The prompt takes the priority of the prompt it lands next to, so the
+new order holds.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := parseQueueIDs(args[:1])
+			if err != nil {
+				return err
+			}
+			pos, err := strconv.Atoi(args[1])
+			if err != nil || pos < 1 {
+				return fmt.Errorf("invalid position %q: must be 1 or more", args[1])
+			}
+			store, qs, err := openPromptQueue()
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			if err := qs.Move(ids[0], pos-1); err != nil {
+				return err
+			}
+			q, err := qs.Get(ids[0])
+			if err != nil {
+				return err
+			}
+			if IsJSONOutput() {
+				return output.PrintJSON(q)
+			}
+			fmt.Printf("Moved prompt %d to position %d (priority %d)\n", q.ID, pos, q.Priority)
+			return nil
+		},
+	}
+}
+
+func newQueueDispatchCmd() *cobra.Command {
+	var watch bool
+
+	cmd := &cobra.Command{
+		Use:   "dispatch <session>",
+		Short: "Deliver queued prompts to idle panes now",
+		Long: `Check the session's panes and deliver the next queued prompt to each idle
+agent. The session monitor does this continuously; use this when the monitor
+is not running, or --watch to keep dispatching in the foreground.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			session := args[0]
+			store, qs, err := openPromptQueue()
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			d := newPromptDispatcher(qs)
+			if watch {
+				ctx, cancel := context.WithCancel(cmd.Context())
+				defer cancel()
+				output.PrintInfof("Dispatching queued prompts for %s (Ctrl+C to stop)", session)
+				d.Run(ctx, session)
+				return nil
+			}
+
+			deliveries, err := d.DispatchOnce(cmd.Context(), session)
+			if err != nil {
+				return err
+			}
+			if IsJSONOutput() {
+				if deliveries == nil {
+					deliveries = []promptqueue.Delivery{}
+				}
+				return output.PrintJSON(map[string]any{"session": session, "delivered": deliveries})
+			}
+			if len(deliveries) == 0 {
+				output.PrintInfof("Nothing delivered (no idle pane with a waiting prompt)")
+				return nil
+			}
+			for _, dl := range deliveries {
+				if dl.Error != "" {
+					fmt.Fprintf(os.Stderr, "prompt %d → %s failed: %s\n", dl.PromptID, dl.PaneID, dl.Error)
+					continue
+				}
+				fmt.Printf("prompt %d → %s\n", dl.PromptID, dl.PaneID)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&watch, "watch", false, "Keep dispatching until interrupted")
+	return cmd
+}
+
+func parseQueueIDs(args []string) ([]int64, error) {
+	ids := make([]int64, 0, len(args))
+	for _, a := range args {
+		id, err := strconv.ParseInt(a, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid prompt id %q", a)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func queueTargetLabel(q state.QueuedPrompt) string {
+	switch {
+	case q.PaneIndex != nil:
+		return fmt.Sprintf("pane %d", *q.PaneIndex)
+	case q.PaneID != "":
+		return q.PaneID
+	}
+	return "any " + q.AgentType
+}
@@ -15,14 +15,20 @@ import (
 )
 
 func newQuotaCmd() *cobra.Command {
-	return &cobra.Command{
+	var forecast bool
+	cmd := &cobra.Command{
 		Use:   "quota [session]",
 		Short: "Check agent quota usage",
 		Long: `Query agents for their current quota usage.
 Sends /usage command to supported agents (Claude) and parses the output.
 
+With --forecast, shows each account's predicted time to its usage limit
+from the quota samples, sends and rate limit hits recorded by the session
+monitor ([rotation.forecast]), without querying the agents.
+
 Examples:
   ntm quota myproject
+  ntm quota myproject --forecast
   ntm quota --json`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -41,9 +47,14 @@ Examples:
 			res.ExplainIfInferred(os.Stderr)
 
 			session = res.Session
+			if forecast {
+				return runQuotaForecast(session)
+			}
 			return runQuota(session)
 		},
 	}
+	cmd.Flags().BoolVar(&forecast, "forecast", false, "Show each account's forecast time to limit instead of querying agents")
+	return cmd
 }
 
 func runQuota(session string) error {
@@ -0,0 +1,220 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shahbajlive/ntm/internal/output"
+	"github.com/shahbajlive/ntm/internal/recording"
+	"github.com/shahbajlive/ntm/internal/tmux"
+	"github.com/shahbajlive/ntm/internal/tui/player"
+	"github.com/shahbajlive/ntm/internal/util"
+)
+
+func newRecordCmd() *cobra.Command {
+	var (
+		outputDir string
+		duration  time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "record [session]",
+		Short: "Record all panes of a session as asciicast v2",
+		Long: `Record every pane of a session into asciicast v2 files.
+
+Each pane is captured with pipe-pane (falling back to capture-pane polling)
+into its own .cast file. All files share one clock so they play back in
+sync with 'ntm play'. When recording stops, sends, checkpoints, errors and
+rotations from the session timeline are attached as markers.
+
+Recordings are stored in ~/.ntm/recordings/<session>_<timestamp>/.
+Individual .cast files also play in any asciicast v2 player.
+
+Examples:
+  ntm record myproject                # Record until Ctrl+C
+  ntm record myproject --duration 1h  # Stop automatically after an hour
+  ntm record --list                   # List recordings`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if list, _ := cmd.Flags().GetBool("list"); list {
+				return runRecordList(outputDir)
+			}
+			var session string
+			if len(args) > 0 {
+				session = args[0]
+			}
+			return runRecord(session, outputDir, duration)
+		},
+	}
+
+	cmd.Flags().StringVar(&outputDir, "output", "", "Recordings directory (default: ~/.ntm/recordings)")
+	cmd.Flags().DurationVar(&duration, "duration", 0, "Stop recording after this long (0 = until interrupted)")
+	cmd.Flags().Bool("list", false, "List existing recordings")
+	cmd.ValidArgsFunction = completeSessionArgs
+
+	return cmd
+}
+
+func runRecord(session, outputDir string, duration time.Duration) error {
+	if err := tmux.EnsureInstalled(); err != nil {
+		return err
+	}
+
+	res, err := ResolveSession(session, os.Stdout)
+	if err != nil {
+		return err
+	}
+	if res.Session == "" {
+		return nil
+	}
+	res.ExplainIfInferred(os.Stderr)
+	session = res.Session
+
+	if !tmux.SessionExists(session) {
+		return fmt.Errorf("session '%s' not found", session)
+	}
+
+	panes, err := tmux.GetPanes(session)
+	if err != nil {
+		return fmt.Errorf("getting panes: %w", err)
+	}
+
+	if outputDir != "" {
+		outputDir = util.ExpandPath(outputDir)
+	}
+	rec, err := recording.NewRecorder(recording.RecorderOptions{
+		Session:   session,
+		OutputDir: outputDir,
+		Stream:    tmux.DefaultPaneStreamerConfig(),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := rec.Start(panes); err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	if duration > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, duration)
+		defer cancelTimeout()
+	}
+
+	if !IsJSONOutput() {
+		fmt.Printf("Recording %d pane(s) of %s to %s\n", len(panes), session, rec.Dir())
+		fmt.Println("Press Ctrl+C to stop.")
+	}
+
+	<-ctx.Done()
+
+	manifest, err := rec.Stop()
+	if err != nil && manifest == nil {
+		return err
+	}
+
+	if IsJSONOutput() {
+		return output.PrintJSON(map[string]interface{}{
+			"success":  err == nil,
+			"dir":      rec.Dir(),
+			"session":  session,
+			"duration": manifest.Duration().String(),
+			"panes":    len(manifest.Panes),
+			"markers":  len(manifest.Markers),
+		})
+	}
+
+	fmt.Printf("\nSaved %s of %d pane(s) with %d marker(s) to %s\n",
+		manifest.Duration().Truncate(time.Second), len(manifest.Panes), len(manifest.Markers), rec.Dir())
+	fmt.Printf("Play it with: ntm play %s\n", rec.Dir())
+	return err
+}
+
+func runRecordList(outputDir string) error {
+	if outputDir != "" {
+		outputDir = util.ExpandPath(outputDir)
+	}
+	infos, err := recording.List(outputDir)
+	if err != nil {
+		return fmt.Errorf("listing recordings: %w", err)
+	}
+
+	if IsJSONOutput() {
+		return output.PrintJSON(map[string]interface{}{
+			"recordings": infos,
+			"count":      len(infos),
+		})
+	}
+
+	if len(infos) == 0 {
+		fmt.Println("No recordings found. Start one with: ntm record <session>")
+		return nil
+	}
+	for _, info := range infos {
+		fmt.Printf("%-40s  %-16s  %s  %8s  %d panes  %d markers\n",
+			info.Name, info.Session, info.StartedAt.Local().Format("2006-01-02 15:04"),
+			info.Duration.Truncate(time.Second), info.Panes, info.Markers)
+	}
+	return nil
+}
+
+func newPlayCmd() *cobra.Command {
+	var recordingsDir string
+
+	cmd := &cobra.Command{
+		Use:   "play [recording|session]",
+		Short: "Play back a session recording",
+		Long: `Play back a recording made with 'ntm record'.
+
+All panes are tiled and share one timeline. The scrubber at the bottom
+shows sends (▶), checkpoints (⚑), errors (✗) and rotations (↻).
+
+The argument may be a recording directory, its name from 'ntm record --list',
+or a session name (plays that session's latest recording). With no argument
+the most recent recording is played.
+
+Keys:
+  space      Play / pause
+  ←/→ h/l    Seek 5s        H/L   Seek 1s
+  [ / ]      Previous / next marker
+  + / -      Change speed   g/G   Start / end
+  q          Quit
+
+Examples:
+  ntm play                          # Latest recording
+  ntm play myproject                # Latest recording of myproject
+  ntm play ~/.ntm/recordings/myproject_20260101-120000`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var ref string
+			if len(args) > 0 {
+				ref = args[0]
+			}
+			if recordingsDir != "" {
+				recordingsDir = util.ExpandPath(recordingsDir)
+			}
+
+			dir, err := recording.Resolve(recordingsDir, util.ExpandPath(ref))
+			if err != nil {
+				return err
+			}
+			rec, err := recording.Load(dir)
+			if err != nil {
+				return err
+			}
+			return player.Run(rec)
+		},
+	}
+
+	cmd.Flags().StringVar(&recordingsDir, "dir", "", "Recordings directory (default: ~/.ntm/recordings)")
+
+	return cmd
+}
@@ -44,6 +44,7 @@ These commands NEVER print raw matched secrets. Output is always safe-redacted.`
 
 	cmd.AddCommand(
 		newRedactPreviewCmd(),
+		newRedactVaultCmd(),
 	)
 
 	return cmd
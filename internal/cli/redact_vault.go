@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shahbajlive/ntm/internal/output"
+	"github.com/shahbajlive/ntm/internal/redaction"
+)
+
+// RedactVaultEntry is a vault entry as shown to users. The secret value is
+// never included.
+type RedactVaultEntry struct {
+	Token     string             `json:"token"`
+	Category  redaction.Category `json:"category"`
+	Length    int                `json:"length"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+// RedactVaultResponse is the JSON output for redact vault list.
+type RedactVaultResponse struct {
+	output.TimestampedResponse
+	Path    string             `json:"path"`
+	Entries []RedactVaultEntry `json:"entries"`
+}
+
+func newRedactVaultCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "vault",
+		Short: "Inspect the pseudonymization vault",
+		Long: `Inspect the encrypted vault behind pseudonym tokens like «SECRET:GITHUB_TOKEN:3».
+
+The vault is used when redaction.mode = "pseudonymize" and encryption is
+enabled. Secrets are never printed; prompts sent to trusted panes (tagged
+"trusted" or listed in redaction.trusted_panes) get the real values.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRedactVaultList()
+		},
+	}
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "list",
+			Short: "List vault tokens without their values",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runRedactVaultList()
+			},
+		},
+		&cobra.Command{
+			Use:   "forget <token>",
+			Short: "Remove a token so it can no longer be rehydrated",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runRedactVaultForget(args[0])
+			},
+		},
+	)
+
+	return cmd
+}
+
+func requireSecretVault() (*redaction.Vault, error) {
+	v := redaction.GetVault()
+	if v == nil {
+		return nil, fmt.Errorf("secret vault not available: set redaction.mode = \"pseudonymize\" and enable [encryption]")
+	}
+	return v, nil
+}
+
+func runRedactVaultList() error {
+	v, err := requireSecretVault()
+	if err != nil {
+		return err
+	}
+
+	resp := RedactVaultResponse{
+		TimestampedResponse: output.NewTimestamped(),
+		Path:                v.Path(),
+		Entries:             []RedactVaultEntry{},
+	}
+	for _, e := range v.Entries() {
+		resp.Entries = append(resp.Entries, RedactVaultEntry{
+			Token:     e.Token,
+			Category:  e.Category,
+			Length:    len(e.Value),
+			CreatedAt: e.CreatedAt,
+		})
+	}
+
+	if IsJSONOutput() {
+		return output.PrintJSON(resp)
+	}
+
+	fmt.Printf("Vault: %s\n", resp.Path)
+	if len(resp.Entries) == 0 {
+		fmt.Println("No secrets recorded.")
+		return nil
+	}
+	for _, e := range resp.Entries {
+		fmt.Printf("  %-32s %3d chars  %s\n", e.Token, e.Length, e.CreatedAt.Local().Format("2006-01-02 15:04"))
+	}
+	return nil
+}
+
+func runRedactVaultForget(token string) error {
+	v, err := requireSecretVault()
+	if err != nil {
+		return err
+	}
+	removed, err := v.Forget(token)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return fmt.Errorf("token %s not found in vault", token)
+	}
+	if IsJSONOutput() {
+		return output.PrintJSON(map[string]interface{}{"token": token, "forgotten": true})
+	}
+	fmt.Printf("Forgot %s\n", token)
+	return nil
+}
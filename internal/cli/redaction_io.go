@@ -5,7 +5,9 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/shahbajlive/ntm/internal/output"
 	"github.com/shahbajlive/ntm/internal/redaction"
+	"github.com/shahbajlive/ntm/internal/util"
 )
 
 // RedactionSummary is a safe-to-print summary of redaction findings.
@@ -66,6 +68,8 @@ func summarizeRedactionResult(result redaction.Result) RedactionSummary {
 		summary.Action = "redact"
 	case redaction.ModeBlock:
 		summary.Action = "block"
+	case redaction.ModePseudonymize:
+		summary.Action = "pseudonymize"
 	}
 
 	return summary
@@ -87,3 +91,20 @@ func formatRedactionCategoryCounts(categories map[string]int) string {
 	}
 	return strings.Join(parts, ", ")
 }
+
+// setupSecretVault opens the pseudonymization vault with the encryption
+// keyring. Without a key, pseudonymize degrades to plain redaction.
+func setupSecretVault(path string, key []byte, keys [][]byte) {
+	if len(key) == 0 {
+		output.PrintWarningf("redaction mode pseudonymize requires [encryption]; falling back to redact")
+		redaction.SetVault(nil)
+		return
+	}
+	v, err := redaction.OpenVault(util.ExpandPath(path), key, keys)
+	if err != nil {
+		output.PrintWarningf("secret vault unavailable, falling back to redact: %v", err)
+		redaction.SetVault(nil)
+		return
+	}
+	redaction.SetVault(v)
+}
@@ -0,0 +1,419 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shahbajlive/ntm/internal/assignment"
+	"github.com/shahbajlive/ntm/internal/config"
+	"github.com/shahbajlive/ntm/internal/output"
+	"github.com/shahbajlive/ntm/internal/review"
+	"github.com/shahbajlive/ntm/internal/status"
+	"github.com/shahbajlive/ntm/internal/tmux"
+)
+
+// reviewLoopInterval is how often the session loop collects results and
+// requests reviews of newly completed work.
+const reviewLoopInterval = 15 * time.Second
+
+// ReviewListItem is one assignment's review state.
+type ReviewListItem struct {
+	BeadID       string                      `json:"bead_id"`
+	BeadTitle    string                      `json:"bead_title,omitempty"`
+	Pane         int                         `json:"pane"`
+	AgentType    string                      `json:"agent_type"`
+	Status       assignment.AssignmentStatus `json:"status"`
+	Review       assignment.ReviewStatus     `json:"review,omitempty"`
+	Round        int                         `json:"round,omitempty"`
+	ReviewerPane int                         `json:"reviewer_pane,omitempty"`
+	Comments     int                         `json:"comments,omitempty"`
+}
+
+// ReviewListOutput is the JSON output for review ls.
+type ReviewListOutput struct {
+	output.TimestampedResponse
+	Session     string           `json:"session"`
+	Assignments []ReviewListItem `json:"assignments"`
+}
+
+// ReviewShowOutput is the JSON output for review show and review request.
+type ReviewShowOutput struct {
+	output.TimestampedResponse
+	Session string              `json:"session"`
+	BeadID  string              `json:"bead_id"`
+	Reviews []assignment.Review `json:"reviews"`
+}
+
+// reviewConfig returns the project's [review] section and the coordinator
+// config it describes. A project config that cannot be read is an error
+// rather than an empty section, so gates such as gate_merge are not skipped.
+func reviewConfig(projectDir string) (config.ProjectReview, review.Config, error) {
+	var rc config.ProjectReview
+	_, projectCfg, err := config.FindProjectConfig(projectDir)
+	if err != nil {
+		return rc, review.Config{}, fmt.Errorf("loading project config: %w", err)
+	}
+	if projectCfg != nil {
+		rc = projectCfg.Review
+	}
+	c := review.Config{
+		ReviewerTypes: rc.ReviewerTypes,
+		Spawn:         rc.Spawn,
+		MaxRounds:     rc.MaxRounds,
+		Auto:          rc.Enabled,
+	}
+	if rc.Timeout != "" {
+		if d, err := time.ParseDuration(rc.Timeout); err == nil {
+			c.Timeout = d
+		}
+	}
+	return rc, c, nil
+}
+
+// newReviewCoordinator returns a coordinator for session. Diffs are taken
+// from each author pane's current directory, its worktree when spawned with
+// --worktrees.
+func newReviewCoordinator(session, projectDir string, store *assignment.AssignmentStore, rcfg review.Config) *review.Coordinator {
+	return &review.Coordinator{
+		Session:    session,
+		ProjectDir: projectDir,
+		Store:      store,
+		Config:     rcfg,
+		WorkDir: func(a *assignment.Assignment) string {
+			target := fmt.Sprintf("%s.%d", session, a.Pane)
+			if dir, err := tmux.DefaultClient.Run("display-message", "-p", "-t", target, "#{pane_current_path}"); err == nil && strings.TrimSpace(dir) != "" {
+				return strings.TrimSpace(dir)
+			}
+			return projectDir
+		},
+		Spawn: func(ctx context.Context, agentType string) error {
+			return spawnReviewer(ctx, session, agentType)
+		},
+	}
+}
+
+// spawnReviewer adds one agent of agentType to session and waits for it to
+// settle at its prompt.
+func spawnReviewer(ctx context.Context, session, agentType string) error {
+	before, err := tmux.GetPanes(session)
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool, len(before))
+	for _, p := range before {
+		known[p.ID] = true
+	}
+	if err := runAdd(AddOptions{
+		Session: session,
+		Agents:  AgentSpecs{{Type: AgentType(agentType), Count: 1}},
+	}); err != nil {
+		return err
+	}
+
+	detector := status.NewDetector()
+	deadline := time.Now().Add(90 * time.Second)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(3 * time.Second):
+		}
+		panes, err := tmux.GetPanes(session)
+		if err != nil {
+			return err
+		}
+		for _, p := range panes {
+			if known[p.ID] {
+				continue
+			}
+			if st, err := detector.Detect(p.ID); err == nil && st.State == status.StateIdle {
+				return nil
+			}
+		}
+	}
+	// Still starting; the review prompt queues behind its startup
+	return nil
+}
+
+// startReviewLoop runs cross-agent review rounds for session until ctx is
+// done, when the project's [review] section enables it. It reports whether
+// the loop started.
+func startReviewLoop(ctx context.Context, session, projectDir string) bool {
+	rc, rcfg, err := reviewConfig(projectDir)
+	if err != nil {
+		fmt.Printf("Review loop disabled: %v\n", err)
+		return false
+	}
+	if !rc.Enabled {
+		return false
+	}
+	store, err := assignment.LoadStore(session)
+	if err != nil {
+		fmt.Printf("Review loop disabled: %v\n", err)
+		return false
+	}
+	coord := newReviewCoordinator(session, projectDir, store, rcfg)
+	go func() {
+		ticker := time.NewTicker(reviewLoopInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				for _, ev := range coord.Tick(ctx) {
+					switch {
+					case ev.Error != "":
+						fmt.Printf("Review of %s: %s\n", ev.BeadID, ev.Error)
+					case ev.Review != nil && ev.Review.Status == assignment.ReviewPending:
+						fmt.Printf("Review of %s: round %d requested from pane %d (%s)\n",
+							ev.BeadID, ev.Review.Round, ev.Review.ReviewerPane, ev.Review.ReviewerType)
+					case ev.Review != nil:
+						fmt.Printf("Review of %s: round %d %s (%d comments)\n",
+							ev.BeadID, ev.Review.Round, ev.Review.Status, len(ev.Review.Comments))
+					}
+				}
+			}
+		}
+	}()
+	return true
+}
+
+// resolveReviewSession resolves an optional session argument.
+func resolveReviewSession(cmd *cobra.Command, session string) (string, error) {
+	res, err := ResolveSession(session, cmd.ErrOrStderr())
+	if err != nil {
+		return "", err
+	}
+	if res.Session == "" {
+		return "", fmt.Errorf("session is required")
+	}
+	res.ExplainIfInferred(cmd.ErrOrStderr())
+	return res.Session, nil
+}
+
+func newReviewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "review",
+		Short: "Cross-agent code review of completed assignments",
+		Long: `Have agents review each other's work. A review packages the author's
+changes since the assignment started (its worktree when spawned with
+--worktrees) into .ntm/reviews/<id>/diff.patch and asks an idle agent of a
+different type to review it. The reviewer answers with JSON comments (file,
+line, severity, suggestion) and a verdict. Requested changes are sent back to
+the author and reopen the assignment; the next completion starts another
+round.
+
+With [review] enabled, the session monitor requests reviews as assignments
+complete. With gate_merge, 'ntm worktrees merge' requires the worktree's
+latest review to be approved at its current head.
+
+  [review]
+  enabled = true
+  reviewer_types = ["cod", "gmi"]
+  spawn = true            # add a reviewer when none is idle
+  max_rounds = 3
+  timeout = "30m"
+  gate_merge = true
+
+Examples:
+  ntm review request myproject bd-42
+  ntm review ls myproject
+  ntm review show myproject bd-42`,
+	}
+
+	cmd.AddCommand(
+		newReviewRequestCmd(),
+		newReviewListCmd(),
+		newReviewShowCmd(),
+	)
+	return cmd
+}
+
+func newReviewRequestCmd() *cobra.Command {
+	var (
+		reviewerTypes []string
+		spawn         bool
+	)
+	cmd := &cobra.Command{
+		Use:   "request [session] <bead-id>",
+		Short: "Ask an agent of another type to review an assignment's changes",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var session string
+			if len(args) == 2 {
+				session = args[0]
+			}
+			session, err := resolveReviewSession(cmd, session)
+			if err != nil {
+				return err
+			}
+			beadID := args[len(args)-1]
+
+			store, err := assignment.LoadStore(session)
+			if err != nil {
+				return err
+			}
+			projectDir := cfg.GetProjectDir(session)
+			_, rcfg, err := reviewConfig(projectDir)
+			if err != nil {
+				return err
+			}
+			if len(reviewerTypes) > 0 {
+				rcfg.ReviewerTypes = reviewerTypes
+			}
+			if cmd.Flags().Changed("spawn") {
+				rcfg.Spawn = spawn
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+			defer cancel()
+			r, err := newReviewCoordinator(session, projectDir, store, rcfg).Request(ctx, beadID)
+			if err != nil {
+				if errors.Is(err, review.ErrNoReviewer) && !rcfg.Spawn {
+					return fmt.Errorf("%w (use --spawn to add one)", err)
+				}
+				return err
+			}
+
+			if IsJSONOutput() {
+				return output.PrintJSON(ReviewShowOutput{
+					TimestampedResponse: output.NewTimestamped(),
+					Session:             session,
+					BeadID:              beadID,
+					Reviews:             []assignment.Review{*r},
+				})
+			}
+			output.PrintInfof("Review round %d of %s requested from pane %d (%s): %d files", r.Round, beadID, r.ReviewerPane, r.ReviewerType, len(r.Files))
+			return nil
+		},
+	}
+	cmd.Flags().StringSliceVar(&reviewerTypes, "reviewer", nil, "Preferred reviewer agent types, in order (e.g. cod,gmi)")
+	cmd.Flags().BoolVar(&spawn, "spawn", false, "Add a reviewer agent when none is idle")
+	return cmd
+}
+
+func newReviewListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "ls [session]",
+		Aliases: []string{"list"},
+		Short:   "List assignments with their review state",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var session string
+			if len(args) > 0 {
+				session = args[0]
+			}
+			session, err := resolveReviewSession(cmd, session)
+			if err != nil {
+				return err
+			}
+			store, err := assignment.LoadStore(session)
+			if err != nil {
+				return err
+			}
+
+			out := ReviewListOutput{Session: session, Assignments: []ReviewListItem{}}
+			for _, a := range store.List() {
+				item := ReviewListItem{
+					BeadID:    a.BeadID,
+					BeadTitle: a.BeadTitle,
+					Pane:      a.Pane,
+					AgentType: a.AgentType,
+					Status:    a.Status,
+				}
+				if r := a.LatestReview(); r != nil {
+					item.Review = r.Status
+					item.Round = r.Round
+					item.ReviewerPane = r.ReviewerPane
+					item.Comments = len(r.Comments)
+				}
+				out.Assignments = append(out.Assignments, item)
+			}
+			if IsJSONOutput() {
+				out.TimestampedResponse = output.NewTimestamped()
+				return output.PrintJSON(out)
+			}
+			if len(out.Assignments) == 0 {
+				output.PrintInfof("No assignments in %s", session)
+				return nil
+			}
+			fmt.Printf("%-14s %-5s %-7s %-10s %-18s %-6s %-9s %s\n", "BEAD", "PANE", "AGENT", "STATUS", "REVIEW", "ROUND", "REVIEWER", "COMMENTS")
+			for _, it := range out.Assignments {
+				reviewState, round, reviewer := "-", "-", "-"
+				if it.Round > 0 {
+					reviewState, round, reviewer = string(it.Review), fmt.Sprint(it.Round), fmt.Sprint(it.ReviewerPane)
+				}
+				fmt.Printf("%-14s %-5d %-7s %-10s %-18s %-6s %-9s %d\n", truncateString(it.BeadID, 14), it.Pane, it.AgentType,
+					it.Status, reviewState, round, reviewer, it.Comments)
+			}
+			return nil
+		},
+	}
+}
+
+func newReviewShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show [session] <bead-id>",
+		Short: "Show an assignment's review rounds and comments",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var session string
+			if len(args) == 2 {
+				session = args[0]
+			}
+			session, err := resolveReviewSession(cmd, session)
+			if err != nil {
+				return err
+			}
+			beadID := args[len(args)-1]
+			store, err := assignment.LoadStore(session)
+			if err != nil {
+				return err
+			}
+			a := store.Get(beadID)
+			if a == nil {
+				return fmt.Errorf("no assignment for %s in %s", beadID, session)
+			}
+
+			out := ReviewShowOutput{Session: session, BeadID: beadID, Reviews: a.Reviews}
+			if out.Reviews == nil {
+				out.Reviews = []assignment.Review{}
+			}
+			if IsJSONOutput() {
+				out.TimestampedResponse = output.NewTimestamped()
+				return output.PrintJSON(out)
+			}
+			if len(out.Reviews) == 0 {
+				output.PrintInfof("%s has not been reviewed", beadID)
+				return nil
+			}
+			for _, r := range out.Reviews {
+				fmt.Printf("Round %d: %s by pane %d (%s), %d files", r.Round, r.Status, r.ReviewerPane, r.ReviewerType, len(r.Files))
+				if r.Worktree != "" {
+					fmt.Printf(", worktree %s", r.Worktree)
+				}
+				fmt.Println()
+				if r.Summary != "" {
+					fmt.Printf("  %s\n", r.Summary)
+				}
+				for _, c := range r.Comments {
+					loc := c.File
+					if c.Line > 0 {
+						loc = fmt.Sprintf("%s:%d", c.File, c.Line)
+					}
+					fmt.Printf("  [%s] %s: %s\n", c.Severity, loc, c.Message)
+					if c.Suggestion != "" {
+						fmt.Printf("      suggestion: %s\n", c.Suggestion)
+					}
+				}
+			}
+			return nil
+		},
+	}
+}
@@ -265,12 +265,11 @@ func getSessionWorkDir(session string) (string, error) {
 	if !tmux.SessionExists(session) {
 		return "", fmt.Errorf("session %q does not exist", session)
 	}
-	cmd := exec.Command(tmux.BinaryPath(), "display-message", "-p", "-t", session, "#{pane_current_path}")
-	out, err := cmd.Output()
+	out, err := tmux.DefaultClient.Run("display-message", "-p", "-t", session, "#{pane_current_path}")
 	if err != nil {
 		return "", err
 	}
-	return strings.TrimSpace(string(out)), nil
+	return strings.TrimSpace(out), nil
 }
 
 // hasUncommittedChanges checks if there are uncommitted changes.
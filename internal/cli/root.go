@@ -16,6 +16,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/shahbajlive/ntm/internal/audit"
+	"github.com/shahbajlive/ntm/internal/budget"
 	"github.com/shahbajlive/ntm/internal/checkpoint"
 	"github.com/shahbajlive/ntm/internal/config"
 	"github.com/shahbajlive/ntm/internal/encryption"
@@ -26,9 +27,11 @@ import (
 	"github.com/shahbajlive/ntm/internal/pipeline"
 	"github.com/shahbajlive/ntm/internal/plugins"
 	"github.com/shahbajlive/ntm/internal/privacy"
+	"github.com/shahbajlive/ntm/internal/redaction"
 	"github.com/shahbajlive/ntm/internal/robot"
 	"github.com/shahbajlive/ntm/internal/session"
 	"github.com/shahbajlive/ntm/internal/startup"
+	"github.com/shahbajlive/ntm/internal/state"
 	"github.com/shahbajlive/ntm/internal/tmux"
 	"github.com/shahbajlive/ntm/internal/util"
 )
@@ -87,6 +90,9 @@ Shell Integration:
 		if sshHost != "" {
 			tmux.DefaultClient = tmux.NewClient(sshHost)
 		}
+		if err := configureBackend(); err != nil {
+			return err
+		}
 
 		// Handle --no-color flag by setting environment variable
 		// This integrates with the existing theme.NoColorEnabled() system
@@ -138,9 +144,14 @@ Shell Integration:
 				audit.SetRedactionConfig(&redactCfg)
 				session.SetRedactionConfig(&redactCfg)
 				checkpoint.SetRedactionConfig(&redactCfg)
+
+				// Every send path checks spend budgets, not just the CLI's.
+				budget.SetConfig(cfg.Budgets)
 			}
 
 			// Wire encryption into history + event log persistence (bd-3ld77)
+			var vaultKey []byte
+			var vaultKeys [][]byte
 			if cfg != nil && cfg.Encryption.Enabled {
 				keyCfg := encryption.KeyConfig{
 					KeySource:   cfg.Encryption.KeySource,
@@ -169,10 +180,18 @@ Shell Integration:
 							EncryptKey:  encKey,
 							DecryptKeys: allKeys,
 						})
+						vaultKey, vaultKeys = encKey, allKeys
 					}
 				}
 			}
 
+			// Pseudonymization keeps the secrets behind its tokens in a vault
+			// encrypted with the same keyring.
+			if cfg != nil && cfg.Redaction.Mode == "pseudonymize" {
+				setupSecretVault(cfg.Redaction.VaultPath, vaultKey, vaultKeys)
+				redaction.SetTrustedPanes(cfg.Redaction.TrustedPanes)
+			}
+
 			// Run automatic temp file cleanup if enabled
 			MaybeRunStartupCleanup(
 				cfg.Cleanup.AutoCleanOnStartup,
@@ -731,6 +750,56 @@ Shell Integration:
 			}
 			return
 		}
+		if robotTranscript != "" {
+			opts := robot.TranscriptOptions{
+				Session:  robotTranscript,
+				BeadID:   robotTranscriptBead,
+				Text:     robotTranscriptText,
+				Tool:     robotTranscriptTool,
+				File:     robotTranscriptFile,
+				Since:    robotTranscriptSince,
+				Limit:    robotTranscriptLimit,
+				NoIngest: robotTranscriptNoIngest,
+			}
+			if cfg != nil {
+				opts.ProjectDir = cfg.GetProjectDir(robotTranscript)
+			}
+			if robotTranscriptPane >= 0 {
+				opts.Pane = &robotTranscriptPane
+			}
+			if err := robot.PrintTranscript(opts); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		if robotQueue != "" {
+			opts := robot.QueueOptions{
+				Session:  robotQueue,
+				Status:   robotQueueStatus,
+				Add:      robotQueueAdd,
+				Agent:    robotQueueAgent,
+				Priority: robotQueuePriority,
+				Dedup:    robotQueueDedup,
+				TTL:      robotQueueTTL,
+				Remove:   robotQueueRemove,
+			}
+			if robotQueuePane >= 0 {
+				opts.Pane = &robotQueuePane
+			}
+			if err := robot.PrintQueue(opts); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		if robotSchedule != "" {
+			if err := robot.PrintSchedule(robot.ScheduleOptions{Session: robotSchedule, Runs: robotScheduleRuns}); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
 		if robotActivity != "" {
 			// Parse pane filter (reuse --panes flag)
 			var paneFilter []string
@@ -1534,6 +1603,7 @@ Shell Integration:
 				PaneIndex:   robotInspectIndex,
 				Lines:       robotInspectLines,
 				IncludeCode: robotInspectCode,
+				Segments:    robotInspectSegments,
 			}
 			if err := robot.PrintInspectPane(opts); err != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -2316,6 +2386,32 @@ var (
 	robotHistorySince string // time-based filter
 	robotHistoryStats bool   // show statistics instead of entries
 
+	// Robot-transcript flags for ingested agent transcripts
+	robotTranscript         string // session name for transcript query
+	robotTranscriptPane     int    // filter by pane index (-1 = all)
+	robotTranscriptBead     string // filter by bead ID
+	robotTranscriptText     string // turn text or tool input/output contains
+	robotTranscriptTool     string // turn called this tool
+	robotTranscriptFile     string // turn edited a path with this suffix
+	robotTranscriptSince    string // time-based filter
+	robotTranscriptLimit    int    // maximum turns
+	robotTranscriptNoIngest bool   // skip scanning for new transcripts
+
+	// Robot-queue flags for the per-pane prompt queue
+	robotQueue         string // session name for queue listing
+	robotQueueStatus   string // status filter (default: waiting)
+	robotQueueAdd      string // prompt to enqueue
+	robotQueuePane     int    // target pane index (-1 = use --queue-agent)
+	robotQueueAgent    string // agent type whose first idle pane takes the prompt
+	robotQueuePriority int    // 0 (first) to 4 (last)
+	robotQueueDedup    string // skip if a waiting prompt has this key
+	robotQueueTTL      string // expire undelivered prompt after this long
+	robotQueueRemove   int64  // prompt ID to remove
+
+	// Robot-schedule flags for scheduled jobs
+	robotSchedule     string // session name for schedule listing
+	robotScheduleRuns int    // recent runs per schedule
+
 	// Robot-activity flags for agent activity detection
 	robotActivity     string // session name for activity query
 	robotActivityType string // filter by agent type (claude, codex, gemini)
@@ -2355,6 +2451,7 @@ var (
 	robotInspectIndex    int    // pane index to inspect
 	robotInspectLines    int    // lines to capture for inspection
 	robotInspectCode     bool   // parse code blocks in output
+	robotInspectSegments bool   // segment output into turns, tool calls and diffs
 	robotMetrics         string // session name for metrics
 	robotMetricsPeriod   string // period: 1h, 24h, 7d, all
 	robotReplay          string // session name for replay
@@ -2553,12 +2650,13 @@ func init() {
 	// Global JSON output flag - applies to all commands
 	rootCmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "Output in JSON format (machine-readable)")
 	rootCmd.PersistentFlags().StringVar(&sshHost, "ssh", "", "Remote host for SSH execution (e.g. user@host)")
+	rootCmd.PersistentFlags().StringVar(&paneBackend, "backend", "", "Pane backend: tmux (default) or pty for headless CI runs (env: NTM_BACKEND)")
 
 	// Global no-color flag - disables colored output (respects NO_COLOR env var standard)
 	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colored output")
 
 	// Global redaction flags - secrets/PII redaction control
-	rootCmd.PersistentFlags().StringVar(&redactMode, "redact", "", "Redaction mode override: off, warn, redact, block")
+	rootCmd.PersistentFlags().StringVar(&redactMode, "redact", "", "Redaction mode override: off, warn, redact, block, pseudonymize")
 	rootCmd.PersistentFlags().BoolVar(&allowSecret, "allow-secret", false, "Bypass 'block' mode for this invocation (use with caution)")
 
 	// Profiling flag for startup timing analysis
@@ -2860,6 +2958,30 @@ func init() {
 	rootCmd.Flags().StringVar(&robotHistorySince, "history-since", "", "Show entries since time (1h, 30m, 2d, or ISO8601). Optional with --robot-history")
 	rootCmd.Flags().BoolVar(&robotHistoryStats, "history-stats", false, "Show statistics instead of entries. Optional with --robot-history")
 
+	// Robot-transcript flags for ingested agent transcripts
+	rootCmd.Flags().StringVar(&robotTranscript, "robot-transcript", "", "Ingest agent transcripts for a session and return recent turns, tool calls and edited files (JSON). Required: SESSION. Example: ntm --robot-transcript=myproject")
+	rootCmd.Flags().IntVar(&robotTranscriptPane, "transcript-pane", -1, "Filter by pane index. Optional with --robot-transcript")
+	rootCmd.Flags().StringVar(&robotTranscriptBead, "transcript-bead", "", "Filter by bead ID. Optional with --robot-transcript")
+	rootCmd.Flags().StringVar(&robotTranscriptText, "transcript-text", "", "Only turns whose text or tool input/output contains this. Optional with --robot-transcript")
+	rootCmd.Flags().StringVar(&robotTranscriptTool, "transcript-tool", "", "Only turns that called this tool (e.g. Edit, apply_patch). Optional with --robot-transcript")
+	rootCmd.Flags().StringVar(&robotTranscriptFile, "transcript-file", "", "Only turns that edited a path ending in this. Optional with --robot-transcript")
+	rootCmd.Flags().StringVar(&robotTranscriptSince, "transcript-since", "", "Only turns since time (1h, 30m, 2d, or ISO8601). Optional with --robot-transcript")
+	rootCmd.Flags().IntVar(&robotTranscriptLimit, "transcript-limit", 50, "Maximum turns to return. Optional with --robot-transcript")
+	rootCmd.Flags().BoolVar(&robotTranscriptNoIngest, "transcript-no-ingest", false, "Query stored transcripts without scanning for new ones. Optional with --robot-transcript")
+
+	// Robot-queue flags for the per-pane prompt queue
+	rootCmd.Flags().StringVar(&robotQueue, "robot-queue", "", "List prompts queued for idle panes, optionally adding or removing one (JSON). Required: SESSION. Example: ntm --robot-queue=myproject --queue-add='run tests' --queue-pane=2")
+	rootCmd.Flags().StringVar(&robotQueueStatus, "queue-status", "", "Status to list: pending, delivering, delivered, failed, expired or all. Optional with --robot-queue")
+	rootCmd.Flags().StringVar(&robotQueueAdd, "queue-add", "", "Prompt to queue. Needs --queue-pane or --queue-agent. Optional with --robot-queue")
+	rootCmd.Flags().IntVar(&robotQueuePane, "queue-pane", -1, "Pane index the queued prompt is for. Optional with --robot-queue")
+	rootCmd.Flags().StringVar(&robotQueueAgent, "queue-agent", "", "Agent type (cc, cod, gmi) whose first idle pane takes the prompt. Optional with --robot-queue")
+	rootCmd.Flags().IntVar(&robotQueuePriority, "queue-priority", state.DefaultQueuePriority, "Queue priority, 0 (first) to 4 (last). Optional with --robot-queue")
+	rootCmd.Flags().StringVar(&robotQueueDedup, "queue-dedup", "", "Dedup key: skip if a waiting prompt for the target has it. Optional with --robot-queue")
+	rootCmd.Flags().StringVar(&robotQueueTTL, "queue-ttl", "", "Drop the prompt if undelivered after this long (e.g. 30m). Optional with --robot-queue")
+	rootCmd.Flags().Int64Var(&robotQueueRemove, "queue-remove", 0, "ID of a queued prompt to remove. Optional with --robot-queue")
+	rootCmd.Flags().StringVar(&robotSchedule, "robot-schedule", "", "List scheduled jobs with their recent runs (JSON). Required: SESSION. Example: ntm --robot-schedule=myproject")
+	rootCmd.Flags().IntVar(&robotScheduleRuns, "schedule-runs", 5, "Recent runs to include per schedule. Optional with --robot-schedule")
+
 	// Robot-activity flags for agent activity detection
 	rootCmd.Flags().StringVar(&robotActivity, "robot-activity", "", "Get agent activity state (idle/busy/error). Required: SESSION. Example: ntm --robot-activity=myproject")
 	rootCmd.Flags().StringVar(&robotActivityType, "activity-type", "", "Filter by agent type: claude, codex, gemini. Optional with --robot-activity. Example: --activity-type=claude")
@@ -3076,6 +3198,7 @@ func init() {
 	// --index, --code for inspect
 	rootCmd.Flags().IntVar(&robotInspectIndex, "index", 0, "Pane index to inspect")
 	rootCmd.Flags().BoolVar(&robotInspectCode, "code", false, "Parse code blocks from output")
+	rootCmd.Flags().BoolVar(&robotInspectSegments, "segments", false, "Segment output into prompts, prose, tool calls, results, diffs and errors")
 
 	// --period for metrics
 	rootCmd.Flags().StringVar(&robotMetricsPeriod, "period", "24h", "Time period: 1h, 24h, 7d, all")
@@ -3327,6 +3450,7 @@ func init() {
 		newViewCmd(),
 		newZoomCmd(),
 		newDashboardCmd(),
+		newFleetCmd(),
 		newWatchCmd(),
 		newGetAllSessionTextCmd(),
 
@@ -3338,6 +3462,7 @@ func init() {
 		newErrorsCmd(),
 		newExtractCmd(),
 		newDiffCmd(),
+		newInspectCmd(),
 		newChangesCmd(),
 		newConflictsCmd(),
 		newSummaryCmd(),
@@ -3349,6 +3474,8 @@ func init() {
 		newSessionPersistCmd(),
 		newHandoffCmd(),
 		newResumeCmd(),
+		newRecordCmd(),
+		newPlayCmd(),
 
 		// Utilities
 		newPaletteCmd(),
@@ -3359,6 +3486,7 @@ func init() {
 		newScanCmd(),
 		newScrubCmd(),
 		newRedactCmd(),
+		newEncryptionCmd(),
 		newBugsCmd(),
 		newCassCmd(),
 		newAuditCmd(),
@@ -3385,6 +3513,7 @@ func init() {
 
 		// Internal commands
 		newMonitorCmd(),
+		newHeadlessServerCmd(),
 
 		// Memory integration
 		newMemoryCmd(),
@@ -3392,6 +3521,18 @@ func init() {
 		// Context pack building
 		newContextCmd(),
 
+		// Agent transcript ingestion and search
+		newTranscriptCmd(),
+
+		// Prompts waiting for idle agents
+		newQueueCmd(),
+		newScheduleCmd(),
+		newBudgetCmd(),
+		newDetectCmd(),
+		newDaemonCmd(),
+		newPermissionsCmd(),
+		newReviewCmd(),
+
 		// Beads daemon management
 		newBeadsCmd(),
 
@@ -4125,7 +4266,7 @@ func applyRedactionFlagOverrides(cfg *config.Config) {
 	// --redact flag overrides config mode
 	if redactMode != "" {
 		switch redactMode {
-		case "off", "warn", "redact", "block":
+		case "off", "warn", "redact", "block", "pseudonymize":
 			cfg.Redaction.Mode = redactMode
 		default:
 			fmt.Fprintf(os.Stderr, "Warning: invalid --redact value %q, ignoring\n", redactMode)
@@ -4217,7 +4358,7 @@ func needsConfigLoading(cmdName string) bool {
 			robotInterrupt != "" || robotRestartPane != "" || robotProbe != "" || robotGraph || robotMail || robotHealth != "" ||
 			robotHealthOAuth != "" || robotHealthRestartStuck != "" || robotLogs != "" || robotDiagnose != "" || robotTerse || robotMarkdown || robotSave != "" || robotRestore != "" ||
 			robotContext != "" || robotEnsemble != "" || robotEnsembleSpawn != "" || robotEnsembleSuggest != "" || robotEnsembleStop != "" || robotAlerts || robotIsWorking != "" || robotAgentHealth != "" ||
-			robotSmartRestart != "" || robotMonitor != "" || robotEnv != "" || robotSupportBundle != "" {
+			robotSmartRestart != "" || robotMonitor != "" || robotEnv != "" || robotSupportBundle != "" || robotTranscript != "" || robotQueue != "" || robotSchedule != "" {
 			return true
 		}
 	}
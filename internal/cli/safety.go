@@ -652,30 +652,10 @@ if [ -z "$REAL_GIT" ]; then
     REAL_GIT="/usr/bin/git"
 fi
 
-# Check command against policy (include "git" in the command string)
-check_result=$(ntm safety check "git $*" --json 2>&1)
-exit_code=$?
-
-# ntm safety check exits 0 for allow/approve, 1 for block
-if [ $exit_code -eq 1 ]; then
-    # Command was blocked
-    reason=$(echo "$check_result" | jq -r '.reason // "Policy violation"' 2>/dev/null)
-    echo "NTM Safety: Command blocked" >&2
-    echo "  Reason: $reason" >&2
-    echo "  Command: git $*" >&2
-
-    # Log the blocked command (use jq for proper JSON escaping)
-    mkdir -p "$HOME/.ntm/logs"
-    if command -v jq >/dev/null 2>&1; then
-        jq -n --arg ts "$(date -u +%Y-%m-%dT%H:%M:%SZ)" \
-              --arg cmd "git $*" \
-              --arg reason "${reason:-Policy violation}" \
-              '{timestamp: $ts, command: $cmd, reason: $reason, action: "block"}' >> "$HOME/.ntm/logs/blocked.jsonl"
-    else
-        # Fallback without proper escaping (best effort)
-        echo "{\"timestamp\":\"$(date -u +%Y-%m-%dT%H:%M:%SZ)\",\"action\":\"block\"}" >> "$HOME/.ntm/logs/blocked.jsonl"
-    fi
-
+# Ask the policy service (ntm policy serve) about the command. Without a
+# running service the policy is evaluated locally. Blocked and denied
+# commands are logged and explained on stderr by ntm itself.
+if ! ntm policy hook wrapper -- git "$@"; then
     exit 1
 fi
 
@@ -692,30 +672,10 @@ if [ -z "$REAL_RM" ]; then
     REAL_RM="/bin/rm"
 fi
 
-# Check command against policy
-check_result=$(ntm safety check "rm $*" --json 2>&1)
-exit_code=$?
-
-# ntm safety check exits 0 for allow/approve, 1 for block
-if [ $exit_code -eq 1 ]; then
-    # Command was blocked
-    reason=$(echo "$check_result" | jq -r '.reason // "Policy violation"' 2>/dev/null)
-    echo "NTM Safety: Command blocked" >&2
-    echo "  Reason: $reason" >&2
-    echo "  Command: rm $*" >&2
-
-    # Log the blocked command (use jq for proper JSON escaping)
-    mkdir -p "$HOME/.ntm/logs"
-    if command -v jq >/dev/null 2>&1; then
-        jq -n --arg ts "$(date -u +%Y-%m-%dT%H:%M:%SZ)" \
-              --arg cmd "rm $*" \
-              --arg reason "${reason:-Policy violation}" \
-              '{timestamp: $ts, command: $cmd, reason: $reason, action: "block"}' >> "$HOME/.ntm/logs/blocked.jsonl"
-    else
-        # Fallback without proper escaping (best effort)
-        echo "{\"timestamp\":\"$(date -u +%Y-%m-%dT%H:%M:%SZ)\",\"action\":\"block\"}" >> "$HOME/.ntm/logs/blocked.jsonl"
-    fi
-
+# Ask the policy service (ntm policy serve) about the command. Without a
+# running service the policy is evaluated locally. Blocked and denied
+# commands are logged and explained on stderr by ntm itself.
+if ! ntm policy hook wrapper -- rm "$@"; then
     exit 1
 fi
 
@@ -35,6 +35,7 @@ func newScanCmd() *cobra.Command {
 		analyzeImpact  bool
 		showHotspots   bool
 		priorityReport bool
+		format         string
 	)
 
 	cmd := &cobra.Command{
@@ -64,7 +65,9 @@ Examples:
   ntm scan --notify          # Notify agents via Agent Mail
   ntm scan --analyze-impact  # Show findings sorted by graph impact
   ntm scan --hotspots        # Show quality hotspots by file
-  ntm scan --priority-report # Show smart priority report`,
+  ntm scan --priority-report # Show smart priority report
+  ntm scan --format sarif > ntm.sarif  # Export findings as SARIF 2.1.0
+  ntm scan import gosec.sarif          # Import findings from other tools`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			path := "."
@@ -95,11 +98,25 @@ Examples:
 				Verbose:     verbose,
 			}
 
+			var sarifOutput bool
+			switch strings.ToLower(format) {
+			case "", "text":
+			case "json":
+				jsonOutput = true
+			case "sarif":
+				if watch {
+					return fmt.Errorf("--format sarif cannot be combined with --watch")
+				}
+				sarifOutput = true
+			default:
+				return fmt.Errorf("invalid --format %q: must be text, json, or sarif", format)
+			}
+
 			if watch {
 				return runScanWatch(absPath, opts, createBeads, updateBeads, notifyAgents, bridgeCfg)
 			}
 
-			return runScan(absPath, opts, createBeads, updateBeads, notifyAgents, bridgeCfg, analyzeImpact, showHotspots, priorityReport)
+			return runScan(absPath, opts, createBeads, updateBeads, notifyAgents, bridgeCfg, analyzeImpact, showHotspots, priorityReport, sarifOutput)
 		},
 	}
 
@@ -124,6 +141,9 @@ Examples:
 	cmd.Flags().BoolVar(&analyzeImpact, "analyze-impact", false, "Show findings sorted by dependency graph impact")
 	cmd.Flags().BoolVar(&showHotspots, "hotspots", false, "Show quality hotspots by file")
 	cmd.Flags().BoolVar(&priorityReport, "priority-report", false, "Show smart priority report combining severity and graph position")
+	cmd.Flags().StringVar(&format, "format", "text", "Output format: text, json, or sarif")
+
+	cmd.AddCommand(newScanImportCmd())
 
 	return cmd
 }
@@ -142,11 +162,14 @@ func parseSeverity(s string) scanner.Severity {
 	}
 }
 
-func runScan(path string, opts scanner.ScanOptions, createBeads, updateBeads, notifyAgents bool, bridgeCfg scanner.BridgeConfig, analyzeImpact, showHotspots, priorityReport bool) error {
+func runScan(path string, opts scanner.ScanOptions, createBeads, updateBeads, notifyAgents bool, bridgeCfg scanner.BridgeConfig, analyzeImpact, showHotspots, priorityReport, sarifOutput bool) error {
 	t := theme.Current()
 
 	// Check if UBS is available
 	if !scanner.IsAvailable() {
+		if sarifOutput {
+			return fmt.Errorf("ubs not installed")
+		}
 		if jsonOutput {
 			return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
 				"error":     "ubs not installed",
@@ -165,7 +188,7 @@ func runScan(path string, opts scanner.ScanOptions, createBeads, updateBeads, no
 	}
 
 	// Show scanning message if not JSON output
-	if !jsonOutput {
+	if !jsonOutput && !sarifOutput {
 		fmt.Printf("Scanning %s...\n", path)
 	}
 
@@ -187,7 +210,7 @@ func runScan(path string, opts scanner.ScanOptions, createBeads, updateBeads, no
 	if createBeads && len(result.Findings) > 0 {
 		bridgeResult, err = scanner.CreateBeadsFromFindings(result, bridgeCfg)
 		if err != nil {
-			if !jsonOutput {
+			if !jsonOutput && !sarifOutput {
 				fmt.Printf("%s✗%s Beads creation failed: %v\n", colorize(t.Error), "\033[0m", err)
 			}
 		}
@@ -195,7 +218,7 @@ func runScan(path string, opts scanner.ScanOptions, createBeads, updateBeads, no
 	if updateBeads {
 		updateResult, err = scanner.UpdateBeadsFromFindings(result, bridgeCfg)
 		if err != nil {
-			if !jsonOutput {
+			if !jsonOutput && !sarifOutput {
 				fmt.Printf("%s✗%s Beads update failed: %v\n", colorize(t.Error), "\033[0m", err)
 			}
 		}
@@ -204,15 +227,23 @@ func runScan(path string, opts scanner.ScanOptions, createBeads, updateBeads, no
 	// Notify agents if requested
 	if notifyAgents && (result.HasCritical() || result.HasWarning()) {
 		if err := scanner.NotifyScanResults(ctx, result, path); err != nil {
-			if !jsonOutput {
+			if !jsonOutput && !sarifOutput {
 				fmt.Printf("⚠ Notification failed: %v\n", err)
 			}
-		} else if !jsonOutput {
+		} else if !jsonOutput && !sarifOutput {
 			fmt.Println("✓ Notified agents")
 		}
 	}
 
 	// Output results
+	if sarifOutput {
+		data, err := scanner.MarshalSARIF(result, scanner.SourceUBS, Version)
+		if err != nil {
+			return fmt.Errorf("encoding SARIF: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
 	if jsonOutput {
 		output := map[string]interface{}{
 			"scan": result,
@@ -465,7 +496,7 @@ func runScanWatch(path string, opts scanner.ScanOptions, createBeads, updateBead
 
 		// Run scan
 		// Note: We ignore error here to keep watching. Analysis flags disabled in watch mode.
-		if err := runScan(path, opts, createBeads, updateBeads, notifyAgents, bridgeCfg, false, false, false); err != nil {
+		if err := runScan(path, opts, createBeads, updateBeads, notifyAgents, bridgeCfg, false, false, false, false); err != nil {
 			fmt.Printf("\nError running scan: %v\n", err)
 		}
 		fmt.Println("\nWaiting for changes... (Ctrl+C to stop)")
@@ -497,7 +528,7 @@ func runScanWatch(path string, opts scanner.ScanOptions, createBeads, updateBead
 	// Run initial scan
 	fmt.Print("\033[H\033[2J")
 	fmt.Printf("Initial scan of %s...\n", path)
-	if err := runScan(path, opts, createBeads, updateBeads, notifyAgents, bridgeCfg, false, false, false); err != nil {
+	if err := runScan(path, opts, createBeads, updateBeads, notifyAgents, bridgeCfg, false, false, false, false); err != nil {
 		fmt.Printf("\nError running scan: %v\n", err)
 	}
 	fmt.Println("\nWaiting for changes... (Ctrl+C to stop)")
@@ -0,0 +1,174 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shahbajlive/ntm/internal/scanner"
+	"github.com/shahbajlive/ntm/internal/tui/theme"
+)
+
+func newScanImportCmd() *cobra.Command {
+	var (
+		root              string
+		createBeads       bool
+		updateBeads       bool
+		minSeverity       string
+		dryRun            bool
+		includeSuppressed bool
+		verbose           bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "import <file.sarif>...",
+		Short: "Import findings from SARIF reports (golangci-lint, semgrep, gosec, ...)",
+		Long: `Import findings from SARIF 2.1.0 reports produced by other tools.
+
+Results are normalized into scanner findings: SARIF levels map to severities
+(error → critical, warning → warning, note → info), a rule's
+security-severity score overrides the level, and duplicate results are
+reported once. Suppressed results are skipped unless --include-suppressed.
+
+With --create-beads, findings that don't already have an open bead become
+beads labelled with their tool. --update-beads closes beads from the same
+tools whose findings are gone.
+
+Examples:
+  golangci-lint run --out-format sarif > lint.sarif
+  ntm scan import lint.sarif
+  ntm scan import gosec.sarif semgrep.sarif --create-beads
+  ntm scan import lint.sarif --update-beads --dry-run`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if root == "" {
+				wd, err := os.Getwd()
+				if err != nil {
+					return fmt.Errorf("getting working directory: %w", err)
+				}
+				root = wd
+			}
+			absRoot, err := filepath.Abs(root)
+			if err != nil {
+				return fmt.Errorf("resolving root: %w", err)
+			}
+
+			bridgeCfg := scanner.BridgeConfig{
+				MinSeverity: parseSeverity(minSeverity),
+				DryRun:      dryRun,
+				Verbose:     verbose,
+			}
+			opts := scanner.SARIFImportOptions{Root: absRoot, IncludeSuppressed: includeSuppressed}
+			return runScanImport(args, opts, createBeads, updateBeads, bridgeCfg)
+		},
+	}
+
+	cmd.Flags().StringVar(&root, "root", "", "Project root that file paths are made relative to (default: current directory)")
+	cmd.Flags().BoolVar(&createBeads, "create-beads", false, "Create beads for new findings")
+	cmd.Flags().BoolVar(&updateBeads, "update-beads", false, "Close beads from the same tools whose findings are gone")
+	cmd.Flags().StringVar(&minSeverity, "min-severity", "warning", "Minimum severity for bead creation (critical|warning|info)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what beads would be created or closed")
+	cmd.Flags().BoolVar(&includeSuppressed, "include-suppressed", false, "Include results suppressed in source or by review")
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output")
+
+	return cmd
+}
+
+func runScanImport(files []string, opts scanner.SARIFImportOptions, createBeads, updateBeads bool, bridgeCfg scanner.BridgeConfig) error {
+	t := theme.Current()
+
+	result, err := scanner.ImportSARIFFiles(files, opts)
+	if err != nil {
+		return err
+	}
+
+	// Findings that already have an open bead are reported, not recreated.
+	newFindings := result.Findings
+	var duplicates []scanner.DuplicateInfo
+	if idx, err := scanner.NewDedupIndex(); err == nil {
+		newFindings, duplicates = idx.CheckFindings(result.Findings)
+	}
+
+	var bridgeResult, updateResult *scanner.BridgeResult
+	if createBeads && len(newFindings) > 0 {
+		subset := *result
+		subset.Findings = newFindings
+		bridgeResult, err = scanner.CreateBeadsFromFindings(&subset, bridgeCfg)
+		if err != nil {
+			return fmt.Errorf("creating beads: %w", err)
+		}
+		bridgeResult.Duplicates += len(duplicates)
+	}
+	if updateBeads {
+		updateResult, err = scanner.UpdateBeadsFromFindings(result, bridgeCfg)
+		if err != nil {
+			return fmt.Errorf("updating beads: %w", err)
+		}
+	}
+
+	if jsonOutput {
+		output := map[string]interface{}{
+			"import":       result,
+			"new_findings": len(newFindings),
+		}
+		if len(duplicates) > 0 {
+			output["duplicates"] = duplicates
+		}
+		if bridgeResult != nil {
+			output["beads_created"] = bridgeResult
+		}
+		if updateResult != nil {
+			output["beads_updated"] = updateResult
+		}
+		return json.NewEncoder(os.Stdout).Encode(output)
+	}
+
+	printSARIFImport(t, result, len(newFindings), len(duplicates))
+	if bridgeResult != nil {
+		printBeadsBridgeResults(t, bridgeResult, bridgeCfg.DryRun)
+	}
+	if updateResult != nil {
+		printBeadsUpdateResults(t, updateResult, bridgeCfg.DryRun)
+	}
+	return nil
+}
+
+func printSARIFImport(t theme.Theme, result *scanner.ScanResult, newCount, dupCount int) {
+	fmt.Println()
+	fmt.Printf("%sSARIF Import%s\n", "\033[1m", "\033[0m")
+	fmt.Printf("%s═══════════════════════════════════════════════════%s\n\n", "\033[2m", "\033[0m")
+
+	perSource := make(map[string]int)
+	for _, f := range result.Findings {
+		perSource[scanner.FindingSource(f)]++
+	}
+	for _, src := range result.SourceList() {
+		fmt.Printf("  %-16s %d finding(s)\n", src, perSource[src])
+	}
+	fmt.Println()
+
+	fmt.Printf("  %sCritical:%s  %s%d%s\n", "\033[1m", "\033[0m", colorize(t.Error), result.Totals.Critical, "\033[0m")
+	fmt.Printf("  %sWarning:%s   %s%d%s\n", "\033[1m", "\033[0m", colorize(t.Warning), result.Totals.Warning, "\033[0m")
+	fmt.Printf("  %sInfo:%s      %d\n", "\033[1m", "\033[0m", result.Totals.Info)
+	fmt.Printf("  Files:     %d\n", result.Totals.Files)
+	if dupCount > 0 {
+		fmt.Printf("  New:       %d (%d already tracked in beads)\n", newCount, dupCount)
+	}
+	fmt.Println()
+
+	for i, f := range result.Findings {
+		if i >= 10 {
+			fmt.Printf("  ... and %d more\n", len(result.Findings)-10)
+			break
+		}
+		loc := f.File
+		if f.Line > 0 {
+			loc = fmt.Sprintf("%s:%d", f.File, f.Line)
+		}
+		fmt.Printf("  %-8s %s %s%s%s - %s\n", f.Severity, loc, "\033[2m", f.RuleID, "\033[0m", f.Message)
+	}
+	fmt.Println()
+}
@@ -0,0 +1,466 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shahbajlive/ntm/internal/output"
+	"github.com/shahbajlive/ntm/internal/schedule"
+	"github.com/shahbajlive/ntm/internal/state"
+)
+
+// ScheduleListOutput is the JSON output for schedule ls.
+type ScheduleListOutput struct {
+	output.TimestampedResponse
+	Schedules []state.Schedule `json:"schedules"`
+}
+
+// ScheduleHistoryOutput is the JSON output for schedule history.
+type ScheduleHistoryOutput struct {
+	output.TimestampedResponse
+	Schedule state.Schedule      `json:"schedule"`
+	Runs     []state.ScheduleRun `json:"runs"`
+}
+
+// scheduleJobTimeout bounds a single scheduled job.
+const scheduleJobTimeout = 30 * time.Minute
+
+// openSchedules opens the state store's schedules. The caller closes the
+// returned store.
+func openSchedules() (*state.Store, *state.ScheduleStore, error) {
+	store, err := openTranscriptStore()
+	if err != nil {
+		return nil, nil, err
+	}
+	return store, state.NewScheduleStore(store), nil
+}
+
+// newScheduleRunner returns a runner that executes jobs through this binary.
+func newScheduleRunner(ss *state.ScheduleStore) *schedule.Runner {
+	return schedule.New(schedule.Config{Store: ss, Exec: execScheduledJob})
+}
+
+// startScheduleRunner fires session's schedules until ctx is done. Like the
+// prompt queue it is best-effort: without a state store nothing is scheduled
+// and false is returned.
+func startScheduleRunner(ctx context.Context, session string) bool {
+	store, ss, err := openSchedules()
+	if err != nil {
+		return false
+	}
+	go func() {
+		defer store.Close()
+		newScheduleRunner(ss).Run(ctx, session)
+	}()
+	return true
+}
+
+// scheduledCommand returns the ntm arguments that run s.
+func scheduledCommand(s state.Schedule) ([]string, error) {
+	switch s.Kind {
+	case schedule.KindSend:
+		return append([]string{"send", s.SessionName}, s.Args...), nil
+	case schedule.KindCheckpoint:
+		return append([]string{"checkpoint", "save", s.SessionName, "-m", "scheduled: " + s.Name}, s.Args...), nil
+	case schedule.KindPipeline:
+		return append(append([]string{"pipeline", "run"}, s.Args...), "--session", s.SessionName), nil
+	case schedule.KindScan:
+		return append([]string{"scan"}, s.Args...), nil
+	case schedule.KindHandoff:
+		return append([]string{"handoff", "create", s.SessionName, "--auto"}, s.Args...), nil
+	}
+	return nil, fmt.Errorf("unknown schedule kind %q", s.Kind)
+}
+
+// execScheduledJob runs s as a child ntm process in the schedule's project
+// directory, so every job goes through the same path as the command a user
+// would type.
+func execScheduledJob(ctx context.Context, s state.Schedule) (string, error) {
+	args, err := scheduledCommand(s)
+	if err != nil {
+		return "", err
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("locate ntm binary: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, scheduleJobTimeout)
+	defer cancel()
+
+	c := exec.CommandContext(ctx, self, args...)
+	if s.ProjectDir != "" {
+		c.Dir = s.ProjectDir
+	}
+	out, err := c.CombinedOutput()
+	if err != nil {
+		return string(out), fmt.Errorf("ntm %s: %w", strings.Join(args, " "), err)
+	}
+	return string(out), nil
+}
+
+func newScheduleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "Run prompts and maintenance jobs on a schedule",
+		Long: `Schedules run a send, checkpoint, pipeline run, scan or handoff for a
+session on a cron expression or a fixed interval. They are stored in the state
+database and fired by the session monitor while the session runs.
+
+Specs are five-field cron expressions in local time ("0 9 * * 1-5"),
+@hourly/@daily/@weekly/@monthly, or intervals ("@every 30m").
+
+Firings missed while no monitor was running are recorded as skipped, or with
+--missed catch-up run once when the monitor comes back. Every firing is
+written to the audit log.
+
+Examples:
+  ntm schedule add send -s myproject --cron "0 9 * * 1-5" -- --cc "post a stand-up summary"
+  ntm schedule add checkpoint -s myproject --every 2h --name hourly-ckpt
+  ntm schedule add pipeline -s myproject --cron @daily -- nightly.yaml
+  ntm schedule ls
+  ntm schedule pause hourly-ckpt
+  ntm schedule run-now hourly-ckpt`,
+	}
+
+	cmd.AddCommand(
+		newScheduleAddCmd(),
+		newScheduleListCmd(),
+		newSchedulePauseCmd(true),
+		newSchedulePauseCmd(false),
+		newScheduleRemoveCmd(),
+		newScheduleRunNowCmd(),
+		newScheduleHistoryCmd(),
+	)
+	return cmd
+}
+
+func newScheduleAddCmd() *cobra.Command {
+	var (
+		name, session, cronExpr, every, dir, missed string
+		jitter                                      time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "add <kind> [-- args...]",
+		Short: "Add a scheduled job",
+		Long: `Add a scheduled job. kind is one of send, checkpoint, pipeline, scan or
+handoff; arguments after -- are passed to the command it runs:
+
+  send        ntm send <session> <args>
+  checkpoint  ntm checkpoint save <session> -m "scheduled: <name>" <args>
+  pipeline    ntm pipeline run <args> --session <session>
+  scan        ntm scan <args>
+  handoff     ntm handoff create <session> --auto <args>`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kind := args[0]
+			if !schedule.ValidKind(kind) {
+				return fmt.Errorf("unknown kind %q (want one of %s)", kind, strings.Join(schedule.Kinds, ", "))
+			}
+			if (cronExpr == "") == (every == "") {
+				return fmt.Errorf("give exactly one of --cron or --every")
+			}
+			spec := cronExpr
+			if every != "" {
+				spec = "@every " + every
+			}
+			if _, err := schedule.Parse(spec); err != nil {
+				return err
+			}
+			if missed != state.MissedSkip && missed != state.MissedCatchUp {
+				return fmt.Errorf("invalid --missed %q (want %s or %s)", missed, state.MissedSkip, state.MissedCatchUp)
+			}
+			if jitter < 0 {
+				return fmt.Errorf("--jitter must not be negative")
+			}
+
+			if session == "" {
+				res, err := ResolveSession("", cmd.ErrOrStderr())
+				if err != nil {
+					return err
+				}
+				if res.Session == "" {
+					return fmt.Errorf("--session is required")
+				}
+				res.ExplainIfInferred(cmd.ErrOrStderr())
+				session = res.Session
+			}
+			if dir == "" {
+				dir = cfg.GetProjectDir(session)
+			}
+			if name == "" {
+				name = fmt.Sprintf("%s-%s-%d", session, kind, time.Now().Unix())
+			}
+
+			store, ss, err := openSchedules()
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			s := &state.Schedule{
+				Name:          name,
+				SessionName:   session,
+				ProjectDir:    dir,
+				Kind:          kind,
+				Args:          args[1:],
+				Spec:          spec,
+				JitterSeconds: int(jitter / time.Second),
+				MissedPolicy:  missed,
+			}
+			if s.NextRunAt, err = newScheduleRunner(ss).NextRun(*s, time.Now()); err != nil {
+				return err
+			}
+			if err := ss.Create(s); err != nil {
+				return err
+			}
+
+			if IsJSONOutput() {
+				return output.PrintJSON(s)
+			}
+			fmt.Printf("Added schedule %s: %s for %s (%s)\n", s.Name, s.Kind, s.SessionName, s.Spec)
+			if s.NextRunAt != nil {
+				fmt.Printf("Next run: %s\n", s.NextRunAt.Local().Format("2006-01-02 15:04"))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", "", "Schedule name (default: generated)")
+	cmd.Flags().StringVarP(&session, "session", "s", "", "Session the job runs against")
+	cmd.Flags().StringVar(&cronExpr, "cron", "", "Cron expression, e.g. \"0 9 * * 1-5\" or @daily")
+	cmd.Flags().StringVar(&every, "every", "", "Fixed interval, e.g. 30m or 2h")
+	cmd.Flags().DurationVar(&jitter, "jitter", 0, "Random delay of up to this much added to each firing")
+	cmd.Flags().StringVar(&missed, "missed", state.MissedSkip, "Missed-run policy: skip or catch-up")
+	cmd.Flags().StringVar(&dir, "dir", "", "Working directory for the job (default: session project dir)")
+	return cmd
+}
+
+func newScheduleListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "ls [session]",
+		Aliases: []string{"list"},
+		Short:   "List schedules",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			session := ""
+			if len(args) > 0 {
+				session = args[0]
+			}
+			store, ss, err := openSchedules()
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			list, err := ss.List(session)
+			if err != nil {
+				return err
+			}
+			if IsJSONOutput() {
+				if list == nil {
+					list = []state.Schedule{}
+				}
+				return output.PrintJSON(ScheduleListOutput{TimestampedResponse: output.NewTimestamped(), Schedules: list})
+			}
+			if len(list) == 0 {
+				output.PrintInfof("No schedules")
+				return nil
+			}
+			fmt.Printf("%-20s %-16s %-10s %-16s %-16s %-8s %s\n", "NAME", "SESSION", "KIND", "SPEC", "NEXT", "LAST", "RUNS")
+			for _, s := range list {
+				next := "-"
+				switch {
+				case s.Paused:
+					next = "paused"
+				case s.NextRunAt != nil:
+					next = s.NextRunAt.Local().Format("01-02 15:04")
+				}
+				last := s.LastStatus
+				if last == "" {
+					last = "-"
+				}
+				fmt.Printf("%-20s %-16s %-10s %-16s %-16s %-8s %d\n", truncateString(s.Name, 20), truncateString(s.SessionName, 16),
+					s.Kind, truncateString(s.Spec, 16), next, last, s.RunCount)
+			}
+			return nil
+		},
+	}
+}
+
+func newSchedulePauseCmd(pause bool) *cobra.Command {
+	use, short, verb := "resume <name>", "Resume a paused schedule", "Resumed"
+	if pause {
+		use, short, verb = "pause <name>", "Pause a schedule", "Paused"
+	}
+	return &cobra.Command{
+		Use:   use,
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, ss, err := openSchedules()
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			s, err := getScheduleByName(ss, args[0])
+			if err != nil {
+				return err
+			}
+			// Resuming starts from now rather than replaying firings missed
+			// while paused.
+			var next *time.Time
+			if !pause {
+				if next, err = newScheduleRunner(ss).NextRun(*s, time.Now()); err != nil {
+					return err
+				}
+			}
+			if _, err := ss.SetPaused(s.ID, pause, next); err != nil {
+				return err
+			}
+			if s, err = ss.Get(s.ID); err != nil {
+				return err
+			}
+			if IsJSONOutput() {
+				return output.PrintJSON(s)
+			}
+			fmt.Printf("%s schedule %s\n", verb, s.Name)
+			return nil
+		},
+	}
+}
+
+func newScheduleRemoveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rm <name>",
+		Short: "Remove a schedule and its run history",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, ss, err := openSchedules()
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			s, err := getScheduleByName(ss, args[0])
+			if err != nil {
+				return err
+			}
+			if _, err := ss.Delete(s.ID); err != nil {
+				return err
+			}
+			if IsJSONOutput() {
+				return output.PrintJSON(map[string]any{"removed": s.Name})
+			}
+			fmt.Printf("Removed schedule %s\n", s.Name)
+			return nil
+		},
+	}
+}
+
+func newScheduleRunNowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "run-now <name>",
+		Short: "Run a scheduled job immediately",
+		Long: `Run a schedule's job now, in the foreground. The run is recorded and
+audited like a scheduled firing; the next scheduled run is unchanged.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, ss, err := openSchedules()
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			s, err := getScheduleByName(ss, args[0])
+			if err != nil {
+				return err
+			}
+			run, err := newScheduleRunner(ss).RunNow(cmd.Context(), *s)
+			if err != nil {
+				return err
+			}
+			if IsJSONOutput() {
+				return output.PrintJSON(run)
+			}
+			if run.Output != "" {
+				fmt.Print(run.Output)
+				if !strings.HasSuffix(run.Output, "\n") {
+					fmt.Println()
+				}
+			}
+			if run.Status == state.ScheduleRunFailed {
+				return fmt.Errorf("schedule %s failed: %s", s.Name, run.Error)
+			}
+			fmt.Printf("Ran schedule %s\n", s.Name)
+			return nil
+		},
+	}
+}
+
+func newScheduleHistoryCmd() *cobra.Command {
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "history <name>",
+		Short: "Show a schedule's recent runs",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, ss, err := openSchedules()
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			s, err := getScheduleByName(ss, args[0])
+			if err != nil {
+				return err
+			}
+			runs, err := ss.Runs(s.ID, limit)
+			if err != nil {
+				return err
+			}
+			if IsJSONOutput() {
+				if runs == nil {
+					runs = []state.ScheduleRun{}
+				}
+				return output.PrintJSON(ScheduleHistoryOutput{TimestampedResponse: output.NewTimestamped(), Schedule: *s, Runs: runs})
+			}
+			if len(runs) == 0 {
+				output.PrintInfof("Schedule %s has not run yet", s.Name)
+				return nil
+			}
+			fmt.Printf("%-16s %-9s %-8s %8s  %s\n", "STARTED", "TRIGGER", "STATUS", "TOOK", "ERROR")
+			for _, r := range runs {
+				took := "-"
+				if r.FinishedAt != nil {
+					took = formatDuration(r.FinishedAt.Sub(r.StartedAt).Truncate(time.Second))
+				}
+				fmt.Printf("%-16s %-9s %-8s %8s  %s\n", r.StartedAt.Local().Format("01-02 15:04:05"), r.Trigger,
+					r.Status, took, truncateString(r.Error, 60))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&limit, "limit", 20, "Maximum runs to show (0 for all)")
+	return cmd
+}
+
+func getScheduleByName(ss *state.ScheduleStore, name string) (*state.Schedule, error) {
+	s, err := ss.GetByName(name)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, fmt.Errorf("schedule %q not found", name)
+	}
+	return s, nil
+}
@@ -247,6 +247,13 @@ type SendOptions struct {
 	BatchBroadcast  bool          // Send same prompt to all agents simultaneously
 	BatchAgentIndex int           // Send to specific agent index (-1 = round-robin)
 
+	// Queue options: hold the prompt until the target goes idle
+	Queue         bool
+	QueuePriority int           // 0 (first) to 4 (last)
+	QueueDedup    string        // Skip if a waiting prompt has this key
+	QueueTTL      time.Duration // Expire undelivered prompts after this long
+	QueueAnyIdle  bool          // One prompt per agent type, taken by its first idle pane
+
 	// Runtime: filled by smart routing
 	routingResult *SendRoutingResult
 }
@@ -490,6 +497,11 @@ func newSendCmd() *cobra.Command {
 	var batchBroadcast bool
 	var batchAgentIndex int
 
+	// Queue mode variables
+	var queue, queueAnyIdle bool
+	var queuePriority int
+	var queueDedup, queueTTL string
+
 	// Project filter (bd-3cu02.14)
 	var projectFilter string
 
@@ -528,6 +540,11 @@ func newSendCmd() *cobra.Command {
 		Use --route to specify the strategy (default: least-loaded).
 		Strategies: least-loaded, round-robin, affinity, sticky, random.
 
+		Queueing:
+		Use --queue to hold the prompt until each target pane goes idle instead of
+		typing it in immediately. --first-idle queues a single prompt per agent type
+		for whichever matching pane is idle first. See 'ntm queue' to inspect the queue.
+
 		Examples:
 		  ntm send myproject "fix the linting errors"           # All agents
 		  ntm send myproject --cc "review the changes"          # All Claude agents
@@ -546,6 +563,8 @@ func newSendCmd() *cobra.Command {
 		  ntm send myproject -c a.go -c b.go "Compare these"    # Multiple files
 		  ntm send myproject -t code_review --file src/main.go  # Template with file
 		  ntm send myproject -t fix --var issue="null pointer" --file src/app.go  # Template with vars
+		  ntm send myproject --cc --queue "run the tests next"  # Deliver when each goes idle
+		  ntm send myproject --cod --first-idle --ttl 1h "triage" # First idle Codex agent
 		  ntm send myproject --smart "fix auth bug"             # Auto-select best agent
 		  ntm send myproject --smart --route=affinity "auth"    # Use affinity strategy`,
 		Args: cobra.ArbitraryArgs,
@@ -656,6 +675,20 @@ func newSendCmd() *cobra.Command {
 				DryRun:         dryRun,
 				Randomize:      randomize,
 				Seed:           seed,
+				Queue:          queue || queueAnyIdle,
+				QueuePriority:  queuePriority,
+				QueueDedup:     queueDedup,
+				QueueAnyIdle:   queueAnyIdle,
+			}
+			if queueTTL != "" {
+				ttl, err := parseDuration(queueTTL)
+				if err != nil {
+					return fmt.Errorf("invalid --ttl: %w", err)
+				}
+				opts.QueueTTL = ttl
+			}
+			if opts.Queue && (queuePriority < 0 || queuePriority > 4) {
+				return fmt.Errorf("--priority must be between 0 and 4")
 			}
 
 			// Handle template-based prompts
@@ -750,6 +783,13 @@ func newSendCmd() *cobra.Command {
 	cmd.Flags().BoolVar(&batchBroadcast, "broadcast", false, "Send same prompt to all agents simultaneously")
 	cmd.Flags().IntVar(&batchAgentIndex, "agent", -1, "Send to specific agent index only (-1 = round-robin)")
 
+	// Queue mode flags - deliver when target panes go idle
+	cmd.Flags().BoolVar(&queue, "queue", false, "Queue the prompt and deliver it when each target pane goes idle")
+	cmd.Flags().BoolVar(&queueAnyIdle, "first-idle", false, "Queue one prompt per agent type for whichever matching pane goes idle first (implies --queue)")
+	cmd.Flags().IntVar(&queuePriority, "priority", state.DefaultQueuePriority, "Queue priority, 0 (first) to 4 (last)")
+	cmd.Flags().StringVar(&queueDedup, "dedup", "", "Dedup key: skip queueing if a waiting prompt already has it")
+	cmd.Flags().StringVar(&queueTTL, "ttl", "", "Drop the queued prompt if undelivered after this long (e.g. 30m, 2h)")
+
 	// Project filter (bd-3cu02.14)
 	cmd.Flags().StringVar(&projectFilter, "project", "", "broadcast to all sessions for a base project name")
 
@@ -949,36 +989,23 @@ func runSendWithTemplate(templateVars []string, promptFile string, contextFiles
 		return fmt.Errorf("loading template '%s': %w", opts.TemplateName, err)
 	}
 
-	// Parse template variables from --var flags
-	vars := make(map[string]string)
-	for _, v := range templateVars {
-		parts := strings.SplitN(v, "=", 2)
-		if len(parts) != 2 {
-			return fmt.Errorf("invalid --var format '%s' (expected key=value)", v)
-		}
-		vars[parts[0]] = parts[1]
+	// Render with --var values, --file content and live context providers
+	pane := -1
+	if opts.PaneIndex >= 0 && !opts.PanesSpecified {
+		pane = opts.PaneIndex
+	} else if len(opts.Panes) == 1 {
+		pane = opts.Panes[0]
 	}
-
-	// Build execution context
-	ctx := templates.ExecutionContext{
-		Variables: vars,
-		Session:   opts.Session,
-	}
-
-	// Read file content if --file specified (used as {{file}} variable)
-	if promptFile != "" {
-		content, err := os.ReadFile(promptFile)
-		if err != nil {
-			return fmt.Errorf("reading file '%s': %w", promptFile, err)
-		}
-		ctx.FileContent = string(content)
-	}
-
-	// Execute the template
-	promptText, err := tmpl.Execute(ctx)
+	promptText, report, err := renderTemplate(tmpl, templateRenderOptions{
+		Vars:       templateVars,
+		PromptFile: promptFile,
+		Session:    opts.Session,
+		Pane:       pane,
+	})
 	if err != nil {
-		return fmt.Errorf("executing template: %w", err)
+		return err
 	}
+	warnTemplateProviders(report)
 
 	// Inject additional file context if specified (via --context)
 	if len(contextFiles) > 0 {
@@ -1056,10 +1083,13 @@ func runSendInternal(opts SendOptions) (err error) {
 					if !jsonOutput {
 						fmt.Fprintln(os.Stderr, msg)
 					}
-				case redaction.ModeRedact:
+				case redaction.ModeRedact, redaction.ModePseudonymize:
 					prompt = result.Output
 					opts.Prompt = prompt
 					msg := "Warning: redacted potential secrets in prompt"
+					if result.Mode == redaction.ModePseudonymize {
+						msg = "Warning: pseudonymized potential secrets in prompt"
+					}
 					if parts := formatRedactionCategoryCounts(summary.Categories); parts != "" {
 						msg = fmt.Sprintf("%s (%s)", msg, parts)
 					}
@@ -1185,6 +1215,12 @@ func runSendInternal(opts SendOptions) (err error) {
 		return outputError(redactionBlockedError{summary: *redactionSummary})
 	}
 
+	if !dryRun {
+		if err := sessionPkg.CheckSends(session); err != nil {
+			return outputError(err)
+		}
+	}
+
 	// Smart routing: select best agent automatically.
 	// Explicit pane selection (--pane/--panes) wins over automatic routing.
 	if opts.SmartRoute && (opts.PanesSpecified || paneIndex >= 0) {
@@ -1509,6 +1545,22 @@ func runSendInternal(opts SendOptions) (err error) {
 		})
 	}
 
+	if opts.Queue {
+		if err := enqueueSendPrompt(opts, selectedPanes, prompt); err != nil {
+			return outputError(err)
+		}
+		histSuccess = true
+		return nil
+	}
+
+	// Queued prompts are checked against budgets when they are delivered;
+	// typing into user panes spends nothing.
+	if types := paneAgentTypes(selectedPanes); len(types) > 0 {
+		if err := enforceSpendBudget(session, types, "send"); err != nil {
+			return outputError(err)
+		}
+	}
+
 	// If specific pane requested
 	if paneIndex >= 0 {
 		p := selectedPanes[0]
@@ -2645,16 +2697,19 @@ const (
 )
 
 func sendPromptToPane(session string, p tmux.Pane, prompt string) error {
+	// Only the keystrokes carry real secrets; markers keep the tokens.
+	text := redaction.RehydrateForPane(p.Title, p.Tags, prompt)
 	if p.Type == tmux.AgentUser {
-		if err := tmux.PasteKeys(p.ID, prompt, true); err != nil {
+		if err := tmux.PasteKeys(p.ID, text, true); err != nil {
 			return err
 		}
 		return nil
 	}
-	if err := sendPromptWithDoubleEnterForAgent(p.ID, prompt, p.Type); err != nil {
+	if err := sendPromptWithDoubleEnterForAgent(p.ID, text, p.Type); err != nil {
 		return err
 	}
 	addTimelinePromptMarker(session, p, prompt)
+	recordPromptSpend(session, p, prompt)
 	return nil
 }
 
@@ -3426,6 +3481,10 @@ func runSendBatch(opts SendOptions) error {
 		})
 	}
 
+	if err := enforceSpendBudget(opts.Session, paneAgentTypes(agentPanes), "batch send"); err != nil {
+		return err
+	}
+
 	// Set up signal handling for graceful Ctrl+C
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -278,7 +278,7 @@ type SpawnOptions struct {
 
 	// Assignment configuration for spawn+assign workflow
 	Assign             bool          // Enable auto-assignment after spawn
-	AssignStrategy     string        // Assignment strategy: balanced, speed, quality, dependency, round-robin
+	AssignStrategy     string        // Assignment strategy: balanced, speed, quality, dependency, round-robin, optimal
 	AssignLimit        int           // Maximum assignments (0 = unlimited)
 	AssignReadyTimeout time.Duration // Timeout waiting for agents to become ready
 	AssignVerbose      bool          // Show detailed scoring/decision logs during assignment
@@ -791,7 +791,7 @@ Examples:
 
 	// Assignment flags for spawn+assign workflow
 	cmd.Flags().BoolVar(&assignEnabled, "assign", false, "Auto-assign beads to spawned agents after ready")
-	cmd.Flags().StringVar(&assignStrategy, "strategy", "balanced", "Assignment strategy: balanced, speed, quality, dependency, round-robin")
+	cmd.Flags().StringVar(&assignStrategy, "strategy", "balanced", "Assignment strategy: balanced, speed, quality, dependency, round-robin, optimal")
 	cmd.Flags().IntVar(&assignLimit, "limit", 0, "Maximum beads to assign (0 = unlimited)")
 	cmd.Flags().DurationVar(&assignReadyTimeout, "ready-timeout", 60*time.Second, "Timeout waiting for agents to become ready")
 	cmd.Flags().BoolVarP(&assignVerbose, "assign-verbose", "", false, "Show detailed scoring/decision logs during assignment")
@@ -1656,9 +1656,24 @@ func spawnSessionLogic(opts SpawnOptions) error {
 				output.PrintWarningf("Failed to save resilience manifest: %v", err)
 			}
 		} else {
-			// Launch monitor in background
+			// Hand the session to ntmd, which hosts the loops of every session
+			hosted := false
+			if daemonEnabled() {
+				if st, err := attachSessionToDaemon(opts.Session); err != nil {
+					if !IsJSONOutput() {
+						output.PrintWarningf("ntmd unavailable, starting a per-session monitor: %v", err)
+					}
+				} else {
+					hosted = true
+					if !IsJSONOutput() {
+						output.PrintInfof("Session loops hosted by ntmd (pid: %d)", st.PID)
+					}
+				}
+			}
+
+			// Otherwise launch a monitor for this session in background
 			exe, err := os.Executable()
-			if err == nil {
+			if err == nil && !hosted {
 				cmd := exec.Command(exe, "internal-monitor", opts.Session)
 
 				// Setup logging
@@ -263,6 +263,12 @@ func runSwarm(ctx context.Context, opts swarmOptions) error {
 		return nil
 	}
 
+	for _, spec := range plan.Sessions {
+		if err := enforceSpendBudget(spec.Name, []string{spec.AgentType}, "swarm spawn"); err != nil {
+			return err
+		}
+	}
+
 	staggerDelay := time.Duration(swarmCfg.StaggerDelayMs) * time.Millisecond
 	if staggerDelay < 0 {
 		staggerDelay = 0
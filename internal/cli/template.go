@@ -19,7 +19,8 @@ func newTemplateCmd() *cobra.Command {
 		Long: `Manage reusable prompt templates with variable substitution.
 
 Templates are markdown files with optional YAML frontmatter that define
-reusable prompts. They support variable substitution and conditional sections.
+reusable prompts. They support variable substitution, conditional sections,
+loops, filters, shared partials and live context providers.
 
 Template locations (in order of precedence):
   1. Project: .ntm/templates/*.md
@@ -45,8 +46,28 @@ Example template format:
   {{/focus}}
 
 Variables:
-  {{variable}}         - Simple substitution
-  {{#var}}...{{/var}}  - Conditional (included only if var is set)
+  {{variable}}                  - Simple substitution
+  {{#var}}...{{/var}}           - Conditional (included only if var is set)
+  {{^var}}...{{/var}}           - Inverted (included only if var is empty)
+  {{#each list}}{{.}}{{/each}}  - Loop over lines (or comma-separated items);
+                                  {{@index}}, {{@number}}, {{@first}}, {{@last}}
+  {{var | default "x" | upper}} - Filters: default, upper, lower, trim, indent N,
+                                  truncate N, head N, tail N, join ", ",
+                                  code "lang", budget N (tokens)
+  {{> name}}                    - Include a partial (partials/name.md, then name.md)
+  {{! comment}}                 - Removed from output
+
+Context providers (resolved at send time, trimmed to a token budget):
+  {{@git_diff}}                 - Uncommitted changes ({{@git_diff "staged"}}, or a ref)
+  {{@test_failures}}            - Failures from .ntm/test-output.log, or
+                                  {{@test_failures "go test ./..."}} to run tests
+                                  (executed directly, not through a shell)
+  {{@files "internal/**/*.go"}} - Contents of matching files
+  {{@bead}}                     - The target pane's assigned bead (or {{@bead "bd-12"}})
+  {{@cass "query"}}             - Related past sessions from CASS
+Budgets default per provider; override in frontmatter:
+  context_budgets:
+    git_diff: 2000
 
 Built-in variables:
   {{cwd}}      - Current working directory
@@ -57,12 +78,16 @@ Built-in variables:
 
 Use with ntm send:
   ntm send myproject --template=code_review --file=src/main.go
-  ntm send myproject -t refactor --var goal="simplify" --file=src/main.go`,
+  ntm send myproject -t refactor --var goal="simplify" --file=src/main.go
+
+Preview with live context:
+  ntm template render fix_tests --session myproject --pane 2 --dry-run`,
 	}
 
 	cmd.AddCommand(
 		newTemplateListCmd(),
 		newTemplateShowCmd(),
+		newTemplateRenderCmd(),
 	)
 
 	return cmd
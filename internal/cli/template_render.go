@@ -0,0 +1,299 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shahbajlive/ntm/internal/assignment"
+	"github.com/shahbajlive/ntm/internal/bv"
+	"github.com/shahbajlive/ntm/internal/cass"
+	"github.com/shahbajlive/ntm/internal/output"
+	"github.com/shahbajlive/ntm/internal/templates"
+)
+
+// templateRenderOptions describes the context a template is rendered in.
+type templateRenderOptions struct {
+	Vars       []string // key=value pairs from --var
+	PromptFile string   // --file content, exposed as {{file}}
+	Session    string
+	Pane       int // Target pane for {{@bead}}; -1 if not a single pane
+}
+
+// TemplateRenderOutput is the JSON output for template render.
+type TemplateRenderOutput struct {
+	output.TimestampedResponse
+	Name      string                    `json:"name"`
+	Source    string                    `json:"source"`
+	Prompt    string                    `json:"prompt"`
+	Tokens    int                       `json:"tokens"`
+	Partials  []string                  `json:"partials,omitempty"`
+	Providers []templates.ProviderUsage `json:"providers,omitempty"`
+}
+
+func newTemplateRenderCmd() *cobra.Command {
+	var (
+		vars       []string
+		promptFile string
+		session    string
+		pane       int
+		dryRun     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "render <name>",
+		Short: "Render a template with live context",
+		Long: `Render a template exactly as 'ntm send --template' would, resolving
+partials and context providers, and print the resulting prompt.
+
+With --dry-run, a summary of the partials used and the tokens each context
+provider contributed (against its budget) is printed before the prompt.
+
+Examples:
+  ntm template render fix_tests --dry-run
+  ntm template render review --var focus=security --session myproject --pane 2
+  ntm template render review --json | jq .providers`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTemplateRender(args[0], templateRenderOptions{
+				Vars:       vars,
+				PromptFile: promptFile,
+				Session:    session,
+				Pane:       pane,
+			}, dryRun)
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&vars, "var", nil, "Template variable (key=value, repeatable)")
+	cmd.Flags().StringVarP(&promptFile, "file", "f", "", "File content for {{file}}")
+	cmd.Flags().StringVarP(&session, "session", "s", "", "Session for {{session}}, {{@bead}} and the project directory")
+	cmd.Flags().IntVarP(&pane, "pane", "p", -1, "Pane whose assigned bead {{@bead}} uses")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show partials and per-provider token usage with the prompt")
+
+	return cmd
+}
+
+func runTemplateRender(name string, opts templateRenderOptions, dryRun bool) error {
+	tmpl, err := templates.NewLoader().Load(name)
+	if err != nil {
+		return err
+	}
+	text, report, err := renderTemplate(tmpl, opts)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		return output.PrintJSON(TemplateRenderOutput{
+			TimestampedResponse: output.NewTimestamped(),
+			Name:                tmpl.Name,
+			Source:              tmpl.Source.String(),
+			Prompt:              text,
+			Tokens:              report.Tokens,
+			Partials:            report.Partials,
+			Providers:           report.Providers,
+		})
+	}
+
+	if dryRun {
+		printTemplateRenderReport(tmpl, report)
+		fmt.Println("─────────────────────────────────────────")
+	}
+	fmt.Println(text)
+	return nil
+}
+
+func printTemplateRenderReport(tmpl *templates.Template, report *templates.RenderReport) {
+	fmt.Printf("Template:  %s (%s)\n", tmpl.Name, tmpl.Source.String())
+	if len(report.Partials) > 0 {
+		fmt.Printf("Partials:  %s\n", strings.Join(report.Partials, ", "))
+	}
+	if len(report.Providers) > 0 {
+		fmt.Println("Context:")
+		for _, p := range report.Providers {
+			label := "@" + p.Name
+			if len(p.Args) > 0 {
+				label += " " + strings.Join(p.Args, " ")
+			}
+			budget := "unlimited"
+			if p.Budget > 0 {
+				budget = fmt.Sprintf("%d", p.Budget)
+			}
+			status := ""
+			switch {
+			case p.Error != "":
+				status = "  (unavailable: " + p.Error + ")"
+			case p.Trimmed:
+				status = "  (trimmed)"
+			}
+			fmt.Printf("  %-32s %6d / %s tokens%s\n", label, p.Tokens, budget, status)
+		}
+	}
+	fmt.Printf("Total:     ~%d tokens\n", report.Tokens)
+}
+
+// renderTemplate executes tmpl with variables, file content and the
+// session-aware context providers.
+func renderTemplate(tmpl *templates.Template, opts templateRenderOptions) (string, *templates.RenderReport, error) {
+	vars := make(map[string]string)
+	for _, v := range opts.Vars {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 {
+			return "", nil, fmt.Errorf("invalid --var format '%s' (expected key=value)", v)
+		}
+		vars[parts[0]] = parts[1]
+	}
+
+	ctx := templates.ExecutionContext{
+		Variables: vars,
+		Session:   opts.Session,
+		Partials:  templates.NewLoader(),
+		Providers: templateContextProviders(opts.Session, opts.Pane),
+	}
+	if opts.Session != "" && cfg != nil {
+		if dir := cfg.GetProjectDir(opts.Session); dir != "" {
+			if info, err := os.Stat(dir); err == nil && info.IsDir() {
+				ctx.WorkDir = dir
+			}
+		}
+	}
+
+	// Read file content if --file specified (used as {{file}} variable)
+	if opts.PromptFile != "" {
+		content, err := os.ReadFile(opts.PromptFile)
+		if err != nil {
+			return "", nil, fmt.Errorf("reading file '%s': %w", opts.PromptFile, err)
+		}
+		ctx.FileContent = string(content)
+	}
+
+	text, report, err := tmpl.ExecuteWithReport(ctx)
+	if err != nil {
+		return "", nil, fmt.Errorf("executing template: %w", err)
+	}
+	return text, report, nil
+}
+
+// templateContextProviders returns the providers that need ntm state:
+// {{@bead}} for the pane's assigned bead and {{@cass "query"}} for related
+// past sessions.
+func templateContextProviders(session string, pane int) map[string]templates.Provider {
+	return map[string]templates.Provider{
+		"bead": {
+			Name:        "bead",
+			Description: "The target pane's assigned bead (or the given bead ID)",
+			Budget:      1000,
+			Resolve: func(req templates.ProviderRequest) (string, error) {
+				return resolveBeadContext(req, session, pane)
+			},
+		},
+		"cass": {
+			Name:        "cass",
+			Description: "Past agent sessions matching the given query (CASS search)",
+			Budget:      1500,
+			Resolve: func(req templates.ProviderRequest) (string, error) {
+				return resolveCassContext(req)
+			},
+		},
+	}
+}
+
+func resolveBeadContext(req templates.ProviderRequest, session string, pane int) (string, error) {
+	beadID := req.Context.BeadID
+	if len(req.Args) > 0 {
+		beadID = req.Args[0]
+	}
+	if beadID == "" {
+		if session == "" || pane < 0 {
+			return "", fmt.Errorf("no bead: pass an ID ({{@bead \"bd-123\"}}) or target a single pane of a session")
+		}
+		store, err := assignment.LoadStore(session)
+		if err != nil {
+			return "", fmt.Errorf("loading assignments: %w", err)
+		}
+		for _, a := range store.ListActive() {
+			if a.Pane == pane {
+				beadID = a.BeadID
+				break
+			}
+		}
+		if beadID == "" {
+			return "", fmt.Errorf("pane %d has no active bead assignment", pane)
+		}
+	}
+
+	out, err := bv.RunBd(req.Dir(), "show", beadID, "--json")
+	if err != nil {
+		return "", err
+	}
+	var issues []struct {
+		ID          string `json:"id"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Status      string `json:"status"`
+		Priority    int    `json:"priority"`
+		IssueType   string `json:"issue_type"`
+	}
+	if err := json.Unmarshal([]byte(out), &issues); err != nil || len(issues) == 0 {
+		return "", fmt.Errorf("parsing bead %s: unexpected br show output", beadID)
+	}
+	issue := issues[0]
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Bead %s: %s\n", beadID, issue.Title)
+	fmt.Fprintf(&sb, "Type: %s  Priority: P%d  Status: %s\n", issue.IssueType, issue.Priority, issue.Status)
+	if desc := strings.TrimSpace(issue.Description); desc != "" {
+		sb.WriteString("\n" + desc + "\n")
+	}
+	return sb.String(), nil
+}
+
+func resolveCassContext(req templates.ProviderRequest) (string, error) {
+	query := strings.TrimSpace(strings.Join(req.Args, " "))
+	if query == "" {
+		return "", fmt.Errorf(`@cass needs a query, e.g. {{@cass "flaky auth test"}}`)
+	}
+
+	var opts []cass.ClientOption
+	if cfg != nil && cfg.CASS.BinaryPath != "" {
+		opts = append(opts, cass.WithBinaryPath(cfg.CASS.BinaryPath))
+	}
+	client := cass.NewClient(opts...)
+	resp, err := client.Search(context.Background(), cass.SearchOptions{
+		Query:     query,
+		Limit:     5,
+		Workspace: req.Context.WorkDir,
+		MaxTokens: req.Budget,
+	})
+	if err != nil {
+		return "", err
+	}
+	if !resp.HasResults() {
+		return "", nil
+	}
+
+	var sb strings.Builder
+	for _, hit := range resp.Hits {
+		fmt.Fprintf(&sb, "- %s (%s, %s)\n", hit.Title, hit.Agent, hit.SourcePath)
+		if snippet := strings.TrimSpace(hit.Snippet); snippet != "" {
+			fmt.Fprintf(&sb, "  %s\n", strings.ReplaceAll(snippet, "\n", "\n  "))
+		}
+	}
+	return sb.String(), nil
+}
+
+// warnTemplateProviders reports context providers that could not be resolved.
+func warnTemplateProviders(report *templates.RenderReport) {
+	if report == nil || jsonOutput {
+		return
+	}
+	for _, p := range report.Providers {
+		if p.Error != "" {
+			output.PrintWarningf("template context @%s unavailable: %s", p.Name, p.Error)
+		}
+	}
+}
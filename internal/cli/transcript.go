@@ -0,0 +1,523 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shahbajlive/ntm/internal/output"
+	"github.com/shahbajlive/ntm/internal/state"
+	"github.com/shahbajlive/ntm/internal/transcript"
+)
+
+// TranscriptIngestOutput is the JSON output for transcript ingest.
+type TranscriptIngestOutput struct {
+	output.TimestampedResponse
+	Session  string                    `json:"session,omitempty"`
+	Ingested int                       `json:"ingested"`
+	Results  []transcript.IngestResult `json:"results"`
+}
+
+// TranscriptListOutput is the JSON output for transcript list.
+type TranscriptListOutput struct {
+	output.TimestampedResponse
+	Transcripts []state.TranscriptRecord `json:"transcripts"`
+}
+
+// TranscriptShowOutput is the JSON output for transcript show.
+type TranscriptShowOutput struct {
+	output.TimestampedResponse
+	Transcript *state.TranscriptRecord `json:"transcript"`
+	Turns      []state.TranscriptTurn  `json:"turns"`
+}
+
+// TranscriptQueryOutput is the JSON output for transcript query.
+type TranscriptQueryOutput struct {
+	output.TimestampedResponse
+	Count int                         `json:"count"`
+	Turns []state.TranscriptTurnMatch `json:"turns"`
+}
+
+func newTranscriptCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "transcript",
+		Short: "Ingest and search agent conversation transcripts",
+		Long: `Parse the transcripts Claude Code, Codex and Gemini CLI write to disk into
+normalized turns (user prompts, assistant text, tool calls with their
+arguments and results, and edited files), store them in the state database
+linked to the session, pane and bead that produced them, and query them.
+
+Transcripts are found in:
+  Claude Code  ~/.claude/projects/<project>/*.jsonl
+  Codex        ~/.codex/sessions/YYYY/MM/DD/rollout-*.jsonl
+  Gemini CLI   ~/.gemini/tmp/<project hash>/chats/*.json
+
+Examples:
+  ntm transcript ingest myproject
+  ntm transcript list myproject
+  ntm transcript show 12 --tools
+  ntm transcript query --session myproject --file internal/auth/token.go
+  ntm transcript query --tool Bash --text "go test" --since 2h`,
+	}
+
+	cmd.AddCommand(
+		newTranscriptIngestCmd(),
+		newTranscriptListCmd(),
+		newTranscriptShowCmd(),
+		newTranscriptQueryCmd(),
+	)
+	return cmd
+}
+
+func newTranscriptIngestCmd() *cobra.Command {
+	var (
+		agents []string
+		paths  []string
+		since  string
+		dir    string
+		pane   int
+		bead   string
+		force  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "ingest [session]",
+		Short: "Parse agent transcripts into the state database",
+		Long: `Find the transcripts agents wrote for a session's project directory and
+store their turns. Files already ingested at the same size and modification
+time are skipped unless --force is given.
+
+Each transcript is linked to the pane whose prompts (from 'ntm send' history)
+appear in it, and to the bead that pane was assigned at the time. Use --pane
+and --bead to set the link explicitly.
+
+With --path, only the given files are ingested; the agent is detected from
+the path or content unless --agent is set.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var session string
+			if len(args) > 0 {
+				session = args[0]
+			}
+			if session != "" || len(paths) == 0 {
+				res, err := ResolveSession(session, cmd.OutOrStdout())
+				if err != nil {
+					return err
+				}
+				if res.Session == "" {
+					return nil
+				}
+				res.ExplainIfInferred(os.Stderr)
+				session = res.Session
+			}
+			if dir == "" && session != "" && cfg != nil {
+				dir = cfg.GetProjectDir(session)
+			}
+
+			var agentList []transcript.Agent
+			for _, a := range agents {
+				agent, err := transcript.ParseAgent(a)
+				if err != nil {
+					return err
+				}
+				agentList = append(agentList, agent)
+			}
+
+			var files []transcript.File
+			if len(paths) > 0 {
+				for _, p := range paths {
+					abs, err := filepath.Abs(p)
+					if err != nil {
+						return err
+					}
+					var agent transcript.Agent
+					if len(agentList) == 1 {
+						agent = agentList[0]
+					} else if agent, err = transcript.DetectAgent(abs); err != nil {
+						return err
+					}
+					files = append(files, transcript.File{Agent: agent, Path: abs})
+				}
+			} else {
+				if dir == "" {
+					return fmt.Errorf("cannot determine the project directory for %q; pass --dir", session)
+				}
+				var sinceTime time.Time
+				if since != "" {
+					d, err := parseDuration(since)
+					if err != nil {
+						return fmt.Errorf("invalid --since: %w", err)
+					}
+					sinceTime = time.Now().Add(-d)
+				}
+				if len(agentList) == 0 {
+					agentList = transcript.Agents
+				}
+				var err error
+				if files, err = transcript.DefaultRoots().Discover(dir, agentList, sinceTime); err != nil {
+					return err
+				}
+			}
+
+			store, err := openTranscriptStore()
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			opts := transcript.IngestOptions{
+				Session:    session,
+				ProjectDir: dir,
+				BeadID:     bead,
+				Force:      force,
+			}
+			if session != "" {
+				opts.Linker = transcript.SessionLinker(session)
+			}
+			if cmd.Flags().Changed("pane") {
+				opts.Pane = &pane
+			}
+			results, err := transcript.Ingest(state.NewTranscriptStore(store), files, opts)
+			if err != nil {
+				return err
+			}
+
+			ingested := 0
+			for _, r := range results {
+				if !r.Unchanged && r.Error == "" {
+					ingested++
+				}
+			}
+			if IsJSONOutput() {
+				return output.PrintJSON(TranscriptIngestOutput{
+					TimestampedResponse: output.NewTimestamped(),
+					Session:             session,
+					Ingested:            ingested,
+					Results:             results,
+				})
+			}
+
+			if len(results) == 0 {
+				output.PrintInfof("No transcripts found for %s", dir)
+				return nil
+			}
+			for _, r := range results {
+				switch {
+				case r.Error != "":
+					output.PrintWarningf("%s: %s", r.Path, r.Error)
+				case r.Unchanged:
+					fmt.Printf("  = #%-4d %-6s %s (unchanged)\n", r.TranscriptID, r.Agent, r.Path)
+				default:
+					fmt.Printf("  + #%-4d %-6s %s\n", r.TranscriptID, r.Agent, r.Path)
+					fmt.Printf("          %d turns, %d tool calls, %d files edited%s\n",
+						r.Turns, r.ToolCalls, r.FilesEdited, transcriptLinkSuffix(r.Pane, r.BeadID))
+				}
+			}
+			output.PrintSuccessf("Ingested %d of %d transcript(s)", ingested, len(results))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&agents, "agent", nil, "Only these agents (claude, codex, gemini)")
+	cmd.Flags().StringArrayVar(&paths, "path", nil, "Ingest this transcript file (repeatable)")
+	cmd.Flags().StringVar(&since, "since", "", "Only transcripts modified within this duration (e.g. 2h, 7d)")
+	cmd.Flags().StringVar(&dir, "dir", "", "Project directory (default: the session's)")
+	cmd.Flags().IntVar(&pane, "pane", -1, "Link the transcripts to this pane")
+	cmd.Flags().StringVar(&bead, "bead", "", "Link the transcripts to this bead")
+	cmd.Flags().BoolVar(&force, "force", false, "Re-ingest transcripts that have not changed")
+	return cmd
+}
+
+func newTranscriptListCmd() *cobra.Command {
+	var (
+		pane  int
+		bead  string
+		agent string
+		limit int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "list [session]",
+		Short: "List ingested transcripts",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f := state.TranscriptFilter{BeadID: bead, Limit: limit}
+			if len(args) > 0 {
+				f.SessionName = args[0]
+			}
+			if cmd.Flags().Changed("pane") {
+				f.PaneIndex = &pane
+			}
+			if agent != "" {
+				a, err := transcript.ParseAgent(agent)
+				if err != nil {
+					return err
+				}
+				f.Agent = string(a)
+			}
+
+			store, err := openTranscriptStore()
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			list, err := state.NewTranscriptStore(store).List(f)
+			if err != nil {
+				return err
+			}
+			if IsJSONOutput() {
+				if list == nil {
+					list = []state.TranscriptRecord{}
+				}
+				return output.PrintJSON(TranscriptListOutput{TimestampedResponse: output.NewTimestamped(), Transcripts: list})
+			}
+
+			if len(list) == 0 {
+				output.PrintInfof("No transcripts ingested; run 'ntm transcript ingest'")
+				return nil
+			}
+			for _, t := range list {
+				when := t.FileMTime
+				if t.EndedAt != nil {
+					when = *t.EndedAt
+				}
+				fmt.Printf("#%-4d %-6s %-16s %3d turns  %s%s\n", t.ID, t.Agent,
+					when.Local().Format("2006-01-02 15:04"), t.TurnCount, t.SessionName,
+					transcriptLinkSuffix(t.PaneIndex, t.BeadID))
+				fmt.Printf("      %s\n", t.Path)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&pane, "pane", -1, "Only transcripts linked to this pane")
+	cmd.Flags().StringVar(&bead, "bead", "", "Only transcripts linked to this bead")
+	cmd.Flags().StringVar(&agent, "agent", "", "Only this agent (claude, codex, gemini)")
+	cmd.Flags().IntVar(&limit, "limit", 20, "Maximum transcripts to list (0 for all)")
+	return cmd
+}
+
+func newTranscriptShowCmd() *cobra.Command {
+	var (
+		tools bool
+		full  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "show <id|path>",
+		Short: "Show an ingested transcript turn by turn",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openTranscriptStore()
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+			ts := state.NewTranscriptStore(store)
+
+			var rec *state.TranscriptRecord
+			if id, convErr := strconv.ParseInt(args[0], 10, 64); convErr == nil {
+				rec, err = ts.Get(id)
+			} else {
+				abs, _ := filepath.Abs(args[0])
+				rec, err = ts.GetByPath(abs)
+			}
+			if err != nil {
+				return err
+			}
+			if rec == nil {
+				return fmt.Errorf("transcript %q not found; run 'ntm transcript ingest' first", args[0])
+			}
+			turns, err := ts.Turns(rec.ID)
+			if err != nil {
+				return err
+			}
+
+			if IsJSONOutput() {
+				if turns == nil {
+					turns = []state.TranscriptTurn{}
+				}
+				return output.PrintJSON(TranscriptShowOutput{TimestampedResponse: output.NewTimestamped(), Transcript: rec, Turns: turns})
+			}
+
+			fmt.Printf("Transcript #%d (%s)%s\n", rec.ID, rec.Agent, transcriptLinkSuffix(rec.PaneIndex, rec.BeadID))
+			fmt.Printf("  %s\n", rec.Path)
+			if rec.Cwd != "" {
+				fmt.Printf("  cwd: %s\n", rec.Cwd)
+			}
+			for _, turn := range turns {
+				fmt.Println()
+				printTranscriptTurn(turn, tools, full)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&tools, "tools", false, "Show tool call arguments and results")
+	cmd.Flags().BoolVar(&full, "full", false, "Do not truncate long text")
+	return cmd
+}
+
+func newTranscriptQueryCmd() *cobra.Command {
+	var (
+		f     state.TranscriptFilter
+		pane  int
+		agent string
+		since string
+		tools bool
+		full  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "query",
+		Short: "Search turns across ingested transcripts",
+		Long: `Search ingested turns, newest first. Filters combine; --text matches the
+turn text and tool call arguments and results, --file matches edited paths
+by suffix.
+
+Examples:
+  ntm transcript query --session myproject --pane 2 --role user
+  ntm transcript query --bead bd-42 --tool Edit
+  ntm transcript query --file auth/token.go --since 1d --json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if cmd.Flags().Changed("pane") {
+				f.PaneIndex = &pane
+			}
+			if agent != "" {
+				a, err := transcript.ParseAgent(agent)
+				if err != nil {
+					return err
+				}
+				f.Agent = string(a)
+			}
+			if since != "" {
+				d, err := parseDuration(since)
+				if err != nil {
+					return fmt.Errorf("invalid --since: %w", err)
+				}
+				f.Since = time.Now().Add(-d)
+			}
+			if f.Role != "" && f.Role != string(transcript.RoleUser) && f.Role != string(transcript.RoleAssistant) {
+				return fmt.Errorf("invalid --role %q (expected user or assistant)", f.Role)
+			}
+
+			store, err := openTranscriptStore()
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			matches, err := state.NewTranscriptStore(store).QueryTurns(f)
+			if err != nil {
+				return err
+			}
+			if IsJSONOutput() {
+				if matches == nil {
+					matches = []state.TranscriptTurnMatch{}
+				}
+				return output.PrintJSON(TranscriptQueryOutput{TimestampedResponse: output.NewTimestamped(), Count: len(matches), Turns: matches})
+			}
+
+			if len(matches) == 0 {
+				output.PrintInfof("No matching turns")
+				return nil
+			}
+			for i, m := range matches {
+				if i > 0 {
+					fmt.Println()
+				}
+				fmt.Println(strings.TrimSpace(fmt.Sprintf("#%d/%d %s %s", m.TranscriptID, m.Seq, m.Agent, m.SessionName)) + transcriptLinkSuffix(m.PaneIndex, m.BeadID))
+				printTranscriptTurn(m.TranscriptTurn, tools, full)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&f.SessionName, "session", "s", "", "Only this session")
+	cmd.Flags().IntVarP(&pane, "pane", "p", -1, "Only this pane")
+	cmd.Flags().StringVar(&f.BeadID, "bead", "", "Only this bead")
+	cmd.Flags().StringVar(&agent, "agent", "", "Only this agent (claude, codex, gemini)")
+	cmd.Flags().StringVar(&f.Role, "role", "", "Only user or assistant turns")
+	cmd.Flags().StringVar(&f.Text, "text", "", "Turn text or tool input/output contains this")
+	cmd.Flags().StringVar(&f.Tool, "tool", "", "Turn called this tool")
+	cmd.Flags().StringVar(&f.File, "file", "", "Turn edited a path ending in this")
+	cmd.Flags().StringVar(&since, "since", "", "Only turns within this duration (e.g. 30m, 2h, 7d)")
+	cmd.Flags().IntVar(&f.Limit, "limit", 50, "Maximum turns to return (0 for all)")
+	cmd.Flags().BoolVar(&tools, "tools", false, "Show tool call arguments and results")
+	cmd.Flags().BoolVar(&full, "full", false, "Do not truncate long text")
+	return cmd
+}
+
+func openTranscriptStore() (*state.Store, error) {
+	store, err := state.Open("")
+	if err != nil {
+		return nil, fmt.Errorf("open state store: %w", err)
+	}
+	if err := store.Migrate(); err != nil {
+		store.Close()
+		return nil, fmt.Errorf("migrate state store: %w", err)
+	}
+	return store, nil
+}
+
+func printTranscriptTurn(turn state.TranscriptTurn, tools, full bool) {
+	clip := func(s string, n int) string {
+		s = strings.TrimSpace(s)
+		if full {
+			return s
+		}
+		return truncateString(s, n)
+	}
+
+	header := strings.ToUpper(turn.Role)
+	if turn.Timestamp != nil {
+		header += "  " + turn.Timestamp.Local().Format("2006-01-02 15:04:05")
+	}
+	if turn.Model != "" {
+		header += "  " + turn.Model
+	}
+	fmt.Printf("── %s\n", header)
+	if turn.Text != "" {
+		fmt.Println(clip(turn.Text, 600))
+	}
+	for _, tc := range turn.ToolCalls {
+		status := ""
+		if tc.IsError {
+			status = " ✗"
+		}
+		line := "  → " + tc.Name + status
+		if len(tc.Files) > 0 {
+			line += "  " + strings.Join(tc.Files, ", ")
+		}
+		fmt.Println(line)
+		if tools {
+			if tc.Input != "" {
+				fmt.Printf("      in:  %s\n", clip(tc.Input, 200))
+			}
+			if tc.Output != "" {
+				fmt.Printf("      out: %s\n", strings.ReplaceAll(clip(tc.Output, 200), "\n", "\n           "))
+			}
+		}
+	}
+}
+
+func transcriptLinkSuffix(pane *int, bead string) string {
+	var parts []string
+	if pane != nil {
+		parts = append(parts, fmt.Sprintf("pane %d", *pane))
+	}
+	if bead != "" {
+		parts = append(parts, bead)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(parts, ", ") + "]"
+}
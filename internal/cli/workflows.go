@@ -45,7 +45,7 @@ func newWorkflowsCmd() *cobra.Command {
 		Use:     "workflows",
 		Aliases: []string{"workflow", "wf"},
 		Short:   "Manage workflow templates (orchestration patterns)",
-		Long: `List and view workflow templates (orchestration patterns).
+		Long: `List, view and run workflow templates (orchestration patterns).
 
 Workflow templates define multi-agent coordination patterns like:
   - ping-pong: Alternating work between agents (e.g., TDD red-green)
@@ -61,11 +61,18 @@ Sources (in precedence order):
 Examples:
   ntm workflows list                # List all available templates
   ntm workflows show red-green      # Show details of a template
+  ntm workflows run red-green proj --var feature=login  # Run a template's flow
   ntm workflows list --json         # JSON output for scripts`,
 	}
 
 	cmd.AddCommand(newWorkflowsListCmd())
 	cmd.AddCommand(newWorkflowsShowCmd())
+	cmd.AddCommand(newWorkflowsRunCmd())
+	cmd.AddCommand(newWorkflowsStatusCmd())
+	cmd.AddCommand(newWorkflowsSignalCmd("advance", "Fire a manual transition (by trigger label or target stage)"))
+	cmd.AddCommand(newWorkflowsSignalCmd("pause", "Pause trigger evaluation of a running workflow"))
+	cmd.AddCommand(newWorkflowsSignalCmd("resume", "Resume a paused workflow"))
+	cmd.AddCommand(newWorkflowsSignalCmd("abort", "Stop a running workflow"))
 
 	return cmd
 }
@@ -0,0 +1,676 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shahbajlive/ntm/internal/agentmail"
+	"github.com/shahbajlive/ntm/internal/notify"
+	"github.com/shahbajlive/ntm/internal/persona"
+	"github.com/shahbajlive/ntm/internal/session"
+	"github.com/shahbajlive/ntm/internal/status"
+	"github.com/shahbajlive/ntm/internal/tmux"
+	"github.com/shahbajlive/ntm/internal/util"
+	"github.com/shahbajlive/ntm/internal/watcher"
+	"github.com/shahbajlive/ntm/internal/workflow"
+)
+
+const (
+	workflowRunFile    = "workflow_run.json"
+	workflowSignalFile = "workflow_signal.json"
+	// workflowRoleTagPrefix marks the pane bound to a workflow role, e.g. [role:red].
+	workflowRoleTagPrefix  = "role:"
+	workflowCommandTimeout = 10 * time.Minute
+)
+
+// workflowSignal is a control request from another ntm process to the
+// runner attached to a session.
+type workflowSignal struct {
+	Action string    `json:"action"` // advance, pause, resume, abort
+	Arg    string    `json:"arg,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+func newWorkflowsRunCmd() *cobra.Command {
+	var vars []string
+	var poll time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "run <workflow> <session>",
+		Short: "Run a workflow template's flow in a session",
+		Long: `Run a workflow template as a state machine in an existing session.
+
+Each role is bound to a pane tagged [role:<name>]. Untagged agent panes of
+the role's agent type are claimed first; missing agents are added (using the
+role's profile as a persona when one exists). The runner then prompts the
+active stage's role, watches the stage's transition triggers (file changes,
+commands, agent output and Agent Mail, idle agents, elapsed time) and hands
+off to the next role with the previous stage's output.
+
+The runner stays in the foreground until the flow reaches a terminal stage.
+Control it from another terminal:
+  ntm workflows advance <session> [label|stage]   # fire a manual trigger
+  ntm workflows pause <session>
+  ntm workflows resume <session>
+  ntm workflows abort <session>
+  ntm workflows status <session>
+
+Examples:
+  ntm spawn myproject -t red-green
+  ntm workflows run red-green myproject --var feature="rate limiting"`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			values, err := parseWorkflowVars(vars)
+			if err != nil {
+				return err
+			}
+			return runWorkflow(cmd.OutOrStdout(), cmd.ErrOrStderr(), args[0], args[1], values, poll)
+		},
+	}
+	cmd.Flags().StringArrayVar(&vars, "var", nil, "Setup prompt answer as key=value (repeatable)")
+	cmd.Flags().DurationVar(&poll, "poll", workflow.DefaultPollInterval, "Trigger polling interval")
+	cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 1 {
+			return completeSessionArgs(cmd, nil, toComplete)
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return cmd
+}
+
+func newWorkflowsSignalCmd(action, short string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   action + " <session> [argument]",
+		Short: short,
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			arg := ""
+			if len(args) > 1 {
+				arg = args[1]
+			}
+			return sendWorkflowSignal(cmd.OutOrStdout(), args[0], workflowSignal{Action: action, Arg: arg})
+		},
+	}
+	cmd.ValidArgsFunction = completeSessionArgs
+	return cmd
+}
+
+func newWorkflowsStatusCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status <session>",
+		Short: "Show the workflow run attached to a session",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			st, err := loadWorkflowRun(args[0])
+			if err != nil {
+				return err
+			}
+			if st == nil {
+				return fmt.Errorf("no workflow has run in session %q", args[0])
+			}
+			w := cmd.OutOrStdout()
+			if jsonOutput {
+				enc := json.NewEncoder(w)
+				enc.SetIndent("", "  ")
+				return enc.Encode(st)
+			}
+			printWorkflowStatus(w, *st)
+			return nil
+		},
+	}
+	cmd.ValidArgsFunction = completeSessionArgs
+	return cmd
+}
+
+func parseWorkflowVars(pairs []string) (map[string]string, error) {
+	vars := make(map[string]string, len(pairs))
+	for _, p := range pairs {
+		k, v, ok := strings.Cut(p, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			return nil, fmt.Errorf("invalid --var %q (want key=value)", p)
+		}
+		vars[strings.TrimSpace(k)] = v
+	}
+	return vars, nil
+}
+
+func runWorkflow(w, errW io.Writer, name, sessionName string, vars map[string]string, poll time.Duration) error {
+	if err := tmux.EnsureInstalled(); err != nil {
+		return err
+	}
+	tmpl, err := workflow.NewLoader().Get(name)
+	if err != nil {
+		return fmt.Errorf("%w\n\nAvailable built-in templates: %s", err, strings.Join(workflow.BuiltinNames(), ", "))
+	}
+	if !tmux.SessionExists(sessionName) {
+		return fmt.Errorf("session '%s' not found (create it with: ntm spawn %s -t %s)", sessionName, sessionName, name)
+	}
+	if prev, err := loadWorkflowRun(sessionName); err == nil && prev != nil && !prev.Done() && workflowRunnerAlive(prev) {
+		return fmt.Errorf("workflow %s is already running in session '%s' (stop it with: ntm workflows abort %s)", prev.Workflow, sessionName, sessionName)
+	}
+	_ = clearWorkflowSignal(sessionName)
+
+	rt := newWorkflowRuntime(sessionName, cfg.GetProjectDir(sessionName), errW)
+	runner, err := workflow.NewRunner(tmpl, rt, workflow.RunConfig{
+		Session:      sessionName,
+		Vars:         vars,
+		PollInterval: poll,
+		OnUpdate: func(st workflow.RunStatus) {
+			if err := saveWorkflowRun(st); err != nil {
+				fmt.Fprintf(errW, "warning: saving workflow state: %v\n", err)
+			}
+			if !jsonOutput {
+				printWorkflowUpdate(w, st)
+			}
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go pollWorkflowSignals(ctx, sessionName, runner)
+
+	st, err := runner.Run(ctx)
+	if jsonOutput {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if encErr := enc.Encode(st); encErr != nil {
+			return encErr
+		}
+	}
+	return err
+}
+
+// pollWorkflowSignals forwards control requests written by other ntm
+// processes to the runner.
+func pollWorkflowSignals(ctx context.Context, sessionName string, runner *workflow.Runner) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		sig, err := takeWorkflowSignal(sessionName)
+		if err != nil || sig == nil {
+			continue
+		}
+		switch sig.Action {
+		case "advance":
+			runner.Advance(sig.Arg)
+		case "pause":
+			runner.Pause(firstNonEmpty(sig.Arg, "paused by operator"))
+		case "resume":
+			runner.Resume()
+		case "abort":
+			runner.Abort(firstNonEmpty(sig.Arg, "by operator"))
+		}
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func workflowStatePath(sessionName, file string) (string, error) {
+	dir, err := session.SessionDir(sessionName)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, file), nil
+}
+
+// workflowRunRecord is the persisted run state plus the runner's PID so
+// other processes can tell a live run from a stale file.
+type workflowRunRecord struct {
+	workflow.RunStatus
+	PID int `json:"pid"`
+}
+
+func saveWorkflowRun(st workflow.RunStatus) error {
+	path, err := workflowStatePath(st.Session, workflowRunFile)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(workflowRunRecord{RunStatus: st, PID: os.Getpid()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return util.AtomicWriteFile(path, data, 0600)
+}
+
+func loadWorkflowRun(sessionName string) (*workflowRunRecord, error) {
+	path, err := workflowStatePath(sessionName, workflowRunFile)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var rec workflowRunRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("parsing workflow state: %w", err)
+	}
+	return &rec, nil
+}
+
+func workflowRunnerAlive(rec *workflowRunRecord) bool {
+	if rec.PID <= 0 {
+		return false
+	}
+	proc, err := os.FindProcess(rec.PID)
+	if err != nil {
+		return false
+	}
+	return proc.Signal(syscall.Signal(0)) == nil
+}
+
+func sendWorkflowSignal(w io.Writer, sessionName string, sig workflowSignal) error {
+	rec, err := loadWorkflowRun(sessionName)
+	if err != nil {
+		return err
+	}
+	if rec == nil || rec.Done() || !workflowRunnerAlive(rec) {
+		return fmt.Errorf("no workflow is running in session %q", sessionName)
+	}
+	path, err := workflowStatePath(sessionName, workflowSignalFile)
+	if err != nil {
+		return err
+	}
+	sig.At = time.Now()
+	data, err := json.Marshal(sig)
+	if err != nil {
+		return err
+	}
+	if err := util.AtomicWriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("writing workflow signal: %w", err)
+	}
+	if jsonOutput {
+		return json.NewEncoder(w).Encode(sig)
+	}
+	fmt.Fprintf(w, "Sent %s to workflow %s in %s (stage: %s)\n", sig.Action, rec.Workflow, sessionName, rec.Stage)
+	return nil
+}
+
+func takeWorkflowSignal(sessionName string) (*workflowSignal, error) {
+	path, err := workflowStatePath(sessionName, workflowSignalFile)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	_ = os.Remove(path)
+	var sig workflowSignal
+	if err := json.Unmarshal(data, &sig); err != nil {
+		return nil, err
+	}
+	return &sig, nil
+}
+
+func clearWorkflowSignal(sessionName string) error {
+	path, err := workflowStatePath(sessionName, workflowSignalFile)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func printWorkflowUpdate(w io.Writer, st workflow.RunStatus) {
+	ts := time.Now().Format("15:04:05")
+	switch st.State {
+	case workflow.RunPaused:
+		fmt.Fprintf(w, "[%s] ⏸ %s paused in %s: %s\n", ts, st.Workflow, st.Stage, st.PauseReason)
+	case workflow.RunCompleted:
+		fmt.Fprintf(w, "[%s] ✓ %s completed after %d stage(s)\n", ts, st.Workflow, len(st.History))
+	case workflow.RunFailed, workflow.RunCancelled:
+		fmt.Fprintf(w, "[%s] ✗ %s %s: %s\n", ts, st.Workflow, st.State, st.Error)
+	default:
+		if n := len(st.History); n > 0 {
+			v := st.History[n-1]
+			if v.From == "" {
+				fmt.Fprintf(w, "[%s] ▶ %s started in %s at stage %s\n", ts, st.Workflow, st.Session, v.Stage)
+			} else {
+				fmt.Fprintf(w, "[%s] → %s: %s → %s (%s)\n", ts, st.Workflow, v.From, v.Stage, v.Trigger)
+			}
+		}
+	}
+}
+
+func printWorkflowStatus(w io.Writer, rec workflowRunRecord) {
+	state := string(rec.State)
+	if !rec.Done() && !workflowRunnerAlive(&rec) {
+		state += " (runner not alive)"
+	}
+	fmt.Fprintf(w, "Workflow: %s (run %s)\n", rec.Workflow, rec.RunID)
+	fmt.Fprintf(w, "Session:  %s\n", rec.Session)
+	fmt.Fprintf(w, "State:    %s\n", state)
+	fmt.Fprintf(w, "Stage:    %s (since %s)\n", rec.Stage, rec.StageEnteredAt.Format(time.RFC3339))
+	if rec.PauseReason != "" {
+		fmt.Fprintf(w, "Paused:   %s\n", rec.PauseReason)
+	}
+	if rec.Error != "" {
+		fmt.Fprintf(w, "Error:    %s\n", rec.Error)
+	}
+	fmt.Fprintln(w, "Agents:")
+	for _, a := range rec.Agents {
+		fmt.Fprintf(w, "  %-12s %s\n", a.Role, a.PaneID)
+	}
+	fmt.Fprintln(w, "History:")
+	for _, v := range rec.History {
+		fmt.Fprintf(w, "  %s  %-10s %s\n", v.At.Format("15:04:05"), v.Stage, v.Trigger)
+	}
+}
+
+// workflowRuntime backs workflow.Runtime with tmux, the status detector,
+// the file watcher and Agent Mail.
+type workflowRuntime struct {
+	session    string
+	projectDir string
+	errW       io.Writer
+	detector   *status.UnifiedDetector
+	mail       *agentmail.Client
+	notifier   *notify.Notifier
+}
+
+func newWorkflowRuntime(sessionName, projectDir string, errW io.Writer) *workflowRuntime {
+	rt := &workflowRuntime{
+		session:    sessionName,
+		projectDir: projectDir,
+		errW:       errW,
+		detector:   status.NewDetector(),
+		mail:       agentmail.NewClient(agentmail.WithProjectKey(projectDir)),
+	}
+	if cfg != nil && cfg.Notifications.Enabled {
+		rt.notifier = notify.New(cfg.Notifications)
+	}
+	return rt
+}
+
+// LaunchAgents binds every role to panes: panes already tagged for the role
+// first, then untagged agent panes of the role's type, then new agents.
+func (rt *workflowRuntime) LaunchAgents(ctx context.Context, t *workflow.WorkflowTemplate) ([]workflow.Agent, error) {
+	panes, err := tmux.GetPanesContext(ctx, rt.session)
+	if err != nil {
+		return nil, err
+	}
+	claimed := make(map[string]bool)
+	bound := make(map[string][]tmux.Pane)
+	for _, p := range panes {
+		for _, tag := range p.Tags {
+			if role, ok := strings.CutPrefix(tag, workflowRoleTagPrefix); ok {
+				bound[role] = append(bound[role], p)
+				claimed[p.ID] = true
+			}
+		}
+	}
+
+	var agents []workflow.Agent
+	for _, wa := range t.Agents {
+		want := wa.Count
+		if want == 0 {
+			want = 1
+		}
+		have := bound[wa.Role]
+		if len(have) > want {
+			have = have[:want]
+		}
+		agentType := tmux.AgentType(workflow.ProfileToAgentType(wa.Profile))
+		for _, p := range panes {
+			if len(have) >= want {
+				break
+			}
+			if claimed[p.ID] || p.Type != agentType {
+				continue
+			}
+			if err := tmux.AddPaneTags(p.ID, []string{workflowRoleTagPrefix + wa.Role}); err != nil {
+				return nil, fmt.Errorf("tagging pane %s for role %s: %w", p.ID, wa.Role, err)
+			}
+			claimed[p.ID] = true
+			have = append(have, p)
+		}
+		if missing := want - len(have); missing > 0 {
+			added, err := rt.addRoleAgents(ctx, wa, missing)
+			if err != nil {
+				return nil, fmt.Errorf("adding %s agents: %w", wa.Role, err)
+			}
+			for _, p := range added {
+				claimed[p.ID] = true
+			}
+			have = append(have, added...)
+		}
+		for _, p := range have {
+			agents = append(agents, workflow.Agent{PaneID: p.ID, Role: wa.Role, Profile: wa.Profile, Title: p.Title})
+		}
+	}
+	return agents, nil
+}
+
+func (rt *workflowRuntime) addRoleAgents(ctx context.Context, wa workflow.WorkflowAgent, n int) ([]tmux.Pane, error) {
+	before, err := tmux.GetPanesContext(ctx, rt.session)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]bool, len(before))
+	for _, p := range before {
+		existing[p.ID] = true
+	}
+
+	opts := AddOptions{Session: rt.session}
+	if registry, err := persona.LoadRegistry(rt.projectDir); err == nil {
+		if p, ok := registry.Get(wa.Profile); ok {
+			opts.PersonaMap = map[string]*persona.Persona{p.Name: p}
+			opts.Agents = AgentSpecs{{Type: AgentType(p.AgentTypeFlag()), Count: n, Model: p.Name}}
+		}
+	}
+	if len(opts.Agents) == 0 {
+		opts.Agents = AgentSpecs{{Type: AgentType(workflow.ProfileToAgentType(wa.Profile)), Count: n}}
+	}
+	if err := runAdd(opts); err != nil {
+		return nil, err
+	}
+
+	after, err := tmux.GetPanesContext(ctx, rt.session)
+	if err != nil {
+		return nil, err
+	}
+	var added []tmux.Pane
+	for _, p := range after {
+		if existing[p.ID] {
+			continue
+		}
+		if err := tmux.AddPaneTags(p.ID, []string{workflowRoleTagPrefix + wa.Role}); err != nil {
+			return nil, err
+		}
+		added = append(added, p)
+	}
+	if len(added) < n {
+		return nil, fmt.Errorf("expected %d new pane(s), found %d", n, len(added))
+	}
+	return added, nil
+}
+
+func (rt *workflowRuntime) WatchFiles(ctx context.Context, fn func(workflow.FileEvent)) error {
+	w, err := watcher.New(func(events []watcher.Event) {
+		for _, ev := range events {
+			if ev.IsDir {
+				continue
+			}
+			rel, err := filepath.Rel(rt.projectDir, ev.Path)
+			if err != nil {
+				rel = ev.Path
+			}
+			fn(workflow.FileEvent{Path: rel, Created: ev.Type&watcher.Create != 0})
+		}
+	},
+		watcher.WithRecursive(true),
+		watcher.WithEventFilter(watcher.Create|watcher.Write),
+		watcher.WithIgnorePaths([]string{".git", ".ntm", "node_modules", "vendor", "target", "dist", "build", "__pycache__", ".venv"}),
+	)
+	if err != nil {
+		return fmt.Errorf("creating watcher: %w", err)
+	}
+	if err := w.Add(rt.projectDir); err != nil {
+		w.Close()
+		return fmt.Errorf("watching %s: %w", rt.projectDir, err)
+	}
+	go func() {
+		<-ctx.Done()
+		w.Close()
+	}()
+	return nil
+}
+
+// SendPrompt delivers through the normal send path so redaction, hooks,
+// history and send pauses apply.
+func (rt *workflowRuntime) SendPrompt(ctx context.Context, a workflow.Agent, prompt string) error {
+	panes, err := tmux.GetPanesContext(ctx, rt.session)
+	if err != nil {
+		return err
+	}
+	for _, p := range panes {
+		if p.ID != a.PaneID {
+			continue
+		}
+		return runSendWithTargets(SendOptions{
+			Session:        rt.session,
+			Prompt:         prompt,
+			PromptSource:   "workflow",
+			PaneIndex:      -1,
+			Panes:          []int{p.Index},
+			PanesSpecified: true,
+		})
+	}
+	return fmt.Errorf("pane %s (%s) is gone", a.PaneID, a.Role)
+}
+
+func (rt *workflowRuntime) CaptureOutput(ctx context.Context, a workflow.Agent) (string, error) {
+	return tmux.CapturePaneOutputContext(ctx, a.PaneID, 200)
+}
+
+func (rt *workflowRuntime) AgentStatuses(ctx context.Context) (map[string]workflow.AgentStatus, error) {
+	statuses, err := rt.detector.DetectAllContext(ctx, rt.session)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]workflow.AgentStatus, len(statuses))
+	for _, st := range statuses {
+		state := workflow.AgentUnknown
+		switch st.State {
+		case status.StateIdle:
+			state = workflow.AgentIdle
+		case status.StateWorking:
+			state = workflow.AgentWorking
+		case status.StateError:
+			state = workflow.AgentError
+			if st.ErrorType == status.ErrorCrash {
+				state = workflow.AgentCrashed
+			}
+		}
+		out[st.PaneID] = workflow.AgentStatus{State: state, LastActive: st.LastActive}
+	}
+	return out, nil
+}
+
+func (rt *workflowRuntime) RunCommand(ctx context.Context, command string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, workflowCommandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = rt.projectDir
+	err := cmd.Run()
+	if err == nil {
+		return true, nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return false, nil
+	}
+	return false, err
+}
+
+func (rt *workflowRuntime) RestartAgent(ctx context.Context, a workflow.Agent) error {
+	return tmux.RespawnPaneContext(ctx, a.PaneID, true)
+}
+
+// Messages returns Agent Mail sent to the session's coordinator identity,
+// attributed to panes through the session's agent registry.
+func (rt *workflowRuntime) Messages(ctx context.Context, since time.Time) ([]workflow.Message, error) {
+	if !rt.mail.IsAvailable() {
+		return nil, nil
+	}
+	me, err := agentmail.LoadSessionAgent(rt.session, rt.projectDir)
+	if err != nil || me == nil {
+		return nil, err
+	}
+	inbox, err := rt.mail.FetchInbox(ctx, agentmail.FetchInboxOptions{
+		ProjectKey:    rt.projectDir,
+		AgentName:     me.AgentName,
+		SinceTS:       &since,
+		IncludeBodies: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	paneOf := make(map[string]string)
+	if reg, err := agentmail.LoadSessionAgentRegistry(rt.session, rt.projectDir); err == nil && reg != nil {
+		for paneID, name := range reg.PaneIDMap {
+			paneOf[name] = paneID
+		}
+	}
+	msgs := make([]workflow.Message, 0, len(inbox))
+	for _, m := range inbox {
+		msgs = append(msgs, workflow.Message{
+			PaneID:  paneOf[m.From],
+			From:    m.From,
+			Subject: m.Subject,
+			Body:    m.BodyMD,
+			At:      m.CreatedTS.Time,
+		})
+	}
+	sort.Slice(msgs, func(i, j int) bool { return msgs[i].At.Before(msgs[j].At) })
+	return msgs, nil
+}
+
+func (rt *workflowRuntime) Notify(ctx context.Context, subject, body string) {
+	fmt.Fprintf(rt.errW, "[%s] ⚠ %s: %s\n", time.Now().Format("15:04:05"), subject, body)
+	if rt.notifier != nil {
+		_ = rt.notifier.Notify(notify.Event{
+			Type:      notify.EventError,
+			Timestamp: time.Now(),
+			Session:   rt.session,
+			Message:   subject + ": " + body,
+		})
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -543,11 +542,10 @@ func getCurrentTmuxSession() (string, error) {
 
 	// Extract session name from tmux environment
 	// This is a simplified approach - could be enhanced
-	cmd := exec.Command(tmux.BinaryPath(), "display-message", "-p", "#S")
-	output, err := cmd.Output()
+	output, err := tmux.DefaultClient.Run("display-message", "-p", "#S")
 	if err != nil {
 		return "", fmt.Errorf("failed to get tmux session: %w", err)
 	}
 
-	return strings.TrimSpace(string(output)), nil
+	return strings.TrimSpace(output), nil
 }
@@ -1,14 +1,18 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/shahbajlive/ntm/internal/assignment"
 	"github.com/shahbajlive/ntm/internal/output"
+	"github.com/shahbajlive/ntm/internal/review"
 	"github.com/shahbajlive/ntm/internal/tmux"
 	"github.com/shahbajlive/ntm/internal/worktrees"
 )
@@ -89,7 +93,11 @@ func newWorktreesListCmd() *cobra.Command {
 }
 
 func newWorktreesMergeCmd() *cobra.Command {
-	var force bool
+	var (
+		force         bool
+		requireReview bool
+		skipReview    bool
+	)
 
 	cmd := &cobra.Command{
 		Use:   "merge <agent-name>",
@@ -97,7 +105,11 @@ func newWorktreesMergeCmd() *cobra.Command {
 		Long: `Merge changes from an agent's worktree branch back to the main branch.
 
 This will switch to the main branch and merge the agent's branch using
-a non-fast-forward merge to preserve the merge history.`,
+a non-fast-forward merge to preserve the merge history.
+
+With [review] gate_merge = true in .ntm/config.toml, or --require-review,
+the merge is refused unless the worktree's latest review (see 'ntm review')
+approved the branch's current head.`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			agentName := args[0]
@@ -128,6 +140,18 @@ a non-fast-forward merge to preserve the merge history.`,
 				return fmt.Errorf("worktree has errors: %s (use --force to proceed)", info.Error)
 			}
 
+			if !skipReview {
+				rc, _, err := reviewConfig(dir)
+				if err != nil {
+					return fmt.Errorf("merge blocked: %w (use --skip-review to merge anyway)", err)
+				}
+				if requireReview || rc.GateMerge {
+					if err := checkMergeReview(session, dir, agentName, info.BranchName); err != nil {
+						return fmt.Errorf("merge blocked by review: %w (use --skip-review to merge anyway)", err)
+					}
+				}
+			}
+
 			// Perform the merge
 			if err := manager.MergeBack(agentName); err != nil {
 				return fmt.Errorf("failed to merge worktree: %w", err)
@@ -139,9 +163,27 @@ a non-fast-forward merge to preserve the merge history.`,
 	}
 
 	cmd.Flags().BoolVar(&force, "force", false, "Force merge even if worktree has errors")
+	cmd.Flags().BoolVar(&requireReview, "require-review", false, "Refuse to merge without an approved review")
+	cmd.Flags().BoolVar(&skipReview, "skip-review", false, "Merge even if [review] gate_merge is set and the work is not approved")
 	return cmd
 }
 
+// checkMergeReview checks that agentName's branch was approved in review at
+// its current head.
+func checkMergeReview(session, dir, agentName, branch string) error {
+	store, err := assignment.LoadStore(session)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	head, err := review.BranchHead(ctx, dir, branch)
+	if err != nil {
+		return err
+	}
+	return review.MergeGate(store, agentName, head)
+}
+
 func newWorktreesCleanCmd() *cobra.Command {
 	var (
 		sessionName string
@@ -238,14 +238,11 @@ func (b wslBackend) name() string    { return "wsl-clipboard" }
 type tmuxBackend struct{}
 
 func (tmuxBackend) copy(text string) error {
-	cmd := exec.Command(tmux.BinaryPath(), "load-buffer", "-")
-	cmd.Stdin = strings.NewReader(text)
-	return cmd.Run()
+	return tmux.DefaultClient.RunSilent("set-buffer", "--", text)
 }
 
 func (tmuxBackend) paste() (string, error) {
-	out, err := exec.Command(tmux.BinaryPath(), "show-buffer").Output()
-	return string(out), err
+	return tmux.DefaultClient.Run("show-buffer")
 }
 
 func (tmuxBackend) available() bool { return true }
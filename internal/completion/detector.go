@@ -41,6 +41,12 @@ type CompletionEvent struct {
 	Output     string          `json:"output"`      // Last N lines (for debugging)
 	IsFailed   bool            `json:"is_failed"`   // True if failure detected
 	FailReason string          `json:"fail_reason"` // Reason for failure
+
+	// Verification is set when a verification gate ran for this completion.
+	Verification *VerificationResult `json:"verification,omitempty"`
+	// Reopened is true when verification failed and the agent was sent
+	// back to fix its work; the assignment stays active.
+	Reopened bool `json:"reopened,omitempty"`
 }
 
 // DetectionConfig configures the detector behavior
@@ -53,6 +59,7 @@ type DetectionConfig struct {
 	DedupWindow       time.Duration // Prevent duplicate events (default 5s)
 	GracefulDegrading bool          // Fall back to lesser methods (default true)
 	CaptureLines      int           // Lines to capture for pattern matching (default 50)
+	MaxVerifyFailures int           // Failed verifications before the assignment fails (default 3)
 }
 
 // DefaultConfig returns sensible default configuration
@@ -66,6 +73,7 @@ func DefaultConfig() DetectionConfig {
 		DedupWindow:       5 * time.Second,
 		GracefulDegrading: true,
 		CaptureLines:      50,
+		MaxVerifyFailures: DefaultMaxVerifyFailures,
 	}
 }
 
@@ -77,10 +85,29 @@ type CompletionDetector struct {
 	Patterns    []*regexp.Regexp // Completion patterns
 	FailPattern []*regexp.Regexp // Failure patterns
 
+	// Verifier, when set, gates completions: work is only marked complete
+	// once its verification commands pass.
+	Verifier *Verifier
+	// History records verification outcomes on the bead history (optional).
+	History BeadHistoryRecorder
+	// Notify sends a message to the agent working on an assignment. Defaults
+	// to pasting into the assignment's pane.
+	Notify func(a *assignment.Assignment, msg string) error
+
 	mu              sync.RWMutex
-	activityTracker map[int]*activityState // pane -> activity state
-	recentEvents    map[string]time.Time   // beadID -> last event time (for dedup)
-	brAvailable     *bool                  // nil = unknown, cached after first check
+	activityTracker map[int]*activityState  // pane -> activity state
+	recentEvents    map[string]time.Time    // beadID -> last event time (for dedup)
+	reopened        map[string]*reopenState // beadID -> state after failed verification
+	verifying       map[string]bool         // beadID -> verification running in the background
+	brAvailable     *bool                   // nil = unknown, cached after first check
+	wg              sync.WaitGroup          // Background verifications
+}
+
+// reopenState tracks an assignment sent back after failed verification so
+// the output that triggered the earlier completion is not matched again.
+type reopenState struct {
+	seen           map[string]bool // Lines present when the assignment was re-opened
+	skipBeadClosed bool            // Bead could not be re-opened in br
 }
 
 // activityState tracks output activity per pane
@@ -128,6 +155,8 @@ func NewWithConfig(session string, store *assignment.AssignmentStore, cfg Detect
 		Store:           store,
 		activityTracker: make(map[int]*activityState),
 		recentEvents:    make(map[string]time.Time),
+		reopened:        make(map[string]*reopenState),
+		verifying:       make(map[string]bool),
 	}
 
 	// Compile default patterns
@@ -176,6 +205,7 @@ func (d *CompletionDetector) Watch(ctx context.Context) <-chan CompletionEvent {
 
 	go func() {
 		defer close(events)
+		defer d.wg.Wait()
 
 		ticker := time.NewTicker(d.Config.PollInterval)
 		defer ticker.Stop()
@@ -205,6 +235,9 @@ func (d *CompletionDetector) checkAll(ctx context.Context, events chan<- Complet
 		case <-ctx.Done():
 			return
 		default:
+			if d.Verifying(a.BeadID) {
+				continue
+			}
 			if event := d.checkAssignment(ctx, a); event != nil {
 				// Check dedup window
 				d.mu.Lock()
@@ -216,19 +249,62 @@ func (d *CompletionDetector) checkAll(ctx context.Context, events chan<- Complet
 				d.recentEvents[a.BeadID] = time.Now()
 				d.mu.Unlock()
 
-				// Update assignment store
-				if event.IsFailed {
-					_ = d.Store.MarkFailed(a.BeadID, event.FailReason)
-				} else {
-					_ = d.Store.MarkCompleted(a.BeadID)
+				if !event.IsFailed && d.Verifier != nil {
+					d.startVerify(ctx, a, event, events)
+					continue
 				}
-
-				events <- *event
+				d.finish(ctx, a, event, events)
 			}
 		}
 	}
 }
 
+// Verifying reports whether a verification gate is still running for beadID.
+func (d *CompletionDetector) Verifying(beadID string) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.verifying[beadID]
+}
+
+// startVerify runs the verification gate in the background so slow test
+// suites do not hold up polling of the other assignments. The bead is left
+// out of detection until its verification has finished.
+func (d *CompletionDetector) startVerify(ctx context.Context, a *assignment.Assignment, event *CompletionEvent, events chan<- CompletionEvent) {
+	d.mu.Lock()
+	d.verifying[a.BeadID] = true
+	d.mu.Unlock()
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		defer func() {
+			d.mu.Lock()
+			delete(d.verifying, a.BeadID)
+			d.mu.Unlock()
+		}()
+		if d.verify(ctx, a, event) {
+			d.finish(ctx, a, event, events)
+		}
+	}()
+}
+
+// finish updates the assignment store for a detected event and emits it.
+func (d *CompletionDetector) finish(ctx context.Context, a *assignment.Assignment, event *CompletionEvent, events chan<- CompletionEvent) {
+	switch {
+	case event.IsFailed:
+		_ = d.Store.MarkFailed(a.BeadID, event.FailReason)
+	case event.Reopened:
+		// Stays active with the same agent
+	default:
+		_ = d.Store.MarkCompleted(a.BeadID)
+	}
+
+	select {
+	case events <- *event:
+	case <-ctx.Done():
+	}
+}
+
 // checkAssignment checks a single assignment for completion
 func (d *CompletionDetector) checkAssignment(ctx context.Context, a *assignment.Assignment) *CompletionEvent {
 	startTime := a.AssignedAt
@@ -267,7 +343,7 @@ func (d *CompletionDetector) checkAssignment(ctx context.Context, a *assignment.
 	}
 
 	// 2. Check bead status via br (most reliable)
-	if d.isBrAvailable() {
+	if d.isBrAvailable() && !d.skipBeadClosed(a.BeadID) {
 		if closed, err := d.checkBeadClosed(ctx, a.BeadID); err == nil && closed {
 			output, _ := tmux.CapturePaneOutput(target, d.Config.CaptureLines)
 			return &CompletionEvent{
@@ -289,8 +365,11 @@ func (d *CompletionDetector) checkAssignment(ctx context.Context, a *assignment.
 		return nil
 	}
 
+	// After a failed verification only output produced since then counts
+	fresh := d.freshOutput(a.BeadID, output)
+
 	// 4. Check for failure patterns
-	if reason := d.matchFailurePatterns(output); reason != "" {
+	if reason := d.matchFailurePatterns(fresh); reason != "" {
 		return &CompletionEvent{
 			Pane:       a.Pane,
 			AgentType:  a.AgentType,
@@ -305,7 +384,7 @@ func (d *CompletionDetector) checkAssignment(ctx context.Context, a *assignment.
 	}
 
 	// 5. Check for completion patterns
-	if d.matchCompletionPatterns(output) {
+	if d.matchCompletionPatterns(fresh) {
 		return &CompletionEvent{
 			Pane:      a.Pane,
 			AgentType: a.AgentType,
@@ -325,6 +404,121 @@ func (d *CompletionDetector) checkAssignment(ctx context.Context, a *assignment.
 	return nil
 }
 
+// verify runs the verification gate for a detected completion. On success
+// the evidence is recorded and the completion proceeds; on failure the
+// assignment is re-opened and the agent is sent the failing output, until
+// MaxVerifyFailures is reached and the event becomes a failure. It returns
+// false when ctx was cancelled mid-run: the killed commands are not a verdict,
+// so nothing is recorded and the bead is verified again on the next run.
+func (d *CompletionDetector) verify(ctx context.Context, a *assignment.Assignment, event *CompletionEvent) bool {
+	result := d.Verifier.Verify(ctx, a)
+	if ctx.Err() != nil {
+		return false
+	}
+	if result == nil {
+		return true
+	}
+	event.Verification = result
+
+	if d.History != nil {
+		_ = d.History.RecordBeadHistory(historyEntry(d.Session, a, result))
+	}
+	_ = d.Store.RecordVerification(a.BeadID, result.Runs...)
+
+	if result.Passed {
+		if a.Status == assignment.StatusAssigned {
+			_ = d.Store.MarkWorking(a.BeadID)
+		}
+		d.mu.Lock()
+		delete(d.reopened, a.BeadID)
+		d.mu.Unlock()
+		return true
+	}
+
+	maxFailures := d.Config.MaxVerifyFailures
+	if maxFailures <= 0 {
+		maxFailures = DefaultMaxVerifyFailures
+	}
+	if current := d.Store.Get(a.BeadID); current != nil && current.FailedVerifications() >= maxFailures {
+		event.IsFailed = true
+		event.FailReason = fmt.Sprintf("%s after %d attempts", result.Summary(), maxFailures)
+		return true
+	}
+
+	event.Reopened = true
+	d.reopen(ctx, a, event, result)
+	return true
+}
+
+// reopen sends the agent back to work after a failed verification.
+func (d *CompletionDetector) reopen(ctx context.Context, a *assignment.Assignment, event *CompletionEvent, result *VerificationResult) {
+	rs := &reopenState{seen: make(map[string]bool)}
+
+	// A closed bead would immediately complete again; re-open it in br
+	if event.Method == MethodBeadClosed {
+		cmd := exec.CommandContext(ctx, "br", "update", a.BeadID, "--status", "in_progress")
+		if err := cmd.Run(); err != nil {
+			rs.skipBeadClosed = true
+		}
+	}
+
+	msg := result.FailureMessage()
+	target := fmt.Sprintf("%s.%d", d.Session, a.Pane)
+	if output, err := tmux.CapturePaneOutput(target, d.Config.CaptureLines); err == nil {
+		addLines(rs.seen, output)
+	}
+	addLines(rs.seen, msg)
+
+	d.mu.Lock()
+	d.reopened[a.BeadID] = rs
+	delete(d.activityTracker, a.Pane) // Require a fresh burst before idle completion
+	d.mu.Unlock()
+
+	notify := d.Notify
+	if notify == nil {
+		notify = func(a *assignment.Assignment, msg string) error {
+			return tmux.PasteKeys(target, msg, true)
+		}
+	}
+	_ = notify(a, msg)
+}
+
+// skipBeadClosed reports whether br closure should be ignored for beadID.
+func (d *CompletionDetector) skipBeadClosed(beadID string) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	rs, ok := d.reopened[beadID]
+	return ok && rs.skipBeadClosed
+}
+
+// freshOutput drops lines that were already visible when beadID was
+// re-opened, so stale completion phrases and the pasted failure log do not
+// trigger detection again.
+func (d *CompletionDetector) freshOutput(beadID, output string) string {
+	d.mu.RLock()
+	rs, ok := d.reopened[beadID]
+	d.mu.RUnlock()
+	if !ok {
+		return output
+	}
+
+	var fresh []string
+	for _, line := range strings.Split(output, "\n") {
+		if !rs.seen[strings.TrimSpace(line)] {
+			fresh = append(fresh, line)
+		}
+	}
+	return strings.Join(fresh, "\n")
+}
+
+func addLines(set map[string]bool, text string) {
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			set[line] = true
+		}
+	}
+}
+
 // CheckNow performs an immediate check for a specific pane
 func (d *CompletionDetector) CheckNow(pane int) (*CompletionEvent, error) {
 	if d.Store == nil {
@@ -0,0 +1,247 @@
+package completion
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/assignment"
+	"github.com/shahbajlive/ntm/internal/state"
+	"github.com/shahbajlive/ntm/internal/status"
+)
+
+const (
+	// DefaultVerifyTimeout bounds a single verification command.
+	DefaultVerifyTimeout = 10 * time.Minute
+	// DefaultVerifyExcerptLines is how much command output is kept as evidence.
+	DefaultVerifyExcerptLines = 40
+	// DefaultMaxVerifyFailures is how many failed verifications re-open an
+	// assignment before it is marked failed.
+	DefaultMaxVerifyFailures = 3
+
+	// maxVerifyOutput caps the output buffered per command.
+	maxVerifyOutput = 1 << 20
+)
+
+// verifyWaitDelay bounds how long a timed-out command's children may keep
+// its output open after the shell is killed.
+var verifyWaitDelay = 2 * time.Second
+
+// Verifier runs verification commands (tests, lint, build) against an
+// agent's work before a detected completion is accepted.
+type Verifier struct {
+	Commands     []string            // Run for every bead
+	BeadCommands map[string][]string // Replace Commands for specific beads
+	Timeout      time.Duration       // Per command (default 10m)
+	ExcerptLines int                 // Output lines kept as evidence (default 40)
+
+	// WorkDir resolves the directory to run in for an assignment, typically
+	// the agent's worktree. Empty means the current directory.
+	WorkDir func(a *assignment.Assignment) string
+}
+
+// VerificationResult is the outcome of verifying one assignment.
+type VerificationResult struct {
+	BeadID string                    `json:"bead_id"`
+	Passed bool                      `json:"passed"`
+	Runs   []assignment.Verification `json:"runs"`
+}
+
+// Failed returns the failing run, if any.
+func (r *VerificationResult) Failed() *assignment.Verification {
+	if r == nil {
+		return nil
+	}
+	for i := range r.Runs {
+		if !r.Runs[i].Passed {
+			return &r.Runs[i]
+		}
+	}
+	return nil
+}
+
+// Summary describes the result in one line.
+func (r *VerificationResult) Summary() string {
+	if r == nil || len(r.Runs) == 0 {
+		return "no verification commands"
+	}
+	if f := r.Failed(); f != nil {
+		return fmt.Sprintf("verification failed: %s (exit %d, %s)",
+			f.Command, f.ExitCode, time.Duration(f.Duration)*time.Millisecond)
+	}
+	var total int64
+	cmds := make([]string, 0, len(r.Runs))
+	for _, run := range r.Runs {
+		total += run.Duration
+		cmds = append(cmds, run.Command)
+	}
+	return fmt.Sprintf("verification passed: %s (%s)",
+		strings.Join(cmds, "; "), time.Duration(total)*time.Millisecond)
+}
+
+// CommandsFor returns the commands that gate beadID.
+func (v *Verifier) CommandsFor(beadID string) []string {
+	if cmds, ok := v.BeadCommands[beadID]; ok && len(cmds) > 0 {
+		return cmds
+	}
+	return v.Commands
+}
+
+// Verify runs the commands for a's bead in order, stopping at the first
+// failure. It returns nil when no commands apply.
+func (v *Verifier) Verify(ctx context.Context, a *assignment.Assignment) *VerificationResult {
+	cmds := v.CommandsFor(a.BeadID)
+	if len(cmds) == 0 {
+		return nil
+	}
+
+	dir := ""
+	if v.WorkDir != nil {
+		dir = v.WorkDir(a)
+	}
+
+	result := &VerificationResult{BeadID: a.BeadID, Passed: true}
+	for _, command := range cmds {
+		run := v.run(ctx, command, dir)
+		result.Runs = append(result.Runs, run)
+		if !run.Passed {
+			result.Passed = false
+			break
+		}
+	}
+	return result
+}
+
+// run executes a single command and captures its evidence.
+func (v *Verifier) run(ctx context.Context, command, dir string) assignment.Verification {
+	timeout := v.Timeout
+	if timeout <= 0 {
+		timeout = DefaultVerifyTimeout
+	}
+	excerptLines := v.ExcerptLines
+	if excerptLines <= 0 {
+		excerptLines = DefaultVerifyExcerptLines
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	out := &limitedBuffer{max: maxVerifyOutput}
+	cmd := exec.CommandContext(runCtx, "sh", "-c", command)
+	cmd.Dir = dir
+	cmd.Stdout = out
+	cmd.Stderr = out
+	cmd.WaitDelay = verifyWaitDelay
+
+	started := time.Now()
+	err := cmd.Run()
+	elapsed := time.Since(started)
+
+	run := assignment.Verification{
+		Command:  command,
+		WorkDir:  dir,
+		Duration: elapsed.Milliseconds(),
+		RanAt:    started.UTC(),
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		run.Passed = true
+	case runCtx.Err() == context.DeadlineExceeded:
+		run.ExitCode = -1
+		out.WriteString(fmt.Sprintf("\n[ntm] verification timed out after %s\n", timeout))
+	case errors.As(err, &exitErr):
+		run.ExitCode = exitErr.ExitCode()
+	default:
+		run.ExitCode = -1
+		out.WriteString(fmt.Sprintf("\n[ntm] %v\n", err))
+	}
+
+	run.LogExcerpt = tailLines(status.StripANSI(out.String()), excerptLines)
+	return run
+}
+
+// FailureMessage is the prompt sent back to the agent when its work fails
+// verification.
+func (r *VerificationResult) FailureMessage() string {
+	f := r.Failed()
+	if f == nil {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Verification failed for %s: `%s` exited with code %d.\n", r.BeadID, f.Command, f.ExitCode)
+	b.WriteString("The work is not accepted yet. Fix the failures below, re-run the command, then report completion again.\n")
+	if f.LogExcerpt != "" {
+		b.WriteString("\n")
+		b.WriteString(f.LogExcerpt)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// BeadHistoryRecorder persists bead transitions; *state.Store implements it.
+type BeadHistoryRecorder interface {
+	RecordBeadHistory(entry *state.BeadHistoryEntry) error
+}
+
+// historyEntry builds the bead history record for a verification result.
+func historyEntry(session string, a *assignment.Assignment, r *VerificationResult) *state.BeadHistoryEntry {
+	to := state.BeadStatusCompleted
+	if !r.Passed {
+		to = state.BeadStatusWorking
+	}
+	reason := r.Summary()
+	if f := r.Failed(); f != nil && f.LogExcerpt != "" {
+		reason += "\n" + tailLines(f.LogExcerpt, 10)
+	}
+	return &state.BeadHistoryEntry{
+		SessionID:  session,
+		BeadID:     a.BeadID,
+		BeadTitle:  a.BeadTitle,
+		FromStatus: state.BeadStatus(a.Status),
+		ToStatus:   to,
+		AgentType:  a.AgentType,
+		AgentName:  a.AgentName,
+		Pane:       a.Pane,
+		Trigger:    "verification",
+		Reason:     reason,
+		RetryCount: a.FailedVerifications(),
+	}
+}
+
+// tailLines returns the last n non-trailing-blank lines of s.
+func tailLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n "), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// limitedBuffer keeps the last max bytes written to it.
+type limitedBuffer struct {
+	buf bytes.Buffer
+	max int
+}
+
+func (l *limitedBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	l.buf.Write(p)
+	if over := l.buf.Len() - l.max; over > 0 {
+		l.buf.Next(over)
+	}
+	return n, nil
+}
+
+func (l *limitedBuffer) WriteString(s string) {
+	_, _ = l.Write([]byte(s))
+}
+
+func (l *limitedBuffer) String() string {
+	return l.buf.String()
+}
@@ -0,0 +1,258 @@
+package completion
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/assignment"
+	"github.com/shahbajlive/ntm/internal/state"
+)
+
+func TestVerifierCommandsFor(t *testing.T) {
+	v := &Verifier{
+		Commands:     []string{"go test ./..."},
+		BeadCommands: map[string][]string{"bd-1": {"make integration"}},
+	}
+	if got := v.CommandsFor("bd-1"); len(got) != 1 || got[0] != "make integration" {
+		t.Errorf("CommandsFor(bd-1) = %v", got)
+	}
+	if got := v.CommandsFor("bd-2"); len(got) != 1 || got[0] != "go test ./..." {
+		t.Errorf("CommandsFor(bd-2) = %v", got)
+	}
+}
+
+func TestVerifierVerify(t *testing.T) {
+	dir := t.TempDir()
+	v := &Verifier{
+		Commands: []string{"pwd", "echo first; echo boom >&2; exit 3", "echo never"},
+		WorkDir:  func(*assignment.Assignment) string { return dir },
+	}
+
+	result := v.Verify(context.Background(), &assignment.Assignment{BeadID: "bd-1"})
+	if result == nil {
+		t.Fatal("Verify() returned nil")
+	}
+	if result.Passed {
+		t.Error("Passed = true, want false")
+	}
+	if len(result.Runs) != 2 {
+		t.Fatalf("runs = %d, want 2 (stop at first failure)", len(result.Runs))
+	}
+	if !result.Runs[0].Passed || !strings.Contains(result.Runs[0].LogExcerpt, dir) {
+		t.Errorf("first run = %+v, want pass in %s", result.Runs[0], dir)
+	}
+
+	failed := result.Failed()
+	if failed == nil || failed.ExitCode != 3 {
+		t.Fatalf("Failed() = %+v, want exit 3", failed)
+	}
+	if !strings.Contains(failed.LogExcerpt, "boom") {
+		t.Errorf("excerpt %q missing stderr", failed.LogExcerpt)
+	}
+	if msg := result.FailureMessage(); !strings.Contains(msg, "exited with code 3") || !strings.Contains(msg, "boom") {
+		t.Errorf("FailureMessage() = %q", msg)
+	}
+	if !strings.HasPrefix(result.Summary(), "verification failed") {
+		t.Errorf("Summary() = %q", result.Summary())
+	}
+}
+
+func TestVerifierNoCommands(t *testing.T) {
+	v := &Verifier{}
+	if r := v.Verify(context.Background(), &assignment.Assignment{BeadID: "bd-1"}); r != nil {
+		t.Errorf("Verify() = %+v, want nil", r)
+	}
+}
+
+func TestVerifierTimeout(t *testing.T) {
+	defer func(d time.Duration) { verifyWaitDelay = d }(verifyWaitDelay)
+	verifyWaitDelay = 100 * time.Millisecond
+
+	v := &Verifier{Commands: []string{"sleep 5"}, Timeout: 50 * time.Millisecond}
+	result := v.Verify(context.Background(), &assignment.Assignment{BeadID: "bd-1"})
+	if result.Passed || result.Runs[0].ExitCode != -1 {
+		t.Fatalf("run = %+v, want timeout failure", result.Runs[0])
+	}
+	if !strings.Contains(result.Runs[0].LogExcerpt, "timed out") {
+		t.Errorf("excerpt = %q", result.Runs[0].LogExcerpt)
+	}
+}
+
+func TestTailLinesAndLimitedBuffer(t *testing.T) {
+	if got := tailLines("a\nb\nc\n\n", 2); got != "b\nc" {
+		t.Errorf("tailLines() = %q", got)
+	}
+
+	b := &limitedBuffer{max: 4}
+	b.WriteString("abc")
+	b.WriteString("defg")
+	if b.String() != "defg" {
+		t.Errorf("limitedBuffer = %q, want last 4 bytes", b.String())
+	}
+}
+
+type fakeHistory struct {
+	entries []*state.BeadHistoryEntry
+}
+
+func (f *fakeHistory) RecordBeadHistory(e *state.BeadHistoryEntry) error {
+	f.entries = append(f.entries, e)
+	return nil
+}
+
+func TestDetectorVerifyReopensOnFailure(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	store := assignment.NewStore("verify-test")
+	a, err := store.Assign("bd-1", "Fix it", 2, "claude", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	history := &fakeHistory{}
+	var sent []string
+	d := New("verify-test", store)
+	d.Config.MaxVerifyFailures = 2
+	d.History = history
+	d.Verifier = &Verifier{Commands: []string{"echo FAIL: TestThing; exit 1"}}
+	d.Notify = func(_ *assignment.Assignment, msg string) error {
+		sent = append(sent, msg)
+		return nil
+	}
+
+	event := &CompletionEvent{BeadID: "bd-1", Pane: 2, Method: MethodPatternMatch}
+	d.verify(context.Background(), a, event)
+
+	if !event.Reopened || event.IsFailed {
+		t.Fatalf("event = %+v, want reopened", event)
+	}
+	if got := store.Get("bd-1"); got.Status != assignment.StatusWorking || len(got.Verifications) != 1 {
+		t.Errorf("assignment = %+v, want working with one verification", got)
+	}
+	if len(sent) != 1 || !strings.Contains(sent[0], "FAIL: TestThing") {
+		t.Errorf("notifications = %q", sent)
+	}
+	if len(history.entries) != 1 || history.entries[0].Trigger != "verification" {
+		t.Errorf("history = %+v", history.entries)
+	}
+
+	// Output visible at re-open time no longer counts for detection.
+	if fresh := d.freshOutput("bd-1", "FAIL: TestThing\nnew line"); fresh != "new line" {
+		t.Errorf("freshOutput() = %q", fresh)
+	}
+
+	// Second failure reaches the limit and fails the assignment.
+	event = &CompletionEvent{BeadID: "bd-1", Pane: 2, Method: MethodPatternMatch}
+	d.verify(context.Background(), store.Get("bd-1"), event)
+	if !event.IsFailed || event.Reopened {
+		t.Errorf("event = %+v, want failed after max attempts", event)
+	}
+}
+
+func TestDetectorVerifyRecordsPass(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	store := assignment.NewStore("verify-test")
+	a, err := store.Assign("bd-1", "Fix it", 2, "claude", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := New("verify-test", store)
+	d.Verifier = &Verifier{Commands: []string{"true"}}
+
+	event := &CompletionEvent{BeadID: "bd-1", Pane: 2, Method: MethodIdle}
+	d.verify(context.Background(), a, event)
+
+	if event.Reopened || event.IsFailed || event.Verification == nil || !event.Verification.Passed {
+		t.Fatalf("event = %+v, want passed verification", event)
+	}
+	// Passing moves an assigned bead to working so MarkCompleted is valid.
+	if err := store.MarkCompleted("bd-1"); err != nil {
+		t.Errorf("MarkCompleted() error: %v", err)
+	}
+	if got := store.Get("bd-1"); len(got.Verifications) != 1 || !got.Verifications[0].Passed {
+		t.Errorf("verifications = %+v", got.Verifications)
+	}
+}
+
+func TestDetectorVerifiesInBackground(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	store := assignment.NewStore("verify-test")
+	a, err := store.Assign("bd-1", "Fix it", 2, "claude", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := New("verify-test", store)
+	d.Verifier = &Verifier{Commands: []string{"sleep 0.2"}}
+
+	events := make(chan CompletionEvent, 1)
+	event := &CompletionEvent{BeadID: "bd-1", Pane: 2, Method: MethodPatternMatch}
+	d.startVerify(context.Background(), a, event, events)
+
+	if !d.Verifying("bd-1") {
+		t.Fatal("Verifying() = false while the gate is running")
+	}
+	select {
+	case <-events:
+		t.Fatal("event emitted before verification finished")
+	default:
+	}
+
+	select {
+	case got := <-events:
+		if got.Verification == nil || !got.Verification.Passed {
+			t.Errorf("event = %+v, want passed verification", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for verification")
+	}
+	d.wg.Wait()
+	if d.Verifying("bd-1") {
+		t.Error("Verifying() = true after the gate finished")
+	}
+	if got := store.Get("bd-1"); got.Status != assignment.StatusCompleted {
+		t.Errorf("status = %s, want completed", got.Status)
+	}
+}
+
+func TestDetectorVerifyCancelledIsNotAFailure(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	store := assignment.NewStore("verify-test")
+	a, err := store.Assign("bd-1", "Fix it", 2, "claude", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	history := &fakeHistory{}
+	var sent []string
+	d := New("verify-test", store)
+	d.History = history
+	d.Verifier = &Verifier{Commands: []string{"sleep 5"}}
+	d.Notify = func(_ *assignment.Assignment, msg string) error {
+		sent = append(sent, msg)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	event := &CompletionEvent{BeadID: "bd-1", Pane: 2, Method: MethodPatternMatch}
+	if d.verify(ctx, a, event) {
+		t.Fatal("verify() = true for a cancelled run")
+	}
+
+	if event.Reopened || event.IsFailed || event.Verification != nil {
+		t.Errorf("event = %+v, want untouched", event)
+	}
+	if got := store.Get("bd-1"); len(got.Verifications) != 0 {
+		t.Errorf("verifications = %+v, want none", got.Verifications)
+	}
+	if len(history.entries) != 0 || len(sent) != 0 {
+		t.Errorf("history = %+v, notifications = %q, want none", history.entries, sent)
+	}
+}
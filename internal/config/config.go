@@ -6,12 +6,14 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/BurntSushi/toml"
 
+	"github.com/shahbajlive/ntm/internal/cost"
 	"github.com/shahbajlive/ntm/internal/notify"
 	"github.com/shahbajlive/ntm/internal/redaction"
 	"github.com/shahbajlive/ntm/internal/util"
@@ -95,6 +97,9 @@ type Config struct {
 	Encryption         EncryptionConfig      `toml:"encryption"`       // Encryption at rest for artifacts
 	Send               SendConfig            `toml:"send"`             // Send command defaults
 	Prompts            PromptsConfig         `toml:"prompts"`          // Per-agent-type default prompts
+	Budgets            BudgetsConfig         `toml:"budgets"`          // Session spend budgets
+	Daemon             DaemonConfig          `toml:"daemon"`           // Background daemon hosting session loops
+	Permissions        PermissionsConfig     `toml:"permissions"`      // Agent tool-permission prompt handling
 
 	// Runtime-only fields (populated by project config merging)
 	ProjectDefaults map[string]int `toml:"-"`
@@ -334,6 +339,15 @@ type RotationDashboard struct {
 	ShowResetTimers   bool `toml:"show_reset_timers"`   // Show reset countdown
 }
 
+// RotationForecast configures usage-limit forecasting and pre-emptive rotation
+type RotationForecast struct {
+	Enabled         bool `toml:"enabled"`          // Forecast time-to-limit
+	Sample          bool `toml:"sample"`           // Type /usage into an idle agent each interval
+	IntervalMinutes int  `toml:"interval_minutes"` // Minutes between forecasts
+	WindowHours     int  `toml:"window_hours"`     // Usage history the forecast is fitted over
+	PreemptMinutes  int  `toml:"preempt_minutes"`  // Rotate idle agents when the limit is this close
+}
+
 // RotationConfig holds account rotation configuration
 type RotationConfig struct {
 	Enabled            bool               `toml:"enabled"`             // Master toggle
@@ -345,6 +359,7 @@ type RotationConfig struct {
 	Accounts           []RotationAccount  `toml:"accounts"`            // Configured accounts per provider
 	Thresholds         RotationThresholds `toml:"thresholds"`
 	Dashboard          RotationDashboard  `toml:"dashboard"`
+	Forecast           RotationForecast   `toml:"forecast"`
 }
 
 // GetAccountsForProvider returns all accounts for a given provider in priority order
@@ -386,6 +401,13 @@ func DefaultRotationConfig() RotationConfig {
 			ShowAccountStatus: true,
 			ShowResetTimers:   true,
 		},
+		Forecast: RotationForecast{
+			Enabled:         true,
+			Sample:          false,
+			IntervalMinutes: 10,
+			WindowHours:     5,
+			PreemptMinutes:  30,
+		},
 	}
 }
 
@@ -691,32 +713,35 @@ func DefaultCleanupConfig() CleanupConfig {
 }
 
 // FileReservationConfig holds configuration for automatic file reservation via Agent Mail.
-// When enabled, NTM monitors pane output for file edits and automatically reserves
-// those files in Agent Mail, preventing other agents from conflicting edits.
+// When enabled, NTM detects file edits (from filesystem events attributed to the
+// writing agent's process, or from pane output) and automatically reserves those
+// files in Agent Mail, preventing other agents from conflicting edits.
 type FileReservationConfig struct {
-	Enabled               bool `toml:"enabled"`                   // Master toggle for auto file reservation
-	AutoReserve           bool `toml:"auto_reserve"`              // Automatically reserve on edit detection
-	AutoReleaseIdleMin    int  `toml:"auto_release_idle_minutes"` // Release reservations after this idle time
-	NotifyOnConflict      bool `toml:"notify_on_conflict"`        // Show notification when conflict detected
-	ExtendOnActivity      bool `toml:"extend_on_activity"`        // Extend TTL while agent is actively editing
-	DefaultTTLMin         int  `toml:"default_ttl_minutes"`       // Default TTL for reservations
-	PollIntervalSec       int  `toml:"poll_interval_seconds"`     // How often to poll pane output for edits
-	CaptureLinesForDetect int  `toml:"capture_lines"`             // Lines of output to scan for file edits
-	Debug                 bool `toml:"debug"`                     // Enable debug logging
+	Enabled               bool   `toml:"enabled"`                   // Master toggle for auto file reservation
+	AutoReserve           bool   `toml:"auto_reserve"`              // Automatically reserve on edit detection
+	AutoReleaseIdleMin    int    `toml:"auto_release_idle_minutes"` // Release reservations after this idle time
+	NotifyOnConflict      bool   `toml:"notify_on_conflict"`        // Show notification when conflict detected
+	ExtendOnActivity      bool   `toml:"extend_on_activity"`        // Extend TTL while agent is actively editing
+	DefaultTTLMin         int    `toml:"default_ttl_minutes"`       // Default TTL for reservations
+	PollIntervalSec       int    `toml:"poll_interval_seconds"`     // How often to poll pane output for edits
+	CaptureLinesForDetect int    `toml:"capture_lines"`             // Lines of output to scan for file edits
+	DetectFrom            string `toml:"detect_from"`               // "filesystem" (fsnotify + process attribution), "output" (pane text) or "both"
+	Debug                 bool   `toml:"debug"`                     // Enable debug logging
 }
 
 // DefaultFileReservationConfig returns sensible defaults for file reservation.
 func DefaultFileReservationConfig() FileReservationConfig {
 	return FileReservationConfig{
-		Enabled:               true,  // Enabled by default (when Agent Mail is available)
-		AutoReserve:           true,  // Automatically reserve detected edits
-		AutoReleaseIdleMin:    10,    // Release after 10 minutes of inactivity
-		NotifyOnConflict:      true,  // Notify user on conflicts
-		ExtendOnActivity:      true,  // Extend TTL while actively editing
-		DefaultTTLMin:         15,    // 15-minute reservation TTL
-		PollIntervalSec:       10,    // Poll every 10 seconds
-		CaptureLinesForDetect: 100,   // Scan last 100 lines for file patterns
-		Debug:                 false, // Debug logging disabled by default
+		Enabled:               true,   // Enabled by default (when Agent Mail is available)
+		AutoReserve:           true,   // Automatically reserve detected edits
+		AutoReleaseIdleMin:    10,     // Release after 10 minutes of inactivity
+		NotifyOnConflict:      true,   // Notify user on conflicts
+		ExtendOnActivity:      true,   // Extend TTL while actively editing
+		DefaultTTLMin:         15,     // 15-minute reservation TTL
+		PollIntervalSec:       10,     // Poll every 10 seconds
+		CaptureLinesForDetect: 100,    // Scan last 100 lines for file patterns
+		DetectFrom:            "both", // Pane text plus filesystem events tied to a pane by open files or writes
+		Debug:                 false,  // Debug logging disabled by default
 	}
 }
 
@@ -734,6 +759,11 @@ func ValidateFileReservationConfig(cfg *FileReservationConfig) error {
 	if cfg.CaptureLinesForDetect < 10 {
 		return fmt.Errorf("capture_lines must be at least 10, got %d", cfg.CaptureLinesForDetect)
 	}
+	switch cfg.DetectFrom {
+	case "", "filesystem", "output", "both":
+	default:
+		return fmt.Errorf("detect_from must be filesystem, output or both, got %q", cfg.DetectFrom)
+	}
 	return nil
 }
 
@@ -901,11 +931,11 @@ type DCGConfig struct {
 
 // AssignConfig holds configuration for the ntm assign command
 type AssignConfig struct {
-	Strategy string `toml:"strategy"` // Default strategy: balanced, speed, quality, dependency, round-robin
+	Strategy string `toml:"strategy"` // Default strategy: balanced, speed, quality, dependency, round-robin, optimal
 }
 
 // ValidAssignStrategies are the recognized assignment strategies
-var ValidAssignStrategies = []string{"balanced", "speed", "quality", "dependency", "round-robin"}
+var ValidAssignStrategies = []string{"balanced", "speed", "quality", "dependency", "round-robin", "optimal"}
 
 // IsValidStrategy returns true if the strategy is recognized
 func IsValidStrategy(strategy string) bool {
@@ -1697,11 +1727,13 @@ func applySafetyProfileDefaults(cfg *Config) {
 // RedactionConfig holds configuration for secrets/PII redaction.
 // This controls how NTM handles sensitive content in commands, mail, and exports.
 type RedactionConfig struct {
-	// Mode controls redaction behavior: off, warn, redact, block
+	// Mode controls redaction behavior: off, warn, redact, block, pseudonymize
 	// - off: disable all scanning
 	// - warn: log findings but don't modify content
 	// - redact: replace sensitive content with placeholders
 	// - block: fail operations if secrets detected
+	// - pseudonymize: replace secrets with stable tokens kept in an encrypted
+	//   vault (requires [encryption]); falls back to redact without a key
 	Mode string `toml:"mode"`
 
 	// Allowlist contains regex patterns that should NOT be flagged.
@@ -1715,8 +1747,25 @@ type RedactionConfig struct {
 	// DisabledCategories lists secret categories to skip during scanning.
 	// Valid categories: OPENAI_KEY, ANTHROPIC_KEY, GITHUB_TOKEN, AWS_ACCESS_KEY,
 	// AWS_SECRET_KEY, JWT, GOOGLE_API_KEY, PRIVATE_KEY, DATABASE_URL, PASSWORD,
-	// GENERIC_API_KEY, GENERIC_SECRET, BEARER_TOKEN
+	// GENERIC_API_KEY, GENERIC_SECRET, BEARER_TOKEN, HIGH_ENTROPY
 	DisabledCategories []string `toml:"disabled_categories,omitempty"`
+
+	// Entropy enables detection of random-looking strings no pattern covers.
+	Entropy bool `toml:"entropy,omitempty"`
+
+	// EntropyThreshold is the minimum bits per character (default 4.0).
+	EntropyThreshold float64 `toml:"entropy_threshold,omitempty"`
+
+	// EntropyMinLength is the minimum candidate length (default 20).
+	EntropyMinLength int `toml:"entropy_min_length,omitempty"`
+
+	// VaultPath overrides the pseudonym vault location (default ~/.ntm/secrets.vault).
+	VaultPath string `toml:"vault_path,omitempty"`
+
+	// TrustedPanes lists pane titles (glob patterns allowed) that receive
+	// prompts with vault tokens rehydrated to the real secrets. Panes tagged
+	// "trusted" are trusted as well.
+	TrustedPanes []string `toml:"trusted_panes,omitempty"`
 }
 
 // DefaultRedactionConfig returns sensible redaction defaults.
@@ -1729,11 +1778,22 @@ func DefaultRedactionConfig() RedactionConfig {
 // ValidateRedactionConfig validates the redaction configuration.
 func ValidateRedactionConfig(cfg *RedactionConfig) error {
 	switch cfg.Mode {
-	case "", "off", "warn", "redact", "block":
-		return nil
+	case "", "off", "warn", "redact", "block", "pseudonymize":
 	default:
-		return fmt.Errorf("invalid redaction mode %q: must be off, warn, redact, or block", cfg.Mode)
+		return fmt.Errorf("invalid redaction mode %q: must be off, warn, redact, block, or pseudonymize", cfg.Mode)
+	}
+	if cfg.EntropyThreshold < 0 {
+		return fmt.Errorf("redaction.entropy_threshold must be >= 0")
+	}
+	if cfg.EntropyMinLength < 0 {
+		return fmt.Errorf("redaction.entropy_min_length must be >= 0")
 	}
+	for _, pattern := range cfg.TrustedPanes {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("redaction.trusted_panes: invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
 }
 
 // PrivacyConfig holds configuration for privacy mode.
@@ -1843,6 +1903,128 @@ type SendConfig struct {
 	BasePromptFile string `toml:"base_prompt_file"` // File whose contents are prepended to all prompts
 }
 
+// BudgetsConfig holds spend budgets, checked before prompts are sent by
+// send, pipeline steps and swarm spawns. Spend comes from the state
+// database's spend ledger.
+type BudgetsConfig struct {
+	Enabled    bool                         `toml:"enabled"`
+	HardAction string                       `toml:"hard_action"` // At a hard limit: "refuse" or "approve" (file an approval request)
+	Session    BudgetLimitConfig            `toml:"session"`     // Each session's total spend
+	Daily      BudgetLimitConfig            `toml:"daily"`       // All sessions' spend today
+	Agents     map[string]BudgetLimitConfig `toml:"agents"`      // Spend per agent type within a session, keyed cc, cod, gmi, ...
+}
+
+// BudgetLimitConfig sets soft (alert) and hard (enforced) thresholds in USD
+// and tokens. Zero disables a threshold.
+type BudgetLimitConfig struct {
+	SoftUSD    float64 `toml:"soft_usd"`
+	HardUSD    float64 `toml:"hard_usd"`
+	SoftTokens int64   `toml:"soft_tokens"`
+	HardTokens int64   `toml:"hard_tokens"`
+}
+
+// Budget hard actions.
+const (
+	BudgetRefuse  = "refuse"
+	BudgetApprove = "approve"
+)
+
+// DefaultBudgetsConfig returns budgets disabled, refusing at hard limits.
+func DefaultBudgetsConfig() BudgetsConfig {
+	return BudgetsConfig{HardAction: BudgetRefuse}
+}
+
+// ValidateBudgetsConfig validates budget thresholds and the hard action.
+func ValidateBudgetsConfig(cfg *BudgetsConfig) error {
+	if cfg == nil {
+		return nil
+	}
+	switch cfg.HardAction {
+	case "", BudgetRefuse, BudgetApprove:
+	default:
+		return fmt.Errorf("hard_action must be %q or %q, got %q", BudgetRefuse, BudgetApprove, cfg.HardAction)
+	}
+	check := func(name string, l BudgetLimitConfig) error {
+		if l.SoftUSD < 0 || l.HardUSD < 0 || l.SoftTokens < 0 || l.HardTokens < 0 {
+			return fmt.Errorf("%s: limits must be non-negative", name)
+		}
+		if l.SoftUSD > 0 && l.HardUSD > 0 && l.SoftUSD > l.HardUSD {
+			return fmt.Errorf("%s: soft_usd (%g) must be <= hard_usd (%g)", name, l.SoftUSD, l.HardUSD)
+		}
+		if l.SoftTokens > 0 && l.HardTokens > 0 && l.SoftTokens > l.HardTokens {
+			return fmt.Errorf("%s: soft_tokens (%d) must be <= hard_tokens (%d)", name, l.SoftTokens, l.HardTokens)
+		}
+		return nil
+	}
+	if err := check("session", cfg.Session); err != nil {
+		return err
+	}
+	if err := check("daily", cfg.Daily); err != nil {
+		return err
+	}
+	for agentType, l := range cfg.Agents {
+		if err := check("agents."+agentType, l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Budgets returns the configured budgets, or nil when budgets are disabled.
+func (c BudgetsConfig) Budgets() []cost.Budget {
+	if !c.Enabled {
+		return nil
+	}
+	var out []cost.Budget
+	add := func(scope cost.BudgetScope, agentType string, l BudgetLimitConfig) {
+		if l.SoftUSD > 0 || l.HardUSD > 0 {
+			out = append(out, cost.Budget{Scope: scope, AgentType: agentType, Unit: cost.UnitUSD, Soft: l.SoftUSD, Hard: l.HardUSD})
+		}
+		if l.SoftTokens > 0 || l.HardTokens > 0 {
+			out = append(out, cost.Budget{Scope: scope, AgentType: agentType, Unit: cost.UnitTokens,
+				Soft: float64(l.SoftTokens), Hard: float64(l.HardTokens)})
+		}
+	}
+	add(cost.ScopeSession, "", c.Session)
+	add(cost.ScopeDaily, "", c.Daily)
+	agentTypes := make([]string, 0, len(c.Agents))
+	for agentType := range c.Agents {
+		agentTypes = append(agentTypes, agentType)
+	}
+	sort.Strings(agentTypes)
+	for _, agentType := range agentTypes {
+		add(cost.ScopeAgent, agentType, c.Agents[agentType])
+	}
+	return out
+}
+
+// DaemonConfig controls ntmd, the per-user background daemon that hosts the
+// monitors and watchers of every session.
+type DaemonConfig struct {
+	Enabled         bool `toml:"enabled"`           // Spawn hands sessions to ntmd instead of a per-session monitor
+	Coordinator     bool `toml:"coordinator"`       // Also run the session coordinator for hosted sessions
+	ScanIntervalSec int  `toml:"scan_interval_sec"` // How often ntmd looks for sessions to host
+}
+
+// DefaultDaemonConfig returns the daemon enabled, scanning every 10 seconds.
+func DefaultDaemonConfig() DaemonConfig {
+	return DaemonConfig{Enabled: true, ScanIntervalSec: 10}
+}
+
+// PermissionsConfig controls how agents' tool-permission prompts are handled
+// by the session monitor.
+type PermissionsConfig struct {
+	Enabled         bool `toml:"enabled"`           // Watch panes for permission prompts
+	AutoAnswer      bool `toml:"auto_answer"`       // Answer prompts matching explicit allowed/blocked policy rules
+	PollIntervalSec int  `toml:"poll_interval_sec"` // How often panes are checked
+}
+
+// DefaultPermissionsConfig returns prompt watching on and policy answers
+// off, polling every 3 seconds.
+func DefaultPermissionsConfig() PermissionsConfig {
+	return PermissionsConfig{Enabled: true, AutoAnswer: false, PollIntervalSec: 3}
+}
+
 // PromptsConfig holds per-agent-type default prompts (bd-2ywo).
 type PromptsConfig struct {
 	CCDefault      string `toml:"cc_default"`       // Default prompt for Claude agents
@@ -1892,11 +2074,16 @@ func (c *RedactionConfig) ToRedactionLibConfig() redaction.Config {
 		mode = redaction.ModeRedact
 	case "block":
 		mode = redaction.ModeBlock
+	case "pseudonymize":
+		mode = redaction.ModePseudonymize
 	}
 
 	libCfg := redaction.Config{
-		Mode:      mode,
-		Allowlist: c.Allowlist,
+		Mode:             mode,
+		Allowlist:        c.Allowlist,
+		Entropy:          c.Entropy,
+		EntropyThreshold: c.EntropyThreshold,
+		EntropyMinLength: c.EntropyMinLength,
 	}
 
 	// Convert extra patterns
@@ -1973,6 +2160,9 @@ func Default() *Config {
 		Privacy:         DefaultPrivacyConfig(),
 		Encryption:      DefaultEncryptionConfig(),
 		SpawnPacing:     DefaultSpawnPacingConfig(),
+		Budgets:         DefaultBudgetsConfig(),
+		Daemon:          DefaultDaemonConfig(),
+		Permissions:     DefaultPermissionsConfig(),
 	}
 
 	// Apply safety profile defaults (standard/safe/paranoid).
@@ -2784,6 +2974,16 @@ func Print(cfg *Config, w io.Writer) error {
 	fmt.Fprintf(w, "show_reset_timers = %t     # Show reset countdown\n", cfg.Rotation.Dashboard.ShowResetTimers)
 	fmt.Fprintln(w)
 
+	fmt.Fprintln(w, "[rotation.forecast]")
+	fmt.Fprintln(w, "# Forecast time-to-limit per account; with rotation enabled, idle agents")
+	fmt.Fprintln(w, "# move to an account with headroom before the limit is hit")
+	fmt.Fprintf(w, "enabled = %t            # Forecast from sends and rate limits\n", cfg.Rotation.Forecast.Enabled)
+	fmt.Fprintf(w, "sample = %t            # Also type /usage into an idle agent\n", cfg.Rotation.Forecast.Sample)
+	fmt.Fprintf(w, "interval_minutes = %d     # Minutes between forecasts\n", cfg.Rotation.Forecast.IntervalMinutes)
+	fmt.Fprintf(w, "window_hours = %d          # Usage history to fit over\n", cfg.Rotation.Forecast.WindowHours)
+	fmt.Fprintf(w, "preempt_minutes = %d      # Rotate when the limit is this close\n", cfg.Rotation.Forecast.PreemptMinutes)
+	fmt.Fprintln(w)
+
 	// Write health monitoring configuration
 	fmt.Fprintln(w, "[health]")
 	fmt.Fprintln(w, "# Agent health monitoring configuration")
@@ -3111,6 +3311,148 @@ func upsertTOMLKey(contents, key, value string) string {
 	return result
 }
 
+// SetEncryptionKeyring adds keys to [encryption.keyring] in the config file
+// at path (DefaultPath when empty), removes the IDs in remove, and sets
+// encryption.active_key_id. Key IDs must be bare TOML keys.
+func SetEncryptionKeyring(path, activeID string, add map[string]string, remove []string) error {
+	for id := range add {
+		if !isBareTOMLKey(id) {
+			return fmt.Errorf("invalid key id %q: use letters, digits, '-' or '_'", id)
+		}
+	}
+	if !isBareTOMLKey(activeID) {
+		return fmt.Errorf("invalid key id %q: use letters, digits, '-' or '_'", activeID)
+	}
+
+	configPath := path
+	if configPath == "" {
+		configPath = DefaultPath()
+	}
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		return fmt.Errorf("creating config directory: %w", err)
+	}
+
+	var fileContents string
+	if data, err := os.ReadFile(configPath); err == nil {
+		fileContents = string(data)
+	}
+	if _, ok := findTOMLSectionKey(strings.Split(fileContents, "\n"), "encryption", "keyring"); ok {
+		return fmt.Errorf("encryption.keyring is an inline table in %s; convert it to an [encryption.keyring] section first", configPath)
+	}
+
+	ids := make([]string, 0, len(add))
+	for id := range add {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		fileContents = upsertTOMLSectionKey(fileContents, "encryption.keyring", id, add[id])
+	}
+	for _, id := range remove {
+		fileContents = removeTOMLSectionKey(fileContents, "encryption.keyring", id)
+	}
+	fileContents = upsertTOMLSectionKey(fileContents, "encryption", "active_key_id", activeID)
+
+	// The keyring holds key material; keep the file private.
+	if err := util.AtomicWriteFile(configPath, []byte(fileContents), 0600); err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	return nil
+}
+
+func isBareTOMLKey(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z') && !(r >= 'A' && r <= 'Z') && !(r >= '0' && r <= '9') && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// tomlSectionRange returns the line range [start, end) of a [section] body,
+// or ok=false when the section header is absent.
+func tomlSectionRange(lines []string, section string) (start, end int, ok bool) {
+	header := "[" + section + "]"
+	start = -1
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if start < 0 {
+			if trimmed == header {
+				start = i + 1
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "[") {
+			return start, i, true
+		}
+	}
+	if start < 0 {
+		return 0, 0, false
+	}
+	return start, len(lines), true
+}
+
+// findTOMLSectionKey returns the line index of key inside [section].
+func findTOMLSectionKey(lines []string, section, key string) (int, bool) {
+	start, end, ok := tomlSectionRange(lines, section)
+	if !ok {
+		return 0, false
+	}
+	for i := start; i < end; i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, key+" ") || strings.HasPrefix(trimmed, key+"=") {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// upsertTOMLSectionKey updates or inserts a string key inside [section],
+// appending the section when it does not exist.
+func upsertTOMLSectionKey(contents, section, key, value string) string {
+	lines := strings.Split(contents, "\n")
+	newLine := fmt.Sprintf("%s = %q", key, value)
+
+	if i, ok := findTOMLSectionKey(lines, section, key); ok {
+		lines[i] = newLine
+	} else if start, end, ok := tomlSectionRange(lines, section); ok {
+		// Insert after the last non-blank line of the section.
+		insertIdx := start
+		for i := start; i < end; i++ {
+			if strings.TrimSpace(lines[i]) != "" {
+				insertIdx = i + 1
+			}
+		}
+		lines = append(lines[:insertIdx], append([]string{newLine}, lines[insertIdx:]...)...)
+	} else {
+		for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+			lines = lines[:len(lines)-1]
+		}
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, "["+section+"]", newLine)
+	}
+
+	result := strings.Join(lines, "\n")
+	if !strings.HasSuffix(result, "\n") {
+		result += "\n"
+	}
+	return result
+}
+
+// removeTOMLSectionKey deletes key from [section] if present.
+func removeTOMLSectionKey(contents, section, key string) string {
+	lines := strings.Split(contents, "\n")
+	if i, ok := findTOMLSectionKey(lines, section, key); ok {
+		lines = append(lines[:i], lines[i+1:]...)
+	}
+	return strings.Join(lines, "\n")
+}
+
 // GetValue retrieves a configuration value by its dotted path (e.g., "alerts.enabled")
 func GetValue(cfg *Config, path string) (interface{}, error) {
 	if cfg == nil {
@@ -3541,6 +3883,11 @@ func Validate(cfg *Config) []error {
 		errs = append(errs, fmt.Errorf("ensemble: %w", err))
 	}
 
+	// Validate spend budgets
+	if err := ValidateBudgetsConfig(&cfg.Budgets); err != nil {
+		errs = append(errs, fmt.Errorf("budgets: %w", err))
+	}
+
 	// Validate health monitoring
 	if err := ValidateHealthConfig(&cfg.Health); err != nil {
 		errs = append(errs, fmt.Errorf("health: %w", err))
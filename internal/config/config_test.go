@@ -8,6 +8,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/shahbajlive/ntm/internal/cost"
 )
 
 func TestDefault(t *testing.T) {
@@ -2203,7 +2205,7 @@ func TestIsValidStrategy(t *testing.T) {
 
 func TestValidAssignStrategies(t *testing.T) {
 	// Verify all expected strategies are present
-	expected := []string{"balanced", "speed", "quality", "dependency", "round-robin"}
+	expected := []string{"balanced", "speed", "quality", "dependency", "round-robin", "optimal"}
 	if len(ValidAssignStrategies) != len(expected) {
 		t.Errorf("Expected %d strategies, got %d", len(expected), len(ValidAssignStrategies))
 	}
@@ -2575,6 +2577,77 @@ func TestValidateProxyConfig(t *testing.T) {
 	}
 }
 
+func TestValidateBudgetsConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     BudgetsConfig
+		wantErr bool
+		errMsg  string
+	}{
+		{name: "valid default config", cfg: DefaultBudgetsConfig()},
+		{
+			name: "approve with limits",
+			cfg: BudgetsConfig{Enabled: true, HardAction: BudgetApprove,
+				Session: BudgetLimitConfig{SoftUSD: 4, HardUSD: 5}},
+		},
+		{
+			name:    "unknown hard_action",
+			cfg:     BudgetsConfig{HardAction: "ignore"},
+			wantErr: true,
+			errMsg:  "hard_action",
+		},
+		{
+			name:    "negative limit",
+			cfg:     BudgetsConfig{Daily: BudgetLimitConfig{HardUSD: -1}},
+			wantErr: true,
+			errMsg:  "daily",
+		},
+		{
+			name:    "soft above hard",
+			cfg:     BudgetsConfig{Agents: map[string]BudgetLimitConfig{"cc": {SoftTokens: 10, HardTokens: 5}}},
+			wantErr: true,
+			errMsg:  "agents.cc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateBudgetsConfig(&tt.cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				if tt.errMsg != "" && !strings.Contains(err.Error(), tt.errMsg) {
+					t.Fatalf("expected error containing %q, got %v", tt.errMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected nil error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestBudgetsConfigBudgets(t *testing.T) {
+	t.Parallel()
+	cfg := BudgetsConfig{
+		Session: BudgetLimitConfig{HardUSD: 5, HardTokens: 1000},
+		Agents:  map[string]BudgetLimitConfig{"cod": {SoftUSD: 1}, "cc": {HardUSD: 2}},
+	}
+	if got := cfg.Budgets(); got != nil {
+		t.Fatalf("disabled Budgets() = %+v, want nil", got)
+	}
+	cfg.Enabled = true
+	got := cfg.Budgets()
+	if len(got) != 4 {
+		t.Fatalf("Budgets() = %+v, want 4", got)
+	}
+	if got[1].Unit != cost.UnitTokens || got[2].Name() != "agent:cc" || got[3].Name() != "agent:cod" {
+		t.Errorf("Budgets() = %+v", got)
+	}
+}
+
 // =============================================================================
 // ValidateXFConfig — nil branch (bd-4b4zf)
 // =============================================================================
@@ -3229,6 +3302,7 @@ func TestValidateRedactionConfig(t *testing.T) {
 		{"warn mode", "warn", false},
 		{"redact mode", "redact", false},
 		{"block mode", "block", false},
+		{"pseudonymize mode", "pseudonymize", false},
 		{"invalid mode", "invalid", true},
 		{"uppercase mode is invalid", "WARN", true},
 	}
@@ -3244,7 +3318,26 @@ func TestValidateRedactionConfig(t *testing.T) {
 	}
 }
 
+func TestValidateRedactionConfig_TrustedPanes(t *testing.T) {
+	cfg := &RedactionConfig{Mode: "pseudonymize", TrustedPanes: []string{"proj__cc_*"}}
+	if err := ValidateRedactionConfig(cfg); err != nil {
+		t.Fatalf("valid trusted_panes rejected: %v", err)
+	}
+	cfg.TrustedPanes = []string{"proj__cc_["}
+	if err := ValidateRedactionConfig(cfg); err == nil {
+		t.Error("malformed trusted_panes pattern should be rejected")
+	}
+}
+
 func TestRedactionConfig_ToRedactionLibConfig(t *testing.T) {
+	t.Run("pseudonymize with entropy", func(t *testing.T) {
+		cfg := &RedactionConfig{Mode: "pseudonymize", Entropy: true, EntropyThreshold: 4.5}
+		libCfg := cfg.ToRedactionLibConfig()
+		if string(libCfg.Mode) != "pseudonymize" || !libCfg.Entropy || libCfg.EntropyThreshold != 4.5 {
+			t.Errorf("unexpected lib config: %+v", libCfg)
+		}
+	})
+
 	t.Run("basic conversion", func(t *testing.T) {
 		cfg := &RedactionConfig{
 			Mode:      "redact",
@@ -3612,6 +3705,15 @@ func TestValidateFileReservationConfig(t *testing.T) {
 			name:    "capture lines too low",
 			cfg:     FileReservationConfig{AutoReleaseIdleMin: 0, DefaultTTLMin: 5, PollIntervalSec: 5, CaptureLinesForDetect: 5},
 			wantErr: true,
+		},		{
+			name:    "detect from output",
+			cfg:     FileReservationConfig{DefaultTTLMin: 5, PollIntervalSec: 5, CaptureLinesForDetect: 20, DetectFrom: "output"},
+			wantErr: false,
+		},
+		{
+			name:    "unknown detect source",
+			cfg:     FileReservationConfig{DefaultTTLMin: 5, PollIntervalSec: 5, CaptureLinesForDetect: 20, DetectFrom: "inotify"},
+			wantErr: true,
 		},
 	}
 
@@ -4481,3 +4583,49 @@ func TestConfigReset_NoExistingFile(t *testing.T) {
 		t.Fatalf("config file should exist after reset: %v", err)
 	}
 }
+
+func TestSetEncryptionKeyring(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmpDir, "xdg"))
+
+	// An explicit path (--config) is written, not the default config.
+	configPath := filepath.Join(tmpDir, "custom.toml")
+	initial := "projects_base = \"/tmp/p\"\n\n[encryption]\nenabled = true\nkey_source = \"env\"\n\n[alerts]\nenabled = true\n"
+	if err := os.WriteFile(configPath, []byte(initial), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SetEncryptionKeyring(configPath, "k2", map[string]string{"legacy": "aa", "k2": "bb"}, nil); err != nil {
+		t.Fatalf("SetEncryptionKeyring: %v", err)
+	}
+	if err := SetEncryptionKeyring(configPath, "k3", map[string]string{"k3": "cc"}, []string{"legacy"}); err != nil {
+		t.Fatalf("SetEncryptionKeyring: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !cfg.Encryption.Enabled || cfg.Encryption.ActiveKeyID != "k3" || !cfg.Alerts.Enabled {
+		t.Errorf("encryption = %+v", cfg.Encryption)
+	}
+	want := map[string]string{"k2": "bb", "k3": "cc"}
+	if len(cfg.Encryption.Keyring) != len(want) {
+		t.Fatalf("keyring = %v, want %v", cfg.Encryption.Keyring, want)
+	}
+	for id, v := range want {
+		if cfg.Encryption.Keyring[id] != v {
+			t.Errorf("keyring[%s] = %q, want %q", id, cfg.Encryption.Keyring[id], v)
+		}
+	}
+	if info, _ := os.Stat(configPath); info.Mode().Perm() != 0600 {
+		t.Errorf("config mode = %v, want 0600", info.Mode().Perm())
+	}
+	if _, err := os.Stat(DefaultPath()); !os.IsNotExist(err) {
+		t.Errorf("default config written: %v", err)
+	}
+
+	if err := SetEncryptionKeyring(configPath, "bad id", nil, nil); err == nil {
+		t.Error("expected error for invalid key id")
+	}
+}
@@ -21,6 +21,8 @@ type ProjectConfig struct {
 	Templates    ProjectTemplates    `toml:"templates"`
 	Agents       AgentConfig         `toml:"agents"`
 	Integrations ProjectIntegrations `toml:"integrations"`
+	Verify       ProjectVerify       `toml:"verify"`
+	Review       ProjectReview       `toml:"review"`
 }
 
 // ProjectMeta holds basic project metadata.
@@ -37,6 +39,58 @@ type ProjectIntegrations struct {
 	CM        bool `toml:"cm"`
 }
 
+// ProjectVerify declares commands that must pass before assigned work is
+// accepted as complete. Commands run through sh -c in the agent's working
+// directory, in order, stopping at the first failure.
+//
+//	[verify]
+//	commands = ["go build ./...", "go test ./..."]
+//	timeout = "10m"
+//
+//	[verify.beads]
+//	bd-42 = ["make integration"]  # replaces commands for this bead
+type ProjectVerify struct {
+	Commands     []string            `toml:"commands"`
+	Timeout      string              `toml:"timeout"`       // Per command, e.g. "10m"
+	MaxFailures  int                 `toml:"max_failures"`  // Failed runs before the assignment is failed
+	ExcerptLines int                 `toml:"excerpt_lines"` // Output lines kept as evidence
+	Beads        map[string][]string `toml:"beads"`         // Per-bead overrides
+}
+
+// Enabled reports whether any verification command is configured.
+func (v ProjectVerify) Enabled() bool {
+	if len(v.Commands) > 0 {
+		return true
+	}
+	for _, cmds := range v.Beads {
+		if len(cmds) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ProjectReview configures cross-agent code review. With enabled set,
+// completed assignments are reviewed automatically by an agent of another
+// type; gate_merge makes 'ntm worktrees merge' require an approved review of
+// the worktree's current head.
+//
+//	[review]
+//	enabled = true
+//	reviewer_types = ["cod", "gmi"]  # preferred reviewer agents, in order
+//	spawn = true                     # add a reviewer when none is idle
+//	max_rounds = 3
+//	timeout = "30m"
+//	gate_merge = true
+type ProjectReview struct {
+	Enabled       bool     `toml:"enabled"`
+	ReviewerTypes []string `toml:"reviewer_types"`
+	Spawn         bool     `toml:"spawn"`
+	MaxRounds     int      `toml:"max_rounds"` // Rounds per assignment before giving up
+	Timeout       string   `toml:"timeout"`    // Time a reviewer has to answer, e.g. "30m"
+	GateMerge     bool     `toml:"gate_merge"`
+}
+
 // ProjectDefaults holds default settings for the project
 type ProjectDefaults struct {
 	Agents map[string]int `toml:"agents"` // e.g., { cc = 2, cod = 1 }
@@ -30,6 +30,11 @@ type ScannerConfig struct {
 
 	// Notifications for scan events
 	Notifications ScannerNotifications `toml:"notifications" yaml:"notifications"`
+
+	// SARIF lists reports from other tools (golangci-lint, semgrep, gosec)
+	// that the pre-commit hook also gates on. Relative paths are resolved
+	// against the repository root.
+	SARIF []string `toml:"sarif" yaml:"sarif"`
 }
 
 // ScannerDefaults holds default scan settings
@@ -352,6 +352,7 @@ func isRecognizedWebhookEvent(ev string) bool {
 		"agent.idle",
 		"agent.busy",
 		"agent.rate_limit",
+		"agent.permission",
 		"agent.completed",
 		"rotation.needed",
 		"session.created",
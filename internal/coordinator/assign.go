@@ -29,6 +29,9 @@ const (
 	// StrategyRoundRobin distributes tasks evenly in deterministic order.
 	// All assignments get score 1.0. First agents get +1 if counts are uneven.
 	StrategyRoundRobin AssignmentStrategy = "round-robin"
+	// StrategyOptimal maximizes the total score of the round with min-cost flow
+	// matching, honoring agent capacity, file reservations and dependencies.
+	StrategyOptimal AssignmentStrategy = "optimal"
 )
 
 // Assignment represents an agent-task pairing with reasoning.
@@ -825,6 +828,7 @@ func matchFocusPattern(pattern, file string) bool {
 //   - "speed": assign tasks to any available agent quickly
 //   - "quality": assign tasks to the highest-scoring agent
 //   - "dependency": prioritize blockers and critical path items
+//   - "optimal": maximize total score (see AssignTasksOptimal)
 //
 // The function handles:
 //   - More beads than agents (some beads unassigned)
@@ -840,6 +844,15 @@ func AssignTasks(
 		return nil
 	}
 
+	// Optimal needs reservations and dependency data, not just the pair matrix
+	if strategy == StrategyOptimal {
+		result := AssignTasksOptimal(beads, agents, reservations, DefaultOptimalOptions())
+		if len(result.Assignments) == 0 {
+			return nil
+		}
+		return result.Assignments
+	}
+
 	// Filter to available agents (idle with sufficient context)
 	availableAgents := filterAvailableAgents(agents)
 	if len(availableAgents) == 0 {
@@ -916,6 +929,12 @@ func buildStrategyConfig(strategy AssignmentStrategy) ScoreConfig {
 		// Dependency: heavily weight critical path and blockers
 		base.PreferCriticalPath = true
 		base.PenalizeFileOverlap = true
+
+	case StrategyOptimal:
+		// Optimal: score like quality; conflicts are hard constraints in the solver
+		base.UseAgentProfiles = true
+		base.PreferCriticalPath = true
+		base.PenalizeFileOverlap = true
 	}
 
 	return base
@@ -1216,6 +1235,8 @@ func buildAssignmentReason(p scoredPair, strategy AssignmentStrategy) string {
 		reasons = append(reasons, "even workload distribution")
 	case StrategySpeed:
 		reasons = append(reasons, "fastest available agent")
+	case StrategyOptimal:
+		reasons = append(reasons, "globally optimal matching")
 	}
 
 	// Add breakdown insights
@@ -1285,6 +1306,8 @@ func ParseStrategy(s string) AssignmentStrategy {
 		return StrategyDependency
 	case "round-robin", "roundrobin", "rr":
 		return StrategyRoundRobin
+	case "optimal", "optimum", "matching":
+		return StrategyOptimal
 	default:
 		return StrategyBalanced // Default to balanced
 	}
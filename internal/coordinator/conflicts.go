@@ -285,6 +285,11 @@ func sanitizeForID(s string) string {
 	return s
 }
 
+// MatchesPattern reports whether path falls under a reservation pattern.
+func MatchesPattern(path, pattern string) bool {
+	return matchesPattern(path, pattern)
+}
+
 // matchesPattern checks if a path matches a glob pattern.
 // Supports:
 // - Exact match: "src/main.go"
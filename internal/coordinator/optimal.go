@@ -0,0 +1,750 @@
+package coordinator
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/shahbajlive/ntm/internal/bv"
+)
+
+// OptimalOptions configures the optimal (min-cost flow) assignment strategy.
+type OptimalOptions struct {
+	// MaxPerAgent is how many beads an agent may hold at once, including
+	// assignments it already has (AgentState.Assignments). Default: 1.
+	MaxPerAgent int
+
+	// AgentCapacity overrides MaxPerAgent for specific pane IDs.
+	AgentCapacity map[string]int
+
+	// Score overrides the scoring used to build the pair matrix. Nil uses the
+	// optimal strategy's defaults.
+	Score *ScoreConfig
+}
+
+// DefaultOptimalOptions returns one-bead-per-agent matching over the default scores.
+func DefaultOptimalOptions() OptimalOptions {
+	return OptimalOptions{MaxPerAgent: 1}
+}
+
+// OptimalResult is the outcome of a globally optimal assignment, together
+// with the greedy result over the same score matrix for comparison.
+type OptimalResult struct {
+	Assignments      []Assignment        `json:"assignments"`
+	TotalScore       float64             `json:"total_score"`
+	Greedy           []Assignment        `json:"greedy"`
+	GreedyTotalScore float64             `json:"greedy_total_score"`
+	Explanations     []PairExplanation   `json:"explanations"`
+	Excluded         []ExcludedBead      `json:"excluded,omitempty"`
+	Improvement      OptimalImprovement  `json:"improvement"`
+	Capacity         map[string]int      `json:"capacity"`
+	ConflictGroups   map[string][]string `json:"conflict_groups,omitempty"` // File -> beads mentioning it
+}
+
+// OptimalImprovement summarizes how the optimal result differs from greedy.
+type OptimalImprovement struct {
+	ScoreDelta       float64  `json:"score_delta"`
+	ExtraAssigned    int      `json:"extra_assigned"`
+	OnlyOptimal      []string `json:"only_optimal,omitempty"` // Beads assigned by optimal but left out by greedy
+	OnlyGreedy       []string `json:"only_greedy,omitempty"`  // Beads assigned by greedy but not by optimal
+	ReassignedAgents int      `json:"reassigned_agents"`      // Beads that went to a different agent than under greedy
+}
+
+// PairExplanation records why a bead went to an agent.
+type PairExplanation struct {
+	BeadID       string  `json:"bead_id"`
+	AgentPaneID  string  `json:"agent_pane_id"`
+	Score        float64 `json:"score"`
+	BestAgent    string  `json:"best_agent"` // Highest-scoring eligible agent for this bead
+	BestScore    float64 `json:"best_score"`
+	GreedyAgent  string  `json:"greedy_agent,omitempty"` // Agent greedy would have picked ("" = unassigned)
+	GreedyReason string  `json:"greedy_reason"`
+	Why          string  `json:"why"`
+}
+
+// ExcludedBead is a bead the solver could not consider this round.
+type ExcludedBead struct {
+	BeadID string `json:"bead_id"`
+	Reason string `json:"reason"`
+}
+
+type pairKey struct {
+	agent string
+	bead  string
+}
+
+// optimalSolver holds the working state used to explain a solution.
+type optimalSolver struct {
+	result         *OptimalResult
+	scores         map[pairKey]float64 // Full score matrix
+	eligible       map[pairKey]bool    // Pairs that survived the hard constraints
+	greedyByBead   map[string]string   // Bead -> agent under greedy
+	optimalByAgent map[string][]string // Agent -> beads under optimal
+	fileOwner      map[string]string   // Bead -> agent holding its file reservations
+	excluded       map[string]string   // Bead -> reason it was not considered
+}
+
+// AssignTasksOptimal solves bead-to-agent assignment as a min-cost flow over
+// the scoreAllPairs matrix. Unlike the greedy strategies it maximizes the
+// total score of the round, so an agent that is the best match for two beads
+// takes the one where it matters most.
+//
+// Constraints:
+//   - each agent takes at most its capacity (OptimalOptions.MaxPerAgent minus
+//     active assignments)
+//   - a bead mentioning files reserved by one agent may only go to that agent;
+//     files reserved by an unavailable agent exclude the bead
+//   - beads mentioning the same file conflict; no two of them are assigned
+//     in one round so two agents never edit the same file
+//   - a bead blocked by another bead in the batch waits for a later round
+func AssignTasksOptimal(
+	beads []*bv.TriageRecommendation,
+	agents []*AgentState,
+	reservations map[string][]string,
+	opts OptimalOptions,
+) *OptimalResult {
+	result := &OptimalResult{Capacity: make(map[string]int)}
+	if len(beads) == 0 || len(agents) == 0 {
+		return result
+	}
+
+	available := filterAvailableAgents(agents)
+	if len(available) == 0 {
+		return result
+	}
+
+	config := buildStrategyConfig(StrategyOptimal)
+	if opts.Score != nil {
+		config = *opts.Score
+	}
+	pairs := scoreAllPairs(available, beads, config, reservations)
+
+	s := &optimalSolver{
+		result:         result,
+		scores:         make(map[pairKey]float64, len(pairs)),
+		eligible:       make(map[pairKey]bool, len(pairs)),
+		greedyByBead:   make(map[string]string),
+		optimalByAgent: make(map[string][]string),
+	}
+	for _, a := range available {
+		result.Capacity[a.PaneID] = agentCapacity(a, opts)
+	}
+	for _, p := range pairs {
+		s.scores[pairKey{p.agent.PaneID, p.bead.ID}] = p.score
+	}
+
+	// Greedy baseline over the same matrix (selectGreedy sorts in place)
+	greedy := selectGreedy(append([]scoredPair(nil), pairs...), len(available), len(beads))
+	result.Greedy = buildAssignments(greedy, StrategySpeed)
+	for _, p := range greedy {
+		result.GreedyTotalScore += p.score
+		s.greedyByBead[p.bead.ID] = p.agent.PaneID
+	}
+
+	// Hard constraints
+	s.excluded = dependencyExclusions(beads)
+	s.fileOwner, result.ConflictGroups = fileConstraints(beads, available, reservations, s.excluded)
+
+	var eligible []scoredPair
+	for _, p := range pairs {
+		if _, ok := s.excluded[p.bead.ID]; ok {
+			continue
+		}
+		if owner, ok := s.fileOwner[p.bead.ID]; ok && owner != p.agent.PaneID {
+			continue
+		}
+		if result.Capacity[p.agent.PaneID] <= 0 {
+			continue
+		}
+		eligible = append(eligible, p)
+		s.eligible[pairKey{p.agent.PaneID, p.bead.ID}] = true
+	}
+
+	selected := solveWithConflicts(eligible, result.Capacity, result.ConflictGroups)
+	result.Assignments = buildAssignments(selected, StrategyOptimal)
+	for _, p := range selected {
+		result.TotalScore += p.score
+		s.optimalByAgent[p.agent.PaneID] = append(s.optimalByAgent[p.agent.PaneID], p.bead.ID)
+	}
+
+	s.explain(selected)
+	s.collectExcluded(beads, selected)
+	s.compare(selected, greedy)
+
+	// Fold the explanation into each Assignment's reason
+	for i := range result.Assignments {
+		result.Assignments[i].Reason = result.Explanations[i].Why
+	}
+	return result
+}
+
+// agentCapacity returns how many more beads an agent can take this round.
+func agentCapacity(a *AgentState, opts OptimalOptions) int {
+	capacity := opts.MaxPerAgent
+	if capacity <= 0 {
+		capacity = 1
+	}
+	if override, ok := opts.AgentCapacity[a.PaneID]; ok {
+		capacity = override
+	}
+	if a.Assignments > 0 {
+		capacity -= a.Assignments
+	}
+	if capacity < 0 {
+		capacity = 0
+	}
+	return capacity
+}
+
+// dependencyExclusions returns beads that must wait for another bead in the
+// batch, keyed by bead ID with a human-readable reason.
+func dependencyExclusions(beads []*bv.TriageRecommendation) map[string]string {
+	inBatch := make(map[string]bool, len(beads))
+	for _, b := range beads {
+		inBatch[b.ID] = true
+	}
+
+	blockers := make(map[string][]string)
+	for _, b := range beads {
+		for _, dep := range b.BlockedBy {
+			if inBatch[dep] && dep != b.ID {
+				blockers[b.ID] = append(blockers[b.ID], dep)
+			}
+		}
+		for _, unblocked := range b.UnblocksIDs {
+			if inBatch[unblocked] && unblocked != b.ID {
+				blockers[unblocked] = append(blockers[unblocked], b.ID)
+			}
+		}
+	}
+
+	excluded := make(map[string]string)
+	for _, b := range beads {
+		if b.Status == "blocked" {
+			excluded[b.ID] = "bead is blocked"
+			continue
+		}
+		if deps := uniqueSorted(blockers[b.ID]); len(deps) > 0 {
+			excluded[b.ID] = "waits for " + strings.Join(deps, ", ")
+		}
+	}
+	return excluded
+}
+
+// fileConstraints derives per-bead owning agents from file reservations and
+// groups beads that mention the same files. Beads touching files reserved by
+// agents outside the available set are added to excluded.
+func fileConstraints(
+	beads []*bv.TriageRecommendation,
+	available []*AgentState,
+	reservations map[string][]string,
+	excluded map[string]string,
+) (map[string]string, map[string][]string) {
+	isAvailable := make(map[string]bool, len(available))
+	allReservations := make(map[string][]string)
+	for _, a := range available {
+		isAvailable[a.PaneID] = true
+		if len(reservations[a.PaneID]) == 0 && len(a.Reservations) > 0 {
+			allReservations[a.PaneID] = a.Reservations
+		}
+	}
+	for pane, patterns := range reservations {
+		allReservations[pane] = patterns
+	}
+	// Visit panes in order so exclusion reasons do not depend on map order.
+	panes := make([]string, 0, len(allReservations))
+	for pane := range allReservations {
+		panes = append(panes, pane)
+	}
+	sort.Strings(panes)
+
+	owners := make(map[string]string)
+	fileBeads := make(map[string][]string)
+
+	for _, b := range beads {
+		if _, ok := excluded[b.ID]; ok {
+			continue
+		}
+		files := ExtractMentionedFiles(b.Title, "")
+		for _, f := range files {
+			fileBeads[f] = append(fileBeads[f], b.ID)
+		}
+
+		for _, pane := range panes {
+			if !reservationCovers(allReservations[pane], files) {
+				continue
+			}
+			if !isAvailable[pane] {
+				excluded[b.ID] = fmt.Sprintf("files reserved by busy agent %s", pane)
+				break
+			}
+			if prev, ok := owners[b.ID]; ok && prev != pane {
+				excluded[b.ID] = fmt.Sprintf("files reserved by both %s and %s", prev, pane)
+				delete(owners, b.ID)
+				break
+			}
+			owners[b.ID] = pane
+		}
+	}
+	for id := range excluded {
+		delete(owners, id)
+	}
+
+	groups := make(map[string][]string)
+	for f, ids := range fileBeads {
+		ids = uniqueSorted(ids)
+		if len(ids) > 1 {
+			groups[f] = ids
+		}
+	}
+	return owners, groups
+}
+
+func reservationCovers(patterns, files []string) bool {
+	for _, pattern := range patterns {
+		for _, f := range files {
+			if pattern == f || matchFocusPattern(pattern, f) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// beadGroups maps each bead that shares exactly one file with other beads to
+// that file, so the flow's group node lets one of them through. Beads sharing
+// several files stay ungrouped; solveWithConflicts settles their conflicts.
+// Grouping only on a single file keeps the constraint pairwise: A~B and B~C
+// never keep A and C apart.
+func beadGroups(groups map[string][]string) map[string]string {
+	filesOf := make(map[string][]string)
+	for f, ids := range groups {
+		for _, id := range ids {
+			filesOf[id] = append(filesOf[id], f)
+		}
+	}
+	out := make(map[string]string, len(filesOf))
+	for id, files := range filesOf {
+		if len(files) == 1 {
+			out[id] = "file:" + files[0]
+		}
+	}
+	return out
+}
+
+// conflictingPair returns two selected beads that mention the same file, in
+// a deterministic order, or ok == false when the selection is conflict-free.
+func conflictingPair(selected []scoredPair, groups map[string][]string) (a, b string, ok bool) {
+	chosen := make(map[string]bool, len(selected))
+	for _, p := range selected {
+		chosen[p.bead.ID] = true
+	}
+	files := make([]string, 0, len(groups))
+	for f := range groups {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	for _, f := range files {
+		var taken []string
+		for _, id := range groups[f] {
+			if chosen[id] {
+				taken = append(taken, id)
+			}
+		}
+		if len(taken) > 1 {
+			return taken[0], taken[1], true
+		}
+	}
+	return "", "", false
+}
+
+// maxConflictBranches caps how many times solveWithConflicts branches.
+// Each branch doubles the remaining work, so past the cap conflicts are
+// settled greedily instead.
+const maxConflictBranches = 64
+
+// solveWithConflicts runs solveMinCostFlow and, while the selection still
+// holds two beads that share a file, branches on dropping one or the other
+// and keeps the higher-scoring outcome. Only beads sharing several files can
+// collide after the flow, so branching stays rare; once maxConflictBranches
+// is spent, the lower-scoring bead of each remaining conflict is dropped.
+func solveWithConflicts(pairs []scoredPair, capacity map[string]int, groups map[string][]string) []scoredPair {
+	budget := maxConflictBranches
+	return branchConflicts(pairs, capacity, groups, &budget)
+}
+
+func branchConflicts(pairs []scoredPair, capacity map[string]int, groups map[string][]string, budget *int) []scoredPair {
+	selected := solveMinCostFlow(pairs, capacity, beadGroups(groups))
+	a, b, ok := conflictingPair(selected, groups)
+	if !ok {
+		return selected
+	}
+	if *budget <= 0 {
+		return dropConflictsGreedily(pairs, selected, capacity, groups)
+	}
+	*budget--
+	withoutA := branchConflicts(withoutBead(pairs, a), capacity, groups, budget)
+	withoutB := branchConflicts(withoutBead(pairs, b), capacity, groups, budget)
+	if totalScore(withoutB) > totalScore(withoutA) {
+		return withoutB
+	}
+	return withoutA
+}
+
+// dropConflictsGreedily settles the conflicts in selected one at a time,
+// dropping whichever bead of the first conflicting pair scored lower and
+// solving again, until the selection is conflict-free.
+func dropConflictsGreedily(pairs, selected []scoredPair, capacity map[string]int, groups map[string][]string) []scoredPair {
+	for {
+		a, b, ok := conflictingPair(selected, groups)
+		if !ok {
+			return selected
+		}
+		scores := make(map[string]float64, len(selected))
+		for _, p := range selected {
+			scores[p.bead.ID] = p.score
+		}
+		drop := a
+		if scores[b] < scores[a] {
+			drop = b
+		}
+		pairs = withoutBead(pairs, drop)
+		selected = solveMinCostFlow(pairs, capacity, beadGroups(groups))
+	}
+}
+
+// withoutBead returns the pairs not involving bead id.
+func withoutBead(pairs []scoredPair, id string) []scoredPair {
+	var kept []scoredPair
+	for _, p := range pairs {
+		if p.bead.ID != id {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+func totalScore(pairs []scoredPair) float64 {
+	total := 0.0
+	for _, p := range pairs {
+		total += p.score
+	}
+	return total
+}
+
+// flowEdge is an edge in the residual graph.
+type flowEdge struct {
+	to, rev int
+	cap     int
+	cost    int64
+}
+
+// scoreScale converts float scores to integer costs for exact comparisons.
+const scoreScale = 1e6
+
+// solveMinCostFlow finds the maximum-weight set of pairs subject to agent
+// capacities and one bead per conflict group, using successive shortest
+// paths (Bellman-Ford, since pair costs are negative scores). Augmentation
+// stops when no path lowers total cost, which yields the maximum total score
+// rather than the maximum number of assignments.
+//
+// Graph: source → agent (capacity) → bead (1) → group (1) → sink.
+func solveMinCostFlow(pairs []scoredPair, capacity map[string]int, groupOf map[string]string) []scoredPair {
+	if len(pairs) == 0 {
+		return nil
+	}
+
+	// Deterministic node numbering
+	var agentIDs, beadIDs, groupIDs []string
+	seenAgent, seenBead, seenGroup := map[string]bool{}, map[string]bool{}, map[string]bool{}
+	for _, p := range pairs {
+		if !seenAgent[p.agent.PaneID] {
+			seenAgent[p.agent.PaneID] = true
+			agentIDs = append(agentIDs, p.agent.PaneID)
+		}
+		if !seenBead[p.bead.ID] {
+			seenBead[p.bead.ID] = true
+			beadIDs = append(beadIDs, p.bead.ID)
+		}
+		g := groupOf[p.bead.ID]
+		if g == "" {
+			g = p.bead.ID
+		}
+		if !seenGroup[g] {
+			seenGroup[g] = true
+			groupIDs = append(groupIDs, g)
+		}
+	}
+	sort.Strings(agentIDs)
+	sort.Strings(beadIDs)
+	sort.Strings(groupIDs)
+
+	const source = 0
+	agentNode := make(map[string]int)
+	beadNode := make(map[string]int)
+	groupNode := make(map[string]int)
+	n := 1
+	for _, id := range agentIDs {
+		agentNode[id] = n
+		n++
+	}
+	for _, id := range beadIDs {
+		beadNode[id] = n
+		n++
+	}
+	for _, id := range groupIDs {
+		groupNode[id] = n
+		n++
+	}
+	sink := n
+	n++
+
+	graph := make([][]flowEdge, n)
+	addEdge := func(from, to, cap int, cost int64) int {
+		graph[from] = append(graph[from], flowEdge{to: to, rev: len(graph[to]), cap: cap, cost: cost})
+		graph[to] = append(graph[to], flowEdge{to: from, rev: len(graph[from]) - 1, cap: 0, cost: -cost})
+		return len(graph[from]) - 1
+	}
+
+	for _, id := range agentIDs {
+		addEdge(source, agentNode[id], capacity[id], 0)
+	}
+	type pairEdge struct {
+		from, idx int
+		pair      scoredPair
+	}
+	var pairEdges []pairEdge
+	for _, p := range pairs {
+		from := agentNode[p.agent.PaneID]
+		cost := -int64(math.Round(p.score * scoreScale))
+		idx := addEdge(from, beadNode[p.bead.ID], 1, cost)
+		pairEdges = append(pairEdges, pairEdge{from: from, idx: idx, pair: p})
+	}
+	for _, id := range beadIDs {
+		g := groupOf[id]
+		if g == "" {
+			g = id
+		}
+		addEdge(beadNode[id], groupNode[g], 1, 0)
+	}
+	for _, id := range groupIDs {
+		addEdge(groupNode[id], sink, 1, 0)
+	}
+
+	const inf = math.MaxInt64 / 4
+	for {
+		dist := make([]int64, n)
+		inQueue := make([]bool, n)
+		prevNode := make([]int, n)
+		prevEdge := make([]int, n)
+		for i := range dist {
+			dist[i] = inf
+			prevNode[i] = -1
+		}
+		dist[source] = 0
+		queue := []int{source}
+		inQueue[source] = true
+		for len(queue) > 0 {
+			u := queue[0]
+			queue = queue[1:]
+			inQueue[u] = false
+			for i, e := range graph[u] {
+				if e.cap <= 0 || dist[u]+e.cost >= dist[e.to] {
+					continue
+				}
+				dist[e.to] = dist[u] + e.cost
+				prevNode[e.to] = u
+				prevEdge[e.to] = i
+				if !inQueue[e.to] {
+					inQueue[e.to] = true
+					queue = append(queue, e.to)
+				}
+			}
+		}
+
+		if dist[sink] >= 0 {
+			break // No augmenting path improves the total score
+		}
+		for v := sink; v != source; v = prevNode[v] {
+			u := prevNode[v]
+			e := &graph[u][prevEdge[v]]
+			e.cap--
+			graph[v][e.rev].cap++
+		}
+	}
+
+	var selected []scoredPair
+	for _, pe := range pairEdges {
+		if graph[pe.from][pe.idx].cap == 0 {
+			selected = append(selected, pe.pair)
+		}
+	}
+	sort.SliceStable(selected, func(i, j int) bool {
+		if selected[i].score != selected[j].score {
+			return selected[i].score > selected[j].score
+		}
+		return selected[i].bead.ID < selected[j].bead.ID
+	})
+	return selected
+}
+
+// explain builds a PairExplanation for every selected pair.
+func (s *optimalSolver) explain(selected []scoredPair) {
+	for _, p := range selected {
+		bestAgent, bestScore := s.bestEligibleAgent(p.bead.ID)
+		ex := PairExplanation{
+			BeadID:      p.bead.ID,
+			AgentPaneID: p.agent.PaneID,
+			Score:       p.score,
+			BestAgent:   bestAgent,
+			BestScore:   bestScore,
+		}
+
+		var why []string
+		if bestAgent == p.agent.PaneID {
+			why = append(why, fmt.Sprintf("highest score for this bead (%.2f)", p.score))
+		} else {
+			other := s.optimalByAgent[bestAgent]
+			if len(other) > 0 {
+				why = append(why, fmt.Sprintf("%s scores higher (%.2f vs %.2f) but is worth more on %s",
+					bestAgent, bestScore, p.score, strings.Join(other, ", ")))
+			} else {
+				why = append(why, fmt.Sprintf("%s scores higher (%.2f vs %.2f) but is at capacity",
+					bestAgent, bestScore, p.score))
+			}
+		}
+		if s.fileOwner[p.bead.ID] == p.agent.PaneID {
+			why = append(why, "agent already holds the file reservations")
+		}
+		if n := len(p.bead.UnblocksIDs); n > 0 {
+			why = append(why, fmt.Sprintf("unblocks %d tasks", n))
+		}
+
+		if g, ok := s.greedyByBead[p.bead.ID]; ok {
+			ex.GreedyAgent = g
+			if g == p.agent.PaneID {
+				ex.GreedyReason = "greedy picks the same agent"
+			} else {
+				ex.GreedyReason = fmt.Sprintf("greedy would pick %s", g)
+			}
+		} else {
+			ex.GreedyReason = "greedy would leave this bead unassigned"
+		}
+		why = append(why, ex.GreedyReason)
+
+		ex.Why = strings.Join(why, "; ")
+		s.result.Explanations = append(s.result.Explanations, ex)
+	}
+}
+
+// bestEligibleAgent returns the highest-scoring eligible agent for a bead.
+func (s *optimalSolver) bestEligibleAgent(beadID string) (string, float64) {
+	best, bestScore := "", math.Inf(-1)
+	for key, score := range s.scores {
+		if key.bead != beadID || !s.eligible[key] {
+			continue
+		}
+		if score > bestScore || (score == bestScore && key.agent < best) {
+			best, bestScore = key.agent, score
+		}
+	}
+	return best, bestScore
+}
+
+// collectExcluded records beads that could not be assigned and why.
+func (s *optimalSolver) collectExcluded(beads []*bv.TriageRecommendation, selected []scoredPair) {
+	assigned := make(map[string]bool, len(selected))
+	groupTaken := make(map[string]string)
+	for _, p := range selected {
+		assigned[p.bead.ID] = true
+	}
+	for file, ids := range s.result.ConflictGroups {
+		for _, id := range ids {
+			if assigned[id] {
+				groupTaken[file] = id
+			}
+		}
+	}
+
+	for _, b := range beads {
+		if assigned[b.ID] {
+			continue
+		}
+		reason, ok := s.excluded[b.ID]
+		if !ok {
+			reason = s.unassignedReason(b.ID, groupTaken)
+		}
+		s.result.Excluded = append(s.result.Excluded, ExcludedBead{BeadID: b.ID, Reason: reason})
+	}
+}
+
+func (s *optimalSolver) unassignedReason(beadID string, groupTaken map[string]string) string {
+	for file, ids := range s.result.ConflictGroups {
+		for _, id := range ids {
+			if id == beadID && groupTaken[file] != "" {
+				return fmt.Sprintf("shares %s with %s", file, groupTaken[file])
+			}
+		}
+	}
+	hasEligible := false
+	for key := range s.eligible {
+		if key.bead == beadID {
+			hasEligible = true
+			break
+		}
+	}
+	if !hasEligible {
+		return "no eligible agent"
+	}
+	return "agents are worth more on other beads"
+}
+
+// compare fills in Improvement relative to the greedy selection.
+func (s *optimalSolver) compare(selected, greedy []scoredPair) {
+	optimalBeads := make(map[string]string)
+	for _, p := range selected {
+		optimalBeads[p.bead.ID] = p.agent.PaneID
+	}
+	greedyBeads := make(map[string]string)
+	for _, p := range greedy {
+		greedyBeads[p.bead.ID] = p.agent.PaneID
+	}
+
+	imp := OptimalImprovement{
+		ScoreDelta:    s.result.TotalScore - s.result.GreedyTotalScore,
+		ExtraAssigned: len(selected) - len(greedy),
+	}
+	for id, agent := range optimalBeads {
+		g, ok := greedyBeads[id]
+		if !ok {
+			imp.OnlyOptimal = append(imp.OnlyOptimal, id)
+		} else if g != agent {
+			imp.ReassignedAgents++
+		}
+	}
+	for id := range greedyBeads {
+		if _, ok := optimalBeads[id]; !ok {
+			imp.OnlyGreedy = append(imp.OnlyGreedy, id)
+		}
+	}
+	sort.Strings(imp.OnlyOptimal)
+	sort.Strings(imp.OnlyGreedy)
+	s.result.Improvement = imp
+}
+
+func uniqueSorted(ids []string) []string {
+	if len(ids) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool, len(ids))
+	var out []string
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,311 @@
+package coordinator
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/shahbajlive/ntm/internal/bv"
+	"github.com/shahbajlive/ntm/internal/robot"
+)
+
+func TestSolveMinCostFlowBeatsGreedy(t *testing.T) {
+	a := &AgentState{PaneID: "%1"}
+	b := &AgentState{PaneID: "%2"}
+	x := &bv.TriageRecommendation{ID: "x"}
+	y := &bv.TriageRecommendation{ID: "y"}
+
+	// Greedy takes a→x (1.0) and strands y; the optimum is a→y + b→x.
+	pairs := []scoredPair{
+		{agent: a, bead: x, score: 1.0},
+		{agent: a, bead: y, score: 0.9},
+		{agent: b, bead: x, score: 0.8},
+	}
+	capacity := map[string]int{"%1": 1, "%2": 1}
+
+	greedy := selectGreedy(append([]scoredPair(nil), pairs...), 2, 2)
+	if len(greedy) != 1 {
+		t.Fatalf("greedy assignments = %d, want 1", len(greedy))
+	}
+
+	selected := solveMinCostFlow(pairs, capacity, nil)
+	got := make(map[string]string)
+	total := 0.0
+	for _, p := range selected {
+		got[p.bead.ID] = p.agent.PaneID
+		total += p.score
+	}
+	if got["x"] != "%2" || got["y"] != "%1" {
+		t.Errorf("assignments = %v, want x→%%2 y→%%1", got)
+	}
+	if total < 1.69 || total > 1.71 {
+		t.Errorf("total score = %f, want 1.7", total)
+	}
+}
+
+func TestSolveMinCostFlowSkipsLosingAugmentation(t *testing.T) {
+	a := &AgentState{PaneID: "%1"}
+	x := &bv.TriageRecommendation{ID: "x"}
+	y := &bv.TriageRecommendation{ID: "y"}
+
+	// With one group, only the higher-scoring bead may be taken.
+	pairs := []scoredPair{
+		{agent: a, bead: x, score: 0.4},
+		{agent: a, bead: y, score: 0.6},
+	}
+	selected := solveMinCostFlow(pairs, map[string]int{"%1": 2}, map[string]string{"x": "g", "y": "g"})
+	if len(selected) != 1 || selected[0].bead.ID != "y" {
+		t.Errorf("selected = %+v, want only y", selected)
+	}
+}
+
+func TestAssignTasksOptimalCapacity(t *testing.T) {
+	agents := []*AgentState{
+		{PaneID: "%1", AgentType: "cc", ContextUsage: 30, Status: robot.StateWaiting},
+	}
+	beads := []*bv.TriageRecommendation{
+		{ID: "ntm-001", Title: "First", Type: "task", Status: "open", Priority: 2, Score: 0.8},
+		{ID: "ntm-002", Title: "Second", Type: "task", Status: "open", Priority: 2, Score: 0.6},
+		{ID: "ntm-003", Title: "Third", Type: "task", Status: "open", Priority: 2, Score: 0.4},
+	}
+
+	opts := DefaultOptimalOptions()
+	opts.MaxPerAgent = 2
+	result := AssignTasksOptimal(beads, agents, nil, opts)
+	if len(result.Assignments) != 2 {
+		t.Fatalf("assignments = %d, want 2", len(result.Assignments))
+	}
+	if result.Assignments[0].Bead.ID == "ntm-003" || result.Assignments[1].Bead.ID == "ntm-003" {
+		t.Errorf("lowest-scoring bead should be left over: %+v", result.Assignments)
+	}
+	if len(result.Excluded) != 1 || result.Excluded[0].BeadID != "ntm-003" {
+		t.Errorf("excluded = %+v, want ntm-003", result.Excluded)
+	}
+
+	// Existing assignments consume capacity.
+	agents[0].Assignments = 2
+	if result := AssignTasksOptimal(beads, agents, nil, opts); len(result.Assignments) != 0 {
+		t.Errorf("agent at capacity got %d assignments", len(result.Assignments))
+	}
+}
+
+func TestAssignTasksOptimalDependencies(t *testing.T) {
+	agents := []*AgentState{
+		{PaneID: "%1", AgentType: "cc", ContextUsage: 30, Status: robot.StateWaiting},
+		{PaneID: "%2", AgentType: "cc", ContextUsage: 30, Status: robot.StateWaiting},
+	}
+	beads := []*bv.TriageRecommendation{
+		{ID: "ntm-001", Title: "Foundation", Type: "task", Status: "open", Priority: 2, Score: 0.5, UnblocksIDs: []string{"ntm-002"}},
+		{ID: "ntm-002", Title: "Follow-up", Type: "task", Status: "open", Priority: 2, Score: 0.9},
+		{ID: "ntm-003", Title: "Follow-up two", Type: "task", Status: "open", Priority: 2, Score: 0.7, BlockedBy: []string{"ntm-001"}},
+	}
+
+	result := AssignTasksOptimal(beads, agents, nil, DefaultOptimalOptions())
+	if len(result.Assignments) != 1 || result.Assignments[0].Bead.ID != "ntm-001" {
+		t.Fatalf("assignments = %+v, want only ntm-001", result.Assignments)
+	}
+	for _, ex := range result.Excluded {
+		if !strings.Contains(ex.Reason, "ntm-001") {
+			t.Errorf("excluded %s reason = %q, want it to name ntm-001", ex.BeadID, ex.Reason)
+		}
+	}
+}
+
+func TestAssignTasksOptimalFileReservations(t *testing.T) {
+	agents := []*AgentState{
+		{PaneID: "%1", AgentType: "cc", ContextUsage: 30, Status: robot.StateWaiting},
+		{PaneID: "%2", AgentType: "cc", ContextUsage: 30, Status: robot.StateWaiting},
+		{PaneID: "%3", AgentType: "cc", ContextUsage: 30, Status: robot.StateGenerating},
+	}
+	beads := []*bv.TriageRecommendation{
+		{ID: "ntm-001", Title: "Fix internal/api/handler.go", Type: "task", Status: "open", Priority: 2, Score: 0.9},
+		{ID: "ntm-002", Title: "Refactor internal/db/store.go", Type: "task", Status: "open", Priority: 2, Score: 0.8},
+		{ID: "ntm-003", Title: "Plain task", Type: "task", Status: "open", Priority: 2, Score: 0.5},
+	}
+	reservations := map[string][]string{
+		"%2": {"internal/api/*.go"},
+		"%3": {"internal/db/**"},
+	}
+
+	result := AssignTasksOptimal(beads, agents, reservations, DefaultOptimalOptions())
+
+	got := make(map[string]string)
+	for _, a := range result.Assignments {
+		got[a.Bead.ID] = a.Agent.PaneID
+	}
+	if got["ntm-001"] != "%2" {
+		t.Errorf("ntm-001 assigned to %q, want reservation owner %%2", got["ntm-001"])
+	}
+	if _, ok := got["ntm-002"]; ok {
+		t.Errorf("ntm-002 assigned despite busy agent's reservation")
+	}
+	if got["ntm-003"] != "%1" {
+		t.Errorf("ntm-003 assigned to %q, want %%1", got["ntm-003"])
+	}
+
+	var reason string
+	for _, ex := range result.Excluded {
+		if ex.BeadID == "ntm-002" {
+			reason = ex.Reason
+		}
+	}
+	if !strings.Contains(reason, "%3") {
+		t.Errorf("ntm-002 exclusion reason = %q, want busy agent %%3", reason)
+	}
+}
+
+func TestAssignTasksOptimalConflictGroups(t *testing.T) {
+	agents := []*AgentState{
+		{PaneID: "%1", AgentType: "cc", ContextUsage: 30, Status: robot.StateWaiting},
+		{PaneID: "%2", AgentType: "cc", ContextUsage: 30, Status: robot.StateWaiting},
+	}
+	beads := []*bv.TriageRecommendation{
+		{ID: "ntm-001", Title: "Add flag to config.go", Type: "task", Status: "open", Priority: 2, Score: 0.9},
+		{ID: "ntm-002", Title: "Validate config.go", Type: "task", Status: "open", Priority: 2, Score: 0.7},
+		{ID: "ntm-003", Title: "Unrelated", Type: "task", Status: "open", Priority: 2, Score: 0.3},
+	}
+
+	result := AssignTasksOptimal(beads, agents, nil, DefaultOptimalOptions())
+
+	if ids := result.ConflictGroups["config.go"]; len(ids) != 2 {
+		t.Fatalf("conflict groups = %v, want config.go group", result.ConflictGroups)
+	}
+	assigned := make(map[string]bool)
+	for _, a := range result.Assignments {
+		assigned[a.Bead.ID] = true
+	}
+	if !assigned["ntm-001"] || assigned["ntm-002"] || !assigned["ntm-003"] {
+		t.Errorf("assigned = %v, want ntm-001 and ntm-003", assigned)
+	}
+
+	// Greedy ignores file conflicts and hands both config.go beads out.
+	greedy := make(map[string]bool)
+	for _, a := range result.Greedy {
+		greedy[a.Bead.ID] = true
+	}
+	if !greedy["ntm-002"] {
+		t.Errorf("greedy = %v, expected it to take ntm-002", greedy)
+	}
+	if len(result.Improvement.OnlyGreedy) == 0 {
+		t.Errorf("improvement = %+v, want ntm-002 listed as greedy-only", result.Improvement)
+	}
+}
+
+func TestAssignTasksOptimalConflictsArePairwise(t *testing.T) {
+	agents := []*AgentState{
+		{PaneID: "%1", AgentType: "cc", ContextUsage: 30, Status: robot.StateWaiting},
+		{PaneID: "%2", AgentType: "cc", ContextUsage: 30, Status: robot.StateWaiting},
+		{PaneID: "%3", AgentType: "cc", ContextUsage: 30, Status: robot.StateWaiting},
+	}
+	// ntm-002 shares a file with each of the others, but ntm-001 and ntm-003
+	// have nothing in common and may run side by side.
+	beads := []*bv.TriageRecommendation{
+		{ID: "ntm-001", Title: "Fix parser.go", Type: "task", Status: "open", Priority: 2, Score: 0.8},
+		{ID: "ntm-002", Title: "Move helpers from parser.go to lexer.go", Type: "task", Status: "open", Priority: 2, Score: 0.9},
+		{ID: "ntm-003", Title: "Speed up lexer.go", Type: "task", Status: "open", Priority: 2, Score: 0.8},
+	}
+
+	result := AssignTasksOptimal(beads, agents, nil, DefaultOptimalOptions())
+
+	assigned := make(map[string]bool)
+	for _, a := range result.Assignments {
+		assigned[a.Bead.ID] = true
+	}
+	if !assigned["ntm-001"] || assigned["ntm-002"] || !assigned["ntm-003"] {
+		t.Errorf("assigned = %v, want ntm-001 and ntm-003", assigned)
+	}
+}
+
+func TestSolveWithConflictsCapsBranching(t *testing.T) {
+	// A chain of beads, each sharing two files with the next, collides on
+	// every link after the flow; unbounded branching would take 2^n solves.
+	const n = 40
+	capacity := make(map[string]int)
+	groups := make(map[string][]string)
+	var pairs []scoredPair
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("b%02d", i)
+		agent := &AgentState{PaneID: fmt.Sprintf("%%%d", i)}
+		capacity[agent.PaneID] = 1
+		pairs = append(pairs, scoredPair{agent: agent, bead: &bv.TriageRecommendation{ID: id}, score: 1 + float64(i%3)/10})
+		if i > 0 {
+			prev := fmt.Sprintf("b%02d", i-1)
+			groups[fmt.Sprintf("a%02d.go", i)] = []string{prev, id}
+			groups[fmt.Sprintf("b%02d.go", i)] = []string{prev, id}
+		}
+	}
+
+	selected := solveWithConflicts(pairs, capacity, groups)
+	if a, b, ok := conflictingPair(selected, groups); ok {
+		t.Fatalf("selection still has %s and %s sharing a file", a, b)
+	}
+	// Past the cap conflicts are settled greedily, which is not optimal on a
+	// chain but must still keep a fair share of it.
+	if len(selected) < n/3 {
+		t.Errorf("selected %d beads, want at least %d", len(selected), n/3)
+	}
+}
+
+func TestAssignTasksOptimalReservationReasonsAreStable(t *testing.T) {
+	agents := []*AgentState{
+		{PaneID: "%1", AgentType: "cc", ContextUsage: 30, Status: robot.StateWaiting},
+		{PaneID: "%2", AgentType: "cc", ContextUsage: 30, Status: robot.StateWaiting},
+	}
+	beads := []*bv.TriageRecommendation{
+		{ID: "ntm-001", Title: "Fix internal/api/handler.go", Type: "task", Status: "open", Priority: 2, Score: 0.9},
+	}
+	reservations := map[string][]string{
+		"%2": {"internal/api/*.go"},
+		"%1": {"internal/api/handler.go"},
+	}
+
+	for i := 0; i < 20; i++ {
+		result := AssignTasksOptimal(beads, agents, reservations, DefaultOptimalOptions())
+		if len(result.Excluded) != 1 || result.Excluded[0].Reason != "files reserved by both %1 and %2" {
+			t.Fatalf("excluded = %+v, want reserved by both %%1 and %%2", result.Excluded)
+		}
+	}
+}
+
+func TestAssignTasksOptimalExplanations(t *testing.T) {
+	agents := []*AgentState{
+		{PaneID: "%1", AgentType: "cc", ContextUsage: 30, Status: robot.StateWaiting},
+		{PaneID: "%2", AgentType: "cod", ContextUsage: 50, Status: robot.StateWaiting},
+	}
+	beads := []*bv.TriageRecommendation{
+		{ID: "ntm-001", Title: "Epic task", Type: "epic", Status: "open", Priority: 2, Score: 0.8},
+		{ID: "ntm-002", Title: "Quick fix", Type: "chore", Status: "open", Priority: 2, Score: 0.6},
+	}
+
+	result := AssignTasksOptimal(beads, agents, nil, DefaultOptimalOptions())
+	if len(result.Assignments) != 2 || len(result.Explanations) != 2 {
+		t.Fatalf("assignments = %d, explanations = %d; want 2 each", len(result.Assignments), len(result.Explanations))
+	}
+	if result.TotalScore < result.GreedyTotalScore {
+		t.Errorf("optimal total %f below greedy %f", result.TotalScore, result.GreedyTotalScore)
+	}
+	for i, a := range result.Assignments {
+		if a.Reason == "" || a.Reason != result.Explanations[i].Why {
+			t.Errorf("assignment %s reason = %q, want explanation", a.Bead.ID, a.Reason)
+		}
+	}
+}
+
+func TestAssignTasksOptimalEmpty(t *testing.T) {
+	if r := AssignTasksOptimal(nil, nil, nil, DefaultOptimalOptions()); r == nil || len(r.Assignments) != 0 {
+		t.Errorf("empty input result = %+v", r)
+	}
+	busy := []*AgentState{{PaneID: "%1", Status: robot.StateGenerating}}
+	beads := []*bv.TriageRecommendation{{ID: "ntm-001", Score: 0.5}}
+	if r := AssignTasksOptimal(beads, busy, nil, DefaultOptimalOptions()); len(r.Assignments) != 0 {
+		t.Errorf("busy agents got assignments: %+v", r.Assignments)
+	}
+}
+
+func TestParseStrategyOptimal(t *testing.T) {
+	for _, s := range []string{"optimal", "OPTIMAL", "matching"} {
+		if got := ParseStrategy(s); got != StrategyOptimal {
+			t.Errorf("ParseStrategy(%q) = %q, want optimal", s, got)
+		}
+	}
+}
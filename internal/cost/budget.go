@@ -0,0 +1,188 @@
+package cost
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// BudgetScope is what a budget's spend is measured over.
+type BudgetScope string
+
+const (
+	ScopeSession BudgetScope = "session" // Everything recorded for the session
+	ScopeAgent   BudgetScope = "agent"   // One agent type within the session
+	ScopeDaily   BudgetScope = "daily"   // All sessions, today (local time)
+)
+
+// BudgetUnit is what a budget limits.
+type BudgetUnit string
+
+const (
+	UnitUSD    BudgetUnit = "usd"
+	UnitTokens BudgetUnit = "tokens"
+)
+
+// BudgetLevel is how far spend has reached into a budget.
+type BudgetLevel string
+
+const (
+	LevelOK   BudgetLevel = "ok"
+	LevelSoft BudgetLevel = "soft" // At or over the soft threshold: alert
+	LevelHard BudgetLevel = "hard" // At or over the hard threshold: refuse or require approval
+)
+
+func (l BudgetLevel) rank() int {
+	switch l {
+	case LevelHard:
+		return 2
+	case LevelSoft:
+		return 1
+	}
+	return 0
+}
+
+// Budget is a spend limit. Soft and Hard are thresholds in Unit; zero
+// disables a threshold.
+type Budget struct {
+	Scope     BudgetScope `json:"scope"`
+	AgentType string      `json:"agent_type,omitempty"` // For ScopeAgent
+	Unit      BudgetUnit  `json:"unit"`
+	Soft      float64     `json:"soft,omitempty"`
+	Hard      float64     `json:"hard,omitempty"`
+}
+
+// Name identifies the budget, e.g. "session", "agent:cc" or "daily".
+func (b Budget) Name() string {
+	if b.Scope == ScopeAgent {
+		return "agent:" + b.AgentType
+	}
+	return string(b.Scope)
+}
+
+// Spent is the spend a budget is measured against.
+type Spent struct {
+	Tokens int64
+	USD    float64
+}
+
+// SpendSource reports recorded spend. agentType is empty for session and
+// daily budgets; session is empty for daily budgets.
+type SpendSource func(scope BudgetScope, session, agentType string) (Spent, error)
+
+// BudgetStatus is a budget and how much of it has been used.
+type BudgetStatus struct {
+	Budget
+	Name    string      `json:"name"`
+	Used    float64     `json:"used"`
+	Percent float64     `json:"percent"` // Of the hard threshold, or the soft one when there is no hard one
+	Level   BudgetLevel `json:"level"`
+}
+
+// String describes the status, e.g. "session budget: $4.20 of $5.00 (84%)".
+func (s BudgetStatus) String() string {
+	limit := s.Hard
+	if limit == 0 {
+		limit = s.Soft
+	}
+	return fmt.Sprintf("%s budget: %s of %s (%.0f%%)", s.Name, s.Unit.format(s.Used), s.Unit.format(limit), s.Percent)
+}
+
+func (u BudgetUnit) format(v float64) string {
+	if u == UnitTokens {
+		return fmt.Sprintf("%d tokens", int64(v))
+	}
+	return FormatCost(v)
+}
+
+// Evaluate measures session's spend against budgets. Agent budgets apply
+// only to the given agent types; with none given, all agent budgets apply.
+// Statuses are returned worst first.
+func Evaluate(budgets []Budget, session string, agentTypes []string, spent SpendSource) ([]BudgetStatus, error) {
+	cache := make(map[string]Spent)
+	var out []BudgetStatus
+	for _, b := range budgets {
+		if b.Soft <= 0 && b.Hard <= 0 {
+			continue
+		}
+		if b.Scope == ScopeAgent && len(agentTypes) > 0 && !containsFold(agentTypes, b.AgentType) {
+			continue
+		}
+		scopeSession, agentType := session, ""
+		switch b.Scope {
+		case ScopeDaily:
+			scopeSession = ""
+		case ScopeAgent:
+			agentType = b.AgentType
+		}
+		key := string(b.Scope) + "\x00" + agentType
+		s, ok := cache[key]
+		if !ok {
+			var err error
+			if s, err = spent(b.Scope, scopeSession, agentType); err != nil {
+				return nil, fmt.Errorf("%s budget: %w", b.Name(), err)
+			}
+			cache[key] = s
+		}
+		out = append(out, b.status(s))
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Level.rank() != out[j].Level.rank() {
+			return out[i].Level.rank() > out[j].Level.rank()
+		}
+		return out[i].Percent > out[j].Percent
+	})
+	return out, nil
+}
+
+func (b Budget) status(s Spent) BudgetStatus {
+	st := BudgetStatus{Budget: b, Name: b.Name(), Used: s.USD, Level: LevelOK}
+	if b.Unit == UnitTokens {
+		st.Used = float64(s.Tokens)
+	}
+	limit := b.Hard
+	if limit <= 0 {
+		limit = b.Soft
+	}
+	if limit > 0 {
+		st.Percent = st.Used / limit * 100
+	}
+	switch {
+	case b.Hard > 0 && st.Used >= b.Hard:
+		st.Level = LevelHard
+	case b.Soft > 0 && st.Used >= b.Soft:
+		st.Level = LevelSoft
+	}
+	return st
+}
+
+// WorstLevel returns the most severe level among statuses.
+func WorstLevel(statuses []BudgetStatus) BudgetLevel {
+	worst := LevelOK
+	for _, s := range statuses {
+		if s.Level.rank() > worst.rank() {
+			worst = s.Level
+		}
+	}
+	return worst
+}
+
+// Exceeded returns the statuses at level or worse.
+func Exceeded(statuses []BudgetStatus, level BudgetLevel) []BudgetStatus {
+	var out []BudgetStatus
+	for _, s := range statuses {
+		if s.Level.rank() >= level.rank() {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
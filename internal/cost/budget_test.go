@@ -0,0 +1,66 @@
+package cost
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	spend := map[string]Spent{
+		"session/proj/":  {Tokens: 90_000, USD: 4.5},
+		"agent/proj/cc":  {Tokens: 60_000, USD: 4},
+		"agent/proj/cod": {Tokens: 30_000, USD: 0.5},
+		"daily//":        {Tokens: 200_000, USD: 12},
+	}
+	calls := 0
+	src := func(scope BudgetScope, session, agentType string) (Spent, error) {
+		calls++
+		return spend[string(scope)+"/"+session+"/"+agentType], nil
+	}
+	budgets := []Budget{
+		{Scope: ScopeSession, Unit: UnitUSD, Soft: 4, Hard: 5},
+		{Scope: ScopeSession, Unit: UnitTokens, Hard: 1_000_000},
+		{Scope: ScopeAgent, AgentType: "cc", Unit: UnitUSD, Hard: 4},
+		{Scope: ScopeAgent, AgentType: "cod", Unit: UnitUSD, Soft: 1},
+		{Scope: ScopeDaily, Unit: UnitUSD, Soft: 10},
+		{Scope: ScopeDaily, Unit: UnitUSD}, // No thresholds: ignored
+	}
+
+	got, err := Evaluate(budgets, "proj", nil, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 5 {
+		t.Fatalf("got %d statuses: %+v", len(got), got)
+	}
+	if got[0].Name != "agent:cc" || got[0].Level != LevelHard || got[0].Percent != 100 {
+		t.Errorf("worst = %+v", got[0])
+	}
+	if got[1].Name != "daily" || got[1].Level != LevelSoft || got[2].Name != "session" || got[2].Level != LevelSoft {
+		t.Errorf("soft statuses = %+v, %+v", got[1], got[2])
+	}
+	if last := got[4]; last.Unit != UnitTokens || last.Level != LevelOK || last.Used != 90_000 {
+		t.Errorf("token budget = %+v", last)
+	}
+	if calls != 4 {
+		t.Errorf("spend source called %d times, want once per scope", calls)
+	}
+	if WorstLevel(got) != LevelHard || len(Exceeded(got, LevelSoft)) != 3 {
+		t.Errorf("WorstLevel = %s, Exceeded(soft) = %d", WorstLevel(got), len(Exceeded(got, LevelSoft)))
+	}
+	if s := got[0].String(); s != "agent:cc budget: $4.00 of $4.00 (100%)" {
+		t.Errorf("String() = %q", s)
+	}
+
+	// Sending only to codex panes leaves the claude budget out.
+	got, _ = Evaluate(budgets, "proj", []string{"cod"}, src)
+	if WorstLevel(got) != LevelSoft {
+		t.Errorf("cod-only worst level = %s", WorstLevel(got))
+	}
+
+	if _, err := Evaluate(budgets, "proj", nil, func(BudgetScope, string, string) (Spent, error) {
+		return Spent{}, errors.New("db locked")
+	}); err == nil {
+		t.Error("spend source error not returned")
+	}
+}
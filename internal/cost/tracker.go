@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -104,11 +105,29 @@ func (s *SessionCost) TotalTokens() (input, output int) {
 	return
 }
 
+// Usage is token usage recorded against a session's pane.
+type Usage struct {
+	Session      string    `json:"session"`
+	Pane         string    `json:"pane"`
+	AgentType    string    `json:"agent_type,omitempty"`
+	Model        string    `json:"model,omitempty"`
+	InputTokens  int       `json:"input_tokens"`
+	OutputTokens int       `json:"output_tokens"`
+	CostUSD      float64   `json:"cost_usd"`
+	Source       string    `json:"source"` // prompt, response, tokens
+	At           time.Time `json:"at"`
+}
+
+// UsageSink receives each usage the tracker records, e.g. to persist it to
+// a spend ledger. It is called without the tracker's lock held.
+type UsageSink func(Usage)
+
 // CostTracker manages cost tracking across multiple sessions.
 type CostTracker struct {
 	mu       sync.RWMutex
 	sessions map[string]*SessionCost
 	dataDir  string
+	sink     UsageSink
 }
 
 // NewCostTracker creates a new CostTracker instance.
@@ -192,48 +211,52 @@ func (s *SessionCost) getOrCreateAgent(pane, model string) *AgentCost {
 	return a
 }
 
-// RecordPrompt records input tokens from a prompt.
-func (t *CostTracker) RecordPrompt(session, pane, model, prompt string) {
+// SetSink sets the function that receives every recorded usage.
+func (t *CostTracker) SetSink(sink UsageSink) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
+	t.sink = sink
+}
 
-	tokens := EstimateTokens(prompt)
-	s := t.getOrCreateSession(session)
-	a := s.getOrCreateAgent(pane, model)
-	a.InputTokens += tokens
-	a.LastUpdated = time.Now()
-	if model != "" && a.Model == "" {
-		a.Model = model
-	}
+// RecordPrompt records input tokens from a prompt.
+func (t *CostTracker) RecordPrompt(session, pane, model, prompt string) {
+	t.RecordUsage(Usage{Session: session, Pane: pane, Model: model, InputTokens: EstimateTokens(prompt), Source: "prompt"})
 }
 
 // RecordResponse records output tokens from a response.
 func (t *CostTracker) RecordResponse(session, pane, model, response string) {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-
-	tokens := EstimateTokens(response)
-	s := t.getOrCreateSession(session)
-	a := s.getOrCreateAgent(pane, model)
-	a.OutputTokens += tokens
-	a.LastUpdated = time.Now()
-	if model != "" && a.Model == "" {
-		a.Model = model
-	}
+	t.RecordUsage(Usage{Session: session, Pane: pane, Model: model, OutputTokens: EstimateTokens(response), Source: "response"})
 }
 
 // RecordTokens records token counts directly (for when exact counts are known).
 func (t *CostTracker) RecordTokens(session, pane, model string, inputTokens, outputTokens int) {
+	t.RecordUsage(Usage{Session: session, Pane: pane, Model: model, InputTokens: inputTokens, OutputTokens: outputTokens, Source: "tokens"})
+}
+
+// RecordUsage adds u to its pane's totals and passes it, priced with the
+// pane's model, to the sink. Exact counts (source "tokens") replace the
+// pane's model; estimates only fill it in.
+func (t *CostTracker) RecordUsage(u Usage) {
+	if u.At.IsZero() {
+		u.At = time.Now()
+	}
+
 	t.mu.Lock()
-	defer t.mu.Unlock()
+	s := t.getOrCreateSession(u.Session)
+	a := s.getOrCreateAgent(u.Pane, u.Model)
+	a.InputTokens += u.InputTokens
+	a.OutputTokens += u.OutputTokens
+	a.LastUpdated = u.At
+	if u.Model != "" && (a.Model == "" || u.Source == "tokens") {
+		a.Model = u.Model
+	}
+	u.Model = a.Model
+	u.CostUSD = (&AgentCost{InputTokens: u.InputTokens, OutputTokens: u.OutputTokens, Model: a.Model}).Cost()
+	sink := t.sink
+	t.mu.Unlock()
 
-	s := t.getOrCreateSession(session)
-	a := s.getOrCreateAgent(pane, model)
-	a.InputTokens += inputTokens
-	a.OutputTokens += outputTokens
-	a.LastUpdated = time.Now()
-	if model != "" {
-		a.Model = model
+	if sink != nil && (u.InputTokens > 0 || u.OutputTokens > 0) {
+		sink(u)
 	}
 }
 
@@ -359,3 +382,56 @@ func FormatCost(usd float64) string {
 	}
 	return fmt.Sprintf("$%.2f", usd)
 }
+
+// OutputDelta returns the lines of a pane capture that are new since the
+// previous capture: current minus its longest prefix that matches a suffix of
+// prev. With no previous capture, all of current is new.
+func OutputDelta(prev, current string) string {
+	if current == "" {
+		return ""
+	}
+	if prev == "" {
+		curLines := strings.Split(current, "\n")
+		if len(curLines) > 0 && curLines[len(curLines)-1] == "" {
+			curLines = curLines[:len(curLines)-1]
+		}
+		if len(curLines) == 0 {
+			return ""
+		}
+		return strings.Join(curLines, "\n")
+	}
+	if prev == current {
+		return ""
+	}
+
+	prevLines := strings.Split(prev, "\n")
+	curLines := strings.Split(current, "\n")
+	if len(prevLines) > 0 && prevLines[len(prevLines)-1] == "" {
+		prevLines = prevLines[:len(prevLines)-1]
+	}
+	if len(curLines) > 0 && curLines[len(curLines)-1] == "" {
+		curLines = curLines[:len(curLines)-1]
+	}
+	if len(curLines) == 0 {
+		return ""
+	}
+
+	maxOverlap := len(prevLines)
+	if len(curLines) < maxOverlap {
+		maxOverlap = len(curLines)
+	}
+
+	overlap := 0
+	for k := maxOverlap; k > 0; k-- {
+		if slices.Equal(prevLines[len(prevLines)-k:], curLines[:k]) {
+			overlap = k
+			break
+		}
+	}
+
+	deltaLines := curLines[overlap:]
+	if len(deltaLines) == 0 {
+		return ""
+	}
+	return strings.Join(deltaLines, "\n")
+}
@@ -0,0 +1,155 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/tracker"
+)
+
+// ErrNotRunning is returned by Client calls when no daemon answers on the
+// socket.
+var ErrNotRunning = errors.New("ntmd is not running")
+
+// Client talks to a daemon over its unix socket.
+type Client struct {
+	socket string
+	http   *http.Client
+}
+
+// NewClient returns a client for the daemon at socket, or SocketPath() when
+// socket is empty.
+func NewClient(socket string) *Client {
+	if socket == "" {
+		socket = SocketPath()
+	}
+	var d net.Dialer
+	return &Client{
+		socket: socket,
+		http: &http.Client{
+			Timeout: 30 * time.Second,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return d.DialContext(ctx, "unix", socket)
+				},
+			},
+		},
+	}
+}
+
+// Socket returns the socket path the client dials.
+func (c *Client) Socket() string { return c.socket }
+
+// Status returns the daemon's status.
+func (c *Client) Status(ctx context.Context) (*Status, error) {
+	var st Status
+	if err := c.do(ctx, http.MethodGet, "/v1/status", http.StatusOK, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// Attach asks the daemon to host session. Hosting an already hosted session
+// is a no-op.
+func (c *Client) Attach(ctx context.Context, session string) (*SessionStatus, error) {
+	var st SessionStatus
+	if err := c.do(ctx, http.MethodPost, "/v1/sessions/"+url.PathEscape(session), http.StatusOK, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// Detach asks the daemon to stop hosting session.
+func (c *Client) Detach(ctx context.Context, session string) error {
+	return c.do(ctx, http.MethodDelete, "/v1/sessions/"+url.PathEscape(session), http.StatusNoContent, nil)
+}
+
+// Changes returns the file changes the daemon attributed in session after
+// since, oldest first.
+func (c *Client) Changes(ctx context.Context, session string, since time.Time) ([]tracker.RecordedFileChange, error) {
+	path := "/v1/sessions/" + url.PathEscape(session) + "/changes"
+	if !since.IsZero() {
+		path += "?since=" + url.QueryEscape(since.Format(time.RFC3339Nano))
+	}
+	var changes []tracker.RecordedFileChange
+	if err := c.do(ctx, http.MethodGet, path, http.StatusOK, &changes); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// Shutdown asks the daemon to stop its loops and exit.
+func (c *Client) Shutdown(ctx context.Context) error {
+	return c.do(ctx, http.MethodPost, "/v1/shutdown", http.StatusAccepted, nil)
+}
+
+// WaitReady polls the daemon until it answers or timeout passes.
+func (c *Client) WaitReady(ctx context.Context, timeout time.Duration) (*Status, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		st, err := c.Status(ctx)
+		if err == nil || !errors.Is(err, ErrNotRunning) || time.Now().After(deadline) {
+			return st, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// WaitStopped polls until the daemon no longer answers or timeout passes.
+func (c *Client) WaitStopped(ctx context.Context, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		if _, err := c.Status(ctx); errors.Is(err, ErrNotRunning) {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("ntmd still running after %s", timeout)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+func (c *Client) do(ctx context.Context, method, path string, want int, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, "http://ntmd"+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return fmt.Errorf("%w (%s)", ErrNotRunning, c.socket)
+		}
+		return fmt.Errorf("ntmd %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != want {
+		var apiErr apiError
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("ntmd %s %s: %s", method, path, apiErr.Error)
+		}
+		return fmt.Errorf("ntmd %s %s: %s", method, path, resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("ntmd %s %s: decode: %w", method, path, err)
+	}
+	return nil
+}
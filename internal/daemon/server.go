@@ -0,0 +1,425 @@
+// Package daemon implements ntmd, the per-user background process that hosts
+// the long-running loops of every ntm session: the resilience monitor,
+// archiver, prompt queue, scheduler, spend meter, checkpoint worker, file
+// reservation watcher and coordinator. One process owns them all, so several
+// dashboards or commands open on a session no longer start duplicate
+// pollers, and the loops outlive the command that spawned the session.
+//
+// The CLI, dashboard and REST server talk to ntmd over HTTP on a unix
+// socket. What runs for a session is up to the Runner the daemon is built
+// with; this package only hosts, reports on and stops them.
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/tracker"
+)
+
+// SocketEnv names the environment variable that overrides the socket path.
+const SocketEnv = "NTM_DAEMON_SOCKET"
+
+const (
+	// DefaultScanInterval is how often the daemon looks for sessions to host.
+	DefaultScanInterval = 10 * time.Second
+	// detachTimeout bounds how long Detach waits for a session's loops.
+	detachTimeout = 10 * time.Second
+)
+
+// Dir returns the directory holding the daemon's socket and log:
+// ~/.ntm/daemon, or a per-user temp directory without a home directory.
+func Dir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), fmt.Sprintf("ntm-daemon-%d", os.Getuid()))
+	}
+	return filepath.Join(home, ".ntm", "daemon")
+}
+
+// SocketPath returns $NTM_DAEMON_SOCKET, else ntmd.sock in Dir.
+func SocketPath() string {
+	if p := os.Getenv(SocketEnv); p != "" {
+		return p
+	}
+	return filepath.Join(Dir(), "ntmd.sock")
+}
+
+// LogPath returns the log file a started daemon writes to.
+func LogPath() string {
+	return filepath.Join(Dir(), "ntmd.log")
+}
+
+// Status describes a running daemon.
+type Status struct {
+	PID       int             `json:"pid"`
+	Version   string          `json:"version,omitempty"`
+	Socket    string          `json:"socket"`
+	StartedAt time.Time       `json:"started_at"`
+	Sessions  []SessionStatus `json:"sessions"`
+	Detached  []string        `json:"detached,omitempty"` // Discovered sessions left unhosted by Detach
+}
+
+// Hosts reports whether the daemon is hosting session.
+func (s *Status) Hosts(session string) bool {
+	for _, ss := range s.Sessions {
+		if ss.Session == session {
+			return true
+		}
+	}
+	return false
+}
+
+// SessionStatus describes the loops hosted for one session.
+type SessionStatus struct {
+	Session   string    `json:"session"`
+	StartedAt time.Time `json:"started_at"`
+	Loops     []string  `json:"loops"`
+}
+
+// Loops records which loops a Runner has started for a session, for status.
+type Loops struct {
+	mu    sync.Mutex
+	names []string
+}
+
+// Add records that the named loop is running.
+func (l *Loops) Add(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.names = append(l.names, name)
+}
+
+// Names returns the loops recorded so far.
+func (l *Loops) Names() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string{}, l.names...)
+}
+
+// Runner runs a session's loops until ctx is done or the session ends,
+// recording each loop it starts in loops. Returning ends hosting.
+type Runner func(ctx context.Context, session string, loops *Loops) error
+
+// Options configures a Server.
+type Options struct {
+	Socket  string // Default SocketPath()
+	Version string
+	Run     Runner
+	// Discover lists the sessions that should be hosted. It is polled every
+	// ScanInterval; sessions it returns that are not hosted get attached,
+	// unless they were detached since Discover last stopped returning them.
+	Discover     func() []string
+	ScanInterval time.Duration // Default DefaultScanInterval
+}
+
+type hosted struct {
+	status SessionStatus
+	loops  *Loops
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Server hosts session loops and serves the daemon API.
+type Server struct {
+	opts    Options
+	started time.Time
+
+	mu       sync.Mutex
+	ctx      context.Context // Parent of every hosted session
+	sessions map[string]*hosted
+	detached map[string]bool // Sessions reconcile must not re-attach
+	wg       sync.WaitGroup
+	shutdown context.CancelFunc
+}
+
+// NewServer creates a daemon server.
+func NewServer(opts Options) *Server {
+	if opts.Socket == "" {
+		opts.Socket = SocketPath()
+	}
+	if opts.ScanInterval <= 0 {
+		opts.ScanInterval = DefaultScanInterval
+	}
+	return &Server{opts: opts, sessions: make(map[string]*hosted), detached: make(map[string]bool)}
+}
+
+// Listen opens the unix socket at path, replacing a stale socket left by a
+// daemon that died. It returns an error wrapping os.ErrExist if another
+// daemon is still answering there.
+func Listen(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("create socket dir: %w", err)
+	}
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("ntmd already running on %s: %w", path, os.ErrExist)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("remove stale socket: %w", err)
+		}
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("listen on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("secure socket: %w", err)
+	}
+	return ln, nil
+}
+
+// ListenAndServe listens on the configured socket and serves until ctx is
+// done or a client asks the daemon to shut down. Hosted sessions are then
+// stopped and waited for.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	ln, err := Listen(s.opts.Socket)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve serves the daemon API on ln; see ListenAndServe.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	s.mu.Lock()
+	s.ctx = ctx
+	s.shutdown = cancel
+	s.started = time.Now()
+	s.mu.Unlock()
+
+	srv := &http.Server{Handler: s.Handler(), ReadHeaderTimeout: 5 * time.Second}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.Serve(ln) }()
+	log.Printf("[ntmd] listening on %s (pid %d)", s.opts.Socket, os.Getpid())
+
+	s.reconcile()
+	ticker := time.NewTicker(s.opts.ScanInterval)
+	defer ticker.Stop()
+
+	var err error
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case err = <-serveErr:
+			break loop
+		case <-ticker.C:
+			s.reconcile()
+		}
+	}
+
+	log.Printf("[ntmd] shutting down")
+	cancel()
+	shutdownCtx, done := context.WithTimeout(context.Background(), 5*time.Second)
+	defer done()
+	_ = srv.Shutdown(shutdownCtx)
+	s.wg.Wait()
+	_ = os.Remove(s.opts.Socket)
+	if errors.Is(err, http.ErrServerClosed) {
+		err = nil
+	}
+	return err
+}
+
+// reconcile attaches the sessions Discover reports, except detached ones.
+// A detached session Discover no longer reports is forgotten, so a new
+// session reusing its name is hosted.
+func (s *Server) reconcile() {
+	if s.opts.Discover == nil {
+		return
+	}
+	discovered := s.opts.Discover()
+	seen := make(map[string]bool, len(discovered))
+	for _, session := range discovered {
+		seen[session] = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for session := range s.detached {
+		if !seen[session] {
+			delete(s.detached, session)
+		}
+	}
+	for _, session := range discovered {
+		if !s.detached[session] {
+			s.attachLocked(session)
+		}
+	}
+}
+
+// Attach starts hosting session if it is not hosted yet, undoing an earlier
+// Detach. It reports the session's status and whether this call started it.
+func (s *Server) Attach(session string) (SessionStatus, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.detached, session)
+	return s.attachLocked(session)
+}
+
+// attachLocked is Attach without clearing a detach; s.mu is held.
+func (s *Server) attachLocked(session string) (SessionStatus, bool) {
+	if h, ok := s.sessions[session]; ok {
+		st := h.status
+		st.Loops = h.loops.Names()
+		return st, false
+	}
+	if s.ctx == nil || s.ctx.Err() != nil || s.opts.Run == nil {
+		return SessionStatus{Session: session}, false
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	h := &hosted{
+		status: SessionStatus{Session: session, StartedAt: time.Now()},
+		loops:  &Loops{},
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	s.sessions[session] = h
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer close(h.done)
+		defer cancel()
+		log.Printf("[ntmd] hosting session %s", session)
+		if err := s.opts.Run(ctx, session, h.loops); err != nil && !errors.Is(err, context.Canceled) {
+			log.Printf("[ntmd] session %s: %v", session, err)
+		}
+		log.Printf("[ntmd] stopped hosting session %s", session)
+
+		s.mu.Lock()
+		if s.sessions[session] == h {
+			delete(s.sessions, session)
+		}
+		s.mu.Unlock()
+	}()
+	return h.status, true
+}
+
+// Detach stops hosting session and waits for its loops to return. The
+// session stays unhosted until it is attached again or ends. It reports
+// whether the session was hosted.
+func (s *Server) Detach(session string) bool {
+	s.mu.Lock()
+	h, ok := s.sessions[session]
+	if ok {
+		delete(s.sessions, session)
+		s.detached[session] = true
+	}
+	s.mu.Unlock()
+	if !ok {
+		return false
+	}
+	h.cancel()
+	select {
+	case <-h.done:
+	case <-time.After(detachTimeout):
+		log.Printf("[ntmd] session %s: loops did not stop within %s", session, detachTimeout)
+	}
+	return true
+}
+
+// Status reports the daemon and its hosted sessions, ordered by name.
+func (s *Server) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := Status{
+		PID:       os.Getpid(),
+		Version:   s.opts.Version,
+		Socket:    s.opts.Socket,
+		StartedAt: s.started,
+		Sessions:  make([]SessionStatus, 0, len(s.sessions)),
+	}
+	for _, h := range s.sessions {
+		ss := h.status
+		ss.Loops = h.loops.Names()
+		st.Sessions = append(st.Sessions, ss)
+	}
+	sort.Slice(st.Sessions, func(i, j int) bool { return st.Sessions[i].Session < st.Sessions[j].Session })
+	for session := range s.detached {
+		st.Detached = append(st.Detached, session)
+	}
+	sort.Strings(st.Detached)
+	return st
+}
+
+// Handler returns the daemon API:
+//
+//	GET    /v1/status                   daemon status
+//	POST   /v1/sessions/{name}          host a session
+//	DELETE /v1/sessions/{name}          stop hosting a session
+//	GET    /v1/sessions/{name}/changes  file changes attributed in a session
+//	POST   /v1/shutdown                 stop the daemon
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/status", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, s.Status())
+	})
+	mux.HandleFunc("POST /v1/sessions/{name}", func(w http.ResponseWriter, r *http.Request) {
+		st, _ := s.Attach(r.PathValue("name"))
+		writeJSON(w, http.StatusOK, st)
+	})
+	mux.HandleFunc("DELETE /v1/sessions/{name}", func(w http.ResponseWriter, r *http.Request) {
+		if !s.Detach(r.PathValue("name")) {
+			writeJSON(w, http.StatusNotFound, apiError{Error: "session not hosted"})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /v1/sessions/{name}/changes", func(w http.ResponseWriter, r *http.Request) {
+		var since time.Time
+		if v := r.URL.Query().Get("since"); v != "" {
+			t, err := time.Parse(time.RFC3339Nano, v)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, apiError{Error: "invalid since: " + err.Error()})
+				return
+			}
+			since = t
+		}
+		session := r.PathValue("name")
+		changes := []tracker.RecordedFileChange{}
+		for _, c := range tracker.RecordedChangesSince(since) {
+			if c.Session == session {
+				changes = append(changes, c)
+			}
+		}
+		writeJSON(w, http.StatusOK, changes)
+	})
+	mux.HandleFunc("POST /v1/shutdown", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		s.mu.Lock()
+		stop := s.shutdown
+		s.mu.Unlock()
+		if stop != nil {
+			stop()
+		}
+	})
+	return mux
+}
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
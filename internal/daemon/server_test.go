@@ -0,0 +1,234 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/tracker"
+)
+
+// fakeSessions is a Runner whose sessions end when killed.
+type fakeSessions struct {
+	mu    sync.Mutex
+	alive map[string]chan struct{}
+	runs  map[string]int
+}
+
+func newFakeSessions(names ...string) *fakeSessions {
+	f := &fakeSessions{alive: make(map[string]chan struct{}), runs: make(map[string]int)}
+	for _, n := range names {
+		f.alive[n] = make(chan struct{})
+	}
+	return f
+}
+
+func (f *fakeSessions) discover() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []string
+	for n := range f.alive {
+		out = append(out, n)
+	}
+	return out
+}
+
+func (f *fakeSessions) kill(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	close(f.alive[name])
+	delete(f.alive, name)
+}
+
+func (f *fakeSessions) run(ctx context.Context, session string, loops *Loops) error {
+	f.mu.Lock()
+	f.runs[session]++
+	gone := f.alive[session]
+	f.mu.Unlock()
+	if gone == nil {
+		return nil
+	}
+	loops.Add("monitor")
+	loops.Add("queue")
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-gone:
+		return nil
+	}
+}
+
+func (f *fakeSessions) runCount(session string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.runs[session]
+}
+
+func startServer(t *testing.T, f *fakeSessions) (*Client, <-chan error) {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "ntmd.sock")
+	srv := NewServer(Options{
+		Socket:       socket,
+		Version:      "test",
+		Run:          f.run,
+		Discover:     f.discover,
+		ScanInterval: 20 * time.Millisecond,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe(ctx) }()
+	t.Cleanup(cancel)
+
+	c := NewClient(socket)
+	if _, err := c.WaitReady(context.Background(), 5*time.Second); err != nil {
+		t.Fatalf("daemon not ready: %v", err)
+	}
+	return c, errc
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func hostedNames(t *testing.T, c *Client) []string {
+	t.Helper()
+	st, err := c.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	var names []string
+	for _, s := range st.Sessions {
+		names = append(names, s.Session)
+	}
+	return names
+}
+
+func TestServer_HostsDiscoveredSessions(t *testing.T) {
+	f := newFakeSessions("alpha", "beta")
+	c, _ := startServer(t, f)
+	ctx := context.Background()
+
+	var st *Status
+	waitFor(t, "both sessions hosted", func() bool {
+		st, _ = c.Status(ctx)
+		return st != nil && len(st.Sessions) == 2 && len(st.Sessions[0].Loops) == 2
+	})
+	if st.PID != os.Getpid() || st.Version != "test" || !st.Hosts("alpha") || st.Hosts("gamma") {
+		t.Errorf("status = %+v", st)
+	}
+	if loops := st.Sessions[0].Loops; len(loops) != 2 || loops[0] != "monitor" {
+		t.Errorf("alpha loops = %v", loops)
+	}
+
+	// A session that dies is dropped, and repeated scans never host a
+	// session twice.
+	f.kill("alpha")
+	waitFor(t, "alpha dropped", func() bool { return len(hostedNames(t, c)) == 1 })
+	time.Sleep(100 * time.Millisecond)
+	if n := f.runCount("beta"); n != 1 {
+		t.Errorf("beta run %d times, want once", n)
+	}
+
+	if err := c.Detach(ctx, "beta"); err != nil {
+		t.Fatalf("Detach: %v", err)
+	}
+	if err := c.Detach(ctx, "beta"); err == nil {
+		t.Error("detaching an unhosted session succeeded")
+	}
+
+	// Later scans leave a detached session alone until it is attached.
+	time.Sleep(100 * time.Millisecond)
+	st, _ = c.Status(ctx)
+	if len(st.Sessions) != 0 || len(st.Detached) != 1 || st.Detached[0] != "beta" {
+		t.Errorf("status after detach = %+v", st)
+	}
+	if _, err := c.Attach(ctx, "beta"); err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+	waitFor(t, "beta hosted again", func() bool { return f.runCount("beta") == 2 })
+	if st, _ = c.Status(ctx); len(st.Detached) != 0 {
+		t.Errorf("detached after attach = %v", st.Detached)
+	}
+}
+
+func TestServer_Changes(t *testing.T) {
+	c, _ := startServer(t, newFakeSessions())
+	ctx := context.Background()
+
+	start := time.Now()
+	tracker.GlobalFileChanges.Add(tracker.RecordedFileChange{Timestamp: start.Add(time.Second), Session: "alpha", Change: tracker.FileChange{Path: "/p/a.go"}})
+	tracker.GlobalFileChanges.Add(tracker.RecordedFileChange{Timestamp: start.Add(2 * time.Second), Session: "beta", Change: tracker.FileChange{Path: "/p/b.go"}})
+
+	changes, err := c.Changes(ctx, "alpha", start)
+	if err != nil || len(changes) != 1 || changes[0].Change.Path != "/p/a.go" {
+		t.Fatalf("Changes = %+v, %v", changes, err)
+	}
+	if changes, err = c.Changes(ctx, "alpha", changes[0].Timestamp); err != nil || len(changes) != 0 {
+		t.Errorf("Changes after last = %+v, %v", changes, err)
+	}
+}
+
+func TestServer_AttachAndShutdown(t *testing.T) {
+	f := newFakeSessions()
+	c, errc := startServer(t, f)
+	ctx := context.Background()
+
+	f.mu.Lock()
+	f.alive["manual"] = make(chan struct{})
+	f.mu.Unlock()
+	st, err := c.Attach(ctx, "manual")
+	if err != nil || st.Session != "manual" {
+		t.Fatalf("Attach = %+v, %v", st, err)
+	}
+	if _, err := c.Attach(ctx, "manual"); err != nil {
+		t.Fatalf("second Attach: %v", err)
+	}
+
+	if err := c.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	select {
+	case err := <-errc:
+		if err != nil {
+			t.Errorf("ListenAndServe = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("daemon did not stop")
+	}
+	if n := f.runCount("manual"); n != 1 {
+		t.Errorf("manual run %d times, want once", n)
+	}
+	if _, err := c.Status(ctx); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("Status after shutdown = %v, want ErrNotRunning", err)
+	}
+}
+
+func TestListen_RefusesSecondDaemon(t *testing.T) {
+	f := newFakeSessions()
+	c, _ := startServer(t, f)
+	if _, err := Listen(c.Socket()); !errors.Is(err, os.ErrExist) {
+		t.Errorf("Listen on a live socket = %v, want ErrExist", err)
+	}
+
+	// A stale socket file left by a dead daemon is replaced.
+	stale := filepath.Join(t.TempDir(), "stale.sock")
+	if err := os.WriteFile(stale, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ln, err := Listen(stale)
+	if err != nil {
+		t.Fatalf("Listen on stale socket: %v", err)
+	}
+	ln.Close()
+}
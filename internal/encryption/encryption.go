@@ -179,3 +179,18 @@ func newCipher(key []byte) (cipher.Block, error) {
 	}
 	return block, nil
 }
+
+// DecryptWithKeyring tries each key in order until one decrypts data.
+// Returns ErrWrongKey if no key works.
+func DecryptWithKeyring(keys [][]byte, data []byte) ([]byte, error) {
+	for _, key := range keys {
+		plaintext, err := Decrypt(key, data)
+		if err == nil {
+			return plaintext, nil
+		}
+		if !IsWrongKey(err) {
+			return nil, err
+		}
+	}
+	return nil, &Error{Kind: ErrWrongKey, Err: fmt.Errorf("no key in keyring could decrypt the data")}
+}
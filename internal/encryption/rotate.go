@@ -0,0 +1,344 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/util"
+)
+
+// RotationState records the progress of a key rotation so an interrupted
+// run can resume. It never contains key material.
+type RotationState struct {
+	KeyID     string                 `json:"key_id"`
+	StartedAt time.Time              `json:"started_at"`
+	Targets   []string               `json:"targets"`
+	Files     map[string]RotatedFile `json:"files"`
+	Failed    map[string]string      `json:"failed,omitempty"`
+}
+
+// RotatedFile summarizes the re-encryption of one file.
+type RotatedFile struct {
+	Path     string    `json:"path"`
+	Format   string    `json:"format"` // lines, blob, or plain
+	Records  int       `json:"records"`
+	Verified bool      `json:"verified"`
+	At       time.Time `json:"at"`
+}
+
+// RotationProgress is reported after each file.
+type RotationProgress struct {
+	Index   int
+	Total   int
+	File    RotatedFile
+	Skipped bool // already rotated in an earlier run
+	Err     error
+}
+
+// Rotator re-encrypts stored artifacts from the old keys to a new key.
+type Rotator struct {
+	// OldKeys are tried when decrypting existing records.
+	OldKeys [][]byte
+	// NewKey encrypts every record that is rewritten.
+	NewKey []byte
+	// StatePath persists progress for resuming; empty disables resume.
+	StatePath string
+	// Progress is called after each file.
+	Progress func(RotationProgress)
+}
+
+// LoadRotationState reads a saved rotation state. It returns nil, nil when
+// no rotation is in progress.
+func LoadRotationState(path string) (*RotationState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading rotation state: %w", err)
+	}
+	var st RotationState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("parsing rotation state: %w", err)
+	}
+	if st.Files == nil {
+		st.Files = make(map[string]RotatedFile)
+	}
+	return &st, nil
+}
+
+// SaveRotationState writes the state atomically.
+func SaveRotationState(path string, st *RotationState) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding rotation state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("creating state directory: %w", err)
+	}
+	return util.AtomicWriteFile(path, data, 0o600)
+}
+
+// ExpandTargets resolves target paths into a sorted list of regular files.
+// Directories are walked recursively; missing paths are ignored.
+func ExpandTargets(targets []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var files []string
+	for _, t := range targets {
+		info, err := os.Stat(t)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		if !info.IsDir() {
+			if !seen[t] {
+				seen[t] = true
+				files = append(files, t)
+			}
+			continue
+		}
+		err = filepath.WalkDir(t, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.Type().IsRegular() && !seen[path] {
+				seen[path] = true
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("walking %s: %w", t, err)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Run rotates every file under targets. Files already recorded in state are
+// skipped, so calling Run again with the same state resumes the rotation.
+// Failures are recorded per file and returned as a combined error after all
+// files have been attempted.
+func (r *Rotator) Run(st *RotationState) error {
+	if len(r.NewKey) != KeySize {
+		return &Error{Kind: ErrInvalidKey, Err: fmt.Errorf("new key must be %d bytes", KeySize)}
+	}
+	if st.Files == nil {
+		st.Files = make(map[string]RotatedFile)
+	}
+	st.Failed = nil
+
+	files, err := ExpandTargets(st.Targets)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i, path := range files {
+		if done, ok := st.Files[path]; ok {
+			r.report(RotationProgress{Index: i + 1, Total: len(files), File: done, Skipped: true})
+			continue
+		}
+
+		res, err := r.RotateFile(path)
+		if err != nil {
+			if st.Failed == nil {
+				st.Failed = make(map[string]string)
+			}
+			st.Failed[path] = err.Error()
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+		} else {
+			st.Files[path] = res
+		}
+		if r.StatePath != "" {
+			if serr := SaveRotationState(r.StatePath, st); serr != nil {
+				return serr
+			}
+		}
+		r.report(RotationProgress{Index: i + 1, Total: len(files), File: res, Err: err})
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Rotator) report(p RotationProgress) {
+	if r.Progress != nil {
+		r.Progress(p)
+	}
+}
+
+// RotateFile re-encrypts one file in place. Whole-file blobs (as written by
+// Encrypt) and JSONL files with encrypted lines are supported; plaintext
+// lines and files are left untouched. The new content is verified against
+// NewKey before and after the atomic replace.
+func (r *Rotator) RotateFile(path string) (RotatedFile, error) {
+	res := RotatedFile{Path: path, Format: "plain", At: time.Now().UTC()}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return res, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return res, err
+	}
+	keys := append([][]byte{r.NewKey}, r.OldKeys...)
+
+	var out []byte
+	if plaintext, ok := r.decryptBlob(keys, data); ok {
+		res.Format = "blob"
+		res.Records = 1
+		out, err = Encrypt(r.NewKey, plaintext)
+		if err != nil {
+			return res, err
+		}
+		if _, err := Decrypt(r.NewKey, out); err != nil {
+			return res, fmt.Errorf("verifying re-encrypted blob: %w", err)
+		}
+	} else {
+		out, res.Records, err = r.rotateLines(keys, data)
+		if err != nil {
+			return res, err
+		}
+		if res.Records > 0 {
+			res.Format = "lines"
+		}
+	}
+
+	if res.Records == 0 {
+		res.Verified = true
+		return res, nil
+	}
+
+	if err := util.AtomicWriteFile(path, out, info.Mode().Perm()); err != nil {
+		return res, err
+	}
+	n, err := VerifyFile(r.NewKey, path)
+	if err != nil {
+		return res, fmt.Errorf("verifying rewritten file: %w", err)
+	}
+	if n != res.Records {
+		return res, fmt.Errorf("verified %d of %d records after rewrite", n, res.Records)
+	}
+	res.Verified = true
+	return res, nil
+}
+
+func (r *Rotator) decryptBlob(keys [][]byte, data []byte) ([]byte, bool) {
+	if len(data) < headerSize || data[0] != FormatVersion {
+		return nil, false
+	}
+	plaintext, err := DecryptWithKeyring(keys, data)
+	return plaintext, err == nil
+}
+
+// rotateLines re-encrypts encrypted JSONL lines and returns the new content
+// with every other byte preserved.
+func (r *Rotator) rotateLines(keys [][]byte, data []byte) ([]byte, int, error) {
+	lines := bytes.Split(data, []byte("\n"))
+	records := 0
+	for i, line := range lines {
+		ciphertext, ok := encryptedLinePayload(line)
+		if !ok {
+			continue
+		}
+		plaintext, err := DecryptWithKeyring(keys, ciphertext)
+		if err != nil {
+			if IsWrongKey(err) {
+				return nil, 0, fmt.Errorf("line %d: no configured key decrypts this record", i+1)
+			}
+			continue
+		}
+		enc, err := EncryptLine(r.NewKey, plaintext)
+		if err != nil {
+			return nil, 0, err
+		}
+		if _, err := DecryptLine(r.NewKey, enc); err != nil {
+			return nil, 0, fmt.Errorf("line %d: verifying re-encrypted record: %w", i+1, err)
+		}
+		lines[i] = enc
+		records++
+	}
+	return bytes.Join(lines, []byte("\n")), records, nil
+}
+
+// encryptedLinePayload returns the decoded ciphertext when line looks like an
+// EncryptLine record.
+func encryptedLinePayload(line []byte) ([]byte, bool) {
+	line = bytes.TrimRight(line, "\r")
+	if !IsEncryptedLine(line) {
+		return nil, false
+	}
+	ciphertext := make([]byte, base64.StdEncoding.DecodedLen(len(line)))
+	n, err := base64.StdEncoding.Decode(ciphertext, line)
+	if err != nil || n < headerSize || ciphertext[0] != FormatVersion {
+		return nil, false
+	}
+	return ciphertext[:n], true
+}
+
+// VerifyFile counts the encrypted records in path that decrypt with key and
+// fails if any encrypted record does not.
+func VerifyFile(key []byte, path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) >= headerSize && data[0] == FormatVersion {
+		if _, err := Decrypt(key, data); err == nil {
+			return 1, nil
+		}
+	}
+	count := 0
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		ciphertext, ok := encryptedLinePayload(line)
+		if !ok {
+			continue
+		}
+		if _, err := Decrypt(key, ciphertext); err != nil {
+			if IsWrongKey(err) {
+				return count, fmt.Errorf("line %d does not decrypt with the new key", i+1)
+			}
+			continue
+		}
+		count++
+	}
+	return count, nil
+}
+
+// GenerateKey returns a new random AES-256 key.
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, fmt.Errorf("generating key: %w", err)
+	}
+	return key, nil
+}
+
+// EncodeKey renders key in the given format (hex or base64).
+func EncodeKey(key []byte, format string) (string, error) {
+	switch format {
+	case "", "hex":
+		return fmt.Sprintf("%x", key), nil
+	case "base64":
+		return base64.StdEncoding.EncodeToString(key), nil
+	default:
+		return "", fmt.Errorf("unsupported key_format %q: use hex or base64", format)
+	}
+}
+
+// DecodeKey parses key material in the given format (hex or base64).
+func DecodeKey(encoded, format string) ([]byte, error) {
+	return decodeKey(encoded, format)
+}
@@ -0,0 +1,202 @@
+package encryption
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeEncryptedLines(t *testing.T, path string, key []byte, records ...string) {
+	t.Helper()
+	var buf bytes.Buffer
+	for _, r := range records {
+		line, err := EncryptLine(key, []byte(r))
+		if err != nil {
+			t.Fatalf("EncryptLine: %v", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRotateFile_Lines(t *testing.T) {
+	oldKey, newKey := randomKey(t), randomKey(t)
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	writeEncryptedLines(t, path, oldKey, `{"a":1}`, `{"a":2}`)
+
+	// Mixed files keep plaintext lines as they are.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("{\"plain\":true}\n")
+	f.Close()
+
+	r := &Rotator{OldKeys: [][]byte{oldKey}, NewKey: newKey}
+	res, err := r.RotateFile(path)
+	if err != nil {
+		t.Fatalf("RotateFile: %v", err)
+	}
+	if res.Format != "lines" || res.Records != 2 || !res.Verified {
+		t.Fatalf("result = %+v", res)
+	}
+
+	data, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) != 3 || lines[2] != `{"plain":true}` {
+		t.Fatalf("unexpected content:\n%s", data)
+	}
+	for i, want := range []string{`{"a":1}`, `{"a":2}`} {
+		got, err := DecryptLine(newKey, []byte(lines[i]))
+		if err != nil || string(got) != want {
+			t.Errorf("line %d = %q, %v", i+1, got, err)
+		}
+		if _, err := DecryptLine(oldKey, []byte(lines[i])); err == nil {
+			t.Errorf("line %d still decrypts with the old key", i+1)
+		}
+	}
+}
+
+func TestRotateFile_Blob(t *testing.T) {
+	oldKey, newKey := randomKey(t), randomKey(t)
+	path := filepath.Join(t.TempDir(), "secrets.vault")
+	blob, err := Encrypt(oldKey, []byte(`{"entries":[]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, blob, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &Rotator{OldKeys: [][]byte{oldKey}, NewKey: newKey}
+	res, err := r.RotateFile(path)
+	if err != nil {
+		t.Fatalf("RotateFile: %v", err)
+	}
+	if res.Format != "blob" || !res.Verified {
+		t.Fatalf("result = %+v", res)
+	}
+	data, _ := os.ReadFile(path)
+	if got, err := Decrypt(newKey, data); err != nil || string(got) != `{"entries":[]}` {
+		t.Fatalf("Decrypt = %q, %v", got, err)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestRotateFile_PlainUntouched(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	orig := []byte("{\"session\":\"demo\"}\n")
+	if err := os.WriteFile(path, orig, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := &Rotator{OldKeys: [][]byte{randomKey(t)}, NewKey: randomKey(t)}
+	res, err := r.RotateFile(path)
+	if err != nil || res.Format != "plain" || res.Records != 0 {
+		t.Fatalf("RotateFile = %+v, %v", res, err)
+	}
+	if data, _ := os.ReadFile(path); !bytes.Equal(data, orig) {
+		t.Errorf("plain file modified: %q", data)
+	}
+}
+
+func TestRotateFile_UnknownKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	writeEncryptedLines(t, path, randomKey(t), `{"x":1}`)
+	before, _ := os.ReadFile(path)
+
+	r := &Rotator{OldKeys: [][]byte{randomKey(t)}, NewKey: randomKey(t)}
+	if _, err := r.RotateFile(path); err == nil {
+		t.Fatal("expected error for records no key decrypts")
+	}
+	if after, _ := os.ReadFile(path); !bytes.Equal(before, after) {
+		t.Error("file changed after failed rotation")
+	}
+}
+
+func TestRotatorRun_Resume(t *testing.T) {
+	oldKey, newKey := randomKey(t), randomKey(t)
+	dir := t.TempDir()
+	good := filepath.Join(dir, "a.jsonl")
+	bad := filepath.Join(dir, "b.jsonl")
+	writeEncryptedLines(t, good, oldKey, `{"n":1}`)
+	stray := randomKey(t)
+	writeEncryptedLines(t, bad, stray, `{"n":2}`)
+
+	statePath := filepath.Join(t.TempDir(), "rotation.json")
+	st := &RotationState{KeyID: "k2", Targets: []string{dir, filepath.Join(dir, "missing")}}
+	var progress []RotationProgress
+	r := &Rotator{
+		OldKeys:   [][]byte{oldKey},
+		NewKey:    newKey,
+		StatePath: statePath,
+		Progress:  func(p RotationProgress) { progress = append(progress, p) },
+	}
+
+	if err := r.Run(st); err == nil {
+		t.Fatal("expected error for b.jsonl")
+	}
+	if len(progress) != 2 || progress[1].Err == nil {
+		t.Fatalf("progress = %+v", progress)
+	}
+
+	saved, err := LoadRotationState(statePath)
+	if err != nil || saved == nil {
+		t.Fatalf("LoadRotationState = %v, %v", saved, err)
+	}
+	if _, ok := saved.Files[good]; !ok || saved.Failed[bad] == "" {
+		t.Fatalf("saved state = %+v", saved)
+	}
+
+	// Resume once the missing key is available; a.jsonl is not touched again.
+	goodBefore, _ := os.ReadFile(good)
+	progress = nil
+	r.OldKeys = append(r.OldKeys, stray)
+	if err := r.Run(saved); err != nil {
+		t.Fatalf("resumed Run: %v", err)
+	}
+	if !progress[0].Skipped || progress[1].Skipped {
+		t.Errorf("progress = %+v", progress)
+	}
+	if goodAfter, _ := os.ReadFile(good); !bytes.Equal(goodBefore, goodAfter) {
+		t.Error("already rotated file was rewritten on resume")
+	}
+	for _, p := range []string{good, bad} {
+		if n, err := VerifyFile(newKey, p); err != nil || n != 1 {
+			t.Errorf("VerifyFile(%s) = %d, %v", filepath.Base(p), n, err)
+		}
+	}
+}
+
+func TestLoadRotationState_Missing(t *testing.T) {
+	st, err := LoadRotationState(filepath.Join(t.TempDir(), "none.json"))
+	if st != nil || err != nil {
+		t.Fatalf("LoadRotationState = %v, %v", st, err)
+	}
+}
+
+func TestEncodeDecodeKey(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, format := range []string{"hex", "base64"} {
+		enc, err := EncodeKey(key, format)
+		if err != nil {
+			t.Fatalf("EncodeKey(%s): %v", format, err)
+		}
+		got, err := DecodeKey(enc, format)
+		if err != nil || !bytes.Equal(got, key) {
+			t.Errorf("DecodeKey(%s) = %x, %v", format, got, err)
+		}
+	}
+	if _, err := EncodeKey(key, "pem"); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
@@ -21,6 +21,13 @@ const (
 	EventAgentCrash   EventType = "agent_crash"
 	EventAgentRestart EventType = "agent_restart"
 
+	// Tool-permission prompt events
+	EventPermissionPrompt   EventType = "permission_prompt"
+	EventPermissionAnswered EventType = "permission_answered"
+
+	// File conflict events
+	EventConflictResolved EventType = "conflict_resolved"
+
 	// Communication events
 	EventPromptSend      EventType = "prompt_send"
 	EventPromptBroadcast EventType = "prompt_broadcast"
@@ -116,6 +123,33 @@ type CheckpointData struct {
 	IncludesGit  bool   `json:"includes_git,omitempty"`
 }
 
+// PermissionData contains data for permission_prompt and
+// permission_answered events.
+type PermissionData struct {
+	Pane      string `json:"pane"`
+	AgentType string `json:"agent_type"`
+	RequestID string `json:"request_id"`
+	Kind      string `json:"kind"`
+	Target    string `json:"target,omitempty"`
+	Decision  string `json:"decision,omitempty"`   // allow, deny or ask
+	DecidedBy string `json:"decided_by,omitempty"` // policy or the answering user/client
+	Reason    string `json:"reason,omitempty"`
+}
+
+// ConflictResolutionData contains data for conflict_resolved events.
+type ConflictResolutionData struct {
+	Path      string   `json:"path"`
+	SideA     string   `json:"side_a"`
+	SideB     string   `json:"side_b"`
+	Conflicts int      `json:"conflicts"`
+	Method    string   `json:"method"`             // clean, pick, delegate or file
+	Picks     []string `json:"picks,omitempty"`    // Per conflict: a, b, ab, ba or base
+	Delegate  string   `json:"delegate,omitempty"` // Agent that merged the file
+	Winner    string   `json:"winner,omitempty"`
+	AppliedTo string   `json:"applied_to"`
+	Released  []string `json:"released,omitempty"` // Agent Mail holders whose reservations were released
+}
+
 // ErrorData contains data for error events.
 type ErrorData struct {
 	ErrorType string `json:"error_type"`
@@ -156,6 +190,30 @@ func ToMap(v interface{}) map[string]interface{} {
 			"description":   d.Description,
 			"includes_git":  d.IncludesGit,
 		}
+	case PermissionData:
+		return map[string]interface{}{
+			"pane":       d.Pane,
+			"agent_type": d.AgentType,
+			"request_id": d.RequestID,
+			"kind":       d.Kind,
+			"target":     d.Target,
+			"decision":   d.Decision,
+			"decided_by": d.DecidedBy,
+			"reason":     d.Reason,
+		}
+	case ConflictResolutionData:
+		return map[string]interface{}{
+			"path":       d.Path,
+			"side_a":     d.SideA,
+			"side_b":     d.SideB,
+			"conflicts":  d.Conflicts,
+			"method":     d.Method,
+			"picks":      d.Picks,
+			"delegate":   d.Delegate,
+			"winner":     d.Winner,
+			"applied_to": d.AppliedTo,
+			"released":   d.Released,
+		}
 	case ErrorData:
 		return map[string]interface{}{
 			"error_type": d.ErrorType,
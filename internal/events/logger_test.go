@@ -547,7 +547,7 @@ func TestEventTypeConstants(t *testing.T) {
 		EventAgentSpawn, EventAgentAdd, EventAgentCrash, EventAgentRestart,
 		EventPromptSend, EventPromptBroadcast, EventInterrupt,
 		EventCheckpointCreate, EventCheckpointRestore, EventSessionSave, EventSessionRestore,
-		EventTemplateUse, EventError,
+		EventTemplateUse, EventError, EventPermissionPrompt, EventPermissionAnswered,
 	}
 
 	seen := make(map[EventType]bool)
@@ -0,0 +1,103 @@
+package fleet
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shahbajlive/ntm/internal/checkpoint"
+	"github.com/shahbajlive/ntm/internal/session"
+	"github.com/shahbajlive/ntm/internal/status"
+	"github.com/shahbajlive/ntm/internal/tmux"
+)
+
+// PauseReason is recorded on send pauses placed from the fleet view.
+const PauseReason = "paused from fleet overview"
+
+// ActionResult is the outcome of a bulk action on one session.
+type ActionResult struct {
+	Session string `json:"session"`
+	Detail  string `json:"detail,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Failed counts results that carry an error.
+func Failed(results []ActionResult) int {
+	n := 0
+	for _, r := range results {
+		if r.Error != "" {
+			n++
+		}
+	}
+	return n
+}
+
+func forEach(sessions []string, fn func(name string) (string, error)) []ActionResult {
+	results := make([]ActionResult, 0, len(sessions))
+	for _, name := range sessions {
+		r := ActionResult{Session: name}
+		detail, err := fn(name)
+		r.Detail = detail
+		if err != nil {
+			r.Error = err.Error()
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// PauseSends pauses prompt delivery to each session.
+func PauseSends(sessions []string, reason string) []ActionResult {
+	return forEach(sessions, func(name string) (string, error) {
+		return "sends paused", session.PauseSends(name, reason)
+	})
+}
+
+// ResumeSends lifts send pauses on each session.
+func ResumeSends(sessions []string) []ActionResult {
+	return forEach(sessions, func(name string) (string, error) {
+		return "sends resumed", session.ResumeSends(name)
+	})
+}
+
+// CheckpointAll creates a checkpoint of each session with default capture
+// options.
+func CheckpointAll(sessions []string, description string) []ActionResult {
+	capturer := checkpoint.NewCapturer()
+	return forEach(sessions, func(name string) (string, error) {
+		var opts []checkpoint.CheckpointOption
+		if description != "" {
+			opts = append(opts, checkpoint.WithDescription(description))
+		}
+		cp, err := capturer.Create(name, "", opts...)
+		if err != nil {
+			return "", fmt.Errorf("creating checkpoint: %w", err)
+		}
+		return cp.ID, nil
+	})
+}
+
+// IdlePanes returns the panes of agentType in a session that the status
+// detector currently reports as idle.
+func IdlePanes(ctx context.Context, src Sources, name string, agentType tmux.AgentType) ([]tmux.Pane, error) {
+	panes, err := tmux.GetPanesContext(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	statuses, err := src.Statuses(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	idle := make(map[string]bool, len(statuses))
+	for _, st := range statuses {
+		if st.State == status.StateIdle {
+			idle[st.PaneID] = true
+		}
+	}
+	var out []tmux.Pane
+	for _, p := range panes {
+		if p.Type == agentType && idle[p.ID] {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
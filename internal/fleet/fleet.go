@@ -0,0 +1,373 @@
+// Package fleet aggregates per-session status across every ntm session so a
+// single view can show the whole fleet: agent states, context pressure,
+// estimated spend, alerts, pipeline runs and bead throughput.
+package fleet
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/alerts"
+	"github.com/shahbajlive/ntm/internal/cost"
+	"github.com/shahbajlive/ntm/internal/pipeline"
+	"github.com/shahbajlive/ntm/internal/session"
+	"github.com/shahbajlive/ntm/internal/status"
+	"github.com/shahbajlive/ntm/internal/tmux"
+)
+
+// ContextHotPercent is the context usage at which an agent counts as under
+// context pressure.
+const ContextHotPercent = 80.0
+
+// StateRateLimited is reported for agents in an error state caused by rate
+// limiting, so they are not lumped in with crashes.
+const StateRateLimited = "rate_limited"
+
+// SessionSummary is the fleet view of one ntm session.
+type SessionSummary struct {
+	Name       string         `json:"name"`
+	ProjectDir string         `json:"project_dir,omitempty"`
+	Attached   bool           `json:"attached"`
+	Agents     int            `json:"agents"`
+	AgentTypes map[string]int `json:"agent_types"`
+	States     map[string]int `json:"states"`
+	// IdleClaude counts idle Claude Code agents, the targets of a fleet
+	// broadcast.
+	IdleClaude int `json:"idle_claude"`
+
+	ContextMax float64 `json:"context_max_percent"`
+	ContextHot int     `json:"context_hot"`
+	CostUSD    float64 `json:"estimated_cost_usd"`
+
+	Alerts         int `json:"alerts"`
+	CriticalAlerts int `json:"critical_alerts"`
+
+	PipelinesRunning int `json:"pipelines_running"`
+	PipelinesFailed  int `json:"pipelines_failed"`
+	PipelinesTotal   int `json:"pipelines_total"`
+
+	BeadsCompletedHour int `json:"beads_completed_1h"`
+	BeadsCompletedDay  int `json:"beads_completed_24h"`
+
+	SendsPaused bool   `json:"sends_paused"`
+	PauseReason string `json:"pause_reason,omitempty"`
+
+	// Error records a source that failed for this session; the remaining
+	// fields are still filled in from the sources that worked.
+	Error string `json:"error,omitempty"`
+}
+
+// Totals sums the per-session summaries.
+type Totals struct {
+	Sessions           int            `json:"sessions"`
+	Agents             int            `json:"agents"`
+	States             map[string]int `json:"states"`
+	IdleClaude         int            `json:"idle_claude"`
+	ContextHot         int            `json:"context_hot"`
+	CostUSD            float64        `json:"estimated_cost_usd"`
+	Alerts             int            `json:"alerts"`
+	CriticalAlerts     int            `json:"critical_alerts"`
+	PipelinesRunning   int            `json:"pipelines_running"`
+	BeadsCompletedHour int            `json:"beads_completed_1h"`
+	BeadsCompletedDay  int            `json:"beads_completed_24h"`
+	SessionsPaused     int            `json:"sessions_paused"`
+}
+
+// Overview is a snapshot of the whole fleet.
+type Overview struct {
+	GeneratedAt time.Time        `json:"generated_at"`
+	Sessions    []SessionSummary `json:"sessions"`
+	Totals      Totals           `json:"totals"`
+}
+
+// Find returns the summary for a session, or nil.
+func (o *Overview) Find(name string) *SessionSummary {
+	for i := range o.Sessions {
+		if o.Sessions[i].Name == name {
+			return &o.Sessions[i]
+		}
+	}
+	return nil
+}
+
+// Sources supplies the data the collector aggregates. Nil fields are
+// skipped, which keeps the collector usable without every integration.
+type Sources struct {
+	Sessions   func() ([]tmux.Session, error)
+	Panes      func(ctx context.Context) (map[string][]tmux.Pane, error)
+	Statuses   func(ctx context.Context, session string) ([]status.AgentStatus, error)
+	Model      func(p tmux.Pane) string
+	ProjectDir func(session string) string
+	Alerts     func() []alerts.Alert
+	Pipelines  func(projectDir string) ([]*pipeline.ExecutionState, error)
+	// BeadCompletions returns when beads were completed in a session.
+	BeadCompletions func(session string) ([]time.Time, error)
+	Prompts         func(session string) (*session.PromptHistory, error)
+	SendPause       func(session string) (*session.SendPause, error)
+	Now             func() time.Time
+}
+
+// Collect builds an overview of every tmux session that has at least one
+// agent pane. Sessions are probed concurrently.
+func Collect(ctx context.Context, src Sources) (*Overview, error) {
+	now := time.Now()
+	if src.Now != nil {
+		now = src.Now()
+	}
+
+	sessions, err := src.Sessions()
+	if err != nil {
+		return nil, err
+	}
+	var allPanes map[string][]tmux.Pane
+	if src.Panes != nil {
+		if allPanes, err = src.Panes(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	alertsBySession := make(map[string][]alerts.Alert)
+	if src.Alerts != nil {
+		for _, a := range src.Alerts() {
+			if a.Session != "" && !a.IsResolved() {
+				alertsBySession[a.Session] = append(alertsBySession[a.Session], a)
+			}
+		}
+	}
+
+	type job struct {
+		sess  tmux.Session
+		panes []tmux.Pane
+	}
+	var jobs []job
+	for _, s := range sessions {
+		panes := allPanes[s.Name]
+		if panes == nil {
+			panes = s.Panes
+		}
+		if countAgents(panes) == 0 {
+			continue
+		}
+		jobs = append(jobs, job{sess: s, panes: panes})
+	}
+
+	out := &Overview{GeneratedAt: now, Sessions: make([]SessionSummary, len(jobs))}
+	var wg sync.WaitGroup
+	for i, j := range jobs {
+		wg.Add(1)
+		go func(i int, j job) {
+			defer wg.Done()
+			out.Sessions[i] = summarize(ctx, src, j.sess, j.panes, alertsBySession[j.sess.Name], now)
+		}(i, j)
+	}
+	wg.Wait()
+
+	sort.Slice(out.Sessions, func(i, j int) bool { return out.Sessions[i].Name < out.Sessions[j].Name })
+	out.Totals = total(out.Sessions)
+	return out, nil
+}
+
+func summarize(ctx context.Context, src Sources, sess tmux.Session, panes []tmux.Pane, active []alerts.Alert, now time.Time) SessionSummary {
+	s := SessionSummary{
+		Name:       sess.Name,
+		Attached:   sess.Attached,
+		AgentTypes: make(map[string]int),
+		States:     make(map[string]int),
+	}
+	var errs []string
+
+	byID := make(map[string]tmux.Pane, len(panes))
+	for _, p := range panes {
+		if p.Type == tmux.AgentUser {
+			continue
+		}
+		byID[p.ID] = p
+		s.Agents++
+		s.AgentTypes[string(p.Type)]++
+	}
+
+	seen := make(map[string]bool)
+	if src.Statuses != nil {
+		statuses, err := src.Statuses(ctx, sess.Name)
+		if err != nil {
+			errs = append(errs, "status: "+err.Error())
+		}
+		for _, st := range statuses {
+			p, ok := byID[st.PaneID]
+			if !ok {
+				continue
+			}
+			seen[st.PaneID] = true
+			state := string(st.State)
+			if st.State == status.StateError && st.ErrorType == status.ErrorRateLimit {
+				state = StateRateLimited
+			}
+			s.States[state]++
+			if st.State == status.StateIdle && p.Type == tmux.AgentClaude {
+				s.IdleClaude++
+			}
+			if st.ContextUsage > s.ContextMax {
+				s.ContextMax = st.ContextUsage
+			}
+			if st.ContextUsage >= ContextHotPercent {
+				s.ContextHot++
+			}
+		}
+	}
+	if unknown := s.Agents - len(seen); unknown > 0 {
+		s.States[string(status.StateUnknown)] += unknown
+	}
+
+	for _, a := range active {
+		s.Alerts++
+		if a.Severity == alerts.SeverityCritical || a.Severity == alerts.SeverityError {
+			s.CriticalAlerts++
+		}
+	}
+
+	if src.ProjectDir != nil {
+		s.ProjectDir = src.ProjectDir(sess.Name)
+	}
+	if src.Pipelines != nil && s.ProjectDir != "" {
+		runs, err := src.Pipelines(s.ProjectDir)
+		if err != nil {
+			errs = append(errs, "pipelines: "+err.Error())
+		}
+		for _, r := range runs {
+			if r.Session != "" && r.Session != sess.Name {
+				continue
+			}
+			s.PipelinesTotal++
+			switch r.Status {
+			case pipeline.StatusRunning, pipeline.StatusPending, pipeline.StatusPaused:
+				s.PipelinesRunning++
+			case pipeline.StatusFailed:
+				s.PipelinesFailed++
+			}
+		}
+	}
+
+	if src.BeadCompletions != nil {
+		times, err := src.BeadCompletions(sess.Name)
+		if err != nil {
+			errs = append(errs, "beads: "+err.Error())
+		}
+		for _, t := range times {
+			age := now.Sub(t)
+			if age < 0 || age > 24*time.Hour {
+				continue
+			}
+			s.BeadsCompletedDay++
+			if age <= time.Hour {
+				s.BeadsCompletedHour++
+			}
+		}
+	}
+
+	if src.Prompts != nil {
+		history, err := src.Prompts(sess.Name)
+		if err != nil {
+			errs = append(errs, "prompts: "+err.Error())
+		} else {
+			s.CostUSD = estimatePromptCost(history, panes, src.Model)
+		}
+	}
+
+	if src.SendPause != nil {
+		if pause, err := src.SendPause(sess.Name); err != nil {
+			errs = append(errs, "pause: "+err.Error())
+		} else if pause != nil {
+			s.SendsPaused = true
+			s.PauseReason = pause.Reason
+		}
+	}
+
+	s.Error = strings.Join(errs, "; ")
+	return s
+}
+
+// estimatePromptCost prices the prompts sent to a session at each target
+// pane's input rate. Output tokens are not visible from here, so this is a
+// lower bound on spend, consistent across sessions for comparison.
+func estimatePromptCost(history *session.PromptHistory, panes []tmux.Pane, model func(tmux.Pane) string) float64 {
+	if history == nil {
+		return 0
+	}
+	byIndex := make(map[int]tmux.Pane, len(panes))
+	var agents []tmux.Pane
+	for _, p := range panes {
+		if p.Type == tmux.AgentUser {
+			continue
+		}
+		byIndex[p.Index] = p
+		agents = append(agents, p)
+	}
+	rate := func(p tmux.Pane) float64 {
+		name := p.Variant
+		if model != nil {
+			name = model(p)
+		}
+		return cost.GetModelPricing(name).InputPer1K
+	}
+
+	var usd float64
+	for _, entry := range history.Prompts {
+		tokens := float64(cost.EstimateTokens(entry.Content)) / 1000.0
+		if tokens <= 0 {
+			continue
+		}
+		for _, target := range entry.Targets {
+			target = strings.TrimSpace(target)
+			if strings.EqualFold(target, "all") {
+				for _, p := range agents {
+					usd += tokens * rate(p)
+				}
+				continue
+			}
+			idx, err := strconv.Atoi(target)
+			if err != nil {
+				continue
+			}
+			if p, ok := byIndex[idx]; ok {
+				usd += tokens * rate(p)
+			}
+		}
+	}
+	return usd
+}
+
+func countAgents(panes []tmux.Pane) int {
+	n := 0
+	for _, p := range panes {
+		if p.Type != tmux.AgentUser {
+			n++
+		}
+	}
+	return n
+}
+
+func total(sessions []SessionSummary) Totals {
+	t := Totals{Sessions: len(sessions), States: make(map[string]int)}
+	for _, s := range sessions {
+		t.Agents += s.Agents
+		for k, v := range s.States {
+			t.States[k] += v
+		}
+		t.IdleClaude += s.IdleClaude
+		t.ContextHot += s.ContextHot
+		t.CostUSD += s.CostUSD
+		t.Alerts += s.Alerts
+		t.CriticalAlerts += s.CriticalAlerts
+		t.PipelinesRunning += s.PipelinesRunning
+		t.BeadsCompletedHour += s.BeadsCompletedHour
+		t.BeadsCompletedDay += s.BeadsCompletedDay
+		if s.SendsPaused {
+			t.SessionsPaused++
+		}
+	}
+	return t
+}
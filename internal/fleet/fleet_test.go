@@ -0,0 +1,175 @@
+package fleet
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/alerts"
+	"github.com/shahbajlive/ntm/internal/pipeline"
+	"github.com/shahbajlive/ntm/internal/session"
+	"github.com/shahbajlive/ntm/internal/status"
+	"github.com/shahbajlive/ntm/internal/tmux"
+)
+
+func testSources(now time.Time) Sources {
+	panes := map[string][]tmux.Pane{
+		"alpha": {
+			{ID: "%0", Index: 0, Type: tmux.AgentUser},
+			{ID: "%1", Index: 1, Type: tmux.AgentClaude, Variant: "opus"},
+			{ID: "%2", Index: 2, Type: tmux.AgentClaude},
+			{ID: "%3", Index: 3, Type: tmux.AgentCodex},
+		},
+		"beta": {
+			{ID: "%4", Index: 1, Type: tmux.AgentGemini},
+		},
+		"shell": {
+			{ID: "%5", Index: 0, Type: tmux.AgentUser},
+		},
+	}
+	resolved := now.Add(-time.Minute)
+
+	return Sources{
+		Sessions: func() ([]tmux.Session, error) {
+			return []tmux.Session{{Name: "shell"}, {Name: "beta"}, {Name: "alpha", Attached: true}}, nil
+		},
+		Panes: func(context.Context) (map[string][]tmux.Pane, error) { return panes, nil },
+		Statuses: func(_ context.Context, name string) ([]status.AgentStatus, error) {
+			switch name {
+			case "alpha":
+				return []status.AgentStatus{
+					{PaneID: "%0", State: status.StateIdle},
+					{PaneID: "%1", State: status.StateIdle, ContextUsage: 91},
+					{PaneID: "%2", State: status.StateWorking, ContextUsage: 40},
+					{PaneID: "%3", State: status.StateError, ErrorType: status.ErrorRateLimit},
+				}, nil
+			default:
+				return nil, errors.New("capture timed out")
+			}
+		},
+		Model:      func(p tmux.Pane) string { return "claude-sonnet-4-20250514" },
+		ProjectDir: func(name string) string { return "/work/" + name },
+		Alerts: func() []alerts.Alert {
+			return []alerts.Alert{
+				{Session: "alpha", Severity: alerts.SeverityCritical},
+				{Session: "alpha", Severity: alerts.SeverityWarning},
+				{Session: "alpha", Severity: alerts.SeverityCritical, ResolvedAt: &resolved},
+				{Severity: alerts.SeverityWarning},
+			}
+		},
+		Pipelines: func(dir string) ([]*pipeline.ExecutionState, error) {
+			if dir != "/work/alpha" {
+				return nil, nil
+			}
+			return []*pipeline.ExecutionState{
+				{RunID: "a", Session: "alpha", Status: pipeline.StatusRunning},
+				{RunID: "b", Session: "alpha", Status: pipeline.StatusFailed},
+				{RunID: "c", Session: "other", Status: pipeline.StatusRunning},
+			}, nil
+		},
+		BeadCompletions: func(name string) ([]time.Time, error) {
+			if name != "alpha" {
+				return nil, nil
+			}
+			return []time.Time{now.Add(-10 * time.Minute), now.Add(-5 * time.Hour), now.Add(-48 * time.Hour)}, nil
+		},
+		Prompts: func(name string) (*session.PromptHistory, error) {
+			return &session.PromptHistory{Prompts: []session.PromptEntry{
+				{Content: strings.Repeat("x", 4000), Targets: []string{"all"}},
+			}}, nil
+		},
+		SendPause: func(name string) (*session.SendPause, error) {
+			if name == "beta" {
+				return &session.SendPause{Session: name, Reason: PauseReason}, nil
+			}
+			return nil, nil
+		},
+		Now: func() time.Time { return now },
+	}
+}
+
+func TestCollect(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	ov, err := Collect(context.Background(), testSources(now))
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if len(ov.Sessions) != 2 || ov.Sessions[0].Name != "alpha" || ov.Sessions[1].Name != "beta" {
+		t.Fatalf("sessions = %+v, want alpha and beta (shell has no agents)", ov.Sessions)
+	}
+
+	alpha := ov.Find("alpha")
+	if alpha.Agents != 3 || alpha.AgentTypes["cc"] != 2 || alpha.AgentTypes["cod"] != 1 {
+		t.Errorf("alpha agents = %d %v", alpha.Agents, alpha.AgentTypes)
+	}
+	if alpha.States["idle"] != 1 || alpha.States["working"] != 1 || alpha.States[StateRateLimited] != 1 {
+		t.Errorf("alpha states = %v", alpha.States)
+	}
+	if alpha.IdleClaude != 1 || alpha.ContextMax != 91 || alpha.ContextHot != 1 {
+		t.Errorf("alpha idle cc = %d, context max = %v, hot = %d", alpha.IdleClaude, alpha.ContextMax, alpha.ContextHot)
+	}
+	if alpha.Alerts != 2 || alpha.CriticalAlerts != 1 {
+		t.Errorf("alpha alerts = %d (critical %d), want 2 (1)", alpha.Alerts, alpha.CriticalAlerts)
+	}
+	if alpha.PipelinesTotal != 2 || alpha.PipelinesRunning != 1 || alpha.PipelinesFailed != 1 {
+		t.Errorf("alpha pipelines = %d/%d/%d", alpha.PipelinesTotal, alpha.PipelinesRunning, alpha.PipelinesFailed)
+	}
+	if alpha.BeadsCompletedHour != 1 || alpha.BeadsCompletedDay != 2 {
+		t.Errorf("alpha beads = %d/h %d/day", alpha.BeadsCompletedHour, alpha.BeadsCompletedDay)
+	}
+	if alpha.CostUSD <= 0 {
+		t.Errorf("alpha cost = %v, want > 0", alpha.CostUSD)
+	}
+
+	beta := ov.Find("beta")
+	if beta.States["unknown"] != 1 || !strings.Contains(beta.Error, "capture timed out") {
+		t.Errorf("beta = %+v, want unknown state and status error", beta)
+	}
+	if !beta.SendsPaused || beta.PauseReason != PauseReason {
+		t.Errorf("beta pause = %v %q", beta.SendsPaused, beta.PauseReason)
+	}
+
+	tot := ov.Totals
+	if tot.Sessions != 2 || tot.Agents != 4 || tot.SessionsPaused != 1 || tot.IdleClaude != 1 || tot.Alerts != 2 {
+		t.Errorf("totals = %+v", tot)
+	}
+	if math.Abs(tot.CostUSD-(alpha.CostUSD+beta.CostUSD)) > 1e-9 {
+		t.Errorf("total cost = %v, want %v", tot.CostUSD, alpha.CostUSD+beta.CostUSD)
+	}
+}
+
+func TestEstimatePromptCost(t *testing.T) {
+	t.Parallel()
+	panes := []tmux.Pane{
+		{Index: 0, Type: tmux.AgentUser},
+		{Index: 1, Type: tmux.AgentClaude},
+		{Index: 2, Type: tmux.AgentClaude},
+	}
+	model := func(tmux.Pane) string { return "claude-sonnet-4-20250514" }
+	prompt := strings.Repeat("word ", 800)
+
+	one := estimatePromptCost(&session.PromptHistory{Prompts: []session.PromptEntry{
+		{Content: prompt, Targets: []string{"1"}},
+	}}, panes, model)
+	all := estimatePromptCost(&session.PromptHistory{Prompts: []session.PromptEntry{
+		{Content: prompt, Targets: []string{"all"}},
+	}}, panes, model)
+	ignored := estimatePromptCost(&session.PromptHistory{Prompts: []session.PromptEntry{
+		{Content: prompt, Targets: []string{"0", "9", "x"}},
+	}}, panes, model)
+
+	if one <= 0 || math.Abs(all-2*one) > 1e-9 {
+		t.Errorf("cost one = %v, all = %v; want all == 2*one > 0", one, all)
+	}
+	if ignored != 0 {
+		t.Errorf("cost for user/missing/invalid targets = %v, want 0", ignored)
+	}
+	if estimatePromptCost(nil, panes, model) != 0 {
+		t.Error("nil history should cost nothing")
+	}
+}
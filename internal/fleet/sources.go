@@ -0,0 +1,92 @@
+package fleet
+
+import (
+	"context"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/alerts"
+	"github.com/shahbajlive/ntm/internal/config"
+	"github.com/shahbajlive/ntm/internal/pipeline"
+	"github.com/shahbajlive/ntm/internal/session"
+	"github.com/shahbajlive/ntm/internal/state"
+	"github.com/shahbajlive/ntm/internal/status"
+	"github.com/shahbajlive/ntm/internal/tmux"
+)
+
+// beadHistoryLimit bounds the completed-bead rows read per session; it only
+// needs to cover a day of throughput.
+const beadHistoryLimit = 1000
+
+// DefaultSources wires the collector to tmux, the status detector, the alert
+// generator, persisted pipeline runs, prompt history and the state store.
+// cfg and store may be nil; the sources that need them are then skipped.
+func DefaultSources(cfg *config.Config, store *state.Store) Sources {
+	detector := status.NewDetector()
+	src := Sources{
+		Sessions: tmux.ListSessions,
+		Panes: func(ctx context.Context) (map[string][]tmux.Pane, error) {
+			return tmux.DefaultClient.GetAllPanesContext(ctx)
+		},
+		Statuses:  detector.DetectAllContext,
+		Model:     func(p tmux.Pane) string { return PaneModel(cfg, p) },
+		Alerts:    func() []alerts.Alert { return alerts.GetActiveAlerts(alertConfig(cfg)) },
+		Pipelines: pipeline.ListStates,
+		Prompts:   session.LoadPromptHistory,
+		SendPause: session.SendsPaused,
+		Now:       time.Now,
+	}
+	if cfg != nil {
+		src.ProjectDir = cfg.GetProjectDir
+	}
+	if store != nil {
+		src.BeadCompletions = func(name string) ([]time.Time, error) {
+			entries, err := store.GetBeadHistoryByStatus(name, state.BeadStatusCompleted, beadHistoryLimit)
+			if err != nil {
+				return nil, err
+			}
+			times := make([]time.Time, 0, len(entries))
+			for _, e := range entries {
+				times = append(times, e.TransitionAt)
+			}
+			return times, nil
+		}
+	}
+	return src
+}
+
+// PaneModel resolves the model a pane runs: its title variant, else the
+// configured default for its agent type.
+func PaneModel(cfg *config.Config, p tmux.Pane) string {
+	if p.Variant != "" {
+		return p.Variant
+	}
+	models := config.DefaultModels()
+	if cfg != nil {
+		models = cfg.Models
+	}
+	switch p.Type {
+	case tmux.AgentClaude:
+		return models.DefaultClaude
+	case tmux.AgentCodex:
+		return models.DefaultCodex
+	case tmux.AgentGemini:
+		return models.DefaultGemini
+	default:
+		return ""
+	}
+}
+
+func alertConfig(cfg *config.Config) alerts.Config {
+	if cfg == nil {
+		return alerts.DefaultConfig()
+	}
+	return alerts.ToConfigAlerts(
+		cfg.Alerts.Enabled,
+		cfg.Alerts.AgentStuckMinutes,
+		cfg.Alerts.DiskLowThresholdGB,
+		cfg.Alerts.MailBacklogThreshold,
+		cfg.Alerts.BeadStaleHours,
+		cfg.Alerts.ResolvedPruneMinutes,
+		cfg.ProjectsBase,
+	)
+}
@@ -0,0 +1,121 @@
+package headless
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// serverStartTimeout bounds how long Client waits for a server it started.
+const serverStartTimeout = 5 * time.Second
+
+// Client is a tmux.Backend that forwards commands to a headless server,
+// starting one on demand.
+type Client struct {
+	socket string
+	// serverCmd is the argv that runs a server on socket, e.g.
+	// ntm headless-server --socket <path>. Nil disables auto-start.
+	serverCmd []string
+}
+
+// NewClient returns a client for the server at socket. When serverCmd is
+// non-empty and a command needs a server that is not running (new-session),
+// the client starts serverCmd detached from the calling process.
+func NewClient(socket string, serverCmd ...string) *Client {
+	return &Client{socket: socket, serverCmd: serverCmd}
+}
+
+// Name implements tmux.Backend.
+func (c *Client) Name() string { return BackendName }
+
+// Socket returns the server socket path.
+func (c *Client) Socket() string { return c.socket }
+
+// Available reports why headless panes cannot run on this system, or nil.
+func Available() error { return ptyAvailable() }
+
+// Run implements tmux.Backend.
+func (c *Client) Run(ctx context.Context, args ...string) (string, error) {
+	conn, err := c.dial(ctx)
+	if err != nil && len(args) > 0 && (args[0] == "new-session" || args[0] == "new") && len(c.serverCmd) > 0 {
+		if startErr := c.startServer(); startErr != nil {
+			return "", startErr
+		}
+		conn, err = c.dialRetry(ctx, serverStartTimeout)
+	}
+	if err != nil {
+		// Mirrors tmux, whose callers treat this as "no sessions".
+		return "", fmt.Errorf("no server running on %s", c.socket)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if err := json.NewEncoder(conn).Encode(request{Args: args}); err != nil {
+		return "", fmt.Errorf("headless %s: %w", strings.Join(args, " "), err)
+	}
+	var resp response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", ctxErr
+		}
+		return "", fmt.Errorf("headless %s: %w", strings.Join(args, " "), err)
+	}
+	if resp.Error != "" {
+		return "", fmt.Errorf("headless %s: %s", strings.Join(args, " "), resp.Error)
+	}
+	return resp.Output, nil
+}
+
+func (c *Client) dial(ctx context.Context) (net.Conn, error) {
+	var d net.Dialer
+	return d.DialContext(ctx, "unix", c.socket)
+}
+
+func (c *Client) dialRetry(ctx context.Context, timeout time.Duration) (net.Conn, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		conn, err := c.dial(ctx)
+		if err == nil || time.Now().After(deadline) {
+			return conn, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// startServer launches the server in its own session so it outlives the
+// ntm command that started it. Its output goes to server.log beside the
+// socket.
+func (c *Client) startServer() error {
+	if err := os.MkdirAll(filepath.Dir(c.socket), 0o700); err != nil {
+		return fmt.Errorf("create socket dir: %w", err)
+	}
+	logFile, err := os.OpenFile(filepath.Join(filepath.Dir(c.socket), "server.log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("open headless server log: %w", err)
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(c.serverCmd[0], c.serverCmd[1:]...)
+	cmd.Stdout, cmd.Stderr = logFile, logFile
+	cmd.Env = append(os.Environ(), SocketEnv+"="+c.socket)
+	setDetached(cmd)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start headless server: %w", err)
+	}
+	// Reap the child if it exits early (e.g. another server won the race);
+	// otherwise it runs on after this process exits.
+	go func() { _ = cmd.Wait() }()
+	return nil
+}
@@ -0,0 +1,1203 @@
+// Package headless runs ntm agent panes without tmux.
+//
+// A Host keeps sessions of panes, each a child process attached to a
+// pseudo-terminal whose output is rendered by a virtual Terminal with
+// scrollback. The Host answers the subset of tmux commands that
+// tmux.Client issues (new-session, split-window, list-panes -F, send-keys,
+// paste-buffer, capture-pane, respawn-pane, ...) with tmux-compatible output,
+// so it can stand in as a tmux.Backend. Because ntm runs as a series of
+// short-lived commands, the Host normally lives in a background server
+// process reached over a unix socket (see Server and Client).
+package headless
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default pane geometry and scrollback, matching a detached tmux session.
+const (
+	DefaultWidth        = 200
+	DefaultHeight       = 50
+	DefaultHistoryLimit = 2000
+)
+
+// BackendName is the name reported by the headless tmux.Backend.
+const BackendName = "pty"
+
+// Host owns headless sessions and executes tmux commands against them.
+type Host struct {
+	mu       sync.Mutex
+	sessions []*session
+	buffers  map[string]string
+	nextPane int
+	hadAny   bool
+
+	shell         string
+	env           []string
+	width, height int
+	onIdle        func()
+}
+
+type session struct {
+	name         string
+	dir          string
+	created      time.Time
+	historyLimit int
+	remainOnExit bool
+	panes        []*pane
+}
+
+type pane struct {
+	id      int
+	session *session
+	title   string
+	dir     string
+	argv    []string
+	active  bool
+	term    *Terminal
+
+	proc         *process
+	dead         bool
+	lastActivity time.Time
+	pipe         *exec.Cmd
+	pipeIn       io.WriteCloser
+}
+
+type process struct {
+	cmd    *exec.Cmd
+	master *os.File
+	done   chan struct{}
+}
+
+// HostOption configures a Host.
+type HostOption func(*Host)
+
+// WithShell sets the program panes run when no command is given.
+// Defaults to $SHELL, falling back to /bin/sh.
+func WithShell(shell string) HostOption {
+	return func(h *Host) { h.shell = shell }
+}
+
+// WithEnv adds environment variables (KEY=value) to every pane.
+func WithEnv(env ...string) HostOption {
+	return func(h *Host) { h.env = append(h.env, env...) }
+}
+
+// WithSize sets the default pane size.
+func WithSize(width, height int) HostOption {
+	return func(h *Host) { h.width, h.height = width, height }
+}
+
+// WithIdleHandler sets a callback invoked when the last session goes away.
+func WithIdleHandler(fn func()) HostOption {
+	return func(h *Host) { h.onIdle = fn }
+}
+
+// NewHost creates an empty host.
+func NewHost(opts ...HostOption) *Host {
+	h := &Host{
+		buffers: make(map[string]string),
+		shell:   os.Getenv("SHELL"),
+		width:   DefaultWidth,
+		height:  DefaultHeight,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.shell == "" {
+		h.shell = "/bin/sh"
+	}
+	return h
+}
+
+// Name implements tmux.Backend.
+func (h *Host) Name() string { return BackendName }
+
+// Close kills every pane and removes all sessions.
+func (h *Host) Close() {
+	h.mu.Lock()
+	var panes []*pane
+	for _, s := range h.sessions {
+		panes = append(panes, s.panes...)
+	}
+	h.sessions = nil
+	h.mu.Unlock()
+	h.killPanes(panes...)
+}
+
+// Run implements tmux.Backend by executing one tmux command.
+func (h *Host) Run(ctx context.Context, args ...string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if len(args) == 0 {
+		return "", errors.New("no command given")
+	}
+	name := args[0]
+	if name == "-V" {
+		return "tmux 3.4 (ntm headless)", nil
+	}
+	if alias, ok := commandAliases[name]; ok {
+		name = alias
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		return "", fmt.Errorf("unknown command: %s", name)
+	}
+	return cmd.run(h, parseArgs(args[1:], cmd.valueFlags))
+}
+
+// cmdArgs holds parsed tmux command arguments.
+type cmdArgs struct {
+	flags map[byte]string
+	rest  []string
+}
+
+func (a cmdArgs) has(f byte) bool {
+	_, ok := a.flags[f]
+	return ok
+}
+
+func (a cmdArgs) get(f byte) string { return a.flags[f] }
+
+// parseArgs parses getopt-style flags; letters in valueFlags take an argument.
+func parseArgs(args []string, valueFlags string) cmdArgs {
+	a := cmdArgs{flags: make(map[byte]string)}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			a.rest = append(a.rest, args[i+1:]...)
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			a.rest = append(a.rest, args[i:]...)
+			break
+		}
+		for j := 1; j < len(arg); j++ {
+			f := arg[j]
+			if strings.IndexByte(valueFlags, f) < 0 {
+				a.flags[f] = ""
+				continue
+			}
+			if j+1 < len(arg) {
+				a.flags[f] = arg[j+1:]
+			} else if i+1 < len(args) {
+				i++
+				a.flags[f] = args[i]
+			} else {
+				a.flags[f] = ""
+			}
+			break
+		}
+	}
+	return a
+}
+
+type command struct {
+	valueFlags string
+	run        func(h *Host, a cmdArgs) (string, error)
+}
+
+var commands map[string]command
+
+var commandAliases = map[string]string{
+	"new":      "new-session",
+	"ls":       "list-sessions",
+	"has":      "has-session",
+	"splitw":   "split-window",
+	"lsp":      "list-panes",
+	"selectp":  "select-pane",
+	"killp":    "kill-pane",
+	"respawnp": "respawn-pane",
+	"display":  "display-message",
+	"send":     "send-keys",
+	"setb":     "set-buffer",
+	"showb":    "show-buffer",
+	"deleteb":  "delete-buffer",
+	"pasteb":   "paste-buffer",
+	"capturep": "capture-pane",
+	"set":      "set-option",
+	"setw":     "set-window-option",
+}
+
+func init() {
+	commands = map[string]command{
+		"has-session":     {"t", (*Host).hasSession},
+		"new-session":     {"scnxyFte", (*Host).newSession},
+		"list-sessions":   {"Ff", (*Host).listSessions},
+		"kill-session":    {"t", (*Host).killSession},
+		"kill-server":     {"", (*Host).killServer},
+		"list-windows":    {"tFf", (*Host).listWindows},
+		"split-window":    {"tcFlpe", (*Host).splitWindow},
+		"list-panes":      {"tFf", (*Host).listPanes},
+		"select-pane":     {"tTP", (*Host).selectPane},
+		"kill-pane":       {"t", (*Host).killPane},
+		"respawn-pane":    {"tce", (*Host).respawnPane},
+		"display-message": {"tdFc", (*Host).displayMessage},
+		"send-keys":       {"tN", (*Host).sendKeys},
+		"set-buffer":      {"bnt", (*Host).setBuffer},
+		"show-buffer":     {"b", (*Host).showBuffer},
+		"delete-buffer":   {"b", (*Host).deleteBuffer},
+		"paste-buffer":    {"bst", (*Host).pasteBuffer},
+		"capture-pane":    {"tSEb", (*Host).capturePane},
+		"pipe-pane":       {"t", (*Host).pipePane},
+		"set-option":      {"t", (*Host).setOption},
+
+		// Layout and presentation have no meaning without a display.
+		"set-window-option": {"t", noop},
+		"select-layout":     {"t", noop},
+		"resize-pane":       {"txy", noop},
+		"resize-window":     {"txy", noop},
+		"rename-window":     {"t", noop},
+		"refresh-client":    {"t", noop},
+		"set-hook":          {"t", noop},
+		"bind-key":          {"T", noop},
+		"unbind-key":        {"T", noop},
+		"set-environment":   {"t", noop},
+		"source-file":       {"", noop},
+
+		"attach-session": {"t", unsupported("attach-session")},
+		"switch-client":  {"t", unsupported("switch-client")},
+		"new-window":     {"t", unsupported("new-window")},
+	}
+}
+
+func noop(*Host, cmdArgs) (string, error) { return "", nil }
+
+func unsupported(name string) func(*Host, cmdArgs) (string, error) {
+	return func(*Host, cmdArgs) (string, error) {
+		return "", fmt.Errorf("%s: not supported by the %s backend", name, BackendName)
+	}
+}
+
+// --- sessions ---
+
+func (h *Host) hasSession(a cmdArgs) (string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.findSession(a.get('t'))
+	return "", err
+}
+
+func (h *Host) newSession(a cmdArgs) (string, error) {
+	name := a.get('s')
+	if name == "" {
+		h.mu.Lock()
+		name = strconv.Itoa(len(h.sessions))
+		h.mu.Unlock()
+	}
+	if strings.ContainsAny(name, ":.") {
+		return "", fmt.Errorf("invalid session name: %s", name)
+	}
+	width, height := h.width, h.height
+	if v, err := strconv.Atoi(a.get('x')); err == nil && v > 0 {
+		width = v
+	}
+	if v, err := strconv.Atoi(a.get('y')); err == nil && v > 0 {
+		height = v
+	}
+
+	h.mu.Lock()
+	if _, err := h.findSession(name); err == nil {
+		h.mu.Unlock()
+		return "", fmt.Errorf("duplicate session: %s", name)
+	}
+	s := &session{name: name, dir: a.get('c'), created: time.Now(), historyLimit: DefaultHistoryLimit}
+	if s.dir == "" {
+		s.dir, _ = os.Getwd()
+	}
+	h.sessions = append(h.sessions, s)
+	h.hadAny = true
+	p := h.addPane(s, s.dir, a.rest)
+	h.mu.Unlock()
+
+	if err := h.start(p, width, height); err != nil {
+		h.removeSession(s)
+		return "", err
+	}
+	if a.has('P') {
+		return h.formatPane(p, a.get('F'), "#{session_name}:"), nil
+	}
+	return "", nil
+}
+
+func (h *Host) listSessions(a cmdArgs) (string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	format := a.get('F')
+	if format == "" {
+		format = "#{session_name}: #{session_windows} windows (created #{session_created_string})"
+	}
+	var lines []string
+	for _, s := range h.sessions {
+		vars := h.sessionVars(s)
+		if f := a.get('f'); f != "" && !truthy(expand(f, vars)) {
+			continue
+		}
+		lines = append(lines, expand(format, vars))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func (h *Host) killSession(a cmdArgs) (string, error) {
+	h.mu.Lock()
+	s, err := h.findSession(a.get('t'))
+	h.mu.Unlock()
+	if err != nil {
+		return "", err
+	}
+	h.removeSession(s)
+	return "", nil
+}
+
+func (h *Host) killServer(cmdArgs) (string, error) {
+	h.Close()
+	h.notifyIdle()
+	return "", nil
+}
+
+func (h *Host) listWindows(a cmdArgs) (string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, err := h.findSession(a.get('t'))
+	if err != nil {
+		return "", err
+	}
+	p := activePane(s)
+	if p == nil {
+		return "", nil
+	}
+	format := a.get('F')
+	if format == "" {
+		format = "#{window_index}: #{window_name} (#{session_windows} panes)"
+	}
+	return expand(format, h.paneVars(p)), nil
+}
+
+// --- panes ---
+
+func (h *Host) splitWindow(a cmdArgs) (string, error) {
+	h.mu.Lock()
+	target, err := h.findPane(a.get('t'))
+	if err != nil {
+		h.mu.Unlock()
+		return "", err
+	}
+	s := target.session
+	dir := a.get('c')
+	if dir == "" {
+		dir = target.dir
+	}
+	p := h.addPane(s, dir, a.rest)
+	if !a.has('d') {
+		setActive(s, p)
+	}
+	h.mu.Unlock()
+
+	if err := h.start(p, h.width, h.height); err != nil {
+		h.removePane(p)
+		return "", err
+	}
+	if a.has('P') {
+		return h.formatPane(p, a.get('F'), "#{session_name}:#{window_index}.#{pane_index}"), nil
+	}
+	return "", nil
+}
+
+func (h *Host) listPanes(a cmdArgs) (string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var panes []*pane
+	switch {
+	case a.has('a'):
+		for _, s := range h.sessions {
+			panes = append(panes, s.panes...)
+		}
+	default:
+		// With a single window per session, -s and a window target list the
+		// same panes.
+		s, err := h.findSession(a.get('t'))
+		if err != nil {
+			return "", err
+		}
+		panes = s.panes
+	}
+	format := a.get('F')
+	if format == "" {
+		format = "#{pane_index}: [#{pane_width}x#{pane_height}] #{pane_id}"
+	}
+	lines := make([]string, 0, len(panes))
+	for _, p := range panes {
+		lines = append(lines, expand(format, h.paneVars(p)))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func (h *Host) selectPane(a cmdArgs) (string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	p, err := h.findPane(a.get('t'))
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case a.has('T'):
+		p.title = a.get('T')
+	case a.has('P'):
+		// Pane styles are cosmetic.
+	default:
+		setActive(p.session, p)
+	}
+	return "", nil
+}
+
+func (h *Host) killPane(a cmdArgs) (string, error) {
+	h.mu.Lock()
+	p, err := h.findPane(a.get('t'))
+	h.mu.Unlock()
+	if err != nil {
+		return "", err
+	}
+	h.removePane(p)
+	return "", nil
+}
+
+func (h *Host) respawnPane(a cmdArgs) (string, error) {
+	h.mu.Lock()
+	p, err := h.findPane(a.get('t'))
+	if err != nil {
+		h.mu.Unlock()
+		return "", err
+	}
+	if !p.dead && !a.has('k') {
+		h.mu.Unlock()
+		return "", fmt.Errorf("pane %%%d still active", p.id)
+	}
+	if dir := a.get('c'); dir != "" {
+		p.dir = dir
+	}
+	if len(a.rest) > 0 {
+		p.argv = a.rest
+	}
+	old := p.proc
+	p.proc = nil // Detach so the exit handler does not remove the pane.
+	h.mu.Unlock()
+
+	if old != nil {
+		old.kill()
+		<-old.done
+	}
+	p.term.Reset()
+	width, height := p.term.Size()
+	return "", h.start(p, width, height)
+}
+
+func (h *Host) displayMessage(a cmdArgs) (string, error) {
+	if !a.has('p') {
+		return "", nil // Status-line messages have nowhere to go.
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	target := a.get('t')
+	if target == "" {
+		return "", errors.New("no current client")
+	}
+	p, err := h.findPane(target)
+	if err != nil {
+		return "", err
+	}
+	return expand(strings.Join(a.rest, " "), h.paneVars(p)), nil
+}
+
+// --- input ---
+
+func (h *Host) sendKeys(a cmdArgs) (string, error) {
+	p, err := h.lockedPane(a.get('t'))
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, k := range a.rest {
+		if a.has('l') {
+			b.WriteString(k)
+		} else {
+			b.WriteString(keyBytes(k))
+		}
+	}
+	return "", h.writePane(p, []byte(b.String()))
+}
+
+func (h *Host) setBuffer(a cmdArgs) (string, error) {
+	if len(a.rest) == 0 {
+		return "", errors.New("no data specified")
+	}
+	name := a.get('b')
+	if name == "" {
+		name = "buffer0"
+	}
+	h.mu.Lock()
+	h.buffers[name] = a.rest[0]
+	h.mu.Unlock()
+	return "", nil
+}
+
+func (h *Host) showBuffer(a cmdArgs) (string, error) {
+	name := a.get('b')
+	if name == "" {
+		name = "buffer0"
+	}
+	h.mu.Lock()
+	content, ok := h.buffers[name]
+	h.mu.Unlock()
+	if !ok {
+		return "", fmt.Errorf("no buffer %s", name)
+	}
+	return content, nil
+}
+
+func (h *Host) deleteBuffer(a cmdArgs) (string, error) {
+	h.mu.Lock()
+	delete(h.buffers, a.get('b'))
+	h.mu.Unlock()
+	return "", nil
+}
+
+func (h *Host) pasteBuffer(a cmdArgs) (string, error) {
+	name := a.get('b')
+	if name == "" {
+		name = "buffer0"
+	}
+	h.mu.Lock()
+	content, ok := h.buffers[name]
+	if ok && a.has('d') {
+		delete(h.buffers, name)
+	}
+	p, err := h.findPane(a.get('t'))
+	h.mu.Unlock()
+	if !ok {
+		return "", fmt.Errorf("no buffer %s", name)
+	}
+	if err != nil {
+		return "", err
+	}
+	if !a.has('r') {
+		content = strings.ReplaceAll(content, "\n", "\r")
+	}
+	if a.has('p') && p.term.BracketedPaste() {
+		content = "\x1b[200~" + content + "\x1b[201~"
+	}
+	return "", h.writePane(p, []byte(content))
+}
+
+// keyBytes translates a tmux key name into the bytes a terminal sends.
+// Strings that are not key names are sent as typed, as tmux does.
+func keyBytes(key string) string {
+	if seq, ok := namedKeys[key]; ok {
+		return seq
+	}
+	switch {
+	case len(key) == 3 && (strings.HasPrefix(key, "C-") || strings.HasPrefix(key, "^-")):
+		c := key[2]
+		if c >= 'A' && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		if c >= 'a' && c <= 'z' || c >= '@' && c <= '_' {
+			return string([]byte{c & 0x1f})
+		}
+	case len(key) == 2 && key[0] == '^':
+		return string([]byte{key[1] & 0x1f})
+	case strings.HasPrefix(key, "M-") && len(key) > 2:
+		return "\x1b" + keyBytes(key[2:])
+	}
+	return key
+}
+
+var namedKeys = map[string]string{
+	"Enter":    "\r",
+	"C-m":      "\r",
+	"C-j":      "\n",
+	"Tab":      "\t",
+	"BTab":     "\x1b[Z",
+	"Escape":   "\x1b",
+	"Space":    " ",
+	"BSpace":   "\x7f",
+	"Up":       "\x1b[A",
+	"Down":     "\x1b[B",
+	"Right":    "\x1b[C",
+	"Left":     "\x1b[D",
+	"Home":     "\x1b[H",
+	"End":      "\x1b[F",
+	"PageUp":   "\x1b[5~",
+	"PPage":    "\x1b[5~",
+	"PageDown": "\x1b[6~",
+	"NPage":    "\x1b[6~",
+	"IC":       "\x1b[2~",
+	"DC":       "\x1b[3~",
+}
+
+// --- output ---
+
+func (h *Host) capturePane(a cmdArgs) (string, error) {
+	p, err := h.lockedPane(a.get('t'))
+	if err != nil {
+		return "", err
+	}
+	lines := 0
+	switch start := a.get('S'); {
+	case start == "-":
+		lines = -1
+	case strings.HasPrefix(start, "-"):
+		n, err := strconv.Atoi(start[1:])
+		if err != nil {
+			return "", fmt.Errorf("invalid start line: %s", start)
+		}
+		lines = n
+	}
+	out := p.term.Capture(lines)
+	if !a.has('p') {
+		name := a.get('b')
+		if name == "" {
+			name = "buffer0"
+		}
+		h.mu.Lock()
+		h.buffers[name] = out
+		h.mu.Unlock()
+		return "", nil
+	}
+	return out + "\n", nil
+}
+
+func (h *Host) pipePane(a cmdArgs) (string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	p, err := h.findPane(a.get('t'))
+	if err != nil {
+		return "", err
+	}
+	p.stopPipe()
+	if len(a.rest) == 0 || a.rest[0] == "" {
+		return "", nil
+	}
+	cmd := exec.Command("/bin/sh", "-c", strings.Join(a.rest, " "))
+	cmd.Dir = p.dir
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return "", err
+	}
+	if err := cmd.Start(); err != nil {
+		return "", err
+	}
+	p.pipe, p.pipeIn = cmd, in
+	return "", nil
+}
+
+func (h *Host) setOption(a cmdArgs) (string, error) {
+	if len(a.rest) == 0 {
+		return "", nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, _ := h.findSession(a.get('t'))
+	option := a.rest[0]
+	value := ""
+	if len(a.rest) > 1 {
+		value = a.rest[1]
+	}
+	if s == nil {
+		return "", nil // Global and unknown-target options are accepted and ignored.
+	}
+	switch option {
+	case "history-limit":
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			s.historyLimit = n
+			for _, p := range s.panes {
+				p.term.SetHistoryLimit(n)
+			}
+		}
+	case "remain-on-exit":
+		s.remainOnExit = value == "on"
+	}
+	return "", nil
+}
+
+// --- pane lifecycle ---
+
+// addPane registers a new pane; h.mu must be held.
+func (h *Host) addPane(s *session, dir string, argv []string) *pane {
+	h.nextPane++
+	p := &pane{
+		id:           h.nextPane,
+		session:      s,
+		dir:          dir,
+		argv:         argv,
+		lastActivity: time.Now(),
+	}
+	if host, err := os.Hostname(); err == nil {
+		p.title = host
+	}
+	s.panes = append(s.panes, p)
+	if len(s.panes) == 1 {
+		p.active = true
+	}
+	return p
+}
+
+// start launches the pane's program on a fresh pseudo-terminal.
+func (h *Host) start(p *pane, width, height int) error {
+	h.mu.Lock()
+	argv := p.argv
+	if len(argv) == 0 {
+		argv = []string{h.shell}
+	} else {
+		argv = []string{"/bin/sh", "-c", strings.Join(argv, " ")}
+	}
+	if p.term == nil {
+		p.term = NewTerminal(width, height, p.session.historyLimit)
+	}
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Dir = p.dir
+	cmd.Env = paneEnv(os.Environ(), h.env, p.id)
+	h.mu.Unlock()
+
+	master, err := startPTY(cmd, width, height)
+	if err != nil {
+		return fmt.Errorf("start pane: %w", err)
+	}
+	proc := &process{cmd: cmd, master: master, done: make(chan struct{})}
+	p.term.SetResponder(func(b []byte) { _, _ = master.Write(b) })
+
+	h.mu.Lock()
+	p.proc = proc
+	p.dead = false
+	h.mu.Unlock()
+
+	go h.readLoop(p, proc)
+	return nil
+}
+
+// paneEnv builds a pane's environment: the server's own, minus any tmux
+// variables that would make ntm inside the pane talk to a real tmux server,
+// plus TERM, TMUX_PANE and the configured extras.
+func paneEnv(base, extra []string, id int) []string {
+	env := make([]string, 0, len(base)+len(extra)+2)
+	for _, kv := range base {
+		if strings.HasPrefix(kv, "TMUX=") || strings.HasPrefix(kv, "TMUX_PANE=") || strings.HasPrefix(kv, "TERM=") {
+			continue
+		}
+		env = append(env, kv)
+	}
+	env = append(env, "TERM=xterm-256color", "TMUX_PANE=%"+strconv.Itoa(id))
+	return append(env, extra...)
+}
+
+func (h *Host) readLoop(p *pane, proc *process) {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := proc.master.Read(buf)
+		if n > 0 {
+			_, _ = p.term.Write(buf[:n])
+			h.mu.Lock()
+			p.lastActivity = time.Now()
+			pipe := p.pipeIn
+			h.mu.Unlock()
+			if pipe != nil {
+				_, _ = pipe.Write(buf[:n])
+			}
+		}
+		if err != nil {
+			break // EIO once the last process holding the terminal exits.
+		}
+	}
+	_ = proc.cmd.Wait()
+	_ = proc.master.Close()
+	close(proc.done)
+
+	h.mu.Lock()
+	current := p.proc == proc
+	if current {
+		p.dead = true
+	}
+	remove := current && !p.session.remainOnExit
+	h.mu.Unlock()
+	if remove {
+		h.removePane(p)
+	}
+}
+
+// removePane kills the pane's program and drops it, and its session when it
+// was the last pane.
+func (h *Host) removePane(p *pane) {
+	h.mu.Lock()
+	s := p.session
+	idx := -1
+	for i, q := range s.panes {
+		if q == p {
+			idx = i
+			break
+		}
+	}
+	if idx >= 0 {
+		s.panes = append(s.panes[:idx], s.panes[idx+1:]...)
+		if p.active && len(s.panes) > 0 {
+			setActive(s, s.panes[min(idx, len(s.panes)-1)])
+		}
+	}
+	empty := len(s.panes) == 0
+	h.mu.Unlock()
+
+	h.killPanes(p)
+	if empty {
+		h.removeSession(s)
+	}
+}
+
+func (h *Host) removeSession(s *session) {
+	h.mu.Lock()
+	for i, q := range h.sessions {
+		if q == s {
+			h.sessions = append(h.sessions[:i], h.sessions[i+1:]...)
+			break
+		}
+	}
+	panes := s.panes
+	s.panes = nil
+	idle := h.hadAny && len(h.sessions) == 0
+	h.mu.Unlock()
+
+	h.killPanes(panes...)
+	if idle {
+		h.notifyIdle()
+	}
+}
+
+func (h *Host) notifyIdle() {
+	if h.onIdle != nil {
+		h.onIdle()
+	}
+}
+
+// killPanes stops the panes' output pipes and hangs up their programs.
+// The processes are detached first so their exit does not remove anything.
+func (h *Host) killPanes(panes ...*pane) {
+	h.mu.Lock()
+	var procs []*process
+	for _, p := range panes {
+		p.stopPipe()
+		if p.proc != nil {
+			procs = append(procs, p.proc)
+			p.proc = nil
+		}
+	}
+	h.mu.Unlock()
+	for _, proc := range procs {
+		proc.kill()
+	}
+}
+
+func (p *pane) stopPipe() {
+	if p.pipeIn != nil {
+		_ = p.pipeIn.Close()
+		go func(cmd *exec.Cmd) { _ = cmd.Wait() }(p.pipe)
+		p.pipe, p.pipeIn = nil, nil
+	}
+}
+
+func (proc *process) kill() {
+	select {
+	case <-proc.done:
+		return
+	default:
+	}
+	signalGroup(proc.cmd, false)
+	select {
+	case <-proc.done:
+	case <-time.After(2 * time.Second):
+		signalGroup(proc.cmd, true)
+	}
+}
+
+// writePane sends input to the pane's terminal.
+func (h *Host) writePane(p *pane, b []byte) error {
+	h.mu.Lock()
+	proc, dead := p.proc, p.dead
+	h.mu.Unlock()
+	if proc == nil || dead {
+		return fmt.Errorf("pane %%%d is dead", p.id)
+	}
+	_, err := proc.master.Write(b)
+	return err
+}
+
+// lockedPane resolves a target under the host lock.
+func (h *Host) lockedPane(target string) (*pane, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.findPane(target)
+}
+
+// --- targets ---
+
+// findSession resolves a session target ("name", "=name", "name:0.1" or
+// "%3"); h.mu must be held.
+func (h *Host) findSession(target string) (*session, error) {
+	if strings.HasPrefix(target, "%") {
+		p, err := h.findPane(target)
+		if err != nil {
+			return nil, err
+		}
+		return p.session, nil
+	}
+	name := strings.TrimPrefix(target, "=")
+	if i := strings.IndexByte(name, ':'); i >= 0 {
+		name = name[:i]
+	}
+	if name == "" {
+		if len(h.sessions) > 0 {
+			return h.sessions[len(h.sessions)-1], nil
+		}
+		return nil, errors.New("no sessions")
+	}
+	for _, s := range h.sessions {
+		if s.name == name {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("can't find session: %s", name)
+}
+
+// findPane resolves a pane target ("%3", "name", "name:0", "name:0.1",
+// "name.1"); h.mu must be held.
+func (h *Host) findPane(target string) (*pane, error) {
+	if strings.HasPrefix(target, "%") {
+		id, err := strconv.Atoi(target[1:])
+		if err == nil {
+			for _, s := range h.sessions {
+				for _, p := range s.panes {
+					if p.id == id {
+						return p, nil
+					}
+				}
+			}
+		}
+		return nil, fmt.Errorf("can't find pane: %s", target)
+	}
+
+	sessionPart, windowPart := target, ""
+	if i := strings.IndexByte(target, ':'); i >= 0 {
+		sessionPart, windowPart = target[:i], target[i+1:]
+	}
+	panePart := ""
+	if i := strings.IndexByte(windowPart, '.'); i >= 0 {
+		windowPart, panePart = windowPart[:i], windowPart[i+1:]
+	} else if i := strings.LastIndexByte(sessionPart, '.'); i >= 0 && windowPart == "" {
+		sessionPart, panePart = sessionPart[:i], sessionPart[i+1:]
+	}
+	s, err := h.findSession(sessionPart)
+	if err != nil {
+		return nil, err
+	}
+	if windowPart != "" && windowPart != "0" && windowPart != "^" && windowPart != "$" {
+		return nil, fmt.Errorf("can't find window: %s", windowPart)
+	}
+	if panePart == "" {
+		if p := activePane(s); p != nil {
+			return p, nil
+		}
+		return nil, fmt.Errorf("can't find pane: %s", target)
+	}
+	idx, err := strconv.Atoi(panePart)
+	if err != nil || idx < 0 || idx >= len(s.panes) {
+		return nil, fmt.Errorf("can't find pane: %s", panePart)
+	}
+	return s.panes[idx], nil
+}
+
+func activePane(s *session) *pane {
+	for _, p := range s.panes {
+		if p.active {
+			return p
+		}
+	}
+	if len(s.panes) > 0 {
+		return s.panes[0]
+	}
+	return nil
+}
+
+func setActive(s *session, p *pane) {
+	for _, q := range s.panes {
+		q.active = q == p
+	}
+}
+
+// --- formats ---
+
+func (h *Host) formatPane(p *pane, format, def string) string {
+	if format == "" {
+		format = def
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return expand(format, h.paneVars(p))
+}
+
+func (h *Host) sessionVars(s *session) map[string]string {
+	return map[string]string{
+		"session_name":           s.name,
+		"session_windows":        "1",
+		"session_attached":       "0",
+		"session_created":        strconv.FormatInt(s.created.Unix(), 10),
+		"session_created_string": s.created.Format("Mon Jan _2 15:04:05 2006"),
+		"session_path":           s.dir,
+		"session_panes":          strconv.Itoa(len(s.panes)),
+	}
+}
+
+// paneVars returns the format variables for p; h.mu must be held.
+func (h *Host) paneVars(p *pane) map[string]string {
+	vars := h.sessionVars(p.session)
+	index := 0
+	for i, q := range p.session.panes {
+		if q == p {
+			index = i
+			break
+		}
+	}
+	width, height := p.term.Size()
+	pid, command, cwd := "", "", p.dir
+	if p.proc != nil && p.proc.cmd.Process != nil {
+		pid = strconv.Itoa(p.proc.cmd.Process.Pid)
+		command = foregroundCommand(p.proc.master)
+		if dir, err := os.Readlink("/proc/" + pid + "/cwd"); err == nil {
+			cwd = dir
+		}
+	}
+	if command == "" {
+		if len(p.argv) > 0 {
+			command = filepath.Base(p.argv[0])
+		} else {
+			command = filepath.Base(h.shell)
+		}
+	}
+	vars["pane_id"] = "%" + strconv.Itoa(p.id)
+	vars["pane_index"] = strconv.Itoa(index)
+	vars["pane_title"] = p.title
+	vars["pane_current_command"] = command
+	vars["pane_current_path"] = cwd
+	vars["pane_width"] = strconv.Itoa(width)
+	vars["pane_height"] = strconv.Itoa(height)
+	vars["pane_active"] = boolFlag(p.active)
+	vars["pane_dead"] = boolFlag(p.dead)
+	vars["pane_pid"] = pid
+	vars["pane_last_activity"] = strconv.FormatInt(p.lastActivity.Unix(), 10)
+	vars["pane_in_mode"] = "0"
+	vars["window_index"] = "0"
+	vars["window_name"] = filepath.Base(h.shell)
+	vars["window_active"] = "1"
+	vars["window_zoomed_flag"] = "0"
+	vars["window_layout"] = "tiled"
+	vars["window_panes"] = strconv.Itoa(len(p.session.panes))
+	return vars
+}
+
+func boolFlag(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+func truthy(s string) bool {
+	return s != "" && s != "0"
+}
+
+// expand substitutes #{variable} references and #{==:a,b} / #{!=:a,b}
+// comparisons in a tmux format string. Unknown variables expand to "".
+func expand(format string, vars map[string]string) string {
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '#' || i+1 >= len(format) {
+			b.WriteByte(format[i])
+			continue
+		}
+		switch format[i+1] {
+		case '#':
+			b.WriteByte('#')
+			i++
+			continue
+		case '{':
+		default:
+			b.WriteByte('#')
+			continue
+		}
+		end := matchBrace(format, i+1)
+		if end < 0 {
+			b.WriteString(format[i:])
+			break
+		}
+		b.WriteString(expandExpr(format[i+2:end], vars))
+		i = end
+	}
+	return b.String()
+}
+
+func expandExpr(expr string, vars map[string]string) string {
+	for _, op := range []string{"==:", "!=:"} {
+		if !strings.HasPrefix(expr, op) {
+			continue
+		}
+		left, right, ok := splitTopLevel(expr[len(op):])
+		if !ok {
+			return ""
+		}
+		equal := expand(left, vars) == expand(right, vars)
+		return boolFlag(equal == (op == "==:"))
+	}
+	return vars[expr]
+}
+
+// matchBrace returns the index of the brace closing the one at open.
+func matchBrace(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitTopLevel splits s at the first comma outside #{...}.
+func splitTopLevel(s string) (string, string, bool) {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				return s[:i], s[i+1:], true
+			}
+		}
+	}
+	return "", "", false
+}
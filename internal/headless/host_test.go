@@ -0,0 +1,193 @@
+package headless
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/tmux"
+)
+
+func requirePTY(t *testing.T) {
+	t.Helper()
+	if err := Available(); err != nil {
+		t.Skipf("pty unavailable: %v", err)
+	}
+}
+
+// waitForCapture polls a pane until its capture contains want.
+func waitForCapture(t *testing.T, c *tmux.Client, target, want string) string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	var out string
+	for time.Now().Before(deadline) {
+		var err error
+		out, err = c.CapturePaneOutput(target, 100)
+		if err != nil {
+			t.Fatalf("CapturePaneOutput(%s): %v", target, err)
+		}
+		if strings.Contains(out, want) {
+			return out
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("pane %s never showed %q; last capture:\n%s", target, want, out)
+	return ""
+}
+
+func TestHostDrivesTmuxClient(t *testing.T) {
+	requirePTY(t)
+	t.Parallel()
+
+	host := NewHost(WithShell("/bin/sh"), WithSize(80, 24))
+	defer host.Close()
+	c := tmux.NewClientWithBackend(host)
+	dir := t.TempDir()
+
+	if err := c.CreateSession("ci", dir); err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	if !c.SessionExists("ci") || c.SessionExists("other") {
+		t.Fatal("SessionExists mismatch")
+	}
+	if err := c.CreateSession("ci", dir); err == nil {
+		t.Error("duplicate CreateSession succeeded")
+	}
+	paneID, err := c.SplitWindow("ci", dir)
+	if err != nil {
+		t.Fatalf("SplitWindow: %v", err)
+	}
+	if err := c.SetPaneTitle(paneID, "ci__cc_1"); err != nil {
+		t.Fatalf("SetPaneTitle: %v", err)
+	}
+
+	panes, err := c.GetPanes("ci")
+	if err != nil {
+		t.Fatalf("GetPanes: %v", err)
+	}
+	if len(panes) != 2 {
+		t.Fatalf("GetPanes = %+v, want 2 panes", panes)
+	}
+	agent := panes[1]
+	if agent.ID != paneID || agent.Index != 1 || agent.Type != tmux.AgentClaude || agent.PID == 0 || agent.Width != 80 {
+		t.Errorf("agent pane = %+v", agent)
+	}
+
+	if err := c.SendKeys(paneID, "echo hello-$((40+2))", true); err != nil {
+		t.Fatalf("SendKeys: %v", err)
+	}
+	waitForCapture(t, c, "ci:0.1", "hello-42")
+
+	if err := c.SendBuffer(paneID, "echo one\necho two", true); err != nil {
+		t.Fatalf("SendBuffer: %v", err)
+	}
+	waitForCapture(t, c, paneID, "two")
+
+	if err := c.RespawnPane(paneID, true); err != nil {
+		t.Fatalf("RespawnPane: %v", err)
+	}
+	if out, _ := c.CapturePaneOutput(paneID, 100); strings.Contains(out, "hello-42") {
+		t.Errorf("respawned pane kept old output:\n%s", out)
+	}
+
+	if err := c.KillSession("ci"); err != nil {
+		t.Fatalf("KillSession: %v", err)
+	}
+	sessions, err := c.ListSessions()
+	if err != nil || len(sessions) != 0 {
+		t.Errorf("ListSessions after kill = %+v, %v", sessions, err)
+	}
+}
+
+func TestHostRemovesExitedPanes(t *testing.T) {
+	requirePTY(t)
+	t.Parallel()
+
+	idle := make(chan struct{})
+	host := NewHost(WithShell("/bin/sh"), WithIdleHandler(func() { close(idle) }))
+	defer host.Close()
+	ctx := context.Background()
+
+	if _, err := host.Run(ctx, "new-session", "-d", "-s", "short", "-c", t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := host.Run(ctx, "send-keys", "-t", "short", "exit", "Enter"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-idle:
+	case <-time.After(5 * time.Second):
+		t.Fatal("host did not go idle after the only pane exited")
+	}
+	if _, err := host.Run(ctx, "has-session", "-t", "short"); err == nil {
+		t.Error("session survived its last pane")
+	}
+}
+
+func TestHostBuffers(t *testing.T) {
+	t.Parallel()
+	host := NewHost()
+	defer host.Close()
+	ctx := context.Background()
+
+	if _, err := host.Run(ctx, "show-buffer"); err == nil {
+		t.Error("show-buffer succeeded with no buffer set")
+	}
+	if _, err := host.Run(ctx, "set-buffer", "--", "-not a flag"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := host.Run(ctx, "showb"); err != nil || got != "-not a flag" {
+		t.Errorf("show-buffer = %q, %v", got, err)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	t.Parallel()
+	vars := map[string]string{"session_name": "proj", "pane_index": "2"}
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"#{session_name}:#{pane_index}", "proj:2"},
+		{"#{session_name}_NTM_SEP_#{missing}", "proj_NTM_SEP_"},
+		{"#{==:#{session_name},proj}", "1"},
+		{"#{==:#{session_name},other}", "0"},
+		{"#{!=:#{pane_index},3}", "1"},
+		{"100## #x", "100# #x"},
+	}
+	for _, tt := range tests {
+		if got := expand(tt.format, vars); got != tt.want {
+			t.Errorf("expand(%q) = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	t.Parallel()
+	a := parseArgs([]string{"-p", "-t", "s:0.1", "-S", "-200", "-dP", "--", "-l", "x"}, "tS")
+	if a.get('t') != "s:0.1" || a.get('S') != "-200" || !a.has('p') || !a.has('d') || !a.has('P') {
+		t.Errorf("flags = %v", a.flags)
+	}
+	if strings.Join(a.rest, " ") != "-l x" {
+		t.Errorf("rest = %q", a.rest)
+	}
+}
+
+func TestKeyBytes(t *testing.T) {
+	t.Parallel()
+	tests := map[string]string{
+		"Enter":  "\r",
+		"C-c":    "\x03",
+		"C-D":    "\x04",
+		"Escape": "\x1b",
+		"M-x":    "\x1bx",
+		"Up":     "\x1b[A",
+		"hello":  "hello",
+	}
+	for key, want := range tests {
+		if got := keyBytes(key); got != want {
+			t.Errorf("keyBytes(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
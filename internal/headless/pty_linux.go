@@ -0,0 +1,110 @@
+//go:build linux
+
+package headless
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// ptyAvailable reports whether pseudo-terminals can be allocated.
+func ptyAvailable() error {
+	f, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("open /dev/ptmx: %w", err)
+	}
+	return f.Close()
+}
+
+// startPTY starts cmd attached to a new pseudo-terminal of the given size and
+// returns the master side. The child becomes a session leader with the
+// terminal as its controlling tty, as it would inside a tmux pane.
+func startPTY(cmd *exec.Cmd, width, height int) (*os.File, error) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("open /dev/ptmx: %w", err)
+	}
+	fd := int(master.Fd())
+	if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+		master.Close()
+		return nil, fmt.Errorf("unlock pty: %w", err)
+	}
+	n, err := unix.IoctlGetInt(fd, unix.TIOCGPTN)
+	if err != nil {
+		master.Close()
+		return nil, fmt.Errorf("get pty number: %w", err)
+	}
+	slave, err := os.OpenFile("/dev/pts/"+strconv.Itoa(n), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		return nil, fmt.Errorf("open pty slave: %w", err)
+	}
+	defer slave.Close()
+
+	if err := setPTYSize(master, width, height); err != nil {
+		master.Close()
+		return nil, err
+	}
+
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = slave, slave, slave
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setsid = true
+	cmd.SysProcAttr.Setctty = true
+	cmd.SysProcAttr.Ctty = 0 // Index into the child's fds: stdin
+	if err := cmd.Start(); err != nil {
+		master.Close()
+		return nil, err
+	}
+	return master, nil
+}
+
+// setPTYSize sets the terminal window size seen by the child.
+func setPTYSize(master *os.File, width, height int) error {
+	ws := &unix.Winsize{Col: uint16(width), Row: uint16(height)}
+	if err := unix.IoctlSetWinsize(int(master.Fd()), unix.TIOCSWINSZ, ws); err != nil {
+		return fmt.Errorf("set pty size: %w", err)
+	}
+	return nil
+}
+
+// foregroundCommand returns the name of the terminal's foreground process,
+// the equivalent of tmux's #{pane_current_command}.
+func foregroundCommand(master *os.File) string {
+	pgrp, err := unix.IoctlGetInt(int(master.Fd()), unix.TIOCGPGRP)
+	if err != nil || pgrp <= 0 {
+		return ""
+	}
+	comm, err := os.ReadFile("/proc/" + strconv.Itoa(pgrp) + "/comm")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(comm))
+}
+
+// signalGroup hangs up the process group led by cmd, or kills it when force
+// is set.
+func signalGroup(cmd *exec.Cmd, force bool) {
+	if cmd.Process == nil {
+		return
+	}
+	sig := syscall.SIGHUP
+	if force {
+		sig = syscall.SIGKILL
+	}
+	_ = syscall.Kill(-cmd.Process.Pid, sig)
+	_ = cmd.Process.Signal(sig)
+}
+
+// setDetached starts cmd in a new session, away from the caller's terminal
+// and process group.
+func setDetached(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
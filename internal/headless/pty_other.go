@@ -0,0 +1,27 @@
+//go:build !linux
+
+package headless
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+var errPTYUnsupported = errors.New("headless pty backend is only supported on linux")
+
+func ptyAvailable() error { return errPTYUnsupported }
+
+func startPTY(*exec.Cmd, int, int) (*os.File, error) { return nil, errPTYUnsupported }
+
+func setPTYSize(*os.File, int, int) error { return errPTYUnsupported }
+
+func foregroundCommand(*os.File) string { return "" }
+
+func signalGroup(cmd *exec.Cmd, _ bool) {
+	if cmd.Process != nil {
+		_ = cmd.Process.Kill()
+	}
+}
+
+func setDetached(*exec.Cmd) {}
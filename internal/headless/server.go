@@ -0,0 +1,148 @@
+package headless
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SocketEnv names the environment variable that overrides the server socket.
+const SocketEnv = "NTM_HEADLESS_SOCKET"
+
+// startupGrace is how long a server with no sessions waits for its first one
+// before exiting.
+const startupGrace = 30 * time.Second
+
+// request and response are the wire format: one JSON object each way per
+// connection.
+type request struct {
+	Args []string `json:"args"`
+}
+
+type response struct {
+	Output string `json:"output"`
+	Error  string `json:"error,omitempty"`
+}
+
+// SocketPath returns the server socket path: $NTM_HEADLESS_SOCKET, else
+// ntm-headless/default.sock under $XDG_RUNTIME_DIR or a per-user temp dir.
+func SocketPath() string {
+	if p := os.Getenv(SocketEnv); p != "" {
+		return p
+	}
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir != "" {
+		dir = filepath.Join(dir, "ntm-headless")
+	} else {
+		dir = filepath.Join(os.TempDir(), "ntm-headless-"+strconv.Itoa(os.Getuid()))
+	}
+	return filepath.Join(dir, "default.sock")
+}
+
+// Server exposes a Host on a unix socket.
+type Server struct {
+	host *Host
+	path string
+
+	mu       sync.Mutex
+	listener net.Listener
+	idle     chan struct{}
+	idleOnce sync.Once
+}
+
+// NewServer creates a server for a host listening on socketPath. Panes get
+// NTM_BACKEND=pty and the socket path in their environment, so ntm commands
+// run inside a pane reach the same server.
+func NewServer(socketPath string, opts ...HostOption) *Server {
+	s := &Server{path: socketPath, idle: make(chan struct{})}
+	opts = append([]HostOption{
+		WithEnv("NTM_BACKEND="+BackendName, SocketEnv+"="+socketPath),
+		WithIdleHandler(s.markIdle),
+	}, opts...)
+	s.host = NewHost(opts...)
+	return s
+}
+
+// Host returns the server's host.
+func (s *Server) Host() *Host { return s.host }
+
+func (s *Server) markIdle() {
+	s.idleOnce.Do(func() { close(s.idle) })
+}
+
+// ListenAndServe serves requests until ctx is done or the last session
+// exits. It returns an error wrapping os.ErrExist if another server already
+// owns the socket.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	if err := ptyAvailable(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("create socket dir: %w", err)
+	}
+	if conn, err := net.Dial("unix", s.path); err == nil {
+		conn.Close()
+		return fmt.Errorf("headless server already running on %s: %w", s.path, os.ErrExist)
+	}
+	_ = os.Remove(s.path) // Stale socket from a server that died.
+
+	ln, err := net.Listen("unix", s.path)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", s.path, err)
+	}
+	s.mu.Lock()
+	s.listener = ln
+	s.mu.Unlock()
+	defer os.Remove(s.path)
+	defer s.host.Close()
+
+	grace := time.AfterFunc(startupGrace, func() {
+		s.host.mu.Lock()
+		hadAny := s.host.hadAny
+		s.host.mu.Unlock()
+		if !hadAny {
+			s.markIdle()
+		}
+	})
+	defer grace.Stop()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-s.idle:
+		}
+		ln.Close()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.handle(ctx, conn)
+	}
+}
+
+func (s *Server) handle(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	var req request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		return
+	}
+	out, err := s.host.Run(ctx, req.Args...)
+	resp := response{Output: out}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	_ = json.NewEncoder(conn).Encode(resp)
+}
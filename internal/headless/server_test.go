@@ -0,0 +1,86 @@
+package headless
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServerRoundTrip(t *testing.T) {
+	requirePTY(t)
+	t.Parallel()
+
+	sock := filepath.Join(t.TempDir(), "s.sock")
+	client := NewClient(sock)
+	ctx := context.Background()
+
+	if _, err := client.Run(ctx, "list-sessions"); err == nil || !strings.Contains(err.Error(), "no server running") {
+		t.Fatalf("Run without server = %v, want no server running", err)
+	}
+
+	srv := NewServer(sock, WithShell("/bin/sh"))
+	done := make(chan error, 1)
+	go func() { done <- srv.ListenAndServe(ctx) }()
+	conn, err := client.dialRetry(ctx, 5*time.Second)
+	if err != nil {
+		t.Fatalf("server did not start: %v", err)
+	}
+	conn.Close()
+
+	if err := NewServer(sock).ListenAndServe(ctx); !errors.Is(err, os.ErrExist) {
+		t.Errorf("second server = %v, want ErrExist", err)
+	}
+
+	if _, err := client.Run(ctx, "new-session", "-d", "-s", "remote", "-c", t.TempDir()); err != nil {
+		t.Fatalf("new-session: %v", err)
+	}
+	out, err := client.Run(ctx, "display-message", "-p", "-t", "remote", "#{session_name}")
+	if err != nil || strings.TrimSpace(out) != "remote" {
+		t.Fatalf("display-message = %q, %v", out, err)
+	}
+	if _, err := client.Run(ctx, "send-keys", "-t", "remote", "-l", "--", "echo X${NTM_BACKEND}X"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Run(ctx, "send-keys", "-t", "remote", "Enter"); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		out, err = client.Run(ctx, "capture-pane", "-p", "-t", "remote", "-S", "-50")
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Only the expanded output has the marker: the echoed command line
+		// holds the unexpanded variable, and a prompt may precede either.
+		if strings.Contains(out, "X"+BackendName+"X") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("pane environment missing NTM_BACKEND; capture:\n%s", out)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if _, err := client.Run(ctx, "bogus-command"); err == nil || !strings.Contains(err.Error(), "unknown command") {
+		t.Errorf("bogus command error = %v", err)
+	}
+
+	// Killing the last session shuts the server down and removes the socket.
+	if _, err := client.Run(ctx, "kill-session", "-t", "remote"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("ListenAndServe: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not exit after its last session")
+	}
+	if _, err := os.Stat(sock); !os.IsNotExist(err) {
+		t.Errorf("socket left behind: %v", err)
+	}
+}
@@ -0,0 +1,624 @@
+package headless
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// wideTail marks the second cell of a double-width character.
+const wideTail = -1
+
+// parser states
+const (
+	stGround = iota
+	stEscape
+	stEscapeIntermediate
+	stCSI
+	stString // OSC, DCS, APC, PM, SOS: consumed until BEL or ST
+	stStringEscape
+)
+
+// Terminal is a minimal VT100/xterm screen with scrollback. It understands
+// the cursor movement, erase, scroll-region and alternate-screen sequences
+// that agent TUIs use to redraw, so capturing it yields the text a user would
+// see in a tmux pane. Colors and other attributes are discarded.
+type Terminal struct {
+	mu sync.Mutex
+
+	width, height int
+	historyLimit  int
+
+	main, alt [][]rune
+	altActive bool
+	history   [][]rune
+
+	cx, cy         int
+	savedX, savedY int
+	wrapPending    bool
+	top, bottom    int // Scroll region, inclusive
+
+	bracketedPaste bool
+
+	state   int
+	params  []byte
+	private byte
+	pending []byte // Incomplete UTF-8 sequence
+
+	// respond receives replies to terminal queries (cursor position, device
+	// attributes). Programs such as crossterm-based TUIs block until answered.
+	respond func([]byte)
+}
+
+// NewTerminal creates a terminal of the given size keeping up to historyLimit
+// lines of scrollback.
+func NewTerminal(width, height, historyLimit int) *Terminal {
+	if width <= 0 {
+		width = DefaultWidth
+	}
+	if height <= 0 {
+		height = DefaultHeight
+	}
+	t := &Terminal{width: width, height: height, historyLimit: historyLimit}
+	t.main = newGrid(width, height)
+	t.alt = newGrid(width, height)
+	t.bottom = height - 1
+	return t
+}
+
+func newGrid(width, height int) [][]rune {
+	g := make([][]rune, height)
+	for i := range g {
+		g[i] = make([]rune, width)
+	}
+	return g
+}
+
+// SetResponder sets the callback that receives replies to terminal queries.
+func (t *Terminal) SetResponder(fn func([]byte)) {
+	t.mu.Lock()
+	t.respond = fn
+	t.mu.Unlock()
+}
+
+// Size returns the terminal dimensions.
+func (t *Terminal) Size() (width, height int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.width, t.height
+}
+
+// BracketedPaste reports whether the running program enabled bracketed paste.
+func (t *Terminal) BracketedPaste() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.bracketedPaste
+}
+
+// SetHistoryLimit changes the number of scrollback lines kept.
+func (t *Terminal) SetHistoryLimit(n int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.historyLimit = n
+	if n >= 0 && len(t.history) > n {
+		t.history = t.history[len(t.history)-n:]
+	}
+}
+
+// Reset clears the screen and scrollback, as when a pane is respawned.
+func (t *Terminal) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.main = newGrid(t.width, t.height)
+	t.alt = newGrid(t.width, t.height)
+	t.history = nil
+	t.altActive = false
+	t.cx, t.cy, t.savedX, t.savedY = 0, 0, 0, 0
+	t.wrapPending = false
+	t.top, t.bottom = 0, t.height-1
+	t.bracketedPaste = false
+	t.state = stGround
+	t.pending = nil
+}
+
+// Capture returns the last lines of scrollback followed by the visible
+// screen, one line per row with trailing blanks removed, like
+// `tmux capture-pane -p -S -<lines>`. A negative lines value returns the whole
+// history.
+func (t *Terminal) Capture(lines int) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var rows [][]rune
+	if !t.altActive {
+		start := 0
+		if lines >= 0 && lines < len(t.history) {
+			start = len(t.history) - lines
+		}
+		rows = append(rows, t.history[start:]...)
+	}
+	rows = append(rows, t.screen()...)
+
+	var b strings.Builder
+	for i, row := range rows {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(rowText(row))
+	}
+	return b.String()
+}
+
+func rowText(row []rune) string {
+	var b strings.Builder
+	for _, r := range row {
+		switch r {
+		case wideTail:
+			continue
+		case 0:
+			b.WriteByte(' ')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return strings.TrimRight(b.String(), " ")
+}
+
+func (t *Terminal) screen() [][]rune {
+	if t.altActive {
+		return t.alt
+	}
+	return t.main
+}
+
+// Write feeds program output to the terminal.
+func (t *Terminal) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	data := p
+	if len(t.pending) > 0 {
+		data = append(t.pending, p...)
+		t.pending = nil
+	}
+	for i := 0; i < len(data); {
+		b := data[i]
+		if t.state != stGround || b < 0x80 {
+			t.feedByte(b)
+			i++
+			continue
+		}
+		if !utf8.FullRune(data[i:]) {
+			t.pending = append([]byte(nil), data[i:]...)
+			break
+		}
+		r, size := utf8.DecodeRune(data[i:])
+		t.print(r)
+		i += size
+	}
+	return len(p), nil
+}
+
+func (t *Terminal) feedByte(b byte) {
+	switch t.state {
+	case stGround:
+		t.ground(b)
+	case stEscape:
+		t.escape(b)
+	case stEscapeIntermediate:
+		// Charset designation and similar: one final byte follows.
+		t.state = stGround
+	case stCSI:
+		t.csiByte(b)
+	case stString:
+		switch b {
+		case 0x07:
+			t.state = stGround
+		case 0x1b:
+			t.state = stStringEscape
+		}
+	case stStringEscape:
+		// ESC \ terminates; anything else resumes the string.
+		if b == '\\' {
+			t.state = stGround
+		} else {
+			t.state = stString
+		}
+	}
+}
+
+func (t *Terminal) ground(b byte) {
+	switch b {
+	case 0x1b:
+		t.state = stEscape
+	case '\r':
+		t.cx = 0
+		t.wrapPending = false
+	case '\n', 0x0b, 0x0c:
+		t.lineFeed()
+	case '\b':
+		if t.cx > 0 {
+			t.cx--
+		}
+		t.wrapPending = false
+	case '\t':
+		next := (t.cx/8 + 1) * 8
+		if next >= t.width {
+			next = t.width - 1
+		}
+		t.cx = next
+	default:
+		if b >= 0x20 && b != 0x7f {
+			t.print(rune(b))
+		}
+	}
+}
+
+func (t *Terminal) escape(b byte) {
+	t.state = stGround
+	switch b {
+	case '[':
+		t.state = stCSI
+		t.params = t.params[:0]
+		t.private = 0
+	case ']', 'P', '_', '^', 'X':
+		t.state = stString
+	case '(', ')', '*', '+', '#', '%':
+		t.state = stEscapeIntermediate
+	case '7':
+		t.savedX, t.savedY = t.cx, t.cy
+	case '8':
+		t.cx, t.cy = t.savedX, t.savedY
+		t.wrapPending = false
+	case 'D':
+		t.lineFeed()
+	case 'E':
+		t.cx = 0
+		t.lineFeed()
+	case 'M':
+		t.reverseIndex()
+	case 'c':
+		grid := t.screen()
+		for y := range grid {
+			clearCells(grid[y], 0, t.width)
+		}
+		t.cx, t.cy = 0, 0
+		t.top, t.bottom = 0, t.height-1
+	}
+}
+
+func (t *Terminal) csiByte(b byte) {
+	switch {
+	case b >= '0' && b <= '9', b == ';', b == ':':
+		t.params = append(t.params, b)
+	case b == '?' || b == '>' || b == '<' || b == '=':
+		t.private = b
+	case b >= 0x20 && b <= 0x2f:
+		// Intermediate bytes (e.g. DECSCUSR " q"); ignored.
+	case b >= 0x40 && b <= 0x7e:
+		t.state = stGround
+		t.csi(b, t.parseParams())
+	default:
+		t.state = stGround
+	}
+}
+
+func (t *Terminal) parseParams() []int {
+	if len(t.params) == 0 {
+		return nil
+	}
+	parts := strings.Split(string(t.params), ";")
+	out := make([]int, len(parts))
+	for i, p := range parts {
+		if j := strings.IndexByte(p, ':'); j >= 0 {
+			p = p[:j]
+		}
+		out[i], _ = strconv.Atoi(p)
+	}
+	return out
+}
+
+// param returns the i'th parameter or def when absent or zero.
+func param(ps []int, i, def int) int {
+	if i < len(ps) && ps[i] > 0 {
+		return ps[i]
+	}
+	return def
+}
+
+func (t *Terminal) csi(final byte, ps []int) {
+	if t.private == '?' {
+		switch final {
+		case 'h', 'l':
+			t.setPrivateModes(ps, final == 'h')
+		}
+		return
+	}
+	if t.private != 0 {
+		if final == 'c' && t.respond != nil {
+			t.respond([]byte("\x1b[>0;10;1c")) // Secondary device attributes
+		}
+		return
+	}
+
+	n := param(ps, 0, 1)
+	switch final {
+	case 'A':
+		t.moveTo(t.cx, max(t.cy-n, t.regionTopFor(t.cy)))
+	case 'B', 'e':
+		t.moveTo(t.cx, min(t.cy+n, t.regionBottomFor(t.cy)))
+	case 'C', 'a':
+		t.moveTo(t.cx+n, t.cy)
+	case 'D':
+		t.moveTo(t.cx-n, t.cy)
+	case 'E':
+		t.moveTo(0, min(t.cy+n, t.regionBottomFor(t.cy)))
+	case 'F':
+		t.moveTo(0, max(t.cy-n, t.regionTopFor(t.cy)))
+	case 'G', '`':
+		t.moveTo(n-1, t.cy)
+	case 'd':
+		t.moveTo(t.cx, n-1)
+	case 'H', 'f':
+		t.moveTo(param(ps, 1, 1)-1, param(ps, 0, 1)-1)
+	case 'J':
+		t.eraseDisplay(param(ps, 0, 0))
+	case 'K':
+		t.eraseLine(param(ps, 0, 0))
+	case '@':
+		t.insertChars(n)
+	case 'P':
+		t.deleteChars(n)
+	case 'X':
+		row := t.screen()[t.cy]
+		clearCells(row, t.cx, min(t.cx+n, t.width))
+	case 'L':
+		t.insertLines(n)
+	case 'M':
+		t.deleteLines(n)
+	case 'S':
+		for i := 0; i < n; i++ {
+			t.scrollUp()
+		}
+	case 'T':
+		for i := 0; i < n; i++ {
+			t.scrollDown()
+		}
+	case 'r':
+		top, bottom := param(ps, 0, 1)-1, param(ps, 1, t.height)-1
+		if top < bottom && bottom < t.height {
+			t.top, t.bottom = top, bottom
+			t.moveTo(0, 0)
+		}
+	case 's':
+		t.savedX, t.savedY = t.cx, t.cy
+	case 'u':
+		t.cx, t.cy = t.savedX, t.savedY
+		t.wrapPending = false
+	case 'n':
+		if param(ps, 0, 0) == 6 && t.respond != nil {
+			t.respond([]byte("\x1b[" + strconv.Itoa(t.cy+1) + ";" + strconv.Itoa(t.cx+1) + "R"))
+		} else if param(ps, 0, 0) == 5 && t.respond != nil {
+			t.respond([]byte("\x1b[0n"))
+		}
+	case 'c':
+		if t.respond != nil {
+			t.respond([]byte("\x1b[?62;22c")) // VT220 with ANSI color
+		}
+	}
+	// 'm' (SGR) and anything else is ignored.
+}
+
+func (t *Terminal) setPrivateModes(ps []int, on bool) {
+	for _, mode := range ps {
+		switch mode {
+		case 1049, 1047, 47:
+			if on == t.altActive {
+				continue
+			}
+			if on {
+				if mode == 1049 {
+					t.savedX, t.savedY = t.cx, t.cy
+				}
+				t.alt = newGrid(t.width, t.height)
+				t.altActive = true
+			} else {
+				t.altActive = false
+				if mode == 1049 {
+					t.cx, t.cy = t.savedX, t.savedY
+				}
+			}
+			t.wrapPending = false
+		case 2004:
+			t.bracketedPaste = on
+		}
+	}
+}
+
+func (t *Terminal) regionTopFor(y int) int {
+	if y >= t.top {
+		return t.top
+	}
+	return 0
+}
+
+func (t *Terminal) regionBottomFor(y int) int {
+	if y <= t.bottom {
+		return t.bottom
+	}
+	return t.height - 1
+}
+
+func (t *Terminal) moveTo(x, y int) {
+	t.cx = clamp(x, 0, t.width-1)
+	t.cy = clamp(y, 0, t.height-1)
+	t.wrapPending = false
+}
+
+func (t *Terminal) print(r rune) {
+	w := runewidth.RuneWidth(r)
+	if w == 0 {
+		return // Combining marks and other zero-width runes are dropped.
+	}
+	if t.wrapPending || t.cx+w > t.width {
+		t.cx = 0
+		t.lineFeed()
+	}
+	row := t.screen()[t.cy]
+	// Overwriting half of a wide character blanks the other half.
+	if row[t.cx] == wideTail && t.cx > 0 {
+		row[t.cx-1] = 0
+	}
+	row[t.cx] = r
+	if w == 2 {
+		row[t.cx+1] = wideTail
+	}
+	if t.cx+w >= t.width {
+		t.cx = t.width - 1
+		t.wrapPending = true
+	} else {
+		t.cx += w
+	}
+}
+
+func (t *Terminal) lineFeed() {
+	t.wrapPending = false
+	if t.cy == t.bottom {
+		t.scrollUp()
+		return
+	}
+	if t.cy < t.height-1 {
+		t.cy++
+	}
+}
+
+func (t *Terminal) reverseIndex() {
+	if t.cy == t.top {
+		t.scrollDown()
+		return
+	}
+	if t.cy > 0 {
+		t.cy--
+	}
+}
+
+// scrollUp scrolls the region up one line. Lines leaving the top of a
+// full-screen region on the main screen go to scrollback.
+func (t *Terminal) scrollUp() {
+	grid := t.screen()
+	first := grid[t.top]
+	if !t.altActive && t.top == 0 && t.historyLimit != 0 {
+		t.history = append(t.history, first)
+		if t.historyLimit > 0 && len(t.history) > t.historyLimit {
+			t.history = t.history[len(t.history)-t.historyLimit:]
+		}
+		first = make([]rune, t.width)
+	} else {
+		clearCells(first, 0, t.width)
+	}
+	copy(grid[t.top:t.bottom], grid[t.top+1:t.bottom+1])
+	grid[t.bottom] = first
+}
+
+func (t *Terminal) scrollDown() {
+	grid := t.screen()
+	last := grid[t.bottom]
+	clearCells(last, 0, t.width)
+	copy(grid[t.top+1:t.bottom+1], grid[t.top:t.bottom])
+	grid[t.top] = last
+}
+
+func (t *Terminal) insertLines(n int) {
+	if t.cy < t.top || t.cy > t.bottom {
+		return
+	}
+	savedTop := t.top
+	t.top = t.cy
+	for i := 0; i < n; i++ {
+		t.scrollDown()
+	}
+	t.top = savedTop
+	t.cx = 0
+}
+
+func (t *Terminal) deleteLines(n int) {
+	if t.cy < t.top || t.cy > t.bottom {
+		return
+	}
+	grid := t.screen()
+	for i := 0; i < n; i++ {
+		first := grid[t.cy]
+		clearCells(first, 0, t.width)
+		copy(grid[t.cy:t.bottom], grid[t.cy+1:t.bottom+1])
+		grid[t.bottom] = first
+	}
+	t.cx = 0
+}
+
+func (t *Terminal) insertChars(n int) {
+	row := t.screen()[t.cy]
+	n = min(n, t.width-t.cx)
+	copy(row[t.cx+n:], row[t.cx:t.width-n])
+	clearCells(row, t.cx, t.cx+n)
+}
+
+func (t *Terminal) deleteChars(n int) {
+	row := t.screen()[t.cy]
+	n = min(n, t.width-t.cx)
+	copy(row[t.cx:], row[t.cx+n:])
+	clearCells(row, t.width-n, t.width)
+}
+
+func (t *Terminal) eraseDisplay(mode int) {
+	grid := t.screen()
+	switch mode {
+	case 0:
+		clearCells(grid[t.cy], t.cx, t.width)
+		for y := t.cy + 1; y < t.height; y++ {
+			clearCells(grid[y], 0, t.width)
+		}
+	case 1:
+		for y := 0; y < t.cy; y++ {
+			clearCells(grid[y], 0, t.width)
+		}
+		clearCells(grid[t.cy], 0, t.cx+1)
+	case 2:
+		for y := range grid {
+			clearCells(grid[y], 0, t.width)
+		}
+	case 3:
+		t.history = nil
+	}
+}
+
+func (t *Terminal) eraseLine(mode int) {
+	row := t.screen()[t.cy]
+	switch mode {
+	case 0:
+		clearCells(row, t.cx, t.width)
+	case 1:
+		clearCells(row, 0, t.cx+1)
+	case 2:
+		clearCells(row, 0, t.width)
+	}
+	t.wrapPending = false
+}
+
+func clearCells(row []rune, from, to int) {
+	for i := max(from, 0); i < to && i < len(row); i++ {
+		row[i] = 0
+	}
+}
+
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
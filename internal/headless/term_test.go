@@ -0,0 +1,138 @@
+package headless
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTerminalCapture(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		width  int
+		height int
+		input  []string
+		lines  int
+		want   string
+	}{
+		{
+			name:  "plain lines",
+			input: []string{"hello\r\nworld\r\n"},
+			want:  "hello\nworld\n\n",
+		},
+		{
+			name:  "carriage return overwrites",
+			input: []string{"progress 10%\rprogress 99%"},
+			want:  "progress 99%\n\n\n",
+		},
+		{
+			name:  "erase line and cursor motion",
+			input: []string{"aaaa\r\nbbbb\x1b[1;3H\x1b[K", "\x1b[2;2HX"},
+			want:  "aa\nbXbb\n\n",
+		},
+		{
+			name:  "wraps at width",
+			width: 4, height: 3,
+			input: []string{"abcdefg"},
+			want:  "abcd\nefg\n",
+		},
+		{
+			name:  "scrollback kept and limited by lines",
+			width: 10, height: 2,
+			input: []string{"1\r\n2\r\n3\r\n4\r\n5"},
+			lines: 2,
+			want:  "2\n3\n4\n5",
+		},
+		{
+			name:  "clear screen",
+			input: []string{"junk\r\nmore\x1b[2J\x1b[Hclean"},
+			want:  "clean\n\n\n",
+		},
+		{
+			name:  "alternate screen restores main",
+			input: []string{"shell$ ", "\x1b[?1049h\x1b[Hfull screen app", "\x1b[?1049l", "done"},
+			want:  "shell$ done\n\n\n",
+		},
+		{
+			name:  "utf-8 split across writes and wide runes",
+			input: []string{"caf\xc3", "\xa9 界!"},
+			want:  "café 界!\n\n\n",
+		},
+		{
+			name:  "osc title and sgr ignored",
+			input: []string{"\x1b]0;title\x07\x1b[1;31mred\x1b[0m \x1b]2;t\x1b\\ok"},
+			want:  "red ok\n\n\n",
+		},
+		{
+			name:  "scroll region keeps header",
+			width: 10, height: 4,
+			input: []string{"header\x1b[2;4r\x1b[4;1Ha\r\nb\r\nc\r\nd"},
+			want:  "header\nb\nc\nd",
+		},
+		{
+			name:  "insert and delete characters",
+			input: []string{"abcdef\x1b[1;2H\x1b[2P\x1b[1@-"},
+			want:  "a-def\n\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			width, height := tt.width, tt.height
+			if width == 0 {
+				width, height = 20, 4
+			}
+			term := NewTerminal(width, height, 100)
+			for _, in := range tt.input {
+				if _, err := term.Write([]byte(in)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			lines := tt.lines
+			if lines == 0 {
+				lines = -1
+			}
+			if got := term.Capture(lines); got != tt.want {
+				t.Errorf("Capture() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTerminalHistoryLimit(t *testing.T) {
+	t.Parallel()
+	term := NewTerminal(10, 2, 3)
+	for i := 0; i < 20; i++ {
+		_, _ = term.Write([]byte("line\r\n"))
+	}
+	if got := strings.Count(term.Capture(-1), "\n") + 1; got != 5 {
+		t.Errorf("captured %d lines, want 3 history + 2 screen", got)
+	}
+	term.SetHistoryLimit(1)
+	if got := strings.Count(term.Capture(-1), "\n") + 1; got != 3 {
+		t.Errorf("captured %d lines after shrinking history, want 3", got)
+	}
+}
+
+func TestTerminalQueries(t *testing.T) {
+	t.Parallel()
+	term := NewTerminal(20, 5, 0)
+	var replies []string
+	term.SetResponder(func(b []byte) { replies = append(replies, string(b)) })
+
+	_, _ = term.Write([]byte("ab\r\ncd\x1b[6n\x1b[c\x1b[?2004h"))
+
+	want := []string{"\x1b[2;3R", "\x1b[?62;22c"}
+	if strings.Join(replies, "|") != strings.Join(want, "|") {
+		t.Errorf("replies = %q, want %q", replies, want)
+	}
+	if !term.BracketedPaste() {
+		t.Error("bracketed paste not enabled")
+	}
+	term.Reset()
+	if term.BracketedPaste() || strings.TrimSpace(term.Capture(-1)) != "" {
+		t.Error("Reset did not clear state")
+	}
+}
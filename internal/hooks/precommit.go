@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -25,6 +26,10 @@ type PreCommitConfig struct {
 	Verbose bool
 	// SkipEmpty skips the scan if no staged files.
 	SkipEmpty bool
+	// SARIFFiles are reports from other tools (golangci-lint, semgrep,
+	// gosec, ...) whose findings on staged files count toward the thresholds.
+	// Relative paths are resolved against the repository root.
+	SARIFFiles []string
 }
 
 // DefaultPreCommitConfig returns sensible defaults.
@@ -47,6 +52,9 @@ type PreCommitResult struct {
 	BlockReason  string              `json:"block_reason,omitempty"`
 	Duration     time.Duration       `json:"duration"`
 	UBSAvailable bool                `json:"ubs_available"`
+	// SARIFFindings counts findings on staged files taken from SARIFFiles.
+	SARIFFindings int      `json:"sarif_findings,omitempty"`
+	SARIFWarnings []string `json:"sarif_warnings,omitempty"`
 }
 
 // RunPreCommit executes the pre-commit hook logic.
@@ -70,10 +78,17 @@ func RunPreCommit(ctx context.Context, repoPath string, config PreCommitConfig)
 		return result, nil
 	}
 
+	sarifResult := loadStagedSARIF(repoPath, config.SARIFFiles, stagedFiles, result)
+
 	// Check if UBS is available
 	if !scanner.IsAvailable() {
-		// Graceful degradation - pass but note UBS is not available
+		// Graceful degradation - pass but note UBS is not available.
+		// SARIF reports still gate the commit.
 		result.Duration = time.Since(startTime)
+		if sarifResult != nil {
+			result.ScanResult = sarifResult
+			applyPreCommitThresholds(result, config)
+		}
 		return result, nil
 	}
 
@@ -102,10 +117,17 @@ func RunPreCommit(ctx context.Context, repoPath string, config PreCommitConfig)
 	if err != nil {
 		return nil, fmt.Errorf("running scan: %w", err)
 	}
-	result.ScanResult = scanResult
+	result.ScanResult = scanner.MergeResults(scanResult, sarifResult)
 	result.Duration = time.Since(startTime)
 
-	// Check thresholds
+	applyPreCommitThresholds(result, config)
+	return result, nil
+}
+
+// applyPreCommitThresholds blocks the commit when the scan exceeds the
+// configured limits.
+func applyPreCommitThresholds(result *PreCommitResult, config PreCommitConfig) {
+	scanResult := result.ScanResult
 	if scanResult.Totals.Critical > config.MaxCritical {
 		result.Passed = false
 		result.BlockReason = fmt.Sprintf(
@@ -119,8 +141,60 @@ func RunPreCommit(ctx context.Context, repoPath string, config PreCommitConfig)
 			scanResult.Totals.Warning, config.MaxWarning,
 		)
 	}
+}
 
-	return result, nil
+// loadStagedSARIF imports the configured SARIF reports and keeps findings on
+// staged files. Missing or unreadable reports are noted but never block.
+func loadStagedSARIF(repoPath string, files, staged []string, result *PreCommitResult) *scanner.ScanResult {
+	if len(files) == 0 {
+		return nil
+	}
+	var paths []string
+	for _, f := range files {
+		if !filepath.IsAbs(f) {
+			f = filepath.Join(repoPath, f)
+		}
+		if _, err := os.Stat(f); err != nil {
+			result.SARIFWarnings = append(result.SARIFWarnings, fmt.Sprintf("SARIF report %s: %v", f, err))
+			continue
+		}
+		paths = append(paths, f)
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+
+	root := repoPath
+	if abs, err := filepath.Abs(repoPath); err == nil {
+		root = abs
+	}
+	imported, err := scanner.ImportSARIFFiles(paths, scanner.SARIFImportOptions{Root: root})
+	if err != nil {
+		result.SARIFWarnings = append(result.SARIFWarnings, err.Error())
+		return nil
+	}
+
+	stagedSet := make(map[string]bool, len(staged))
+	for _, f := range staged {
+		stagedSet[filepath.ToSlash(f)] = true
+	}
+	filtered := &scanner.ScanResult{Project: imported.Project, Sources: imported.Sources}
+	for _, f := range imported.Findings {
+		if !stagedSet[f.File] {
+			continue
+		}
+		filtered.Findings = append(filtered.Findings, f)
+		switch f.Severity {
+		case scanner.SeverityCritical:
+			filtered.Totals.Critical++
+		case scanner.SeverityWarning:
+			filtered.Totals.Warning++
+		default:
+			filtered.Totals.Info++
+		}
+	}
+	result.SARIFFindings = len(filtered.Findings)
+	return filtered
 }
 
 // getStagedFiles returns a list of staged file paths.
@@ -162,7 +236,16 @@ func PrintPreCommitResult(result *PreCommitResult) {
 	if !result.UBSAvailable {
 		fmt.Printf("  %s⚠%s UBS not installed - skipping scan\n", yellow, reset)
 		fmt.Printf("    Install: %shttps://github.com/nightowlai/ubs%s\n\n", dim, reset)
-		return
+		if result.ScanResult == nil {
+			return
+		}
+	}
+
+	for _, w := range result.SARIFWarnings {
+		fmt.Printf("  %s⚠%s %s\n", yellow, reset, w)
+	}
+	if result.SARIFFindings > 0 {
+		fmt.Printf("  SARIF findings on staged files: %d\n\n", result.SARIFFindings)
 	}
 
 	// No staged files
@@ -204,9 +287,13 @@ func PrintPreCommitResult(result *PreCommitResult) {
 				case scanner.SeverityWarning:
 					severityColor = yellow
 				}
-				fmt.Printf("  %s%s%s %s:%d - %s\n",
+				source := ""
+				if f.Source != "" {
+					source = fmt.Sprintf(" %s[%s]%s", dim, f.Source, reset)
+				}
+				fmt.Printf("  %s%s%s %s:%d - %s%s\n",
 					severityColor, string(f.Severity), reset,
-					f.File, f.Line, f.Message)
+					f.File, f.Line, f.Message, source)
 			}
 			fmt.Println()
 		}
@@ -1,9 +1,11 @@
 package hooks
 
 import (
+	"context"
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -241,6 +243,58 @@ func TestGetStagedFiles_InvalidRepo(t *testing.T) {
 	}
 }
 
+// =============================================================================
+// SARIF gating
+// =============================================================================
+
+const precommitSARIF = `{"version":"2.1.0","runs":[{"tool":{"driver":{"name":"gosec"}},"results":[
+  {"ruleId":"G101","level":"error","message":{"text":"hardcoded credentials"},
+   "locations":[{"physicalLocation":{"artifactLocation":{"uri":"hello.go"},"region":{"startLine":3}}}]},
+  {"ruleId":"G104","level":"error","message":{"text":"unhandled error"},
+   "locations":[{"physicalLocation":{"artifactLocation":{"uri":"other.go"},"region":{"startLine":9}}}]}
+]}]}`
+
+func TestRunPreCommit_SARIFGate(t *testing.T) {
+	repoDir := setupHooksGitRepo(t)
+	if err := os.WriteFile(filepath.Join(repoDir, "hello.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("git", "add", "hello.go")
+	cmd.Dir = repoDir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git add: %v\n%s", err, out)
+	}
+	if err := os.WriteFile(filepath.Join(repoDir, "gosec.sarif"), []byte(precommitSARIF), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultPreCommitConfig()
+	cfg.SARIFFiles = []string{"gosec.sarif", "missing.sarif"}
+	result, err := RunPreCommit(context.Background(), repoDir, cfg)
+	if err != nil {
+		t.Fatalf("RunPreCommit: %v", err)
+	}
+	if result.Passed {
+		t.Fatal("critical SARIF finding on a staged file should block the commit")
+	}
+	// other.go is not staged, so only hello.go's finding counts.
+	if result.SARIFFindings != 1 {
+		t.Errorf("SARIFFindings = %d, want 1", result.SARIFFindings)
+	}
+	if len(result.SARIFWarnings) != 1 || !contains(result.SARIFWarnings[0], "missing.sarif") {
+		t.Errorf("SARIFWarnings = %v", result.SARIFWarnings)
+	}
+
+	cfg.SARIFFiles = nil
+	result, err = RunPreCommit(context.Background(), repoDir, cfg)
+	if err != nil {
+		t.Fatalf("RunPreCommit: %v", err)
+	}
+	if result.SARIFFindings != 0 {
+		t.Errorf("no SARIF files configured, got %d findings", result.SARIFFindings)
+	}
+}
+
 // helper
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && searchSubstring(s, substr)
@@ -0,0 +1,106 @@
+package mailserver
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// args are a tools/call request's arguments as decoded from JSON.
+type args map[string]any
+
+// str returns the first non-empty string among keys.
+func (a args) str(keys ...string) string {
+	for _, k := range keys {
+		if v, ok := a[k].(string); ok && strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+// required returns a string argument or an invalid-params error.
+func (a args) required(key string) (string, error) {
+	if v := a.str(key); v != "" {
+		return v, nil
+	}
+	return "", invalidParams("missing required argument: %s", key)
+}
+
+// strs returns a string-list argument; a single string is accepted as a
+// one-element list.
+func (a args) strs(key string) []string {
+	switch v := a[key].(type) {
+	case string:
+		if v = strings.TrimSpace(v); v != "" {
+			return []string{v}
+		}
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+				out = append(out, strings.TrimSpace(s))
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// integer returns a numeric argument, or def when absent or malformed.
+func (a args) integer(key string, def int) int {
+	switch v := a[key].(type) {
+	case float64:
+		return int(v)
+	case string:
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+	}
+	return def
+}
+
+// ints returns a numeric-list argument.
+func (a args) ints(key string) []int64 {
+	list, ok := a[key].([]any)
+	if !ok {
+		if n := a.integer(key, 0); n > 0 {
+			return []int64{int64(n)}
+		}
+		return nil
+	}
+	out := make([]int64, 0, len(list))
+	for _, item := range list {
+		if f, ok := item.(float64); ok {
+			out = append(out, int64(f))
+		}
+	}
+	return out
+}
+
+// boolean returns a boolean argument, or def when absent.
+func (a args) boolean(key string, def bool) bool {
+	switch v := a[key].(type) {
+	case bool:
+		return v
+	case string:
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return def
+}
+
+// timestamp parses an ISO-8601 argument; the zero time means absent.
+func (a args) timestamp(key string) (time.Time, error) {
+	v := a.str(key)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02T15:04:05.999999"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, invalidParams("invalid timestamp for %s: %q", key, v)
+}
@@ -0,0 +1,184 @@
+package mailserver
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// GuardMarker identifies pre-commit hooks installed by ntm.
+const GuardMarker = "ntm-precommit-guard"
+
+// GuardHookPath returns the pre-commit hook path for a repository.
+func GuardHookPath(repoPath string) string {
+	return filepath.Join(repoPath, ".git", "hooks", "pre-commit")
+}
+
+// GuardScript returns a pre-commit hook that rejects commits touching files
+// another agent holds an exclusive reservation on. The check itself is
+// `ntm mail guard-check`, which works against any Agent Mail server.
+func GuardScript(projectKey, repoPath string) string {
+	return fmt.Sprintf(`#!/bin/bash
+# %s
+# Installed by: ntm guards install
+# Project: %s
+# Repository: %s
+#
+# Blocks commits that touch files exclusively reserved by another agent.
+# Export AGENT_NAME so your own reservations are ignored; set
+# NTM_GUARD_BYPASS=1 to skip the check.
+
+[ "${NTM_GUARD_BYPASS:-}" = "1" ] && exit 0
+
+# Allow the commit if ntm is not installed
+command -v ntm >/dev/null 2>&1 || exit 0
+
+exec ntm mail guard-check --project %s
+`, GuardMarker, commentSafe(projectKey), commentSafe(repoPath), shellQuote(projectKey))
+}
+
+// WriteGuardHook writes the guard script to hookPath, creating the hooks
+// directory if needed.
+func WriteGuardHook(hookPath, projectKey, repoPath string) error {
+	if err := os.MkdirAll(filepath.Dir(hookPath), 0755); err != nil {
+		return fmt.Errorf("creating hooks directory: %w", err)
+	}
+	return os.WriteFile(hookPath, []byte(GuardScript(projectKey, repoPath)), 0755)
+}
+
+func (s *Server) toolInstallGuard(a args) (any, error) {
+	repo, err := a.required("code_repo_path")
+	if err != nil {
+		return nil, err
+	}
+	key := a.str("project_key")
+	if key == "" {
+		key = repo
+	}
+	hook := GuardHookPath(repo)
+	if content, err := os.ReadFile(hook); err == nil && !strings.Contains(string(content), GuardMarker) {
+		return nil, toolError("pre-commit hook at %s was not installed by ntm; refusing to overwrite", hook)
+	}
+	if err := WriteGuardHook(hook, key, repo); err != nil {
+		return nil, err
+	}
+	return map[string]any{"installed": true, "hook": hook}, nil
+}
+
+func (s *Server) toolUninstallGuard(a args) (any, error) {
+	repo, err := a.required("code_repo_path")
+	if err != nil {
+		return nil, err
+	}
+	hook := GuardHookPath(repo)
+	content, err := os.ReadFile(hook)
+	if os.IsNotExist(err) {
+		return map[string]any{"removed": false, "hook": hook}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(string(content), GuardMarker) {
+		return nil, toolError("pre-commit hook at %s was not installed by ntm; refusing to remove", hook)
+	}
+	if err := os.Remove(hook); err != nil {
+		return nil, err
+	}
+	return map[string]any{"removed": true, "hook": hook}, nil
+}
+
+// PatternsOverlap reports whether two reservation patterns (paths, directory
+// prefixes or globs with *, ? and **) can match a common file. When both are
+// globs it compares their literal prefixes, erring towards overlap.
+func PatternsOverlap(a, b string) bool {
+	a, b = normalizePattern(a), normalizePattern(b)
+	if a == b || a == "" || b == "" {
+		return true
+	}
+	aGlob, bGlob := isGlob(a), isGlob(b)
+	switch {
+	case aGlob && bGlob:
+		pa, pb := literalPrefix(a), literalPrefix(b)
+		return strings.HasPrefix(pa, pb) || strings.HasPrefix(pb, pa)
+	case aGlob:
+		return globMatches(a, b)
+	case bGlob:
+		return globMatches(b, a)
+	default:
+		return strings.HasPrefix(a, b+"/") || strings.HasPrefix(b, a+"/")
+	}
+}
+
+func normalizePattern(p string) string {
+	p = filepath.ToSlash(strings.TrimSpace(p))
+	p = strings.TrimPrefix(p, "./")
+	if p != "/" {
+		p = strings.TrimSuffix(p, "/")
+	}
+	return p
+}
+
+func isGlob(p string) bool {
+	return strings.ContainsAny(p, "*?[")
+}
+
+func literalPrefix(p string) string {
+	if i := strings.IndexAny(p, "*?["); i >= 0 {
+		return p[:i]
+	}
+	return p
+}
+
+// globMatches reports whether pattern matches name or any of its parent
+// directories (a reserved directory glob covers the files beneath it).
+func globMatches(pattern, name string) bool {
+	if !strings.Contains(pattern, "**") {
+		for p := name; p != "." && p != "/" && p != ""; p = path.Dir(p) {
+			if ok, _ := path.Match(pattern, p); ok {
+				return true
+			}
+		}
+		return false
+	}
+	re, err := globRegexp(pattern)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(name)
+}
+
+// globRegexp translates a ** glob: "**/" matches zero or more directories,
+// a trailing "**" matches everything below, "*" and "?" stay within a segment.
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("(?:/.*)?$")
+	return regexp.Compile(b.String())
+}
+
+func commentSafe(s string) string {
+	return strings.NewReplacer("\n", " ", "\r", " ").Replace(s)
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
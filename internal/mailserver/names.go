@@ -0,0 +1,39 @@
+package mailserver
+
+import (
+	"math/rand/v2"
+	"strconv"
+)
+
+// Agent names follow the external server's AdjectiveNoun style
+// ("GreenCastle", "BlueLake") so they are interchangeable in prompts.
+var (
+	nameAdjectives = []string{
+		"Amber", "Blue", "Bold", "Brown", "Calm", "Coral", "Crimson", "Dusty",
+		"Emerald", "Frosty", "Golden", "Green", "Grey", "Indigo", "Ivory", "Jade",
+		"Lilac", "Misty", "Olive", "Orange", "Pearl", "Purple", "Quiet", "Red",
+		"Rose", "Ruby", "Sage", "Silver", "Swift", "Teal", "Violet", "White",
+	}
+	nameNouns = []string{
+		"Badger", "Bay", "Brook", "Castle", "Cliff", "Creek", "Dog", "Falcon",
+		"Fox", "Hill", "Hollow", "Lake", "Meadow", "Mountain", "Otter", "Owl",
+		"Peak", "Pond", "Ridge", "River", "Rock", "Snow", "Stone", "Tower",
+	}
+)
+
+// randomAgentName returns a candidate name; taken reports names in use.
+func randomAgentName(taken func(string) bool) string {
+	for range 64 {
+		name := nameAdjectives[rand.IntN(len(nameAdjectives))] + nameNouns[rand.IntN(len(nameNouns))]
+		if !taken(name) {
+			return name
+		}
+	}
+	// The namespace is exhausted for practical purposes; disambiguate.
+	base := nameAdjectives[rand.IntN(len(nameAdjectives))] + nameNouns[rand.IntN(len(nameNouns))]
+	for i := 2; ; i++ {
+		if name := base + strconv.Itoa(i); !taken(name) {
+			return name
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package mailserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/shahbajlive/ntm/internal/agentmail"
+	"github.com/shahbajlive/ntm/internal/state"
+)
+
+// readResource serves the resource:// URIs agentmail.Client reads:
+// file_reservations/{project} and agents/{project}.
+func (s *Server) readResource(uri string) (any, error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "resource" {
+		return nil, invalidParams("invalid resource uri: %s", uri)
+	}
+	key, err := url.PathUnescape(strings.TrimPrefix(u.EscapedPath(), "/"))
+	if err != nil || key == "" {
+		return nil, invalidParams("resource uri %s has no project", uri)
+	}
+
+	var payload any
+	switch u.Host {
+	case "file_reservations":
+		payload, err = s.toolListReservations(args{"project_key": key, "all_agents": true})
+	case "agents":
+		payload, err = s.agentsResource(key)
+	default:
+		return nil, invalidParams("unknown resource: %s", uri)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"contents": []map[string]string{{
+			"uri":      uri,
+			"mimeType": "application/json",
+			"text":     string(text),
+		}},
+	}, nil
+}
+
+func (s *Server) agentsResource(key string) (any, error) {
+	p, err := s.project(args{"project_key": key})
+	if err != nil {
+		return nil, err
+	}
+	agents, err := s.mail.ListAgents(p.ID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*agentmail.Agent, 0, len(agents))
+	for i := range agents {
+		out = append(out, toAgent(&agents[i]))
+	}
+	return map[string]any{"project": toProject(p), "agents": out}, nil
+}
+
+// overseerPreamble is prepended to Human Overseer messages, as the external
+// server does, so agents prioritise them.
+const overseerPreamble = "---\n\n**MESSAGE FROM HUMAN OVERSEER**\n\n" +
+	"This message is from a human operator overseeing this project. " +
+	"Please prioritize the instructions below over your current tasks.\n\n---\n\n"
+
+// handleOverseerSend implements POST /mail/{slug}/overseer/send. Overseer
+// messages bypass contact policies and are always high importance.
+func (s *Server) handleOverseerSend(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Recipients []string `json:"recipients"`
+		Subject    string   `json:"subject"`
+		BodyMD     string   `json:"body_md"`
+		ThreadID   string   `json:"thread_id"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"detail": "invalid request body: " + err.Error()})
+		return
+	}
+	if len(req.Recipients) == 0 || strings.TrimSpace(req.Subject) == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"detail": "recipients and subject are required"})
+		return
+	}
+
+	msg, err := s.sendOverseer(r.PathValue("slug"), req.Recipients, req.Subject, req.BodyMD, req.ThreadID)
+	if err != nil {
+		status := http.StatusBadRequest
+		var rerr *rpcError
+		if !errors.As(err, &rerr) {
+			status = http.StatusInternalServerError
+		}
+		writeJSON(w, status, map[string]string{"detail": err.Error()})
+		return
+	}
+
+	names := make([]string, 0, len(msg.Recipients))
+	for _, rc := range msg.Recipients {
+		names = append(names, rc.Name)
+	}
+	writeJSON(w, http.StatusOK, agentmail.OverseerSendResult{
+		Success:    true,
+		MessageID:  int(msg.ID),
+		Recipients: names,
+		SentAt:     flex(msg.CreatedTS),
+	})
+}
+
+func (s *Server) sendOverseer(projectKey string, recipients []string, subject, body, threadID string) (*state.MailMessage, error) {
+	p, err := s.project(args{"project_key": projectKey})
+	if err != nil {
+		return nil, err
+	}
+	overseer, err := s.mail.RegisterAgent(&state.MailAgent{
+		ProjectID:       p.ID,
+		Name:            overseerName,
+		Program:         "ntm",
+		Model:           "human",
+		TaskDescription: "Human operator",
+	})
+	if err != nil {
+		return nil, err
+	}
+	rcpts, err := s.resolveRecipients(p, overseer, recipients, nil, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	msg := &state.MailMessage{
+		ProjectID:  p.ID,
+		SenderID:   overseer.ID,
+		Sender:     overseer.Name,
+		ThreadID:   threadID,
+		Subject:    subject,
+		BodyMD:     overseerPreamble + body,
+		Importance: "high",
+		CreatedTS:  s.now(),
+		Recipients: rcpts,
+	}
+	if err := s.mail.CreateMessage(msg); err != nil {
+		return nil, fmt.Errorf("store overseer message: %w", err)
+	}
+	return msg, nil
+}
@@ -0,0 +1,254 @@
+// Package mailserver implements a local Agent Mail server backed by the ntm
+// state store. It speaks the same MCP-over-HTTP protocol as the external
+// Agent Mail server for the subset of tools agentmail.Client uses, so
+// messaging, file reservations and pre-commit guards keep working when that
+// server is not installed.
+package mailserver
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/state"
+)
+
+// DefaultPort matches the external Agent Mail server so clients need no
+// extra configuration.
+const DefaultPort = 8765
+
+// protocolVersion is the MCP protocol revision reported on initialize.
+const protocolVersion = "2025-03-26"
+
+// maxRequestBody bounds a single JSON-RPC request.
+const maxRequestBody = 10 << 20
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeToolError      = -32000
+)
+
+// Server is an http.Handler serving the Agent Mail MCP endpoint, health
+// checks and the Human Overseer REST endpoint.
+type Server struct {
+	mail    *state.MailStore
+	token   string
+	version string
+	now     func() time.Time
+	tools   map[string]toolFunc
+	mux     *http.ServeMux
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithToken requires "Authorization: Bearer <token>" on all non-health
+// requests.
+func WithToken(token string) Option {
+	return func(s *Server) { s.token = token }
+}
+
+// WithVersion sets the version reported in serverInfo.
+func WithVersion(version string) Option {
+	return func(s *Server) { s.version = version }
+}
+
+// New returns a Server persisting to store. The store must be migrated.
+func New(store *state.Store, opts ...Option) *Server {
+	s := &Server{
+		mail:    state.NewMailStore(store),
+		version: "dev",
+		now:     func() time.Time { return time.Now().UTC() },
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.tools = s.toolTable()
+
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("/mcp", s.handleMCP)
+	s.mux.HandleFunc("/mcp/", s.handleMCP)
+	s.mux.HandleFunc("GET /health", s.handleHealth)
+	s.mux.HandleFunc("GET /health/{probe}", s.handleHealth)
+	s.mux.HandleFunc("POST /mail/{slug}/overseer/send", s.handleOverseerSend)
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, "/health") && !s.authorized(r) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"detail": "invalid or missing bearer token"})
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if s.token == "" {
+		return true
+	}
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(got), []byte(s.token)) == 1
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"status":    "ok",
+		"timestamp": s.now().Format(time.RFC3339),
+	})
+}
+
+// rpcRequest is an incoming JSON-RPC 2.0 request. A missing ID marks a
+// notification.
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcError is both the wire error object and the error type tool handlers
+// return. The message wording matters: agentmail.Client classifies errors
+// by substrings such as "agent not registered" and "message not found".
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+func invalidParams(format string, args ...any) error {
+	return &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf(format, args...)}
+}
+
+func toolError(format string, args ...any) error {
+	return &rpcError{Code: codeToolError, Message: fmt.Sprintf(format, args...)}
+}
+
+func (s *Server) handleMCP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBody))
+	if err != nil {
+		http.Error(w, "read request", http.StatusBadRequest)
+		return
+	}
+
+	var req rpcRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeJSON(w, http.StatusOK, rpcResponse{
+			JSONRPC: "2.0",
+			ID:      json.RawMessage("null"),
+			Error:   &rpcError{Code: codeParseError, Message: "parse error: " + err.Error()},
+		})
+		return
+	}
+
+	result, err := s.dispatch(&req)
+	if len(req.ID) == 0 {
+		// Notifications get no response body.
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	resp := rpcResponse{JSONRPC: "2.0", ID: req.ID}
+	if err != nil {
+		var rerr *rpcError
+		if !errors.As(err, &rerr) {
+			rerr = &rpcError{Code: codeToolError, Message: err.Error()}
+		}
+		resp.Error = rerr
+	} else {
+		resp.Result = result
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) dispatch(req *rpcRequest) (any, error) {
+	switch req.Method {
+	case "":
+		return nil, &rpcError{Code: codeInvalidRequest, Message: "invalid request: missing method"}
+	case "initialize":
+		return map[string]any{
+			"protocolVersion": protocolVersion,
+			"capabilities": map[string]any{
+				"tools":     map[string]any{},
+				"resources": map[string]any{},
+			},
+			"serverInfo": map[string]string{"name": "ntm-agent-mail", "version": s.version},
+		}, nil
+	case "ping":
+		return map[string]any{}, nil
+	case "tools/list":
+		return map[string]any{"tools": s.toolList()}, nil
+	case "tools/call":
+		var params struct {
+			Name      string         `json:"name"`
+			Arguments map[string]any `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams("invalid tools/call params: %v", err)
+		}
+		tool, ok := s.tools[params.Name]
+		if !ok {
+			return nil, &rpcError{Code: codeMethodNotFound, Message: "unknown tool: " + params.Name}
+		}
+		out, err := tool(args(params.Arguments))
+		if err != nil {
+			return nil, err
+		}
+		return toolResult(out)
+	case "resources/read":
+		var params struct {
+			URI string `json:"uri"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams("invalid resources/read params: %v", err)
+		}
+		return s.readResource(params.URI)
+	default:
+		if strings.HasPrefix(req.Method, "notifications/") {
+			return nil, nil
+		}
+		return nil, &rpcError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+}
+
+// toolResult wraps a tool's output in the MCP tools/call envelope.
+func toolResult(out any) (any, error) {
+	data, err := json.Marshal(out)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"content":           []map[string]string{{"type": "text", "text": string(data)}},
+		"structuredContent": json.RawMessage(data),
+		"isError":           false,
+	}, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
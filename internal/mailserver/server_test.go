@@ -0,0 +1,382 @@
+package mailserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/agentmail"
+	"github.com/shahbajlive/ntm/internal/state"
+)
+
+func newTestServer(t *testing.T, opts ...Option) (*httptest.Server, *Server) {
+	t.Helper()
+	store, err := state.Open(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	if err := store.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	srv := New(store, opts...)
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+	return ts, srv
+}
+
+func newTestClient(ts *httptest.Server, opts ...agentmail.Option) *agentmail.Client {
+	return agentmail.NewClient(append([]agentmail.Option{agentmail.WithBaseURL(ts.URL + "/mcp/")}, opts...)...)
+}
+
+func register(t *testing.T, c *agentmail.Client, project, name string) *agentmail.Agent {
+	t.Helper()
+	a, err := c.RegisterAgent(context.Background(), agentmail.RegisterAgentOptions{
+		ProjectKey: project, Program: "claude-code", Model: "test", Name: name,
+	})
+	if err != nil {
+		t.Fatalf("RegisterAgent(%s): %v", name, err)
+	}
+	return a
+}
+
+func TestServerMessaging(t *testing.T) {
+	ts, _ := newTestServer(t)
+	c := newTestClient(ts)
+	ctx := context.Background()
+	project := "/work/app"
+
+	if !c.IsAvailable() {
+		t.Fatal("client reports server unavailable")
+	}
+	if p, err := c.EnsureProject(ctx, project); err != nil || p.Slug != "app" {
+		t.Fatalf("EnsureProject = %+v, %v", p, err)
+	}
+	register(t, c, project, "BlueLake")
+	register(t, c, project, "RedStone")
+	generated := register(t, c, project, "")
+	if generated.Name == "" {
+		t.Error("expected a generated agent name")
+	}
+
+	sent, err := c.SendMessage(ctx, agentmail.SendMessageOptions{
+		ProjectKey: project, SenderName: "BlueLake", To: []string{"RedStone"},
+		Subject: "API review", BodyMD: "- [ ] check handlers", AckRequired: true, ThreadID: "FEAT-1",
+	})
+	if err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	if sent.Count != 1 || sent.Deliveries[0].Payload.From != "BlueLake" {
+		t.Fatalf("SendMessage result = %+v", sent)
+	}
+	msgID := sent.Deliveries[0].Payload.ID
+
+	inbox, err := c.FetchInbox(ctx, agentmail.FetchInboxOptions{ProjectKey: project, AgentName: "RedStone", IncludeBodies: true})
+	if err != nil {
+		t.Fatalf("FetchInbox: %v", err)
+	}
+	if len(inbox) != 1 || inbox[0].ID != msgID || inbox[0].BodyMD == "" || inbox[0].ReadAt != nil {
+		t.Fatalf("inbox = %+v", inbox)
+	}
+
+	if err := c.AcknowledgeMessage(ctx, project, "RedStone", msgID); err != nil {
+		t.Fatalf("AcknowledgeMessage: %v", err)
+	}
+	inbox, _ = c.FetchInbox(ctx, agentmail.FetchInboxOptions{ProjectKey: project, AgentName: "RedStone"})
+	if inbox[0].ReadAt == nil {
+		t.Error("acknowledged message should be read")
+	}
+
+	reply, err := c.ReplyMessage(ctx, agentmail.ReplyMessageOptions{
+		ProjectKey: project, MessageID: msgID, SenderName: "RedStone", BodyMD: "done",
+	})
+	if err != nil {
+		t.Fatalf("ReplyMessage: %v", err)
+	}
+	if reply.Subject != "Re: API review" || len(reply.To) != 1 || reply.To[0] != "BlueLake" || *reply.ThreadID != "FEAT-1" {
+		t.Errorf("reply = %+v", reply)
+	}
+
+	summary, err := c.SummarizeThread(ctx, agentmail.SummarizeThreadOptions{ProjectKey: project, ThreadID: "FEAT-1"})
+	if err != nil {
+		t.Fatalf("SummarizeThread: %v", err)
+	}
+	if len(summary.Participants) != 2 || len(summary.KeyPoints) != 2 || len(summary.ActionItems) != 2 {
+		t.Errorf("summary = %+v", summary)
+	}
+
+	results, err := c.SearchMessages(ctx, agentmail.SearchOptions{ProjectKey: project, Query: "review"})
+	if err != nil || len(results) != 2 {
+		t.Errorf("SearchMessages = %+v, %v", results, err)
+	}
+
+	agents, err := c.ListProjectAgents(ctx, project)
+	if err != nil || len(agents) != 3 {
+		t.Errorf("ListProjectAgents = %+v, %v", agents, err)
+	}
+
+	_, err = c.SendMessage(ctx, agentmail.SendMessageOptions{
+		ProjectKey: project, SenderName: "Ghost", To: []string{"RedStone"}, Subject: "hi",
+	})
+	if !errors.Is(err, agentmail.ErrAgentNotRegistered) {
+		t.Errorf("send from unknown agent err = %v, want ErrAgentNotRegistered", err)
+	}
+	if _, err := c.GetMessage(ctx, project, 9999); !errors.Is(err, agentmail.ErrMessageNotFound) {
+		t.Errorf("GetMessage(9999) err = %v, want ErrMessageNotFound", err)
+	}
+}
+
+func TestServerContactPolicy(t *testing.T) {
+	ts, _ := newTestServer(t)
+	c := newTestClient(ts)
+	ctx := context.Background()
+	project := "/work/app"
+	register(t, c, project, "BlueLake")
+	register(t, c, project, "RedStone")
+
+	if err := c.SetContactPolicy(ctx, project, "RedStone", "contacts_only"); err != nil {
+		t.Fatalf("SetContactPolicy: %v", err)
+	}
+	send := func() error {
+		_, err := c.SendMessage(ctx, agentmail.SendMessageOptions{
+			ProjectKey: project, SenderName: "BlueLake", To: []string{"RedStone"}, Subject: "hello",
+		})
+		return err
+	}
+	if err := send(); err == nil || !strings.Contains(err.Error(), "contact approval required") {
+		t.Fatalf("send before approval err = %v", err)
+	}
+
+	req, err := c.RequestContact(ctx, agentmail.RequestContactOptions{ProjectKey: project, FromAgent: "BlueLake", ToAgent: "RedStone"})
+	if err != nil || req.Status != "pending" {
+		t.Fatalf("RequestContact = %+v, %v", req, err)
+	}
+	if err := c.RespondContact(ctx, agentmail.RespondContactOptions{ProjectKey: project, ToAgent: "RedStone", FromAgent: "BlueLake", Accept: true}); err != nil {
+		t.Fatalf("RespondContact: %v", err)
+	}
+	if err := send(); err != nil {
+		t.Fatalf("send after approval: %v", err)
+	}
+	contacts, err := c.ListContacts(ctx, project, "RedStone")
+	if err != nil || len(contacts) != 1 || !contacts[0].Approved || contacts[0].To != "BlueLake" {
+		t.Errorf("ListContacts = %+v, %v", contacts, err)
+	}
+}
+
+func TestServerReservations(t *testing.T) {
+	ts, srv := newTestServer(t)
+	c := newTestClient(ts)
+	ctx := context.Background()
+	project := "/work/app"
+	register(t, c, project, "BlueLake")
+	register(t, c, project, "RedStone")
+
+	res, err := c.ReservePaths(ctx, agentmail.FileReservationOptions{
+		ProjectKey: project, AgentName: "BlueLake", Paths: []string{"internal/cli/**"}, TTLSeconds: 7200, Exclusive: true, Reason: "refactor",
+	})
+	if err != nil || len(res.Granted) != 1 {
+		t.Fatalf("ReservePaths = %+v, %v", res, err)
+	}
+	blueID := res.Granted[0].ID
+
+	res, err = c.ReservePaths(ctx, agentmail.FileReservationOptions{
+		ProjectKey: project, AgentName: "RedStone", Paths: []string{"internal/cli/mail.go", "docs/README.md"}, Exclusive: true,
+	})
+	if !errors.Is(err, agentmail.ErrReservationConflict) {
+		t.Fatalf("overlapping ReservePaths err = %v, want conflict", err)
+	}
+	if len(res.Granted) != 1 || res.Granted[0].PathPattern != "docs/README.md" {
+		t.Errorf("granted = %+v", res.Granted)
+	}
+	if len(res.Conflicts) != 1 || res.Conflicts[0].Holders[0] != "BlueLake" {
+		t.Errorf("conflicts = %+v", res.Conflicts)
+	}
+
+	conflicts, err := c.CheckConflicts(ctx, project, []string{"internal/cli/root.go", "README.md"})
+	if err != nil || len(conflicts) != 1 || conflicts[0].Path != "internal/cli/root.go" {
+		t.Errorf("CheckConflicts = %+v, %v", conflicts, err)
+	}
+
+	all, err := c.ListReservations(ctx, project, "", true)
+	if err != nil || len(all) != 2 {
+		t.Fatalf("ListReservations = %+v, %v", all, err)
+	}
+	mine, _ := c.ListReservations(ctx, project, "BlueLake", false)
+	if len(mine) != 1 || mine[0].Reason != "refactor" {
+		t.Errorf("ListReservations(BlueLake) = %+v", mine)
+	}
+
+	renewed, err := c.RenewReservations(ctx, agentmail.RenewReservationsOptions{ProjectKey: project, AgentName: "BlueLake", ExtendSeconds: 300})
+	if err != nil || renewed.Renewed != 1 {
+		t.Fatalf("RenewReservations = %+v, %v", renewed, err)
+	}
+	if got := renewed.Reservations[0].NewExpiresTS.Sub(renewed.Reservations[0].OldExpiresTS.Time); got != 5*time.Minute {
+		t.Errorf("renewal extended by %v, want 5m", got)
+	}
+
+	// RedStone cannot force-release while BlueLake is active...
+	_, err = c.ForceReleaseReservation(ctx, agentmail.ForceReleaseOptions{ProjectKey: project, AgentName: "RedStone", ReservationID: blueID})
+	if err == nil {
+		t.Fatal("force release of an active holder's reservation succeeded")
+	}
+	// ...but can once BlueLake has been idle long enough.
+	srv.now = func() time.Time { return time.Now().UTC().Add(staleHolderAfter + time.Minute) }
+	fr, err := c.ForceReleaseReservation(ctx, agentmail.ForceReleaseOptions{
+		ProjectKey: project, AgentName: "RedStone", ReservationID: blueID, NotifyPrevious: true,
+	})
+	srv.now = func() time.Time { return time.Now().UTC() }
+	if err != nil || !fr.Success || fr.PreviousHolder != "BlueLake" || !fr.Notified {
+		t.Fatalf("ForceReleaseReservation = %+v, %v", fr, err)
+	}
+
+	if err := c.ReleaseReservations(ctx, project, "RedStone", nil, nil); err != nil {
+		t.Fatalf("ReleaseReservations: %v", err)
+	}
+	if all, _ := c.ListReservations(ctx, project, "", true); len(all) != 0 {
+		t.Errorf("reservations after release = %+v", all)
+	}
+}
+
+func TestServerReservationsRace(t *testing.T) {
+	ts, _ := newTestServer(t)
+	c := newTestClient(ts)
+	ctx := context.Background()
+	project := "/work/app"
+	names := []string{"BlueLake", "RedStone", "GreenCastle", "PinkHill"}
+	for _, name := range names {
+		register(t, c, project, name)
+	}
+
+	// Agents racing for the same path: exactly one may win it.
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	granted := 0
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			res, err := c.ReservePaths(ctx, agentmail.FileReservationOptions{
+				ProjectKey: project, AgentName: name, Paths: []string{"internal/cli/**"}, Exclusive: true,
+			})
+			if err != nil && !errors.Is(err, agentmail.ErrReservationConflict) {
+				t.Errorf("ReservePaths(%s): %v", name, err)
+				return
+			}
+			mu.Lock()
+			granted += len(res.Granted)
+			mu.Unlock()
+		}(name)
+	}
+	wg.Wait()
+	if granted != 1 {
+		t.Errorf("%d agents were granted the same exclusive path, want 1", granted)
+	}
+	all, err := c.ListReservations(ctx, project, "", true)
+	if err != nil || len(all) != 1 {
+		t.Errorf("ListReservations = %+v, %v; want one reservation", all, err)
+	}
+}
+
+func TestServerOverseerAndAuth(t *testing.T) {
+	ts, _ := newTestServer(t, WithToken("secret"))
+	ctx := context.Background()
+	project := "/work/app"
+
+	if _, err := newTestClient(ts).HealthCheck(ctx); !errors.Is(err, agentmail.ErrUnauthorized) {
+		t.Fatalf("unauthenticated HealthCheck err = %v, want ErrUnauthorized", err)
+	}
+	resp, err := http.Get(ts.URL + "/health/liveness")
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("liveness = %v, %v", resp, err)
+	}
+	resp.Body.Close()
+
+	c := newTestClient(ts, agentmail.WithToken("secret"))
+	register(t, c, project, "BlueLake")
+	if err := c.SetContactPolicy(ctx, project, "BlueLake", "block_all"); err != nil {
+		t.Fatalf("SetContactPolicy: %v", err)
+	}
+
+	sent, err := c.SendOverseerMessage(ctx, agentmail.OverseerMessageOptions{
+		ProjectSlug: "app", Recipients: []string{"BlueLake"}, Subject: "Stop", BodyMD: "checkpoint now",
+	})
+	if err != nil || !sent.Success || sent.MessageID == 0 {
+		t.Fatalf("SendOverseerMessage = %+v, %v", sent, err)
+	}
+	inbox, err := c.FetchInbox(ctx, agentmail.FetchInboxOptions{ProjectKey: project, AgentName: "BlueLake", IncludeBodies: true})
+	if err != nil || len(inbox) != 1 {
+		t.Fatalf("FetchInbox = %+v, %v", inbox, err)
+	}
+	if inbox[0].From != overseerName || inbox[0].Importance != "high" || !strings.Contains(inbox[0].BodyMD, "HUMAN OVERSEER") {
+		t.Errorf("overseer message = %+v", inbox[0])
+	}
+}
+
+func TestServerPrecommitGuard(t *testing.T) {
+	ts, _ := newTestServer(t)
+	c := newTestClient(ts)
+	ctx := context.Background()
+	repo := t.TempDir()
+
+	if err := c.InstallPrecommitGuard(ctx, repo, repo); err != nil {
+		t.Fatalf("InstallPrecommitGuard: %v", err)
+	}
+	content, err := os.ReadFile(GuardHookPath(repo))
+	if err != nil {
+		t.Fatalf("read hook: %v", err)
+	}
+	if !strings.Contains(string(content), GuardMarker) || !strings.Contains(string(content), "ntm mail guard-check --project '"+repo+"'") {
+		t.Errorf("hook content:\n%s", content)
+	}
+	if err := c.UninstallPrecommitGuard(ctx, repo); err != nil {
+		t.Fatalf("UninstallPrecommitGuard: %v", err)
+	}
+	if _, err := os.Stat(GuardHookPath(repo)); !os.IsNotExist(err) {
+		t.Errorf("hook still present after uninstall: %v", err)
+	}
+
+	// A foreign hook is left alone.
+	if err := os.WriteFile(GuardHookPath(repo), []byte("#!/bin/sh\nexit 0\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.InstallPrecommitGuard(ctx, repo, repo); err == nil {
+		t.Error("install over a foreign hook succeeded")
+	}
+}
+
+func TestPatternsOverlap(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"internal/cli/mail.go", "internal/cli/mail.go", true},
+		{"internal/cli", "internal/cli/mail.go", true},
+		{"internal/cli/", "internal/cli/mail.go", true},
+		{"internal/cl", "internal/cli/mail.go", false},
+		{"internal/cli/*.go", "internal/cli/mail.go", true},
+		{"internal/*.go", "internal/cli/mail.go", false},
+		{"internal/*", "internal/cli/mail.go", true},
+		{"internal/**", "internal/cli/mail.go", true},
+		{"**/*.go", "internal/cli/mail.go", true},
+		{"**/*.go", "main.go", true},
+		{"**/*.md", "internal/cli/mail.go", false},
+		{"internal/**/*.go", "internal/cli/*.go", true},
+		{"internal/**", "docs/*", false},
+		{"./docs/README.md", "docs/README.md", true},
+	}
+	for _, tt := range tests {
+		if got := PatternsOverlap(tt.a, tt.b); got != tt.want {
+			t.Errorf("PatternsOverlap(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+		if got := PatternsOverlap(tt.b, tt.a); got != tt.want {
+			t.Errorf("PatternsOverlap(%q, %q) = %v, want %v", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
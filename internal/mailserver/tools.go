@@ -0,0 +1,1172 @@
+package mailserver
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/agentmail"
+	"github.com/shahbajlive/ntm/internal/state"
+)
+
+const (
+	// defaultReservationTTL applies when file_reservation_paths omits ttl_seconds.
+	defaultReservationTTL = time.Hour
+	// minReservationTTL keeps reservations from expiring before they are used.
+	minReservationTTL = time.Minute
+	// defaultRenewExtension applies when renew_file_reservations omits extend_seconds.
+	defaultRenewExtension = 30 * time.Minute
+	// staleHolderAfter is how long a holder must be idle before another
+	// agent may force-release its reservation.
+	staleHolderAfter = 30 * time.Minute
+	// defaultInboxLimit applies when fetch_inbox omits limit.
+	defaultInboxLimit = 20
+
+	// overseerName is the identity used for Human Overseer messages.
+	overseerName = "HumanOverseer"
+)
+
+// Contact policies, as in the external server.
+var contactPolicies = map[string]bool{
+	"open":          true,
+	"auto":          true,
+	"contacts_only": true,
+	"block_all":     true,
+}
+
+type toolFunc func(a args) (any, error)
+
+func (s *Server) toolTable() map[string]toolFunc {
+	return map[string]toolFunc{
+		"health_check":                   s.toolHealthCheck,
+		"ensure_project":                 s.toolEnsureProject,
+		"register_agent":                 s.toolRegisterAgent,
+		"create_agent_identity":          s.toolCreateAgentIdentity,
+		"whois":                          s.toolWhois,
+		"send_message":                   s.toolSendMessage,
+		"reply_message":                  s.toolReplyMessage,
+		"fetch_inbox":                    s.toolFetchInbox,
+		"mark_message_read":              s.toolMarkMessageRead,
+		"acknowledge_message":            s.toolAcknowledgeMessage,
+		"get_message":                    s.toolGetMessage,
+		"search_messages":                s.toolSearchMessages,
+		"summarize_thread":               s.toolSummarizeThread,
+		"request_contact":                s.toolRequestContact,
+		"respond_contact":                s.toolRespondContact,
+		"list_contacts":                  s.toolListContacts,
+		"set_contact_policy":             s.toolSetContactPolicy,
+		"file_reservation_paths":         s.toolReservePaths,
+		"release_file_reservations":      s.toolReleaseReservations,
+		"renew_file_reservations":        s.toolRenewReservations,
+		"list_file_reservations":         s.toolListReservations,
+		"list_reservations":              s.toolListReservations,
+		"force_release_file_reservation": s.toolForceRelease,
+		"macro_start_session":            s.toolStartSession,
+		"macro_prepare_thread":           s.toolPrepareThread,
+		"macro_contact_handshake":        s.toolContactHandshake,
+		"install_precommit_guard":        s.toolInstallGuard,
+		"uninstall_precommit_guard":      s.toolUninstallGuard,
+	}
+}
+
+func (s *Server) toolList() []map[string]any {
+	names := make([]string, 0, len(s.tools))
+	for name := range s.tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := make([]map[string]any, 0, len(names))
+	for _, name := range names {
+		out = append(out, map[string]any{
+			"name":        name,
+			"inputSchema": map[string]any{"type": "object"},
+		})
+	}
+	return out
+}
+
+// ========================
+// Lookups
+// ========================
+
+// project resolves project_key (or human_key). Absolute paths are
+// registered on first use, as most clients skip ensure_project.
+func (s *Server) project(a args) (*state.MailProject, error) {
+	key := a.str("project_key", "human_key")
+	if key == "" {
+		return nil, invalidParams("missing required argument: project_key")
+	}
+	if filepath.IsAbs(key) {
+		p, err := s.mail.EnsureProject(key, agentmail.ProjectSlugFromPath(key))
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+	}
+	p, err := s.mail.FindProject(key)
+	if errors.Is(err, state.ErrMailNotFound) {
+		return nil, toolError("project not found: %s", key)
+	}
+	return p, err
+}
+
+// agent resolves a registered agent and records its activity.
+func (s *Server) agent(p *state.MailProject, name string) (*state.MailAgent, error) {
+	if name == "" {
+		return nil, invalidParams("missing required argument: agent_name")
+	}
+	ag, err := s.mail.GetAgent(p.ID, name)
+	if errors.Is(err, state.ErrMailNotFound) {
+		return nil, toolError("agent not registered: %s", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	_ = s.mail.TouchAgent(ag.ID)
+	return ag, nil
+}
+
+func (s *Server) projectAgent(a args, keys ...string) (*state.MailProject, *state.MailAgent, error) {
+	p, err := s.project(a)
+	if err != nil {
+		return nil, nil, err
+	}
+	ag, err := s.agent(p, a.str(keys...))
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, ag, nil
+}
+
+func (s *Server) nameTaken(projectID int64) func(string) bool {
+	return func(name string) bool {
+		_, err := s.mail.GetAgent(projectID, name)
+		return err == nil
+	}
+}
+
+// register creates or refreshes an agent; an empty name gets a generated one.
+func (s *Server) register(p *state.MailProject, name string, a args) (*state.MailAgent, error) {
+	if name == "" {
+		name = randomAgentName(s.nameTaken(p.ID))
+	}
+	return s.mail.RegisterAgent(&state.MailAgent{
+		ProjectID:       p.ID,
+		Name:            name,
+		Program:         a.str("program"),
+		Model:           a.str("model"),
+		TaskDescription: a.str("task_description"),
+	})
+}
+
+// ========================
+// Conversions
+// ========================
+
+func flex(t time.Time) agentmail.FlexTime { return agentmail.FlexTime{Time: t.UTC()} }
+
+func flexPtr(t *time.Time) *agentmail.FlexTime {
+	if t == nil {
+		return nil
+	}
+	f := flex(*t)
+	return &f
+}
+
+func toProject(p *state.MailProject) *agentmail.Project {
+	return &agentmail.Project{ID: int(p.ID), Slug: p.Slug, HumanKey: p.HumanKey, CreatedAt: flex(p.CreatedAt)}
+}
+
+func toAgent(a *state.MailAgent) *agentmail.Agent {
+	return &agentmail.Agent{
+		ID:              int(a.ID),
+		Name:            a.Name,
+		Program:         a.Program,
+		Model:           a.Model,
+		TaskDescription: a.TaskDescription,
+		InceptionTS:     flex(a.InceptionTS),
+		LastActiveTS:    flex(a.LastActiveTS),
+		ProjectID:       int(a.ProjectID),
+	}
+}
+
+func threadPtr(id string) *string {
+	if id == "" {
+		return nil
+	}
+	return &id
+}
+
+// toMessage renders a message. Blind-copy recipients are never shown.
+func toMessage(m *state.MailMessage) *agentmail.Message {
+	out := &agentmail.Message{
+		ID:          int(m.ID),
+		ProjectID:   int(m.ProjectID),
+		SenderID:    int(m.SenderID),
+		ThreadID:    threadPtr(m.ThreadID),
+		Subject:     m.Subject,
+		BodyMD:      m.BodyMD,
+		From:        m.Sender,
+		To:          []string{},
+		Importance:  m.Importance,
+		AckRequired: m.AckRequired,
+		CreatedTS:   flex(m.CreatedTS),
+	}
+	for _, r := range m.Recipients {
+		switch r.Kind {
+		case "cc":
+			out.CC = append(out.CC, r.Name)
+		case "bcc":
+		default:
+			out.To = append(out.To, r.Name)
+		}
+	}
+	return out
+}
+
+func toInbox(m *state.MailMessage, includeBody bool) agentmail.InboxMessage {
+	out := agentmail.InboxMessage{
+		ID:          int(m.ID),
+		Subject:     m.Subject,
+		From:        m.Sender,
+		CreatedTS:   flex(m.CreatedTS),
+		ThreadID:    threadPtr(m.ThreadID),
+		Importance:  m.Importance,
+		AckRequired: m.AckRequired,
+		Kind:        "to",
+	}
+	if includeBody {
+		out.BodyMD = m.BodyMD
+	}
+	if len(m.Recipients) > 0 {
+		out.Kind = m.Recipients[0].Kind
+		out.ReadAt = flexPtr(m.Recipients[0].ReadTS)
+	}
+	return out
+}
+
+func toReservation(r *state.MailReservation) agentmail.FileReservation {
+	return agentmail.FileReservation{
+		ID:          int(r.ID),
+		PathPattern: r.PathPattern,
+		AgentName:   r.AgentName,
+		ProjectID:   int(r.ProjectID),
+		Exclusive:   r.Exclusive,
+		Reason:      r.Reason,
+		ExpiresTS:   flex(r.ExpiresTS),
+		CreatedTS:   flex(r.CreatedTS),
+		ReleasedTS:  flexPtr(r.ReleasedTS),
+	}
+}
+
+func toContact(c *state.MailContact) agentmail.ContactLink {
+	updated := flex(c.UpdatedTS)
+	return agentmail.ContactLink{
+		FromAgent: c.FromAgent,
+		ToAgent:   c.ToAgent,
+		Status:    c.Status,
+		Reason:    c.Reason,
+		Approved:  c.Status == "approved",
+		UpdatedTS: &updated,
+		ExpiresTS: flexPtr(c.ExpiresTS),
+	}
+}
+
+// ========================
+// Identity
+// ========================
+
+func (s *Server) toolHealthCheck(a args) (any, error) {
+	return agentmail.HealthStatus{Status: "ok", Timestamp: s.now().Format(time.RFC3339)}, nil
+}
+
+func (s *Server) toolEnsureProject(a args) (any, error) {
+	key := a.str("human_key", "project_key")
+	if key == "" {
+		return nil, invalidParams("missing required argument: human_key")
+	}
+	p, err := s.mail.EnsureProject(key, agentmail.ProjectSlugFromPath(key))
+	if err != nil {
+		return nil, err
+	}
+	return toProject(p), nil
+}
+
+func (s *Server) toolRegisterAgent(a args) (any, error) {
+	p, err := s.project(a)
+	if err != nil {
+		return nil, err
+	}
+	ag, err := s.register(p, a.str("name", "agent_name"), a)
+	if err != nil {
+		return nil, err
+	}
+	return toAgent(ag), nil
+}
+
+func (s *Server) toolCreateAgentIdentity(a args) (any, error) {
+	p, err := s.project(a)
+	if err != nil {
+		return nil, err
+	}
+	// Unlike register_agent this always mints a new identity; a taken hint
+	// falls back to a generated name.
+	name := a.str("name_hint", "name")
+	if name == "" || s.nameTaken(p.ID)(name) {
+		name = ""
+	}
+	ag, err := s.register(p, name, a)
+	if err != nil {
+		return nil, err
+	}
+	return toAgent(ag), nil
+}
+
+func (s *Server) toolWhois(a args) (any, error) {
+	_, ag, err := s.projectAgent(a, "agent_name")
+	if err != nil {
+		return nil, err
+	}
+	return toAgent(ag), nil
+}
+
+// ========================
+// Messaging
+// ========================
+
+// resolveRecipients maps to/cc/bcc names to agents, rejecting unknown names
+// and recipients whose contact policy refuses the sender.
+func (s *Server) resolveRecipients(p *state.MailProject, sender *state.MailAgent, to, cc, bcc []string, bypassPolicy bool) ([]state.MailRecipient, error) {
+	if len(to)+len(cc)+len(bcc) == 0 {
+		return nil, invalidParams("at least one recipient is required")
+	}
+	var out []state.MailRecipient
+	seen := make(map[int64]bool)
+	for _, group := range []struct {
+		kind  string
+		names []string
+	}{{"to", to}, {"cc", cc}, {"bcc", bcc}} {
+		for _, name := range group.names {
+			ag, err := s.agent(p, name)
+			if err != nil {
+				return nil, err
+			}
+			if seen[ag.ID] {
+				continue
+			}
+			seen[ag.ID] = true
+			if !bypassPolicy {
+				if err := s.checkContactPolicy(sender, ag); err != nil {
+					return nil, err
+				}
+			}
+			out = append(out, state.MailRecipient{AgentID: ag.ID, Name: ag.Name, Kind: group.kind})
+		}
+	}
+	return out, nil
+}
+
+func (s *Server) checkContactPolicy(sender, recipient *state.MailAgent) error {
+	if sender.ID == recipient.ID {
+		return nil
+	}
+	switch recipient.ContactPolicy {
+	case "block_all":
+		return toolError("contact refused: %s does not accept messages", recipient.Name)
+	case "contacts_only":
+		ok, err := s.mail.ContactApproved(sender.ID, recipient.ID, s.now())
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return toolError("contact approval required: %s only accepts messages from approved contacts (use request_contact)", recipient.Name)
+		}
+	}
+	return nil
+}
+
+func (s *Server) toolSendMessage(a args) (any, error) {
+	p, sender, err := s.projectAgent(a, "sender_name")
+	if err != nil {
+		return nil, err
+	}
+	subject, err := a.required("subject")
+	if err != nil {
+		return nil, err
+	}
+	recipients, err := s.resolveRecipients(p, sender, a.strs("to"), a.strs("cc"), a.strs("bcc"), false)
+	if err != nil {
+		return nil, err
+	}
+	msg := &state.MailMessage{
+		ProjectID:   p.ID,
+		SenderID:    sender.ID,
+		Sender:      sender.Name,
+		ThreadID:    a.str("thread_id"),
+		Subject:     subject,
+		BodyMD:      a.str("body_md"),
+		Importance:  importance(a.str("importance")),
+		AckRequired: a.boolean("ack_required", false),
+		CreatedTS:   s.now(),
+		Recipients:  recipients,
+	}
+	if err := s.mail.CreateMessage(msg); err != nil {
+		return nil, err
+	}
+	return agentmail.SendResult{
+		Deliveries: []agentmail.MessageDelivery{{Project: p.HumanKey, Payload: toMessage(msg)}},
+		Count:      1,
+	}, nil
+}
+
+func importance(v string) string {
+	switch v {
+	case "low", "high", "urgent":
+		return v
+	default:
+		return "normal"
+	}
+}
+
+func (s *Server) message(p *state.MailProject, a args) (*state.MailMessage, error) {
+	id := a.integer("message_id", 0)
+	if id <= 0 {
+		return nil, invalidParams("missing required argument: message_id")
+	}
+	msg, err := s.mail.GetMessage(p.ID, int64(id))
+	if errors.Is(err, state.ErrMailNotFound) {
+		return nil, toolError("message not found: %d", id)
+	}
+	return msg, err
+}
+
+func (s *Server) toolReplyMessage(a args) (any, error) {
+	p, sender, err := s.projectAgent(a, "sender_name")
+	if err != nil {
+		return nil, err
+	}
+	orig, err := s.message(p, a)
+	if err != nil {
+		return nil, err
+	}
+
+	to := a.strs("to")
+	if len(to) == 0 {
+		to = []string{orig.Sender}
+	}
+	recipients, err := s.resolveRecipients(p, sender, to, a.strs("cc"), a.strs("bcc"), false)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := a.str("subject_prefix")
+	if prefix == "" {
+		prefix = "Re:"
+	}
+	subject := orig.Subject
+	if !strings.HasPrefix(strings.ToLower(subject), strings.ToLower(prefix)) {
+		subject = prefix + " " + subject
+	}
+	thread := orig.ThreadID
+	if thread == "" {
+		thread = strconv.FormatInt(orig.ID, 10)
+	}
+
+	msg := &state.MailMessage{
+		ProjectID:   p.ID,
+		SenderID:    sender.ID,
+		Sender:      sender.Name,
+		ThreadID:    thread,
+		Subject:     subject,
+		BodyMD:      a.str("body_md"),
+		Importance:  orig.Importance,
+		AckRequired: orig.AckRequired,
+		CreatedTS:   s.now(),
+		Recipients:  recipients,
+	}
+	if err := s.mail.CreateMessage(msg); err != nil {
+		return nil, err
+	}
+	return toMessage(msg), nil
+}
+
+func (s *Server) inbox(ag *state.MailAgent, a args, limit int, includeBodies bool) ([]agentmail.InboxMessage, error) {
+	since, err := a.timestamp("since_ts")
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := s.mail.Inbox(ag.ID, state.MailInboxQuery{
+		Since:      since,
+		UrgentOnly: a.boolean("urgent_only", false),
+		Limit:      limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]agentmail.InboxMessage, 0, len(msgs))
+	for i := range msgs {
+		out = append(out, toInbox(&msgs[i], includeBodies))
+	}
+	return out, nil
+}
+
+func (s *Server) toolFetchInbox(a args) (any, error) {
+	_, ag, err := s.projectAgent(a, "agent_name")
+	if err != nil {
+		return nil, err
+	}
+	return s.inbox(ag, a, a.integer("limit", defaultInboxLimit), a.boolean("include_bodies", false))
+}
+
+func (s *Server) markMessage(a args, ack bool) (any, error) {
+	p, ag, err := s.projectAgent(a, "agent_name")
+	if err != nil {
+		return nil, err
+	}
+	msg, err := s.message(p, a)
+	if err != nil {
+		return nil, err
+	}
+	if ack {
+		err = s.mail.Acknowledge(msg.ID, ag.ID)
+	} else {
+		err = s.mail.MarkRead(msg.ID, ag.ID)
+	}
+	if errors.Is(err, state.ErrMailNotFound) {
+		return nil, toolError("message not found: %d is not addressed to %s", msg.ID, ag.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	ts := s.now().Format(time.RFC3339Nano)
+	if ack {
+		return map[string]any{"message_id": msg.ID, "acknowledged": true, "acknowledged_at": ts}, nil
+	}
+	return map[string]any{"message_id": msg.ID, "read": true, "read_at": ts}, nil
+}
+
+func (s *Server) toolMarkMessageRead(a args) (any, error)    { return s.markMessage(a, false) }
+func (s *Server) toolAcknowledgeMessage(a args) (any, error) { return s.markMessage(a, true) }
+
+func (s *Server) toolGetMessage(a args) (any, error) {
+	p, err := s.project(a)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := s.message(p, a)
+	if err != nil {
+		return nil, err
+	}
+	return toMessage(msg), nil
+}
+
+func (s *Server) toolSearchMessages(a args) (any, error) {
+	p, err := s.project(a)
+	if err != nil {
+		return nil, err
+	}
+	query, err := a.required("query")
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := s.mail.SearchMessages(p.ID, query, a.integer("limit", 20))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]agentmail.SearchResult, 0, len(msgs))
+	for _, m := range msgs {
+		out = append(out, agentmail.SearchResult{
+			ID:          int(m.ID),
+			Subject:     m.Subject,
+			Importance:  m.Importance,
+			AckRequired: m.AckRequired,
+			CreatedTS:   flex(m.CreatedTS),
+			ThreadID:    threadPtr(m.ThreadID),
+			From:        m.Sender,
+		})
+	}
+	return out, nil
+}
+
+// summarize builds a thread summary without an LLM: participants, one key
+// point per message, and open action items (unchecked task-list entries and
+// unacknowledged messages that require an acknowledgement).
+func (s *Server) summarize(p *state.MailProject, threadID string) (*agentmail.ThreadSummary, []state.MailMessage, error) {
+	msgs, err := s.mail.ThreadMessages(p.ID, threadID)
+	if err != nil {
+		return nil, nil, err
+	}
+	sum := &agentmail.ThreadSummary{
+		ThreadID:     threadID,
+		Participants: []string{},
+		KeyPoints:    []string{},
+		ActionItems:  []string{},
+	}
+	seen := make(map[string]bool)
+	addParticipant := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			sum.Participants = append(sum.Participants, name)
+		}
+	}
+	for _, m := range msgs {
+		addParticipant(m.Sender)
+		var pending []string
+		for _, r := range m.Recipients {
+			if r.Kind != "bcc" {
+				addParticipant(r.Name)
+			}
+			if m.AckRequired && r.AckTS == nil {
+				pending = append(pending, r.Name)
+			}
+		}
+		sum.KeyPoints = append(sum.KeyPoints, fmt.Sprintf("%s: %s", m.Sender, m.Subject))
+		for _, line := range strings.Split(m.BodyMD, "\n") {
+			line = strings.TrimSpace(line)
+			if item, ok := strings.CutPrefix(line, "- [ ] "); ok {
+				sum.ActionItems = append(sum.ActionItems, item)
+			} else if strings.HasPrefix(line, "TODO") {
+				sum.ActionItems = append(sum.ActionItems, line)
+			}
+		}
+		if len(pending) > 0 {
+			sum.ActionItems = append(sum.ActionItems,
+				fmt.Sprintf("Acknowledge %q (%s)", m.Subject, strings.Join(pending, ", ")))
+		}
+	}
+	sort.Strings(sum.Participants)
+	return sum, msgs, nil
+}
+
+func (s *Server) toolSummarizeThread(a args) (any, error) {
+	p, err := s.project(a)
+	if err != nil {
+		return nil, err
+	}
+	threadID, err := a.required("thread_id")
+	if err != nil {
+		return nil, err
+	}
+	sum, _, err := s.summarize(p, threadID)
+	if err != nil {
+		return nil, err
+	}
+	return sum, nil
+}
+
+// ========================
+// Contacts
+// ========================
+
+func (s *Server) contactTTL(a args) *time.Time {
+	ttl := a.integer("ttl_seconds", 0)
+	if ttl <= 0 {
+		return nil
+	}
+	t := s.now().Add(time.Duration(ttl) * time.Second)
+	return &t
+}
+
+// requestContact records a request from one agent to another and resolves
+// it immediately according to the target's policy.
+func (s *Server) requestContact(p *state.MailProject, from, to *state.MailAgent, reason string, expires *time.Time) (*agentmail.ContactLink, error) {
+	status := "pending"
+	switch to.ContactPolicy {
+	case "open", "auto":
+		status = "approved"
+	case "block_all":
+		status = "blocked"
+	}
+	if err := s.mail.UpsertContact(p.ID, from.ID, to.ID, status, reason, expires); err != nil {
+		return nil, err
+	}
+	return s.contactLink(from, to)
+}
+
+func (s *Server) contactLink(from, to *state.MailAgent) (*agentmail.ContactLink, error) {
+	contacts, err := s.mail.ListContacts(from.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range contacts {
+		if contacts[i].FromAgent == from.Name && contacts[i].ToAgent == to.Name {
+			link := toContact(&contacts[i])
+			return &link, nil
+		}
+	}
+	return nil, toolError("contact link %s -> %s not found", from.Name, to.Name)
+}
+
+func (s *Server) toolRequestContact(a args) (any, error) {
+	p, from, err := s.projectAgent(a, "from_agent")
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.agent(p, a.str("to_agent"))
+	if err != nil {
+		return nil, err
+	}
+	link, err := s.requestContact(p, from, to, a.str("reason"), s.contactTTL(a))
+	if err != nil {
+		return nil, err
+	}
+	return agentmail.ContactRequestResult{Status: link.Status, Link: link}, nil
+}
+
+func (s *Server) toolRespondContact(a args) (any, error) {
+	p, to, err := s.projectAgent(a, "to_agent")
+	if err != nil {
+		return nil, err
+	}
+	from, err := s.agent(p, a.str("from_agent"))
+	if err != nil {
+		return nil, err
+	}
+	status := "blocked"
+	if a.boolean("accept", false) {
+		status = "approved"
+	}
+	if err := s.mail.UpsertContact(p.ID, from.ID, to.ID, status, "", s.contactTTL(a)); err != nil {
+		return nil, err
+	}
+	link, err := s.contactLink(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"status": status, "link": link}, nil
+}
+
+func (s *Server) toolListContacts(a args) (any, error) {
+	_, ag, err := s.projectAgent(a, "agent_name")
+	if err != nil {
+		return nil, err
+	}
+	contacts, err := s.mail.ListContacts(ag.ID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]agentmail.ContactLink, 0, len(contacts))
+	for i := range contacts {
+		link := toContact(&contacts[i])
+		// Mirror the external server: "to" names the other party.
+		link.To = contacts[i].ToAgent
+		if link.To == ag.Name {
+			link.To = contacts[i].FromAgent
+		}
+		out = append(out, link)
+	}
+	return out, nil
+}
+
+func (s *Server) toolSetContactPolicy(a args) (any, error) {
+	_, ag, err := s.projectAgent(a, "agent_name")
+	if err != nil {
+		return nil, err
+	}
+	policy := strings.ToLower(a.str("policy"))
+	if !contactPolicies[policy] {
+		return nil, invalidParams("invalid contact policy %q (want open, auto, contacts_only or block_all)", policy)
+	}
+	if err := s.mail.SetContactPolicy(ag.ID, policy); err != nil {
+		return nil, err
+	}
+	return map[string]any{"agent": ag.Name, "policy": policy}, nil
+}
+
+// ========================
+// File reservations
+// ========================
+
+type conflictHolder struct {
+	Agent       string             `json:"agent"`
+	PathPattern string             `json:"path_pattern"`
+	Exclusive   bool               `json:"exclusive"`
+	ExpiresTS   agentmail.FlexTime `json:"expires_ts"`
+}
+
+type reservationConflict struct {
+	Path    string           `json:"path"`
+	Holders []conflictHolder `json:"holders"`
+}
+
+// toolReservePaths grants reservations that do not overlap another agent's
+// active reservation (where either side is exclusive) and reports the rest
+// as conflicts. Without agent_name it only checks for conflicts.
+func (s *Server) toolReservePaths(a args) (any, error) {
+	p, err := s.project(a)
+	if err != nil {
+		return nil, err
+	}
+	paths := a.strs("paths")
+	if len(paths) == 0 {
+		return nil, invalidParams("missing required argument: paths")
+	}
+
+	var holder *state.MailAgent
+	if name := a.str("agent_name"); name != "" {
+		if holder, err = s.agent(p, name); err != nil {
+			return nil, err
+		}
+	}
+
+	exclusive := a.boolean("exclusive", true)
+	ttl := time.Duration(a.integer("ttl_seconds", 0)) * time.Second
+	if ttl <= 0 {
+		ttl = defaultReservationTTL
+	}
+	ttl = max(ttl, minReservationTTL)
+
+	now := s.now()
+	var granted []agentmail.FileReservation
+	var conflicts []reservationConflict
+	// The conflict check and the writes share one transaction so two agents
+	// cannot both be granted overlapping exclusive reservations.
+	err = s.mail.Reserve(p.ID, now, func(active []state.MailReservation, tx *state.MailReservationTx) error {
+		granted = []agentmail.FileReservation{}
+		conflicts = []reservationConflict{}
+		for _, path := range paths {
+			var holders []conflictHolder
+			var own *state.MailReservation
+			for i := range active {
+				r := &active[i]
+				if holder != nil && r.AgentID == holder.ID {
+					if r.PathPattern == path {
+						own = r
+					}
+					continue
+				}
+				if (exclusive || r.Exclusive) && PatternsOverlap(path, r.PathPattern) {
+					holders = append(holders, conflictHolder{
+						Agent:       r.AgentName,
+						PathPattern: r.PathPattern,
+						Exclusive:   r.Exclusive,
+						ExpiresTS:   flex(r.ExpiresTS),
+					})
+				}
+			}
+			if len(holders) > 0 {
+				conflicts = append(conflicts, reservationConflict{Path: path, Holders: holders})
+				continue
+			}
+			if holder == nil {
+				continue
+			}
+
+			expires := now.Add(ttl)
+			if own != nil {
+				// Re-reserving a held pattern refreshes it instead of stacking.
+				if expires.After(own.ExpiresTS) {
+					if err := tx.Extend(own.ID, expires); err != nil {
+						return err
+					}
+					own.ExpiresTS = expires
+				}
+				granted = append(granted, toReservation(own))
+				continue
+			}
+			r := &state.MailReservation{
+				ProjectID:   p.ID,
+				AgentID:     holder.ID,
+				AgentName:   holder.Name,
+				PathPattern: path,
+				Exclusive:   exclusive,
+				Reason:      a.str("reason"),
+				CreatedTS:   now,
+				ExpiresTS:   expires,
+			}
+			if err := tx.Create(r); err != nil {
+				return err
+			}
+			active = append(active, *r)
+			granted = append(granted, toReservation(r))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]any{"granted": granted, "conflicts": conflicts}, nil
+}
+
+// ownReservations returns the agent's active reservations selected by ID or
+// exact pattern; with neither filter it returns all of them.
+func (s *Server) ownReservations(p *state.MailProject, ag *state.MailAgent, a args) ([]state.MailReservation, error) {
+	active, err := s.mail.ActiveReservations(p.ID, s.now())
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[int64]bool)
+	for _, id := range a.ints("file_reservation_ids") {
+		ids[id] = true
+	}
+	paths := make(map[string]bool)
+	for _, path := range a.strs("paths") {
+		paths[path] = true
+	}
+
+	var out []state.MailReservation
+	for _, r := range active {
+		if r.AgentID != ag.ID {
+			continue
+		}
+		if len(ids)+len(paths) > 0 && !ids[r.ID] && !paths[r.PathPattern] {
+			continue
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+func (s *Server) toolReleaseReservations(a args) (any, error) {
+	p, ag, err := s.projectAgent(a, "agent_name")
+	if err != nil {
+		return nil, err
+	}
+	own, err := s.ownReservations(p, ag, a)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, 0, len(own))
+	for _, r := range own {
+		ids = append(ids, r.ID)
+	}
+	now := s.now()
+	if err := s.mail.ReleaseReservations(ids, now); err != nil {
+		return nil, err
+	}
+	return map[string]any{"released": len(ids), "released_at": flex(now)}, nil
+}
+
+func (s *Server) toolRenewReservations(a args) (any, error) {
+	p, ag, err := s.projectAgent(a, "agent_name")
+	if err != nil {
+		return nil, err
+	}
+	own, err := s.ownReservations(p, ag, a)
+	if err != nil {
+		return nil, err
+	}
+	extend := time.Duration(a.integer("extend_seconds", 0)) * time.Second
+	if extend <= 0 {
+		extend = defaultRenewExtension
+	}
+
+	now := s.now()
+	result := agentmail.RenewReservationsResult{Reservations: []agentmail.RenewedReservation{}}
+	for _, r := range own {
+		expires := r.ExpiresTS
+		if expires.Before(now) {
+			expires = now
+		}
+		expires = expires.Add(extend)
+		if err := s.mail.ExtendReservation(r.ID, expires); err != nil {
+			return nil, err
+		}
+		result.Reservations = append(result.Reservations, agentmail.RenewedReservation{
+			ID:           int(r.ID),
+			PathPattern:  r.PathPattern,
+			OldExpiresTS: flex(r.ExpiresTS),
+			NewExpiresTS: flex(expires),
+		})
+	}
+	result.Renewed = len(result.Reservations)
+	return result, nil
+}
+
+func (s *Server) toolListReservations(a args) (any, error) {
+	p, err := s.project(a)
+	if err != nil {
+		return nil, err
+	}
+	active, err := s.mail.ActiveReservations(p.ID, s.now())
+	if err != nil {
+		return nil, err
+	}
+	name := a.str("agent_name")
+	all := a.boolean("all_agents", false)
+	out := []agentmail.FileReservation{}
+	for i := range active {
+		if name != "" && !all && active[i].AgentName != name {
+			continue
+		}
+		out = append(out, toReservation(&active[i]))
+	}
+	return out, nil
+}
+
+// toolForceRelease releases another agent's reservation once the holder has
+// been idle for staleHolderAfter.
+func (s *Server) toolForceRelease(a args) (any, error) {
+	p, requester, err := s.projectAgent(a, "agent_name")
+	if err != nil {
+		return nil, err
+	}
+	id := a.integer("file_reservation_id", 0)
+	if id <= 0 {
+		return nil, invalidParams("missing required argument: file_reservation_id")
+	}
+	r, err := s.mail.GetReservation(p.ID, int64(id))
+	if errors.Is(err, state.ErrMailNotFound) {
+		return nil, toolError("no file lock with id %d", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	if !r.Active(now) {
+		return agentmail.ForceReleaseResult{Success: true, PreviousHolder: r.AgentName, PathPattern: r.PathPattern}, nil
+	}
+	if r.AgentID != requester.ID {
+		holder, err := s.agent(p, r.AgentName)
+		if err != nil {
+			return nil, err
+		}
+		if idle := now.Sub(holder.LastActiveTS); idle < staleHolderAfter {
+			return nil, toolError("holder %s was active %s ago; force release requires %s of inactivity",
+				holder.Name, idle.Round(time.Second), staleHolderAfter)
+		}
+	}
+	if err := s.mail.ReleaseReservations([]int64{r.ID}, now); err != nil {
+		return nil, err
+	}
+
+	result := agentmail.ForceReleaseResult{
+		Success:        true,
+		ReleasedAt:     flexPtr(&now),
+		PreviousHolder: r.AgentName,
+		PathPattern:    r.PathPattern,
+	}
+	if a.boolean("notify_previous", false) && r.AgentID != requester.ID {
+		body := fmt.Sprintf("%s force-released your hold on `%s` after inactivity.", requester.Name, r.PathPattern)
+		if note := a.str("note"); note != "" {
+			body += "\n\n" + note
+		}
+		msg := &state.MailMessage{
+			ProjectID:  p.ID,
+			SenderID:   requester.ID,
+			Sender:     requester.Name,
+			Subject:    "Released: " + r.PathPattern,
+			BodyMD:     body,
+			Importance: "high",
+			CreatedTS:  now,
+			Recipients: []state.MailRecipient{{AgentID: r.AgentID, Name: r.AgentName, Kind: "to"}},
+		}
+		result.Notified = s.mail.CreateMessage(msg) == nil
+	}
+	return result, nil
+}
+
+// ========================
+// Macros
+// ========================
+
+func (s *Server) toolStartSession(a args) (any, error) {
+	p, err := s.project(a)
+	if err != nil {
+		return nil, err
+	}
+	ag, err := s.register(p, a.str("agent_name", "name"), a)
+	if err != nil {
+		return nil, err
+	}
+	inbox, err := s.inbox(ag, a, a.integer("inbox_limit", defaultInboxLimit), false)
+	if err != nil {
+		return nil, err
+	}
+	return agentmail.SessionStartResult{
+		Project:          toProject(p),
+		Agent:            toAgent(ag),
+		FileReservations: &agentmail.ReservationResult{Granted: []agentmail.FileReservation{}, Conflicts: []agentmail.ReservationConflict{}},
+		Inbox:            inbox,
+	}, nil
+}
+
+func (s *Server) toolPrepareThread(a args) (any, error) {
+	p, err := s.project(a)
+	if err != nil {
+		return nil, err
+	}
+	threadID, err := a.required("thread_id")
+	if err != nil {
+		return nil, err
+	}
+
+	name := a.str("agent_name")
+	var ag *state.MailAgent
+	if a.boolean("register_if_missing", true) {
+		ag, err = s.register(p, name, a)
+	} else {
+		ag, err = s.agent(p, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sum, msgs, err := s.summarize(p, threadID)
+	if err != nil {
+		return nil, err
+	}
+	inbox, err := s.inbox(ag, a, a.integer("inbox_limit", defaultInboxLimit), a.boolean("include_inbox_bodies", false))
+	if err != nil {
+		return nil, err
+	}
+	result := agentmail.PrepareThreadResult{Agent: toAgent(ag), ThreadSummary: sum, Inbox: inbox}
+	if a.boolean("include_examples", true) {
+		for i := max(0, len(msgs)-3); i < len(msgs); i++ {
+			result.Examples = append(result.Examples, toInbox(&msgs[i], true))
+		}
+	}
+	return result, nil
+}
+
+func (s *Server) toolContactHandshake(a args) (any, error) {
+	p, err := s.project(a)
+	if err != nil {
+		return nil, err
+	}
+	from, err := s.register(p, a.str("agent_name"), a)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.agent(p, a.str("to_agent"))
+	if err != nil {
+		return nil, err
+	}
+	link, err := s.requestContact(p, from, to, a.str("reason"), s.contactTTL(a))
+	if err != nil {
+		return nil, err
+	}
+	if a.boolean("auto_accept", false) && link.Status == "pending" {
+		if err := s.mail.UpsertContact(p.ID, from.ID, to.ID, "approved", "", s.contactTTL(a)); err != nil {
+			return nil, err
+		}
+		if link, err = s.contactLink(from, to); err != nil {
+			return nil, err
+		}
+	}
+
+	result := agentmail.ContactHandshakeResult{Agent: toAgent(from), ContactStatus: link.Status, Link: link}
+	if subject := a.str("welcome_subject"); subject != "" && link.Status == "approved" {
+		msg := &state.MailMessage{
+			ProjectID:  p.ID,
+			SenderID:   from.ID,
+			Sender:     from.Name,
+			Subject:    subject,
+			BodyMD:     a.str("welcome_body"),
+			CreatedTS:  s.now(),
+			Recipients: []state.MailRecipient{{AgentID: to.ID, Name: to.Name, Kind: "to"}},
+		}
+		if err := s.mail.CreateMessage(msg); err != nil {
+			return nil, err
+		}
+		result.WelcomeMsg = toMessage(msg)
+	}
+	return result, nil
+}
@@ -38,6 +38,8 @@ const (
 	EventSessionCreated EventType = "session.created"  // New session spawned
 	EventSessionKilled  EventType = "session.killed"   // Session terminated
 	EventHealthDegraded EventType = "health.degraded"  // Overall health dropped
+	EventBudgetAlert    EventType = "budget.alert"     // Spend crossed a budget threshold
+	EventPermission     EventType = "agent.permission" // Agent waits on a tool-permission prompt
 )
 
 // Event represents a notification event
@@ -106,7 +108,7 @@ type FileBoxConfig struct {
 func DefaultConfig() Config {
 	return Config{
 		Enabled:  true,
-		Events:   []string{string(EventAgentError), string(EventAgentCrashed)},
+		Events:   []string{string(EventAgentError), string(EventAgentCrashed), string(EventPermission)},
 		Primary:  "desktop",
 		Fallback: "filebox",
 		Routing:  nil, // Use default (all enabled channels in parallel)
@@ -782,3 +784,47 @@ func NewHealthDegradedEvent(session string, healthy, warning, error int) Event {
 		},
 	}
 }
+
+// NewBudgetAlertEvent creates a budget threshold notification event
+func NewBudgetAlertEvent(session, budget, level, message string) Event {
+	return Event{
+		Type:    EventBudgetAlert,
+		Session: session,
+		Message: message,
+		Details: map[string]string{
+			"budget": budget,
+			"level":  level,
+		},
+	}
+}
+
+// NewPermissionEvent creates an event for a tool-permission prompt that
+// needs a human answer
+func NewPermissionEvent(session, pane, agentType, requestID, target string) Event {
+	return Event{
+		Type:    EventPermission,
+		Session: session,
+		Pane:    pane,
+		Agent:   agentType,
+		Message: fmt.Sprintf("%s in %s asks permission: %s", agentType, pane, target),
+		Details: map[string]string{
+			"request_id": requestID,
+			"target":     target,
+		},
+	}
+}
+
+// NewLimitForecastEvent creates an event for an account forecast to reach
+// its usage limit soon
+func NewLimitForecastEvent(session, agentType, account, summary string) Event {
+	return Event{
+		Type:    EventRotationNeeded,
+		Session: session,
+		Agent:   agentType,
+		Message: fmt.Sprintf("%s account %s is near its usage limit (%s)", agentType, account, summary),
+		Details: map[string]string{
+			"account":  account,
+			"forecast": summary,
+		},
+	}
+}
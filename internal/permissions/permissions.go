@@ -0,0 +1,344 @@
+// Package permissions finds agents blocked on their own tool-permission
+// prompts ("Allow this command?"), answers the ones the project policy
+// decides explicitly, and lets a human answer the rest from anywhere by
+// sending the prompt's keystroke back to the pane.
+//
+// Prompts are not stored: a pane's prompt is whatever is on screen, and its
+// ID is derived from the prompt's content (see agent.PermissionRequest), so
+// an answer can be checked against what the agent is asking right now.
+package permissions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/agent"
+	"github.com/shahbajlive/ntm/internal/audit"
+	"github.com/shahbajlive/ntm/internal/events"
+	"github.com/shahbajlive/ntm/internal/policy"
+	"github.com/shahbajlive/ntm/internal/tmux"
+)
+
+const (
+	// DefaultInterval is how often Run looks for new prompts.
+	DefaultInterval = 3 * time.Second
+	// CaptureLines is how much of each pane is read; it covers Claude Code's
+	// edit prompts, which include a diff.
+	CaptureLines = 120
+)
+
+var (
+	// ErrNoPrompt is returned when answering a pane that shows no prompt.
+	ErrNoPrompt = errors.New("pane is not waiting on a permission prompt")
+	// ErrPromptChanged is returned when the pane shows a different prompt
+	// than the one being answered.
+	ErrPromptChanged = errors.New("pane is waiting on a different permission prompt")
+)
+
+// Prompt is a permission prompt waiting in a pane.
+type Prompt struct {
+	Session   string `json:"session"`
+	PaneID    string `json:"pane_id"`
+	PaneIndex int    `json:"pane_index"`
+	agent.PermissionRequest
+}
+
+// Decision is how a prompt is answered.
+type Decision string
+
+const (
+	DecisionAllow Decision = "allow"
+	DecisionDeny  Decision = "deny"
+	DecisionAsk   Decision = "ask" // left for a human
+)
+
+// Verdict is the policy's decision on a prompt.
+type Verdict struct {
+	Decision Decision `json:"decision"`
+	Pattern  string   `json:"pattern,omitempty"`
+	Reason   string   `json:"reason,omitempty"`
+}
+
+// Evaluate routes a request through p. Only explicit rules answer a prompt,
+// and denying wins: a blocked rule anywhere in the request denies it, while
+// an allowed rule approves it only when the rule matches the whole request
+// and the request chains no other commands. approval_required rules, and
+// requests no rule mentions, are left for a human, unlike policy.Check where
+// no match means allowed: the agent asked precisely because its own settings
+// did not allow the action.
+func Evaluate(p *policy.Policy, req *agent.PermissionRequest) Verdict {
+	if p == nil {
+		return Verdict{Decision: DecisionAsk}
+	}
+	subject := strings.TrimSpace(req.PolicySubject())
+	if r := matchRule(p.Blocked, subject, false); r != nil {
+		return Verdict{Decision: DecisionDeny, Pattern: r.Pattern, Reason: r.Reason}
+	}
+	if r := matchRule(p.ApprovalRequired, subject, false); r != nil {
+		return Verdict{Decision: DecisionAsk, Pattern: r.Pattern, Reason: r.Reason}
+	}
+	if strings.ContainsAny(subject, compoundChars) || strings.Contains(subject, "$(") {
+		return Verdict{Decision: DecisionAsk, Reason: "compound command"}
+	}
+	if r := matchRule(p.Allowed, subject, true); r != nil {
+		return Verdict{Decision: DecisionAllow, Pattern: r.Pattern, Reason: r.Reason}
+	}
+	return Verdict{Decision: DecisionAsk, Reason: "no policy rule matches"}
+}
+
+// compoundChars separate, pipe, background, substitute or redirect
+// commands; a request containing any of them is never approved
+// automatically.
+const compoundChars = ";&|`<>\n"
+
+// matchRule returns the first of rules matching subject, anywhere in it or,
+// with whole set, across all of it. Invalid patterns match nothing.
+func matchRule(rules []policy.Rule, subject string, whole bool) *policy.Rule {
+	for i := range rules {
+		pattern := rules[i].Pattern
+		if whole {
+			pattern = `^(?:` + pattern + `)$`
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			continue
+		}
+		if re.MatchString(subject) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// Config configures a Watcher. All fields are optional.
+type Config struct {
+	// Policy returns the policy new prompts are checked against; nil, or a
+	// nil result, leaves every prompt for a human.
+	Policy func() *policy.Policy
+	// OnPrompt is called once for each new prompt, after any automatic
+	// answer; err reports a failed answer.
+	OnPrompt func(p Prompt, v Verdict, err error)
+	Interval time.Duration
+
+	// Panes lists a session's panes; defaults to tmux.GetPanesContext.
+	Panes func(ctx context.Context, session string) ([]tmux.Pane, error)
+	// Capture reads a pane; defaults to the last CaptureLines lines.
+	Capture func(ctx context.Context, paneID string) (string, error)
+	// SendKey sends one tmux key to a pane.
+	SendKey func(paneID, key string) error
+}
+
+// Watcher finds and answers permission prompts.
+type Watcher struct {
+	cfg Config
+
+	mu   sync.Mutex
+	seen map[string]string // pane ID -> ID of the prompt last handled
+}
+
+// New creates a watcher, filling in defaults for unset Config fields.
+func New(cfg Config) *Watcher {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	if cfg.Panes == nil {
+		cfg.Panes = tmux.GetPanesContext
+	}
+	if cfg.Capture == nil {
+		cfg.Capture = func(ctx context.Context, paneID string) (string, error) {
+			return tmux.CapturePaneOutputContext(ctx, paneID, CaptureLines)
+		}
+	}
+	if cfg.SendKey == nil {
+		cfg.SendKey = func(paneID, key string) error {
+			return tmux.DefaultClient.RunSilent("send-keys", "-t", paneID, key)
+		}
+	}
+	return &Watcher{cfg: cfg, seen: make(map[string]string)}
+}
+
+// Scan returns the prompts waiting in session's agent panes.
+func (w *Watcher) Scan(ctx context.Context, session string) ([]Prompt, error) {
+	panes, err := w.cfg.Panes(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+	prompts := []Prompt{}
+	for _, p := range panes {
+		if pr, ok := w.scanPane(ctx, session, p); ok {
+			prompts = append(prompts, pr)
+		}
+	}
+	return prompts, nil
+}
+
+func (w *Watcher) scanPane(ctx context.Context, session string, p tmux.Pane) (Prompt, bool) {
+	if p.Type == tmux.AgentUser {
+		return Prompt{}, false
+	}
+	out, err := w.cfg.Capture(ctx, p.ID)
+	if err != nil {
+		return Prompt{}, false
+	}
+	req := agent.DetectPermissionPrompt(out, agent.AgentType(p.Type))
+	if req == nil {
+		return Prompt{}, false
+	}
+	return Prompt{Session: session, PaneID: p.ID, PaneIndex: p.Index, PermissionRequest: *req}, true
+}
+
+// Answer describes a reply to a prompt.
+type Answer struct {
+	Pane      string // Pane ID ("%3") or index ("2")
+	RequestID string // When set, the pane must still show this prompt
+	Approve   bool
+	By        string // Who answered, recorded in the event log
+	Reason    string
+}
+
+// Answer sends a's keystroke to the prompt waiting in its pane and returns
+// that prompt.
+func (w *Watcher) Answer(ctx context.Context, session string, a Answer) (*Prompt, error) {
+	panes, err := w.cfg.Panes(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range panes {
+		if !paneMatches(p, a.Pane) {
+			continue
+		}
+		pr, ok := w.scanPane(ctx, session, p)
+		if !ok {
+			return nil, fmt.Errorf("pane %s: %w", a.Pane, ErrNoPrompt)
+		}
+		if a.RequestID != "" && a.RequestID != pr.ID {
+			return &pr, fmt.Errorf("pane %s shows %s, not %s: %w", a.Pane, pr.ID, a.RequestID, ErrPromptChanged)
+		}
+		if err := w.send(pr, a.Approve); err != nil {
+			return &pr, err
+		}
+		decision := DecisionDeny
+		if a.Approve {
+			decision = DecisionAllow
+		}
+		emit(events.EventPermissionAnswered, pr, decision, a.By, a.Reason)
+		return &pr, nil
+	}
+	return nil, fmt.Errorf("pane %s not found in session %s", a.Pane, session)
+}
+
+func (w *Watcher) send(pr Prompt, approve bool) error {
+	w.mu.Lock()
+	w.seen[pr.PaneID] = pr.ID
+	w.mu.Unlock()
+	key := agent.PermissionKey(pr.Agent, approve)
+	if err := w.cfg.SendKey(pr.PaneID, key); err != nil {
+		return fmt.Errorf("send %s to pane %s: %w", key, pr.PaneID, err)
+	}
+	return nil
+}
+
+func paneMatches(p tmux.Pane, sel string) bool {
+	if sel == p.ID {
+		return true
+	}
+	idx, err := strconv.Atoi(strings.TrimPrefix(sel, "pane"))
+	return err == nil && idx == p.Index
+}
+
+// CheckOnce handles the prompts that appeared in session since the last
+// check: each is evaluated against the policy, answered if the policy is
+// explicit, and reported to OnPrompt. It returns the prompts still waiting
+// for a human.
+func (w *Watcher) CheckOnce(ctx context.Context, session string) ([]Prompt, error) {
+	prompts, err := w.Scan(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	var p *policy.Policy
+	if w.cfg.Policy != nil {
+		p = w.cfg.Policy()
+	}
+
+	current := make(map[string]bool, len(prompts))
+	var waiting []Prompt
+	for _, pr := range prompts {
+		current[pr.PaneID] = true
+		w.mu.Lock()
+		handled := w.seen[pr.PaneID] == pr.ID
+		w.seen[pr.PaneID] = pr.ID
+		w.mu.Unlock()
+
+		v := Evaluate(p, &pr.PermissionRequest)
+		if v.Decision == DecisionAsk {
+			waiting = append(waiting, pr)
+		}
+		if handled {
+			continue
+		}
+
+		var answerErr error
+		if v.Decision != DecisionAsk {
+			answerErr = w.send(pr, v.Decision == DecisionAllow)
+		}
+		emit(events.EventPermissionPrompt, pr, v.Decision, "policy", v.Reason)
+		if w.cfg.OnPrompt != nil {
+			w.cfg.OnPrompt(pr, v, answerErr)
+		}
+	}
+
+	// A prompt that went away may be asked again later; handle it afresh.
+	w.mu.Lock()
+	for pane := range w.seen {
+		if !current[pane] {
+			delete(w.seen, pane)
+		}
+	}
+	w.mu.Unlock()
+	return waiting, nil
+}
+
+// Run checks session for prompts every Interval until ctx is done.
+func (w *Watcher) Run(ctx context.Context, session string) {
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		_, _ = w.CheckOnce(ctx, session)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// emit records a prompt and its answer in the event log and, once answered,
+// the audit log.
+func emit(t events.EventType, pr Prompt, d Decision, by, reason string) {
+	data := events.PermissionData{
+		Pane:      pr.PaneID,
+		AgentType: string(pr.Agent),
+		RequestID: pr.ID,
+		Kind:      string(pr.Kind),
+		Target:    pr.Target(),
+		Decision:  string(d),
+		DecidedBy: by,
+		Reason:    reason,
+	}
+	events.Emit(t, pr.Session, data)
+	if d == DecisionAsk {
+		return
+	}
+	actor := audit.ActorUser
+	if by == "policy" {
+		actor = audit.ActorSystem
+	}
+	_ = audit.LogEvent(pr.Session, audit.EventTypeCommand, actor, "permission."+string(d), events.ToMap(data), nil)
+}
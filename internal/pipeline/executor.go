@@ -15,7 +15,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/shahbajlive/ntm/internal/cost"
 	"github.com/shahbajlive/ntm/internal/robot"
+	"github.com/shahbajlive/ntm/internal/state"
 	"github.com/shahbajlive/ntm/internal/status"
 	"github.com/shahbajlive/ntm/internal/tmux"
 	"github.com/shahbajlive/ntm/internal/util"
@@ -23,17 +25,24 @@ import (
 
 // ExecutorConfig configures the executor behavior
 type ExecutorConfig struct {
-	Session          string        // Required: tmux session name
-	ProjectDir       string        // Optional: project root for .ntm state
-	WorkflowFile     string        // Optional: workflow file path for state persistence
-	DefaultTimeout   time.Duration // Default step timeout (default: 5m)
-	GlobalTimeout    time.Duration // Maximum workflow runtime (default: 30m)
-	ProgressInterval time.Duration // Interval for progress updates (default: 1s)
-	DryRun           bool          // If true, validate but don't execute
-	Verbose          bool          // Enable verbose logging
-	RunID            string        // Optional: pre-generated run ID (if empty, one is generated)
+	Session          string                  // Required: tmux session name
+	ProjectDir       string                  // Optional: project root for .ntm state
+	WorkflowFile     string                  // Optional: workflow file path for state persistence
+	DefaultTimeout   time.Duration           // Default step timeout (default: 5m)
+	GlobalTimeout    time.Duration           // Maximum workflow runtime (default: 30m)
+	ProgressInterval time.Duration           // Interval for progress updates (default: 1s)
+	DryRun           bool                    // If true, validate but don't execute
+	Verbose          bool                    // Enable verbose logging
+	RunID            string                  // Optional: pre-generated run ID (if empty, one is generated)
+	RunIndex         *state.PipelineRunStore // Optional: run history index updated with each state save
+	Budget           BudgetCheck             // Optional: checked before each prompt is sent
+	Costs            *cost.CostTracker       // Optional: records each step's prompt as input tokens
 }
 
+// BudgetCheck reports whether a prompt may be sent to an agentType pane;
+// a non-nil error fails the step.
+type BudgetCheck func(agentType string) error
+
 // MinProgressInterval is the minimum allowed progress interval to prevent ticker panics.
 // time.NewTicker requires a positive duration.
 const MinProgressInterval = 100 * time.Millisecond
@@ -136,6 +145,10 @@ func (e *Executor) Run(ctx context.Context, workflow *Workflow, vars map[string]
 	for name, val := range vars {
 		e.state.Variables[name] = val
 	}
+	e.state.Inputs = make(map[string]interface{}, len(e.state.Variables))
+	for name, val := range e.state.Variables {
+		e.state.Inputs[name] = val
+	}
 
 	e.persistState()
 
@@ -568,6 +581,11 @@ func (e *Executor) executeStepOnce(ctx context.Context, step *Step, workflow *Wo
 		return result
 	}
 
+	if result.Error = e.checkBudget(agentType); result.Error != nil {
+		result.Status = StatusFailed
+		return result
+	}
+
 	// Capture state before sending
 	beforeOutput, _ := tmux.CapturePaneOutput(paneID, 2000)
 
@@ -581,6 +599,7 @@ func (e *Executor) executeStepOnce(ctx context.Context, step *Step, workflow *Wo
 		}
 		return result
 	}
+	e.recordSpend(paneID, agentType, prompt)
 
 	// Handle wait condition
 	waitCondition := step.Wait
@@ -996,6 +1015,11 @@ func (e *Executor) executeParallelStep(ctx context.Context, step *Step, workflow
 			return result
 		}
 
+		if result.Error = e.checkBudget(agentType); result.Error != nil {
+			result.Status = StatusFailed
+			goto HANDLE_RESULT
+		}
+
 		// Capture state before sending
 		beforeOutput, _ = tmux.CapturePaneOutput(paneID, 2000)
 
@@ -1009,6 +1033,7 @@ func (e *Executor) executeParallelStep(ctx context.Context, step *Step, workflow
 			}
 			goto HANDLE_RESULT
 		}
+		e.recordSpend(paneID, agentType, prompt)
 
 		switch waitCondition {
 		case WaitNone:
@@ -1598,6 +1623,11 @@ func (e *Executor) persistState() {
 	if err := SaveState(projectDir, snapshot); err != nil && e.config.Verbose {
 		log.Printf("pipeline: state persistence failed: %v", err)
 	}
+	if e.config.RunIndex != nil {
+		if err := IndexRun(e.config.RunIndex, projectDir, snapshot, e.graph); err != nil && e.config.Verbose {
+			log.Printf("pipeline: run index update failed: %v", err)
+		}
+	}
 }
 
 // GetState returns the current execution state (for monitoring)
@@ -1605,6 +1635,33 @@ func (e *Executor) GetState() *ExecutionState {
 	return e.state
 }
 
+// checkBudget runs the configured budget check before a prompt is sent to an
+// agentType pane.
+func (e *Executor) checkBudget(agentType string) *StepError {
+	if e.config.Budget == nil {
+		return nil
+	}
+	if err := e.config.Budget(agentType); err != nil {
+		return &StepError{Type: "budget", Message: err.Error(), Timestamp: time.Now()}
+	}
+	return nil
+}
+
+// recordSpend records a step's prompt with the configured cost tracker.
+// Agent output is metered by the session monitor, as for 'ntm send'.
+func (e *Executor) recordSpend(paneID, agentType, prompt string) {
+	if e.config.Costs == nil {
+		return
+	}
+	e.config.Costs.RecordUsage(cost.Usage{
+		Session:     e.config.Session,
+		Pane:        paneID,
+		AgentType:   agentType,
+		InputTokens: cost.EstimateTokens(prompt),
+		Source:      "prompt",
+	})
+}
+
 // captureErrorContext captures recent pane output for error debugging
 func (e *Executor) captureErrorContext(paneID string, lines int) string {
 	if paneID == "" || e.config.DryRun {
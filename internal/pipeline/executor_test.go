@@ -11,6 +11,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/shahbajlive/ntm/internal/cost"
 	"github.com/shahbajlive/ntm/internal/status"
 )
 
@@ -75,6 +76,34 @@ func TestNewExecutor(t *testing.T) {
 	}
 }
 
+func TestExecutor_BudgetAndSpend(t *testing.T) {
+	cfg := DefaultExecutorConfig("test")
+	cfg.Budget = func(agentType string) error {
+		if agentType == "cc" {
+			return errors.New("over hard session budget")
+		}
+		return nil
+	}
+	cfg.Costs = cost.NewCostTracker("")
+	e := NewExecutor(cfg)
+
+	if serr := e.checkBudget("cc"); serr == nil || serr.Type != "budget" || !strings.Contains(serr.Message, "session budget") {
+		t.Errorf("checkBudget(cc) = %+v", serr)
+	}
+	if serr := e.checkBudget("cod"); serr != nil {
+		t.Errorf("checkBudget(cod) = %+v", serr)
+	}
+
+	prompt := strings.Repeat("refactor the parser ", 20)
+	e.recordSpend("%1", "cc", prompt)
+	if sc := cfg.Costs.GetSession("test"); sc == nil || sc.Agents["%1"].InputTokens != cost.EstimateTokens(prompt) {
+		t.Errorf("recorded session cost = %+v", sc)
+	}
+	if serr := NewExecutor(DefaultExecutorConfig("test")).checkBudget("cc"); serr != nil {
+		t.Errorf("checkBudget without a budget = %+v", serr)
+	}
+}
+
 func TestExecutor_SetNotifier(t *testing.T) {
 	cfg := DefaultExecutorConfig("test")
 	e := NewExecutor(cfg)
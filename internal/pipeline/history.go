@@ -0,0 +1,318 @@
+// Package pipeline provides workflow execution for AI agent orchestration.
+// history.go indexes finished and in-flight runs in the state database and
+// compares runs against each other.
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+
+	"github.com/shahbajlive/ntm/internal/config"
+	"github.com/shahbajlive/ntm/internal/cost"
+	"github.com/shahbajlive/ntm/internal/state"
+)
+
+// BuildRunRecord summarizes an execution state for the run history index.
+// graph supplies step prompts for the cost estimate and may be nil.
+func BuildRunRecord(projectDir string, st *ExecutionState, graph *DependencyGraph) *state.PipelineRun {
+	run := &state.PipelineRun{
+		RunID:        st.RunID,
+		ProjectDir:   projectDir,
+		WorkflowID:   st.WorkflowID,
+		WorkflowFile: st.WorkflowFile,
+		SessionName:  st.Session,
+		Status:       string(st.Status),
+		Vars:         st.Inputs,
+		StartedAt:    st.StartedAt,
+		UpdatedAt:    st.UpdatedAt,
+	}
+	if !st.FinishedAt.IsZero() {
+		finished := st.FinishedAt
+		run.FinishedAt = &finished
+		run.DurationMs = finished.Sub(st.StartedAt).Milliseconds()
+	} else if !st.UpdatedAt.IsZero() {
+		run.DurationMs = st.UpdatedAt.Sub(st.StartedAt).Milliseconds()
+	}
+
+	for id, res := range st.Steps {
+		step := state.PipelineRunStep{
+			StepID:    id,
+			Status:    string(res.Status),
+			AgentType: res.AgentType,
+			Pane:      res.PaneUsed,
+			Attempts:  res.Attempts,
+			Output:    res.Output,
+		}
+		if !res.StartedAt.IsZero() {
+			started := res.StartedAt
+			step.StartedAt = &started
+		}
+		if !res.FinishedAt.IsZero() {
+			finished := res.FinishedAt
+			step.FinishedAt = &finished
+			if step.StartedAt != nil {
+				step.DurationMs = finished.Sub(res.StartedAt).Milliseconds()
+			}
+		}
+		if res.Error != nil {
+			step.Error = res.Error.Message
+		}
+		var prompt string
+		if graph != nil {
+			if s, ok := graph.GetStep(id); ok {
+				prompt = s.Prompt
+			}
+		}
+		step.CostUSD = estimateStepCost(res, prompt)
+
+		run.StepCount++
+		if res.Status == StatusFailed {
+			run.FailedSteps++
+		}
+		if res.Attempts > 1 {
+			run.Retries += res.Attempts - 1
+		}
+		run.CostUSD += step.CostUSD
+		run.Steps = append(run.Steps, step)
+	}
+	sort.Slice(run.Steps, func(i, j int) bool { return run.Steps[i].StepID < run.Steps[j].StepID })
+	return run
+}
+
+// estimateStepCost prices a step from the prompt it was sent and the output
+// it produced, using the default model of its agent type. Each retry resends
+// the prompt.
+func estimateStepCost(res StepResult, prompt string) float64 {
+	if res.AgentType == "" || (prompt == "" && res.Output == "") {
+		return 0
+	}
+	pricing := cost.GetModelPricing(defaultModelForAgent(res.AgentType))
+	attempts := res.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	in := float64(cost.EstimateTokens(prompt) * attempts)
+	out := float64(cost.EstimateTokens(res.Output))
+	return in/1000*pricing.InputPer1K + out/1000*pricing.OutputPer1K
+}
+
+func defaultModelForAgent(agentType string) string {
+	models := config.DefaultModels()
+	switch strings.ToLower(agentType) {
+	case "claude", "cc", "claude_code", "claude-code":
+		return models.DefaultClaude
+	case "codex", "cod", "openai-codex":
+		return models.DefaultCodex
+	case "gemini", "gmi", "google-gemini":
+		return models.DefaultGemini
+	}
+	return agentType
+}
+
+// IndexRun records st in the run history index.
+func IndexRun(store *state.PipelineRunStore, projectDir string, st *ExecutionState, graph *DependencyGraph) error {
+	return store.Save(BuildRunRecord(projectDir, st, graph))
+}
+
+// SyncRunIndex indexes the persisted run states of projectDir that are
+// missing from the index or changed since they were indexed, so runs started
+// before the index existed (or by an executor without one) still show up.
+// Returns the number of runs indexed.
+func SyncRunIndex(store *state.PipelineRunStore, projectDir string) (int, error) {
+	states, err := ListStates(projectDir)
+	if err != nil {
+		return 0, err
+	}
+	indexed, err := store.UpdatedTimes(projectDir)
+	if err != nil {
+		return 0, err
+	}
+
+	graphs := make(map[string]*DependencyGraph)
+	count := 0
+	for _, st := range states {
+		if t, ok := indexed[st.RunID]; ok && !st.UpdatedAt.After(t) {
+			continue
+		}
+		graph, ok := graphs[st.WorkflowFile]
+		if !ok && st.WorkflowFile != "" {
+			if _, statErr := os.Stat(st.WorkflowFile); statErr == nil {
+				if wf, parseErr := ParseFile(st.WorkflowFile); parseErr == nil {
+					graph = NewDependencyGraph(wf)
+				}
+			}
+			graphs[st.WorkflowFile] = graph
+		}
+		if err := IndexRun(store, projectDir, st, graph); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// RunDiff compares two indexed runs step by step.
+type RunDiff struct {
+	RunA          string            `json:"run_a"`
+	RunB          string            `json:"run_b"`
+	WorkflowA     string            `json:"workflow_a"`
+	WorkflowB     string            `json:"workflow_b"`
+	StatusA       string            `json:"status_a"`
+	StatusB       string            `json:"status_b"`
+	DurationDelta int64             `json:"duration_delta_ms"`
+	CostDelta     float64           `json:"cost_delta_usd"`
+	VarChanges    map[string][2]any `json:"var_changes,omitempty"`
+	Steps         []StepDiff        `json:"steps"`
+}
+
+// StepDiff is the comparison of one step across two runs. A step missing
+// from one run has an empty status on that side.
+type StepDiff struct {
+	StepID        string  `json:"step_id"`
+	StatusA       string  `json:"status_a"`
+	StatusB       string  `json:"status_b"`
+	AttemptsA     int     `json:"attempts_a"`
+	AttemptsB     int     `json:"attempts_b"`
+	DurationA     int64   `json:"duration_a_ms"`
+	DurationB     int64   `json:"duration_b_ms"`
+	DurationDelta int64   `json:"duration_delta_ms"`
+	OutputChanged bool    `json:"output_changed"`
+	Similarity    float64 `json:"similarity"`
+	OutputDiff    string  `json:"output_diff,omitempty"`
+}
+
+// Changed reports whether the step's outcome or output differs.
+func (d StepDiff) Changed() bool {
+	return d.StatusA != d.StatusB || d.AttemptsA != d.AttemptsB || d.OutputChanged
+}
+
+// DiffRuns compares run b against run a. Both runs must include their steps.
+func DiffRuns(a, b *state.PipelineRun) *RunDiff {
+	d := &RunDiff{
+		RunA:          a.RunID,
+		RunB:          b.RunID,
+		WorkflowA:     a.WorkflowID,
+		WorkflowB:     b.WorkflowID,
+		StatusA:       a.Status,
+		StatusB:       b.Status,
+		DurationDelta: b.DurationMs - a.DurationMs,
+		CostDelta:     b.CostUSD - a.CostUSD,
+	}
+
+	for k, va := range a.Vars {
+		if vb, ok := b.Vars[k]; !ok || !sameValue(va, vb) {
+			d.addVarChange(k, va, b.Vars[k])
+		}
+	}
+	for k, vb := range b.Vars {
+		if _, ok := a.Vars[k]; !ok {
+			d.addVarChange(k, nil, vb)
+		}
+	}
+
+	stepsA := make(map[string]state.PipelineRunStep, len(a.Steps))
+	var order []string
+	for _, s := range a.Steps {
+		stepsA[s.StepID] = s
+		order = append(order, s.StepID)
+	}
+	stepsB := make(map[string]state.PipelineRunStep, len(b.Steps))
+	for _, s := range b.Steps {
+		stepsB[s.StepID] = s
+		if _, ok := stepsA[s.StepID]; !ok {
+			order = append(order, s.StepID)
+		}
+	}
+
+	for _, id := range order {
+		sa, sb := stepsA[id], stepsB[id]
+		sd := StepDiff{
+			StepID:        id,
+			StatusA:       sa.Status,
+			StatusB:       sb.Status,
+			AttemptsA:     sa.Attempts,
+			AttemptsB:     sb.Attempts,
+			DurationA:     sa.DurationMs,
+			DurationB:     sb.DurationMs,
+			DurationDelta: sb.DurationMs - sa.DurationMs,
+			Similarity:    1,
+		}
+		if sa.Output != sb.Output {
+			sd.OutputChanged = true
+			sd.OutputDiff, sd.Similarity = lineDiff(sa.Output, sb.Output)
+		}
+		d.Steps = append(d.Steps, sd)
+	}
+	return d
+}
+
+func (d *RunDiff) addVarChange(key string, a, b any) {
+	if d.VarChanges == nil {
+		d.VarChanges = make(map[string][2]any)
+	}
+	d.VarChanges[key] = [2]any{a, b}
+}
+
+// sameValue compares decoded JSON values, which may be maps or slices.
+func sameValue(a, b any) bool {
+	return formatValue(a) == formatValue(b)
+}
+
+// diffContext is the number of unchanged lines kept around each change.
+const diffContext = 2
+
+// lineDiff renders a line-oriented diff of two outputs, with removed lines
+// prefixed "-", added lines "+" and long unchanged stretches elided. The
+// similarity is the share of lines the outputs have in common.
+func lineDiff(a, b string) (string, float64) {
+	dmp := diffmatchpatch.New()
+	ca, cb, lines := dmp.DiffLinesToChars(a, b)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(ca, cb, false), lines)
+
+	var sb strings.Builder
+	same, total := 0, 0
+	for i, d := range diffs {
+		text := strings.TrimSuffix(d.Text, "\n")
+		parts := strings.Split(text, "\n")
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			total += len(parts)
+			for _, line := range parts {
+				fmt.Fprintf(&sb, "-%s\n", line)
+			}
+		case diffmatchpatch.DiffInsert:
+			total += len(parts)
+			for _, line := range parts {
+				fmt.Fprintf(&sb, "+%s\n", line)
+			}
+		case diffmatchpatch.DiffEqual:
+			same += len(parts)
+			total += len(parts)
+			head, tail := diffContext, diffContext
+			if i == 0 {
+				head = 0
+			}
+			if i == len(diffs)-1 {
+				tail = 0
+			}
+			if len(parts) > head+tail+1 {
+				for _, line := range parts[:head] {
+					fmt.Fprintf(&sb, " %s\n", line)
+				}
+				fmt.Fprintf(&sb, "@@ %d unchanged lines @@\n", len(parts)-head-tail)
+				parts = parts[len(parts)-tail:]
+			}
+			for _, line := range parts {
+				fmt.Fprintf(&sb, " %s\n", line)
+			}
+		}
+	}
+	if total == 0 {
+		return "", 1
+	}
+	return sb.String(), float64(same) / float64(total)
+}
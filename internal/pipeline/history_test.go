@@ -0,0 +1,163 @@
+package pipeline
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/state"
+)
+
+func openRunIndex(t *testing.T) *state.PipelineRunStore {
+	t.Helper()
+	store, err := state.Open(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	if err := store.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return state.NewPipelineRunStore(store)
+}
+
+func TestBuildRunRecord(t *testing.T) {
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	wf := &Workflow{Name: "review", Steps: []Step{
+		{ID: "plan", Agent: "claude", Prompt: strings.Repeat("plan the work ", 50)},
+		{ID: "build", Agent: "codex", Prompt: "build it", DependsOn: []string{"plan"}},
+	}}
+	st := &ExecutionState{
+		RunID:      "run-a",
+		WorkflowID: "review",
+		Session:    "proj",
+		Status:     StatusFailed,
+		StartedAt:  start,
+		UpdatedAt:  start.Add(90 * time.Second),
+		FinishedAt: start.Add(90 * time.Second),
+		Inputs:     map[string]interface{}{"target": "api"},
+		Variables:  map[string]interface{}{"target": "api", "plan_out": "long output"},
+		Steps: map[string]StepResult{
+			"plan": {
+				StepID: "plan", Status: StatusCompleted, AgentType: "claude", PaneUsed: "%1",
+				StartedAt: start, FinishedAt: start.Add(30 * time.Second), Output: "a plan", Attempts: 1,
+			},
+			"build": {
+				StepID: "build", Status: StatusFailed, AgentType: "codex",
+				StartedAt: start.Add(30 * time.Second), FinishedAt: start.Add(90 * time.Second),
+				Error: &StepError{Message: "timeout"}, Attempts: 3,
+			},
+		},
+	}
+
+	run := BuildRunRecord("/proj", st, NewDependencyGraph(wf))
+	if run.DurationMs != 90000 || run.FinishedAt == nil {
+		t.Errorf("duration = %d, finished = %v", run.DurationMs, run.FinishedAt)
+	}
+	if run.StepCount != 2 || run.FailedSteps != 1 || run.Retries != 2 {
+		t.Errorf("counts = %d steps, %d failed, %d retries", run.StepCount, run.FailedSteps, run.Retries)
+	}
+	if _, ok := run.Vars["plan_out"]; ok || run.Vars["target"] != "api" {
+		t.Errorf("vars = %v, want inputs only", run.Vars)
+	}
+	if len(run.Steps) != 2 || run.Steps[0].StepID != "build" || run.Steps[0].Error != "timeout" {
+		t.Fatalf("steps = %+v", run.Steps)
+	}
+	if run.Steps[1].DurationMs != 30000 || run.Steps[1].CostUSD <= 0 {
+		t.Errorf("plan step = %+v", run.Steps[1])
+	}
+	if run.CostUSD != run.Steps[0].CostUSD+run.Steps[1].CostUSD {
+		t.Errorf("run cost %f is not the sum of step costs", run.CostUSD)
+	}
+}
+
+func TestSyncRunIndex(t *testing.T) {
+	dir := t.TempDir()
+	idx := openRunIndex(t)
+	start := time.Now().Add(-time.Hour).UTC()
+
+	for i, id := range []string{"run-1", "run-2"} {
+		st := &ExecutionState{
+			RunID: id, WorkflowID: "wf", Status: StatusCompleted,
+			StartedAt: start.Add(time.Duration(i) * time.Minute),
+			UpdatedAt: start.Add(time.Duration(i)*time.Minute + time.Second),
+			Steps:     map[string]StepResult{"s": {StepID: "s", Status: StatusCompleted}},
+		}
+		if err := SaveState(dir, st); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := SyncRunIndex(idx, dir)
+	if err != nil || n != 2 {
+		t.Fatalf("first sync = %d, %v; want 2", n, err)
+	}
+	if n, err = SyncRunIndex(idx, dir); err != nil || n != 0 {
+		t.Fatalf("second sync = %d, %v; want 0", n, err)
+	}
+
+	st, err := LoadState(dir, "run-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.Status = StatusFailed
+	st.UpdatedAt = st.UpdatedAt.Add(time.Minute)
+	if err := SaveState(dir, st); err != nil {
+		t.Fatal(err)
+	}
+	if n, err = SyncRunIndex(idx, dir); err != nil || n != 1 {
+		t.Fatalf("sync after change = %d, %v; want 1", n, err)
+	}
+	runs, err := idx.List(state.PipelineRunFilter{ProjectDir: dir, Status: string(StatusFailed)})
+	if err != nil || len(runs) != 1 || runs[0].RunID != "run-1" {
+		t.Errorf("failed runs = %+v, %v", runs, err)
+	}
+}
+
+func TestDiffRuns(t *testing.T) {
+	a := &state.PipelineRun{
+		RunID: "a", WorkflowID: "wf", Status: "completed", DurationMs: 1000, CostUSD: 0.5,
+		Vars: map[string]interface{}{"env": "dev", "n": 1.0},
+		Steps: []state.PipelineRunStep{
+			{StepID: "one", Status: "completed", DurationMs: 400, Attempts: 1, Output: "same\n"},
+			{StepID: "two", Status: "completed", DurationMs: 600, Attempts: 1, Output: "alpha\nbeta\n"},
+			{StepID: "old", Status: "completed"},
+		},
+	}
+	b := &state.PipelineRun{
+		RunID: "b", WorkflowID: "wf", Status: "failed", DurationMs: 1500, CostUSD: 0.25,
+		Vars: map[string]interface{}{"env": "prod", "n": 1.0, "extra": true},
+		Steps: []state.PipelineRunStep{
+			{StepID: "one", Status: "completed", DurationMs: 500, Attempts: 1, Output: "same\n"},
+			{StepID: "two", Status: "failed", DurationMs: 1000, Attempts: 2, Output: "alpha\ngamma\n"},
+			{StepID: "new", Status: "skipped"},
+		},
+	}
+
+	d := DiffRuns(a, b)
+	if d.DurationDelta != 500 || d.CostDelta != -0.25 {
+		t.Errorf("deltas = %d ms, %f usd", d.DurationDelta, d.CostDelta)
+	}
+	if len(d.VarChanges) != 2 || d.VarChanges["env"] != [2]any{"dev", "prod"} || d.VarChanges["extra"] != [2]any{nil, true} {
+		t.Errorf("var changes = %v", d.VarChanges)
+	}
+
+	var ids []string
+	for _, s := range d.Steps {
+		ids = append(ids, s.StepID)
+	}
+	if strings.Join(ids, ",") != "one,two,old,new" {
+		t.Fatalf("step order = %v", ids)
+	}
+	if d.Steps[0].Changed() || d.Steps[0].DurationDelta != 100 {
+		t.Errorf("step one = %+v", d.Steps[0])
+	}
+	two := d.Steps[1]
+	if !two.Changed() || !two.OutputChanged || !strings.Contains(two.OutputDiff, "gamma") || two.Similarity >= 1 {
+		t.Errorf("step two = %+v", two)
+	}
+	if d.Steps[2].StatusB != "" || d.Steps[3].StatusA != "" {
+		t.Errorf("missing steps not reported: %+v %+v", d.Steps[2], d.Steps[3])
+	}
+}
@@ -254,7 +254,8 @@ type ExecutionState struct {
 	FinishedAt   time.Time              `json:"finished_at,omitempty"`
 	CurrentStep  string                 `json:"current_step,omitempty"`
 	Steps        map[string]StepResult  `json:"steps"`
-	Variables    map[string]interface{} `json:"variables"` // Runtime variables including step outputs
+	Variables    map[string]interface{} `json:"variables"`        // Runtime variables including step outputs
+	Inputs       map[string]interface{} `json:"inputs,omitempty"` // Variables the run was started with
 	Errors       []ExecutionError       `json:"errors,omitempty"`
 }
 
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/shahbajlive/ntm/internal/util"
@@ -73,6 +75,35 @@ func LoadState(projectDir, runID string) (*ExecutionState, error) {
 	return &state, nil
 }
 
+// ListStates loads every persisted execution state under projectDir, most
+// recently started first. Unreadable state files are skipped.
+func ListStates(projectDir string) ([]*ExecutionState, error) {
+	entries, err := os.ReadDir(pipelineStateDir(projectDir))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read pipeline state dir: %w", err)
+	}
+
+	var states []*ExecutionState
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		state, err := LoadState(projectDir, strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+		states = append(states, state)
+	}
+
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].StartedAt.After(states[j].StartedAt)
+	})
+	return states, nil
+}
+
 // CleanupStates removes pipeline state files older than the provided duration.
 // Returns the number of deleted state files.
 func CleanupStates(projectDir string, olderThan time.Duration) (int, error) {
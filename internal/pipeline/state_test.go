@@ -534,3 +534,30 @@ func TestCleanupStates_MultipleFiles(t *testing.T) {
 		t.Errorf("remaining files = %d, want 2", len(entries))
 	}
 }
+
+func TestListStates(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	if states, err := ListStates(tmpDir); err != nil || len(states) != 0 {
+		t.Fatalf("ListStates() on empty dir = %v, %v", states, err)
+	}
+
+	base := time.Now()
+	for i, id := range []string{"run-old", "run-new"} {
+		state := &ExecutionState{RunID: id, Session: "proj", Status: StatusRunning, StartedAt: base.Add(time.Duration(i) * time.Minute)}
+		if err := SaveState(tmpDir, state); err != nil {
+			t.Fatalf("SaveState() error = %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, ".ntm", "pipelines", "broken.json"), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	states, err := ListStates(tmpDir)
+	if err != nil {
+		t.Fatalf("ListStates() error = %v", err)
+	}
+	if len(states) != 2 || states[0].RunID != "run-new" || states[1].RunID != "run-old" {
+		t.Errorf("ListStates() = %+v, want run-new then run-old", states)
+	}
+}
@@ -0,0 +1,241 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// HookAgent names an agent hook adapter.
+type HookAgent string
+
+const (
+	HookClaude  HookAgent = "claude"
+	HookCodex   HookAgent = "codex"
+	HookGemini  HookAgent = "gemini"
+	HookWrapper HookAgent = "wrapper" // PATH wrappers pass the command as arguments
+)
+
+// HookAgents lists the supported adapters.
+var HookAgents = []HookAgent{HookClaude, HookCodex, HookGemini, HookWrapper}
+
+// hookPayload covers the fields the agents put on a pre-tool hook's stdin.
+// Claude Code and Gemini CLI send tool_name/tool_input; Codex approval
+// payloads carry the argv under command.
+type hookPayload struct {
+	Cwd       string          `json:"cwd"`
+	ToolName  string          `json:"tool_name"`
+	ToolInput json.RawMessage `json:"tool_input"`
+	Command   json.RawMessage `json:"command"`
+}
+
+// shellTools are the tool names whose input is a shell command.
+var shellTools = map[HookAgent][]string{
+	HookClaude: {"Bash"},
+	HookGemini: {"run_shell_command"},
+	HookCodex:  {"shell", "exec", "local_shell", "exec_command"},
+}
+
+// ParseHookInput turns an adapter's input into a policy Request. The second
+// result is false when the call is not a shell command and needs no check.
+func ParseHookInput(agent HookAgent, stdin []byte, args []string) (Request, bool, error) {
+	req := Request{Agent: string(agent)}
+
+	if agent == HookWrapper {
+		req.Command = strings.Join(args, " ")
+		return req, req.Command != "", nil
+	}
+	if len(args) > 0 && len(strings.TrimSpace(string(stdin))) == 0 {
+		req.Command = strings.Join(args, " ")
+		return req, req.Command != "", nil
+	}
+
+	var p hookPayload
+	if err := json.Unmarshal(stdin, &p); err != nil {
+		return req, false, fmt.Errorf("parsing %s hook input: %w", agent, err)
+	}
+	req.Cwd = p.Cwd
+	req.Tool = p.ToolName
+
+	if p.ToolName != "" && !isShellTool(agent, p.ToolName) {
+		return req, false, nil
+	}
+
+	cmd := commandString(p.Command)
+	if cmd == "" && len(p.ToolInput) > 0 {
+		var input struct {
+			Command json.RawMessage `json:"command"`
+		}
+		if err := json.Unmarshal(p.ToolInput, &input); err == nil {
+			cmd = commandString(input.Command)
+		}
+	}
+	req.Command = cmd
+	return req, cmd != "", nil
+}
+
+func isShellTool(agent HookAgent, tool string) bool {
+	for _, name := range shellTools[agent] {
+		if strings.EqualFold(name, tool) {
+			return true
+		}
+	}
+	return false
+}
+
+// commandString accepts either a plain string or an argv array. For argv of
+// the form [bash -lc "script"] the script itself is returned.
+func commandString(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return strings.TrimSpace(s)
+	}
+	var argv []string
+	if err := json.Unmarshal(raw, &argv); err != nil {
+		return ""
+	}
+	if len(argv) == 3 && (argv[1] == "-c" || argv[1] == "-lc") {
+		return strings.TrimSpace(argv[2])
+	}
+	return strings.TrimSpace(strings.Join(argv, " "))
+}
+
+// HookResult is what an adapter writes back to the calling agent.
+type HookResult struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+// FormatHookResult renders a decision in the form each agent expects. All
+// adapters deny with exit code 2 and the reason on stderr, which Claude Code,
+// Gemini CLI and Codex feed back to the model; Gemini and Codex additionally
+// get a JSON decision on stdout. PATH wrappers exit 1 like the safety wrappers.
+func FormatHookResult(agent HookAgent, d Decision) HookResult {
+	if d.Allowed {
+		switch agent {
+		case HookGemini:
+			return HookResult{Stdout: `{"decision":"allow"}` + "\n"}
+		case HookCodex:
+			return HookResult{Stdout: `{"decision":"approve"}` + "\n"}
+		}
+		return HookResult{}
+	}
+
+	msg := DenyMessage(d)
+	res := HookResult{Stderr: msg + "\n", ExitCode: 2}
+	switch agent {
+	case HookGemini:
+		out, _ := json.Marshal(map[string]string{"decision": "deny", "reason": msg})
+		res.Stdout = string(out) + "\n"
+	case HookCodex:
+		out, _ := json.Marshal(map[string]string{"decision": "block", "reason": msg})
+		res.Stdout = string(out) + "\n"
+	case HookWrapper:
+		res.ExitCode = 1
+	}
+	return res
+}
+
+// DenyMessage explains a denied decision in one line.
+func DenyMessage(d Decision) string {
+	reason := d.Reason
+	if reason == "" {
+		reason = "policy violation"
+	}
+	msg := "NTM policy: command blocked: " + reason
+	switch d.ApprovalStatus {
+	case "denied", "expired", "timeout":
+		msg = fmt.Sprintf("NTM policy: approval %s for command (%s)", d.ApprovalStatus, reason)
+	case "unavailable":
+		msg = "NTM policy: command requires approval: " + reason
+	}
+	if d.Pattern != "" {
+		msg += " [pattern: " + d.Pattern + "]"
+	}
+	if d.Error != "" {
+		msg += " (" + d.Error + ")"
+	}
+	return msg
+}
+
+// hookInstall describes where an agent reads its pre-tool hooks from.
+type hookInstall struct {
+	settings string // settings file relative to the home directory
+	event    string
+	matcher  string
+	timeout  int // in the unit the agent expects
+}
+
+var hookInstalls = map[HookAgent]hookInstall{
+	// Claude Code hook timeouts are in seconds.
+	HookClaude: {settings: ".claude/settings.json", event: "PreToolUse", matcher: "Bash", timeout: 660},
+	// Gemini CLI hook timeouts are in milliseconds.
+	HookGemini: {settings: ".gemini/settings.json", event: "BeforeTool", matcher: "run_shell_command", timeout: 660000},
+}
+
+// HookSettingsPath returns the settings file that registers hooks for agent,
+// or "" when the agent is covered by PATH wrappers instead.
+func HookSettingsPath(home string, agent HookAgent) string {
+	inst, ok := hookInstalls[agent]
+	if !ok {
+		return ""
+	}
+	return filepath.Join(home, inst.settings)
+}
+
+// MergeHookSettings adds a pre-tool hook running command to an agent's JSON
+// settings, preserving everything else. It reports false when an ntm policy
+// hook is already registered.
+func MergeHookSettings(data []byte, agent HookAgent, command string) ([]byte, bool, error) {
+	inst, ok := hookInstalls[agent]
+	if !ok {
+		return nil, false, fmt.Errorf("agent %q has no hook settings", agent)
+	}
+
+	settings := map[string]interface{}{}
+	if len(strings.TrimSpace(string(data))) > 0 {
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return nil, false, fmt.Errorf("parsing settings: %w", err)
+		}
+	}
+
+	hooks, _ := settings["hooks"].(map[string]interface{})
+	if hooks == nil {
+		hooks = map[string]interface{}{}
+	}
+	entries, _ := hooks[inst.event].([]interface{})
+	for _, e := range entries {
+		entry, _ := e.(map[string]interface{})
+		inner, _ := entry["hooks"].([]interface{})
+		for _, h := range inner {
+			hook, _ := h.(map[string]interface{})
+			if cmd, _ := hook["command"].(string); strings.HasPrefix(cmd, "ntm policy hook") {
+				return data, false, nil
+			}
+		}
+	}
+
+	entries = append(entries, map[string]interface{}{
+		"matcher": inst.matcher,
+		"hooks": []interface{}{
+			map[string]interface{}{
+				"type":    "command",
+				"command": command,
+				"timeout": inst.timeout,
+			},
+		},
+	})
+	hooks[inst.event] = entries
+	settings["hooks"] = hooks
+
+	out, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return nil, false, fmt.Errorf("encoding settings: %w", err)
+	}
+	return append(out, '\n'), true, nil
+}
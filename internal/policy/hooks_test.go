@@ -0,0 +1,163 @@
+package policy
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseHookInput(t *testing.T) {
+	cases := []struct {
+		name      string
+		agent     HookAgent
+		stdin     string
+		args      []string
+		wantCmd   string
+		wantCheck bool
+	}{
+		{
+			name:      "claude bash",
+			agent:     HookClaude,
+			stdin:     `{"hook_event_name":"PreToolUse","cwd":"/repo","tool_name":"Bash","tool_input":{"command":"git push --force"}}`,
+			wantCmd:   "git push --force",
+			wantCheck: true,
+		},
+		{
+			name:  "claude non-shell tool",
+			agent: HookClaude,
+			stdin: `{"tool_name":"Edit","tool_input":{"file_path":"main.go"}}`,
+		},
+		{
+			name:      "gemini shell",
+			agent:     HookGemini,
+			stdin:     `{"hook_event_name":"BeforeTool","tool_name":"run_shell_command","tool_input":{"command":"rm -rf /"}}`,
+			wantCmd:   "rm -rf /",
+			wantCheck: true,
+		},
+		{
+			name:  "gemini other tool",
+			agent: HookGemini,
+			stdin: `{"tool_name":"read_file","tool_input":{"path":"x"}}`,
+		},
+		{
+			name:      "codex argv",
+			agent:     HookCodex,
+			stdin:     `{"cwd":"/repo","command":["bash","-lc","git reset --hard"]}`,
+			wantCmd:   "git reset --hard",
+			wantCheck: true,
+		},
+		{
+			name:      "codex plain argv",
+			agent:     HookCodex,
+			stdin:     `{"command":["git","clean","-fd"]}`,
+			wantCmd:   "git clean -fd",
+			wantCheck: true,
+		},
+		{
+			name:      "codex args without stdin",
+			agent:     HookCodex,
+			args:      []string{"git", "status"},
+			wantCmd:   "git status",
+			wantCheck: true,
+		},
+		{
+			name:      "wrapper",
+			agent:     HookWrapper,
+			args:      []string{"git", "branch", "-D", "main"},
+			wantCmd:   "git branch -D main",
+			wantCheck: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, check, err := ParseHookInput(tc.agent, []byte(tc.stdin), tc.args)
+			if err != nil {
+				t.Fatalf("ParseHookInput: %v", err)
+			}
+			if check != tc.wantCheck || req.Command != tc.wantCmd {
+				t.Errorf("got (%q, %v), want (%q, %v)", req.Command, check, tc.wantCmd, tc.wantCheck)
+			}
+			if req.Agent != string(tc.agent) {
+				t.Errorf("Agent = %q", req.Agent)
+			}
+		})
+	}
+
+	if _, _, err := ParseHookInput(HookClaude, []byte("not json"), nil); err == nil {
+		t.Error("expected error for malformed hook input")
+	}
+}
+
+func TestFormatHookResult(t *testing.T) {
+	allow := Decision{Allowed: true, Action: ActionAllow}
+	deny := Decision{Action: ActionBlock, Reason: "root wipe", Pattern: `rm\s+-rf`}
+
+	if res := FormatHookResult(HookClaude, allow); res.ExitCode != 0 || res.Stderr != "" {
+		t.Errorf("claude allow = %+v", res)
+	}
+	res := FormatHookResult(HookClaude, deny)
+	if res.ExitCode != 2 || !strings.Contains(res.Stderr, "root wipe") {
+		t.Errorf("claude deny = %+v", res)
+	}
+
+	res = FormatHookResult(HookGemini, deny)
+	var out map[string]string
+	if err := json.Unmarshal([]byte(res.Stdout), &out); err != nil {
+		t.Fatalf("gemini stdout not JSON: %q", res.Stdout)
+	}
+	if res.ExitCode != 2 || out["decision"] != "deny" || !strings.Contains(out["reason"], "root wipe") {
+		t.Errorf("gemini deny = %+v", res)
+	}
+
+	res = FormatHookResult(HookCodex, deny)
+	if err := json.Unmarshal([]byte(res.Stdout), &out); err != nil || out["decision"] != "block" {
+		t.Errorf("codex deny = %+v", res)
+	}
+
+	if res := FormatHookResult(HookWrapper, deny); res.ExitCode != 1 {
+		t.Errorf("wrapper deny exit = %d, want 1", res.ExitCode)
+	}
+
+	timedOut := Decision{Action: ActionApprove, Reason: "pushes need sign-off", ApprovalStatus: "timeout"}
+	if msg := DenyMessage(timedOut); !strings.Contains(msg, "approval timeout") {
+		t.Errorf("DenyMessage = %q", msg)
+	}
+}
+
+func TestMergeHookSettings(t *testing.T) {
+	existing := []byte(`{"model":"opus","hooks":{"PreToolUse":[{"matcher":"Edit","hooks":[{"type":"command","command":"lint"}]}]}}`)
+	out, changed, err := MergeHookSettings(existing, HookClaude, "ntm policy hook claude")
+	if err != nil || !changed {
+		t.Fatalf("MergeHookSettings = %v, %v", changed, err)
+	}
+	var settings struct {
+		Model string `json:"model"`
+		Hooks map[string][]struct {
+			Matcher string `json:"matcher"`
+			Hooks   []struct {
+				Command string `json:"command"`
+			} `json:"hooks"`
+		} `json:"hooks"`
+	}
+	if err := json.Unmarshal(out, &settings); err != nil {
+		t.Fatal(err)
+	}
+	pre := settings.Hooks["PreToolUse"]
+	if settings.Model != "opus" || len(pre) != 2 || pre[1].Matcher != "Bash" || pre[1].Hooks[0].Command != "ntm policy hook claude" {
+		t.Fatalf("merged settings = %s", out)
+	}
+
+	if _, changed, err := MergeHookSettings(out, HookClaude, "ntm policy hook claude"); err != nil || changed {
+		t.Errorf("second merge should be a no-op, got changed=%v err=%v", changed, err)
+	}
+
+	out, changed, err = MergeHookSettings(nil, HookGemini, "ntm policy hook gemini")
+	if err != nil || !changed || !strings.Contains(string(out), `"BeforeTool"`) || !strings.Contains(string(out), "run_shell_command") {
+		t.Errorf("gemini settings = %s, %v", out, err)
+	}
+
+	if _, _, err := MergeHookSettings(nil, HookCodex, "x"); err == nil {
+		t.Error("codex has no settings file; expected error")
+	}
+}
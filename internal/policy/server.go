@@ -0,0 +1,428 @@
+package policy
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSocketSubPath is the policy service socket location relative to the
+// user's home directory.
+const DefaultSocketSubPath = ".ntm/policy.sock"
+
+// DefaultApprovalTimeout bounds how long an approval_required decision blocks
+// the calling hook before it is treated as denied.
+const DefaultApprovalTimeout = 10 * time.Minute
+
+// DefaultSocketPath returns the default policy service socket path.
+func DefaultSocketPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return DefaultSocketSubPath
+	}
+	return filepath.Join(home, DefaultSocketSubPath)
+}
+
+// Request is a single decision request sent by an agent hook or PATH wrapper.
+type Request struct {
+	Command string `json:"command"`
+	Tool    string `json:"tool,omitempty"`    // agent tool name, e.g. Bash or run_shell_command
+	Agent   string `json:"agent,omitempty"`   // hook adapter: claude, codex, gemini, wrapper
+	Pane    string `json:"pane,omitempty"`    // tmux pane id ($TMUX_PANE)
+	Session string `json:"session,omitempty"` // ntm session, when the caller knows it
+	Cwd     string `json:"cwd,omitempty"`     // working directory used to locate the policy
+}
+
+// Identity describes the pane a request originated from.
+type Identity struct {
+	Session   string `json:"session,omitempty"`
+	PaneTitle string `json:"pane_title,omitempty"`
+	AgentType string `json:"agent_type,omitempty"`
+}
+
+// Decision is the service's answer to a Request.
+type Decision struct {
+	Allowed        bool     `json:"allowed"`
+	Action         Action   `json:"action"` // matched rule action; allow when nothing matched
+	Pattern        string   `json:"pattern,omitempty"`
+	Reason         string   `json:"reason,omitempty"`
+	SLB            bool     `json:"slb,omitempty"`
+	ApprovalID     string   `json:"approval_id,omitempty"`
+	ApprovalStatus string   `json:"approval_status,omitempty"`
+	PolicyPath     string   `json:"policy_path,omitempty"`
+	Identity       Identity `json:"identity"`
+	Error          string   `json:"error,omitempty"`
+}
+
+// Approver bridges approval_required decisions to the approval engine.
+type Approver interface {
+	// RequestApproval files an approval request and returns its id.
+	RequestApproval(ctx context.Context, req Request, id Identity, m *Match) (string, error)
+	// ApprovalStatus reports pending, approved, denied or expired along with
+	// an optional reason supplied by the approver.
+	ApprovalStatus(ctx context.Context, approvalID string) (status, reason string, err error)
+}
+
+// ServerConfig configures a Server. Only Approver is required for
+// approval_required rules to be honoured; without one they are denied.
+type ServerConfig struct {
+	Approver        Approver
+	ApprovalTimeout time.Duration
+	ApprovalPoll    time.Duration
+	// Identify resolves a tmux pane id to its session and agent.
+	Identify func(pane string) Identity
+	// Record is called once per decision, e.g. to write the audit log.
+	Record func(req Request, d Decision)
+}
+
+// Server evaluates commands against the project policy on behalf of agent
+// hooks. Policies are looked up per working directory and reloaded when the
+// file changes, so edits to .ntm/policy.yaml apply without a restart.
+type Server struct {
+	cfg ServerConfig
+
+	mu    sync.Mutex
+	cache map[string]cachedPolicy
+}
+
+type cachedPolicy struct {
+	modTime time.Time
+	policy  *Policy
+}
+
+// NewServer creates a policy decision server.
+func NewServer(cfg ServerConfig) *Server {
+	if cfg.ApprovalTimeout <= 0 {
+		cfg.ApprovalTimeout = DefaultApprovalTimeout
+	}
+	if cfg.ApprovalPoll <= 0 {
+		cfg.ApprovalPoll = time.Second
+	}
+	return &Server{cfg: cfg, cache: make(map[string]cachedPolicy)}
+}
+
+// FindPolicyFile walks up from dir looking for .ntm/policy.yaml and falls
+// back to the user's home policy. It returns "" when neither exists.
+func FindPolicyFile(dir string) string {
+	if dir != "" {
+		if abs, err := filepath.Abs(dir); err == nil {
+			dir = abs
+		}
+		for d := dir; ; d = filepath.Dir(d) {
+			candidate := filepath.Join(d, DefaultPolicyPath)
+			if _, err := os.Stat(candidate); err == nil {
+				return candidate
+			}
+			if parent := filepath.Dir(d); parent == d {
+				break
+			}
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		candidate := filepath.Join(home, DefaultPolicyPath)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// LoadForDir loads the policy that governs commands run in dir, or the
+// default policy when no policy file is found.
+func LoadForDir(dir string) (*Policy, string, error) {
+	path := FindPolicyFile(dir)
+	if path == "" {
+		return DefaultPolicy(), "", nil
+	}
+	p, err := Load(path)
+	if err != nil {
+		return nil, path, err
+	}
+	return p, path, nil
+}
+
+func (s *Server) policyFor(dir string) (*Policy, string, error) {
+	path := FindPolicyFile(dir)
+	if path == "" {
+		return DefaultPolicy(), "", nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, path, fmt.Errorf("stat policy: %w", err)
+	}
+
+	s.mu.Lock()
+	cached, ok := s.cache[path]
+	s.mu.Unlock()
+	if ok && cached.modTime.Equal(info.ModTime()) {
+		return cached.policy, path, nil
+	}
+
+	p, err := Load(path)
+	if err != nil {
+		return nil, path, err
+	}
+	s.mu.Lock()
+	s.cache[path] = cachedPolicy{modTime: info.ModTime(), policy: p}
+	s.mu.Unlock()
+	return p, path, nil
+}
+
+// Decide evaluates a request. For approval_required matches it files an
+// approval request and blocks until it is resolved, ctx is cancelled, or the
+// approval timeout passes.
+func (s *Server) Decide(ctx context.Context, req Request) Decision {
+	d := s.decide(ctx, req)
+	if s.cfg.Record != nil {
+		s.cfg.Record(req, d)
+	}
+	return d
+}
+
+func (s *Server) decide(ctx context.Context, req Request) Decision {
+	var d Decision
+	if req.Pane != "" && s.cfg.Identify != nil {
+		d.Identity = s.cfg.Identify(req.Pane)
+	}
+	if d.Identity.Session == "" {
+		d.Identity.Session = req.Session
+	}
+
+	command := strings.TrimSpace(req.Command)
+	if command == "" {
+		d.Allowed = true
+		d.Action = ActionAllow
+		return d
+	}
+
+	p, path, err := s.policyFor(req.Cwd)
+	d.PolicyPath = path
+	if err != nil {
+		// A broken policy file must not silently disable protection.
+		d.Action = ActionBlock
+		d.Reason = "policy file is invalid"
+		d.Error = err.Error()
+		return d
+	}
+
+	if m := applyMatch(p, command, &d); m != nil && m.Action == ActionApprove {
+		s.awaitApproval(ctx, req, m, &d)
+	}
+	return d
+}
+
+// applyMatch checks command against p and fills in the rule outcome. It
+// returns the match so callers can handle approval_required rules.
+func applyMatch(p *Policy, command string, d *Decision) *Match {
+	m := p.Check(command)
+	if m == nil {
+		d.Allowed = true
+		d.Action = ActionAllow
+		return nil
+	}
+	d.Action = m.Action
+	d.Pattern = m.Pattern
+	d.Reason = m.Reason
+	d.SLB = m.SLB
+	d.Allowed = m.Action == ActionAllow
+	return m
+}
+
+func (s *Server) awaitApproval(ctx context.Context, req Request, m *Match, d *Decision) {
+	if s.cfg.Approver == nil {
+		d.ApprovalStatus = "unavailable"
+		d.Error = "no approval engine configured"
+		return
+	}
+
+	id, err := s.cfg.Approver.RequestApproval(ctx, req, d.Identity, m)
+	if err != nil {
+		d.ApprovalStatus = "unavailable"
+		d.Error = fmt.Sprintf("requesting approval: %v", err)
+		return
+	}
+	d.ApprovalID = id
+
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.ApprovalTimeout)
+	defer cancel()
+	ticker := time.NewTicker(s.cfg.ApprovalPoll)
+	defer ticker.Stop()
+
+	for {
+		status, reason, err := s.cfg.Approver.ApprovalStatus(ctx, id)
+		if err == nil && status != "pending" {
+			d.ApprovalStatus = status
+			d.Allowed = status == "approved"
+			if !d.Allowed && reason != "" {
+				d.Reason = reason
+			}
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			d.ApprovalStatus = "timeout"
+			d.Error = "approval not granted before timeout"
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Serve accepts connections on ln until ctx is cancelled. Each connection
+// carries one JSON Request line and receives one JSON Decision line.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("accept: %w", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.handle(ctx, conn)
+		}()
+	}
+}
+
+func (s *Server) handle(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil && len(line) == 0 {
+		return
+	}
+
+	var d Decision
+	var req Request
+	if err := json.Unmarshal(line, &req); err != nil {
+		d = Decision{Action: ActionBlock, Reason: "malformed policy request", Error: err.Error()}
+	} else {
+		d = s.Decide(ctx, req)
+	}
+
+	data, _ := json.Marshal(d)
+	conn.Write(append(data, '\n'))
+}
+
+// Listen opens the unix socket at path, replacing a stale socket left by a
+// previous server. It fails if another server is still answering there.
+func Listen(path string) (net.Listener, error) {
+	if path == "" {
+		path = DefaultSocketPath()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("creating socket directory: %w", err)
+	}
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("policy server already listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("removing stale socket: %w", err)
+		}
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("listening on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("securing socket: %w", err)
+	}
+	return ln, nil
+}
+
+// ErrServerNotRunning is wrapped by Query's error when nothing is listening
+// on the socket. Any other Query error means the server was reached but no
+// decision came back.
+var ErrServerNotRunning = errors.New("policy server is not running")
+
+// Query sends req to the policy server at socketPath and waits for its
+// decision. The caller's ctx bounds the wait, including any approval.
+func Query(ctx context.Context, socketPath string, req Request) (Decision, error) {
+	if socketPath == "" {
+		socketPath = DefaultSocketPath()
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "unix", socketPath)
+	if err != nil {
+		return Decision{}, fmt.Errorf("connecting to policy server: %w: %w", ErrServerNotRunning, err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return Decision{}, fmt.Errorf("encoding request: %w", err)
+	}
+	if _, err := conn.Write(append(data, '\n')); err != nil {
+		return Decision{}, fmt.Errorf("sending request: %w", err)
+	}
+
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil && len(line) == 0 {
+		return Decision{}, fmt.Errorf("reading decision: %w", err)
+	}
+	var d Decision
+	if err := json.Unmarshal(line, &d); err != nil {
+		return Decision{}, fmt.Errorf("decoding decision: %w", err)
+	}
+	return d, nil
+}
+
+// EvaluateLocal decides a request without a running server. There is nobody
+// to resolve approvals here, so approval_required commands are denied with a
+// hint to start the service.
+func EvaluateLocal(req Request) Decision {
+	d := Decision{Identity: Identity{Session: req.Session}}
+	command := strings.TrimSpace(req.Command)
+	if command == "" {
+		d.Allowed = true
+		d.Action = ActionAllow
+		return d
+	}
+
+	p, path, err := LoadForDir(req.Cwd)
+	d.PolicyPath = path
+	if err != nil {
+		d.Action = ActionBlock
+		d.Reason = "policy file is invalid"
+		d.Error = err.Error()
+		return d
+	}
+
+	if m := applyMatch(p, command, &d); m != nil && m.Action == ActionApprove {
+		d.ApprovalStatus = "unavailable"
+		d.Error = "policy server is not running; start it with 'ntm policy serve'"
+	}
+	return d
+}
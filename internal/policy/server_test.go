@@ -0,0 +1,244 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeApprover struct {
+	mu       sync.Mutex
+	requests []Request
+	status   string
+	reason   string
+	polls    int
+	resolve  int // polls before status is reported instead of pending
+}
+
+func (f *fakeApprover) RequestApproval(ctx context.Context, req Request, id Identity, m *Match) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, req)
+	return "appr-1", nil
+}
+
+func (f *fakeApprover) ApprovalStatus(ctx context.Context, id string) (string, string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.polls++
+	if f.polls <= f.resolve {
+		return "pending", "", nil
+	}
+	return f.status, f.reason, nil
+}
+
+func writePolicy(t *testing.T, dir, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, DefaultPolicyPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const testPolicy = `version: 1
+blocked:
+  - pattern: 'rm\s+-rf\s+/$'
+    reason: "root wipe"
+approval_required:
+  - pattern: 'git\s+push'
+    reason: "pushes need sign-off"
+allowed:
+  - pattern: 'git\s+status'
+`
+
+func TestServerDecide(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	project := t.TempDir()
+	policyPath := writePolicy(t, project, testPolicy)
+	sub := filepath.Join(project, "pkg", "inner")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var recorded []Decision
+	approver := &fakeApprover{status: "approved", resolve: 2}
+	srv := NewServer(ServerConfig{
+		Approver:     approver,
+		ApprovalPoll: time.Millisecond,
+		Identify: func(pane string) Identity {
+			return Identity{Session: "proj", PaneTitle: "proj__cc_1", AgentType: "cc"}
+		},
+		Record: func(req Request, d Decision) { recorded = append(recorded, d) },
+	})
+
+	ctx := context.Background()
+
+	d := srv.Decide(ctx, Request{Command: "rm -rf /", Cwd: sub, Pane: "%3"})
+	if d.Allowed || d.Action != ActionBlock || d.Reason != "root wipe" {
+		t.Fatalf("rm -rf / decision = %+v", d)
+	}
+	if d.PolicyPath != policyPath {
+		t.Errorf("PolicyPath = %q, want %q", d.PolicyPath, policyPath)
+	}
+	if d.Identity.PaneTitle != "proj__cc_1" {
+		t.Errorf("Identity = %+v", d.Identity)
+	}
+
+	d = srv.Decide(ctx, Request{Command: "git status", Cwd: sub})
+	if !d.Allowed || d.Action != ActionAllow {
+		t.Fatalf("git status decision = %+v", d)
+	}
+
+	d = srv.Decide(ctx, Request{Command: "git push origin main", Cwd: sub})
+	if !d.Allowed || d.ApprovalID != "appr-1" || d.ApprovalStatus != "approved" {
+		t.Fatalf("approved push decision = %+v", d)
+	}
+	if approver.polls != 3 {
+		t.Errorf("polls = %d, want 3", approver.polls)
+	}
+
+	approver.polls, approver.status, approver.reason = 0, "denied", "not today"
+	d = srv.Decide(ctx, Request{Command: "git push origin main", Cwd: sub})
+	if d.Allowed || d.ApprovalStatus != "denied" || d.Reason != "not today" {
+		t.Fatalf("denied push decision = %+v", d)
+	}
+
+	if len(recorded) != 4 {
+		t.Errorf("recorded %d decisions, want 4", len(recorded))
+	}
+}
+
+func TestServerApprovalTimeout(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	project := t.TempDir()
+	writePolicy(t, project, testPolicy)
+
+	srv := NewServer(ServerConfig{
+		Approver:        &fakeApprover{status: "approved", resolve: 1 << 30},
+		ApprovalPoll:    time.Millisecond,
+		ApprovalTimeout: 20 * time.Millisecond,
+	})
+	d := srv.Decide(context.Background(), Request{Command: "git push", Cwd: project})
+	if d.Allowed || d.ApprovalStatus != "timeout" {
+		t.Fatalf("decision = %+v, want timeout denial", d)
+	}
+
+	noApprover := NewServer(ServerConfig{})
+	d = noApprover.Decide(context.Background(), Request{Command: "git push", Cwd: project})
+	if d.Allowed || d.ApprovalStatus != "unavailable" {
+		t.Fatalf("decision without approver = %+v", d)
+	}
+}
+
+func TestServerReloadsAndRejectsInvalidPolicy(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	project := t.TempDir()
+	path := writePolicy(t, project, testPolicy)
+	srv := NewServer(ServerConfig{})
+
+	if d := srv.Decide(context.Background(), Request{Command: "make deploy", Cwd: project}); !d.Allowed {
+		t.Fatalf("make deploy should be allowed initially: %+v", d)
+	}
+
+	updated := `version: 1
+blocked:
+  - pattern: 'make\s+deploy'
+    reason: "deploys go through CI"
+`
+	if err := os.WriteFile(path, []byte(updated), 0644); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatal(err)
+	}
+	if d := srv.Decide(context.Background(), Request{Command: "make deploy", Cwd: project}); d.Allowed {
+		t.Fatalf("make deploy should be blocked after reload: %+v", d)
+	}
+
+	if err := os.WriteFile(path, []byte("blocked:\n  - pattern: '(['\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := future.Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	d := srv.Decide(context.Background(), Request{Command: "ls", Cwd: project})
+	if d.Allowed || d.Error == "" {
+		t.Fatalf("invalid policy should fail closed: %+v", d)
+	}
+}
+
+func TestServeAndQuery(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	project := t.TempDir()
+	writePolicy(t, project, testPolicy)
+
+	// Unix socket paths are length-limited, so avoid the long test tempdir.
+	dir, err := os.MkdirTemp("", "ntmpol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sock := filepath.Join(dir, "policy.sock")
+
+	ln, err := Listen(sock)
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	if _, err := Listen(sock); err == nil {
+		t.Fatal("second Listen on a live socket should fail")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	srv := NewServer(ServerConfig{Approver: &fakeApprover{status: "approved"}, ApprovalPoll: time.Millisecond})
+	go func() { done <- srv.Serve(ctx, ln) }()
+
+	qctx, qcancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer qcancel()
+	d, err := Query(qctx, sock, Request{Command: "rm -rf /", Cwd: project, Agent: "claude"})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if d.Allowed || d.Reason != "root wipe" {
+		t.Fatalf("decision = %+v", d)
+	}
+	d, err = Query(qctx, sock, Request{Command: "git push", Cwd: project})
+	if err != nil || !d.Allowed {
+		t.Fatalf("approved push: %+v, %v", d, err)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Serve returned %v", err)
+	}
+	if _, err := Query(qctx, sock, Request{Command: "ls"}); !errors.Is(err, ErrServerNotRunning) {
+		t.Fatalf("Query after the server stopped = %v, want ErrServerNotRunning", err)
+	}
+}
+
+func TestEvaluateLocal(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	project := t.TempDir()
+	writePolicy(t, project, testPolicy)
+
+	if d := EvaluateLocal(Request{Command: "git status", Cwd: project}); !d.Allowed {
+		t.Errorf("git status should be allowed: %+v", d)
+	}
+	if d := EvaluateLocal(Request{Command: "rm -rf /", Cwd: project}); d.Allowed {
+		t.Errorf("rm -rf / should be blocked: %+v", d)
+	}
+	d := EvaluateLocal(Request{Command: "git push", Cwd: project})
+	if d.Allowed || d.ApprovalStatus != "unavailable" {
+		t.Errorf("approval_required without a server should be denied: %+v", d)
+	}
+}
@@ -0,0 +1,24 @@
+package promptqueue
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// claimDir holds the pane claim files; tests point it elsewhere.
+var claimDir = defaultClaimDir
+
+func defaultClaimDir() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "ntm", "panes")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("ntm-%d", os.Getuid()), "panes")
+}
+
+// claimPath returns the claim file of a pane.
+func claimPath(session, paneID string) string {
+	name := strings.NewReplacer("/", "_", "%", "", ":", "_").Replace(session + "-" + paneID)
+	return filepath.Join(claimDir(), name+".lock")
+}
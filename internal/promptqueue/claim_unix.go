@@ -0,0 +1,33 @@
+//go:build unix
+
+package promptqueue
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// ClaimPane takes exclusive use of a pane for typing into it, across
+// processes: the dispatcher holds it while delivering a prompt, and other
+// loops that type into idle panes, such as the quota forecaster, take it so
+// the two never interleave keystrokes. It returns false, without waiting,
+// when the pane is already claimed. The caller calls release when done.
+func ClaimPane(session, paneID string) (release func(), ok bool) {
+	path := claimPath(session, paneID)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return func() {}, true // Claims are best effort; never block delivery
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return func() {}, true
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		return nil, false
+	}
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, true
+}
@@ -0,0 +1,27 @@
+//go:build windows
+
+package promptqueue
+
+import "sync"
+
+var (
+	claimMu sync.Mutex
+	claimed = make(map[string]bool)
+)
+
+// ClaimPane takes exclusive use of a pane for typing into it; see the unix
+// version. Claims only exclude other loops of this process on Windows.
+func ClaimPane(session, paneID string) (release func(), ok bool) {
+	path := claimPath(session, paneID)
+	claimMu.Lock()
+	defer claimMu.Unlock()
+	if claimed[path] {
+		return nil, false
+	}
+	claimed[path] = true
+	return func() {
+		claimMu.Lock()
+		delete(claimed, path)
+		claimMu.Unlock()
+	}, true
+}
@@ -0,0 +1,263 @@
+// Package promptqueue delivers queued prompts to agent panes as they go idle.
+//
+// Prompts live in the state store (see state.PromptQueueStore); the
+// dispatcher polls a session's panes, and for each idle agent pane sends the
+// highest-priority prompt waiting for that pane or its agent type.
+package promptqueue
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/state"
+	"github.com/shahbajlive/ntm/internal/status"
+	"github.com/shahbajlive/ntm/internal/tmux"
+)
+
+const (
+	// DefaultInterval is how often Run polls for idle panes.
+	DefaultInterval = 2 * time.Second
+	// DefaultCooldown keeps a pane from receiving a second prompt before the
+	// status detector has had a chance to see it start working on the first.
+	DefaultCooldown = 10 * time.Second
+	// DefaultMaxAttempts is how many failed sends a prompt gets before it is
+	// marked failed.
+	DefaultMaxAttempts = 3
+	// DefaultClaimTimeout is how long a prompt may stay claimed before it is
+	// assumed its dispatcher died mid-send and it is returned to pending.
+	DefaultClaimTimeout = 2 * time.Minute
+)
+
+// SendFunc types a prompt into a pane.
+type SendFunc func(session string, pane tmux.Pane, prompt string) error
+
+// HoldFunc reports why prompts must not be sent to a pane right now, or nil
+// if they may be.
+type HoldFunc func(session string, pane tmux.Pane) error
+
+// Config configures a Dispatcher. Only Store and Send are required.
+type Config struct {
+	Store       *state.PromptQueueStore
+	Send        SendFunc
+	Interval    time.Duration
+	Cooldown    time.Duration
+	MaxAttempts int
+	// ClaimTimeout returns prompts claimed longer ago than this to pending.
+	ClaimTimeout time.Duration
+
+	// Hold, if set, is checked before a prompt is claimed. While it returns
+	// an error the prompt stays pending without using up an attempt.
+	Hold HoldFunc
+
+	// Panes lists a session's panes; defaults to tmux.GetPanesContext.
+	Panes func(ctx context.Context, session string) ([]tmux.Pane, error)
+	// States reports each pane's state keyed by pane ID; defaults to the
+	// unified status detector.
+	States func(ctx context.Context, session string) (map[string]status.AgentState, error)
+	// Now defaults to time.Now.
+	Now func() time.Time
+}
+
+// Delivery records one prompt handed to a pane.
+type Delivery struct {
+	PromptID int64  `json:"prompt_id"`
+	PaneID   string `json:"pane_id"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Dispatcher delivers queued prompts to idle panes.
+type Dispatcher struct {
+	cfg Config
+
+	mu       sync.Mutex
+	lastSent map[string]time.Time // pane ID -> last delivery
+}
+
+// New creates a dispatcher, filling in defaults for unset Config fields.
+func New(cfg Config) *Dispatcher {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = DefaultCooldown
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = DefaultMaxAttempts
+	}
+	if cfg.ClaimTimeout <= 0 {
+		cfg.ClaimTimeout = DefaultClaimTimeout
+	}
+	if cfg.Panes == nil {
+		cfg.Panes = tmux.GetPanesContext
+	}
+	if cfg.States == nil {
+		cfg.States = detectStates
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return &Dispatcher{cfg: cfg, lastSent: make(map[string]time.Time)}
+}
+
+func detectStates(ctx context.Context, session string) (map[string]status.AgentState, error) {
+	statuses, err := status.NewDetector().DetectAllContext(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+	states := make(map[string]status.AgentState, len(statuses))
+	for _, s := range statuses {
+		states[s.PaneID] = s.State
+	}
+	return states, nil
+}
+
+// DispatchOnce expires stale prompts and delivers at most one prompt to each
+// idle agent pane of session. It does not touch tmux when nothing is waiting.
+//
+// Panes with a prompt waiting are claimed before pane states are detected,
+// once per call, so nothing else types into a pane between the check that
+// finds it idle and the send.
+func (d *Dispatcher) DispatchOnce(ctx context.Context, session string) ([]Delivery, error) {
+	if d.cfg.Store == nil || d.cfg.Send == nil {
+		return nil, fmt.Errorf("prompt queue dispatcher is not configured")
+	}
+	now := d.cfg.Now()
+	if _, err := d.cfg.Store.ExpireStale(now, d.cfg.ClaimTimeout); err != nil {
+		return nil, fmt.Errorf("expire queued prompts: %w", err)
+	}
+	waiting, err := d.cfg.Store.List(state.PromptQueueFilter{SessionName: session, Status: state.QueueStatusPending, Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(waiting) == 0 {
+		return nil, nil
+	}
+
+	panes, err := d.cfg.Panes(ctx, session)
+	if err != nil {
+		return nil, fmt.Errorf("list panes: %w", err)
+	}
+	// Lowest pane index first, so "first idle pane" is stable.
+	sort.Slice(panes, func(i, j int) bool { return panes[i].Index < panes[j].Index })
+
+	var (
+		claimed  []tmux.Pane
+		releases []func()
+	)
+	defer func() {
+		for _, release := range releases {
+			release()
+		}
+	}()
+	for _, p := range panes {
+		if p.Type == tmux.AgentUser || d.coolingDown(p.ID, now) {
+			continue
+		}
+		q, err := d.cfg.Store.Next(session, p.ID, string(p.Type), now)
+		if err != nil {
+			return nil, err
+		}
+		if q == nil {
+			continue
+		}
+		release, ok := ClaimPane(session, p.ID)
+		if !ok {
+			continue // Another loop is typing into the pane
+		}
+		claimed = append(claimed, p)
+		releases = append(releases, release)
+	}
+	if len(claimed) == 0 {
+		return nil, nil
+	}
+	states, err := d.cfg.States(ctx, session)
+	if err != nil {
+		return nil, fmt.Errorf("detect pane states: %w", err)
+	}
+
+	var deliveries []Delivery
+	for _, p := range claimed {
+		if ctx.Err() != nil {
+			return deliveries, ctx.Err()
+		}
+		if states[p.ID] != status.StateIdle {
+			continue
+		}
+		delivery, err := d.deliver(session, p, now)
+		if err != nil {
+			return deliveries, err
+		}
+		if delivery != nil {
+			deliveries = append(deliveries, *delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+// deliver sends the next prompt waiting for idle pane p, if any. The caller
+// holds the pane's claim. The prompt is looked up again since an earlier
+// pane may have taken one addressed to its agent type.
+func (d *Dispatcher) deliver(session string, p tmux.Pane, now time.Time) (*Delivery, error) {
+	q, err := d.cfg.Store.Next(session, p.ID, string(p.Type), now)
+	if err != nil || q == nil {
+		return nil, err
+	}
+	if d.cfg.Hold != nil {
+		if err := d.cfg.Hold(session, p); err != nil {
+			slog.Debug("queued prompt held", "session", session, "prompt", q.ID, "pane", p.ID, "reason", err)
+			return nil, nil
+		}
+	}
+	claimed, err := d.cfg.Store.Claim(q.ID)
+	if err != nil || !claimed {
+		return nil, err // Another dispatcher got it first
+	}
+
+	sendErr := d.cfg.Send(session, p, q.Prompt)
+	d.markSent(p.ID, now)
+	if err := d.cfg.Store.Complete(q.ID, p.ID, sendErr, d.cfg.MaxAttempts); err != nil {
+		return nil, err
+	}
+	delivery := &Delivery{PromptID: q.ID, PaneID: p.ID}
+	if sendErr != nil {
+		delivery.Error = sendErr.Error()
+	}
+	return delivery, nil
+}
+
+// Run dispatches on every interval until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context, session string) {
+	ticker := time.NewTicker(d.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deliveries, err := d.DispatchOnce(ctx, session)
+			if err != nil && ctx.Err() == nil {
+				slog.Debug("prompt queue dispatch failed", "session", session, "error", err)
+			}
+			for _, dl := range deliveries {
+				slog.Debug("delivered queued prompt", "session", session, "prompt", dl.PromptID, "pane", dl.PaneID, "error", dl.Error)
+			}
+		}
+	}
+}
+
+func (d *Dispatcher) coolingDown(paneID string, now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	last, ok := d.lastSent[paneID]
+	return ok && now.Sub(last) < d.cfg.Cooldown
+}
+
+func (d *Dispatcher) markSent(paneID string, now time.Time) {
+	d.mu.Lock()
+	d.lastSent[paneID] = now
+	d.mu.Unlock()
+}
@@ -0,0 +1,274 @@
+package promptqueue
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/state"
+	"github.com/shahbajlive/ntm/internal/status"
+	"github.com/shahbajlive/ntm/internal/tmux"
+)
+
+func openQueue(t *testing.T) *state.PromptQueueStore {
+	t.Helper()
+	store, err := state.Open(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	if err := store.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	dir := t.TempDir()
+	claimDir = func() string { return dir }
+	t.Cleanup(func() { claimDir = defaultClaimDir })
+	return state.NewPromptQueueStore(store)
+}
+
+func TestDispatchOnce(t *testing.T) {
+	qs := openQueue(t)
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	panes := []tmux.Pane{
+		{ID: "%3", Index: 3, Type: tmux.AgentCodex},
+		{ID: "%1", Index: 1, Type: tmux.AgentClaude},
+		{ID: "%2", Index: 2, Type: tmux.AgentClaude},
+	}
+	states := map[string]status.AgentState{"%1": status.StateIdle, "%2": status.StateIdle, "%3": status.StateWorking}
+
+	enqueue := func(q state.QueuedPrompt) int64 {
+		t.Helper()
+		q.SessionName = "proj"
+		q.Priority = state.DefaultQueuePriority
+		got, _, err := qs.Enqueue(&q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got.ID
+	}
+	forPane2 := enqueue(state.QueuedPrompt{PaneID: "%2", AgentType: "cc", Prompt: "pane two"})
+	anyCC := enqueue(state.QueuedPrompt{AgentType: "cc", Prompt: "any claude"})
+	forCodex := enqueue(state.QueuedPrompt{PaneID: "%3", AgentType: "cod", Prompt: "codex"})
+
+	var sent []string
+	failPane := ""
+	panesCalls := 0
+	d := New(Config{
+		Store: qs,
+		Send: func(_ string, p tmux.Pane, prompt string) error {
+			if p.ID == failPane {
+				return errors.New("send-keys failed")
+			}
+			sent = append(sent, p.ID+":"+prompt)
+			return nil
+		},
+		Panes: func(context.Context, string) ([]tmux.Pane, error) {
+			panesCalls++
+			return append([]tmux.Pane(nil), panes...), nil
+		},
+		States: func(context.Context, string) (map[string]status.AgentState, error) { return states, nil },
+		Now:    func() time.Time { return now },
+	})
+
+	deliveries, err := d.DispatchOnce(context.Background(), "proj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 2 || len(sent) != 2 || sent[0] != "%1:any claude" || sent[1] != "%2:pane two" {
+		t.Fatalf("deliveries = %+v, sent = %v", deliveries, sent)
+	}
+	for _, id := range []int64{forPane2, anyCC} {
+		if q, _ := qs.Get(id); q.Status != state.QueueStatusDelivered {
+			t.Errorf("prompt %d status = %s", id, q.Status)
+		}
+	}
+
+	// The working codex pane keeps its prompt until it goes idle, and the
+	// claude panes are cooling down.
+	states["%3"] = status.StateIdle
+	failPane = "%3"
+	deliveries, _ = d.DispatchOnce(context.Background(), "proj")
+	if len(deliveries) != 1 || deliveries[0].Error == "" {
+		t.Fatalf("deliveries = %+v, want one failed send", deliveries)
+	}
+	if q, _ := qs.Get(forCodex); q.Status != state.QueueStatusPending || q.Attempts != 1 {
+		t.Errorf("after failed send = %+v, want pending with one attempt", q)
+	}
+
+	failPane = ""
+	now = now.Add(DefaultCooldown)
+	if _, err := d.DispatchOnce(context.Background(), "proj"); err != nil {
+		t.Fatal(err)
+	}
+	if q, _ := qs.Get(forCodex); q.Status != state.QueueStatusDelivered {
+		t.Errorf("codex prompt status = %s", q.Status)
+	}
+
+	// An empty queue never lists panes.
+	before := panesCalls
+	if _, err := d.DispatchOnce(context.Background(), "proj"); err != nil {
+		t.Fatal(err)
+	}
+	if panesCalls != before {
+		t.Error("DispatchOnce listed panes with an empty queue")
+	}
+}
+
+func TestDispatchOnceSkipsClaimedPane(t *testing.T) {
+	qs := openQueue(t)
+	if _, _, err := qs.Enqueue(&state.QueuedPrompt{SessionName: "proj", AgentType: "cc", Prompt: "hi", Priority: state.DefaultQueuePriority}); err != nil {
+		t.Fatal(err)
+	}
+	var sent []string
+	d := New(Config{
+		Store: qs,
+		Send: func(_ string, p tmux.Pane, prompt string) error {
+			sent = append(sent, p.ID)
+			return nil
+		},
+		Panes: func(context.Context, string) ([]tmux.Pane, error) {
+			return []tmux.Pane{{ID: "%1", Index: 1, Type: tmux.AgentClaude}}, nil
+		},
+		States: func(context.Context, string) (map[string]status.AgentState, error) {
+			return map[string]status.AgentState{"%1": status.StateIdle}, nil
+		},
+	})
+
+	release, ok := ClaimPane("proj", "%1")
+	if !ok {
+		t.Fatal("ClaimPane failed on a free pane")
+	}
+	if _, ok := ClaimPane("proj", "%1"); ok {
+		t.Error("pane claimed twice")
+	}
+	if _, err := d.DispatchOnce(context.Background(), "proj"); err != nil || len(sent) != 0 {
+		t.Fatalf("sent %v to a claimed pane (err %v)", sent, err)
+	}
+	release()
+	if _, err := d.DispatchOnce(context.Background(), "proj"); err != nil || len(sent) != 1 {
+		t.Errorf("sent %v after release (err %v)", sent, err)
+	}
+}
+
+func TestDispatchOnceHoldLeavesPromptPending(t *testing.T) {
+	qs := openQueue(t)
+	q, _, err := qs.Enqueue(&state.QueuedPrompt{SessionName: "proj", AgentType: "cc", Prompt: "hi", Priority: state.DefaultQueuePriority})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sent []string
+	held := errors.New("over budget")
+	d := New(Config{
+		Store: qs,
+		Send: func(_ string, p tmux.Pane, prompt string) error {
+			sent = append(sent, p.ID)
+			return nil
+		},
+		Hold: func(string, tmux.Pane) error { return held },
+		Panes: func(context.Context, string) ([]tmux.Pane, error) {
+			return []tmux.Pane{{ID: "%1", Index: 1, Type: tmux.AgentClaude}}, nil
+		},
+		States: func(context.Context, string) (map[string]status.AgentState, error) {
+			return map[string]status.AgentState{"%1": status.StateIdle}, nil
+		},
+	})
+
+	for i := 0; i < DefaultMaxAttempts+1; i++ {
+		deliveries, err := d.DispatchOnce(context.Background(), "proj")
+		if err != nil || len(deliveries) != 0 {
+			t.Fatalf("deliveries = %+v (err %v) while held", deliveries, err)
+		}
+	}
+	if got, _ := qs.Get(q.ID); got.Status != state.QueueStatusPending || got.Attempts != 0 {
+		t.Errorf("held prompt = %+v, want pending with no attempts", got)
+	}
+
+	held = nil
+	if _, err := d.DispatchOnce(context.Background(), "proj"); err != nil || len(sent) != 1 {
+		t.Fatalf("sent %v after the hold lifted (err %v)", sent, err)
+	}
+}
+
+func TestDispatchOnceDetectsStatesOnceWhileClaimed(t *testing.T) {
+	qs := openQueue(t)
+	for _, prompt := range []string{"one", "two"} {
+		if _, _, err := qs.Enqueue(&state.QueuedPrompt{SessionName: "proj", AgentType: "cc", Prompt: prompt, Priority: state.DefaultQueuePriority}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var sent []string
+	scans := 0
+	d := New(Config{
+		Store: qs,
+		Send: func(_ string, p tmux.Pane, prompt string) error {
+			sent = append(sent, p.ID)
+			return nil
+		},
+		Panes: func(context.Context, string) ([]tmux.Pane, error) {
+			return []tmux.Pane{
+				{ID: "%1", Index: 1, Type: tmux.AgentClaude},
+				{ID: "%2", Index: 2, Type: tmux.AgentClaude},
+			}, nil
+		},
+		States: func(context.Context, string) (map[string]status.AgentState, error) {
+			scans++
+			// The panes are claimed while their state is read.
+			for _, id := range []string{"%1", "%2"} {
+				if release, ok := ClaimPane("proj", id); ok {
+					release()
+					t.Errorf("pane %s not claimed during detection", id)
+				}
+			}
+			return map[string]status.AgentState{"%1": status.StateIdle, "%2": status.StateIdle}, nil
+		},
+	})
+
+	deliveries, err := d.DispatchOnce(context.Background(), "proj")
+	if err != nil || len(deliveries) != 2 || len(sent) != 2 {
+		t.Fatalf("deliveries = %+v, sent = %v (err %v), want one prompt per idle pane", deliveries, sent, err)
+	}
+	if scans != 1 {
+		t.Errorf("states detected %d times, want once per dispatch", scans)
+	}
+	if release, ok := ClaimPane("proj", "%1"); !ok {
+		t.Error("pane still claimed after dispatch")
+	} else {
+		release()
+	}
+}
+
+func TestDispatchOnceReleasesStaleClaims(t *testing.T) {
+	qs := openQueue(t)
+	q, _, err := qs.Enqueue(&state.QueuedPrompt{SessionName: "proj", AgentType: "cc", Prompt: "hi", Priority: state.DefaultQueuePriority})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A dispatcher claimed the prompt and died before completing it.
+	if ok, err := qs.Claim(q.ID); !ok || err != nil {
+		t.Fatalf("Claim = %v, %v", ok, err)
+	}
+	var sent []string
+	d := New(Config{
+		Store: qs,
+		Send: func(_ string, p tmux.Pane, prompt string) error {
+			sent = append(sent, p.ID)
+			return nil
+		},
+		Panes: func(context.Context, string) ([]tmux.Pane, error) {
+			return []tmux.Pane{{ID: "%1", Index: 1, Type: tmux.AgentClaude}}, nil
+		},
+		States: func(context.Context, string) (map[string]status.AgentState, error) {
+			return map[string]status.AgentState{"%1": status.StateIdle}, nil
+		},
+		Now: func() time.Time { return time.Now().Add(DefaultClaimTimeout + time.Minute) },
+	})
+
+	if _, err := d.DispatchOnce(context.Background(), "proj"); err != nil || len(sent) != 1 {
+		t.Fatalf("sent %v (err %v), want the abandoned prompt redelivered", sent, err)
+	}
+	if got, _ := qs.Get(q.ID); got.Status != state.QueueStatusDelivered || got.Attempts != 2 {
+		t.Errorf("prompt = %+v, want delivered on its second attempt", got)
+	}
+}
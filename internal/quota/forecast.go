@@ -0,0 +1,274 @@
+// Package quota provides real-time quota tracking for AI providers.
+// forecast.go predicts when an account will reach its usage limit from quota
+// snapshots, send volume and past rate limit hits.
+package quota
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DefaultForecastConfig returns the default configuration for PredictLimit.
+func DefaultForecastConfig() ForecastConfig {
+	return ForecastConfig{
+		Window:      5 * time.Hour,    // Providers' rolling usage window
+		PaceWindow:  30 * time.Minute, // Recent send pace
+		MinSamples:  2,                // Quota samples for a quota-based forecast
+		RotateAhead: 30 * time.Minute, // At risk when the limit is this close
+	}
+}
+
+// ForecastConfigFor returns the default configuration with the window and
+// pre-emption lead set under [rotation.forecast] applied where positive.
+func ForecastConfigFor(windowHours, preemptMinutes int) ForecastConfig {
+	fc := DefaultForecastConfig()
+	if windowHours > 0 {
+		fc.Window = time.Duration(windowHours) * time.Hour
+	}
+	if preemptMinutes > 0 {
+		fc.RotateAhead = time.Duration(preemptMinutes) * time.Minute
+	}
+	return fc
+}
+
+// ForecastConfig configures PredictLimit.
+type ForecastConfig struct {
+	Window      time.Duration // History the forecast is fitted over
+	PaceWindow  time.Duration // Window the current send pace is measured over
+	MinSamples  int           // Minimum quota samples for a quota-based forecast
+	RotateAhead time.Duration // A forecast is at risk when the limit is closer than this
+}
+
+// UsageSample is one quota snapshot of an account.
+type UsageSample struct {
+	At      time.Time
+	Usage   float64   // Highest usage percentage (0-100)
+	ResetAt time.Time // When the usage period resets, if known
+	Limited bool
+}
+
+// History is what is known about an account's recent usage.
+type History struct {
+	Provider Provider
+	Account  string
+	Samples  []UsageSample // Quota snapshots
+	Sends    []time.Time   // Prompts sent on the account
+	Limits   []time.Time   // Rate limit hits on the account
+}
+
+// Forecast bases
+const (
+	BasisQuota   = "quota"   // Fitted to quota snapshots
+	BasisHistory = "history" // Sends so far against the sends that last hit the limit
+)
+
+// Forecast is the predicted time until an account reaches its usage limit.
+type Forecast struct {
+	Provider     Provider      `json:"provider"`
+	Account      string        `json:"account"`
+	Usage        float64       `json:"usage"`                    // Latest or estimated usage percentage
+	UsagePerHour float64       `json:"usage_per_hour,omitempty"` // Percentage points per hour at the current pace
+	SendsPerHour float64       `json:"sends_per_hour"`
+	Basis        string        `json:"basis,omitempty"` // quota, history; empty without enough data
+	TimeToLimit  time.Duration `json:"time_to_limit,omitempty"`
+	LimitAt      time.Time     `json:"limit_at,omitempty"`
+	ResetAt      time.Time     `json:"reset_at,omitempty"`
+	ResetsFirst  bool          `json:"resets_first,omitempty"` // The period resets before the limit is reached
+	Limited      bool          `json:"limited,omitempty"`
+	AtRisk       bool          `json:"at_risk,omitempty"` // Limit expected within RotateAhead
+	Samples      int           `json:"samples"`
+	RecentLimits int           `json:"recent_limits,omitempty"`
+}
+
+// Known reports whether the forecast predicts a limit time.
+func (f *Forecast) Known() bool {
+	return f.Basis != "" && !f.ResetsFirst
+}
+
+// Summary returns a short description, e.g. "limit in 42m".
+func (f *Forecast) Summary() string {
+	switch {
+	case f.Limited:
+		return "limited"
+	case f.Basis == "":
+		return "no forecast"
+	case f.ResetsFirst:
+		return "resets first"
+	case f.TimeToLimit <= 0:
+		return "limit now"
+	}
+	return "limit in " + formatForecastDuration(f.TimeToLimit)
+}
+
+func formatForecastDuration(d time.Duration) string {
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// PredictLimit forecasts when h's account reaches its limit at the current
+// send pace. Quota snapshots since the last reset give the usage and how
+// much each send costs; without them, the sends that preceded the most
+// recent limit hit are taken as the account's capacity.
+func PredictLimit(h History, cfg ForecastConfig, now time.Time) Forecast {
+	if cfg.Window <= 0 {
+		cfg.Window = 5 * time.Hour
+	}
+	if cfg.PaceWindow <= 0 {
+		cfg.PaceWindow = 30 * time.Minute
+	}
+	if cfg.MinSamples < 2 {
+		cfg.MinSamples = 2
+	}
+
+	f := Forecast{Provider: h.Provider, Account: h.Account}
+	since := now.Add(-cfg.Window)
+	sends := sortedTimes(h.Sends)
+	f.SendsPerHour = float64(countBetween(sends, now.Add(-cfg.PaceWindow), now)) / cfg.PaceWindow.Hours()
+	for _, l := range h.Limits {
+		if l.After(since) && !l.After(now) {
+			f.RecentLimits++
+		}
+	}
+
+	seg := currentPeriod(h.Samples, since, now)
+	f.Samples = len(seg)
+	if len(seg) > 0 {
+		latest := seg[len(seg)-1]
+		f.Usage = latest.Usage
+		if latest.ResetAt.After(now) {
+			f.ResetAt = latest.ResetAt
+		}
+		f.Limited = latest.Limited
+	}
+	if f.Limited {
+		return f
+	}
+
+	var ttl time.Duration
+	switch {
+	case len(seg) >= cfg.MinSamples && seg[len(seg)-1].At.Sub(seg[0].At) >= time.Minute:
+		first, last := seg[0], seg[len(seg)-1]
+		du := last.Usage - first.Usage
+		if du <= 0 {
+			break
+		}
+		if n := countBetween(sends, first.At, last.At); n > 0 && f.SendsPerHour > 0 {
+			f.UsagePerHour = du / float64(n) * f.SendsPerHour
+		} else {
+			f.UsagePerHour = du / last.At.Sub(first.At).Hours()
+		}
+		f.Basis = BasisQuota
+		ttl = time.Duration((100 - f.Usage) / f.UsagePerHour * float64(time.Hour))
+
+	case f.SendsPerHour > 0:
+		last, ok := lastBefore(h.Limits, now)
+		if !ok || last.Before(now.Add(-24*time.Hour)) {
+			break
+		}
+		capacity := countBetween(sends, last.Add(-cfg.Window), last)
+		if capacity == 0 {
+			break
+		}
+		used := countBetween(sends, since, now)
+		if len(seg) == 0 {
+			f.Usage = 100 * float64(used) / float64(capacity)
+		}
+		f.Basis = BasisHistory
+		ttl = time.Duration(float64(capacity-used) / f.SendsPerHour * float64(time.Hour))
+	}
+
+	if f.Basis == "" {
+		return f
+	}
+	if ttl < 0 {
+		ttl = 0
+	}
+	f.TimeToLimit = ttl
+	f.LimitAt = now.Add(ttl)
+	if !f.ResetAt.IsZero() && f.ResetAt.Before(f.LimitAt) {
+		f.ResetsFirst = true
+		return f
+	}
+	f.AtRisk = ttl <= cfg.RotateAhead
+	return f
+}
+
+// PickHeadroom returns the forecast of the account with the most headroom
+// other than current: not limited, not at risk, and with the lowest usage,
+// preferring the latest limit time on ties. It returns nil when no other
+// account qualifies.
+func PickHeadroom(forecasts []Forecast, current string) *Forecast {
+	var best *Forecast
+	for i := range forecasts {
+		f := &forecasts[i]
+		if f.Account == current || f.Limited || f.AtRisk {
+			continue
+		}
+		if best == nil || f.Usage < best.Usage ||
+			(f.Usage == best.Usage && laterLimit(f, best)) {
+			best = f
+		}
+	}
+	return best
+}
+
+// laterLimit reports whether a is expected to reach its limit after b; an
+// account without a forecast limit is treated as never reaching it.
+func laterLimit(a, b *Forecast) bool {
+	if !b.Known() {
+		return false
+	}
+	return !a.Known() || a.TimeToLimit > b.TimeToLimit
+}
+
+// currentPeriod returns the samples in [since, now] taken since the last
+// usage reset, oldest first. A reset shows as usage dropping or a sample
+// taken after the previous sample's reset time.
+func currentPeriod(samples []UsageSample, since, now time.Time) []UsageSample {
+	var in []UsageSample
+	for _, s := range samples {
+		if !s.At.Before(since) && !s.At.After(now) {
+			in = append(in, s)
+		}
+	}
+	sort.SliceStable(in, func(i, j int) bool { return in[i].At.Before(in[j].At) })
+
+	start := 0
+	for i := 1; i < len(in); i++ {
+		prev := in[i-1]
+		if in[i].Usage < prev.Usage-1 || (!prev.ResetAt.IsZero() && in[i].At.After(prev.ResetAt)) {
+			start = i
+		}
+	}
+	return in[start:]
+}
+
+func sortedTimes(ts []time.Time) []time.Time {
+	out := append([]time.Time(nil), ts...)
+	sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+	return out
+}
+
+// countBetween counts the times in (from, to].
+func countBetween(ts []time.Time, from, to time.Time) int {
+	n := 0
+	for _, t := range ts {
+		if t.After(from) && !t.After(to) {
+			n++
+		}
+	}
+	return n
+}
+
+func lastBefore(ts []time.Time, now time.Time) (time.Time, bool) {
+	var last time.Time
+	for _, t := range ts {
+		if !t.After(now) && t.After(last) {
+			last = t
+		}
+	}
+	return last, !last.IsZero()
+}
@@ -0,0 +1,117 @@
+package quota
+
+import (
+	"testing"
+	"time"
+)
+
+// everyMinutes returns times from start, every step minutes, up to end.
+func everyMinutes(start, end time.Time, step int) []time.Time {
+	var out []time.Time
+	for t := start; !t.After(end); t = t.Add(time.Duration(step) * time.Minute) {
+		out = append(out, t)
+	}
+	return out
+}
+
+func TestPredictLimit_Quota(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	cfg := DefaultForecastConfig()
+
+	// 40% -> 70% over the last hour on 30 sends: 1 point per send. At the
+	// current pace of one send every 2 minutes the remaining 30 points last
+	// an hour.
+	h := History{
+		Provider: ProviderClaude,
+		Account:  "main",
+		Samples: []UsageSample{
+			{At: now.Add(-3 * time.Hour), Usage: 90}, // previous period
+			{At: now.Add(-time.Hour), Usage: 40},
+			{At: now.Add(-30 * time.Minute), Usage: 55},
+			{At: now, Usage: 70},
+		},
+		Sends: everyMinutes(now.Add(-58*time.Minute), now, 2),
+	}
+	f := PredictLimit(h, cfg, now)
+	if f.Basis != BasisQuota || f.Samples != 3 || f.Usage != 70 {
+		t.Fatalf("forecast = %+v", f)
+	}
+	if f.TimeToLimit < 55*time.Minute || f.TimeToLimit > 65*time.Minute {
+		t.Errorf("TimeToLimit = %v, want about 1h", f.TimeToLimit)
+	}
+	if f.AtRisk {
+		t.Error("an hour out should not be at risk")
+	}
+
+	// Doubling the recent pace (45 sends over the samples, 60 an hour now)
+	// brings the limit to 45 minutes out, inside a 50 minute lead.
+	h.Sends = append(h.Sends, everyMinutes(now.Add(-29*time.Minute), now, 2)...)
+	cfg.RotateAhead = 50 * time.Minute
+	f = PredictLimit(h, cfg, now)
+	if !f.AtRisk || f.TimeToLimit != 45*time.Minute {
+		t.Errorf("faster pace: %+v", f)
+	}
+
+	// A reset before the limit makes it moot.
+	h.Samples[len(h.Samples)-1].ResetAt = now.Add(10 * time.Minute)
+	f = PredictLimit(h, cfg, now)
+	if !f.ResetsFirst || f.AtRisk || f.Known() || f.Summary() != "resets first" {
+		t.Errorf("reset first: %+v", f)
+	}
+}
+
+func TestPredictLimit_History(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	cfg := DefaultForecastConfig()
+
+	// The last limit hit came after 60 sends; 50 have been sent in the
+	// current window at 20 an hour, so 10 remain: 30 minutes.
+	limit := now.Add(-6 * time.Hour)
+	sends := everyMinutes(limit.Add(-5*time.Hour).Add(5*time.Minute), limit, 5)
+	sends = append(sends, everyMinutes(now.Add(-147*time.Minute), now, 3)...)
+	h := History{Provider: ProviderCodex, Account: "a", Sends: sends, Limits: []time.Time{limit}}
+
+	f := PredictLimit(h, cfg, now)
+	if f.Basis != BasisHistory {
+		t.Fatalf("forecast = %+v", f)
+	}
+	if f.TimeToLimit < 25*time.Minute || f.TimeToLimit > 35*time.Minute || !f.AtRisk {
+		t.Errorf("TimeToLimit = %v, at risk %v; want about 30m and at risk", f.TimeToLimit, f.AtRisk)
+	}
+	if f.Usage < 80 || f.Usage > 85 {
+		t.Errorf("estimated usage = %.1f", f.Usage)
+	}
+
+	// Without sends there is no pace and nothing to forecast.
+	f = PredictLimit(History{Limits: []time.Time{limit}}, cfg, now)
+	if f.Basis != "" || f.Summary() != "no forecast" {
+		t.Errorf("idle account: %+v", f)
+	}
+}
+
+func TestPredictLimit_Limited(t *testing.T) {
+	now := time.Now()
+	f := PredictLimit(History{Samples: []UsageSample{{At: now, Usage: 100, Limited: true}}}, DefaultForecastConfig(), now)
+	if !f.Limited || f.AtRisk || f.Summary() != "limited" {
+		t.Errorf("forecast = %+v", f)
+	}
+}
+
+func TestPickHeadroom(t *testing.T) {
+	forecasts := []Forecast{
+		{Account: "main", Usage: 90, Basis: BasisQuota, AtRisk: true},
+		{Account: "busy", Usage: 20, Limited: true},
+		{Account: "soon", Usage: 60, Basis: BasisQuota, TimeToLimit: time.Hour},
+		{Account: "late", Usage: 60, Basis: BasisQuota, TimeToLimit: 3 * time.Hour},
+		{Account: "fresh", Usage: 60},
+	}
+	if got := PickHeadroom(forecasts, "main"); got == nil || got.Account != "fresh" {
+		t.Errorf("PickHeadroom = %+v, want fresh", got)
+	}
+	if got := PickHeadroom(forecasts[:4], "main"); got == nil || got.Account != "late" {
+		t.Errorf("PickHeadroom = %+v, want late", got)
+	}
+	if got := PickHeadroom(forecasts[:2], "main"); got != nil {
+		t.Errorf("PickHeadroom = %+v, want none", got)
+	}
+}
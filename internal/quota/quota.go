@@ -17,6 +17,23 @@ const (
 	ProviderGemini Provider = "gemini"
 )
 
+// Providers lists the providers whose quota can be queried.
+var Providers = []Provider{ProviderClaude, ProviderCodex, ProviderGemini}
+
+// AgentType returns the ntm agent type that runs on the provider
+// ("cc", "cod", "gmi"), or "" for an unknown provider.
+func (p Provider) AgentType() string {
+	switch p {
+	case ProviderClaude:
+		return "cc"
+	case ProviderCodex:
+		return "cod"
+	case ProviderGemini:
+		return "gmi"
+	}
+	return ""
+}
+
 // QuotaInfo represents current quota state for an account
 type QuotaInfo struct {
 	Provider     Provider  `json:"provider"`
@@ -301,6 +301,20 @@ func (t *RateLimitTracker) GetRecentEvents(provider string, limit int) []RateLim
 	return result
 }
 
+// RecentLimitTimes returns when provider's agents in projectDir hit a rate
+// limit, as recorded in its .ntm directory.
+func RecentLimitTimes(projectDir, provider string) []time.Time {
+	tracker := NewRateLimitTracker(projectDir)
+	if err := tracker.LoadFromDir(projectDir); err != nil {
+		return nil
+	}
+	var out []time.Time
+	for _, ev := range tracker.GetRecentEvents(provider, 0) {
+		out = append(out, ev.Time)
+	}
+	return out
+}
+
 // Reset resets the state for a provider to defaults.
 func (t *RateLimitTracker) Reset(provider string) {
 	provider = NormalizeProvider(provider)
@@ -0,0 +1,252 @@
+// Package recording captures tmux pane output as asciicast v2 files and
+// replays it. A recording is a directory holding one .cast file per pane plus
+// a manifest that ties the panes to a shared clock and to timeline markers.
+package recording
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// AsciicastVersion is the asciicast format version written by this package.
+const AsciicastVersion = 2
+
+// Event codes defined by the asciicast v2 format.
+const (
+	// CodeOutput marks data written to the terminal.
+	CodeOutput = "o"
+	// CodeInput marks data read from the keyboard.
+	CodeInput = "i"
+	// CodeMarker marks a named point in time (breakpoint/chapter).
+	CodeMarker = "m"
+	// CodeResize marks a terminal resize ("COLSxROWS").
+	CodeResize = "r"
+)
+
+// Header is the first line of an asciicast v2 file.
+type Header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Event is a single asciicast v2 event line: [time, code, data].
+type Event struct {
+	Time float64 // Seconds since the start of the recording
+	Code string  // One of CodeOutput, CodeInput, CodeMarker, CodeResize
+	Data string
+}
+
+// Offset returns the event time as a duration.
+func (e Event) Offset() time.Duration {
+	return time.Duration(e.Time * float64(time.Second))
+}
+
+// MarshalJSON encodes the event as a three-element JSON array.
+func (e Event) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{e.Time, e.Code, e.Data})
+}
+
+// UnmarshalJSON decodes a three-element JSON array.
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if len(raw) != 3 {
+		return fmt.Errorf("asciicast event: expected 3 elements, got %d", len(raw))
+	}
+	if err := json.Unmarshal(raw[0], &e.Time); err != nil {
+		return fmt.Errorf("asciicast event time: %w", err)
+	}
+	if err := json.Unmarshal(raw[1], &e.Code); err != nil {
+		return fmt.Errorf("asciicast event code: %w", err)
+	}
+	if err := json.Unmarshal(raw[2], &e.Data); err != nil {
+		return fmt.Errorf("asciicast event data: %w", err)
+	}
+	return nil
+}
+
+// Cast is a fully decoded asciicast file.
+type Cast struct {
+	Header Header
+	Events []Event
+}
+
+// Duration returns the time of the last event.
+func (c *Cast) Duration() time.Duration {
+	if len(c.Events) == 0 {
+		return 0
+	}
+	return c.Events[len(c.Events)-1].Offset()
+}
+
+// CastWriter appends events to an asciicast v2 stream. It is safe for
+// concurrent use.
+type CastWriter struct {
+	mu      sync.Mutex
+	w       *bufio.Writer
+	closer  io.Closer
+	started time.Time
+	lastT   float64
+	events  int
+}
+
+// NewCastWriter writes the header to w and returns a writer whose event
+// times are measured from started. If w is an io.Closer it is closed by Close.
+func NewCastWriter(w io.Writer, header Header, started time.Time) (*CastWriter, error) {
+	if header.Version == 0 {
+		header.Version = AsciicastVersion
+	}
+	if header.Timestamp == 0 {
+		header.Timestamp = started.Unix()
+	}
+
+	cw := &CastWriter{
+		w:       bufio.NewWriter(w),
+		started: started,
+	}
+	if c, ok := w.(io.Closer); ok {
+		cw.closer = c
+	}
+
+	data, err := json.Marshal(header)
+	if err != nil {
+		return nil, fmt.Errorf("encode header: %w", err)
+	}
+	if _, err := cw.w.Write(append(data, '\n')); err != nil {
+		return nil, fmt.Errorf("write header: %w", err)
+	}
+	return cw, nil
+}
+
+// CreateCastFile creates path and writes the header.
+func CreateCastFile(path string, header Header, started time.Time) (*CastWriter, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("create cast file: %w", err)
+	}
+	cw, err := NewCastWriter(f, header, started)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return cw, nil
+}
+
+// Write records data with the given code at wall-clock time at. Times are
+// clamped so the stream stays monotonic even if callers deliver events
+// slightly out of order.
+func (cw *CastWriter) Write(at time.Time, code, data string) error {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+
+	t := at.Sub(cw.started).Seconds()
+	if t < cw.lastT {
+		t = cw.lastT
+	}
+	cw.lastT = t
+
+	line, err := json.Marshal(Event{Time: roundMicros(t), Code: code, Data: data})
+	if err != nil {
+		return err
+	}
+	if _, err := cw.w.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	cw.events++
+	return nil
+}
+
+// Events returns the number of events written so far.
+func (cw *CastWriter) Events() int {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+	return cw.events
+}
+
+// Flush writes buffered events to the underlying writer.
+func (cw *CastWriter) Flush() error {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+	return cw.w.Flush()
+}
+
+// Close flushes and closes the underlying writer.
+func (cw *CastWriter) Close() error {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+	err := cw.w.Flush()
+	if cw.closer != nil {
+		if cerr := cw.closer.Close(); err == nil {
+			err = cerr
+		}
+		cw.closer = nil
+	}
+	return err
+}
+
+// ReadCast decodes an asciicast v2 stream. A truncated final line (from a
+// recorder that was killed mid-write) is ignored.
+func ReadCast(r io.Reader) (*Cast, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("read header: %w", err)
+		}
+		return nil, fmt.Errorf("empty asciicast stream")
+	}
+
+	cast := &Cast{}
+	if err := json.Unmarshal(scanner.Bytes(), &cast.Header); err != nil {
+		return nil, fmt.Errorf("decode header: %w", err)
+	}
+	if cast.Header.Version != AsciicastVersion {
+		return nil, fmt.Errorf("unsupported asciicast version %d", cast.Header.Version)
+	}
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var ev Event
+		if err := json.Unmarshal(line, &ev); err != nil {
+			// Only tolerate a broken trailing line
+			if scanner.Scan() {
+				return nil, fmt.Errorf("decode event %d: %w", len(cast.Events)+1, err)
+			}
+			break
+		}
+		cast.Events = append(cast.Events, ev)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read events: %w", err)
+	}
+	return cast, nil
+}
+
+// LoadCastFile reads and decodes the asciicast file at path.
+func LoadCastFile(path string) (*Cast, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadCast(f)
+}
+
+func roundMicros(t float64) float64 {
+	return float64(int64(t*1e6+0.5)) / 1e6
+}
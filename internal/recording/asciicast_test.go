@@ -0,0 +1,147 @@
+package recording
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCastWriterRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	start := time.Unix(1700000000, 0)
+
+	cw, err := NewCastWriter(&buf, Header{Width: 120, Height: 40, Title: "demo"}, start)
+	if err != nil {
+		t.Fatalf("NewCastWriter() error: %v", err)
+	}
+	if err := cw.Write(start.Add(500*time.Millisecond), CodeOutput, "hello\r\n"); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	if err := cw.Write(start.Add(1500*time.Millisecond), CodeMarker, "checkpoint"); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	if err := cw.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	firstLine := strings.SplitN(buf.String(), "\n", 2)[0]
+	if !strings.Contains(firstLine, `"version":2`) {
+		t.Errorf("header line = %s, want version 2", firstLine)
+	}
+	if !strings.Contains(buf.String(), `[0.5,"o","hello\r\n"]`) {
+		t.Errorf("output event not encoded as array: %s", buf.String())
+	}
+
+	cast, err := ReadCast(&buf)
+	if err != nil {
+		t.Fatalf("ReadCast() error: %v", err)
+	}
+	if cast.Header.Width != 120 || cast.Header.Height != 40 {
+		t.Errorf("size = %dx%d, want 120x40", cast.Header.Width, cast.Header.Height)
+	}
+	if cast.Header.Timestamp != start.Unix() {
+		t.Errorf("timestamp = %d, want %d", cast.Header.Timestamp, start.Unix())
+	}
+	if len(cast.Events) != 2 {
+		t.Fatalf("events = %d, want 2", len(cast.Events))
+	}
+	if cast.Events[1].Code != CodeMarker || cast.Events[1].Data != "checkpoint" {
+		t.Errorf("marker event = %+v", cast.Events[1])
+	}
+	if got := cast.Duration(); got != 1500*time.Millisecond {
+		t.Errorf("Duration() = %v, want 1.5s", got)
+	}
+}
+
+func TestCastWriterMonotonic(t *testing.T) {
+	var buf bytes.Buffer
+	start := time.Now()
+	cw, err := NewCastWriter(&buf, Header{Width: 80, Height: 24}, start)
+	if err != nil {
+		t.Fatalf("NewCastWriter() error: %v", err)
+	}
+	_ = cw.Write(start.Add(2*time.Second), CodeOutput, "a")
+	_ = cw.Write(start.Add(1*time.Second), CodeOutput, "b")
+	_ = cw.Flush()
+
+	cast, err := ReadCast(&buf)
+	if err != nil {
+		t.Fatalf("ReadCast() error: %v", err)
+	}
+	if cast.Events[1].Time < cast.Events[0].Time {
+		t.Errorf("event times not monotonic: %v then %v", cast.Events[0].Time, cast.Events[1].Time)
+	}
+}
+
+func TestReadCastErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"empty", ""},
+		{"bad header", "not json\n"},
+		{"wrong version", `{"version":1,"width":80,"height":24}` + "\n"},
+		{"corrupt middle line", `{"version":2,"width":80,"height":24}` + "\n" + "garbage\n" + `[1,"o","x"]` + "\n"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ReadCast(strings.NewReader(tc.input)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestReadCastTruncatedTail(t *testing.T) {
+	input := `{"version":2,"width":80,"height":24}` + "\n" + `[0.1,"o","ok"]` + "\n" + `[0.2,"o","tru`
+	cast, err := ReadCast(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadCast() error: %v", err)
+	}
+	if len(cast.Events) != 1 {
+		t.Errorf("events = %d, want 1", len(cast.Events))
+	}
+}
+
+func TestScreenSeek(t *testing.T) {
+	cast := &Cast{
+		Header: Header{Version: 2, Width: 80, Height: 24},
+		Events: []Event{
+			{Time: 1, Code: CodeOutput, Data: "one\r\ntwo\r\n"},
+			{Time: 2, Code: CodeOutput, Data: "\x1b[31mthree\x1b[0m\r\npart"},
+			{Time: 3, Code: CodeOutput, Data: "ial\r\n"},
+			{Time: 4, Code: CodeOutput, Data: clearScreen + "fresh\r\n"},
+		},
+	}
+	s := NewScreen(cast)
+
+	s.Seek(500 * time.Millisecond)
+	if got := s.Tail(10); len(got) != 0 {
+		t.Errorf("Tail at 0.5s = %q, want empty", got)
+	}
+
+	s.Seek(2 * time.Second)
+	if got := strings.Join(s.Tail(10), "|"); got != "one|two|three|part" {
+		t.Errorf("Tail at 2s = %q", got)
+	}
+
+	s.Seek(3 * time.Second)
+	if got := strings.Join(s.Tail(2), "|"); got != "three|partial" {
+		t.Errorf("Tail at 3s = %q", got)
+	}
+
+	s.Seek(5 * time.Second)
+	if got := strings.Join(s.Tail(10), "|"); got != "fresh" {
+		t.Errorf("Tail after clear = %q", got)
+	}
+	if s.LastOutput() != 4*time.Second {
+		t.Errorf("LastOutput() = %v, want 4s", s.LastOutput())
+	}
+
+	// Seeking backward replays from the start.
+	s.Seek(1 * time.Second)
+	if got := strings.Join(s.Tail(10), "|"); got != "one|two" {
+		t.Errorf("Tail after backward seek = %q", got)
+	}
+}
@@ -0,0 +1,186 @@
+package recording
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	ntmctx "github.com/shahbajlive/ntm/internal/context"
+	"github.com/shahbajlive/ntm/internal/events"
+	"github.com/shahbajlive/ntm/internal/state"
+)
+
+// MarkerSource produces timeline markers for a session within [since, until].
+type MarkerSource func(session string, since, until time.Time) ([]state.TimelineMarker, error)
+
+// DefaultMarkerSources returns the persisted sources ntm writes to during a
+// session: the analytics event log (sends, checkpoints, errors), rotation
+// history, and the persisted agent state timeline.
+func DefaultMarkerSources() []MarkerSource {
+	return []MarkerSource{
+		EventLogMarkers(events.DefaultLogger()),
+		RotationMarkers(ntmctx.DefaultRotationHistoryStore),
+		TimelineErrorMarkers(nil),
+	}
+}
+
+// CollectMarkers merges markers from all sources, sorted by time. Sources
+// that fail are skipped; recordings should still play without annotations.
+func CollectMarkers(session string, since, until time.Time, sources ...MarkerSource) []state.TimelineMarker {
+	var all []state.TimelineMarker
+	for _, src := range sources {
+		if src == nil {
+			continue
+		}
+		markers, err := src(session, since, until)
+		if err != nil {
+			continue
+		}
+		for _, m := range markers {
+			if m.Timestamp.Before(since) || (!until.IsZero() && m.Timestamp.After(until)) {
+				continue
+			}
+			all = append(all, m)
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].Timestamp.Before(all[j].Timestamp)
+	})
+	return all
+}
+
+// eventMarkerTypes maps analytics event types to timeline marker types.
+var eventMarkerTypes = map[events.EventType]state.MarkerType{
+	events.EventPromptSend:        state.MarkerPrompt,
+	events.EventPromptBroadcast:   state.MarkerPrompt,
+	events.EventCheckpointCreate:  state.MarkerCheckpoint,
+	events.EventCheckpointRestore: state.MarkerCheckpoint,
+	events.EventError:             state.MarkerError,
+	events.EventAgentCrash:        state.MarkerError,
+	events.EventAgentRestart:      state.MarkerStart,
+	events.EventSessionKill:       state.MarkerStop,
+}
+
+// EventLogMarkers reads markers from the analytics event log.
+func EventLogMarkers(logger *events.Logger) MarkerSource {
+	return func(session string, since, until time.Time) ([]state.TimelineMarker, error) {
+		if logger == nil {
+			return nil, nil
+		}
+		ch, err := logger.ReplaySession(session, since)
+		if err != nil {
+			return nil, err
+		}
+
+		var markers []state.TimelineMarker
+		for ev := range ch {
+			mt, ok := eventMarkerTypes[ev.Type]
+			if !ok {
+				continue
+			}
+			markers = append(markers, state.TimelineMarker{
+				ID:        fmt.Sprintf("evt-%s-%d", ev.Type, ev.Timestamp.UnixNano()),
+				AgentID:   ev.AgentName,
+				SessionID: session,
+				Type:      mt,
+				Timestamp: ev.Timestamp,
+				Message:   eventMessage(ev),
+			})
+		}
+		return markers, nil
+	}
+}
+
+func eventMessage(ev *events.Event) string {
+	switch ev.Type {
+	case events.EventPromptSend, events.EventPromptBroadcast:
+		if n, ok := ev.Data["target_count"]; ok {
+			return fmt.Sprintf("prompt sent to %v pane(s)", n)
+		}
+		return "prompt sent"
+	case events.EventCheckpointCreate:
+		if id, ok := ev.Data["checkpoint_id"]; ok {
+			return fmt.Sprintf("checkpoint %v", id)
+		}
+		return "checkpoint created"
+	case events.EventCheckpointRestore:
+		return "checkpoint restored"
+	case events.EventError, events.EventAgentCrash:
+		if msg, ok := ev.Data["message"]; ok {
+			return fmt.Sprintf("%v", msg)
+		}
+		return string(ev.Type)
+	default:
+		return string(ev.Type)
+	}
+}
+
+// RotationMarkers reads context rotations from the rotation history store.
+func RotationMarkers(store *ntmctx.RotationHistoryStore) MarkerSource {
+	return func(session string, since, until time.Time) ([]state.TimelineMarker, error) {
+		if store == nil {
+			return nil, nil
+		}
+		records, err := store.ReadForSession(session)
+		if err != nil {
+			return nil, err
+		}
+
+		markers := make([]state.TimelineMarker, 0, len(records))
+		for _, r := range records {
+			msg := fmt.Sprintf("rotated at %.0f%% context", r.ContextBefore)
+			mt := state.MarkerRotation
+			if !r.Success {
+				msg = "rotation failed: " + r.FailureReason
+				mt = state.MarkerError
+			}
+			markers = append(markers, state.TimelineMarker{
+				ID:        "rot-" + r.ID,
+				AgentID:   r.AgentID,
+				SessionID: session,
+				Type:      mt,
+				Timestamp: r.Timestamp,
+				Message:   msg,
+			})
+		}
+		return markers, nil
+	}
+}
+
+// TimelineErrorMarkers turns persisted agent transitions into the error state
+// into error markers. A nil persister uses the default one.
+func TimelineErrorMarkers(persister *state.TimelinePersister) MarkerSource {
+	return func(session string, since, until time.Time) ([]state.TimelineMarker, error) {
+		p := persister
+		if p == nil {
+			var err error
+			if p, err = state.GetDefaultTimelinePersister(); err != nil {
+				return nil, err
+			}
+		}
+		agentEvents, err := p.LoadTimeline(session)
+		if err != nil {
+			return nil, err
+		}
+
+		var markers []state.TimelineMarker
+		for _, ev := range agentEvents {
+			if ev.State != state.TimelineError {
+				continue
+			}
+			msg := ev.Trigger
+			if msg == "" {
+				msg = "agent entered error state"
+			}
+			markers = append(markers, state.TimelineMarker{
+				ID:        fmt.Sprintf("tl-%s-%d", ev.AgentID, ev.Timestamp.UnixNano()),
+				AgentID:   ev.AgentID,
+				SessionID: session,
+				Type:      state.MarkerError,
+				Timestamp: ev.Timestamp,
+				Message:   msg,
+			})
+		}
+		return markers, nil
+	}
+}
@@ -0,0 +1,456 @@
+package recording
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/state"
+	"github.com/shahbajlive/ntm/internal/tmux"
+	"github.com/shahbajlive/ntm/internal/util"
+)
+
+const (
+	// DefaultOutputDir is where recordings are stored.
+	DefaultOutputDir = "~/.ntm/recordings"
+
+	// ManifestFile is the name of the manifest inside a recording directory.
+	ManifestFile = "manifest.json"
+
+	// ManifestVersion is the current manifest schema version.
+	ManifestVersion = 1
+)
+
+// PaneTrack describes one pane's cast file within a recording.
+type PaneTrack struct {
+	Index     int    `json:"index"`
+	PaneID    string `json:"pane_id"`
+	Title     string `json:"title"`
+	AgentType string `json:"agent_type"`
+	File      string `json:"file"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Events    int    `json:"events"`
+}
+
+// Manifest ties the per-pane casts of a recording to a shared clock.
+type Manifest struct {
+	Version   int                    `json:"version"`
+	Session   string                 `json:"session"`
+	StartedAt time.Time              `json:"started_at"`
+	EndedAt   time.Time              `json:"ended_at,omitempty"`
+	Panes     []PaneTrack            `json:"panes"`
+	Markers   []state.TimelineMarker `json:"markers,omitempty"`
+}
+
+// Duration returns the wall-clock length of the recording.
+func (m *Manifest) Duration() time.Duration {
+	if m.EndedAt.IsZero() {
+		return 0
+	}
+	return m.EndedAt.Sub(m.StartedAt)
+}
+
+// RecorderOptions configures a Recorder.
+type RecorderOptions struct {
+	Session   string
+	OutputDir string // Parent directory; a per-recording subdirectory is created
+	Client    *tmux.Client
+	Stream    tmux.PaneStreamerConfig
+
+	// MarkerSources are queried on Stop to annotate the recording.
+	// Nil uses DefaultMarkerSources.
+	MarkerSources []MarkerSource
+}
+
+// Recorder writes every pane of a session to asciicast files.
+type Recorder struct {
+	opts    RecorderOptions
+	dir     string
+	started time.Time
+
+	mu       sync.Mutex
+	tracks   map[string]*paneRecorder // Keyed by stream target (pane ID)
+	markers  []state.TimelineMarker
+	manager  *tmux.StreamManager
+	stopped  bool
+	manifest *Manifest
+}
+
+type paneRecorder struct {
+	track  PaneTrack
+	writer *CastWriter
+}
+
+// NewRecorder creates the recording directory. Call Start to begin capture.
+func NewRecorder(opts RecorderOptions) (*Recorder, error) {
+	if opts.Session == "" {
+		return nil, fmt.Errorf("session name required")
+	}
+	if opts.OutputDir == "" {
+		opts.OutputDir = util.ExpandPath(DefaultOutputDir)
+	}
+	if opts.Client == nil {
+		opts.Client = tmux.DefaultClient
+	}
+	if opts.MarkerSources == nil {
+		opts.MarkerSources = DefaultMarkerSources()
+	}
+
+	started := time.Now()
+	dir := filepath.Join(opts.OutputDir, fmt.Sprintf("%s_%s", opts.Session, started.Format("20060102-150405")))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("creating recording directory: %w", err)
+	}
+
+	return &Recorder{
+		opts:    opts,
+		dir:     dir,
+		started: started,
+		tracks:  make(map[string]*paneRecorder),
+	}, nil
+}
+
+// Dir returns the recording directory.
+func (r *Recorder) Dir() string {
+	return r.dir
+}
+
+// StartedAt returns the shared zero time of all pane casts.
+func (r *Recorder) StartedAt() time.Time {
+	return r.started
+}
+
+// Start opens a cast file per pane and attaches pane streamers.
+func (r *Recorder) Start(panes []tmux.Pane) error {
+	if len(panes) == 0 {
+		return fmt.Errorf("no panes to record")
+	}
+	for _, p := range panes {
+		if err := r.addPane(p); err != nil {
+			r.closeWriters()
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	r.manager = tmux.NewStreamManager(r.opts.Client, r.HandleStreamEvent, r.opts.Stream)
+	manager := r.manager
+	r.mu.Unlock()
+
+	for _, p := range panes {
+		if err := manager.StartStream(p.ID); err != nil {
+			manager.StopAll()
+			r.closeWriters()
+			return fmt.Errorf("stream pane %d: %w", p.Index, err)
+		}
+	}
+	return nil
+}
+
+func (r *Recorder) addPane(p tmux.Pane) error {
+	file := fmt.Sprintf("pane_%02d.cast", p.Index)
+	header := Header{
+		Width:  p.Width,
+		Height: p.Height,
+		Title:  fmt.Sprintf("%s / %s", r.opts.Session, p.Title),
+		Env:    map[string]string{"TERM": "xterm-256color"},
+	}
+	if header.Width <= 0 {
+		header.Width = 80
+	}
+	if header.Height <= 0 {
+		header.Height = 24
+	}
+
+	writer, err := CreateCastFile(filepath.Join(r.dir, file), header, r.started)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tracks[p.ID] = &paneRecorder{
+		track: PaneTrack{
+			Index:     p.Index,
+			PaneID:    p.ID,
+			Title:     p.Title,
+			AgentType: string(p.Type),
+			File:      file,
+			Width:     header.Width,
+			Height:    header.Height,
+		},
+		writer: writer,
+	}
+	return nil
+}
+
+// HandleStreamEvent writes a pane streamer event to the matching cast.
+func (r *Recorder) HandleStreamEvent(ev tmux.StreamEvent) {
+	r.mu.Lock()
+	pr, ok := r.tracks[ev.Target]
+	stopped := r.stopped
+	r.mu.Unlock()
+	if !ok || stopped {
+		return
+	}
+
+	data := strings.Join(ev.Lines, "\r\n") + "\r\n"
+	if ev.IsFull {
+		data = clearScreen + data
+	}
+	_ = pr.writer.Write(ev.Timestamp, CodeOutput, data)
+}
+
+// AddMarker annotates the recording with an in-process timeline marker.
+func (r *Recorder) AddMarker(m state.TimelineMarker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped {
+		return
+	}
+	if m.Timestamp.IsZero() {
+		m.Timestamp = time.Now()
+	}
+	r.markers = append(r.markers, m)
+}
+
+// Stop detaches streamers, closes cast files, collects markers and writes
+// the manifest. It is safe to call more than once.
+func (r *Recorder) Stop() (*Manifest, error) {
+	r.mu.Lock()
+	if r.stopped {
+		m := r.manifest
+		r.mu.Unlock()
+		return m, nil
+	}
+	manager := r.manager
+	r.mu.Unlock()
+
+	if manager != nil {
+		manager.StopAll()
+	}
+
+	r.mu.Lock()
+	r.stopped = true
+	r.mu.Unlock()
+
+	ended := time.Now()
+	closeErr := r.closeWriters()
+
+	r.mu.Lock()
+	manifest := &Manifest{
+		Version:   ManifestVersion,
+		Session:   r.opts.Session,
+		StartedAt: r.started,
+		EndedAt:   ended,
+	}
+	for _, pr := range r.tracks {
+		track := pr.track
+		track.Events = pr.writer.Events()
+		manifest.Panes = append(manifest.Panes, track)
+	}
+	inProcess := append([]state.TimelineMarker(nil), r.markers...)
+	r.mu.Unlock()
+
+	sort.Slice(manifest.Panes, func(i, j int) bool {
+		return manifest.Panes[i].Index < manifest.Panes[j].Index
+	})
+
+	collected := CollectMarkers(r.opts.Session, r.started, ended, r.opts.MarkerSources...)
+	manifest.Markers = mergeMarkers(inProcess, collected)
+
+	if err := WriteManifest(r.dir, manifest); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.manifest = manifest
+	r.mu.Unlock()
+
+	if closeErr != nil {
+		return manifest, fmt.Errorf("closing cast files: %w", closeErr)
+	}
+	return manifest, nil
+}
+
+func (r *Recorder) closeWriters() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var firstErr error
+	for _, pr := range r.tracks {
+		if err := pr.writer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// mergeMarkers combines marker lists, dropping duplicate IDs, sorted by time.
+func mergeMarkers(lists ...[]state.TimelineMarker) []state.TimelineMarker {
+	seen := make(map[string]bool)
+	var out []state.TimelineMarker
+	for _, list := range lists {
+		for _, m := range list {
+			if m.ID != "" {
+				if seen[m.ID] {
+					continue
+				}
+				seen[m.ID] = true
+			}
+			out = append(out, m)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Timestamp.Before(out[j].Timestamp)
+	})
+	return out
+}
+
+// WriteManifest writes manifest.json into dir.
+func WriteManifest(dir string, m *Manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode manifest: %w", err)
+	}
+	path := filepath.Join(dir, ManifestFile)
+	if err := util.AtomicWriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	return nil
+}
+
+// Recording is a loaded recording directory.
+type Recording struct {
+	Dir      string
+	Manifest Manifest
+	Casts    []*Cast // Parallel to Manifest.Panes
+}
+
+// Load reads the manifest and all pane casts in dir.
+func Load(dir string) (*Recording, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	rec := &Recording{Dir: dir}
+	if err := json.Unmarshal(data, &rec.Manifest); err != nil {
+		return nil, fmt.Errorf("decode manifest: %w", err)
+	}
+
+	for _, track := range rec.Manifest.Panes {
+		cast, err := LoadCastFile(filepath.Join(dir, track.File))
+		if err != nil {
+			return nil, fmt.Errorf("load %s: %w", track.File, err)
+		}
+		rec.Casts = append(rec.Casts, cast)
+	}
+	return rec, nil
+}
+
+// Duration returns the playable length: the manifest span, or the longest
+// cast if the recorder did not exit cleanly.
+func (rec *Recording) Duration() time.Duration {
+	d := rec.Manifest.Duration()
+	for _, c := range rec.Casts {
+		if cd := c.Duration(); cd > d {
+			d = cd
+		}
+	}
+	return d
+}
+
+// MarkerOffset returns a marker's position relative to the recording start.
+func (rec *Recording) MarkerOffset(m state.TimelineMarker) time.Duration {
+	return m.Timestamp.Sub(rec.Manifest.StartedAt)
+}
+
+// Info summarizes a recording for listings.
+type Info struct {
+	Name      string        `json:"name"`
+	Dir       string        `json:"dir"`
+	Session   string        `json:"session"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+	Panes     int           `json:"panes"`
+	Markers   int           `json:"markers"`
+}
+
+// List returns recordings under baseDir, newest first.
+func List(baseDir string) ([]Info, error) {
+	if baseDir == "" {
+		baseDir = util.ExpandPath(DefaultOutputDir)
+	}
+	entries, err := os.ReadDir(baseDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var infos []Info
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		dir := filepath.Join(baseDir, e.Name())
+		data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+		if err != nil {
+			continue
+		}
+		var m Manifest
+		if err := json.Unmarshal(data, &m); err != nil {
+			continue
+		}
+		infos = append(infos, Info{
+			Name:      e.Name(),
+			Dir:       dir,
+			Session:   m.Session,
+			StartedAt: m.StartedAt,
+			Duration:  m.Duration(),
+			Panes:     len(m.Panes),
+			Markers:   len(m.Markers),
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].StartedAt.After(infos[j].StartedAt)
+	})
+	return infos, nil
+}
+
+// Resolve finds a recording by directory path, directory name, or session
+// name (latest recording of that session wins).
+func Resolve(baseDir, ref string) (string, error) {
+	if ref != "" {
+		if _, err := os.Stat(filepath.Join(ref, ManifestFile)); err == nil {
+			return ref, nil
+		}
+	}
+	infos, err := List(baseDir)
+	if err != nil {
+		return "", err
+	}
+	if len(infos) == 0 {
+		return "", fmt.Errorf("no recordings found")
+	}
+	if ref == "" {
+		return infos[0].Dir, nil
+	}
+	for _, info := range infos {
+		if info.Name == ref {
+			return info.Dir, nil
+		}
+	}
+	for _, info := range infos {
+		if info.Session == ref {
+			return info.Dir, nil
+		}
+	}
+	return "", fmt.Errorf("recording %q not found", ref)
+}
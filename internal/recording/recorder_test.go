@@ -0,0 +1,155 @@
+package recording
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/state"
+	"github.com/shahbajlive/ntm/internal/tmux"
+)
+
+func TestRecorderWritesCastsAndManifest(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	var sourceCalls int
+	source := func(session string, since, until time.Time) ([]state.TimelineMarker, error) {
+		sourceCalls++
+		return []state.TimelineMarker{
+			{ID: "ckpt", SessionID: session, Type: state.MarkerCheckpoint, Timestamp: since},
+			{ID: "too-late", SessionID: session, Type: state.MarkerError, Timestamp: until.Add(time.Hour)},
+		}, nil
+	}
+
+	r, err := NewRecorder(RecorderOptions{
+		Session:       "proj",
+		OutputDir:     tmpDir,
+		MarkerSources: []MarkerSource{source},
+	})
+	if err != nil {
+		t.Fatalf("NewRecorder() error: %v", err)
+	}
+
+	// Exercise the write path without tmux by registering panes directly.
+	for _, p := range []tmux.Pane{
+		{ID: "%1", Index: 1, Title: "proj__cc_1", Type: tmux.AgentClaude, Width: 100, Height: 30},
+		{ID: "%2", Index: 2, Title: "proj__cod_1", Type: tmux.AgentCodex},
+	} {
+		if err := r.addPane(p); err != nil {
+			t.Fatalf("addPane() error: %v", err)
+		}
+	}
+
+	now := time.Now()
+	r.HandleStreamEvent(tmux.StreamEvent{Target: "%1", Lines: []string{"hello", "world"}, Timestamp: now})
+	r.HandleStreamEvent(tmux.StreamEvent{Target: "%2", Lines: []string{"snapshot"}, Timestamp: now, IsFull: true})
+	r.HandleStreamEvent(tmux.StreamEvent{Target: "%9", Lines: []string{"unknown pane"}, Timestamp: now})
+	r.AddMarker(state.TimelineMarker{ID: "send", Type: state.MarkerPrompt, Timestamp: now})
+
+	manifest, err := r.Stop()
+	if err != nil {
+		t.Fatalf("Stop() error: %v", err)
+	}
+	if _, err := r.Stop(); err != nil {
+		t.Fatalf("second Stop() error: %v", err)
+	}
+	if sourceCalls != 1 {
+		t.Errorf("marker source called %d times, want 1", sourceCalls)
+	}
+
+	if len(manifest.Panes) != 2 || manifest.Panes[0].Index != 1 {
+		t.Fatalf("panes = %+v", manifest.Panes)
+	}
+	if manifest.Panes[0].Events != 1 || manifest.Panes[1].Events != 1 {
+		t.Errorf("event counts = %d, %d; want 1, 1", manifest.Panes[0].Events, manifest.Panes[1].Events)
+	}
+	if manifest.Panes[1].Width != 80 || manifest.Panes[1].Height != 24 {
+		t.Errorf("default size = %dx%d, want 80x24", manifest.Panes[1].Width, manifest.Panes[1].Height)
+	}
+	if len(manifest.Markers) != 2 {
+		t.Fatalf("markers = %+v, want checkpoint and send", manifest.Markers)
+	}
+
+	rec, err := Load(r.Dir())
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if len(rec.Casts) != 2 {
+		t.Fatalf("casts = %d, want 2", len(rec.Casts))
+	}
+
+	s := NewScreen(rec.Casts[0])
+	s.Seek(rec.Duration())
+	if got := strings.Join(s.Tail(5), "|"); got != "hello|world" {
+		t.Errorf("pane 1 replay = %q", got)
+	}
+	if !strings.HasPrefix(rec.Casts[1].Events[0].Data, clearScreen) {
+		t.Errorf("full capture not prefixed with clear: %q", rec.Casts[1].Events[0].Data)
+	}
+}
+
+func TestListAndResolve(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	older := filepath.Join(tmpDir, "proj_20260101-100000")
+	newer := filepath.Join(tmpDir, "proj_20260102-100000")
+	other := filepath.Join(tmpDir, "misc_20260103-100000")
+	base := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	for i, dir := range []string{older, newer, other} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+		session := "proj"
+		if dir == other {
+			session = "misc"
+		}
+		start := base.Add(time.Duration(i) * 24 * time.Hour)
+		if err := WriteManifest(dir, &Manifest{
+			Version:   ManifestVersion,
+			Session:   session,
+			StartedAt: start,
+			EndedAt:   start.Add(time.Minute),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Directories without a manifest are ignored.
+	_ = os.MkdirAll(filepath.Join(tmpDir, "junk"), 0700)
+
+	infos, err := List(tmpDir)
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(infos) != 3 || infos[0].Session != "misc" {
+		t.Fatalf("List() = %+v", infos)
+	}
+	if infos[0].Duration != time.Minute {
+		t.Errorf("Duration = %v, want 1m", infos[0].Duration)
+	}
+
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"", other},
+		{"proj", newer},
+		{"proj_20260101-100000", older},
+		{older, older},
+	}
+	for _, tc := range tests {
+		got, err := Resolve(tmpDir, tc.ref)
+		if err != nil {
+			t.Errorf("Resolve(%q) error: %v", tc.ref, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("Resolve(%q) = %q, want %q", tc.ref, got, tc.want)
+		}
+	}
+
+	if _, err := Resolve(tmpDir, "missing"); err == nil {
+		t.Error("Resolve(missing) expected error")
+	}
+}
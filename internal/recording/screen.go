@@ -0,0 +1,112 @@
+package recording
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/status"
+)
+
+// clearScreen is emitted before full-pane snapshots (polling fallback) so a
+// player knows to discard what it has accumulated so far.
+const clearScreen = "\x1b[2J\x1b[H"
+
+// DefaultScrollback is the number of lines a Screen keeps per pane.
+const DefaultScrollback = 2000
+
+// Screen replays a pane's output events into a plain-text line buffer. It is
+// a deliberately small model of a terminal: it understands line breaks and
+// full clears, which is what pipe-pane and capture-pane snapshots produce, and
+// strips all other escape sequences.
+type Screen struct {
+	cast       *Cast
+	scrollback int
+
+	lines   []string
+	partial string // Trailing text not yet terminated by a newline
+	next    int    // Index of the next event to apply
+	at      time.Duration
+}
+
+// NewScreen creates a screen positioned at the start of the cast.
+func NewScreen(cast *Cast) *Screen {
+	return &Screen{cast: cast, scrollback: DefaultScrollback}
+}
+
+// Seek moves the screen to offset d. Seeking forward applies only the new
+// events; seeking backward replays from the start.
+func (s *Screen) Seek(d time.Duration) {
+	if d < s.at {
+		s.reset()
+	}
+	s.at = d
+
+	events := s.cast.Events
+	end := s.next + sort.Search(len(events)-s.next, func(i int) bool {
+		return events[s.next+i].Offset() > d
+	})
+	for ; s.next < end; s.next++ {
+		if events[s.next].Code == CodeOutput {
+			s.feed(events[s.next].Data)
+		}
+	}
+}
+
+// Position returns the current offset.
+func (s *Screen) Position() time.Duration {
+	return s.at
+}
+
+// Tail returns the last n lines visible at the current position.
+func (s *Screen) Tail(n int) []string {
+	all := s.lines
+	if s.partial != "" {
+		all = append(all[:len(all):len(all)], s.partial)
+	}
+	if n <= 0 || n >= len(all) {
+		return all
+	}
+	return all[len(all)-n:]
+}
+
+// LastOutput returns the offset of the most recent output event applied.
+func (s *Screen) LastOutput() time.Duration {
+	for i := s.next - 1; i >= 0; i-- {
+		if s.cast.Events[i].Code == CodeOutput {
+			return s.cast.Events[i].Offset()
+		}
+	}
+	return 0
+}
+
+func (s *Screen) reset() {
+	s.lines = nil
+	s.partial = ""
+	s.next = 0
+	s.at = 0
+}
+
+func (s *Screen) feed(data string) {
+	if idx := strings.LastIndex(data, "\x1b[2J"); idx >= 0 {
+		s.lines = nil
+		s.partial = ""
+		data = strings.TrimPrefix(data[idx+len("\x1b[2J"):], "\x1b[H")
+	}
+
+	data = status.StripANSI(data)
+	parts := strings.Split(data, "\n")
+	for i, part := range parts {
+		part = strings.TrimSuffix(part, "\r")
+		if i == len(parts)-1 {
+			s.partial += part
+			break
+		}
+		s.lines = append(s.lines, s.partial+part)
+		s.partial = ""
+	}
+
+	if over := len(s.lines) - s.scrollback; over > 0 {
+		s.lines = append([]string(nil), s.lines[over:]...)
+	}
+}
@@ -0,0 +1,98 @@
+package redaction
+
+import (
+	"math"
+	"regexp"
+	"sort"
+)
+
+const (
+	// DefaultEntropyThreshold is the minimum bits per character for a
+	// candidate to count as a secret. Hex digests top out at 4.0, so commit
+	// hashes and checksums stay below it.
+	DefaultEntropyThreshold = 4.0
+	// DefaultEntropyMinLength is the shortest candidate considered.
+	DefaultEntropyMinLength = 20
+)
+
+// entropyCandidate matches token-like runs. Slashes and dots are excluded so
+// file paths and hostnames split into short pieces.
+var entropyCandidate = regexp.MustCompile(`[A-Za-z0-9+=_\-]+`)
+
+// ShannonEntropy returns the Shannon entropy of s in bits per character.
+func ShannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	n := 0
+	for _, r := range s {
+		counts[r]++
+		n++
+	}
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// appendEntropyMatches adds high-entropy candidates that do not overlap an
+// existing pattern match.
+func appendEntropyMatches(input string, matches []match, allowlist []*regexp.Regexp, cfg Config) []match {
+	threshold := cfg.EntropyThreshold
+	if threshold <= 0 {
+		threshold = DefaultEntropyThreshold
+	}
+	minLen := cfg.EntropyMinLength
+	if minLen <= 0 {
+		minLen = DefaultEntropyMinLength
+	}
+
+	out := matches
+	for _, loc := range entropyCandidate.FindAllStringIndex(input, -1) {
+		if loc[1]-loc[0] < minLen {
+			continue
+		}
+		candidate := input[loc[0]:loc[1]]
+		if !hasLetterAndDigit(candidate) || ShannonEntropy(candidate) < threshold {
+			continue
+		}
+		if overlapsAny(loc[0], loc[1], matches) || isAllowlisted(candidate, allowlist) {
+			continue
+		}
+		out = append(out, match{
+			category: CategoryHighEntropy,
+			match:    candidate,
+			start:    loc[0],
+			end:      loc[1],
+			priority: 10,
+		})
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].start < out[j].start })
+	return out
+}
+
+func hasLetterAndDigit(s string) bool {
+	var letter, digit bool
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			digit = true
+		case (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z'):
+			letter = true
+		}
+	}
+	return letter && digit
+}
+
+func overlapsAny(start, end int, matches []match) bool {
+	for _, m := range matches {
+		if start < m.end && m.start < end {
+			return true
+		}
+	}
+	return false
+}
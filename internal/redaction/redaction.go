@@ -14,6 +14,7 @@ import (
 //   - ModeWarn: scans and reports findings but doesn't modify output
 //   - ModeRedact: replaces sensitive content with placeholders
 //   - ModeBlock: scans and sets Blocked=true if findings exist
+//   - ModePseudonymize: replaces sensitive content with vault tokens
 func ScanAndRedact(input string, cfg Config) Result {
 	result := Result{
 		Mode:           cfg.Mode,
@@ -31,6 +32,9 @@ func ScanAndRedact(input string, cfg Config) Result {
 
 	// Scan for all matches.
 	matches := scan(input, allowlist, cfg.DisabledCategories)
+	if cfg.Entropy && !isCategoryDisabled(CategoryHighEntropy, cfg.DisabledCategories) {
+		matches = appendEntropyMatches(input, matches, allowlist, cfg)
+	}
 
 	// No findings: return input unchanged.
 	if len(matches) == 0 {
@@ -39,12 +43,22 @@ func ScanAndRedact(input string, cfg Config) Result {
 	}
 
 	// Convert matches to findings.
+	vault := currentVault()
 	result.Findings = make([]Finding, len(matches))
 	for i, m := range matches {
+		placeholder := ""
+		if cfg.Mode == ModePseudonymize && vault != nil {
+			if token, err := vault.Token(m.category, m.match); err == nil {
+				placeholder = token
+			}
+		}
+		if placeholder == "" {
+			placeholder = generatePlaceholder(m.category, m.match)
+		}
 		result.Findings[i] = Finding{
 			Category: m.category,
 			Match:    m.match,
-			Redacted: generatePlaceholder(m.category, m.match),
+			Redacted: placeholder,
 			Start:    m.start,
 			End:      m.end,
 		}
@@ -54,7 +68,7 @@ func ScanAndRedact(input string, cfg Config) Result {
 	switch cfg.Mode {
 	case ModeWarn:
 		result.Output = input
-	case ModeRedact:
+	case ModeRedact, ModePseudonymize:
 		result.Output = applyRedactions(input, result.Findings)
 	case ModeBlock:
 		result.Output = input
@@ -14,6 +14,10 @@ const (
 	ModeRedact Mode = "redact"
 	// ModeBlock fails the operation if sensitive content is detected.
 	ModeBlock Mode = "block"
+	// ModePseudonymize replaces sensitive content with stable per-secret tokens
+	// recorded in the encrypted vault, so the value can be restored later.
+	// Without a vault it behaves like ModeRedact.
+	ModePseudonymize Mode = "pseudonymize"
 )
 
 // Category identifies the type of sensitive content detected.
@@ -33,6 +37,7 @@ const (
 	CategoryGenericAPIKey Category = "GENERIC_API_KEY"
 	CategoryGenericSecret Category = "GENERIC_SECRET"
 	CategoryBearerToken   Category = "BEARER_TOKEN"
+	CategoryHighEntropy   Category = "HIGH_ENTROPY"
 )
 
 // Finding represents a single detected secret.
@@ -77,6 +82,14 @@ type Config struct {
 	ExtraPatterns map[Category][]string `json:"extra_patterns,omitempty"`
 	// DisabledCategories lists categories to skip during scanning.
 	DisabledCategories []Category `json:"disabled_categories,omitempty"`
+	// Entropy enables detection of random-looking strings that no pattern
+	// covers, reported as CategoryHighEntropy.
+	Entropy bool `json:"entropy,omitempty"`
+	// EntropyThreshold is the minimum Shannon entropy in bits per character
+	// (default DefaultEntropyThreshold).
+	EntropyThreshold float64 `json:"entropy_threshold,omitempty"`
+	// EntropyMinLength is the minimum candidate length (default DefaultEntropyMinLength).
+	EntropyMinLength int `json:"entropy_min_length,omitempty"`
 }
 
 // DefaultConfig returns a Config with sensible defaults.
@@ -89,7 +102,7 @@ func DefaultConfig() Config {
 // Validate checks if the config is valid.
 func (c *Config) Validate() error {
 	switch c.Mode {
-	case ModeOff, ModeWarn, ModeRedact, ModeBlock:
+	case ModeOff, ModeWarn, ModeRedact, ModeBlock, ModePseudonymize:
 		// valid
 	default:
 		return &ConfigError{Field: "mode", Message: "invalid mode: " + string(c.Mode)}
@@ -0,0 +1,385 @@
+package redaction
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/encryption"
+	"github.com/shahbajlive/ntm/internal/util"
+)
+
+// DefaultVaultSubPath is the vault location relative to the user's home directory.
+const DefaultVaultSubPath = ".ntm/secrets.vault"
+
+// tokenPattern matches pseudonym tokens such as «SECRET:GITHUB_TOKEN:3».
+var tokenPattern = regexp.MustCompile(`«SECRET:([A-Z0-9_]+):(\d+)»`)
+
+// FormatToken renders the pseudonym token for the n-th secret of a category.
+func FormatToken(cat Category, n int) string {
+	return fmt.Sprintf("«SECRET:%s:%d»", cat, n)
+}
+
+// ContainsTokens reports whether s contains any pseudonym tokens.
+func ContainsTokens(s string) bool {
+	return tokenPattern.MatchString(s)
+}
+
+// VaultEntry maps one pseudonym token to the secret it stands for.
+type VaultEntry struct {
+	Token     string    `json:"token"`
+	Category  Category  `json:"category"`
+	Value     string    `json:"value"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type vaultFile struct {
+	Version int          `json:"version"`
+	Entries []VaultEntry `json:"entries"`
+	// Counters is the highest token number ever handed out per category.
+	// It never goes down, so a forgotten token's number is not reused.
+	Counters map[Category]int `json:"counters,omitempty"`
+}
+
+// Vault stores the secrets behind pseudonym tokens. The file on disk is a
+// single blob encrypted with the active key; any key in the keyring can read
+// it. Tokens are stable: the same secret always maps to the same token.
+//
+// Several processes may share a vault file. Every operation holds a lock on
+// it and reloads it first, so none of them hands out a token another has
+// taken, drops entries another has added, or resolves a token another has
+// forgotten.
+type Vault struct {
+	path        string
+	encryptKey  []byte
+	decryptKeys [][]byte
+
+	mu       sync.Mutex
+	byToken  map[string]VaultEntry
+	byValue  map[string]string // category + "\x00" + value -> token
+	counters map[Category]int
+}
+
+// DefaultVaultPath returns the default vault path.
+func DefaultVaultPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return DefaultVaultSubPath
+	}
+	return filepath.Join(home, DefaultVaultSubPath)
+}
+
+// OpenVault loads the vault at path, creating an empty one if it does not
+// exist. encryptKey is used for writes; decryptKeys (which should include
+// encryptKey) are tried in order when reading.
+func OpenVault(path string, encryptKey []byte, decryptKeys [][]byte) (*Vault, error) {
+	if len(encryptKey) == 0 {
+		return nil, errors.New("secret vault requires an encryption key")
+	}
+	if path == "" {
+		path = DefaultVaultPath()
+	}
+	if len(decryptKeys) == 0 {
+		decryptKeys = [][]byte{encryptKey}
+	}
+
+	v := &Vault{
+		path:        path,
+		encryptKey:  encryptKey,
+		decryptKeys: decryptKeys,
+		byToken:     make(map[string]VaultEntry),
+		byValue:     make(map[string]string),
+		counters:    make(map[Category]int),
+	}
+
+	if err := v.load(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// load replaces the in-memory entries with those on disk. A missing file
+// is an empty vault.
+func (v *Vault) load() error {
+	data, err := os.ReadFile(v.path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("reading vault: %w", err)
+	}
+	var f vaultFile
+	if err == nil {
+		plaintext, err := encryption.DecryptWithKeyring(v.decryptKeys, data)
+		if err != nil {
+			return fmt.Errorf("decrypting vault: %w", err)
+		}
+		if err := json.Unmarshal(plaintext, &f); err != nil {
+			return fmt.Errorf("parsing vault: %w", err)
+		}
+	}
+	v.byToken = make(map[string]VaultEntry, len(f.Entries))
+	v.byValue = make(map[string]string, len(f.Entries))
+	v.counters = make(map[Category]int, len(f.Counters))
+	for cat, n := range f.Counters {
+		v.counters[cat] = n
+	}
+	for _, e := range f.Entries {
+		v.add(e)
+	}
+	return nil
+}
+
+// reload re-reads the vault file with it locked against other processes. If
+// it cannot be read the cached entries are kept.
+func (v *Vault) reload() {
+	unlock, err := lockVaultFile(v.path + ".lock")
+	if err != nil {
+		return
+	}
+	defer unlock()
+	_ = v.load()
+}
+
+// update runs fn on the entries on disk with the vault file locked against
+// other processes, and saves the result when fn reports a change.
+func (v *Vault) update(fn func() bool) error {
+	if err := os.MkdirAll(filepath.Dir(v.path), 0o700); err != nil {
+		return fmt.Errorf("creating vault directory: %w", err)
+	}
+	unlock, err := lockVaultFile(v.path + ".lock")
+	if err != nil {
+		return fmt.Errorf("locking vault: %w", err)
+	}
+	defer unlock()
+
+	if err := v.load(); err != nil {
+		return err
+	}
+	if !fn() {
+		return nil
+	}
+	if err := v.saveLocked(); err != nil {
+		// Drop the unsaved change
+		_ = v.load()
+		return err
+	}
+	return nil
+}
+
+func (v *Vault) add(e VaultEntry) {
+	v.byToken[e.Token] = e
+	v.byValue[string(e.Category)+"\x00"+e.Value] = e.Token
+	if m := tokenPattern.FindStringSubmatch(e.Token); m != nil {
+		if n, err := strconv.Atoi(m[2]); err == nil && n > v.counters[e.Category] {
+			v.counters[e.Category] = n
+		}
+	}
+}
+
+// Path returns the vault file path.
+func (v *Vault) Path() string {
+	return v.path
+}
+
+// Token returns the pseudonym token for a secret, assigning and persisting a
+// new one the first time the secret is seen.
+func (v *Vault) Token(cat Category, value string) (string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	// Another process may have seen the secret, taken the next number or
+	// forgotten the token since the vault was read.
+	key := string(cat) + "\x00" + value
+	var token string
+	err := v.update(func() bool {
+		if t, ok := v.byValue[key]; ok {
+			token = t
+			return false
+		}
+		entry := VaultEntry{
+			Token:     FormatToken(cat, v.counters[cat]+1),
+			Category:  cat,
+			Value:     value,
+			CreatedAt: time.Now().UTC(),
+		}
+		v.add(entry)
+		token = entry.Token
+		return true
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Lookup returns the entry for a token.
+func (v *Vault) Lookup(token string) (VaultEntry, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.reload()
+	e, ok := v.byToken[token]
+	return e, ok
+}
+
+// Rehydrate replaces every known token in s with its secret and reports how
+// many were replaced. Unknown tokens are left untouched.
+func (v *Vault) Rehydrate(s string) (string, int) {
+	if !ContainsTokens(s) {
+		return s, 0
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.reload()
+
+	replaced := 0
+	out := tokenPattern.ReplaceAllStringFunc(s, func(token string) string {
+		if e, ok := v.byToken[token]; ok {
+			replaced++
+			return e.Value
+		}
+		return token
+	})
+	return out, replaced
+}
+
+// Entries returns all vault entries ordered by category and token number.
+func (v *Vault) Entries() []VaultEntry {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.reload()
+
+	entries := make([]VaultEntry, 0, len(v.byToken))
+	for _, e := range v.byToken {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Category != entries[j].Category {
+			return entries[i].Category < entries[j].Category
+		}
+		return tokenNumber(entries[i].Token) < tokenNumber(entries[j].Token)
+	})
+	return entries
+}
+
+// Forget removes a token from the vault. Text already pseudonymized with it
+// can no longer be rehydrated, and its number is never handed out again.
+func (v *Vault) Forget(token string) (bool, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	var found bool
+	err := v.update(func() bool {
+		e, ok := v.byToken[token]
+		if !ok {
+			return false
+		}
+		delete(v.byToken, token)
+		delete(v.byValue, string(e.Category)+"\x00"+e.Value)
+		found = true
+		return true
+	})
+	return found, err
+}
+
+func tokenNumber(token string) int {
+	if m := tokenPattern.FindStringSubmatch(token); m != nil {
+		n, _ := strconv.Atoi(m[2])
+		return n
+	}
+	return 0
+}
+
+func (v *Vault) saveLocked() error {
+	f := vaultFile{Version: 1, Entries: make([]VaultEntry, 0, len(v.byToken)), Counters: v.counters}
+	for _, e := range v.byToken {
+		f.Entries = append(f.Entries, e)
+	}
+	sort.Slice(f.Entries, func(i, j int) bool { return f.Entries[i].Token < f.Entries[j].Token })
+
+	plaintext, err := json.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("encoding vault: %w", err)
+	}
+	ciphertext, err := encryption.Encrypt(v.encryptKey, plaintext)
+	if err != nil {
+		return fmt.Errorf("encrypting vault: %w", err)
+	}
+	if err := util.AtomicWriteFile(v.path, ciphertext, 0o600); err != nil {
+		return fmt.Errorf("writing vault: %w", err)
+	}
+	return nil
+}
+
+// TrustedPaneTag marks a pane as allowed to receive rehydrated secrets.
+const TrustedPaneTag = "trusted"
+
+var (
+	defaultVault   *Vault
+	trustedPanes   []string
+	defaultVaultMu sync.RWMutex
+)
+
+// SetVault installs the vault used by ModePseudonymize. Pass nil to disable
+// pseudonymization (ModePseudonymize then falls back to placeholders).
+func SetVault(v *Vault) {
+	defaultVaultMu.Lock()
+	defer defaultVaultMu.Unlock()
+	defaultVault = v
+}
+
+// GetVault returns the vault installed with SetVault, or nil.
+func GetVault() *Vault {
+	return currentVault()
+}
+
+func currentVault() *Vault {
+	defaultVaultMu.RLock()
+	defer defaultVaultMu.RUnlock()
+	return defaultVault
+}
+
+// SetTrustedPanes sets the pane title patterns (filepath.Match syntax) whose
+// panes receive rehydrated secrets, in addition to panes tagged "trusted".
+func SetTrustedPanes(patterns []string) {
+	defaultVaultMu.Lock()
+	defer defaultVaultMu.Unlock()
+	trustedPanes = append([]string(nil), patterns...)
+}
+
+// PaneTrusted reports whether a pane with this title and these tags may
+// receive real secret values.
+func PaneTrusted(title string, tags []string) bool {
+	for _, tag := range tags {
+		if tag == TrustedPaneTag {
+			return true
+		}
+	}
+	defaultVaultMu.RLock()
+	defer defaultVaultMu.RUnlock()
+	for _, pattern := range trustedPanes {
+		if ok, _ := filepath.Match(pattern, title); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// RehydrateForPane swaps vault tokens in s back to their secrets when the
+// pane is trusted. Every path that types text into a pane goes through it;
+// untrusted panes get s unchanged.
+func RehydrateForPane(title string, tags []string, s string) string {
+	if !ContainsTokens(s) || !PaneTrusted(title, tags) {
+		return s
+	}
+	v := currentVault()
+	if v == nil {
+		return s
+	}
+	out, _ := v.Rehydrate(s)
+	return out
+}
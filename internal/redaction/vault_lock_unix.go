@@ -0,0 +1,25 @@
+//go:build unix
+
+package redaction
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockVaultFile takes an exclusive flock on path, waiting for other
+// processes to release it, and returns the function releasing it.
+func lockVaultFile(path string) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
@@ -0,0 +1,266 @@
+package redaction
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, 32)
+}
+
+func TestVaultTokensAreStableAndPersisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.vault")
+	key := testKey(1)
+
+	v, err := OpenVault(path, key, nil)
+	if err != nil {
+		t.Fatalf("OpenVault: %v", err)
+	}
+
+	secret := "gh" + "p_" + strings.Repeat("a", 40)
+	other := "gh" + "p_" + strings.Repeat("b", 40)
+
+	t1, err := v.Token(CategoryGitHubToken, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t2, _ := v.Token(CategoryGitHubToken, other)
+	again, _ := v.Token(CategoryGitHubToken, secret)
+
+	if t1 != "«SECRET:GITHUB_TOKEN:1»" || t2 != "«SECRET:GITHUB_TOKEN:2»" {
+		t.Fatalf("tokens = %q, %q", t1, t2)
+	}
+	if again != t1 {
+		t.Errorf("same secret got a new token: %q vs %q", again, t1)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(raw, []byte(secret)) || bytes.Contains(raw, []byte("GITHUB_TOKEN")) {
+		t.Fatal("vault file is not encrypted")
+	}
+
+	reopened, err := OpenVault(path, testKey(2), [][]byte{testKey(2), key})
+	if err != nil {
+		t.Fatalf("reopen with keyring: %v", err)
+	}
+	if e, ok := reopened.Lookup(t2); !ok || e.Value != other {
+		t.Errorf("Lookup(%q) = %+v, %v", t2, e, ok)
+	}
+	t3, _ := reopened.Token(CategoryGitHubToken, "gh"+"p_"+strings.Repeat("c", 40))
+	if t3 != "«SECRET:GITHUB_TOKEN:3»" {
+		t.Errorf("counter not restored, got %q", t3)
+	}
+
+	if _, err := OpenVault(path, testKey(9), nil); err == nil {
+		t.Error("opening with the wrong key should fail")
+	}
+}
+
+func TestVaultRehydrateAndForget(t *testing.T) {
+	v, err := OpenVault(filepath.Join(t.TempDir(), "v"), testKey(3), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := "sk-ant-" + strings.Repeat("x", 45)
+	token, _ := v.Token(CategoryAnthropicKey, secret)
+
+	in := "export KEY=" + token + " and «SECRET:JWT:7»"
+	out, n := v.Rehydrate(in)
+	if n != 1 || out != "export KEY="+secret+" and «SECRET:JWT:7»" {
+		t.Fatalf("Rehydrate = %q, %d", out, n)
+	}
+
+	if ok, err := v.Forget(token); !ok || err != nil {
+		t.Fatalf("Forget = %v, %v", ok, err)
+	}
+	if _, n := v.Rehydrate(in); n != 0 {
+		t.Error("forgotten token should not rehydrate")
+	}
+}
+
+func TestVaultForgottenNumberIsNotReused(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "v")
+	v, err := OpenVault(path, testKey(3), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.Token(CategoryJWT, "one")
+	two, _ := v.Token(CategoryJWT, "two")
+	if ok, err := v.Forget(two); !ok || err != nil {
+		t.Fatalf("Forget = %v, %v", ok, err)
+	}
+
+	reopened, err := OpenVault(path, testKey(3), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := reopened.Token(CategoryJWT, "three"); got != FormatToken(CategoryJWT, 3) {
+		t.Errorf("new token after forgetting %s = %s", two, got)
+	}
+	if out, n := reopened.Rehydrate(two); n != 0 || out != two {
+		t.Errorf("forgotten token rehydrated to %q", out)
+	}
+}
+
+func TestRehydrateForPane(t *testing.T) {
+	v, err := OpenVault(filepath.Join(t.TempDir(), "v"), testKey(5), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetVault(v)
+	SetTrustedPanes([]string{"deploy*"})
+	t.Cleanup(func() {
+		SetVault(nil)
+		SetTrustedPanes(nil)
+	})
+	token, _ := v.Token(CategoryJWT, "secret")
+
+	for _, tt := range []struct {
+		title string
+		tags  []string
+		want  string
+	}{
+		{"cc_1", nil, token},
+		{"cc_1", []string{TrustedPaneTag}, "secret"},
+		{"deploy_1", nil, "secret"},
+	} {
+		if got := RehydrateForPane(tt.title, tt.tags, token); got != tt.want {
+			t.Errorf("RehydrateForPane(%q, %v) = %q, want %q", tt.title, tt.tags, got, tt.want)
+		}
+	}
+}
+
+func TestVaultSharedBetweenProcesses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.vault")
+	a, err := OpenVault(path, testKey(1), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := OpenVault(path, testKey(1), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each vault read the file before the other wrote to it.
+	ta, _ := a.Token(CategoryGitHubToken, "first")
+	tb, _ := b.Token(CategoryGitHubToken, "second")
+	if ta == tb {
+		t.Fatalf("both secrets got %q", ta)
+	}
+	if again, _ := b.Token(CategoryGitHubToken, "first"); again != ta {
+		t.Errorf("b tokenized a's secret as %q, want %q", again, ta)
+	}
+
+	reopened, err := OpenVault(path, testKey(1), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(reopened.Entries()); n != 2 {
+		t.Errorf("vault has %d entries, want 2", n)
+	}
+
+	// a resolves b's token and stops resolving one b forgot.
+	if out, n := a.Rehydrate(tb); n != 1 || out != "second" {
+		t.Errorf("a.Rehydrate(%q) = %q, %d", tb, out, n)
+	}
+	if ok, err := b.Forget(ta); !ok || err != nil {
+		t.Fatalf("Forget = %v, %v", ok, err)
+	}
+	if _, ok := a.Lookup(ta); ok {
+		t.Errorf("a still looks up %q after b forgot it", ta)
+	}
+	if again, _ := a.Token(CategoryGitHubToken, "first"); again == ta {
+		t.Errorf("a reused forgotten token %q", ta)
+	}
+}
+
+func TestScanAndRedactPseudonymize(t *testing.T) {
+	resetPatternsForTest(t)
+	v, err := OpenVault(filepath.Join(t.TempDir(), "v"), testKey(4), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret := "gh" + "p_" + strings.Repeat("d", 40)
+	input := "use " + secret + " to push, then " + secret
+
+	// Without a vault, pseudonymize degrades to placeholders.
+	SetVault(nil)
+	res := ScanAndRedact(input, Config{Mode: ModePseudonymize})
+	if strings.Contains(res.Output, secret) || strings.Contains(res.Output, "«SECRET") {
+		t.Fatalf("fallback output = %q", res.Output)
+	}
+
+	SetVault(v)
+	defer SetVault(nil)
+	res = ScanAndRedact(input, Config{Mode: ModePseudonymize})
+	want := "use «SECRET:GITHUB_TOKEN:1» to push, then «SECRET:GITHUB_TOKEN:1»"
+	if res.Output != want {
+		t.Fatalf("Output = %q, want %q", res.Output, want)
+	}
+	if !ContainsTokens(res.Output) {
+		t.Error("ContainsTokens should be true")
+	}
+	if back, _ := v.Rehydrate(res.Output); back != input {
+		t.Errorf("round trip = %q", back)
+	}
+
+	// Other modes keep the fixed placeholders.
+	if out, _ := Redact(input, Config{}); strings.Contains(out, "«SECRET") {
+		t.Errorf("ModeRedact used vault tokens: %q", out)
+	}
+}
+
+func TestEntropyDetection(t *testing.T) {
+	resetPatternsForTest(t)
+
+	random := "Zx9qLm2VtR8wKp4NcY7bHs3J"
+	cases := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{"random token", "deploy key " + random + " here", true},
+		{"git sha", "commit 3f2a9c1e8b7d6a5f4e3d2c1b0a9f8e7d6c5b4a39", false},
+		{"identifier", "TestServerReloadsAndRejectsInvalidPolicy", false},
+		{"path", "/usr/local/lib/go/src/net/http/server.go", false},
+		{"short", "a1B2c3D4", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			findings := Scan(tc.input, Config{Entropy: true})
+			got := false
+			for _, f := range findings {
+				if f.Category == CategoryHighEntropy {
+					got = true
+				}
+			}
+			if got != tc.want {
+				t.Errorf("high entropy finding = %v, want %v (findings %+v)", got, tc.want, findings)
+			}
+		})
+	}
+
+	if findings := Scan("deploy key "+random, Config{}); len(findings) != 0 {
+		t.Errorf("entropy detection should be opt-in, got %+v", findings)
+	}
+	if findings := Scan("deploy key "+random, Config{Entropy: true, Allowlist: []string{"^Zx9"}}); len(findings) != 0 {
+		t.Errorf("allowlisted candidate reported: %+v", findings)
+	}
+}
+
+func TestShannonEntropy(t *testing.T) {
+	if h := ShannonEntropy("aaaa"); h != 0 {
+		t.Errorf("entropy of repeated char = %v", h)
+	}
+	if h := ShannonEntropy("abcd"); h != 2 {
+		t.Errorf("entropy of 4 distinct chars = %v, want 2", h)
+	}
+}
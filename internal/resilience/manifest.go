@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 )
 
 // SpawnManifest represents the configuration of a spawned session for monitoring
@@ -13,7 +15,8 @@ type SpawnManifest struct {
 	ProjectDir  string        `json:"project_dir"`
 	Agents      []AgentConfig `json:"agents"`
 	AutoRestart bool          `json:"auto_restart"`
-	CockpitMode bool          `json:"cockpit_mode"` // Enable Cockpit heartbeat triggers
+	CockpitMode bool          `json:"cockpit_mode"`          // Enable Cockpit heartbeat triggers
+	MonitorPID  int           `json:"monitor_pid,omitempty"` // Per-session monitor running the loops, when ntmd does not
 }
 
 // AgentConfig represents the configuration for a single agent
@@ -88,3 +91,22 @@ func DeleteManifest(session string) error {
 	path := filepath.Join(ManifestDir(), session+".json")
 	return os.Remove(path)
 }
+
+// ListManifests returns the sessions that have a saved manifest, sorted.
+func ListManifests() ([]string, error) {
+	entries, err := os.ReadDir(ManifestDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest directory: %w", err)
+	}
+	var sessions []string
+	for _, e := range entries {
+		if name, ok := strings.CutSuffix(e.Name(), ".json"); ok && !e.IsDir() {
+			sessions = append(sessions, name)
+		}
+	}
+	sort.Strings(sessions)
+	return sessions, nil
+}
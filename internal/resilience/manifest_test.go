@@ -152,3 +152,28 @@ func TestSaveManifest_EmptyAgents(t *testing.T) {
 		t.Errorf("Agents count = %d, want 0", len(loaded.Agents))
 	}
 }
+
+func TestListManifests(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", tmpDir)
+
+	if got, err := ListManifests(); err != nil || len(got) != 0 {
+		t.Fatalf("ListManifests with no directory = %v, %v", got, err)
+	}
+	for _, s := range []string{"zeta", "alpha"} {
+		if err := SaveManifest(&SpawnManifest{Session: s}); err != nil {
+			t.Fatalf("SaveManifest: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(ManifestDir(), "notes.txt"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ListManifests()
+	if err != nil {
+		t.Fatalf("ListManifests: %v", err)
+	}
+	if len(got) != 2 || got[0] != "alpha" || got[1] != "zeta" {
+		t.Errorf("ListManifests = %v, want [alpha zeta]", got)
+	}
+}
@@ -0,0 +1,84 @@
+package resolve
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Workspace is the directory holding the versions of a conflicting file
+// for an agent asked to merge them.
+type Workspace struct {
+	Dir      string `json:"dir"`
+	Base     string `json:"base"`
+	A        string `json:"a"`
+	B        string `json:"b"`
+	Marked   string `json:"marked"`
+	Resolved string `json:"resolved"` // Where the agent writes the merged file
+}
+
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// WriteWorkspace writes the base, both sides and the marked merge of m
+// under <projectDir>/.ntm/conflicts.
+func WriteWorkspace(projectDir string, m *Merge, now time.Time) (*Workspace, error) {
+	name := unsafeNameChars.ReplaceAllString(m.Path, "_") + "-" + now.Format("20060102-150405")
+	dir := filepath.Join(projectDir, ".ntm", "conflicts", name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	ext := filepath.Ext(m.Path)
+	ws := &Workspace{
+		Dir:      dir,
+		Base:     filepath.Join(dir, "base"+ext),
+		A:        filepath.Join(dir, "a."+unsafeNameChars.ReplaceAllString(m.A.Label, "_")+ext),
+		B:        filepath.Join(dir, "b."+unsafeNameChars.ReplaceAllString(m.B.Label, "_")+ext),
+		Marked:   filepath.Join(dir, "marked"+ext),
+		Resolved: filepath.Join(dir, "resolved"+ext),
+	}
+	for path, data := range map[string][]byte{
+		ws.Base:   m.Base.Content,
+		ws.A:      m.A.Content,
+		ws.B:      m.B.Content,
+		ws.Marked: m.Marked,
+	} {
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return nil, err
+		}
+	}
+	return ws, nil
+}
+
+// ReadResolved returns the merged file the agent wrote, or nil if it has
+// not written one yet. A file still holding conflict markers is not ready
+// either: the agent may be part way through writing it.
+func (ws *Workspace) ReadResolved() ([]byte, error) {
+	data, err := os.ReadFile(ws.Resolved)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if HasMarkers(data) {
+		return nil, nil
+	}
+	return data, nil
+}
+
+// Prompt returns the message asking an agent to merge the sides of m.
+func (ws *Workspace) Prompt(m *Merge) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Merge request: two agents edited %s and their changes conflict.\n\n", m.Path)
+	fmt.Fprintf(&b, "- Base (%s): %s\n", shortSHA(m.Base.Commit), ws.Base)
+	fmt.Fprintf(&b, "- A, %s (%s): %s\n", m.A.Label, m.A.Source, ws.A)
+	fmt.Fprintf(&b, "- B, %s (%s): %s\n", m.B.Label, m.B.Source, ws.B)
+	fmt.Fprintf(&b, "- Merged with %d conflict(s) marked: %s\n", len(m.Conflicts), ws.Marked)
+	b.WriteString("\nKeep the intent of both changes. Changes only one side made are already merged in the marked file.\n")
+	fmt.Fprintf(&b, "Write the complete merged file, without conflict markers, to %s.tmp, then rename it to %s.\n", ws.Resolved, ws.Resolved)
+	fmt.Fprintf(&b, "Do not edit %s or any other file yourself; ntm applies the result.\n", m.Path)
+	return b.String()
+}
@@ -0,0 +1,244 @@
+package resolve
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Pick is how a conflict hunk is resolved.
+type Pick string
+
+// Picks
+const (
+	PickA    Pick = "a"    // Keep side A's lines
+	PickB    Pick = "b"    // Keep side B's lines
+	PickAB   Pick = "ab"   // Keep both, A's first
+	PickBA   Pick = "ba"   // Keep both, B's first
+	PickBase Pick = "base" // Drop both changes
+)
+
+// ParsePick parses a pick as typed by a user.
+func ParsePick(s string) (Pick, error) {
+	switch p := Pick(strings.ToLower(strings.TrimSpace(s))); p {
+	case PickA, PickB, PickAB, PickBA, PickBase:
+		return p, nil
+	case "both":
+		return PickAB, nil
+	}
+	return "", fmt.Errorf("unknown pick %q (want a, b, ab, ba or base)", s)
+}
+
+// Hunk is a region both sides changed differently.
+type Hunk struct {
+	Index int      `json:"index"`
+	Line  int      `json:"line"` // Line of its opening marker in the marked merge
+	Base  []string `json:"base"`
+	A     []string `json:"a"`
+	B     []string `json:"b"`
+}
+
+// segment is a run of merged lines, or a conflict when hunk is set.
+type segment struct {
+	lines []string
+	hunk  *Hunk
+}
+
+// Merge is the three-way merge of two sides of a file.
+type Merge struct {
+	Path      string  `json:"path"`
+	Base      *Side   `json:"base"`
+	A         *Side   `json:"a"`
+	B         *Side   `json:"b"`
+	Conflicts []*Hunk `json:"conflicts"`
+
+	// Marked is the merge with conflict markers, as git writes it
+	Marked   []byte `json:"-"`
+	segments []segment
+}
+
+// ThreeWay merges a and b against base. Changes only one side made are
+// taken as is; overlapping changes become conflicts.
+func ThreeWay(ctx context.Context, path string, base, a, b *Side) (*Merge, error) {
+	dir, err := os.MkdirTemp("", "ntm-merge-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	files := make([]string, 3)
+	for i, s := range []*Side{a, base, b} {
+		files[i] = filepath.Join(dir, fmt.Sprint(i))
+		if err := os.WriteFile(files[i], s.Content, 0644); err != nil {
+			return nil, err
+		}
+	}
+	cmd := exec.CommandContext(ctx, "git", "merge-file", "-p", "--diff3",
+		"-L", a.Label, "-L", base.Label, "-L", b.Label, files[0], files[1], files[2])
+	out, err := cmd.Output()
+	// The exit status is the number of conflicts; negative values are errors
+	var ee *exec.ExitError
+	if err != nil && (!errors.As(err, &ee) || ee.ExitCode() < 0 || ee.ExitCode() > 127) {
+		return nil, fmt.Errorf("git merge-file: %w", err)
+	}
+
+	m := &Merge{Path: path, Base: base, A: a, B: b, Marked: out, Conflicts: []*Hunk{}}
+	if err := m.parse(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+const (
+	markerA    = "<<<<<<<"
+	markerBase = "|||||||"
+	markerSep  = "======="
+	markerB    = ">>>>>>>"
+)
+
+// parse splits the marked merge into clean runs and conflict hunks.
+func (m *Merge) parse() error {
+	const (
+		clean = iota
+		inA
+		inBase
+		inB
+	)
+	state := clean
+	var cur segment
+	var hunk *Hunk
+
+	for i, line := range splitLines(m.Marked) {
+		text := strings.TrimRight(line, "\r\n")
+		switch {
+		case state == clean && isMarker(text, markerA):
+			if len(cur.lines) > 0 {
+				m.segments = append(m.segments, cur)
+			}
+			cur = segment{}
+			hunk = &Hunk{Index: len(m.Conflicts) + 1, Line: i + 1, A: []string{}, Base: []string{}, B: []string{}}
+			state = inA
+		case state == inA && isMarker(text, markerBase):
+			state = inBase
+		case (state == inA || state == inBase) && text == markerSep:
+			state = inB
+		case state == inB && isMarker(text, markerB):
+			m.Conflicts = append(m.Conflicts, hunk)
+			m.segments = append(m.segments, segment{hunk: hunk})
+			state = clean
+		case state == inA:
+			hunk.A = append(hunk.A, line)
+		case state == inBase:
+			hunk.Base = append(hunk.Base, line)
+		case state == inB:
+			hunk.B = append(hunk.B, line)
+		default:
+			cur.lines = append(cur.lines, line)
+		}
+	}
+	if state != clean {
+		return fmt.Errorf("unterminated conflict in merge of %s", m.Path)
+	}
+	if len(cur.lines) > 0 {
+		m.segments = append(m.segments, cur)
+	}
+	return nil
+}
+
+func isMarker(line, marker string) bool {
+	return line == marker || strings.HasPrefix(line, marker+" ")
+}
+
+// splitLines splits data into lines, keeping their line endings.
+func splitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Clean reports whether the sides merged without conflicts.
+func (m *Merge) Clean() bool {
+	return len(m.Conflicts) == 0
+}
+
+// Resolve returns the merged file with conflict i resolved by picks[i].
+func (m *Merge) Resolve(picks []Pick) ([]byte, error) {
+	if len(picks) != len(m.Conflicts) {
+		return nil, fmt.Errorf("%d picks for %d conflicts", len(picks), len(m.Conflicts))
+	}
+	var buf bytes.Buffer
+	for _, seg := range m.segments {
+		if seg.hunk == nil {
+			for _, l := range seg.lines {
+				buf.WriteString(l)
+			}
+			continue
+		}
+		h := seg.hunk
+		var parts [][]string
+		switch picks[h.Index-1] {
+		case PickA:
+			parts = [][]string{h.A}
+		case PickB:
+			parts = [][]string{h.B}
+		case PickAB:
+			parts = [][]string{h.A, h.B}
+		case PickBA:
+			parts = [][]string{h.B, h.A}
+		case PickBase:
+			parts = [][]string{h.Base}
+		default:
+			return nil, fmt.Errorf("conflict %d: unknown pick %q", h.Index, picks[h.Index-1])
+		}
+		for _, p := range parts {
+			for _, l := range p {
+				buf.WriteString(l)
+			}
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// Winner returns the side whose changes every pick kept, or nil when the
+// picks mixed both sides.
+func (m *Merge) Winner(picks []Pick) *Side {
+	var a, b bool
+	for _, p := range picks {
+		switch p {
+		case PickA:
+			a = true
+		case PickB:
+			b = true
+		case PickAB, PickBA:
+			a, b = true, true
+		}
+	}
+	switch {
+	case a && !b:
+		return m.A
+	case b && !a:
+		return m.B
+	}
+	return nil
+}
+
+// HasMarkers reports whether data still contains conflict markers.
+func HasMarkers(data []byte) bool {
+	for _, line := range splitLines(data) {
+		text := strings.TrimRight(line, "\r\n")
+		if isMarker(text, markerA) || isMarker(text, markerB) {
+			return true
+		}
+	}
+	return false
+}
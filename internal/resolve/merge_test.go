@@ -0,0 +1,222 @@
+package resolve
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/checkpoint"
+)
+
+func side(label, content string) *Side {
+	return &Side{Label: label, Source: SourceWorktree, Exists: true, Content: []byte(content)}
+}
+
+func TestThreeWay(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	base := side("base", "one\ntwo\nthree\nfour\nfive\n")
+	a := side("cc_1", "ONE\ntwo\nthree-a\nfour\nfive\n")
+	b := side("cod_1", "one\ntwo\nthree-b\nfour\nFIVE\n")
+
+	m, err := ThreeWay(context.Background(), "f.txt", base, a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Conflicts) != 1 {
+		t.Fatalf("conflicts = %d, want 1:\n%s", len(m.Conflicts), m.Marked)
+	}
+	h := m.Conflicts[0]
+	if strings.Join(h.A, "") != "three-a\n" || strings.Join(h.B, "") != "three-b\n" || strings.Join(h.Base, "") != "three\n" {
+		t.Errorf("hunk = %+v", h)
+	}
+	if !strings.HasPrefix(strings.Split(string(m.Marked), "\n")[h.Line-1], "<<<<<<< cc_1") {
+		t.Errorf("hunk line %d does not point at its marker:\n%s", h.Line, m.Marked)
+	}
+
+	tests := []struct {
+		pick Pick
+		want string
+	}{
+		{PickA, "ONE\ntwo\nthree-a\nfour\nFIVE\n"},
+		{PickB, "ONE\ntwo\nthree-b\nfour\nFIVE\n"},
+		{PickAB, "ONE\ntwo\nthree-a\nthree-b\nfour\nFIVE\n"},
+		{PickBA, "ONE\ntwo\nthree-b\nthree-a\nfour\nFIVE\n"},
+		{PickBase, "ONE\ntwo\nthree\nfour\nFIVE\n"},
+	}
+	for _, tt := range tests {
+		got, err := m.Resolve([]Pick{tt.pick})
+		if err != nil {
+			t.Fatalf("Resolve(%s): %v", tt.pick, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("Resolve(%s) = %q, want %q", tt.pick, got, tt.want)
+		}
+		if HasMarkers(got) {
+			t.Errorf("Resolve(%s) left markers", tt.pick)
+		}
+	}
+	if _, err := m.Resolve(nil); err == nil {
+		t.Error("expected error for missing picks")
+	}
+	if w := m.Winner([]Pick{PickB}); w != b {
+		t.Errorf("Winner(b) = %v", w)
+	}
+	if w := m.Winner([]Pick{PickAB}); w != nil {
+		t.Errorf("Winner(ab) = %v, want none", w)
+	}
+
+	clean, err := ThreeWay(context.Background(), "f.txt", base, a, side("cod_1", "one\ntwo\nthree\nfour\nFIVE\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := clean.Resolve(nil); !clean.Clean() || string(got) != "ONE\ntwo\nthree-a\nfour\nFIVE\n" {
+		t.Errorf("clean merge = %q (conflicts %d)", got, len(clean.Conflicts))
+	}
+}
+
+func TestParseSpec(t *testing.T) {
+	tests := []struct{ spec, agent, id string }{
+		{"cc_1", "cc_1", ""},
+		{"checkpoint:20250101-120000-x", "", "20250101-120000-x"},
+		{"cod_2=checkpoint:abc", "cod_2", "abc"},
+	}
+	for _, tt := range tests {
+		agent, id := ParseSpec(tt.spec)
+		if agent != tt.agent || id != tt.id {
+			t.Errorf("ParseSpec(%q) = %q, %q", tt.spec, agent, id)
+		}
+	}
+}
+
+func gitRun(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com",
+		"GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestSidesFromWorktreesAndCheckpoints(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	t.Setenv("HOME", t.TempDir())
+	ctx := context.Background()
+	dir := t.TempDir()
+	gitRun(t, dir, "init", "-q")
+	if err := os.WriteFile(filepath.Join(dir, "f.txt"), []byte("one\ntwo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, dir, "add", ".")
+	gitRun(t, dir, "commit", "-qm", "base")
+	head := gitRun(t, dir, "rev-parse", "HEAD")
+
+	// cc_1 committed on its branch and then removed its worktree
+	wt := filepath.Join(dir, ".ntm", "worktrees", "cc_1")
+	gitRun(t, dir, "worktree", "add", "-q", "-b", "ntm/s/cc_1", wt)
+	if err := os.WriteFile(filepath.Join(wt, "f.txt"), []byte("one-a\ntwo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, wt, "commit", "-qam", "a")
+	// cod_1 has uncommitted edits in its worktree
+	wt2 := filepath.Join(dir, ".ntm", "worktrees", "cod_1")
+	gitRun(t, dir, "worktree", "add", "-q", "-b", "ntm/s/cod_1", wt2)
+	if err := os.WriteFile(filepath.Join(wt2, "f.txt"), []byte("one\ntwo-b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, dir, "worktree", "remove", wt)
+
+	a, err := LoadSide(ctx, dir, "s", "cc_1", "f.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Source != SourceBranch || string(a.Content) != "one-a\ntwo\n" {
+		t.Errorf("cc_1 side = %+v %q", a, a.Content)
+	}
+	b, err := LoadSide(ctx, dir, "s", "cod_1", "f.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Source != SourceWorktree || string(b.Content) != "one\ntwo-b\n" || b.Commit != head {
+		t.Errorf("cod_1 side = %+v %q", b, b.Content)
+	}
+	base, err := Base(ctx, dir, "f.txt", a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if base.Commit != head || string(base.Content) != "one\ntwo\n" {
+		t.Errorf("base = %+v %q", base, base.Content)
+	}
+
+	// A checkpoint taken while an agent had uncommitted edits
+	storage := checkpoint.NewStorage()
+	cp := &checkpoint.Checkpoint{
+		Version: 1, ID: "cp1", SessionName: "s", WorkingDir: dir, CreatedAt: time.Now(),
+		Git: checkpoint.GitState{Commit: head, PatchFile: checkpoint.GitPatchFile},
+	}
+	if err := storage.Save(cp); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "f.txt"), []byte("one\ntwo\nthree\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	patch := gitRun(t, dir, "diff", "HEAD") + "\n"
+	if err := storage.SaveGitPatch("s", "cp1", patch); err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadSide(ctx, dir, "s", "gmi_1=checkpoint:cp1", "f.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Label != "gmi_1" || c.Agent != "gmi_1" || string(c.Content) != "one\ntwo\nthree\n" {
+		t.Errorf("checkpoint side = %+v %q", c, c.Content)
+	}
+}
+
+func TestWorkspace(t *testing.T) {
+	m := &Merge{
+		Path:   "pkg/f.go",
+		Base:   side("base", "x\n"),
+		A:      side("cc_1", "a\n"),
+		B:      side("cod_1", "b\n"),
+		Marked: []byte("<<<<<<< cc_1\na\n=======\nb\n>>>>>>> cod_1\n"),
+	}
+	m.Conflicts = []*Hunk{{Index: 1}}
+	ws, err := WriteWorkspace(t.TempDir(), m, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(ws.A, "a.cc_1.go") {
+		t.Errorf("A = %s", ws.A)
+	}
+	prompt := ws.Prompt(m)
+	for _, want := range []string{"pkg/f.go", ws.Marked, ws.Resolved + ".tmp", "1 conflict(s)"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q:\n%s", want, prompt)
+		}
+	}
+
+	if data, err := ws.ReadResolved(); data != nil || err != nil {
+		t.Errorf("ReadResolved before write = %q, %v", data, err)
+	}
+	_ = os.WriteFile(ws.Resolved, m.Marked, 0644)
+	if data, err := ws.ReadResolved(); data != nil || err != nil {
+		t.Errorf("ReadResolved with markers = %q, %v", data, err)
+	}
+	_ = os.WriteFile(ws.Resolved, []byte("ab\n"), 0644)
+	if data, err := ws.ReadResolved(); err != nil || string(data) != "ab\n" {
+		t.Errorf("ReadResolved = %q, %v", data, err)
+	}
+}
@@ -0,0 +1,235 @@
+// Package resolve settles edits two agents made to the same file. Each
+// agent's version of the file is loaded from its worktree branch or from a
+// checkpoint patch, merged three ways against their common base, and the
+// remaining conflicts are resolved hunk by hunk or handed to an agent.
+package resolve
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/shahbajlive/ntm/internal/checkpoint"
+	"github.com/shahbajlive/ntm/internal/worktrees"
+)
+
+// Sources a side can be loaded from
+const (
+	SourceWorktree   = "worktree"   // The agent's worktree, including uncommitted edits
+	SourceBranch     = "branch"     // The agent's worktree branch, when the worktree is gone
+	SourceCheckpoint = "checkpoint" // A checkpoint's commit with its patch applied
+	SourceCommit     = "commit"     // A commit, as for the base
+)
+
+// Side is one agent's version of the conflicting file.
+type Side struct {
+	Label   string `json:"label"`           // Shown in the view, e.g. "cc_1"
+	Agent   string `json:"agent,omitempty"` // Agent that made the edits, when known
+	Source  string `json:"source"`
+	Ref     string `json:"ref"`              // Worktree path, branch or checkpoint ID
+	Commit  string `json:"commit,omitempty"` // Commit the version builds on
+	Exists  bool   `json:"exists"`
+	Content []byte `json:"-"`
+}
+
+// ParseSpec splits a side spec: an agent name (cc_1) for its worktree, or
+// checkpoint:<id>, optionally prefixed by the agent that made the edits
+// (cc_1=checkpoint:<id>).
+func ParseSpec(spec string) (agent, checkpointID string) {
+	spec = strings.TrimSpace(spec)
+	if i := strings.Index(spec, "="); i >= 0 {
+		agent, spec = spec[:i], spec[i+1:]
+	}
+	if id, ok := strings.CutPrefix(spec, "checkpoint:"); ok {
+		return agent, id
+	}
+	if agent == "" {
+		agent = spec
+	}
+	return agent, ""
+}
+
+// LoadSide loads path as the side spec describes. Worktrees and branches
+// are looked up for session in projectDir.
+func LoadSide(ctx context.Context, projectDir, session, spec, path string) (*Side, error) {
+	agent, checkpointID := ParseSpec(spec)
+	if checkpointID != "" {
+		side, err := CheckpointSide(ctx, checkpoint.NewStorage(), session, checkpointID, path)
+		if err != nil {
+			return nil, err
+		}
+		side.Agent = agent
+		if agent != "" {
+			side.Label = agent
+		}
+		return side, nil
+	}
+	if agent == "" {
+		return nil, fmt.Errorf("empty side spec")
+	}
+	return WorktreeSide(ctx, projectDir, session, agent, path)
+}
+
+// WorktreeSide loads agent's version of path from its worktree, or from
+// its branch when the worktree has been removed.
+func WorktreeSide(ctx context.Context, projectDir, session, agent, path string) (*Side, error) {
+	info, err := worktrees.NewManager(projectDir, session).GetWorktreeForAgent(agent)
+	if err != nil {
+		return nil, err
+	}
+	side := &Side{Label: agent, Agent: agent}
+
+	if info.Created && info.Error == "" {
+		side.Source, side.Ref = SourceWorktree, info.Path
+		if side.Commit, err = git(ctx, info.Path, "rev-parse", "HEAD"); err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(filepath.Join(info.Path, path))
+		switch {
+		case err == nil:
+			side.Content, side.Exists = data, true
+		case !os.IsNotExist(err):
+			return nil, err
+		}
+		return side, nil
+	}
+
+	side.Source, side.Ref = SourceBranch, info.BranchName
+	if side.Commit, err = git(ctx, projectDir, "rev-parse", "--verify", info.BranchName+"^{commit}"); err != nil {
+		return nil, fmt.Errorf("%s has neither a worktree nor a branch: %w", agent, err)
+	}
+	side.Content, side.Exists, err = show(ctx, projectDir, side.Commit, path)
+	return side, err
+}
+
+// CheckpointSide loads path as it was when checkpoint id of session was
+// taken: the file at the checkpoint's commit with its uncommitted changes
+// applied.
+func CheckpointSide(ctx context.Context, storage *checkpoint.Storage, session, id, path string) (*Side, error) {
+	cp, err := storage.Load(session, id)
+	if err != nil {
+		return nil, err
+	}
+	if cp.Git.Commit == "" {
+		return nil, fmt.Errorf("checkpoint %s has no git state", id)
+	}
+	side := &Side{Label: "checkpoint:" + id, Source: SourceCheckpoint, Ref: id, Commit: cp.Git.Commit}
+	if side.Content, side.Exists, err = show(ctx, cp.WorkingDir, cp.Git.Commit, path); err != nil {
+		return nil, err
+	}
+	if cp.Git.PatchFile == "" {
+		return side, nil
+	}
+	patch, err := storage.LoadGitPatch(session, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := side.applyPatch(ctx, patch, path); err != nil {
+		return nil, fmt.Errorf("checkpoint %s: %w", id, err)
+	}
+	return side, nil
+}
+
+// applyPatch applies the part of patch touching path to the side's content.
+func (s *Side) applyPatch(ctx context.Context, patch, path string) error {
+	dir, err := os.MkdirTemp("", "ntm-resolve-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, filepath.FromSlash(path))
+	if s.Exists {
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(file, s.Content, 0644); err != nil {
+			return err
+		}
+	}
+	patchFile := filepath.Join(dir, ".checkpoint.patch")
+	if err := os.WriteFile(patchFile, []byte(patch), 0644); err != nil {
+		return err
+	}
+	// Outside a repository git apply patches files relative to dir
+	if _, err := git(ctx, dir, "apply", "--include="+path, patchFile); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(file)
+	switch {
+	case err == nil:
+		s.Content, s.Exists = data, true
+	case os.IsNotExist(err):
+		s.Content, s.Exists = nil, false
+	default:
+		return err
+	}
+	return nil
+}
+
+// Base returns path at the common ancestor of both sides' commits in
+// projectDir: the version both agents started from.
+func Base(ctx context.Context, projectDir, path string, a, b *Side) (*Side, error) {
+	commit := a.Commit
+	if a.Commit != b.Commit {
+		mb, err := git(ctx, projectDir, "merge-base", a.Commit, b.Commit)
+		if err != nil {
+			return nil, err
+		}
+		commit = mb
+	}
+	side, err := AtCommit(ctx, projectDir, commit, path)
+	if err != nil {
+		return nil, err
+	}
+	side.Label = "base"
+	return side, nil
+}
+
+// AtCommit returns path as committed at commit in dir.
+func AtCommit(ctx context.Context, dir, commit, path string) (*Side, error) {
+	side := &Side{Label: shortSHA(commit), Source: SourceCommit, Ref: commit, Commit: commit}
+	var err error
+	side.Content, side.Exists, err = show(ctx, dir, commit, path)
+	return side, err
+}
+
+// show returns path at commit, reporting whether it exists there.
+func show(ctx context.Context, dir, commit, path string) ([]byte, bool, error) {
+	cmd := exec.CommandContext(ctx, "git", "cat-file", "-e", commit+":"+path)
+	cmd.Dir = dir
+	if cmd.Run() != nil {
+		return nil, false, nil
+	}
+	cmd = exec.CommandContext(ctx, "git", "show", commit+":"+path)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, false, fmt.Errorf("git show %s:%s: %w", shortSHA(commit), path, err)
+	}
+	return out, true, nil
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		if ee, ok := err.(*exec.ExitError); ok && len(ee.Stderr) > 0 {
+			return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(ee.Stderr)))
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimRight(string(out), "\n"), nil
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
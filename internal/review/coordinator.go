@@ -0,0 +1,405 @@
+package review
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/assign"
+	"github.com/shahbajlive/ntm/internal/assignment"
+	"github.com/shahbajlive/ntm/internal/status"
+	"github.com/shahbajlive/ntm/internal/tmux"
+)
+
+const (
+	// DefaultMaxRounds is how many review rounds an assignment gets.
+	DefaultMaxRounds = 3
+	// DefaultTimeout is how long a reviewer has to write its result.
+	DefaultTimeout = 30 * time.Minute
+)
+
+// DefaultReviewerTypes is the reviewer preference order when none is set.
+var DefaultReviewerTypes = []string{"cod", "cc", "gmi"}
+
+var (
+	// ErrNothingToReview is returned when the author's work changes nothing.
+	ErrNothingToReview = errors.New("no changes to review")
+	// ErrNoReviewer is returned when no agent of another type is free.
+	ErrNoReviewer = errors.New("no idle agent of another type to review")
+)
+
+// Config configures a Coordinator.
+type Config struct {
+	ReviewerTypes []string      // Preferred reviewer agent types, in order; never the author's
+	Spawn         bool          // Add a reviewer agent when none is idle
+	MaxRounds     int           // Review rounds per assignment (default 3)
+	Timeout       time.Duration // Time a reviewer has to answer (default 30m)
+	Auto          bool          // Tick requests reviews for completed assignments
+}
+
+// Coordinator requests reviews of a session's completed assignments,
+// collects the reviewers' results and feeds them back to the authors.
+type Coordinator struct {
+	Session    string
+	ProjectDir string
+	Store      *assignment.AssignmentStore
+	Config     Config
+
+	// Panes lists the session's panes. Defaults to tmux.
+	Panes func() ([]tmux.Pane, error)
+	// IsIdle reports whether an agent is free to review. Defaults to the
+	// status detector.
+	IsIdle func(p tmux.Pane) bool
+	// WorkDir returns the directory holding an assignment's work, typically
+	// the author's worktree. Defaults to ProjectDir.
+	WorkDir func(a *assignment.Assignment) string
+	// Send pastes a message into a pane. Defaults to tmux.
+	Send func(paneID, msg string) error
+	// Spawn adds an agent of the given type to the session and returns once
+	// it is ready for a prompt. Used when Config.Spawn is set.
+	Spawn func(ctx context.Context, agentType string) error
+}
+
+// Event reports something a Coordinator did.
+type Event struct {
+	BeadID string             `json:"bead_id"`
+	Review *assignment.Review `json:"review,omitempty"`
+	Error  string             `json:"error,omitempty"`
+}
+
+// Tick collects finished reviews, expires overdue ones and, with
+// Config.Auto, requests reviews for completed assignments that need one.
+func (c *Coordinator) Tick(ctx context.Context) []Event {
+	_ = c.Store.Load()
+	var events []Event
+	for _, a := range c.Store.List() {
+		if r := a.LatestReview(); r != nil && r.Status == assignment.ReviewPending {
+			if ev := c.collect(a, r); ev != nil {
+				events = append(events, *ev)
+			}
+		}
+	}
+	if !c.Config.Auto {
+		return events
+	}
+	for _, a := range c.Store.ListByStatus(assignment.StatusCompleted) {
+		if !c.needsReview(a) {
+			continue
+		}
+		r, err := c.Request(ctx, a.BeadID)
+		switch {
+		case err == nil:
+			events = append(events, Event{BeadID: a.BeadID, Review: r})
+		case errors.Is(err, ErrNoReviewer):
+			// Try again once an agent frees up
+		default:
+			events = append(events, Event{BeadID: a.BeadID, Error: err.Error()})
+		}
+	}
+	return events
+}
+
+// needsReview reports whether a completed assignment is due a new round:
+// it was never reviewed, it was completed again after requested changes,
+// or the last reviewer never answered.
+func (c *Coordinator) needsReview(a *assignment.Assignment) bool {
+	if len(a.Reviews) >= c.maxRounds() {
+		return false
+	}
+	r := a.LatestReview()
+	switch {
+	case r == nil:
+		return true
+	case r.Status == assignment.ReviewExpired:
+		return true
+	case r.Status == assignment.ReviewChangesRequested:
+		return a.CompletedAt != nil && r.CompletedAt != nil && a.CompletedAt.After(*r.CompletedAt)
+	}
+	return false
+}
+
+// Request packages the work of beadID's assignment and sends it to a
+// reviewer agent of another type, opening a new review round.
+func (c *Coordinator) Request(ctx context.Context, beadID string) (*assignment.Review, error) {
+	a := c.Store.Get(beadID)
+	if a == nil {
+		return nil, fmt.Errorf("no assignment for %s", beadID)
+	}
+	if a.Status != assignment.StatusWorking && a.Status != assignment.StatusCompleted {
+		return nil, fmt.Errorf("%s is %s, not finished work", beadID, a.Status)
+	}
+	prev := a.LatestReview()
+
+	dir := c.ProjectDir
+	if c.WorkDir != nil {
+		if d := c.WorkDir(a); d != "" {
+			dir = d
+		}
+	}
+	base := ""
+	if prev != nil {
+		base = prev.Base // Later rounds review the whole change again
+	} else {
+		var err error
+		if base, err = BaseBefore(ctx, dir, a.AssignedAt); err != nil {
+			return nil, err
+		}
+	}
+	diff, err := PackageDiff(ctx, dir, base)
+	if err != nil {
+		return nil, err
+	}
+	if diff.Empty() {
+		return nil, ErrNothingToReview
+	}
+
+	reviewer, err := c.reviewer(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+
+	round := len(a.Reviews) + 1
+	id := fmt.Sprintf("%s-r%d", unsafeIDChars.ReplaceAllString(beadID, "_"), round)
+	reqDir := filepath.Join(c.ProjectDir, ".ntm", "reviews", id)
+	if err := os.MkdirAll(reqDir, 0755); err != nil {
+		return nil, fmt.Errorf("create review dir: %w", err)
+	}
+	patchPath := filepath.Join(reqDir, "diff.patch")
+	resultPath := filepath.Join(reqDir, "result.json")
+	if err := os.WriteFile(patchPath, []byte(diff.Patch+"\n"), 0644); err != nil {
+		return nil, fmt.Errorf("write review diff: %w", err)
+	}
+	_ = os.Remove(resultPath)
+
+	req := Request{
+		BeadID:     a.BeadID,
+		BeadTitle:  a.BeadTitle,
+		AuthorType: a.AgentType,
+		Round:      round,
+		Diff:       diff,
+		PatchPath:  patchPath,
+		ResultPath: resultPath,
+	}
+	if prev != nil && prev.Status == assignment.ReviewChangesRequested {
+		req.Previous = prev
+	}
+	if err := c.send(reviewer.ID, req.Prompt()); err != nil {
+		return nil, fmt.Errorf("send review request to pane %d: %w", reviewer.Index, err)
+	}
+
+	return c.Store.StartReview(beadID, assignment.Review{
+		ID:           id,
+		ReviewerPane: reviewer.Index,
+		ReviewerType: string(reviewer.Type),
+		Worktree:     worktreeName(c.ProjectDir, diff.Dir),
+		WorkDir:      diff.Dir,
+		Base:         diff.Base,
+		Head:         diff.Head,
+		Files:        diff.Files,
+		PatchPath:    patchPath,
+		ResultPath:   resultPath,
+	})
+}
+
+// collect finishes a pending review whose result has been written, or
+// expires it once the reviewer is out of time.
+func (c *Coordinator) collect(a *assignment.Assignment, r *assignment.Review) *Event {
+	data, err := os.ReadFile(r.ResultPath)
+	if err == nil {
+		res, perr := ParseResult(data)
+		if perr == nil {
+			if err := c.Store.FinishReview(a.BeadID, r.ID, res.Status(), res.Summary, res.Comments); err != nil {
+				return &Event{BeadID: a.BeadID, Error: err.Error()}
+			}
+			done := c.Store.Get(a.BeadID).LatestReview()
+			if done.Status == assignment.ReviewChangesRequested || len(done.Comments) > 0 {
+				if err := c.sendToPane(a.Pane, FeedbackMessage(a.BeadID, done)); err != nil {
+					return &Event{BeadID: a.BeadID, Review: done, Error: "feedback not delivered: " + err.Error()}
+				}
+			}
+			return &Event{BeadID: a.BeadID, Review: done}
+		}
+		// The reviewer may still be writing; judge it at the deadline
+		err = perr
+	}
+
+	if time.Since(r.RequestedAt) < c.timeout() {
+		return nil
+	}
+	summary := "reviewer did not answer in time"
+	if !os.IsNotExist(err) {
+		summary = fmt.Sprintf("invalid review result: %v", err)
+	}
+	if err := c.Store.FinishReview(a.BeadID, r.ID, assignment.ReviewExpired, summary, nil); err != nil {
+		return &Event{BeadID: a.BeadID, Error: err.Error()}
+	}
+	return &Event{BeadID: a.BeadID, Review: c.Store.Get(a.BeadID).LatestReview()}
+}
+
+// reviewer picks an idle agent of a type other than a's author that is
+// neither assigned work nor reviewing, spawning one when configured.
+func (c *Coordinator) reviewer(ctx context.Context, a *assignment.Assignment) (*tmux.Pane, error) {
+	author := assign.ParseAgentType(a.AgentType)
+	types := c.reviewerTypes(author)
+
+	if p, err := c.pickReviewer(a, types, true); p != nil || err != nil {
+		return p, err
+	}
+	if !c.Config.Spawn || c.Spawn == nil || len(types) == 0 {
+		return nil, ErrNoReviewer
+	}
+	if err := c.Spawn(ctx, string(types[0])); err != nil {
+		return nil, fmt.Errorf("spawn %s reviewer: %w", types[0], err)
+	}
+	// A new agent may not read as idle yet
+	if p, err := c.pickReviewer(a, types[:1], false); p != nil || err != nil {
+		return p, err
+	}
+	return nil, ErrNoReviewer
+}
+
+func (c *Coordinator) pickReviewer(a *assignment.Assignment, types []tmux.AgentType, needIdle bool) (*tmux.Pane, error) {
+	panes, err := c.panes()
+	if err != nil {
+		return nil, err
+	}
+	busy := make(map[int]bool)
+	for _, other := range c.Store.ListActive() {
+		busy[other.Pane] = true
+	}
+	for _, other := range c.Store.List() {
+		if r := other.LatestReview(); r != nil && r.Status == assignment.ReviewPending {
+			busy[r.ReviewerPane] = true
+		}
+	}
+
+	rank := make(map[tmux.AgentType]int, len(types))
+	for i, t := range types {
+		rank[t] = i
+	}
+	var candidates []tmux.Pane
+	for _, p := range panes {
+		if _, ok := rank[p.Type]; !ok || p.Index == a.Pane || busy[p.Index] {
+			continue
+		}
+		if needIdle && !c.isIdle(p) {
+			continue
+		}
+		candidates = append(candidates, p)
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return rank[candidates[i].Type] < rank[candidates[j].Type]
+	})
+	return &candidates[0], nil
+}
+
+// reviewerTypes returns the configured reviewer types other than author.
+func (c *Coordinator) reviewerTypes(author tmux.AgentType) []tmux.AgentType {
+	names := c.Config.ReviewerTypes
+	if len(names) == 0 {
+		names = DefaultReviewerTypes
+	}
+	var out []tmux.AgentType
+	for _, n := range names {
+		if t := assign.ParseAgentType(n); t != author && t != tmux.AgentUser {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func (c *Coordinator) sendToPane(index int, msg string) error {
+	panes, err := c.panes()
+	if err != nil {
+		return err
+	}
+	for _, p := range panes {
+		if p.Index == index {
+			return c.send(p.ID, msg)
+		}
+	}
+	return fmt.Errorf("pane %d not found", index)
+}
+
+func (c *Coordinator) panes() ([]tmux.Pane, error) {
+	if c.Panes != nil {
+		return c.Panes()
+	}
+	return tmux.GetPanes(c.Session)
+}
+
+func (c *Coordinator) isIdle(p tmux.Pane) bool {
+	if c.IsIdle != nil {
+		return c.IsIdle(p)
+	}
+	st, err := status.NewDetector().Detect(p.ID)
+	return err == nil && st.State == status.StateIdle
+}
+
+func (c *Coordinator) send(paneID, msg string) error {
+	if c.Send != nil {
+		return c.Send(paneID, msg)
+	}
+	return tmux.PasteKeys(paneID, msg, true)
+}
+
+func (c *Coordinator) maxRounds() int {
+	if c.Config.MaxRounds > 0 {
+		return c.Config.MaxRounds
+	}
+	return DefaultMaxRounds
+}
+
+func (c *Coordinator) timeout() time.Duration {
+	if c.Config.Timeout > 0 {
+		return c.Config.Timeout
+	}
+	return DefaultTimeout
+}
+
+var unsafeIDChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// worktreeName returns the agent name of the ntm worktree holding dir, or
+// "" when dir is not in one.
+func worktreeName(projectDir, dir string) string {
+	rel, err := filepath.Rel(filepath.Join(projectDir, ".ntm", "worktrees"), dir)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	return strings.Split(filepath.ToSlash(rel), "/")[0]
+}
+
+// MergeGate checks that the work in worktree (an agent name such as cc_1)
+// may be merged: its latest review round must be approved, and when head
+// is given, at the commit that was reviewed.
+func MergeGate(store *assignment.AssignmentStore, worktree, head string) error {
+	var latest *assignment.Review
+	var beadID string
+	for _, a := range store.List() {
+		for i := range a.Reviews {
+			r := &a.Reviews[i]
+			if r.Worktree == worktree && (latest == nil || r.RequestedAt.After(latest.RequestedAt)) {
+				latest, beadID = r, a.BeadID
+			}
+		}
+	}
+	switch {
+	case latest == nil:
+		return fmt.Errorf("%s has no review; run 'ntm review request' first", worktree)
+	case latest.Status != assignment.ReviewApproved:
+		return fmt.Errorf("review round %d of %s is %s", latest.Round, beadID, latest.Status)
+	case head != "" && latest.Head != "" && head != latest.Head:
+		return fmt.Errorf("%s was approved at %s but the branch is now at %s; request a new review",
+			worktree, shortSHA(latest.Head), shortSHA(head))
+	}
+	return nil
+}
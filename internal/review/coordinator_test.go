@@ -0,0 +1,230 @@
+package review
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/assignment"
+	"github.com/shahbajlive/ntm/internal/tmux"
+)
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com",
+		"GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// reviewFixture sets up a repository with a completed claude assignment on
+// bd-1 whose work adds a line and a new file, and a session with a codex
+// and a gemini pane.
+func reviewFixture(t *testing.T) (*Coordinator, map[string][]string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-qm", "base", "--date", time.Now().Add(-time.Hour).Format(time.RFC3339))
+
+	store := assignment.NewStore("review-test")
+	if _, err := store.Assign("bd-1", "Add feature", 1, "claude", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	_ = store.MarkWorking("bd-1")
+	_ = store.MarkCompleted("bd-1")
+
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "feature.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := make(map[string][]string)
+	c := &Coordinator{
+		Session:    "review-test",
+		ProjectDir: dir,
+		Store:      store,
+		Config:     Config{Auto: true},
+		Panes: func() ([]tmux.Pane, error) {
+			return []tmux.Pane{
+				{ID: "%1", Index: 1, Type: tmux.AgentClaude},
+				{ID: "%2", Index: 2, Type: tmux.AgentGemini},
+				{ID: "%3", Index: 3, Type: tmux.AgentCodex},
+			}, nil
+		},
+		IsIdle: func(tmux.Pane) bool { return true },
+		Send: func(paneID, msg string) error {
+			sent[paneID] = append(sent[paneID], msg)
+			return nil
+		},
+	}
+	return c, sent
+}
+
+func TestCoordinatorReviewRounds(t *testing.T) {
+	c, sent := reviewFixture(t)
+	ctx := context.Background()
+
+	events := c.Tick(ctx)
+	if len(events) != 1 || events[0].Error != "" || events[0].Review == nil {
+		t.Fatalf("first tick events = %+v", events)
+	}
+	r := events[0].Review
+	if r.ReviewerPane != 3 || r.ReviewerType != "cod" || r.Status != assignment.ReviewPending {
+		t.Errorf("review = %+v; want a pending codex review", r)
+	}
+	if len(r.Files) != 2 {
+		t.Errorf("files = %v, want main.go and feature.go", r.Files)
+	}
+	patch, _ := os.ReadFile(r.PatchPath)
+	if !strings.Contains(string(patch), "+func main() {}") || !strings.Contains(string(patch), "feature.go") {
+		t.Errorf("patch = %s", patch)
+	}
+	if len(sent["%3"]) != 1 || !strings.Contains(sent["%3"][0], r.ResultPath) {
+		t.Fatalf("reviewer prompt = %v", sent["%3"])
+	}
+
+	// Nothing more to do until the reviewer answers
+	if events := c.Tick(ctx); len(events) != 0 {
+		t.Errorf("idle tick events = %+v", events)
+	}
+
+	result := `{"verdict": "changes_requested", "summary": "missing test",
+	  "comments": [{"file": "main.go", "line": 3, "severity": "major", "message": "main does nothing"}]}`
+	if err := os.WriteFile(r.ResultPath, []byte(result), 0644); err != nil {
+		t.Fatal(err)
+	}
+	events = c.Tick(ctx)
+	if len(events) != 1 || events[0].Review.Status != assignment.ReviewChangesRequested {
+		t.Fatalf("collect events = %+v", events)
+	}
+	if a := c.Store.Get("bd-1"); a.Status != assignment.StatusWorking {
+		t.Errorf("assignment status = %s, want working after requested changes", a.Status)
+	}
+	if len(sent["%1"]) != 1 || !strings.Contains(sent["%1"][0], "[major] main.go:3: main does nothing") {
+		t.Errorf("author feedback = %v", sent["%1"])
+	}
+
+	// The author completes again: round 2 carries round 1's comments
+	time.Sleep(10 * time.Millisecond)
+	if err := c.Store.MarkCompleted("bd-1"); err != nil {
+		t.Fatal(err)
+	}
+	events = c.Tick(ctx)
+	if len(events) != 1 || events[0].Review == nil || events[0].Review.Round != 2 {
+		t.Fatalf("second round events = %+v", events)
+	}
+	if !strings.Contains(sent["%3"][1], "Round 1 requested these changes") {
+		t.Errorf("round 2 prompt = %s", sent["%3"][1])
+	}
+	r2 := events[0].Review
+	if r2.Base != r.Base {
+		t.Errorf("round 2 base = %s, want round 1 base %s", r2.Base, r.Base)
+	}
+	if err := os.WriteFile(r2.ResultPath, []byte(`{"verdict": "approve", "summary": "good"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	events = c.Tick(ctx)
+	if len(events) != 1 || events[0].Review.Status != assignment.ReviewApproved {
+		t.Fatalf("approval events = %+v", events)
+	}
+	if len(sent["%1"]) != 1 {
+		t.Errorf("approval without comments should not interrupt the author: %v", sent["%1"])
+	}
+}
+
+func TestCoordinatorExpiresAndSkipsBusyReviewers(t *testing.T) {
+	c, _ := reviewFixture(t)
+	c.Config.ReviewerTypes = []string{"gmi"}
+	c.Config.Timeout = time.Millisecond
+	ctx := context.Background()
+
+	r, err := c.Request(ctx, "bd-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.ReviewerPane != 2 {
+		t.Errorf("reviewer pane = %d, want the configured gemini pane", r.ReviewerPane)
+	}
+	// The only gemini pane is now reviewing
+	if _, err := c.pickReviewer(c.Store.Get("bd-1"), c.reviewerTypes(tmux.AgentClaude), true); err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := c.pickReviewer(c.Store.Get("bd-1"), c.reviewerTypes(tmux.AgentClaude), true); p != nil {
+		t.Errorf("picked busy reviewer %+v", p)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	c.Config.Auto = false
+	events := c.Tick(ctx)
+	if len(events) != 1 || events[0].Review.Status != assignment.ReviewExpired {
+		t.Fatalf("expire events = %+v", events)
+	}
+	if !c.needsReview(c.Store.Get("bd-1")) {
+		t.Error("an expired review should be retried")
+	}
+}
+
+func TestMergeGate(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	store := assignment.NewStore("gate-test")
+	if _, err := store.Assign("bd-1", "", 1, "claude", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	_ = store.MarkWorking("bd-1")
+
+	if err := MergeGate(store, "cc_1", "abc"); err == nil {
+		t.Error("expected an unreviewed worktree to be blocked")
+	}
+	if _, err := store.StartReview("bd-1", assignment.Review{ID: "r1", Worktree: "cc_1", Head: "abc"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := MergeGate(store, "cc_1", "abc"); err == nil || !strings.Contains(err.Error(), "pending") {
+		t.Errorf("pending review: %v", err)
+	}
+	if err := store.FinishReview("bd-1", "r1", assignment.ReviewApproved, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := MergeGate(store, "cc_1", "abc"); err != nil {
+		t.Errorf("approved review blocked: %v", err)
+	}
+	if err := MergeGate(store, "cc_1", "def"); err == nil {
+		t.Error("expected commits after approval to be blocked")
+	}
+	if err := MergeGate(store, "cod_1", ""); err == nil {
+		t.Error("another worktree's approval should not count")
+	}
+}
+
+func TestWorktreeName(t *testing.T) {
+	tests := map[string]string{
+		"/p/.ntm/worktrees/cc_1":     "cc_1",
+		"/p/.ntm/worktrees/cc_1/pkg": "cc_1",
+		"/p":                         "",
+		"/elsewhere":                 "",
+	}
+	for dir, want := range tests {
+		if got := worktreeName("/p", dir); got != want {
+			t.Errorf("worktreeName(%q) = %q, want %q", dir, got, want)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package review
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// emptyTree is git's well-known empty tree object, the base for work on a
+// repository without earlier commits.
+const emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// excludeNTM keeps ntm's own files (worktrees, review requests) out of diffs.
+const excludeNTM = ":(exclude).ntm"
+
+// Diff is an author's work packaged for review: the working tree in Dir
+// against Base, committed or not.
+type Diff struct {
+	Dir        string   `json:"dir"`
+	Base       string   `json:"base"`
+	Head       string   `json:"head"`
+	Files      []string `json:"files"`
+	Insertions int      `json:"insertions"`
+	Deletions  int      `json:"deletions"`
+	Patch      string   `json:"-"`
+}
+
+// Empty reports whether the diff changes nothing.
+func (d *Diff) Empty() bool {
+	return len(d.Files) == 0
+}
+
+// Stat summarizes the diff in one line.
+func (d *Diff) Stat() string {
+	return fmt.Sprintf("%d files changed, +%d -%d", len(d.Files), d.Insertions, d.Deletions)
+}
+
+// BaseBefore returns the last commit in dir's history made before t: the
+// state the work started from. It returns the empty tree when every commit
+// is newer.
+func BaseBefore(ctx context.Context, dir string, t time.Time) (string, error) {
+	out, err := git(ctx, dir, "rev-list", "-1", "--before="+t.UTC().Format(time.RFC3339), "HEAD")
+	if err != nil {
+		return "", err
+	}
+	if out == "" {
+		return emptyTree, nil
+	}
+	return out, nil
+}
+
+// PackageDiff packages the changes in dir's working tree against base.
+func PackageDiff(ctx context.Context, dir, base string) (*Diff, error) {
+	dir, err := git(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	head, err := git(ctx, dir, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	d := &Diff{Dir: dir, Base: base, Head: head}
+
+	numstat, err := git(ctx, dir, "diff", "--numstat", base, "--", ".", excludeNTM)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(numstat, "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		// Binary files show "-" for both counts
+		add, _ := strconv.Atoi(fields[0])
+		del, _ := strconv.Atoi(fields[1])
+		d.Insertions += add
+		d.Deletions += del
+		d.Files = append(d.Files, fields[2])
+	}
+
+	if d.Patch, err = git(ctx, dir, "diff", base, "--", ".", excludeNTM); err != nil {
+		return nil, err
+	}
+
+	// New files the author did not add to the index
+	untracked, err := git(ctx, dir, "ls-files", "--others", "--exclude-standard", "--", ".", excludeNTM)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range strings.Split(untracked, "\n") {
+		if file == "" {
+			continue
+		}
+		patch := untrackedPatch(ctx, dir, file)
+		if patch == "" {
+			continue
+		}
+		d.Files = append(d.Files, file)
+		d.Insertions += strings.Count(patch, "\n+") - 1 // Less the "+++" header
+		if d.Patch != "" {
+			d.Patch += "\n"
+		}
+		d.Patch += patch
+	}
+	return d, nil
+}
+
+// untrackedPatch returns the patch adding an untracked file.
+func untrackedPatch(ctx context.Context, dir, file string) string {
+	cmd := exec.CommandContext(ctx, "git", "diff", "--no-index", "--", "/dev/null", file)
+	cmd.Dir = dir
+	// Exit status 1 means the files differ, which is expected
+	out, _ := cmd.Output()
+	return strings.TrimRight(string(out), "\n")
+}
+
+// BranchHead returns the commit branch points to in the repository at dir.
+func BranchHead(ctx context.Context, dir, branch string) (string, error) {
+	return git(ctx, dir, "rev-parse", "--verify", branch+"^{commit}")
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		if ee, ok := err.(*exec.ExitError); ok && len(ee.Stderr) > 0 {
+			return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(ee.Stderr)))
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimRight(string(out), "\n"), nil
+}
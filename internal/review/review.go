@@ -0,0 +1,202 @@
+// Package review runs cross-agent code review. When an agent's assignment
+// completes, its diff is packaged and handed to an agent of another type,
+// which answers with structured comments through a JSON file; the comments
+// are fed back to the author and each round's verdict is recorded on the
+// assignment.
+package review
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shahbajlive/ntm/internal/assignment"
+)
+
+// Comment severities
+const (
+	SeverityBlocker = "blocker" // Must be fixed before merging
+	SeverityMajor   = "major"   // Should be fixed
+	SeverityMinor   = "minor"   // Worth fixing
+	SeverityNit     = "nit"     // Style or taste
+)
+
+// Verdicts a reviewer can give
+const (
+	VerdictApprove          = "approve"
+	VerdictChangesRequested = "changes_requested"
+)
+
+var severityRank = map[string]int{SeverityBlocker: 0, SeverityMajor: 1, SeverityMinor: 2, SeverityNit: 3}
+
+// Result is the JSON document a reviewer writes:
+//
+//	{
+//	  "verdict": "approve" | "changes_requested",
+//	  "summary": "one paragraph",
+//	  "comments": [
+//	    {"file": "internal/x.go", "line": 42, "severity": "major",
+//	     "message": "what is wrong", "suggestion": "how to fix it"}
+//	  ]
+//	}
+type Result struct {
+	Verdict  string                     `json:"verdict"`
+	Summary  string                     `json:"summary"`
+	Comments []assignment.ReviewComment `json:"comments"`
+}
+
+// ParseResult decodes and validates a reviewer's result. Text around the
+// JSON object, such as a Markdown code fence, is ignored.
+func ParseResult(data []byte) (*Result, error) {
+	s := string(data)
+	start, end := strings.Index(s, "{"), strings.LastIndex(s, "}")
+	if start < 0 || end < start {
+		return nil, errors.New("no JSON object in review result")
+	}
+	var r Result
+	if err := json.Unmarshal([]byte(s[start:end+1]), &r); err != nil {
+		return nil, fmt.Errorf("parse review result: %w", err)
+	}
+	if err := r.normalize(); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// normalize validates r, lower-cases verdict and severities, drops empty
+// comments and sorts the rest by severity. Blocking comments turn an
+// approval into requested changes.
+func (r *Result) normalize() error {
+	r.Verdict = strings.ToLower(strings.TrimSpace(r.Verdict))
+	switch r.Verdict {
+	case VerdictApprove, VerdictChangesRequested:
+	case "approved", "lgtm":
+		r.Verdict = VerdictApprove
+	case "request_changes", "changes":
+		r.Verdict = VerdictChangesRequested
+	default:
+		return fmt.Errorf("review verdict %q is not %q or %q", r.Verdict, VerdictApprove, VerdictChangesRequested)
+	}
+
+	comments := r.Comments[:0]
+	for _, c := range r.Comments {
+		c.Message = strings.TrimSpace(c.Message)
+		if c.Message == "" && c.Suggestion == "" {
+			continue
+		}
+		c.Severity = strings.ToLower(strings.TrimSpace(c.Severity))
+		if _, ok := severityRank[c.Severity]; !ok {
+			c.Severity = SeverityMinor
+		}
+		comments = append(comments, c)
+	}
+	sort.SliceStable(comments, func(i, j int) bool {
+		return severityRank[comments[i].Severity] < severityRank[comments[j].Severity]
+	})
+	r.Comments = comments
+
+	if r.Verdict == VerdictApprove && r.Blocking() > 0 {
+		r.Verdict = VerdictChangesRequested
+	}
+	return nil
+}
+
+// Blocking returns the number of blocker comments.
+func (r *Result) Blocking() int {
+	n := 0
+	for _, c := range r.Comments {
+		if c.Severity == SeverityBlocker {
+			n++
+		}
+	}
+	return n
+}
+
+// Status returns the review status the verdict records.
+func (r *Result) Status() assignment.ReviewStatus {
+	if r.Verdict == VerdictApprove {
+		return assignment.ReviewApproved
+	}
+	return assignment.ReviewChangesRequested
+}
+
+// Request describes the work a reviewer is asked to look at.
+type Request struct {
+	BeadID     string
+	BeadTitle  string
+	AuthorType string
+	Round      int
+	Diff       *Diff
+	PatchPath  string
+	ResultPath string
+	Previous   *assignment.Review // Earlier round whose comments should be addressed
+}
+
+// Prompt returns the message sent to the reviewer agent.
+func (req Request) Prompt() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Code review request (round %d) for %s", req.Round, req.BeadID)
+	if req.BeadTitle != "" {
+		fmt.Fprintf(&b, ": %s", req.BeadTitle)
+	}
+	b.WriteString("\n\n")
+	fmt.Fprintf(&b, "Another agent (%s) finished this work. Review it; do not edit any files.\n", req.AuthorType)
+	fmt.Fprintf(&b, "- Diff against %s: %s\n", shortSHA(req.Diff.Base), req.PatchPath)
+	fmt.Fprintf(&b, "- Working tree: %s\n", req.Diff.Dir)
+	fmt.Fprintf(&b, "- %s\n", req.Diff.Stat())
+	if req.Previous != nil && len(req.Previous.Comments) > 0 {
+		fmt.Fprintf(&b, "\nRound %d requested these changes; check they were addressed:\n", req.Previous.Round)
+		writeComments(&b, req.Previous.Comments)
+	}
+	b.WriteString("\nWrite your review as JSON to " + req.ResultPath + ":\n")
+	b.WriteString(`{"verdict": "approve" or "changes_requested", "summary": "...", "comments": [{"file": "path", "line": 42, "severity": "blocker|major|minor|nit", "message": "...", "suggestion": "..."}]}`)
+	b.WriteString("\nRequest changes for anything that must be fixed before merging; blocker comments always do.\n")
+	return b.String()
+}
+
+// FeedbackMessage returns the message sent to the author with a round's
+// outcome.
+func FeedbackMessage(beadID string, r *assignment.Review) string {
+	var b strings.Builder
+	switch r.Status {
+	case assignment.ReviewApproved:
+		fmt.Fprintf(&b, "Review round %d of %s approved by %s.", r.Round, beadID, r.ReviewerType)
+		if len(r.Comments) > 0 {
+			b.WriteString(" Optional follow-ups:")
+		}
+	default:
+		fmt.Fprintf(&b, "Review round %d of %s: %s requested changes.", r.Round, beadID, r.ReviewerType)
+		b.WriteString(" The work is not accepted yet. Address the comments below, then report completion again.")
+	}
+	b.WriteString("\n")
+	if r.Summary != "" {
+		b.WriteString("\n" + r.Summary + "\n")
+	}
+	if len(r.Comments) > 0 {
+		b.WriteString("\n")
+		writeComments(&b, r.Comments)
+	}
+	return b.String()
+}
+
+func writeComments(b *strings.Builder, comments []assignment.ReviewComment) {
+	for _, c := range comments {
+		loc := c.File
+		if c.Line > 0 {
+			loc = fmt.Sprintf("%s:%d", c.File, c.Line)
+		}
+		fmt.Fprintf(b, "- [%s] %s: %s\n", c.Severity, loc, c.Message)
+		if c.Suggestion != "" {
+			fmt.Fprintf(b, "  Suggestion: %s\n", c.Suggestion)
+		}
+	}
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
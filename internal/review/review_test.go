@@ -0,0 +1,65 @@
+package review
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shahbajlive/ntm/internal/assignment"
+)
+
+func TestParseResult(t *testing.T) {
+	data := "Here is my review:\n```json\n" + `{
+  "verdict": "Approved",
+  "summary": "Looks fine apart from the nil check.",
+  "comments": [
+    {"file": "a.go", "line": 3, "severity": "nit", "message": "rename x"},
+    {"file": "b.go", "line": 10, "severity": "BLOCKER", "message": "nil deref", "suggestion": "check err first"},
+    {"file": "c.go", "severity": "weird", "message": "unclear"},
+    {"file": "d.go", "message": "  "}
+  ]
+}` + "\n```\n"
+
+	r, err := ParseResult([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseResult() error: %v", err)
+	}
+	if r.Verdict != VerdictChangesRequested || r.Status() != assignment.ReviewChangesRequested {
+		t.Errorf("verdict = %q; a blocker should turn approval into requested changes", r.Verdict)
+	}
+	if len(r.Comments) != 3 || r.Comments[0].File != "b.go" || r.Comments[1].Severity != SeverityMinor {
+		t.Errorf("comments = %+v", r.Comments)
+	}
+	if r.Blocking() != 1 {
+		t.Errorf("Blocking() = %d", r.Blocking())
+	}
+
+	if _, err := ParseResult([]byte(`{"verdict": "maybe"}`)); err == nil {
+		t.Error("expected error for unknown verdict")
+	}
+	if _, err := ParseResult([]byte("no json here")); err == nil {
+		t.Error("expected error without a JSON object")
+	}
+}
+
+func TestFeedbackMessage(t *testing.T) {
+	r := &assignment.Review{
+		Round:        2,
+		ReviewerType: "cod",
+		Status:       assignment.ReviewChangesRequested,
+		Summary:      "One real bug.",
+		Comments: []assignment.ReviewComment{
+			{File: "b.go", Line: 10, Severity: SeverityBlocker, Message: "nil deref", Suggestion: "check err first"},
+		},
+	}
+	msg := FeedbackMessage("bd-7", r)
+	for _, want := range []string{"round 2 of bd-7", "requested changes", "One real bug.", "[blocker] b.go:10: nil deref", "Suggestion: check err first"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message missing %q:\n%s", want, msg)
+		}
+	}
+
+	r.Status, r.Comments = assignment.ReviewApproved, nil
+	if msg := FeedbackMessage("bd-7", r); !strings.Contains(msg, "approved by cod") || strings.Contains(msg, "follow-ups") {
+		t.Errorf("approval message = %q", msg)
+	}
+}
@@ -0,0 +1,48 @@
+package robot
+
+import (
+	"github.com/shahbajlive/ntm/internal/config"
+	"github.com/shahbajlive/ntm/internal/cost"
+	"github.com/shahbajlive/ntm/internal/state"
+)
+
+// appendBudgets adds each session's spend budget status to status output and
+// raises an alert for every budget past a threshold. Best-effort: without
+// configured budgets or a state store, status carries no budget data.
+func appendBudgets(output *StatusOutput, cfg *config.Config) {
+	budgets := cfg.Budgets.Budgets()
+	if len(budgets) == 0 || len(output.Sessions) == 0 {
+		return
+	}
+	store, err := state.Open("")
+	if err != nil {
+		return
+	}
+	defer store.Close()
+	if err := store.Migrate(); err != nil {
+		return
+	}
+	ss := state.NewSpendStore(store)
+
+	for i := range output.Sessions {
+		info := &output.Sessions[i]
+		statuses, err := cost.Evaluate(budgets, info.Name, nil, ss.BudgetSpend)
+		if err != nil {
+			return
+		}
+		info.Budgets = statuses
+		for _, s := range cost.Exceeded(statuses, cost.LevelSoft) {
+			severity := "warning"
+			if s.Level == cost.LevelHard {
+				severity = "critical"
+			}
+			output.Alerts = append(output.Alerts, StatusAlert{
+				Type:         "budget_" + string(s.Level),
+				Session:      info.Name,
+				Budget:       s.Name,
+				UsagePercent: s.Percent,
+				Severity:     severity,
+			})
+		}
+	}
+}
@@ -93,7 +93,7 @@ func buildCommandRegistry() []RobotCommandInfo {
 			Name:        "status",
 			Flag:        "--robot-status",
 			Category:    "state",
-			Description: "Get tmux sessions, panes, and agent states. The primary entry point for understanding current system state.",
+			Description: "Get tmux sessions, panes, agent states, and spend budget status. The primary entry point for understanding current system state.",
 			Parameters: []RobotParameter{
 				{Name: "robot-limit", Flag: "--robot-limit", Type: "int", Required: false, Default: "0", Description: "Max sessions to return (alias: --limit)"},
 				{Name: "robot-offset", Flag: "--robot-offset", Type: "int", Required: false, Default: "0", Description: "Pagination offset for sessions (alias: --offset)"},
@@ -222,8 +222,9 @@ func buildCommandRegistry() []RobotCommandInfo {
 				{Name: "inspect-index", Flag: "--inspect-index", Type: "int", Required: false, Default: "0", Description: "Pane index to inspect"},
 				{Name: "inspect-lines", Flag: "--inspect-lines", Type: "int", Required: false, Default: "100", Description: "Lines to capture"},
 				{Name: "inspect-code", Flag: "--inspect-code", Type: "bool", Required: false, Description: "Parse code blocks from output"},
+				{Name: "segments", Flag: "--segments", Type: "bool", Required: false, Description: "Segment output into prompts, prose, tool calls, results, diffs and errors, with the latest of each"},
 			},
-			Examples: []string{"ntm --robot-inspect-pane=myproject --inspect-index=1 --inspect-code"},
+			Examples: []string{"ntm --robot-inspect-pane=myproject --inspect-index=1 --inspect-code", "ntm --robot-inspect-pane=myproject --inspect-index=1 --segments"},
 		},
 		{
 			Name:        "files",
@@ -1161,6 +1162,53 @@ func buildCommandRegistry() []RobotCommandInfo {
 			},
 			Examples: []string{"ntm --robot-history=myproject --history-last=10"},
 		},
+		{
+			Name:        "transcript",
+			Flag:        "--robot-transcript",
+			Category:    "utility",
+			Description: "Ingest Claude Code, Codex and Gemini transcripts for a session and return recent turns, tool calls and edited files.",
+			Parameters: []RobotParameter{
+				{Name: "session", Flag: "--robot-transcript", Type: "string", Required: true, Description: "Session name"},
+				{Name: "transcript-pane", Flag: "--transcript-pane", Type: "int", Required: false, Default: "-1", Description: "Filter by pane index"},
+				{Name: "transcript-bead", Flag: "--transcript-bead", Type: "string", Required: false, Description: "Filter by bead ID"},
+				{Name: "transcript-text", Flag: "--transcript-text", Type: "string", Required: false, Description: "Turn text or tool input/output contains"},
+				{Name: "transcript-tool", Flag: "--transcript-tool", Type: "string", Required: false, Description: "Turn called this tool"},
+				{Name: "transcript-file", Flag: "--transcript-file", Type: "string", Required: false, Description: "Turn edited a path ending in this"},
+				{Name: "transcript-since", Flag: "--transcript-since", Type: "string", Required: false, Description: "Show turns since time"},
+				{Name: "transcript-limit", Flag: "--transcript-limit", Type: "int", Required: false, Default: "50", Description: "Maximum turns to return"},
+				{Name: "transcript-no-ingest", Flag: "--transcript-no-ingest", Type: "bool", Required: false, Description: "Skip scanning for new transcripts"},
+			},
+			Examples: []string{"ntm --robot-transcript=myproject --transcript-pane=2 --transcript-tool=Edit"},
+		},
+		{
+			Name:        "queue",
+			Flag:        "--robot-queue",
+			Category:    "utility",
+			Description: "List prompts waiting for idle panes, and queue or remove one. Queued prompts are delivered by the session monitor when their pane goes idle.",
+			Parameters: []RobotParameter{
+				{Name: "session", Flag: "--robot-queue", Type: "string", Required: true, Description: "Session name"},
+				{Name: "queue-status", Flag: "--queue-status", Type: "string", Required: false, Description: "Status to list (pending, delivered, failed, expired, all)"},
+				{Name: "queue-add", Flag: "--queue-add", Type: "string", Required: false, Description: "Prompt to queue"},
+				{Name: "queue-pane", Flag: "--queue-pane", Type: "int", Required: false, Default: "-1", Description: "Pane index the prompt is for"},
+				{Name: "queue-agent", Flag: "--queue-agent", Type: "string", Required: false, Description: "Agent type whose first idle pane takes the prompt"},
+				{Name: "queue-priority", Flag: "--queue-priority", Type: "int", Required: false, Default: "2", Description: "Priority, 0 (first) to 4 (last)"},
+				{Name: "queue-dedup", Flag: "--queue-dedup", Type: "string", Required: false, Description: "Skip if a waiting prompt for the target has this key"},
+				{Name: "queue-ttl", Flag: "--queue-ttl", Type: "string", Required: false, Description: "Expire the prompt if undelivered after this long"},
+				{Name: "queue-remove", Flag: "--queue-remove", Type: "int", Required: false, Description: "ID of a queued prompt to remove"},
+			},
+			Examples: []string{"ntm --robot-queue=myproject --queue-add='run the tests' --queue-agent=cc --queue-priority=1"},
+		},
+		{
+			Name:        "schedule",
+			Flag:        "--robot-schedule",
+			Category:    "utility",
+			Description: "List a session's scheduled jobs (sends, checkpoints, pipelines, scans, handoffs) with their next firing and recent runs.",
+			Parameters: []RobotParameter{
+				{Name: "session", Flag: "--robot-schedule", Type: "string", Required: true, Description: "Session name"},
+				{Name: "schedule-runs", Flag: "--schedule-runs", Type: "int", Required: false, Default: "5", Description: "Recent runs to include per schedule"},
+			},
+			Examples: []string{"ntm --robot-schedule=myproject --schedule-runs=10"},
+		},
 		{
 			Name:        "replay",
 			Flag:        "--robot-replay",
@@ -333,14 +333,12 @@ func PrintEnv(session string) error {
 
 // detectSessionStructure detects session window/pane structure
 func detectSessionStructure(session string) (*SessionStructureInfo, error) {
-	tmuxPath := tmux.BinaryPath()
-
 	// Determine primary window (prefer window 1 if present)
-	windowOut, err := exec.Command(tmuxPath, "list-windows", "-t", session, "-F", "#{window_index}").Output()
+	windowOut, err := tmux.DefaultClient.Run("list-windows", "-t", session, "-F", "#{window_index}")
 	if err != nil {
 		return nil, err
 	}
-	windowLines := strings.Split(strings.TrimSpace(string(windowOut)), "\n")
+	windowLines := strings.Split(strings.TrimSpace(windowOut), "\n")
 	if len(windowLines) == 0 || (len(windowLines) == 1 && strings.TrimSpace(windowLines[0]) == "") {
 		return nil, fmt.Errorf("no windows found")
 	}
@@ -363,12 +361,12 @@ func detectSessionStructure(session string) (*SessionStructureInfo, error) {
 	target := fmt.Sprintf("%s:%d", session, primaryWindow)
 
 	// Get pane indices from primary window
-	out, err := exec.Command(tmuxPath, "list-panes", "-t", target, "-F", "#{pane_index}").Output()
+	out, err := tmux.DefaultClient.Run("list-panes", "-t", target, "-F", "#{pane_index}")
 	if err != nil {
 		return nil, err
 	}
 
-	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
 	if len(lines) == 0 || (len(lines) == 1 && strings.TrimSpace(lines[0]) == "") {
 		return nil, fmt.Errorf("no panes found")
 	}
@@ -120,15 +120,8 @@ func formatTarget(session string, pane int) string {
 
 // runTmuxCommand executes a tmux command.
 func runTmuxCommand(args ...string) error {
-	cmd := exec.Command(tmux.BinaryPath(), args...)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		if len(output) > 0 {
-			return wrapError(string(output), err)
-		}
-		return err
-	}
-	return nil
+	_, err := tmux.DefaultClient.Run(args...)
+	return err
 }
 
 // =============================================================================
@@ -185,14 +178,13 @@ func hardKillAgent(session string, pane int, seq *RestartSequence) (*HardKillRes
 func getShellPID(session string, pane int) (int, error) {
 	// Use window 1 format (NTM uses window 1 for agents)
 	target := session + ":1"
-	cmd := exec.Command(tmux.BinaryPath(), "list-panes", "-t", target, "-F", "#{pane_index} #{pane_pid}")
-	output, err := cmd.Output()
+	output, err := tmux.DefaultClient.Run("list-panes", "-t", target, "-F", "#{pane_index} #{pane_pid}")
 	if err != nil {
 		return 0, wrapError("tmux list-panes failed", err)
 	}
 
 	// Parse output to find our pane
-	lines := splitLines(string(output))
+	lines := splitLines(output)
 	for _, line := range lines {
 		parts := splitBySpace(line)
 		if len(parts) >= 2 {
@@ -0,0 +1,189 @@
+package robot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/state"
+	"github.com/shahbajlive/ntm/internal/tmux"
+	"github.com/shahbajlive/ntm/internal/util"
+)
+
+// QueueOptions configures --robot-queue.
+type QueueOptions struct {
+	Session string
+	Status  string // Status filter for the listing; empty lists waiting prompts
+
+	// Enqueue: Add is the prompt; it targets Pane, or the first idle pane of
+	// Agent when Pane is unset.
+	Add      string
+	Pane     *int
+	Agent    string
+	Priority int
+	Dedup    string
+	TTL      string // Duration (e.g. "30m")
+
+	Remove int64 // Prompt ID to remove (0 = none)
+}
+
+// QueueOutput is the structured output for --robot-queue.
+type QueueOutput struct {
+	RobotResponse
+	Session     string               `json:"session"`
+	GeneratedAt time.Time            `json:"generated_at"`
+	Added       *state.QueuedPrompt  `json:"added,omitempty"`
+	Duplicate   bool                 `json:"duplicate,omitempty"` // Added is an existing prompt with the same dedup key
+	Removed     int64                `json:"removed,omitempty"`
+	Prompts     []state.QueuedPrompt `json:"prompts"`
+	Depth       state.QueueDepth     `json:"depth"`
+	AgentHints  *QueueAgentHints     `json:"_agent_hints,omitempty"`
+}
+
+// QueueAgentHints provides actionable suggestions for AI agents.
+type QueueAgentHints struct {
+	Summary           string   `json:"summary,omitempty"`
+	SuggestedCommands []string `json:"suggested_commands,omitempty"`
+}
+
+// GetQueue lists a session's prompt queue, optionally adding or removing a
+// prompt first. Queued prompts are delivered by the session monitor when
+// their pane goes idle.
+// This function returns the data struct directly, enabling CLI/REST parity.
+func GetQueue(opts QueueOptions) (*QueueOutput, error) {
+	out := &QueueOutput{
+		RobotResponse: NewRobotResponse(true),
+		Session:       opts.Session,
+		GeneratedAt:   time.Now().UTC(),
+		Prompts:       []state.QueuedPrompt{},
+	}
+	if opts.Session == "" {
+		out.RobotResponse = NewErrorResponse(
+			fmt.Errorf("session name is required"),
+			ErrCodeInvalidFlag,
+			"Provide session name: ntm --robot-queue=myproject",
+		)
+		return out, nil
+	}
+
+	store, err := state.Open("")
+	if err != nil {
+		out.RobotResponse = NewErrorResponse(err, ErrCodeInternalError, "Check ~/.config/ntm/state.db permissions")
+		return out, nil
+	}
+	defer store.Close()
+	if err := store.Migrate(); err != nil {
+		out.RobotResponse = NewErrorResponse(err, ErrCodeInternalError, "State database migration failed")
+		return out, nil
+	}
+	qs := state.NewPromptQueueStore(store)
+
+	if opts.Remove > 0 {
+		ok, err := qs.Remove(opts.Remove)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			out.RobotResponse = NewErrorResponse(
+				fmt.Errorf("queued prompt %d not found", opts.Remove),
+				ErrCodeInvalidFlag,
+				fmt.Sprintf("List the queue: ntm --robot-queue=%s", opts.Session),
+			)
+			return out, nil
+		}
+		out.Removed = opts.Remove
+	}
+
+	if strings.TrimSpace(opts.Add) != "" {
+		q, errResp := buildQueuedPrompt(opts)
+		if errResp != nil {
+			out.RobotResponse = *errResp
+			return out, nil
+		}
+		added, created, err := qs.Enqueue(q)
+		if err != nil {
+			out.RobotResponse = NewErrorResponse(err, ErrCodeInvalidFlag, "Check --queue-add, --queue-pane and --queue-agent")
+			return out, nil
+		}
+		out.Added, out.Duplicate = added, !created
+	}
+
+	prompts, err := qs.List(state.PromptQueueFilter{SessionName: opts.Session, Status: opts.Status})
+	if err != nil {
+		return nil, err
+	}
+	if prompts != nil {
+		out.Prompts = prompts
+	}
+	if out.Depth, err = qs.Depth(opts.Session); err != nil {
+		return nil, err
+	}
+
+	waiting := 0
+	for _, q := range out.Prompts {
+		if q.Status == state.QueueStatusPending {
+			waiting++
+		}
+	}
+	out.AgentHints = &QueueAgentHints{Summary: fmt.Sprintf("%d prompt(s) waiting for idle panes", waiting)}
+	if waiting > 0 {
+		out.AgentHints.SuggestedCommands = []string{fmt.Sprintf("ntm queue dispatch %s", opts.Session)}
+	}
+	return out, nil
+}
+
+func buildQueuedPrompt(opts QueueOptions) (*state.QueuedPrompt, *RobotResponse) {
+	invalid := func(err error, hint string) (*state.QueuedPrompt, *RobotResponse) {
+		resp := NewErrorResponse(err, ErrCodeInvalidFlag, hint)
+		return nil, &resp
+	}
+	if opts.Priority < 0 || opts.Priority > 4 {
+		return invalid(fmt.Errorf("--queue-priority must be between 0 and 4"), "Use 0 (first) to 4 (last)")
+	}
+	q := &state.QueuedPrompt{
+		SessionName: opts.Session,
+		Prompt:      opts.Add,
+		Priority:    opts.Priority,
+		DedupKey:    opts.Dedup,
+		Source:      "robot",
+	}
+	if opts.TTL != "" {
+		ttl, err := util.ParseDuration(opts.TTL)
+		if err != nil {
+			return invalid(fmt.Errorf("invalid --queue-ttl: %w", err), "Use a duration such as 30m or 2h")
+		}
+		expires := time.Now().Add(ttl).UTC()
+		q.ExpiresAt = &expires
+	}
+
+	if opts.Pane == nil {
+		if opts.Agent == "" {
+			return invalid(fmt.Errorf("--queue-add needs --queue-pane or --queue-agent"),
+				"Target a pane index, or an agent type (cc, cod, gmi) for its first idle pane")
+		}
+		q.AgentType = opts.Agent
+		return q, nil
+	}
+	panes, err := tmux.GetPanes(opts.Session)
+	if err != nil {
+		return invalid(err, "Check the session exists: ntm --robot-status")
+	}
+	for _, p := range panes {
+		if p.Index == *opts.Pane {
+			idx := p.Index
+			q.PaneID, q.PaneIndex, q.AgentType = p.ID, &idx, string(p.Type)
+			return q, nil
+		}
+	}
+	return invalid(fmt.Errorf("pane %d not found in session %s", *opts.Pane, opts.Session),
+		"List panes: ntm --robot-status")
+}
+
+// PrintQueue outputs a session's prompt queue as JSON.
+func PrintQueue(opts QueueOptions) error {
+	out, err := GetQueue(opts)
+	if err != nil {
+		return err
+	}
+	return encodeJSON(out)
+}
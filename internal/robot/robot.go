@@ -9,21 +9,26 @@ import (
 	"os"
 	"os/exec"
 	"runtime"
+	"slices"
 	"sort"
 	"strings"
 	"time"
 
+	"github.com/shahbajlive/ntm/internal/agent"
 	"github.com/shahbajlive/ntm/internal/agentmail"
 	"github.com/shahbajlive/ntm/internal/alerts"
+	"github.com/shahbajlive/ntm/internal/budget"
 	"github.com/shahbajlive/ntm/internal/bv"
 	"github.com/shahbajlive/ntm/internal/cass"
 	"github.com/shahbajlive/ntm/internal/config"
 	ntmctx "github.com/shahbajlive/ntm/internal/context"
+	"github.com/shahbajlive/ntm/internal/cost"
 	"github.com/shahbajlive/ntm/internal/git"
 	"github.com/shahbajlive/ntm/internal/handoff"
 	"github.com/shahbajlive/ntm/internal/health"
 	"github.com/shahbajlive/ntm/internal/recipe"
 	"github.com/shahbajlive/ntm/internal/redaction"
+	"github.com/shahbajlive/ntm/internal/session"
 	"github.com/shahbajlive/ntm/internal/status"
 	swarmlib "github.com/shahbajlive/ntm/internal/swarm"
 	"github.com/shahbajlive/ntm/internal/tmux"
@@ -1504,14 +1509,15 @@ var stateTracker = tracker.New()
 
 // SessionInfo contains machine-readable session information
 type SessionInfo struct {
-	Name        string     `json:"name"`
-	Exists      bool       `json:"exists"`
-	Attached    bool       `json:"attached,omitempty"`
-	Windows     int        `json:"windows,omitempty"`
-	Panes       int        `json:"panes,omitempty"`
-	CreatedAt   *time.Time `json:"created_at,omitempty"`
-	Agents      []Agent    `json:"agents,omitempty"`
-	PrivacyMode bool       `json:"privacy_mode,omitempty"` // True if privacy mode is enabled
+	Name        string              `json:"name"`
+	Exists      bool                `json:"exists"`
+	Attached    bool                `json:"attached,omitempty"`
+	Windows     int                 `json:"windows,omitempty"`
+	Panes       int                 `json:"panes,omitempty"`
+	CreatedAt   *time.Time          `json:"created_at,omitempty"`
+	Agents      []Agent             `json:"agents,omitempty"`
+	PrivacyMode bool                `json:"privacy_mode,omitempty"` // True if privacy mode is enabled
+	Budgets     []cost.BudgetStatus `json:"budgets,omitempty"`      // Spend against configured budgets
 }
 
 // Agent represents an AI agent in a session
@@ -1539,6 +1545,8 @@ type Agent struct {
 	ContextLimit         int       `json:"context_limit,omitempty"`           // Model context limit
 	ContextPercent       float64   `json:"context_percent,omitempty"`         // Usage percentage (0-100+)
 	ContextModel         string    `json:"context_model,omitempty"`           // Model name for context limit lookup
+
+	PermissionPrompt *agent.PermissionRequest `json:"permission_prompt,omitempty"` // Tool-permission prompt the agent is waiting on
 }
 
 // SystemInfo contains system and runtime information
@@ -1659,6 +1667,8 @@ type StatusAlert struct {
 	PaneIdx      int     `json:"pane_idx,omitempty"`
 	UsagePercent float64 `json:"usage_percent,omitempty"`
 	ContextModel string  `json:"context_model,omitempty"`
+	Budget       string  `json:"budget,omitempty"`     // For budget_soft and budget_hard alerts
+	RequestID    string  `json:"request_id,omitempty"` // For permission_prompt alerts
 	Severity     string  `json:"severity,omitempty"`
 }
 
@@ -1840,6 +1850,9 @@ Tool Bridges:
 --robot-slb-deny=ID          Deny SLB request by ID (--reason="...")
 --robot-tokens               Token usage stats (--days=30, --group-by=agent)
 --robot-history=SESSION      Command history (--last=10)
+--robot-transcript=SESSION   Agent transcript turns (--transcript-file=PATH)
+--robot-queue=SESSION        Prompts waiting for idle panes (--queue-add="...")
+--robot-schedule=SESSION     Scheduled jobs and recent runs (--schedule-runs=5)
 
 Bead Management:
 ----------------
@@ -1986,6 +1999,17 @@ func GetStatusWithOptions(opts PaginationOptions) (*StatusOutput, error) {
 					})
 				}
 
+				if agent.PermissionPrompt != nil {
+					output.Alerts = append(output.Alerts, StatusAlert{
+						Type:      "permission_prompt",
+						Session:   sess.Name,
+						Pane:      pane.ID,
+						PaneIdx:   pane.Index,
+						RequestID: agent.PermissionPrompt.ID,
+						Severity:  "warning",
+					})
+				}
+
 				info.Agents = append(info.Agents, agent)
 
 				// Update summary counts
@@ -2044,6 +2068,7 @@ func GetStatusWithOptions(opts PaginationOptions) (*StatusOutput, error) {
 	// Include recent file changes (best-effort, bounded).
 	appendFileChanges(output)
 	appendConflicts(output)
+	appendBudgets(output, cfg)
 
 	if paged, page := ApplyPagination(output.Sessions, opts); page != nil {
 		output.Sessions = paged
@@ -4150,6 +4175,8 @@ func summarizeSendRedactionResult(result redaction.Result) RedactionSummary {
 		summary.Action = "redact"
 	case redaction.ModeBlock:
 		summary.Action = "block"
+	case redaction.ModePseudonymize:
+		summary.Action = "pseudonymize"
 	}
 
 	return summary
@@ -4203,7 +4230,7 @@ func applySendMessageRedaction(message string, cfg redaction.Config) (messageToS
 		}
 		warnings = append(warnings, msg)
 		return message, truncateMessage(message), summary, warnings, false
-	case redaction.ModeRedact:
+	case redaction.ModeRedact, redaction.ModePseudonymize:
 		msg := "Warning: redacted potential secrets in message"
 		if parts := formatRedactionCategoryCounts(summary.Categories); parts != "" {
 			msg = fmt.Sprintf("%s (%s)", msg, parts)
@@ -4281,6 +4308,21 @@ func GetSend(opts SendOptions) (*SendOutput, error) {
 		}, nil
 	}
 
+	if err := session.CheckSends(opts.Session); err != nil {
+		return &SendOutput{
+			RobotResponse:  NewErrorResponse(err, ErrCodeSendsPaused, fmt.Sprintf("Resume with 'ntm fleet resume %s'", opts.Session)),
+			Session:        opts.Session,
+			SentAt:         time.Now().UTC(),
+			Blocked:        false,
+			Redaction:      initialSummary,
+			Warnings:       initialWarnings,
+			Targets:        []string{},
+			Successful:     []string{},
+			Failed:         []SendError{{Pane: "session", Error: err.Error()}},
+			MessagePreview: initialPreview,
+		}, nil
+	}
+
 	panes, err := tmux.GetPanes(opts.Session)
 	if err != nil {
 		return &SendOutput{
@@ -4448,6 +4490,26 @@ func GetSend(opts SendOptions) (*SendOutput, error) {
 		return &output, nil
 	}
 
+	// A hard spend budget holds robot sends as it does 'ntm send'.
+	var agentTypes []string
+	for _, pane := range targetPanes {
+		if pane.Type != tmux.AgentUser && !slices.Contains(agentTypes, string(pane.Type)) {
+			agentTypes = append(agentTypes, string(pane.Type))
+		}
+	}
+	soft, err := budget.Enforce(opts.Session, agentTypes, "send")
+	if err != nil {
+		output.RobotResponse = NewErrorResponse(err, ErrCodeBudgetExceeded, "Raise the limit under [budgets] in config, or approve an override with 'ntm approve'")
+		output.Success = false
+		for _, t := range output.Targets {
+			output.Failed = append(output.Failed, SendError{Pane: t, Error: err.Error()})
+		}
+		return &output, nil
+	}
+	for _, b := range soft {
+		output.Warnings = append(output.Warnings, "over soft "+b.String())
+	}
+
 	sendEnter := true
 	if opts.Enter != nil {
 		sendEnter = *opts.Enter
@@ -4473,7 +4535,8 @@ func GetSend(opts SendOptions) (*SendOutput, error) {
 
 		// Use agent-aware send method which handles Gemini's multi-line quirks
 		// by using buffer-based paste instead of send-keys when content has newlines
-		err := tmux.SendKeysForAgentWithDelay(pane.ID, messageToSend, sendEnter, enterDelay, pane.Type)
+		text := redaction.RehydrateForPane(pane.Title, pane.Tags, messageToSend)
+		err := tmux.SendKeysForAgentWithDelay(pane.ID, text, sendEnter, enterDelay, pane.Type)
 		if err != nil {
 			output.Failed = append(output.Failed, SendError{
 				Pane:  paneKey,
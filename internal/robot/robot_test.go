@@ -17,8 +17,11 @@ import (
 	"time"
 
 	"github.com/shahbajlive/ntm/internal/agentmail"
+	"github.com/shahbajlive/ntm/internal/budget"
 	"github.com/shahbajlive/ntm/internal/bv"
 	"github.com/shahbajlive/ntm/internal/config"
+	"github.com/shahbajlive/ntm/internal/cost"
+	"github.com/shahbajlive/ntm/internal/state"
 	"github.com/shahbajlive/ntm/internal/tmux"
 	"github.com/shahbajlive/ntm/tests/testutil"
 )
@@ -860,6 +863,41 @@ func TestSendOptionsExclude(t *testing.T) {
 	}
 }
 
+func TestSendOverHardBudget(t *testing.T) {
+	testutil.RequireTmuxThrottled(t)
+
+	sessionName := "ntm_test_budget_" + time.Now().Format("150405")
+	if err := tmux.CreateSession(sessionName, ""); err != nil {
+		t.Fatalf("Failed to create test session: %v", err)
+	}
+	defer tmux.KillSession(sessionName)
+
+	t.Setenv("HOME", t.TempDir())
+	store, err := state.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	u := cost.Usage{Session: sessionName, Pane: "%1", AgentType: "cc", InputTokens: 5000, Source: "prompt"}
+	if err := state.NewSpendStore(store).RecordUsage(u); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+	budget.SetConfig(config.BudgetsConfig{Enabled: true, HardAction: config.BudgetRefuse,
+		Session: config.BudgetLimitConfig{HardTokens: 1000}})
+	t.Cleanup(func() { budget.SetConfig(config.DefaultBudgetsConfig()) })
+
+	result, err := GetSend(SendOptions{Session: sessionName, Message: "test", All: true})
+	if err != nil {
+		t.Fatalf("GetSend failed: %v", err)
+	}
+	if result.Success || result.ErrorCode != ErrCodeBudgetExceeded || len(result.Successful) != 0 {
+		t.Errorf("send over hard budget = %+v", result)
+	}
+}
+
 func TestSendOptionsPaneFilter(t *testing.T) {
 	testutil.RequireTmuxThrottled(t)
 
@@ -0,0 +1,108 @@
+package robot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/state"
+)
+
+// ScheduleOptions configures --robot-schedule.
+type ScheduleOptions struct {
+	Session string
+	Runs    int // Recent runs to include per schedule
+}
+
+// ScheduleOutput is the structured output for --robot-schedule.
+type ScheduleOutput struct {
+	RobotResponse
+	Session     string              `json:"session"`
+	GeneratedAt time.Time           `json:"generated_at"`
+	Schedules   []ScheduleWithRuns  `json:"schedules"`
+	AgentHints  *ScheduleAgentHints `json:"_agent_hints,omitempty"`
+}
+
+// ScheduleWithRuns is a schedule and its most recent runs.
+type ScheduleWithRuns struct {
+	state.Schedule
+	RecentRuns []state.ScheduleRun `json:"recent_runs"`
+}
+
+// ScheduleAgentHints provides actionable suggestions for AI agents.
+type ScheduleAgentHints struct {
+	Summary           string   `json:"summary,omitempty"`
+	SuggestedCommands []string `json:"suggested_commands,omitempty"`
+}
+
+// GetSchedule lists a session's scheduled jobs with their recent runs.
+// Schedules are fired by the session monitor.
+// This function returns the data struct directly, enabling CLI/REST parity.
+func GetSchedule(opts ScheduleOptions) (*ScheduleOutput, error) {
+	out := &ScheduleOutput{
+		RobotResponse: NewRobotResponse(true),
+		Session:       opts.Session,
+		GeneratedAt:   time.Now().UTC(),
+		Schedules:     []ScheduleWithRuns{},
+	}
+	if opts.Session == "" {
+		out.RobotResponse = NewErrorResponse(
+			fmt.Errorf("session name is required"),
+			ErrCodeInvalidFlag,
+			"Provide session name: ntm --robot-schedule=myproject",
+		)
+		return out, nil
+	}
+	if opts.Runs < 0 {
+		opts.Runs = 0
+	}
+
+	store, err := state.Open("")
+	if err != nil {
+		out.RobotResponse = NewErrorResponse(err, ErrCodeInternalError, "Check ~/.config/ntm/state.db permissions")
+		return out, nil
+	}
+	defer store.Close()
+	if err := store.Migrate(); err != nil {
+		out.RobotResponse = NewErrorResponse(err, ErrCodeInternalError, "State database migration failed")
+		return out, nil
+	}
+	ss := state.NewScheduleStore(store)
+
+	list, err := ss.List(opts.Session)
+	if err != nil {
+		return nil, err
+	}
+	failing := ""
+	for _, s := range list {
+		entry := ScheduleWithRuns{Schedule: s, RecentRuns: []state.ScheduleRun{}}
+		if opts.Runs > 0 {
+			runs, err := ss.Runs(s.ID, opts.Runs)
+			if err != nil {
+				return nil, err
+			}
+			if runs != nil {
+				entry.RecentRuns = runs
+			}
+		}
+		if s.LastStatus == state.ScheduleRunFailed && failing == "" {
+			failing = s.Name
+		}
+		out.Schedules = append(out.Schedules, entry)
+	}
+
+	out.AgentHints = &ScheduleAgentHints{Summary: fmt.Sprintf("%d schedule(s) for %s", len(list), opts.Session)}
+	if failing != "" {
+		out.AgentHints.Summary += fmt.Sprintf("; %s failed its last run", failing)
+		out.AgentHints.SuggestedCommands = []string{fmt.Sprintf("ntm schedule history %s", failing)}
+	}
+	return out, nil
+}
+
+// PrintSchedule outputs a session's schedules as JSON.
+func PrintSchedule(opts ScheduleOptions) error {
+	out, err := GetSchedule(opts)
+	if err != nil {
+		return err
+	}
+	return encodeJSON(out)
+}
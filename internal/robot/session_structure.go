@@ -4,7 +4,6 @@ package robot
 
 import (
 	"fmt"
-	"os/exec"
 	"strconv"
 	"strings"
 
@@ -68,15 +67,14 @@ func DetectSessionStructure(session string) (*SessionStructure, error) {
 
 // detectWindows queries tmux for window information.
 func (s *SessionStructure) detectWindows(session string) error {
-	tmuxPath := tmux.BinaryPath()
 	// Get window list: index and name
-	out, err := exec.Command(tmuxPath, "list-windows", "-t", session,
-		"-F", "#{window_index}|#{window_name}").Output()
+	out, err := tmux.DefaultClient.Run("list-windows", "-t", session,
+		"-F", "#{window_index}|#{window_name}")
 	if err != nil {
 		return fmt.Errorf("list-windows failed: %w", err)
 	}
 
-	trimmed := strings.TrimSpace(string(out))
+	trimmed := strings.TrimSpace(out)
 	if trimmed == "" {
 		return fmt.Errorf("no windows found")
 	}
@@ -133,16 +131,15 @@ func (s *SessionStructure) findPrimaryWindow() int {
 // detectPanes queries tmux for pane information in the primary window.
 func (s *SessionStructure) detectPanes(session string) error {
 	target := fmt.Sprintf("%s:%d", session, s.WindowIndex)
-	tmuxPath := tmux.BinaryPath()
 
 	// Get pane list: index and pid
-	out, err := exec.Command(tmuxPath, "list-panes", "-t", target,
-		"-F", "#{pane_index}|#{pane_pid}|#{pane_current_command}").Output()
+	out, err := tmux.DefaultClient.Run("list-panes", "-t", target,
+		"-F", "#{pane_index}|#{pane_pid}|#{pane_current_command}")
 	if err != nil {
 		return fmt.Errorf("list-panes failed: %w", err)
 	}
 
-	trimmed := strings.TrimSpace(string(out))
+	trimmed := strings.TrimSpace(out)
 	if trimmed == "" {
 		return fmt.Errorf("no panes found in window %d", s.WindowIndex)
 	}
@@ -234,11 +231,10 @@ func (s *SessionStructure) countAgentPanes() int {
 
 // detectLayout gets the tmux layout string.
 func (s *SessionStructure) detectLayout(target string) {
-	tmuxPath := tmux.BinaryPath()
-	out, err := exec.Command(tmuxPath, "display-message", "-t", target,
-		"-p", "#{window_layout}").Output()
+	out, err := tmux.DefaultClient.Run("display-message", "-t", target,
+		"-p", "#{window_layout}")
 	if err == nil {
-		s.Layout = strings.TrimSpace(string(out))
+		s.Layout = strings.TrimSpace(out)
 	}
 }
 
@@ -9,6 +9,7 @@ import (
 	"sync"
 	"time"
 
+	agentpkg "github.com/shahbajlive/ntm/internal/agent"
 	"github.com/shahbajlive/ntm/internal/process"
 	"github.com/shahbajlive/ntm/internal/tmux"
 	"github.com/shahbajlive/ntm/internal/tokens"
@@ -80,6 +81,11 @@ func enrichAgentStatus(agent *Agent, sessionName, modelName string) {
 		agent.RateLimitDetected = detected
 		agent.RateLimitMatch = match
 
+		// Permission prompt the agent is blocked on
+		if agent.Type != "user" {
+			agent.PermissionPrompt = agentpkg.DetectPermissionPrompt(content, agentpkg.AgentTypeUnknown)
+		}
+
 		// Output activity
 		lastOutputTS, linesDelta := updateActivity(agent.Pane, content)
 		agent.LastOutputTS = lastOutputTS
@@ -1692,14 +1692,13 @@ type paneInfo struct {
 func getPanesForSession(session string) ([]paneInfo, error) {
 	// Import tmux package and get panes
 	// This is a simplified version - the actual implementation will use tmux.GetPanes
-	cmd := exec.Command(tmux.BinaryPath(), "list-panes", "-t", session, "-F", "#{pane_id}|#{pane_title}|#{pane_current_command}")
-	output, err := cmd.Output()
+	output, err := tmux.DefaultClient.Run("list-panes", "-t", session, "-F", "#{pane_id}|#{pane_title}|#{pane_current_command}")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get panes: %w", err)
 	}
 
 	var panes []paneInfo
-	for _, line := range strings.Split(string(output), "\n") {
+	for _, line := range strings.Split(output, "\n") {
 		if line == "" {
 			continue
 		}
@@ -1737,10 +1736,5 @@ func getPanesForSession(session string) ([]paneInfo, error) {
 
 // capturePaneOutput captures output from a tmux pane.
 func capturePaneOutput(paneID string, lines int) (string, error) {
-	cmd := exec.Command(tmux.BinaryPath(), "capture-pane", "-t", paneID, "-p", "-S", fmt.Sprintf("-%d", lines))
-	output, err := cmd.Output()
-	if err != nil {
-		return "", err
-	}
-	return string(output), nil
+	return tmux.DefaultClient.Run("capture-pane", "-t", paneID, "-p", "-S", fmt.Sprintf("-%d", lines))
 }
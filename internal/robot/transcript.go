@@ -0,0 +1,160 @@
+package robot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/state"
+	"github.com/shahbajlive/ntm/internal/transcript"
+)
+
+// TranscriptOptions configures --robot-transcript.
+type TranscriptOptions struct {
+	Session    string
+	ProjectDir string // Where the session's agents run; used to find transcripts
+	Pane       *int
+	BeadID     string
+	Text       string
+	Tool       string
+	File       string
+	Since      string // Duration (e.g. "1h") or timestamp
+	Limit      int
+	NoIngest   bool // Query what is already stored without scanning for new transcripts
+}
+
+// TranscriptOutput is the structured output for --robot-transcript.
+type TranscriptOutput struct {
+	RobotResponse
+	Session     string                      `json:"session"`
+	GeneratedAt time.Time                   `json:"generated_at"`
+	Ingested    []transcript.IngestResult   `json:"ingested,omitempty"`
+	Transcripts []state.TranscriptRecord    `json:"transcripts"`
+	Turns       []state.TranscriptTurnMatch `json:"turns"`
+	FilesEdited []string                    `json:"files_edited"`
+	AgentHints  *TranscriptAgentHints       `json:"_agent_hints,omitempty"`
+}
+
+// TranscriptAgentHints provides actionable suggestions for AI agents.
+type TranscriptAgentHints struct {
+	Summary           string   `json:"summary,omitempty"`
+	SuggestedCommands []string `json:"suggested_commands,omitempty"`
+}
+
+// GetTranscript ingests new agent transcripts for a session and returns its
+// most recent turns.
+// This function returns the data struct directly, enabling CLI/REST parity.
+func GetTranscript(opts TranscriptOptions) (*TranscriptOutput, error) {
+	out := &TranscriptOutput{
+		RobotResponse: NewRobotResponse(true),
+		Session:       opts.Session,
+		GeneratedAt:   time.Now().UTC(),
+		Transcripts:   []state.TranscriptRecord{},
+		Turns:         []state.TranscriptTurnMatch{},
+		FilesEdited:   []string{},
+	}
+	if opts.Session == "" {
+		out.RobotResponse = NewErrorResponse(
+			fmt.Errorf("session name is required"),
+			ErrCodeInvalidFlag,
+			"Provide session name: ntm --robot-transcript=myproject",
+		)
+		return out, nil
+	}
+
+	filter := state.TranscriptFilter{
+		SessionName: opts.Session,
+		PaneIndex:   opts.Pane,
+		BeadID:      opts.BeadID,
+		Text:        opts.Text,
+		Tool:        opts.Tool,
+		File:        opts.File,
+		Limit:       opts.Limit,
+	}
+	if opts.Since != "" {
+		since, err := parseSinceTime(opts.Since)
+		if err != nil {
+			out.RobotResponse = NewErrorResponse(
+				fmt.Errorf("invalid --transcript-since: %w", err),
+				ErrCodeInvalidFlag,
+				"Use a duration (30m, 2h, 1d) or an ISO8601 timestamp",
+			)
+			return out, nil
+		}
+		filter.Since = since
+	}
+
+	store, err := state.Open("")
+	if err != nil {
+		out.RobotResponse = NewErrorResponse(err, ErrCodeInternalError, "Check ~/.config/ntm/state.db permissions")
+		return out, nil
+	}
+	defer store.Close()
+	if err := store.Migrate(); err != nil {
+		out.RobotResponse = NewErrorResponse(err, ErrCodeInternalError, "State database migration failed")
+		return out, nil
+	}
+	ts := state.NewTranscriptStore(store)
+
+	if !opts.NoIngest && opts.ProjectDir != "" {
+		results, err := transcript.SyncSession(ts, opts.Session, opts.ProjectDir, time.Time{})
+		if err != nil {
+			out.RobotResponse = NewErrorResponse(err, ErrCodeInternalError, "Transcript ingestion failed; retry with --transcript-no-ingest")
+			return out, nil
+		}
+		for _, r := range results {
+			if !r.Unchanged {
+				out.Ingested = append(out.Ingested, r)
+			}
+		}
+	}
+
+	if out.Transcripts, err = ts.List(state.TranscriptFilter{SessionName: opts.Session, PaneIndex: opts.Pane, BeadID: opts.BeadID}); err != nil {
+		return nil, err
+	}
+	if out.Turns, err = ts.QueryTurns(filter); err != nil {
+		return nil, err
+	}
+	edits, err := ts.FileEdits(state.TranscriptFilter{
+		SessionName: opts.Session,
+		PaneIndex:   opts.Pane,
+		BeadID:      opts.BeadID,
+		File:        opts.File,
+		Since:       filter.Since,
+	})
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	for _, e := range edits {
+		if !seen[e.Path] {
+			seen[e.Path] = true
+			out.FilesEdited = append(out.FilesEdited, e.Path)
+		}
+	}
+	if out.Transcripts == nil {
+		out.Transcripts = []state.TranscriptRecord{}
+	}
+	if out.Turns == nil {
+		out.Turns = []state.TranscriptTurnMatch{}
+	}
+
+	out.AgentHints = &TranscriptAgentHints{
+		Summary: fmt.Sprintf("%d transcript(s), %d matching turn(s), %d file(s) edited",
+			len(out.Transcripts), len(out.Turns), len(out.FilesEdited)),
+	}
+	if len(out.Transcripts) == 0 {
+		out.AgentHints.SuggestedCommands = []string{fmt.Sprintf("ntm transcript ingest %s --dir <project>", opts.Session)}
+	} else if len(out.Turns) == opts.Limit && opts.Limit > 0 {
+		out.AgentHints.SuggestedCommands = []string{fmt.Sprintf("ntm --robot-transcript=%s --transcript-limit=%d", opts.Session, opts.Limit*2)}
+	}
+	return out, nil
+}
+
+// PrintTranscript outputs session transcripts as JSON.
+func PrintTranscript(opts TranscriptOptions) error {
+	out, err := GetTranscript(opts)
+	if err != nil {
+		return err
+	}
+	return encodeJSON(out)
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/shahbajlive/ntm/internal/config"
 	"github.com/shahbajlive/ntm/internal/history"
 	"github.com/shahbajlive/ntm/internal/kernel"
+	"github.com/shahbajlive/ntm/internal/segment"
 	"github.com/shahbajlive/ntm/internal/tmux"
 	"github.com/shahbajlive/ntm/internal/tracker"
 )
@@ -297,6 +298,11 @@ type InspectPaneOutput_ struct {
 	LastLines   []string        `json:"last_lines"`             // Last N lines (configurable)
 	CodeBlocks  []CodeBlockInfo `json:"code_blocks,omitempty"`  // Detected code blocks
 	ErrorsFound []string        `json:"errors_found,omitempty"` // Detected error messages
+
+	// Segments splits the output into prompts, prose, tool calls, diffs
+	// and errors; Latest picks out the most recent of each
+	Segments []segment.Block `json:"segments,omitempty"`
+	Latest   *segment.Latest `json:"latest,omitempty"`
 }
 
 // CodeBlockInfo represents a detected code block in output
@@ -323,6 +329,7 @@ type InspectPaneOptions struct {
 	PaneID      string // Alternative to index
 	Lines       int    // Lines to capture (default: 100)
 	IncludeCode bool   // Parse code blocks
+	Segments    bool   // Segment output into turns, tool calls and diffs
 }
 
 // GetInspectPane returns detailed pane inspection data.
@@ -449,6 +456,13 @@ func GetInspectPane(opts InspectPaneOptions) (*InspectPaneOutput, error) {
 			output.Output.CodeBlocks = parseCodeBlocks(lines)
 		}
 
+		if opts.Segments {
+			blocks := segment.Parse(detection.Type, captured)
+			latest := segment.Summarize(blocks)
+			output.Output.Segments = blocks
+			output.Output.Latest = &latest
+		}
+
 		// Extract file references
 		output.Context.RecentFiles = extractFileReferences(lines)
 	}
@@ -130,6 +130,12 @@ const (
 
 	// ErrCodePromptSendFailed indicates failed to send prompt.
 	ErrCodePromptSendFailed = "PROMPT_SEND_FAILED"
+
+	// ErrCodeSendsPaused indicates sends to the session are paused.
+	ErrCodeSendsPaused = "SENDS_PAUSED"
+
+	// ErrCodeBudgetExceeded indicates a hard spend budget refused the action.
+	ErrCodeBudgetExceeded = "BUDGET_EXCEEDED"
 )
 
 // ResponseMeta provides optional metadata about response generation.
@@ -121,6 +121,10 @@ func BeadFromFinding(f Finding, project string) BeadSpec {
 		desc.WriteString(fmt.Sprintf("**Category:** %s\n\n", f.Category))
 	}
 
+	if f.Source != "" && f.Source != SourceUBS {
+		desc.WriteString(fmt.Sprintf("**Source:** %s\n\n", f.Source))
+	}
+
 	desc.WriteString(fmt.Sprintf("**Message:** %s\n\n", f.Message))
 
 	if f.Suggestion != "" {
@@ -129,14 +133,22 @@ func BeadFromFinding(f Finding, project string) BeadSpec {
 
 	// Embed a stable signature so future runs can deduplicate/close correctly.
 	desc.WriteString(fmt.Sprintf("**Signature:** %s\n\n", signature))
-	desc.WriteString(fmt.Sprintf("---\n*Auto-created by UBS scan at %s*", time.Now().Format(time.RFC3339)))
+	tool := "UBS"
+	// Every scanner bead carries the ubs-scan label so dedup and auto-close
+	// see them; imported findings are also labelled with their tool.
+	labels := []string{"ubs-scan", string(f.Severity)}
+	if f.Source != "" && f.Source != SourceUBS {
+		tool = f.Source
+		labels = append(labels, "sarif", f.Source)
+	}
+	desc.WriteString(fmt.Sprintf("---\n*Auto-created by %s scan at %s*", tool, time.Now().Format(time.RFC3339)))
 
 	return BeadSpec{
 		Title:       title,
 		Type:        "bug",
 		Priority:    int(priority),
 		Description: desc.String(),
-		Labels:      []string{"ubs-scan", string(f.Severity)},
+		Labels:      labels,
 		Signature:   signature,
 	}
 }
@@ -306,6 +318,18 @@ func extractSignatureFromDesc(desc string) string {
 	return ""
 }
 
+// extractSourceFromDesc returns the tool recorded in a bead description,
+// defaulting to UBS for beads without a Source line.
+func extractSourceFromDesc(desc string) string {
+	for _, line := range strings.Split(desc, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "**Source:**") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "**Source:**"))
+		}
+	}
+	return SourceUBS
+}
+
 // truncateMessage truncates a message to maxLen, adding ellipsis if needed.
 // Respects UTF-8 rune boundaries.
 func truncateMessage(msg string, maxLen int) string {
@@ -340,11 +364,18 @@ func shortenPath(path string) string {
 }
 
 // UpdateBeadsFromFindings closes beads for findings that no longer appear.
+// Only beads reported by one of the result's sources are considered, so a
+// UBS scan never closes beads imported from another tool and vice versa.
 func UpdateBeadsFromFindings(result *ScanResult, cfg BridgeConfig) (*BridgeResult, error) {
 	br := &BridgeResult{
 		Messages: make([]string, 0),
 	}
 
+	covered := make(map[string]bool)
+	for _, src := range result.SourceList() {
+		covered[src] = true
+	}
+
 	// Get current findings signatures
 	currentSigs := make(map[string]bool)
 	for _, f := range result.Findings {
@@ -374,6 +405,9 @@ func UpdateBeadsFromFindings(result *ScanResult, cfg BridgeConfig) (*BridgeResul
 		if sig == "" {
 			continue // Can't determine if fixed
 		}
+		if !covered[extractSourceFromDesc(b.Description)] {
+			continue // Reported by a tool this result doesn't cover
+		}
 
 		if !currentSigs[sig] {
 			// Finding no longer exists - issue is fixed
@@ -0,0 +1,489 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SARIF 2.1.0 constants.
+const (
+	SARIFVersion = "2.1.0"
+	SARIFSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	// sarifFingerprintKey holds FindingSignature in exported results so a
+	// re-import deduplicates against the same beads.
+	sarifFingerprintKey = "ntmSignature/v1"
+)
+
+// SARIFLog is the subset of a SARIF 2.1.0 log that ntm reads and writes.
+type SARIFLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema,omitempty"`
+	Runs    []SARIFRun `json:"runs"`
+}
+
+// SARIFRun is the output of a single tool invocation.
+type SARIFRun struct {
+	Tool    SARIFTool     `json:"tool"`
+	Results []SARIFResult `json:"results"`
+}
+
+// SARIFTool describes the tool that produced a run.
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+// SARIFDriver is the tool's primary component.
+type SARIFDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	SemanticVer    string      `json:"semanticVersion,omitempty"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []SARIFRule `json:"rules,omitempty"`
+}
+
+// SARIFRule is a reportingDescriptor for one rule.
+type SARIFRule struct {
+	ID                   string               `json:"id"`
+	Name                 string               `json:"name,omitempty"`
+	ShortDescription     *SARIFMessage        `json:"shortDescription,omitempty"`
+	FullDescription      *SARIFMessage        `json:"fullDescription,omitempty"`
+	Help                 *SARIFMessage        `json:"help,omitempty"`
+	DefaultConfiguration *SARIFConfiguration  `json:"defaultConfiguration,omitempty"`
+	Properties           *SARIFRuleProperties `json:"properties,omitempty"`
+}
+
+// SARIFConfiguration holds a rule's default level.
+type SARIFConfiguration struct {
+	Level string `json:"level,omitempty"`
+}
+
+// SARIFRuleProperties are the rule properties ntm understands. The
+// security-severity score is the convention used by code scanning services.
+type SARIFRuleProperties struct {
+	Tags             []string `json:"tags,omitempty"`
+	SecuritySeverity string   `json:"security-severity,omitempty"`
+	Precision        string   `json:"precision,omitempty"`
+}
+
+// SARIFMessage is a plain-text message.
+type SARIFMessage struct {
+	Text     string `json:"text,omitempty"`
+	Markdown string `json:"markdown,omitempty"`
+}
+
+// SARIFResult is one finding.
+type SARIFResult struct {
+	RuleID              string             `json:"ruleId,omitempty"`
+	RuleIndex           *int               `json:"ruleIndex,omitempty"`
+	Kind                string             `json:"kind,omitempty"`
+	Level               string             `json:"level,omitempty"`
+	Message             SARIFMessage       `json:"message"`
+	Locations           []SARIFLocation    `json:"locations,omitempty"`
+	PartialFingerprints map[string]string  `json:"partialFingerprints,omitempty"`
+	Suppressions        []SARIFSuppression `json:"suppressions,omitempty"`
+	Fixes               []SARIFFix         `json:"fixes,omitempty"`
+	Properties          map[string]any     `json:"properties,omitempty"`
+}
+
+// SARIFLocation wraps a physical location.
+type SARIFLocation struct {
+	PhysicalLocation SARIFPhysicalLocation `json:"physicalLocation"`
+}
+
+// SARIFPhysicalLocation points into an artifact.
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+	Region           *SARIFRegion          `json:"region,omitempty"`
+}
+
+// SARIFArtifactLocation identifies a file.
+type SARIFArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+// SARIFRegion is a line/column range.
+type SARIFRegion struct {
+	StartLine   int `json:"startLine,omitempty"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// SARIFSuppression marks a result as suppressed in source or by review.
+type SARIFSuppression struct {
+	Kind   string `json:"kind"`
+	Status string `json:"status,omitempty"`
+}
+
+// SARIFFix describes a proposed fix.
+type SARIFFix struct {
+	Description SARIFMessage `json:"description"`
+}
+
+// SARIFImportOptions controls how SARIF results become findings.
+type SARIFImportOptions struct {
+	// Root makes absolute artifact paths under it relative, matching the
+	// repo-relative paths UBS and git report.
+	Root string
+	// IncludeSuppressed keeps results suppressed in source or accepted by review.
+	IncludeSuppressed bool
+}
+
+// ParseSARIF decodes a SARIF log.
+func ParseSARIF(data []byte) (*SARIFLog, error) {
+	var log SARIFLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		return nil, fmt.Errorf("parsing SARIF: %w", err)
+	}
+	if log.Version != "" && !strings.HasPrefix(log.Version, "2.") {
+		return nil, fmt.Errorf("unsupported SARIF version %q (need 2.x)", log.Version)
+	}
+	return &log, nil
+}
+
+// ImportSARIFFiles reads SARIF logs and merges them into one ScanResult.
+func ImportSARIFFiles(paths []string, opts SARIFImportOptions) (*ScanResult, error) {
+	var logs []*SARIFLog
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", p, err)
+		}
+		log, err := ParseSARIF(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+		logs = append(logs, log)
+	}
+	return FindingsFromSARIF(opts, logs...), nil
+}
+
+// FindingsFromSARIF normalizes SARIF results into a ScanResult. Results that
+// share a FindingSignature are reported once. Every tool seen is listed in
+// Sources, even if it reported nothing, so fixed findings can be closed.
+func FindingsFromSARIF(opts SARIFImportOptions, logs ...*SARIFLog) *ScanResult {
+	result := &ScanResult{
+		Project:   opts.Root,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}
+	seen := make(map[string]bool)
+	sources := make(map[string]bool)
+	files := make(map[string]bool)
+
+	for _, log := range logs {
+		for _, run := range log.Runs {
+			source := SARIFSourceName(run.Tool.Driver.Name)
+			if !sources[source] {
+				sources[source] = true
+				result.Sources = append(result.Sources, source)
+			}
+			rules := make(map[string]*SARIFRule, len(run.Tool.Driver.Rules))
+			for i := range run.Tool.Driver.Rules {
+				rules[run.Tool.Driver.Rules[i].ID] = &run.Tool.Driver.Rules[i]
+			}
+
+			for _, r := range run.Results {
+				if r.Kind != "" && r.Kind != "fail" {
+					continue // pass, notApplicable, informational, review, open
+				}
+				if !opts.IncludeSuppressed && isSuppressed(r) {
+					continue
+				}
+				rule := rules[r.RuleID]
+				if rule == nil && r.RuleIndex != nil && *r.RuleIndex >= 0 && *r.RuleIndex < len(run.Tool.Driver.Rules) {
+					rule = &run.Tool.Driver.Rules[*r.RuleIndex]
+				}
+
+				f := sarifFinding(r, rule, source, opts.Root)
+				sig := FindingSignature(f)
+				if seen[sig] {
+					continue
+				}
+				seen[sig] = true
+				result.Findings = append(result.Findings, f)
+				if f.File != "" {
+					files[f.File] = true
+				}
+				switch f.Severity {
+				case SeverityCritical:
+					result.Totals.Critical++
+				case SeverityWarning:
+					result.Totals.Warning++
+				default:
+					result.Totals.Info++
+				}
+			}
+		}
+	}
+	result.Totals.Files = len(files)
+	return result
+}
+
+func sarifFinding(r SARIFResult, rule *SARIFRule, source, root string) Finding {
+	f := Finding{
+		RuleID:  r.RuleID,
+		Message: strings.TrimSpace(r.Message.Text),
+		Source:  source,
+	}
+	if f.RuleID == "" && rule != nil {
+		f.RuleID = rule.ID
+	}
+	if f.Message == "" && rule != nil && rule.ShortDescription != nil {
+		f.Message = rule.ShortDescription.Text
+	}
+
+	if len(r.Locations) > 0 {
+		loc := r.Locations[0].PhysicalLocation
+		f.File = sarifPath(loc.ArtifactLocation.URI, root)
+		if loc.Region != nil {
+			f.Line = loc.Region.StartLine
+			f.Column = loc.Region.StartColumn
+		}
+	}
+
+	level := r.Level
+	if level == "" && rule != nil && rule.DefaultConfiguration != nil {
+		level = rule.DefaultConfiguration.Level
+	}
+	f.Severity = SARIFLevelToSeverity(level)
+	if rule != nil && rule.Properties != nil {
+		if sev, ok := securitySeverity(rule.Properties.SecuritySeverity); ok {
+			f.Severity = sev
+		}
+	}
+
+	f.Category = source
+	if rule != nil && rule.Properties != nil && len(rule.Properties.Tags) > 0 {
+		f.Category = rule.Properties.Tags[0]
+	}
+
+	if len(r.Fixes) > 0 && r.Fixes[0].Description.Text != "" {
+		f.Suggestion = r.Fixes[0].Description.Text
+	} else if rule != nil && rule.Help != nil {
+		f.Suggestion = firstParagraph(rule.Help.Text)
+	}
+	return f
+}
+
+// SARIFLevelToSeverity maps a SARIF level to a finding severity. An absent
+// level means "warning" per the SARIF specification.
+func SARIFLevelToSeverity(level string) Severity {
+	switch strings.ToLower(level) {
+	case "error":
+		return SeverityCritical
+	case "", "warning":
+		return SeverityWarning
+	default: // note, none
+		return SeverityInfo
+	}
+}
+
+// SeverityToSARIFLevel maps a finding severity to a SARIF level.
+func SeverityToSARIFLevel(sev Severity) string {
+	switch sev {
+	case SeverityCritical:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// securitySeverity maps a CVSS-style security-severity score: 7.0 and above
+// (high, critical) is critical, 4.0 and above (medium) is a warning.
+func securitySeverity(score string) (Severity, bool) {
+	if score == "" {
+		return "", false
+	}
+	v, err := strconv.ParseFloat(score, 64)
+	if err != nil {
+		return "", false
+	}
+	switch {
+	case v >= 7.0:
+		return SeverityCritical, true
+	case v >= 4.0:
+		return SeverityWarning, true
+	default:
+		return SeverityInfo, true
+	}
+}
+
+func isSuppressed(r SARIFResult) bool {
+	for _, s := range r.Suppressions {
+		if s.Status == "" || s.Status == "accepted" {
+			return true
+		}
+	}
+	return false
+}
+
+// SARIFSourceName normalizes a tool name for use as a finding source and
+// bead label, e.g. "Semgrep OSS" becomes "semgrep-oss".
+func SARIFSourceName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return "sarif"
+	}
+	return strings.Join(strings.FieldsFunc(name, func(r rune) bool {
+		return r == ' ' || r == ',' || r == '/' || r == '\t'
+	}), "-")
+}
+
+// sarifPath turns an artifact URI into a file path, relative to root when
+// the file lives under it.
+func sarifPath(uri, root string) string {
+	p := uri
+	if strings.HasPrefix(uri, "file:") {
+		if u, err := url.Parse(uri); err == nil {
+			p = u.Path
+		}
+	} else if unescaped, err := url.PathUnescape(uri); err == nil {
+		p = unescaped
+	}
+	if root != "" && filepath.IsAbs(p) {
+		if rel, err := filepath.Rel(root, p); err == nil && !strings.HasPrefix(rel, "..") {
+			p = rel
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(p))
+}
+
+func firstParagraph(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.Index(s, "\n\n"); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
+
+// ToSARIF exports a ScanResult as a SARIF log. Findings are grouped into one
+// run per source; UBS findings are reported under toolName.
+func ToSARIF(result *ScanResult, toolName, version string) *SARIFLog {
+	log := &SARIFLog{Version: SARIFVersion, Schema: SARIFSchema, Runs: []SARIFRun{}}
+	if result == nil {
+		return log
+	}
+
+	bySource := make(map[string][]Finding)
+	var order []string
+	for _, f := range result.Findings {
+		src := f.Source
+		if src == "" || src == SourceUBS {
+			src = toolName
+		}
+		if _, ok := bySource[src]; !ok {
+			order = append(order, src)
+		}
+		bySource[src] = append(bySource[src], f)
+	}
+	if len(order) == 0 {
+		order = append(order, toolName)
+	}
+
+	for _, src := range order {
+		run := SARIFRun{Tool: SARIFTool{Driver: SARIFDriver{Name: src}}, Results: []SARIFResult{}}
+		if src == toolName {
+			run.Tool.Driver.Version = version
+		}
+
+		ruleIndex := make(map[string]int)
+		for _, f := range bySource[src] {
+			ruleID := f.RuleID
+			if ruleID == "" {
+				ruleID = f.Category
+			}
+			if ruleID != "" {
+				if _, ok := ruleIndex[ruleID]; !ok {
+					ruleIndex[ruleID] = len(run.Tool.Driver.Rules)
+					rule := SARIFRule{ID: ruleID}
+					if f.Category != "" {
+						rule.Properties = &SARIFRuleProperties{Tags: []string{f.Category}}
+					}
+					run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+				}
+			}
+
+			r := SARIFResult{
+				RuleID:              ruleID,
+				Level:               SeverityToSARIFLevel(f.Severity),
+				Message:             SARIFMessage{Text: f.Message},
+				PartialFingerprints: map[string]string{sarifFingerprintKey: FindingSignature(f)},
+			}
+			if ruleID != "" {
+				idx := ruleIndex[ruleID]
+				r.RuleIndex = &idx
+			}
+			if f.File != "" {
+				loc := SARIFPhysicalLocation{ArtifactLocation: SARIFArtifactLocation{URI: sarifURI(f.File)}}
+				if f.Line > 0 {
+					loc.Region = &SARIFRegion{StartLine: f.Line, StartColumn: f.Column}
+				}
+				r.Locations = []SARIFLocation{{PhysicalLocation: loc}}
+			}
+			if f.Suggestion != "" {
+				r.Fixes = []SARIFFix{{Description: SARIFMessage{Text: f.Suggestion}}}
+			}
+			run.Results = append(run.Results, r)
+		}
+		sort.SliceStable(run.Results, func(i, j int) bool {
+			a, b := run.Results[i], run.Results[j]
+			return resultURI(a) < resultURI(b)
+		})
+		log.Runs = append(log.Runs, run)
+	}
+	return log
+}
+
+func sarifURI(path string) string {
+	if filepath.IsAbs(path) {
+		return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+	}
+	return (&url.URL{Path: filepath.ToSlash(path)}).String()
+}
+
+func resultURI(r SARIFResult) string {
+	if len(r.Locations) == 0 {
+		return ""
+	}
+	return r.Locations[0].PhysicalLocation.ArtifactLocation.URI
+}
+
+// MarshalSARIF renders a ScanResult as indented SARIF JSON.
+func MarshalSARIF(result *ScanResult, toolName, version string) ([]byte, error) {
+	return json.MarshalIndent(ToSARIF(result, toolName, version), "", "  ")
+}
+
+// MergeResults adds the findings and totals of extra into base. Files and
+// duration are kept from base.
+func MergeResults(base, extra *ScanResult) *ScanResult {
+	if base == nil {
+		return extra
+	}
+	if extra == nil {
+		return base
+	}
+	merged := *base
+	merged.Findings = append(append([]Finding(nil), base.Findings...), extra.Findings...)
+	merged.Totals.Critical += extra.Totals.Critical
+	merged.Totals.Warning += extra.Totals.Warning
+	merged.Totals.Info += extra.Totals.Info
+	merged.Warnings = append(append([]string(nil), base.Warnings...), extra.Warnings...)
+	merged.Sources = nil
+	seen := make(map[string]bool)
+	for _, src := range append(base.SourceList(), extra.SourceList()...) {
+		if !seen[src] {
+			seen[src] = true
+			merged.Sources = append(merged.Sources, src)
+		}
+	}
+	return &merged
+}
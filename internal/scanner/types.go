@@ -23,6 +23,19 @@ type Finding struct {
 	Message    string   `json:"message"`
 	Suggestion string   `json:"suggestion,omitempty"`
 	RuleID     string   `json:"rule_id,omitempty"`
+	// Source is the tool that reported the finding; empty means UBS.
+	Source string `json:"source,omitempty"`
+}
+
+// SourceUBS names findings reported by UBS itself.
+const SourceUBS = "ubs"
+
+// FindingSource returns the tool that reported f.
+func FindingSource(f Finding) string {
+	if f.Source == "" {
+		return SourceUBS
+	}
+	return f.Source
 }
 
 // ScannerResult represents results from a single language scanner.
@@ -54,6 +67,9 @@ type ScanResult struct {
 	Warnings  []string        `json:"warnings,omitempty"`
 	Duration  time.Duration   `json:"duration,omitempty"`
 	ExitCode  int             `json:"exit_code"`
+	// Sources lists the tools that contributed to the result; empty means
+	// UBS only.
+	Sources []string `json:"sources,omitempty"`
 }
 
 // ScanOptions configures a UBS scan.
@@ -83,6 +99,14 @@ func DefaultOptions() ScanOptions {
 	}
 }
 
+// SourceList returns the tools that contributed to the result.
+func (r *ScanResult) SourceList() []string {
+	if len(r.Sources) == 0 {
+		return []string{SourceUBS}
+	}
+	return r.Sources
+}
+
 // IsHealthy returns true if the scan found no critical or warning issues.
 func (r *ScanResult) IsHealthy() bool {
 	return r.Totals.Critical == 0 && r.Totals.Warning == 0
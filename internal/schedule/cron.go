@@ -0,0 +1,218 @@
+// Package schedule fires recurring jobs (sends, checkpoints, pipeline runs,
+// scans and handoffs) from cron expressions or fixed intervals.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/util"
+)
+
+// Spec computes the firing times of a schedule.
+type Spec interface {
+	// Next returns the first firing strictly after t, or the zero time if
+	// there is none.
+	Next(t time.Time) time.Time
+}
+
+// Interval fires every fixed duration, aligned to the duration.
+type Interval time.Duration
+
+// Next implements Spec.
+func (d Interval) Next(t time.Time) time.Time {
+	return t.Truncate(time.Duration(d)).Add(time.Duration(d))
+}
+
+// cronSpec is a parsed five-field cron expression. Each field is a bit set
+// of the values it matches.
+type cronSpec struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+	loc                           *time.Location
+}
+
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = cronField{name: "minute", min: 0, max: 59}
+	hourField   = cronField{name: "hour", min: 0, max: 23}
+	domField    = cronField{name: "day of month", min: 1, max: 31}
+	monthField  = cronField{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowField = cronField{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var cronAliases = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a schedule spec: a five-field cron expression (minute hour
+// day-of-month month day-of-week, with lists, ranges, steps and month/day
+// names), one of @hourly, @daily, @weekly, @monthly, @yearly, or an interval
+// written "@every 30m" or "every 2h". Cron times are in the local time zone.
+func Parse(expr string) (Spec, error) {
+	expr = strings.TrimSpace(expr)
+	lower := strings.ToLower(expr)
+	for _, prefix := range []string{"@every ", "every "} {
+		if strings.HasPrefix(lower, prefix) {
+			d, err := util.ParseDuration(strings.TrimSpace(expr[len(prefix):]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid interval %q: %w", expr, err)
+			}
+			if d < time.Minute {
+				return nil, fmt.Errorf("interval %q is shorter than a minute", expr)
+			}
+			return Interval(d), nil
+		}
+	}
+	if alias, ok := cronAliases[lower]; ok {
+		expr = alias
+	} else if strings.HasPrefix(lower, "@") {
+		return nil, fmt.Errorf("unknown schedule %q", expr)
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields (minute hour day-of-month month day-of-week)", expr)
+	}
+	spec := &cronSpec{loc: time.Local}
+	var err error
+	if spec.minute, err = minuteField.parse(fields[0]); err != nil {
+		return nil, err
+	}
+	if spec.hour, err = hourField.parse(fields[1]); err != nil {
+		return nil, err
+	}
+	if spec.dom, err = domField.parse(fields[2]); err != nil {
+		return nil, err
+	}
+	if spec.month, err = monthField.parse(fields[3]); err != nil {
+		return nil, err
+	}
+	if spec.dow, err = dowField.parse(fields[4]); err != nil {
+		return nil, err
+	}
+	// Sunday may be written 0 or 7.
+	if spec.dow&(1<<7) != 0 {
+		spec.dow |= 1
+	}
+	spec.domStar = fields[2] == "*" || fields[2] == "?"
+	spec.dowStar = fields[4] == "*" || fields[4] == "?"
+	return spec, nil
+}
+
+func (f cronField) value(s string) (int, error) {
+	if n, ok := f.names[strings.ToLower(s)]; ok {
+		return n, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < f.min || n > f.max {
+		return 0, fmt.Errorf("invalid %s %q (want %d-%d)", f.name, s, f.min, f.max)
+	}
+	return n, nil
+}
+
+func (f cronField) parse(expr string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expr, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s", stepStr, f.name)
+			}
+			step = n
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case rng == "*" || rng == "?":
+		case strings.Contains(rng, "-"):
+			a, b, _ := strings.Cut(rng, "-")
+			var err error
+			if lo, err = f.value(a); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(b); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid %s range %q", f.name, rng)
+			}
+		default:
+			v, err := f.value(rng)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			if !hasStep {
+				hi = v
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Next implements Spec.
+func (c *cronSpec) Next(t time.Time) time.Time {
+	t = t.In(c.loc).Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, c.loc)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, c.loc)
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, c.loc)
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches follows cron: when both day fields are restricted, a day
+// matching either one fires.
+func (c *cronSpec) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.domStar && c.dowStar:
+		return true
+	case c.domStar:
+		return dow
+	case c.dowStar:
+		return dom
+	}
+	return dom || dow
+}
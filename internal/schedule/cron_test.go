@@ -0,0 +1,57 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func parseUTC(t *testing.T, expr string) Spec {
+	t.Helper()
+	spec, err := Parse(expr)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", expr, err)
+	}
+	if c, ok := spec.(*cronSpec); ok {
+		c.loc = time.UTC
+	}
+	return spec
+}
+
+func TestParseNext(t *testing.T) {
+	// Monday 2026-03-02 08:59
+	from := time.Date(2026, 3, 2, 8, 59, 0, 0, time.UTC)
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"0 9 * * 1-5", time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)},
+		{"30 8 * * *", time.Date(2026, 3, 3, 8, 30, 0, 0, time.UTC)},
+		{"0 0 * * sat,sun", time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 * *", time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 13 * fri", time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC)}, // Either day field matches
+		{"5/20 10 * * *", time.Date(2026, 3, 2, 10, 5, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)},
+		{"@every 30m", time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)},
+		{"every 2h", time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}}, // Never fires
+	}
+	for _, tt := range tests {
+		if got := parseUTC(t, tt.expr).Next(from); !got.Equal(tt.want) {
+			t.Errorf("%q.Next(%v) = %v, want %v", tt.expr, from, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *",
+		"* * * * 8", "5-1 * * * *", "*/0 * * * *", "@sometimes", "every 10s", "every soon"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded", expr)
+		}
+	}
+}
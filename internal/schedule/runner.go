@@ -0,0 +1,314 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/audit"
+	"github.com/shahbajlive/ntm/internal/state"
+)
+
+// Job kinds.
+const (
+	KindSend       = "send"
+	KindCheckpoint = "checkpoint"
+	KindPipeline   = "pipeline"
+	KindScan       = "scan"
+	KindHandoff    = "handoff"
+)
+
+// Kinds lists the job kinds a schedule can run.
+var Kinds = []string{KindSend, KindCheckpoint, KindPipeline, KindScan, KindHandoff}
+
+const (
+	// DefaultInterval is how often Run checks for due schedules.
+	DefaultInterval = 30 * time.Second
+	// DefaultGrace is how late a firing may be before it counts as missed.
+	DefaultGrace = 2 * time.Minute
+	// maxOutput bounds the command output kept per run.
+	maxOutput = 4096
+	// maxMissed bounds how many missed firings are counted.
+	maxMissed = 1000
+)
+
+// Executor runs a schedule's job and returns its output.
+type Executor func(ctx context.Context, s state.Schedule) (string, error)
+
+// Config configures a Runner. Store and Exec are required.
+type Config struct {
+	Store    *state.ScheduleStore
+	Exec     Executor
+	Interval time.Duration
+	Grace    time.Duration
+	Now      func() time.Time
+	// Jitter returns a random delay in [0, max); defaults to math/rand.
+	Jitter func(max time.Duration) time.Duration
+}
+
+// Runner fires due schedules. Each firing runs in its own goroutine, so a
+// long job neither delays other schedules nor makes them look missed.
+type Runner struct {
+	cfg Config
+
+	mu       sync.Mutex
+	lastTick time.Time      // When the runner was last known up; zero before the first tick
+	running  map[int64]bool // Schedules with a firing in progress
+	wg       sync.WaitGroup
+}
+
+// New creates a runner, filling in defaults for unset Config fields.
+func New(cfg Config) *Runner {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	if cfg.Grace <= 0 {
+		cfg.Grace = DefaultGrace
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	if cfg.Jitter == nil {
+		cfg.Jitter = func(max time.Duration) time.Duration {
+			return time.Duration(rand.Int63n(int64(max)))
+		}
+	}
+	return &Runner{cfg: cfg, running: make(map[int64]bool)}
+}
+
+// ValidKind reports whether kind is a known job kind.
+func ValidKind(kind string) bool {
+	for _, k := range Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// NextRun returns when s should next fire after t, including its jitter, or
+// nil if its spec never fires again.
+func (r *Runner) NextRun(s state.Schedule, t time.Time) (*time.Time, error) {
+	spec, err := Parse(s.Spec)
+	if err != nil {
+		return nil, err
+	}
+	next := spec.Next(t)
+	if next.IsZero() {
+		return nil, nil
+	}
+	if s.JitterSeconds > 0 {
+		next = next.Add(r.cfg.Jitter(time.Duration(s.JitterSeconds) * time.Second))
+	}
+	next = next.UTC()
+	return &next, nil
+}
+
+// Tick fires the session's due schedules once each, waits for them, and
+// returns their runs. A firing more than the grace period late, with no tick
+// since shortly after it was due, was missed while nothing was running: the
+// skip policy records it as skipped, catch-up fires it once for all missed
+// firings.
+func (r *Runner) Tick(ctx context.Context, session string) ([]state.ScheduleRun, error) {
+	runs, fired, err := r.tick(ctx, session)
+	for _, ch := range fired {
+		if run := <-ch; run != nil {
+			runs = append(runs, *run)
+		}
+	}
+	// The runner was up while it waited.
+	r.mu.Lock()
+	r.lastTick = r.cfg.Now()
+	r.mu.Unlock()
+	return runs, err
+}
+
+// tick records the session's skipped firings and starts the others. It
+// returns the skipped runs and, for each started firing, a channel that
+// delivers its run, or nil if recording it failed, once it finishes.
+func (r *Runner) tick(ctx context.Context, session string) ([]state.ScheduleRun, []<-chan *state.ScheduleRun, error) {
+	now := r.cfg.Now()
+	r.mu.Lock()
+	// Lateness only means the runner was down if no tick came in between;
+	// otherwise the firing was due while this runner was ticking.
+	down := r.lastTick.IsZero() || now.Sub(r.lastTick) > r.cfg.Interval+r.cfg.Grace
+	r.lastTick = now
+	r.mu.Unlock()
+
+	due, err := r.cfg.Store.Due(session, now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var runs []state.ScheduleRun
+	var fired []<-chan *state.ScheduleRun
+	for _, s := range due {
+		if ctx.Err() != nil {
+			return runs, fired, ctx.Err()
+		}
+		prev := *s.NextRunAt
+		next, err := r.NextRun(s, now)
+		if err != nil {
+			slog.Warn("schedule has an invalid spec", "schedule", s.Name, "spec", s.Spec, "error", err)
+			continue
+		}
+		taken, err := r.cfg.Store.Advance(s.ID, prev, next)
+		if err != nil {
+			return runs, fired, err
+		}
+		if !taken {
+			continue // Another runner fired it
+		}
+
+		trigger, missed := "schedule", 0
+		skip := ""
+		if down && now.Sub(prev) > r.cfg.Grace {
+			missed = countMissed(s.Spec, prev, now)
+			if s.MissedPolicy == state.MissedCatchUp {
+				trigger = "catch-up"
+			} else {
+				trigger = ""
+				skip = fmt.Sprintf("missed %d firing(s) while no monitor was running", missed)
+			}
+		}
+		if skip == "" && !r.claim(s.ID) {
+			skip = "previous run still in progress"
+		}
+		if skip != "" {
+			run := state.ScheduleRun{
+				ScheduleID:   s.ID,
+				ScheduledFor: prev,
+				StartedAt:    now,
+				FinishedAt:   &now,
+				Status:       state.ScheduleRunSkipped,
+				Missed:       missed,
+				Error:        skip,
+			}
+			if err := r.record(s, &run); err != nil {
+				return runs, fired, err
+			}
+			runs = append(runs, run)
+			continue
+		}
+
+		ch := make(chan *state.ScheduleRun, 1)
+		fired = append(fired, ch)
+		r.wg.Add(1)
+		go func(s state.Schedule, scheduledFor time.Time, trigger string, missed int) {
+			defer r.wg.Done()
+			defer r.release(s.ID)
+			run, err := r.fire(ctx, s, scheduledFor, trigger, missed)
+			if err != nil {
+				slog.Warn("recording schedule run failed", "schedule", s.Name, "error", err)
+			}
+			ch <- run
+		}(s, prev, trigger, missed)
+	}
+	return runs, fired, nil
+}
+
+// claim marks schedule id as running, reporting false if it already is.
+func (r *Runner) claim(id int64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.running[id] {
+		return false
+	}
+	r.running[id] = true
+	return true
+}
+
+func (r *Runner) release(id int64) {
+	r.mu.Lock()
+	delete(r.running, id)
+	r.mu.Unlock()
+}
+
+// RunNow fires s immediately without changing its next run.
+func (r *Runner) RunNow(ctx context.Context, s state.Schedule) (*state.ScheduleRun, error) {
+	return r.fire(ctx, s, r.cfg.Now(), "manual", 0)
+}
+
+// Run fires due schedules for session on every interval until ctx is done,
+// without waiting for one tick's jobs before the next tick. It returns once
+// the jobs it started have stopped.
+func (r *Runner) Run(ctx context.Context, session string) {
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+	defer r.wg.Wait()
+	for {
+		if _, _, err := r.tick(ctx, session); err != nil && ctx.Err() == nil {
+			slog.Warn("schedule tick failed", "session", session, "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Runner) fire(ctx context.Context, s state.Schedule, scheduledFor time.Time, trigger string, missed int) (*state.ScheduleRun, error) {
+	run := &state.ScheduleRun{
+		ScheduleID:   s.ID,
+		ScheduledFor: scheduledFor,
+		StartedAt:    r.cfg.Now(),
+		Status:       state.ScheduleRunOK,
+		Trigger:      trigger,
+		Missed:       missed,
+	}
+	out, err := r.cfg.Exec(ctx, s)
+	finished := r.cfg.Now()
+	run.FinishedAt = &finished
+	if len(out) > maxOutput {
+		out = out[len(out)-maxOutput:]
+	}
+	run.Output = out
+	if err != nil {
+		run.Status = state.ScheduleRunFailed
+		run.Error = err.Error()
+	}
+	if err := r.record(s, run); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// record stores a run and writes its audit entry.
+func (r *Runner) record(s state.Schedule, run *state.ScheduleRun) error {
+	if err := r.cfg.Store.RecordRun(run); err != nil {
+		return err
+	}
+	payload := map[string]interface{}{
+		"schedule":      s.Name,
+		"kind":          s.Kind,
+		"args":          s.Args,
+		"trigger":       run.Trigger,
+		"status":        run.Status,
+		"scheduled_for": run.ScheduledFor.UTC().Format(time.RFC3339),
+	}
+	if run.Missed > 0 {
+		payload["missed"] = run.Missed
+	}
+	if run.Error != "" {
+		payload["error"] = run.Error
+	}
+	_ = audit.LogEvent(s.SessionName, audit.EventTypeCommand, audit.ActorSystem, "schedule."+s.Kind, payload, nil)
+	return nil
+}
+
+// countMissed counts the firings of spec from first through now.
+func countMissed(spec string, first, now time.Time) int {
+	parsed, err := Parse(spec)
+	if err != nil {
+		return 1
+	}
+	n := 1
+	for t := parsed.Next(first); !t.IsZero() && !t.After(now) && n < maxMissed; t = parsed.Next(t) {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,153 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/state"
+)
+
+func openSchedules(t *testing.T) *state.ScheduleStore {
+	t.Helper()
+	store, err := state.Open(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	if err := store.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return state.NewScheduleStore(store)
+}
+
+func TestRunnerTick(t *testing.T) {
+	ss := openSchedules(t)
+	now := time.Date(2026, 3, 2, 9, 0, 20, 0, time.UTC)
+	var mu sync.Mutex
+	var fired []string
+	r := New(Config{
+		Store: ss,
+		Exec: func(_ context.Context, s state.Schedule) (string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			fired = append(fired, s.Name)
+			if s.Kind == KindScan {
+				return "", errors.New("ubs not installed")
+			}
+			return "ok", nil
+		},
+		Now:    func() time.Time { return now },
+		Jitter: func(max time.Duration) time.Duration { return max / 2 },
+	})
+
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+	for _, s := range []*state.Schedule{
+		{Name: "standup", Kind: KindSend, Spec: "@every 1h", NextRunAt: at(-20 * time.Second), JitterSeconds: 60},
+		{Name: "scan", Kind: KindScan, Spec: "@every 1h", NextRunAt: at(-10 * time.Second)},
+		{Name: "missed", Kind: KindCheckpoint, Spec: "@every 1h", NextRunAt: at(-3*time.Hour - 20*time.Second)},
+		{Name: "catchup", Kind: KindHandoff, Spec: "@every 1h", NextRunAt: at(-3*time.Hour - 20*time.Second), MissedPolicy: state.MissedCatchUp},
+		{Name: "later", Kind: KindSend, Spec: "@every 1h", NextRunAt: at(time.Hour)},
+	} {
+		s.SessionName = "proj"
+		if err := ss.Create(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runs, err := r.Tick(context.Background(), "proj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 4 || len(fired) != 3 {
+		t.Fatalf("runs = %+v, fired = %v", runs, fired)
+	}
+	byName := func(name string) state.ScheduleRun {
+		s, _ := ss.GetByName(name)
+		list, _ := ss.Runs(s.ID, 1)
+		if len(list) != 1 {
+			t.Fatalf("%s has %d runs", name, len(list))
+		}
+		return list[0]
+	}
+	if run := byName("scan"); run.Status != state.ScheduleRunFailed || run.Error != "ubs not installed" {
+		t.Errorf("scan run = %+v", run)
+	}
+	if run := byName("missed"); run.Status != state.ScheduleRunSkipped || run.Missed != 4 {
+		t.Errorf("missed run = %+v, want skipped with 4 missed", run)
+	}
+	if run := byName("catchup"); run.Status != state.ScheduleRunOK || run.Trigger != "catch-up" || run.Missed != 4 {
+		t.Errorf("catch-up run = %+v", run)
+	}
+
+	standup, _ := ss.GetByName("standup")
+	if want := time.Date(2026, 3, 2, 10, 0, 30, 0, time.UTC); standup.NextRunAt == nil || !standup.NextRunAt.Equal(want) {
+		t.Errorf("standup next run = %v, want %v (hour boundary plus jitter)", standup.NextRunAt, want)
+	}
+
+	// Nothing is due again until the next firing.
+	fired = nil
+	if runs, _ := r.Tick(context.Background(), "proj"); len(runs) != 0 || len(fired) != 0 {
+		t.Errorf("second tick fired %v", fired)
+	}
+
+	// A manual run leaves the next firing alone.
+	run, err := r.RunNow(context.Background(), *standup)
+	if err != nil || run.Trigger != "manual" || run.Status != state.ScheduleRunOK {
+		t.Fatalf("RunNow = %+v, %v", run, err)
+	}
+	if s, _ := ss.GetByName("standup"); !s.NextRunAt.Equal(*standup.NextRunAt) || s.RunCount != 2 {
+		t.Errorf("after RunNow = %+v", s)
+	}
+}
+
+func TestRunnerLateWhileBusyIsNotMissed(t *testing.T) {
+	ss := openSchedules(t)
+	var mu sync.Mutex
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	clock := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	r := New(Config{
+		Store: ss,
+		Exec: func(_ context.Context, s state.Schedule) (string, error) {
+			if s.Name == "slow" {
+				mu.Lock()
+				now = now.Add(30 * time.Minute)
+				mu.Unlock()
+			}
+			return "ok", nil
+		},
+		Now: clock,
+	})
+
+	slowAt, quickAt := now, now.Add(time.Second)
+	for _, s := range []*state.Schedule{
+		{Name: "slow", Kind: KindPipeline, Spec: "@every 1h", NextRunAt: &slowAt},
+		{Name: "quick", Kind: KindSend, Spec: "@every 1h", NextRunAt: &quickAt},
+	} {
+		s.SessionName = "proj"
+		if err := ss.Create(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// quick comes due while slow runs for half an hour: it is late, but
+	// the runner was up, so it fires rather than being skipped as missed.
+	ctx := context.Background()
+	if runs, err := r.Tick(ctx, "proj"); err != nil || len(runs) != 1 {
+		t.Fatalf("first tick = %+v, %v", runs, err)
+	}
+	runs, err := r.Tick(ctx, "proj")
+	if err != nil || len(runs) != 1 || runs[0].Status != state.ScheduleRunOK || runs[0].Missed != 0 {
+		t.Errorf("second tick = %+v, %v; want quick fired on schedule", runs, err)
+	}
+}
@@ -0,0 +1,218 @@
+package segment
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// grammar classifies the lines of one agent CLI's transcript. Lines it
+// does not recognize continue the current block.
+type grammar struct {
+	// classify recognizes a line that opens a block. afterRule reports
+	// whether a horizontal rule precedes it, as it does the input line.
+	classify func(p *parser, line string, afterRule bool) (class, bool)
+
+	// openBox decides from its first line what a box holds; boxes are
+	// chrome unless it says otherwise.
+	openBox func(inner string) (box, class)
+
+	// callOutput makes unmarked lines after a tool call its result
+	callOutput bool
+
+	// fixup adjusts the blocks once the whole capture is parsed
+	fixup func(blocks []Block) []Block
+}
+
+// lookupGrammar returns the grammar for agentType, or nil when the type
+// says nothing about what runs in the pane.
+func lookupGrammar(agentType string) *grammar {
+	switch strings.ToLower(strings.TrimSpace(agentType)) {
+	case "claude", "cc", "claude-code":
+		return claudeGrammar
+	case "codex", "cod":
+		return codexGrammar
+	case "gemini", "gmi":
+		return geminiGrammar
+	case "", "unknown", "user":
+		return nil
+	}
+	return genericGrammar
+}
+
+// Claude Code: "> prompt", "⏺ prose" or "⏺ Tool(args)", "  ⎿  result",
+// edits followed by numbered changed lines.
+var (
+	claudeGrammar = &grammar{classify: classifyClaude, openBox: openClaudeBox}
+
+	claudeStatus = regexp.MustCompile(`esc to interrupt|\? for shortcuts|^⏵⏵|accept edits on|bypass permissions on|plan mode on|auto-compact|^[✻✶✳✢✽·*] \S+(?:…|\.\.\.)|^[✻✶✳✢✽·*] \w+ for \d`)
+	claudeCall   = regexp.MustCompile(`^([A-Za-z][\w.:-]*(?: - [\w.:-]+)?(?: \(MCP\))?)\((.*?)\)?$`)
+)
+
+func classifyClaude(p *parser, line string, afterRule bool) (class, bool) {
+	if t := strings.TrimSpace(line); claudeStatus.MatchString(t) {
+		return class{kind: KindStatus, text: t, join: true}, true
+	}
+	if rest, col, ok := cutMarker(line, "⏺", "●"); ok {
+		if m := claudeCall.FindStringSubmatch(rest); m != nil {
+			return class{kind: KindToolCall, tool: m[1], file: fileArg(m[2]), text: m[2], col: col}, true
+		}
+		return class{kind: KindProse, text: rest, col: col}, true
+	}
+	if rest, col, ok := cutResult(line, "⎿"); ok {
+		return toolResult(rest, col), true
+	}
+	if rest, col, ok := cutMarker(line, ">", "❯"); ok {
+		if afterRule || rest == "" {
+			return class{kind: KindStatus, text: strings.TrimSpace(line), join: true}, true
+		}
+		return class{kind: KindPrompt, text: rest, col: col}, true
+	}
+	return class{}, false
+}
+
+func openClaudeBox(inner string) (box, class) {
+	return boxStatus, class{}
+}
+
+// Codex: "› prompt", "• prose" or "• Ran cmd", "  └ result", edits
+// followed by numbered changed lines, and the composer above the footer.
+var (
+	codexGrammar = &grammar{classify: classifyCodex, fixup: demoteComposer}
+
+	codexStatus = regexp.MustCompile(`(?i)esc to interrupt|% context left|\? for shortcuts|⏎ send|ctrl\+j newline|^─ Worked for`)
+	codexTool   = regexp.MustCompile(`^(Ran|Running|Edited|Editing|Added|Deleted|Explored|Exploring|Searched|Searching|Listed|Listing|Called|Calling|Updated Plan|Applied patch|Applying patch|Waited|Waiting)\b ?(.*)$`)
+)
+
+func classifyCodex(p *parser, line string, afterRule bool) (class, bool) {
+	if t := strings.TrimSpace(line); codexStatus.MatchString(t) {
+		return class{kind: KindStatus, text: t, join: true}, true
+	}
+	if rest, col, ok := cutMarker(line, "•"); ok {
+		if m := codexTool.FindStringSubmatch(rest); m != nil {
+			return class{kind: KindToolCall, tool: m[1], file: fileArg(m[2]), text: m[2], col: col}, true
+		}
+		return class{kind: KindProse, text: rest, col: col}, true
+	}
+	if rest, col, ok := cutResult(line, "└"); ok {
+		return toolResult(rest, col), true
+	}
+	if rest, col, ok := cutResult(line, "│"); ok && p.cur != nil && p.cur.Kind == KindToolCall {
+		// A command too long for one line
+		return class{kind: KindToolCall, text: rest, col: col, join: true}, true
+	}
+	if rest, col, ok := cutMarker(line, "■"); ok {
+		return class{kind: KindError, text: rest, col: col}, true
+	}
+	if rest, col, ok := cutMarker(line, "›", "▌"); ok {
+		if rest == "" {
+			return class{kind: KindStatus, text: strings.TrimSpace(line), join: true}, true
+		}
+		return class{kind: KindPrompt, text: rest, col: col}, true
+	}
+	return class{}, false
+}
+
+// demoteComposer turns the composer, drawn like a sent prompt but with
+// only status lines after it, into status.
+func demoteComposer(blocks []Block) []Block {
+	last := -1
+	for i, b := range blocks {
+		if b.Kind == KindPrompt {
+			last = i
+		}
+	}
+	if last < 0 || last == len(blocks)-1 {
+		return blocks
+	}
+	for _, b := range blocks[last+1:] {
+		if b.Kind != KindStatus {
+			return blocks
+		}
+	}
+	blocks[last].Kind = KindStatus
+	for i := last; i < len(blocks); i++ {
+		blocks[i].Turn--
+	}
+
+	merged := blocks[:0]
+	for _, b := range blocks {
+		if n := len(merged); n > 0 && b.Kind == KindStatus && merged[n-1].Kind == KindStatus {
+			merged[n-1].Text += "\n" + b.Text
+			merged[n-1].EndLine = b.EndLine
+			continue
+		}
+		merged = append(merged, b)
+	}
+	return merged
+}
+
+// Gemini CLI: "> prompt", "✦ prose", tool calls in boxes headed
+// "✔  Tool args", "✕ error", and a boxed input line.
+var (
+	geminiGrammar = &grammar{classify: classifyGemini, openBox: openGeminiBox, callOutput: true}
+
+	geminiStatus = regexp.MustCompile(`(?i)esc to cancel|context left\)|no sandbox|^Using:? \d+ |Type your message|^[⠋⠙⠹⠸⠼⠴⠦⠧⠇⠏] |YOLO mode|accepting edits`)
+	geminiTool   = regexp.MustCompile(`^[✔✓xX✗⊷o?-]\s+([A-Z]\w*)\s*(.*)$`)
+)
+
+func classifyGemini(p *parser, line string, afterRule bool) (class, bool) {
+	if t := strings.TrimSpace(line); geminiStatus.MatchString(t) {
+		return class{kind: KindStatus, text: t, join: true}, true
+	}
+	if rest, col, ok := cutMarker(line, "✦"); ok {
+		return class{kind: KindProse, text: rest, col: col}, true
+	}
+	if rest, col, ok := cutMarker(line, "✕"); ok {
+		return class{kind: KindError, text: rest, col: col}, true
+	}
+	if rest, col, ok := cutMarker(line, ">"); ok && rest != "" && p.box == boxNone {
+		return class{kind: KindPrompt, text: rest, col: col}, true
+	}
+	return class{}, false
+}
+
+func openGeminiBox(inner string) (box, class) {
+	if m := geminiTool.FindStringSubmatch(strings.TrimSpace(inner)); m != nil {
+		return boxTool, class{kind: KindToolCall, tool: m[1], file: fileArg(m[2]), text: m[2]}
+	}
+	return boxStatus, class{}
+}
+
+// Shells and agents without a grammar: only the generic "$ cmd", error
+// and diff rules apply, and a command's output is its result.
+var genericGrammar = &grammar{
+	classify:   func(*parser, string, bool) (class, bool) { return class{}, false },
+	callOutput: true,
+}
+
+// cutMarker returns the rest of a line starting with one of markers,
+// and the column the rest starts at.
+func cutMarker(line string, markers ...string) (string, int, bool) {
+	for _, m := range markers {
+		rest, ok := strings.CutPrefix(line, m)
+		if !ok || (rest != "" && rest[0] != ' ') {
+			continue
+		}
+		rest = strings.TrimLeft(rest, " ")
+		return rest, utf8.RuneCountInString(line) - utf8.RuneCountInString(rest), true
+	}
+	return "", 0, false
+}
+
+// cutResult is cutMarker for result markers, which are indented under
+// their call.
+func cutResult(line, marker string) (string, int, bool) {
+	indent := len(line) - len(strings.TrimLeft(line, " "))
+	rest, col, ok := cutMarker(line[indent:], marker)
+	return rest, indent + col, ok
+}
+
+// toolResult classifies a tool's result line, which may report an error.
+func toolResult(text string, col int) class {
+	kind := KindToolResult
+	if strings.HasPrefix(text, "Error") || errorLine.MatchString(text) {
+		kind = KindError
+	}
+	return class{kind: kind, text: text, col: col}
+}
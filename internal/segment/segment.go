@@ -0,0 +1,498 @@
+// Package segment splits agent pane output into structured blocks: the
+// prompts a user sent, the assistant's prose, tool calls and their results,
+// diffs, errors and the status bar. Each agent CLI draws its transcript
+// differently, so lines are classified by a per-agent grammar, with a
+// generic grammar for shells and agents without one.
+package segment
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Kind is the kind of a block.
+type Kind string
+
+// Block kinds
+const (
+	KindPrompt     Kind = "prompt"      // A prompt the user sent
+	KindProse      Kind = "prose"       // The assistant's text
+	KindToolCall   Kind = "tool_call"   // A tool invocation with its arguments
+	KindToolResult Kind = "tool_result" // Output of the preceding tool call
+	KindDiff       Kind = "diff"        // A patch or an edit's changed lines
+	KindError      Kind = "error"       // An error reported by the agent or a tool
+	KindStatus     Kind = "status"      // Spinners, the input box and the status bar
+)
+
+// Block is a run of output lines of one kind.
+type Block struct {
+	Kind      Kind   `json:"kind"`
+	Turn      int    `json:"turn"`           // Prompts seen so far; 0 before the first
+	Tool      string `json:"tool,omitempty"` // Tool name for calls, their results and errors
+	File      string `json:"file,omitempty"` // File an edit or diff touches, when known
+	Text      string `json:"text"`           // Lines with the agent's markers removed
+	StartLine int    `json:"start_line"`     // 1-based line in the capture
+	EndLine   int    `json:"end_line"`
+}
+
+// Headline returns a one-line description of the block, e.g.
+// "Bash go test ./..." for a tool call or "main.go +2 -1" for a diff.
+func (b Block) Headline() string {
+	first := strings.TrimSpace(firstLine(b.Text))
+	switch b.Kind {
+	case KindToolCall:
+		return strings.TrimSpace(b.Tool + " " + first)
+	case KindDiff:
+		added, removed := b.DiffStat()
+		name := b.File
+		if name == "" {
+			name = "diff"
+		}
+		return fmt.Sprintf("%s +%d -%d", name, added, removed)
+	}
+	return first
+}
+
+// DiffStat counts the lines a diff block adds and removes.
+func (b Block) DiffStat() (added, removed int) {
+	if b.Kind != KindDiff {
+		return 0, 0
+	}
+	for _, line := range strings.Split(b.Text, "\n") {
+		if m := numberedDiffLine.FindStringSubmatch(line); m != nil {
+			line = m[1]
+		} else if strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---") {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "+"):
+			added++
+		case strings.HasPrefix(line, "-"):
+			removed++
+		}
+	}
+	return added, removed
+}
+
+// Latest holds the most recent block of each kind consumers ask about.
+type Latest struct {
+	Turns      int    `json:"turns"`
+	Prompt     *Block `json:"last_prompt,omitempty"`
+	ToolCall   *Block `json:"last_tool_call,omitempty"`
+	ToolResult *Block `json:"last_tool_result,omitempty"`
+	Diff       *Block `json:"last_diff,omitempty"`
+	Error      *Block `json:"last_error,omitempty"`
+}
+
+// Summarize returns the latest prompt, tool call, result, diff and error
+// in blocks.
+func Summarize(blocks []Block) Latest {
+	var l Latest
+	for i := range blocks {
+		b := &blocks[i]
+		l.Turns = b.Turn
+		switch b.Kind {
+		case KindPrompt:
+			l.Prompt = b
+		case KindToolCall:
+			l.ToolCall = b
+		case KindToolResult:
+			l.ToolResult = b
+		case KindDiff:
+			l.Diff = b
+		case KindError:
+			l.Error = b
+		}
+	}
+	return l
+}
+
+// Last returns the last block of kind, or nil if there is none.
+func Last(blocks []Block, kind Kind) *Block {
+	for i := len(blocks) - 1; i >= 0; i-- {
+		if blocks[i].Kind == kind {
+			return &blocks[i]
+		}
+	}
+	return nil
+}
+
+// Filter returns the blocks of the given kinds.
+func Filter(blocks []Block, kinds ...Kind) []Block {
+	var out []Block
+	for _, b := range blocks {
+		for _, k := range kinds {
+			if b.Kind == k {
+				out = append(out, b)
+				break
+			}
+		}
+	}
+	return out
+}
+
+var (
+	ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]|\x1b\][^\x07]*\x07|\x1b[()][A-Z0-9]`)
+
+	// A line of an edit shown with line numbers: "  12 -  old", "  12 +  new"
+	// or "  12    context"
+	numberedDiffLine = regexp.MustCompile(`^\s*\d+(?: ([ +\-].*))?$`)
+
+	// Unified diff headers and hunk body
+	diffHeader = regexp.MustCompile(`^(?:diff --git |@@ -\d+(?:,\d+)? \+\d+(?:,\d+)? @@)`)
+	diffBody   = regexp.MustCompile(`^(?:[ +\-\\]|@@ |diff --git |index [0-9a-f]+\.\.|(?:new|deleted) file mode |similarity index |rename (?:from|to) )`)
+
+	errorLine = regexp.MustCompile(`(?i)^(?:error|fatal|panic)(?:\[\w+\])?[: ]|^Traceback \(most recent call last\)|^\w+(?:Error|Exception):|^npm ERR!|^FAIL\b`)
+	shellCmd  = regexp.MustCompile(`^(?:[\w@.-]+:[~\w/.-]*)?[$#] (\S.*)$`)
+	pureRule  = regexp.MustCompile(`^[─━]{3,}$`)
+)
+
+// Parse splits text captured from a pane running agentType (cc, cod, gmi
+// or their long names) into blocks. An unknown or empty agentType is
+// guessed from the output's markers.
+func Parse(agentType, text string) []Block {
+	lines := strings.Split(ansiEscape.ReplaceAllString(text, ""), "\n")
+	g := lookupGrammar(agentType)
+	if g == nil {
+		g = lookupGrammar(Guess(text))
+	}
+	if g == nil {
+		g = genericGrammar
+	}
+	p := &parser{g: g}
+	for i, line := range lines {
+		p.line(i+1, strings.TrimRight(line, " \t\r"))
+	}
+	p.flush()
+	if g.fixup != nil {
+		p.blocks = g.fixup(p.blocks)
+	}
+	return p.blocks
+}
+
+// Guess returns the agent whose markers text contains, or "" when it
+// looks like plain shell output.
+func Guess(text string) string {
+	counts := map[string]int{
+		"claude": strings.Count(text, "⏺") + strings.Count(text, "⎿"),
+		"codex":  strings.Count(text, "\n• ") + strings.Count(text, "└ ") + strings.Count(text, "\n› "),
+		"gemini": strings.Count(text, "✦") + strings.Count(text, "╭"),
+	}
+	best, n := "", 0
+	for _, name := range []string{"claude", "codex", "gemini"} {
+		if counts[name] > n {
+			best, n = name, counts[name]
+		}
+	}
+	return best
+}
+
+// class is how a grammar classifies a line.
+type class struct {
+	kind Kind
+	tool string
+	file string
+	text string // The line without its marker
+	col  int    // Column the text starts at, stripped from continuation lines
+	join bool   // Extend the current block when it is of the same kind
+}
+
+// box is the kind of the box drawn around the current lines.
+type box int
+
+const (
+	boxNone    box = iota
+	boxPending     // Opened; its first line decides what it holds
+	boxTool        // A tool call and its output
+	boxStatus      // The input box, banners and other chrome
+)
+
+type parser struct {
+	g      *grammar
+	blocks []Block
+
+	cur   *Block
+	lines []string
+	col   int
+	blank int // Blank lines seen since the current block's last line
+	turn  int
+
+	tool        string // Tool of the last call, for its results
+	editFile    string // File of the last edit call; numbered lines that follow are its diff
+	unifiedDiff bool   // The current block is a unified diff
+	diffIndent  int    // Indent of the unified diff's lines
+	box         box
+	afterRule   bool // The previous line was a horizontal rule
+}
+
+func (p *parser) line(n int, line string) {
+	if strings.TrimSpace(line) == "" {
+		if p.cur != nil {
+			p.blank++
+		}
+		return
+	}
+
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case strings.HasPrefix(trimmed, "╭"):
+		p.box = boxPending
+		return
+	case strings.HasPrefix(trimmed, "╰"):
+		p.box = boxNone
+		return
+	case pureRule.MatchString(trimmed):
+		p.afterRule = true
+		return
+	}
+	afterRule := p.afterRule
+	p.afterRule = false
+
+	if p.box != boxNone {
+		inner, ok := unbox(line)
+		if !ok {
+			p.box = boxNone
+		} else {
+			if strings.TrimSpace(inner) == "" {
+				if p.cur != nil {
+					p.blank++
+				}
+				return
+			}
+			switch p.box {
+			case boxPending:
+				b, c := boxStatus, class{}
+				if p.g.openBox != nil {
+					b, c = p.g.openBox(inner)
+				}
+				p.box = b
+				if b == boxStatus {
+					p.start(n, class{kind: KindStatus, text: strings.TrimSpace(inner), join: true})
+				} else {
+					p.classified(n, c)
+				}
+				return
+			case boxStatus:
+				p.start(n, class{kind: KindStatus, text: strings.TrimSpace(inner), join: true})
+				return
+			}
+			line = inner
+		}
+	}
+
+	if p.unifiedDiff {
+		if body := cutIndent(line, p.diffIndent); diffBody.MatchString(body) {
+			p.extend(n, body)
+			return
+		}
+		p.unifiedDiff = false
+	}
+	indent := len(line) - len(strings.TrimLeft(line, " "))
+	if diffHeader.MatchString(strings.TrimSpace(line)) || strings.HasPrefix(strings.TrimSpace(line), "--- a/") {
+		p.startDiff(n, strings.TrimSpace(line), indent)
+		return
+	}
+
+	if c, ok := p.g.classify(p, line, afterRule); ok {
+		p.classified(n, c)
+		return
+	}
+	if indent == 0 {
+		if c, ok := classifyGeneric(line); ok {
+			p.classified(n, c)
+			return
+		}
+	}
+
+	if p.editFile != "" && numberedDiffLine.MatchString(line) {
+		if p.cur != nil && p.cur.Kind == KindDiff && !p.unifiedDiff {
+			p.extend(n, line)
+		} else {
+			p.start(n, class{kind: KindDiff, tool: p.tool, file: p.editFile, text: line})
+		}
+		return
+	}
+	p.continuation(n, line)
+}
+
+// classified starts or extends a block for a line a grammar recognized.
+func (p *parser) classified(n int, c class) {
+	if c.join && p.cur != nil && p.cur.Kind == c.kind {
+		p.extend(n, c.text)
+		return
+	}
+	switch c.kind {
+	case KindToolCall:
+		if !isEditTool(c.tool) && !readTools[strings.ToLower(c.tool)] {
+			c.file = ""
+		}
+		p.tool = c.tool
+		p.editFile = ""
+		if isEditTool(c.tool) {
+			p.editFile = c.file
+		}
+	case KindToolResult, KindError:
+		if c.tool == "" {
+			c.tool = p.tool
+		}
+	default:
+		p.editFile = ""
+	}
+	p.start(n, c)
+}
+
+// continuation adds an unmarked line to the current block, or starts
+// prose or a tool result when the current block cannot take it.
+func (p *parser) continuation(n int, line string) {
+	switch {
+	case p.cur == nil, p.cur.Kind == KindStatus, p.cur.Kind == KindDiff:
+		p.start(n, class{kind: KindProse, text: strings.TrimSpace(line)})
+	case p.cur.Kind == KindToolCall && p.g.callOutput:
+		p.start(n, class{kind: KindToolResult, tool: p.tool, text: line})
+	default:
+		p.extend(n, cutIndent(line, p.col))
+	}
+}
+
+func (p *parser) start(n int, c class) {
+	if c.join && p.cur != nil && p.cur.Kind == c.kind {
+		p.extend(n, c.text)
+		return
+	}
+	p.flush()
+	if c.kind == KindPrompt {
+		p.turn++
+	}
+	p.cur = &Block{Kind: c.kind, Turn: p.turn, Tool: c.tool, File: c.file, StartLine: n, EndLine: n}
+	p.lines = []string{c.text}
+	p.col = c.col
+}
+
+func (p *parser) startDiff(n int, header string, indent int) {
+	p.start(n, class{kind: KindDiff, tool: p.tool, text: header})
+	p.cur.File = diffFile(header)
+	p.unifiedDiff = true
+	p.diffIndent = indent
+}
+
+func (p *parser) extend(n int, text string) {
+	if p.cur.Kind == KindDiff && p.cur.File == "" && p.unifiedDiff {
+		p.cur.File = diffFile(text)
+	}
+	for ; p.blank > 0; p.blank-- {
+		p.lines = append(p.lines, "")
+	}
+	p.lines = append(p.lines, text)
+	p.cur.EndLine = n
+}
+
+func (p *parser) flush() {
+	p.blank = 0
+	if p.cur == nil {
+		return
+	}
+	if p.cur.Kind == KindDiff && !p.unifiedDiff {
+		p.lines = dedent(p.lines)
+	}
+	p.cur.Text = strings.Join(p.lines, "\n")
+	p.blocks = append(p.blocks, *p.cur)
+	p.cur, p.lines = nil, nil
+}
+
+// classifyGeneric recognizes shell commands and errors at the start of a
+// line, which look the same whatever runs in the pane.
+func classifyGeneric(line string) (class, bool) {
+	if m := shellCmd.FindStringSubmatch(line); m != nil {
+		return class{kind: KindToolCall, tool: "shell", text: m[1]}, true
+	}
+	if errorLine.MatchString(line) {
+		return class{kind: KindError, text: line}, true
+	}
+	return class{}, false
+}
+
+// editTools are the tools whose calls are followed by the changed lines.
+var editTools = map[string]bool{
+	"update": true, "edit": true, "multiedit": true, "write": true, "notebookedit": true, // Claude
+	"edited": true, "added": true, // Codex
+	"writefile": true, "replace": true, // Gemini
+}
+
+// readTools are the other tools whose arguments name the file they work on.
+var readTools = map[string]bool{"read": true, "notebookread": true, "readfile": true, "deleted": true}
+
+func isEditTool(tool string) bool {
+	return editTools[strings.ToLower(tool)]
+}
+
+// fileArg returns the first path-like word of a tool call's arguments.
+func fileArg(args string) string {
+	for _, f := range strings.Fields(args) {
+		f = strings.Trim(f, ":,;()\"'`")
+		if strings.ContainsAny(f, "/.") && !strings.HasPrefix(f, "-") && f != "." {
+			return f
+		}
+	}
+	return ""
+}
+
+// diffFile returns the file a unified diff header names.
+func diffFile(header string) string {
+	switch {
+	case strings.HasPrefix(header, "diff --git "):
+		fields := strings.Fields(header)
+		return strings.TrimPrefix(fields[len(fields)-1], "b/")
+	case strings.HasPrefix(header, "+++ "), strings.HasPrefix(header, "--- "):
+		name := strings.Fields(header[4:])
+		if len(name) == 0 || name[0] == "/dev/null" {
+			return ""
+		}
+		return strings.TrimPrefix(strings.TrimPrefix(name[0], "a/"), "b/")
+	}
+	return ""
+}
+
+// unbox returns a line drawn inside a box without its borders.
+func unbox(line string) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+	inner, ok := strings.CutPrefix(trimmed, "│")
+	if !ok {
+		return "", false
+	}
+	inner = strings.TrimSuffix(strings.TrimRight(inner, " "), "│")
+	return strings.TrimPrefix(strings.TrimRight(inner, " "), " "), true
+}
+
+// cutIndent removes up to n leading spaces from line.
+func cutIndent(line string, n int) string {
+	i := 0
+	for i < n && i < len(line) && line[i] == ' ' {
+		i++
+	}
+	return line[i:]
+}
+
+// dedent removes the indent all non-blank lines share.
+func dedent(lines []string) []string {
+	indent := -1
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		if n := len(l) - len(strings.TrimLeft(l, " ")); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	for i, l := range lines {
+		lines[i] = cutIndent(l, indent)
+	}
+	return lines
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
@@ -0,0 +1,232 @@
+package segment
+
+import (
+	"strings"
+	"testing"
+)
+
+const claudeCapture = `╭───────────────────────────────────────────╮
+│ ✻ Welcome to Claude Code!                 │
+╰───────────────────────────────────────────╯
+
+> fix the greeting in main.go
+
+⏺ I'll read the file first.
+
+⏺ Read(main.go)
+  ⎿  Read 12 lines (ctrl+r to expand)
+
+⏺ Update(main.go)
+  ⎿  Updated main.go with 1 addition and 1 removal
+        3    func main() {
+        4 -    fmt.Println("helo")
+        4 +    fmt.Println("hello")
+        5 +    os.Exit(0)
+        6    }
+
+⏺ Bash(go test ./...)
+  ⎿  Error: FAIL ./... [build failed]
+     main.go:5:2: undefined: os
+
+⏺ The test failed because os is not imported;
+  I will add it.
+
+✻ Thinking… (12s · esc to interrupt)
+
+────────────────────────────────────────────
+>
+────────────────────────────────────────────
+  ? for shortcuts
+`
+
+const codexCapture = `╭──────────────────────────────╮
+│ >_ OpenAI Codex (v0.46.0)    │
+╰──────────────────────────────╯
+
+› add a test for Parse
+
+• I'll look at the package first.
+
+• Explored
+  └ Read parse.go
+
+• Ran go test ./internal/parse
+  │ -run TestParse
+  └ ok  	pkg/parse	0.01s
+
+• Edited parse_test.go (+3 -0)
+    10     }
+    11 +
+    12 +func TestParse(t *testing.T) {
+    13 +}
+
+■ stream error: connection reset
+
+• Working (4s • esc to interrupt)
+
+› Summarize recent commits
+
+  100% context left · ? for shortcuts
+`
+
+const geminiCapture = `> rename foo to bar
+
+✦ I will update the function name.
+
+╭──────────────────────────────────────────╮
+│ ✔  ReadFile util.go                      │
+│                                          │
+│    func foo() {}                         │
+╰──────────────────────────────────────────╯
+╭──────────────────────────────────────────╮
+│ ✔  Edit util.go: func foo => func bar    │
+│                                          │
+│ 1 - func foo() {}                        │
+│ 1 + func bar() {}                        │
+╰──────────────────────────────────────────╯
+
+✕ [API Error: quota exceeded]
+
+⠏ Thinking (esc to cancel, 2s)
+
+╭──────────────────────────────────────────╮
+│ >   Type your message or @path/to/file   │
+╰──────────────────────────────────────────╯
+~/proj (main*)     no sandbox     gemini-2.5-pro (97% context left)
+`
+
+const shellCapture = `$ git diff
+diff --git a/README.md b/README.md
+index 3b18e51..a5c1d2f 100644
+--- a/README.md
++++ b/README.md
+@@ -1,2 +1,2 @@
+ # ntm
+-old line
++new line
+$ go build ./...
+error: cannot find package
+$ ls
+README.md
+`
+
+// kinds renders blocks as "kind:tool:file" for comparison.
+func kinds(blocks []Block) []string {
+	var out []string
+	for _, b := range blocks {
+		s := string(b.Kind)
+		if b.Tool != "" || b.File != "" {
+			s += ":" + b.Tool
+		}
+		if b.File != "" {
+			s += ":" + b.File
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name, agent, text string
+		want              []string
+	}{
+		{"claude", "cc", claudeCapture, []string{
+			"status", "prompt", "prose",
+			"tool_call:Read:main.go", "tool_result:Read",
+			"tool_call:Update:main.go", "tool_result:Update", "diff:Update:main.go",
+			"tool_call:Bash", "error:Bash",
+			"prose", "status",
+		}},
+		{"codex", "cod", codexCapture, []string{
+			"status", "prompt", "prose",
+			"tool_call:Explored", "tool_result:Explored",
+			"tool_call:Ran", "tool_result:Ran",
+			"tool_call:Edited:parse_test.go", "diff:Edited:parse_test.go",
+			"error:Edited", "status",
+		}},
+		{"gemini", "gemini", geminiCapture, []string{
+			"prompt", "prose",
+			"tool_call:ReadFile:util.go", "tool_result:ReadFile",
+			"tool_call:Edit:util.go", "diff:Edit:util.go",
+			"error:Edit", "status",
+		}},
+		{"shell", "", shellCapture, []string{
+			"tool_call:shell", "diff:shell:README.md",
+			"tool_call:shell", "error:shell",
+			"tool_call:shell", "tool_result:shell",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := Parse(tt.agent, tt.text)
+			got := kinds(blocks)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("blocks:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+				for _, b := range blocks {
+					t.Logf("%d-%d %s %q", b.StartLine, b.EndLine, b.Kind, b.Text)
+				}
+			}
+		})
+	}
+}
+
+func TestParseClaudeDetails(t *testing.T) {
+	blocks := Parse("claude", claudeCapture)
+	l := Summarize(blocks)
+	if l.Turns != 1 || l.Prompt == nil || l.Prompt.Text != "fix the greeting in main.go" {
+		t.Errorf("prompt = %+v, turns %d", l.Prompt, l.Turns)
+	}
+	if l.ToolCall == nil || l.ToolCall.Headline() != "Bash go test ./..." {
+		t.Errorf("last tool call = %+v", l.ToolCall)
+	}
+	if l.Diff == nil || l.Diff.Headline() != "main.go +2 -1" {
+		t.Fatalf("last diff = %+v", l.Diff)
+	}
+	if !strings.HasPrefix(l.Diff.Text, "3    func main() {") {
+		t.Errorf("diff not dedented:\n%s", l.Diff.Text)
+	}
+	if l.Error == nil || !strings.Contains(l.Error.Text, "undefined: os") {
+		t.Errorf("last error = %+v", l.Error)
+	}
+	prose := Last(blocks, KindProse)
+	if prose == nil || prose.Text != "The test failed because os is not imported;\nI will add it." {
+		t.Errorf("prose = %+v", prose)
+	}
+	if prose.StartLine != 24 || prose.EndLine != 25 {
+		t.Errorf("prose lines = %d-%d, want 24-25", prose.StartLine, prose.EndLine)
+	}
+}
+
+func TestParseCodexComposer(t *testing.T) {
+	blocks := Parse("codex", codexCapture)
+	if p := Last(blocks, KindPrompt); p == nil || p.Text != "add a test for Parse" {
+		t.Errorf("last prompt = %+v", p)
+	}
+	last := blocks[len(blocks)-1]
+	if last.Turn != 1 || !strings.Contains(last.Text, "Summarize recent commits") {
+		t.Errorf("composer block = %+v", last)
+	}
+	if call := Last(blocks, KindToolCall); call.Headline() != "Edited parse_test.go (+3 -0)" {
+		t.Errorf("last call = %q", call.Headline())
+	}
+	if ran := Filter(blocks, KindToolCall)[1]; ran.Text != "go test ./internal/parse\n-run TestParse" {
+		t.Errorf("multi-line command = %q", ran.Text)
+	}
+}
+
+func TestGuess(t *testing.T) {
+	for want, text := range map[string]string{
+		"claude": claudeCapture,
+		"codex":  codexCapture,
+		"gemini": geminiCapture,
+		"":       shellCapture,
+	} {
+		if got := Guess(text); got != want {
+			t.Errorf("Guess = %q, want %q", got, want)
+		}
+	}
+	if got := kinds(Parse("", claudeCapture)); strings.Join(got, ",") != strings.Join(kinds(Parse("cc", claudeCapture)), ",") {
+		t.Errorf("guessed parse differs: %v", got)
+	}
+}
@@ -0,0 +1,119 @@
+// Package serve provides REST API endpoints for spend budgets.
+// budget.go implements the /api/v1/budgets endpoints.
+package serve
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shahbajlive/ntm/internal/config"
+	"github.com/shahbajlive/ntm/internal/cost"
+	"github.com/shahbajlive/ntm/internal/state"
+)
+
+// registerBudgetRoutes registers spend budget endpoints. Budgets themselves
+// are configured under [budgets] in the config file.
+func (s *Server) registerBudgetRoutes(r chi.Router) {
+	r.Route("/budgets", func(r chi.Router) {
+		r.With(s.RequirePermission(PermReadSessions)).Get("/", s.handleBudgetStatus)
+		r.With(s.RequirePermission(PermReadSessions)).Get("/report", s.handleBudgetReport)
+	})
+}
+
+// budgetsConfig loads the budget configuration for the server's project.
+func (s *Server) budgetsConfig() config.BudgetsConfig {
+	cfg, err := config.LoadMerged(s.projectDir, config.DefaultPath())
+	if err != nil || cfg == nil {
+		return config.DefaultBudgetsConfig()
+	}
+	return cfg.Budgets
+}
+
+// handleBudgetStatus handles GET /api/v1/budgets?session=NAME
+func (s *Server) handleBudgetStatus(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+	if s.stateStore == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, ErrCodeServiceUnavail, "state store not available", nil, reqID)
+		return
+	}
+	session := r.URL.Query().Get("session")
+	if session == "" {
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "session is required", nil, reqID)
+		return
+	}
+
+	ss := state.NewSpendStore(s.stateStore)
+	bc := s.budgetsConfig()
+	statuses, err := cost.Evaluate(bc.Budgets(), session, nil, ss.BudgetSpend)
+	if err != nil {
+		slog.Error("evaluate budgets", "request_id", reqID, "error", err)
+		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, "failed to evaluate budgets", nil, reqID)
+		return
+	}
+	if statuses == nil {
+		statuses = []cost.BudgetStatus{}
+	}
+	spend, err := ss.Totals(state.SpendFilter{SessionName: session})
+	if err != nil {
+		slog.Error("sum spend", "request_id", reqID, "error", err)
+		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, "failed to sum spend", nil, reqID)
+		return
+	}
+	today, err := ss.Totals(state.SpendFilter{SessionName: session, Day: time.Now().Format(state.SpendDayLayout)})
+	if err != nil {
+		slog.Error("sum spend", "request_id", reqID, "error", err)
+		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, "failed to sum spend", nil, reqID)
+		return
+	}
+	writeSuccessResponse(w, http.StatusOK, map[string]interface{}{
+		"session":     session,
+		"enabled":     bc.Enabled,
+		"hard_action": bc.HardAction,
+		"level":       cost.WorstLevel(statuses),
+		"budgets":     statuses,
+		"spend":       spend,
+		"today":       today,
+	}, reqID)
+}
+
+// handleBudgetReport handles GET /api/v1/budgets/report?month=YYYY-MM&session=NAME
+func (s *Server) handleBudgetReport(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+	if s.stateStore == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, ErrCodeServiceUnavail, "state store not available", nil, reqID)
+		return
+	}
+	q := r.URL.Query()
+	month := q.Get("month")
+	if month == "" {
+		month = time.Now().Format("2006-01")
+	}
+	if _, err := time.Parse("2006-01", month); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, fmt.Sprintf("invalid month %q (want YYYY-MM)", month), nil, reqID)
+		return
+	}
+
+	rows, err := state.NewSpendStore(s.stateStore).Rollup(state.SpendFilter{Month: month, SessionName: q.Get("session")})
+	if err != nil {
+		slog.Error("roll up spend", "request_id", reqID, "error", err)
+		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, "failed to roll up spend", nil, reqID)
+		return
+	}
+	if rows == nil {
+		rows = []state.SpendRollupRow{}
+	}
+	var total state.SpendTotals
+	for _, row := range rows {
+		total.InputTokens += row.InputTokens
+		total.OutputTokens += row.OutputTokens
+		total.CostUSD += row.CostUSD
+	}
+	writeSuccessResponse(w, http.StatusOK, map[string]interface{}{
+		"month": month,
+		"rows":  rows,
+		"total": total,
+	}, reqID)
+}
@@ -0,0 +1,49 @@
+package serve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/state"
+)
+
+func TestHandleBudgets(t *testing.T) {
+	t.Parallel()
+	srv, _ := setupTestServer(t)
+	ss := state.NewSpendStore(srv.stateStore)
+	now := time.Now()
+	for _, ev := range []state.SpendEvent{
+		{SessionName: "proj", AgentType: "cc", InputTokens: 1000, CostUSD: 0.5, Source: "prompt", RecordedAt: now},
+		{SessionName: "proj", AgentType: "cod", OutputTokens: 3000, CostUSD: 1, Source: "response", RecordedAt: now},
+		{SessionName: "other", AgentType: "cc", InputTokens: 10, CostUSD: 0.25, Source: "prompt", RecordedAt: now},
+	} {
+		if err := ss.Record(&ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	srv.handleBudgetStatus(rr, httptest.NewRequest(http.MethodGet, "/api/v1/budgets?session=proj", nil))
+	resp := decodeTranscriptResponse(t, rr, http.StatusOK)
+	spend, _ := resp["spend"].(map[string]interface{})
+	if spend["cost_usd"] != 1.5 || spend["output_tokens"] != float64(3000) || resp["budgets"] == nil {
+		t.Errorf("status = %v", resp)
+	}
+	rr = httptest.NewRecorder()
+	srv.handleBudgetStatus(rr, httptest.NewRequest(http.MethodGet, "/api/v1/budgets", nil))
+	decodeTranscriptResponse(t, rr, http.StatusBadRequest)
+
+	rr = httptest.NewRecorder()
+	srv.handleBudgetReport(rr, httptest.NewRequest(http.MethodGet, "/api/v1/budgets/report", nil))
+	resp = decodeTranscriptResponse(t, rr, http.StatusOK)
+	rows, _ := resp["rows"].([]interface{})
+	total, _ := resp["total"].(map[string]interface{})
+	if len(rows) != 3 || total["cost_usd"] != 1.75 || resp["month"] != now.Format("2006-01") {
+		t.Errorf("report = %v", resp)
+	}
+	rr = httptest.NewRecorder()
+	srv.handleBudgetReport(rr, httptest.NewRequest(http.MethodGet, "/api/v1/budgets/report?month=10/2026", nil))
+	decodeTranscriptResponse(t, rr, http.StatusBadRequest)
+}
@@ -0,0 +1,34 @@
+// Package serve provides REST API endpoints for the ntmd background daemon.
+// daemon.go implements the /api/v1/daemon endpoint.
+package serve
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shahbajlive/ntm/internal/daemon"
+)
+
+// registerDaemonRoutes registers the ntmd status endpoint.
+func (s *Server) registerDaemonRoutes(r chi.Router) {
+	r.With(s.RequirePermission(PermReadHealth)).Get("/daemon", s.handleDaemonStatus)
+}
+
+// handleDaemonStatus handles GET /api/v1/daemon
+func (s *Server) handleDaemonStatus(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+	c := daemon.NewClient("")
+	st, err := c.Status(r.Context())
+	if err != nil && !errors.Is(err, daemon.ErrNotRunning) {
+		slog.Error("query ntmd", "request_id", reqID, "error", err)
+		writeErrorResponse(w, http.StatusBadGateway, ErrCodeInternalError, "failed to query ntmd", nil, reqID)
+		return
+	}
+	writeSuccessResponse(w, http.StatusOK, map[string]interface{}{
+		"running": st != nil,
+		"socket":  c.Socket(),
+		"daemon":  st,
+	}, reqID)
+}
@@ -0,0 +1,41 @@
+package serve
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/daemon"
+)
+
+func TestHandleDaemonStatus(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "ntmd.sock")
+	t.Setenv(daemon.SocketEnv, socket)
+	srv, _ := setupTestServer(t)
+
+	rr := httptest.NewRecorder()
+	srv.handleDaemonStatus(rr, httptest.NewRequest(http.MethodGet, "/api/v1/daemon", nil))
+	resp := decodeTranscriptResponse(t, rr, http.StatusOK)
+	if resp["running"] != false || resp["socket"] != socket {
+		t.Errorf("status without daemon = %v", resp)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d := daemon.NewServer(daemon.Options{Version: "test"})
+	go func() { _ = d.ListenAndServe(ctx) }()
+	if _, err := daemon.NewClient("").WaitReady(ctx, 5*time.Second); err != nil {
+		t.Fatalf("daemon not ready: %v", err)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.handleDaemonStatus(rr, httptest.NewRequest(http.MethodGet, "/api/v1/daemon", nil))
+	resp = decodeTranscriptResponse(t, rr, http.StatusOK)
+	st, _ := resp["daemon"].(map[string]interface{})
+	if resp["running"] != true || st["version"] != "test" {
+		t.Errorf("status with daemon = %v", resp)
+	}
+}
@@ -21,14 +21,18 @@ import (
 
 	"database/sql"
 
+	"github.com/shahbajlive/ntm/internal/budget"
 	"github.com/shahbajlive/ntm/internal/bv"
 	"github.com/shahbajlive/ntm/internal/cass"
+	"github.com/shahbajlive/ntm/internal/config"
+	"github.com/shahbajlive/ntm/internal/cost"
 	"github.com/shahbajlive/ntm/internal/events"
 	"github.com/shahbajlive/ntm/internal/kernel"
 	"github.com/shahbajlive/ntm/internal/pipeline"
 	"github.com/shahbajlive/ntm/internal/redaction"
 	"github.com/shahbajlive/ntm/internal/robot"
 	"github.com/shahbajlive/ntm/internal/scanner"
+	"github.com/shahbajlive/ntm/internal/state"
 	"github.com/shahbajlive/ntm/internal/tools"
 	"github.com/go-chi/chi/v5"
 	_ "github.com/mattn/go-sqlite3"
@@ -1896,6 +1900,43 @@ func TestHandlePaneInputV1_TmuxError(t *testing.T) {
 	}
 }
 
+// =============================================================================
+// handlePaneInputV1 — hard spend budget
+// =============================================================================
+
+func TestHandlePaneInputV1_OverHardBudget(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	store, err := state.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	u := cost.Usage{Session: "overspent", Pane: "%1", AgentType: "cc", InputTokens: 5000, Source: "prompt"}
+	if err := state.NewSpendStore(store).RecordUsage(u); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+	budget.SetConfig(config.BudgetsConfig{Enabled: true, HardAction: config.BudgetRefuse,
+		Session: config.BudgetLimitConfig{HardTokens: 1000}})
+	t.Cleanup(func() { budget.SetConfig(config.DefaultBudgetsConfig()) })
+
+	srv, _ := setupTestServer(t)
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/sessions/overspent/panes/0/input", strings.NewReader(`{"text":"hello"}`))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("sessionId", "overspent")
+	rctx.URLParams.Add("paneIdx", "0")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	srv.handlePaneInputV1(rec, req)
+
+	if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "over hard") {
+		t.Fatalf("status = %d, want 403; body: %s", rec.Code, rec.Body.String())
+	}
+}
+
 // =============================================================================
 // handleListAgentsV1 — empty session ID
 // =============================================================================
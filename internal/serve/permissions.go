@@ -0,0 +1,97 @@
+// Package serve provides REST API endpoints for agents' permission prompts.
+// permissions.go implements the /api/v1/permissions endpoints.
+package serve
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shahbajlive/ntm/internal/permissions"
+)
+
+// registerPermissionRoutes registers permission prompt endpoints.
+func (s *Server) registerPermissionRoutes(r chi.Router) {
+	r.Route("/permissions", func(r chi.Router) {
+		r.With(s.RequirePermission(PermReadSessions)).Get("/", s.handleListPermissions)
+		r.With(s.RequirePermission(PermWriteAgents)).Post("/approve", s.handleAnswerPermission(true))
+		r.With(s.RequirePermission(PermWriteAgents)).Post("/deny", s.handleAnswerPermission(false))
+	})
+}
+
+// PermissionAnswerRequest is the request body for approving or denying a prompt.
+type PermissionAnswerRequest struct {
+	Session   string `json:"session"`
+	Pane      string `json:"pane"`                 // Pane ID ("%3") or index ("2")
+	RequestID string `json:"request_id,omitempty"` // When set, the pane must still show this prompt
+	Reason    string `json:"reason,omitempty"`
+}
+
+// handleListPermissions handles GET /api/v1/permissions?session=
+func (s *Server) handleListPermissions(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+	session := r.URL.Query().Get("session")
+	if session == "" {
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "session is required", nil, reqID)
+		return
+	}
+	prompts, err := permissions.New(permissions.Config{}).Scan(r.Context(), session)
+	if err != nil {
+		slog.Error("scan permission prompts", "request_id", reqID, "session", session, "error", err)
+		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, "failed to read session panes", nil, reqID)
+		return
+	}
+	writeSuccessResponse(w, http.StatusOK, map[string]interface{}{
+		"session": session,
+		"prompts": prompts,
+		"count":   len(prompts),
+	}, reqID)
+}
+
+// handleAnswerPermission handles POST /api/v1/permissions/approve and /deny
+func (s *Server) handleAnswerPermission(approve bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reqID := requestIDFromContext(r.Context())
+		var req PermissionAnswerRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "invalid JSON body", nil, reqID)
+			return
+		}
+		if req.Session == "" || req.Pane == "" {
+			writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "session and pane are required", nil, reqID)
+			return
+		}
+
+		pr, err := permissions.New(permissions.Config{}).Answer(r.Context(), req.Session, permissions.Answer{
+			Pane:      req.Pane,
+			RequestID: req.RequestID,
+			Approve:   approve,
+			By:        "api",
+			Reason:    req.Reason,
+		})
+		switch {
+		case errors.Is(err, permissions.ErrNoPrompt):
+			writeErrorResponse(w, http.StatusNotFound, ErrCodeNotFound, err.Error(), nil, reqID)
+			return
+		case errors.Is(err, permissions.ErrPromptChanged):
+			writeErrorResponse(w, http.StatusConflict, ErrCodeConflict, err.Error(),
+				map[string]interface{}{"prompt": pr}, reqID)
+			return
+		case err != nil:
+			writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error(), nil, reqID)
+			return
+		}
+
+		decision := permissions.DecisionDeny
+		if approve {
+			decision = permissions.DecisionAllow
+		}
+		writeSuccessResponse(w, http.StatusOK, map[string]interface{}{
+			"session":  req.Session,
+			"decision": decision,
+			"prompt":   pr,
+		}, reqID)
+	}
+}
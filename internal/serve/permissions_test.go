@@ -0,0 +1,27 @@
+package serve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandlePermissions_Validation(t *testing.T) {
+	srv, _ := setupTestServer(t)
+
+	rr := httptest.NewRecorder()
+	srv.handleListPermissions(rr, httptest.NewRequest(http.MethodGet, "/api/v1/permissions", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("list without session = %d, want 400", rr.Code)
+	}
+
+	for _, body := range []string{`{`, `{"session":"proj"}`, `{"pane":"2"}`} {
+		rr = httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/permissions/approve", strings.NewReader(body))
+		srv.handleAnswerPermission(true)(rr, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("approve %s = %d, want 400", body, rr.Code)
+		}
+	}
+}
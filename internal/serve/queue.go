@@ -0,0 +1,244 @@
+// Package serve provides REST API endpoints for the per-pane prompt queue.
+// queue.go implements the /api/v1/queue endpoints.
+package serve
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shahbajlive/ntm/internal/state"
+	"github.com/shahbajlive/ntm/internal/tmux"
+	"github.com/shahbajlive/ntm/internal/util"
+)
+
+// ErrCodeQueuedPromptNotFound is returned when a queued prompt ID is unknown.
+const ErrCodeQueuedPromptNotFound = "QUEUED_PROMPT_NOT_FOUND"
+
+// EnqueuePromptRequest is the payload for POST /queue. The prompt targets a
+// pane (by index or ID), or the first idle pane of AgentType when neither is
+// set.
+type EnqueuePromptRequest struct {
+	Session   string `json:"session"`
+	Prompt    string `json:"prompt"`
+	Pane      *int   `json:"pane,omitempty"`
+	PaneID    string `json:"pane_id,omitempty"`
+	AgentType string `json:"agent_type,omitempty"`
+	Priority  *int   `json:"priority,omitempty"` // 0 (first) to 4 (last); default 2
+	DedupKey  string `json:"dedup_key,omitempty"`
+	TTL       string `json:"ttl,omitempty"` // Expire if undelivered after this long
+}
+
+// MoveQueuedPromptRequest is the payload for POST /queue/{id}/move.
+type MoveQueuedPromptRequest struct {
+	Position int `json:"position"` // 1-based among the session's pending prompts
+}
+
+// registerQueueRoutes registers prompt queue endpoints. Delivery itself is
+// done by the session monitor as panes go idle.
+func (s *Server) registerQueueRoutes(r chi.Router) {
+	r.Route("/queue", func(r chi.Router) {
+		r.With(s.RequirePermission(PermReadSessions)).Get("/", s.handleListQueue)
+		r.With(s.RequirePermission(PermWriteSessions)).Post("/", s.handleEnqueuePrompt)
+		r.With(s.RequirePermission(PermWriteSessions)).Delete("/{id}", s.handleRemoveQueuedPrompt)
+		r.With(s.RequirePermission(PermWriteSessions)).Post("/{id}/move", s.handleMoveQueuedPrompt)
+	})
+}
+
+// handleListQueue handles GET /api/v1/queue
+func (s *Server) handleListQueue(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+	if s.stateStore == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, ErrCodeServiceUnavail, "state store not available", nil, reqID)
+		return
+	}
+	q := r.URL.Query()
+	f := state.PromptQueueFilter{
+		SessionName: q.Get("session"),
+		PaneID:      q.Get("pane_id"),
+		AgentType:   q.Get("agent"),
+		Status:      q.Get("status"),
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, fmt.Sprintf("invalid limit %q", v), nil, reqID)
+			return
+		}
+		f.Limit = limit
+	}
+
+	qs := state.NewPromptQueueStore(s.stateStore)
+	prompts, err := qs.List(f)
+	if err != nil {
+		slog.Error("list prompt queue", "request_id", reqID, "error", err)
+		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, "failed to list queue", nil, reqID)
+		return
+	}
+	if prompts == nil {
+		prompts = []state.QueuedPrompt{}
+	}
+	resp := map[string]interface{}{
+		"prompts": prompts,
+		"count":   len(prompts),
+	}
+	if f.SessionName != "" {
+		depth, err := qs.Depth(f.SessionName)
+		if err != nil {
+			slog.Error("prompt queue depth", "request_id", reqID, "error", err)
+			writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, "failed to count queue", nil, reqID)
+			return
+		}
+		resp["depth"] = depth
+	}
+	writeSuccessResponse(w, http.StatusOK, resp, reqID)
+}
+
+// handleEnqueuePrompt handles POST /api/v1/queue
+func (s *Server) handleEnqueuePrompt(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+	if s.stateStore == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, ErrCodeServiceUnavail, "state store not available", nil, reqID)
+		return
+	}
+	var req EnqueuePromptRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "invalid request body", nil, reqID)
+		return
+	}
+	if req.Session == "" || strings.TrimSpace(req.Prompt) == "" {
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "session and prompt are required", nil, reqID)
+		return
+	}
+
+	q := &state.QueuedPrompt{
+		SessionName: req.Session,
+		Prompt:      req.Prompt,
+		PaneID:      req.PaneID,
+		AgentType:   req.AgentType,
+		Priority:    state.DefaultQueuePriority,
+		DedupKey:    req.DedupKey,
+		Source:      "api",
+	}
+	if req.Priority != nil {
+		if *req.Priority < 0 || *req.Priority > 4 {
+			writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "priority must be between 0 and 4", nil, reqID)
+			return
+		}
+		q.Priority = *req.Priority
+	}
+	if req.TTL != "" {
+		ttl, err := util.ParseDuration(req.TTL)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, fmt.Sprintf("invalid ttl %q", req.TTL), nil, reqID)
+			return
+		}
+		expires := time.Now().Add(ttl).UTC()
+		q.ExpiresAt = &expires
+	}
+	if req.Pane != nil {
+		panes, err := tmux.GetPanes(req.Session)
+		if err != nil {
+			writeErrorResponse(w, http.StatusNotFound, ErrCodeNotFound, fmt.Sprintf("session %q not found", req.Session), nil, reqID)
+			return
+		}
+		for _, p := range panes {
+			if p.Index == *req.Pane {
+				idx := p.Index
+				q.PaneID, q.PaneIndex, q.AgentType = p.ID, &idx, string(p.Type)
+			}
+		}
+		if q.PaneID == "" {
+			writeErrorResponse(w, http.StatusNotFound, ErrCodeNotFound, fmt.Sprintf("pane %d not found", *req.Pane), nil, reqID)
+			return
+		}
+	}
+
+	added, created, err := state.NewPromptQueueStore(s.stateStore).Enqueue(q)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, err.Error(), nil, reqID)
+		return
+	}
+	status := http.StatusCreated
+	if !created {
+		status = http.StatusOK
+	}
+	writeSuccessResponse(w, status, map[string]interface{}{
+		"prompt":    added,
+		"duplicate": !created,
+	}, reqID)
+}
+
+func queuedPromptID(w http.ResponseWriter, r *http.Request, reqID string) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "invalid prompt id", nil, reqID)
+		return 0, false
+	}
+	return id, true
+}
+
+// handleRemoveQueuedPrompt handles DELETE /api/v1/queue/{id}
+func (s *Server) handleRemoveQueuedPrompt(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+	if s.stateStore == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, ErrCodeServiceUnavail, "state store not available", nil, reqID)
+		return
+	}
+	id, ok := queuedPromptID(w, r, reqID)
+	if !ok {
+		return
+	}
+	removed, err := state.NewPromptQueueStore(s.stateStore).Remove(id)
+	if err != nil {
+		slog.Error("remove queued prompt", "request_id", reqID, "id", id, "error", err)
+		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, "failed to remove prompt", nil, reqID)
+		return
+	}
+	if !removed {
+		writeErrorResponse(w, http.StatusNotFound, ErrCodeQueuedPromptNotFound, fmt.Sprintf("queued prompt %d not found", id), nil, reqID)
+		return
+	}
+	writeSuccessResponse(w, http.StatusOK, map[string]interface{}{"removed": id}, reqID)
+}
+
+// handleMoveQueuedPrompt handles POST /api/v1/queue/{id}/move
+func (s *Server) handleMoveQueuedPrompt(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+	if s.stateStore == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, ErrCodeServiceUnavail, "state store not available", nil, reqID)
+		return
+	}
+	id, ok := queuedPromptID(w, r, reqID)
+	if !ok {
+		return
+	}
+	var req MoveQueuedPromptRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Position < 1 {
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "position must be 1 or more", nil, reqID)
+		return
+	}
+
+	qs := state.NewPromptQueueStore(s.stateStore)
+	existing, err := qs.Get(id)
+	if err != nil {
+		slog.Error("get queued prompt", "request_id", reqID, "id", id, "error", err)
+		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, "failed to load prompt", nil, reqID)
+		return
+	}
+	if existing == nil {
+		writeErrorResponse(w, http.StatusNotFound, ErrCodeQueuedPromptNotFound, fmt.Sprintf("queued prompt %d not found", id), nil, reqID)
+		return
+	}
+	if err := qs.Move(id, req.Position-1); err != nil {
+		writeErrorResponse(w, http.StatusConflict, ErrCodeBadRequest, err.Error(), nil, reqID)
+		return
+	}
+	moved, _ := qs.Get(id)
+	writeSuccessResponse(w, http.StatusOK, map[string]interface{}{"prompt": moved}, reqID)
+}
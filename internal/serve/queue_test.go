@@ -0,0 +1,82 @@
+package serve
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func withQueueID(req *http.Request, id string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestHandleQueue(t *testing.T) {
+	t.Parallel()
+	srv, _ := setupTestServer(t)
+
+	enqueue := func(body string, want int) map[string]interface{} {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		srv.handleEnqueuePrompt(rr, httptest.NewRequest(http.MethodPost, "/api/v1/queue", strings.NewReader(body)))
+		return decodeTranscriptResponse(t, rr, want)
+	}
+	promptID := func(resp map[string]interface{}) string {
+		p, _ := resp["prompt"].(map[string]interface{})
+		id, _ := p["id"].(float64)
+		return strconv.FormatInt(int64(id), 10)
+	}
+
+	first := promptID(enqueue(`{"session":"proj","prompt":"run tests","pane_id":"%1","dedup_key":"tests"}`, http.StatusCreated))
+	second := promptID(enqueue(`{"session":"proj","prompt":"review","agent_type":"cc"}`, http.StatusCreated))
+	if resp := enqueue(`{"session":"proj","prompt":"run tests","pane_id":"%1","dedup_key":"tests"}`, http.StatusOK); resp["duplicate"] != true {
+		t.Errorf("dedup enqueue = %v", resp)
+	}
+	for _, bad := range []string{`{"session":"proj"}`, `{"session":"proj","prompt":"x","agent_type":"cc","priority":9}`,
+		`{"session":"proj","prompt":"x"}`, `{"session":"proj","prompt":"x","agent_type":"cc","ttl":"soon"}`, `not json`} {
+		enqueue(bad, http.StatusBadRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	srv.handleListQueue(rr, httptest.NewRequest(http.MethodGet, "/api/v1/queue?session=proj", nil))
+	resp := decodeTranscriptResponse(t, rr, http.StatusOK)
+	if resp["count"] != float64(2) || resp["depth"] == nil {
+		t.Fatalf("list = %v", resp)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.handleMoveQueuedPrompt(rr, withQueueID(httptest.NewRequest(http.MethodPost, "/api/v1/queue/"+second+"/move", strings.NewReader(`{"position":1}`)), second))
+	decodeTranscriptResponse(t, rr, http.StatusOK)
+	rr = httptest.NewRecorder()
+	srv.handleListQueue(rr, httptest.NewRequest(http.MethodGet, "/api/v1/queue?session=proj", nil))
+	prompts, _ := decodeTranscriptResponse(t, rr, http.StatusOK)["prompts"].([]interface{})
+	if top, _ := prompts[0].(map[string]interface{}); top["prompt"] != "review" {
+		t.Errorf("first prompt after move = %v", top["prompt"])
+	}
+
+	remove := func(id string, want int) {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		srv.handleRemoveQueuedPrompt(rr, withQueueID(httptest.NewRequest(http.MethodDelete, "/api/v1/queue/"+id, nil), id))
+		decodeTranscriptResponse(t, rr, want)
+	}
+	remove(first, http.StatusOK)
+	remove(first, http.StatusNotFound)
+	remove("abc", http.StatusBadRequest)
+}
+
+func TestHandleQueueNoStore(t *testing.T) {
+	t.Parallel()
+	srv := &Server{}
+	rr := httptest.NewRecorder()
+	srv.handleListQueue(rr, httptest.NewRequest(http.MethodGet, "/api/v1/queue", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", rr.Code)
+	}
+}
@@ -31,6 +31,7 @@ import (
 	"time"
 
 	"github.com/shahbajlive/ntm/internal/agentmail"
+	"github.com/shahbajlive/ntm/internal/budget"
 	"github.com/shahbajlive/ntm/internal/config"
 	"github.com/shahbajlive/ntm/internal/ensemble"
 	"github.com/shahbajlive/ntm/internal/events"
@@ -38,6 +39,7 @@ import (
 	"github.com/shahbajlive/ntm/internal/metrics"
 	"github.com/shahbajlive/ntm/internal/redaction"
 	"github.com/shahbajlive/ntm/internal/robot"
+	"github.com/shahbajlive/ntm/internal/session"
 	"github.com/shahbajlive/ntm/internal/state"
 	"github.com/shahbajlive/ntm/internal/tmux"
 	"github.com/go-chi/chi/v5"
@@ -913,6 +915,13 @@ func (s *Server) buildRouter() chi.Router {
 		// Checkpoint and Rollback API
 		s.registerCheckpointRoutes(r)
 
+		// Agent transcripts API
+		s.registerTranscriptRoutes(r)
+		s.registerQueueRoutes(r)
+		s.registerBudgetRoutes(r)
+		s.registerDaemonRoutes(r)
+		s.registerPermissionRoutes(r)
+
 		// Metrics API - performance and analytics data
 		r.Route("/metrics", func(r chi.Router) {
 			r.With(s.RequirePermission(PermReadHealth)).Get("/", s.handleMetricsV1)
@@ -2738,10 +2747,41 @@ func (s *Server) handlePaneInputV1(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := session.CheckSends(sessionID); err != nil {
+		writeErrorResponse(w, http.StatusConflict, ErrCodeConflict, err.Error(), nil, reqID)
+		return
+	}
+
+	// The pane, when it can be found, narrows the budgets checked and
+	// decides whether vault tokens are swapped back to their secrets.
+	var pane *tmux.Pane
+	if panes, err := tmux.GetPanes(sessionID); err == nil {
+		for i := range panes {
+			if panes[i].Index == paneIdx {
+				pane = &panes[i]
+				break
+			}
+		}
+	}
+
+	var agentTypes []string
+	if pane != nil && pane.Type != tmux.AgentUser {
+		agentTypes = []string{string(pane.Type)}
+	}
+	if _, err := budget.Enforce(sessionID, agentTypes, "pane input"); err != nil {
+		writeErrorResponse(w, http.StatusForbidden, ErrCodeForbidden, err.Error(), nil, reqID)
+		return
+	}
+
 	// Build pane target
 	paneTarget := fmt.Sprintf("%s:%d", sessionID, paneIdx)
 
-	if err := tmux.SendKeys(paneTarget, req.Text, req.Enter); err != nil {
+	text := req.Text
+	if pane != nil {
+		text = redaction.RehydrateForPane(pane.Title, pane.Tags, text)
+	}
+
+	if err := tmux.SendKeys(paneTarget, text, req.Enter); err != nil {
 		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error(), nil, reqID)
 		return
 	}
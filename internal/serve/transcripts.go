@@ -0,0 +1,254 @@
+// Package serve provides REST API endpoints for ingested agent transcripts.
+// transcripts.go implements the /api/v1/transcripts endpoints.
+package serve
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shahbajlive/ntm/internal/state"
+	"github.com/shahbajlive/ntm/internal/transcript"
+	"github.com/shahbajlive/ntm/internal/util"
+)
+
+// ErrCodeTranscriptNotFound is returned when a transcript ID is unknown.
+const ErrCodeTranscriptNotFound = "TRANSCRIPT_NOT_FOUND"
+
+// TranscriptIngestRequest is the payload for POST /transcripts/ingest.
+type TranscriptIngestRequest struct {
+	Session    string `json:"session"`
+	ProjectDir string `json:"project_dir,omitempty"` // Defaults to the server's project directory
+	Since      string `json:"since,omitempty"`       // Only files modified within this duration or since this time
+}
+
+// registerTranscriptRoutes registers transcript ingestion and query endpoints.
+func (s *Server) registerTranscriptRoutes(r chi.Router) {
+	r.Route("/transcripts", func(r chi.Router) {
+		r.With(s.RequirePermission(PermReadSessions)).Get("/", s.handleListTranscripts)
+		r.With(s.RequirePermission(PermReadSessions)).Get("/query", s.handleQueryTranscripts)
+		r.With(s.RequirePermission(PermWriteSessions)).Post("/ingest", s.handleIngestTranscripts)
+		r.With(s.RequirePermission(PermReadSessions)).Get("/{id}", s.handleGetTranscript)
+	})
+}
+
+// transcriptFilterFromQuery parses the shared query parameters: session,
+// pane, bead, agent, role, text, tool, file, since and limit.
+func transcriptFilterFromQuery(r *http.Request, defaultLimit int) (state.TranscriptFilter, error) {
+	q := r.URL.Query()
+	f := state.TranscriptFilter{
+		SessionName: q.Get("session"),
+		BeadID:      q.Get("bead"),
+		Role:        q.Get("role"),
+		Text:        q.Get("text"),
+		Tool:        q.Get("tool"),
+		File:        q.Get("file"),
+		Limit:       defaultLimit,
+	}
+	if v := q.Get("pane"); v != "" {
+		pane, err := strconv.Atoi(v)
+		if err != nil || pane < 0 {
+			return f, fmt.Errorf("invalid pane %q", v)
+		}
+		f.PaneIndex = &pane
+	}
+	if v := q.Get("agent"); v != "" {
+		agent, err := transcript.ParseAgent(v)
+		if err != nil {
+			return f, err
+		}
+		f.Agent = string(agent)
+	}
+	if v := q.Get("since"); v != "" {
+		since, err := parseTranscriptSince(v)
+		if err != nil {
+			return f, err
+		}
+		f.Since = since
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			return f, fmt.Errorf("invalid limit %q", v)
+		}
+		f.Limit = limit
+	}
+	return f, nil
+}
+
+// parseTranscriptSince accepts a duration ("2h", "7d") or an RFC 3339 time.
+func parseTranscriptSince(v string) (time.Time, error) {
+	if d, err := util.ParseDuration(v); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid since %q (use a duration like 2h or an RFC 3339 time)", v)
+	}
+	return t, nil
+}
+
+// handleListTranscripts handles GET /api/v1/transcripts
+func (s *Server) handleListTranscripts(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+	if s.stateStore == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, ErrCodeServiceUnavail, "state store not available", nil, reqID)
+		return
+	}
+	f, err := transcriptFilterFromQuery(r, 50)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, err.Error(), nil, reqID)
+		return
+	}
+
+	list, err := state.NewTranscriptStore(s.stateStore).List(f)
+	if err != nil {
+		slog.Error("list transcripts", "request_id", reqID, "error", err)
+		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, "failed to list transcripts", nil, reqID)
+		return
+	}
+	if list == nil {
+		list = []state.TranscriptRecord{}
+	}
+	writeSuccessResponse(w, http.StatusOK, map[string]interface{}{
+		"transcripts": list,
+		"count":       len(list),
+	}, reqID)
+}
+
+// handleQueryTranscripts handles GET /api/v1/transcripts/query
+func (s *Server) handleQueryTranscripts(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+	if s.stateStore == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, ErrCodeServiceUnavail, "state store not available", nil, reqID)
+		return
+	}
+	f, err := transcriptFilterFromQuery(r, 50)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, err.Error(), nil, reqID)
+		return
+	}
+	if f.Role != "" && f.Role != string(transcript.RoleUser) && f.Role != string(transcript.RoleAssistant) {
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "role must be user or assistant", nil, reqID)
+		return
+	}
+
+	turns, err := state.NewTranscriptStore(s.stateStore).QueryTurns(f)
+	if err != nil {
+		slog.Error("query transcripts", "request_id", reqID, "error", err)
+		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, "failed to query transcripts", nil, reqID)
+		return
+	}
+	if turns == nil {
+		turns = []state.TranscriptTurnMatch{}
+	}
+	writeSuccessResponse(w, http.StatusOK, map[string]interface{}{
+		"turns": turns,
+		"count": len(turns),
+	}, reqID)
+}
+
+// handleGetTranscript handles GET /api/v1/transcripts/{id}
+func (s *Server) handleGetTranscript(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+	if s.stateStore == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, ErrCodeServiceUnavail, "state store not available", nil, reqID)
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "transcript id must be an integer", nil, reqID)
+		return
+	}
+
+	ts := state.NewTranscriptStore(s.stateStore)
+	rec, err := ts.Get(id)
+	if err != nil {
+		slog.Error("get transcript", "request_id", reqID, "id", id, "error", err)
+		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, "failed to load transcript", nil, reqID)
+		return
+	}
+	if rec == nil {
+		writeErrorResponse(w, http.StatusNotFound, ErrCodeTranscriptNotFound, fmt.Sprintf("transcript %d not found", id), nil, reqID)
+		return
+	}
+	turns, err := ts.Turns(id)
+	if err != nil {
+		slog.Error("get transcript turns", "request_id", reqID, "id", id, "error", err)
+		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, "failed to load transcript turns", nil, reqID)
+		return
+	}
+	if turns == nil {
+		turns = []state.TranscriptTurn{}
+	}
+	writeSuccessResponse(w, http.StatusOK, map[string]interface{}{
+		"transcript": rec,
+		"turns":      turns,
+	}, reqID)
+}
+
+// handleIngestTranscripts handles POST /api/v1/transcripts/ingest
+func (s *Server) handleIngestTranscripts(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+	if s.stateStore == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, ErrCodeServiceUnavail, "state store not available", nil, reqID)
+		return
+	}
+
+	var req TranscriptIngestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "invalid request body", nil, reqID)
+		return
+	}
+	if req.Session == "" {
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "session is required", nil, reqID)
+		return
+	}
+	if req.ProjectDir == "" {
+		req.ProjectDir = s.projectDir
+	}
+	if req.ProjectDir == "" {
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "project_dir is required", nil, reqID)
+		return
+	}
+	var since time.Time
+	if req.Since != "" {
+		var err error
+		if since, err = parseTranscriptSince(req.Since); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, err.Error(), nil, reqID)
+			return
+		}
+	}
+
+	files, err := transcript.DefaultRoots().Discover(req.ProjectDir, transcript.Agents, since)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error(), nil, reqID)
+		return
+	}
+	results, err := transcript.Ingest(state.NewTranscriptStore(s.stateStore), files, transcript.IngestOptions{
+		Session:    req.Session,
+		ProjectDir: req.ProjectDir,
+		Linker:     transcript.SessionLinker(req.Session),
+	})
+	if err != nil {
+		slog.Error("ingest transcripts", "request_id", reqID, "session", req.Session, "error", err)
+		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, "failed to store transcripts", nil, reqID)
+		return
+	}
+
+	ingested := 0
+	for _, res := range results {
+		if !res.Unchanged && res.Error == "" {
+			ingested++
+		}
+	}
+	writeSuccessResponse(w, http.StatusOK, map[string]interface{}{
+		"session":  req.Session,
+		"ingested": ingested,
+		"results":  results,
+	}, reqID)
+}
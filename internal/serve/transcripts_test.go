@@ -0,0 +1,105 @@
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shahbajlive/ntm/internal/state"
+)
+
+func seedTranscript(t *testing.T, store *state.Store) int64 {
+	t.Helper()
+	pane := 1
+	ts := time.Now().UTC().Add(-time.Minute)
+	rec := &state.TranscriptRecord{Path: "/tmp/c.jsonl", Agent: "claude", SessionName: "proj", PaneIndex: &pane}
+	turns := []state.TranscriptTurn{
+		{Seq: 0, Role: "user", Text: "fix login", Timestamp: &ts},
+		{Seq: 1, Role: "assistant", Text: "done", Timestamp: &ts, ToolCalls: []state.TranscriptToolCall{
+			{Name: "Edit", Files: []string{"/repo/auth/login.go"}},
+		}},
+	}
+	if err := state.NewTranscriptStore(store).Save(rec, turns); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	return rec.ID
+}
+
+func decodeTranscriptResponse(t *testing.T, rr *httptest.ResponseRecorder, want int) map[string]interface{} {
+	t.Helper()
+	if rr.Code != want {
+		t.Fatalf("status = %d, want %d: %s", rr.Code, want, rr.Body.String())
+	}
+	var resp map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	return resp
+}
+
+func TestHandleTranscripts(t *testing.T) {
+	t.Parallel()
+	srv, store := setupTestServer(t)
+	id := seedTranscript(t, store)
+
+	rr := httptest.NewRecorder()
+	srv.handleListTranscripts(rr, httptest.NewRequest(http.MethodGet, "/api/v1/transcripts?session=proj&pane=1", nil))
+	if resp := decodeTranscriptResponse(t, rr, http.StatusOK); resp["count"] != float64(1) {
+		t.Errorf("list count = %v", resp["count"])
+	}
+
+	tests := []struct {
+		query string
+		want  float64
+	}{
+		{"?file=login.go", 1},
+		{"?role=user", 1},
+		{"?tool=Edit&session=proj", 1},
+		{"?agent=codex", 0},
+		{"?since=1h", 2},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		srv.handleQueryTranscripts(rr, httptest.NewRequest(http.MethodGet, "/api/v1/transcripts/query"+tt.query, nil))
+		if resp := decodeTranscriptResponse(t, rr, http.StatusOK); resp["count"] != tt.want {
+			t.Errorf("query %s count = %v, want %v", tt.query, resp["count"], tt.want)
+		}
+	}
+
+	for _, bad := range []string{"?pane=x", "?agent=vim", "?role=system", "?since=yesterday"} {
+		rr := httptest.NewRecorder()
+		srv.handleQueryTranscripts(rr, httptest.NewRequest(http.MethodGet, "/api/v1/transcripts/query"+bad, nil))
+		decodeTranscriptResponse(t, rr, http.StatusBadRequest)
+	}
+
+	get := func(param string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/transcripts/"+param, nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", param)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		rr := httptest.NewRecorder()
+		srv.handleGetTranscript(rr, req)
+		return rr
+	}
+	resp := decodeTranscriptResponse(t, get(strconv.FormatInt(id, 10)), http.StatusOK)
+	if turns, _ := resp["turns"].([]interface{}); len(turns) != 2 {
+		t.Errorf("get turns = %v", resp["turns"])
+	}
+	decodeTranscriptResponse(t, get("999"), http.StatusNotFound)
+	decodeTranscriptResponse(t, get("abc"), http.StatusBadRequest)
+}
+
+func TestHandleTranscriptsNoStore(t *testing.T) {
+	t.Parallel()
+	srv := &Server{}
+	rr := httptest.NewRecorder()
+	srv.handleListTranscripts(rr, httptest.NewRequest(http.MethodGet, "/api/v1/transcripts", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", rr.Code)
+	}
+}
@@ -0,0 +1,119 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/util"
+)
+
+const sendPauseFileName = "sends_paused.json"
+
+// SendPause records that prompt delivery to a session has been paused.
+// While a pause is in place every delivery path refuses to type into the
+// session; see CheckSends.
+type SendPause struct {
+	Session  string    `json:"session"`
+	Reason   string    `json:"reason,omitempty"`
+	PausedAt time.Time `json:"paused_at"`
+}
+
+func sendPausePath(sessionName string) (string, error) {
+	dir, err := SessionDir(sessionName)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, sendPauseFileName), nil
+}
+
+// PauseSends pauses prompt delivery to a session. Pausing an already paused
+// session replaces the reason but keeps the original timestamp.
+func PauseSends(sessionName, reason string) error {
+	if sessionName == "" {
+		return fmt.Errorf("session name is required")
+	}
+	existing, err := SendsPaused(sessionName)
+	if err != nil {
+		return err
+	}
+	pause := SendPause{Session: sessionName, Reason: reason, PausedAt: time.Now()}
+	if existing != nil {
+		pause.PausedAt = existing.PausedAt
+	}
+
+	path, err := sendPausePath(sessionName)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(pause, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize send pause: %w", err)
+	}
+	if err := util.AtomicWriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("writing send pause: %w", err)
+	}
+	return nil
+}
+
+// ResumeSends lifts a send pause. Resuming a session that is not paused is
+// not an error.
+func ResumeSends(sessionName string) error {
+	path, err := sendPausePath(sessionName)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// SendsPaused returns the active pause for a session, or nil when sends are
+// allowed.
+func SendsPaused(sessionName string) (*SendPause, error) {
+	path, err := sendPausePath(sessionName)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read send pause: %w", err)
+	}
+	var pause SendPause
+	if err := json.Unmarshal(data, &pause); err != nil {
+		return nil, fmt.Errorf("failed to parse send pause: %w", err)
+	}
+	if pause.Session == "" {
+		pause.Session = sessionName
+	}
+	return &pause, nil
+}
+
+// SendsPausedError is returned by CheckSends while a session is paused.
+type SendsPausedError struct {
+	Pause SendPause
+}
+
+func (e *SendsPausedError) Error() string {
+	return fmt.Sprintf("sends to session %q are paused since %s (resume with: ntm fleet resume %s)",
+		e.Pause.Session, e.Pause.PausedAt.Format(time.RFC3339), e.Pause.Session)
+}
+
+// CheckSends is the gate for every path that types prompts into a session:
+// ntm send, --robot-send, the REST pane input and the prompt queue. It
+// returns a *SendsPausedError while sends are paused. A pause file that
+// cannot be read does not block delivery.
+func CheckSends(sessionName string) error {
+	pause, _ := SendsPaused(sessionName)
+	if pause == nil {
+		return nil
+	}
+	return &SendsPausedError{Pause: *pause}
+}
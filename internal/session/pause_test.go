@@ -0,0 +1,64 @@
+package session
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSendPauseLifecycle(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if p, err := SendsPaused("proj"); err != nil || p != nil {
+		t.Fatalf("SendsPaused before pause = %+v, %v", p, err)
+	}
+	if err := ResumeSends("proj"); err != nil {
+		t.Fatalf("ResumeSends on unpaused session: %v", err)
+	}
+
+	if err := PauseSends("proj", "fleet"); err != nil {
+		t.Fatalf("PauseSends: %v", err)
+	}
+	first, err := SendsPaused("proj")
+	if err != nil || first == nil || first.Reason != "fleet" {
+		t.Fatalf("SendsPaused after pause = %+v, %v", first, err)
+	}
+
+	if err := PauseSends("proj", "budget"); err != nil {
+		t.Fatalf("PauseSends again: %v", err)
+	}
+	second, _ := SendsPaused("proj")
+	if second.Reason != "budget" || !second.PausedAt.Equal(first.PausedAt) {
+		t.Errorf("re-pause = %+v, want reason budget and original timestamp %v", second, first.PausedAt)
+	}
+	if other, _ := SendsPaused("other"); other != nil {
+		t.Errorf("pause leaked to another session: %+v", other)
+	}
+
+	if err := ResumeSends("proj"); err != nil {
+		t.Fatalf("ResumeSends: %v", err)
+	}
+	if p, _ := SendsPaused("proj"); p != nil {
+		t.Errorf("SendsPaused after resume = %+v", p)
+	}
+}
+
+func TestCheckSends(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if err := CheckSends("proj"); err != nil {
+		t.Fatalf("CheckSends before pause = %v", err)
+	}
+	if err := PauseSends("proj", "fleet"); err != nil {
+		t.Fatalf("PauseSends: %v", err)
+	}
+	var paused *SendsPausedError
+	if err := CheckSends("proj"); !errors.As(err, &paused) || paused.Pause.Session != "proj" {
+		t.Fatalf("CheckSends while paused = %v, want *SendsPausedError", err)
+	}
+	if err := ResumeSends("proj"); err != nil {
+		t.Fatalf("ResumeSends: %v", err)
+	}
+	if err := CheckSends("proj"); err != nil {
+		t.Errorf("CheckSends after resume = %v", err)
+	}
+}
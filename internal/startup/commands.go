@@ -26,6 +26,9 @@ var CommandClassification = map[string]CommandRequirement{
 	"completion": RequirePhase1Only,
 	"upgrade":    RequirePhase1Only,
 
+	// Internal commands
+	"headless-server": RequirePhase1Only,
+
 	// Config-only commands
 	"config":   RequireConfig,
 	"bind":     RequireConfig,
@@ -0,0 +1,771 @@
+package state
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrMailNotFound is returned when a mail project, agent, message or
+// reservation does not exist.
+var ErrMailNotFound = errors.New("not found")
+
+// MailProject is a project registered with the local Agent Mail server.
+type MailProject struct {
+	ID        int64     `json:"id"`
+	Slug      string    `json:"slug"`
+	HumanKey  string    `json:"human_key"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MailAgent is an agent identity within a mail project.
+type MailAgent struct {
+	ID              int64     `json:"id"`
+	ProjectID       int64     `json:"project_id"`
+	Name            string    `json:"name"`
+	Program         string    `json:"program"`
+	Model           string    `json:"model"`
+	TaskDescription string    `json:"task_description"`
+	ContactPolicy   string    `json:"contact_policy"`
+	InceptionTS     time.Time `json:"inception_ts"`
+	LastActiveTS    time.Time `json:"last_active_ts"`
+}
+
+// MailRecipient is one recipient of a message and their read/ack state.
+type MailRecipient struct {
+	AgentID int64      `json:"agent_id"`
+	Name    string     `json:"name"`
+	Kind    string     `json:"kind"` // to, cc, bcc
+	ReadTS  *time.Time `json:"read_ts,omitempty"`
+	AckTS   *time.Time `json:"ack_ts,omitempty"`
+}
+
+// MailMessage is a stored message with its recipients.
+type MailMessage struct {
+	ID          int64           `json:"id"`
+	ProjectID   int64           `json:"project_id"`
+	SenderID    int64           `json:"sender_id"`
+	Sender      string          `json:"sender"`
+	ThreadID    string          `json:"thread_id,omitempty"`
+	Subject     string          `json:"subject"`
+	BodyMD      string          `json:"body_md"`
+	Importance  string          `json:"importance"`
+	AckRequired bool            `json:"ack_required"`
+	CreatedTS   time.Time       `json:"created_ts"`
+	Recipients  []MailRecipient `json:"recipients,omitempty"`
+}
+
+// Recipient returns the recipient entry for agentID, if any.
+func (m *MailMessage) Recipient(agentID int64) *MailRecipient {
+	for i := range m.Recipients {
+		if m.Recipients[i].AgentID == agentID {
+			return &m.Recipients[i]
+		}
+	}
+	return nil
+}
+
+// MailInboxQuery filters an agent's inbox.
+type MailInboxQuery struct {
+	Since      time.Time // Only messages created after this time
+	UrgentOnly bool      // Only high/urgent importance
+	Limit      int       // 0 means no limit
+}
+
+// MailReservation is an advisory file reservation.
+type MailReservation struct {
+	ID          int64      `json:"id"`
+	ProjectID   int64      `json:"project_id"`
+	AgentID     int64      `json:"agent_id"`
+	AgentName   string     `json:"agent_name"`
+	PathPattern string     `json:"path_pattern"`
+	Exclusive   bool       `json:"exclusive"`
+	Reason      string     `json:"reason,omitempty"`
+	CreatedTS   time.Time  `json:"created_ts"`
+	ExpiresTS   time.Time  `json:"expires_ts"`
+	ReleasedTS  *time.Time `json:"released_ts,omitempty"`
+}
+
+// Active reports whether the reservation is unreleased and unexpired at now.
+func (r *MailReservation) Active(now time.Time) bool {
+	return r.ReleasedTS == nil && r.ExpiresTS.After(now)
+}
+
+// MailContact is a contact link between two agents.
+type MailContact struct {
+	ID        int64      `json:"id"`
+	ProjectID int64      `json:"project_id"`
+	FromAgent string     `json:"from_agent"`
+	ToAgent   string     `json:"to_agent"`
+	Status    string     `json:"status"` // pending, approved, blocked
+	Reason    string     `json:"reason,omitempty"`
+	UpdatedTS time.Time  `json:"updated_ts"`
+	ExpiresTS *time.Time `json:"expires_ts,omitempty"`
+}
+
+// MailStore persists the local Agent Mail server's projects, agents,
+// messages, reservations and contacts.
+type MailStore struct {
+	store *Store
+}
+
+// NewMailStore returns a new MailStore bound to the provided Store.
+func NewMailStore(store *Store) *MailStore {
+	if store == nil {
+		return nil
+	}
+	return &MailStore{store: store}
+}
+
+// ========================
+// Projects
+// ========================
+
+// EnsureProject returns the project for humanKey, creating it if needed.
+func (m *MailStore) EnsureProject(humanKey, slug string) (*MailProject, error) {
+	if humanKey == "" {
+		return nil, errors.New("project key is required")
+	}
+	m.store.mu.Lock()
+	_, err := m.store.db.Exec(`
+		INSERT OR IGNORE INTO mail_projects (slug, human_key, created_at)
+		VALUES (?, ?, ?)`, slug, humanKey, time.Now().UTC())
+	m.store.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("ensure mail project: %w", err)
+	}
+	return m.FindProject(humanKey)
+}
+
+// FindProject looks a project up by human key, falling back to slug.
+func (m *MailStore) FindProject(key string) (*MailProject, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	var p MailProject
+	err := m.store.db.QueryRow(`
+		SELECT id, slug, human_key, created_at FROM mail_projects
+		WHERE human_key = ? OR slug = ?
+		ORDER BY human_key = ? DESC, id LIMIT 1`, key, key, key,
+	).Scan(&p.ID, &p.Slug, &p.HumanKey, &p.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrMailNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("find mail project: %w", err)
+	}
+	return &p, nil
+}
+
+// ========================
+// Agents
+// ========================
+
+const mailAgentColumns = `id, project_id, name, COALESCE(program, ''), COALESCE(model, ''),
+	COALESCE(task_description, ''), contact_policy, inception_ts, last_active_ts`
+
+func scanMailAgent(row interface{ Scan(...any) error }) (*MailAgent, error) {
+	var a MailAgent
+	if err := row.Scan(&a.ID, &a.ProjectID, &a.Name, &a.Program, &a.Model,
+		&a.TaskDescription, &a.ContactPolicy, &a.InceptionTS, &a.LastActiveTS); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// RegisterAgent creates the agent or refreshes its program, model and task
+// when the name is already registered in the project.
+func (m *MailStore) RegisterAgent(a *MailAgent) (*MailAgent, error) {
+	if a == nil || a.Name == "" {
+		return nil, errors.New("agent name is required")
+	}
+	now := time.Now().UTC()
+	m.store.mu.Lock()
+	_, err := m.store.db.Exec(`
+		INSERT INTO mail_agents (project_id, name, program, model, task_description, inception_ts, last_active_ts)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(project_id, name) DO UPDATE SET
+			program = COALESCE(NULLIF(excluded.program, ''), program),
+			model = COALESCE(NULLIF(excluded.model, ''), model),
+			task_description = COALESCE(NULLIF(excluded.task_description, ''), task_description),
+			last_active_ts = excluded.last_active_ts`,
+		a.ProjectID, a.Name, a.Program, a.Model, a.TaskDescription, now, now)
+	m.store.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("register mail agent: %w", err)
+	}
+	return m.GetAgent(a.ProjectID, a.Name)
+}
+
+// GetAgent returns the named agent in a project.
+func (m *MailStore) GetAgent(projectID int64, name string) (*MailAgent, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	a, err := scanMailAgent(m.store.db.QueryRow(`
+		SELECT `+mailAgentColumns+` FROM mail_agents
+		WHERE project_id = ? AND name = ? COLLATE NOCASE`, projectID, name))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrMailNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get mail agent: %w", err)
+	}
+	return a, nil
+}
+
+// ListAgents returns all agents in a project ordered by name.
+func (m *MailStore) ListAgents(projectID int64) ([]MailAgent, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	rows, err := m.store.db.Query(`
+		SELECT `+mailAgentColumns+` FROM mail_agents
+		WHERE project_id = ? ORDER BY name`, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("list mail agents: %w", err)
+	}
+	defer rows.Close()
+
+	var agents []MailAgent
+	for rows.Next() {
+		a, err := scanMailAgent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan mail agent: %w", err)
+		}
+		agents = append(agents, *a)
+	}
+	return agents, rows.Err()
+}
+
+// TouchAgent records activity for an agent.
+func (m *MailStore) TouchAgent(agentID int64) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+	_, err := m.store.db.Exec(`UPDATE mail_agents SET last_active_ts = ? WHERE id = ?`, time.Now().UTC(), agentID)
+	return err
+}
+
+// SetContactPolicy updates an agent's contact policy.
+func (m *MailStore) SetContactPolicy(agentID int64, policy string) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+	_, err := m.store.db.Exec(`UPDATE mail_agents SET contact_policy = ? WHERE id = ?`, policy, agentID)
+	return err
+}
+
+// ========================
+// Messages
+// ========================
+
+// CreateMessage stores a message and its recipients, filling in ID and
+// CreatedTS.
+func (m *MailStore) CreateMessage(msg *MailMessage) error {
+	if msg == nil {
+		return errors.New("message is nil")
+	}
+	if len(msg.Recipients) == 0 {
+		return errors.New("at least one recipient is required")
+	}
+	if msg.CreatedTS.IsZero() {
+		msg.CreatedTS = time.Now().UTC()
+	}
+	if msg.Importance == "" {
+		msg.Importance = "normal"
+	}
+
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	tx, err := m.store.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	if err := func() error {
+		res, err := tx.Exec(`
+			INSERT INTO mail_messages (project_id, sender_id, thread_id, subject, body_md, importance, ack_required, created_ts)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			msg.ProjectID, msg.SenderID, nullString(msg.ThreadID), msg.Subject, msg.BodyMD,
+			msg.Importance, msg.AckRequired, msg.CreatedTS)
+		if err != nil {
+			return fmt.Errorf("insert message: %w", err)
+		}
+		if msg.ID, err = res.LastInsertId(); err != nil {
+			return err
+		}
+		for _, r := range msg.Recipients {
+			kind := r.Kind
+			if kind == "" {
+				kind = "to"
+			}
+			if _, err := tx.Exec(`
+				INSERT OR IGNORE INTO mail_recipients (message_id, agent_id, kind) VALUES (?, ?, ?)`,
+				msg.ID, r.AgentID, kind); err != nil {
+				return fmt.Errorf("insert recipient: %w", err)
+			}
+		}
+		return nil
+	}(); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+const mailMessageColumns = `m.id, m.project_id, m.sender_id, s.name, COALESCE(m.thread_id, ''),
+	m.subject, m.body_md, m.importance, m.ack_required, m.created_ts`
+
+func scanMailMessage(row interface{ Scan(...any) error }, extra ...any) (*MailMessage, error) {
+	var msg MailMessage
+	dest := append([]any{&msg.ID, &msg.ProjectID, &msg.SenderID, &msg.Sender, &msg.ThreadID,
+		&msg.Subject, &msg.BodyMD, &msg.Importance, &msg.AckRequired, &msg.CreatedTS}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// GetMessage returns a message and its recipients.
+func (m *MailStore) GetMessage(projectID, id int64) (*MailMessage, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	msg, err := scanMailMessage(m.store.db.QueryRow(`
+		SELECT `+mailMessageColumns+`
+		FROM mail_messages m JOIN mail_agents s ON s.id = m.sender_id
+		WHERE m.project_id = ? AND m.id = ?`, projectID, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrMailNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get message: %w", err)
+	}
+	if err := m.loadRecipients(msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (m *MailStore) loadRecipients(msg *MailMessage) error {
+	rows, err := m.store.db.Query(`
+		SELECT r.agent_id, a.name, r.kind, r.read_ts, r.ack_ts
+		FROM mail_recipients r JOIN mail_agents a ON a.id = r.agent_id
+		WHERE r.message_id = ? ORDER BY a.name`, msg.ID)
+	if err != nil {
+		return fmt.Errorf("load recipients: %w", err)
+	}
+	defer rows.Close()
+
+	msg.Recipients = nil
+	for rows.Next() {
+		var (
+			r           MailRecipient
+			read, acked sql.NullTime
+		)
+		if err := rows.Scan(&r.AgentID, &r.Name, &r.Kind, &read, &acked); err != nil {
+			return fmt.Errorf("scan recipient: %w", err)
+		}
+		r.ReadTS = nullTimePtr(read)
+		r.AckTS = nullTimePtr(acked)
+		msg.Recipients = append(msg.Recipients, r)
+	}
+	return rows.Err()
+}
+
+// Inbox returns messages addressed to agentID, newest first. Each message
+// carries only the agent's own recipient entry.
+func (m *MailStore) Inbox(agentID int64, q MailInboxQuery) ([]MailMessage, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	query := `
+		SELECT ` + mailMessageColumns + `, r.kind, r.read_ts, r.ack_ts
+		FROM mail_recipients r
+		JOIN mail_messages m ON m.id = r.message_id
+		JOIN mail_agents s ON s.id = m.sender_id
+		WHERE r.agent_id = ?`
+	if q.UrgentOnly {
+		query += ` AND m.importance IN ('high', 'urgent')`
+	}
+	query += ` ORDER BY m.id DESC`
+
+	rows, err := m.store.db.Query(query, agentID)
+	if err != nil {
+		return nil, fmt.Errorf("query inbox: %w", err)
+	}
+	defer rows.Close()
+
+	var msgs []MailMessage
+	for rows.Next() {
+		var (
+			r           = MailRecipient{AgentID: agentID}
+			read, acked sql.NullTime
+		)
+		msg, err := scanMailMessage(rows, &r.Kind, &read, &acked)
+		if err != nil {
+			return nil, fmt.Errorf("scan inbox message: %w", err)
+		}
+		// Timestamps are compared here rather than in SQL because rows
+		// written by CURRENT_TIMESTAMP and by the driver differ in format.
+		if !q.Since.IsZero() && !msg.CreatedTS.After(q.Since) {
+			continue
+		}
+		r.ReadTS = nullTimePtr(read)
+		r.AckTS = nullTimePtr(acked)
+		msg.Recipients = []MailRecipient{r}
+		msgs = append(msgs, *msg)
+		if q.Limit > 0 && len(msgs) >= q.Limit {
+			break
+		}
+	}
+	return msgs, rows.Err()
+}
+
+// MarkRead records that agentID has read a message.
+func (m *MailStore) MarkRead(messageID, agentID int64) error {
+	return m.updateRecipient(`UPDATE mail_recipients SET read_ts = COALESCE(read_ts, ?)
+		WHERE message_id = ? AND agent_id = ?`, messageID, agentID)
+}
+
+// Acknowledge records that agentID has acknowledged (and so read) a message.
+func (m *MailStore) Acknowledge(messageID, agentID int64) error {
+	return m.updateRecipient(`UPDATE mail_recipients SET read_ts = COALESCE(read_ts, ?1), ack_ts = COALESCE(ack_ts, ?1)
+		WHERE message_id = ?2 AND agent_id = ?3`, messageID, agentID)
+}
+
+func (m *MailStore) updateRecipient(query string, messageID, agentID int64) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	res, err := m.store.db.Exec(query, time.Now().UTC(), messageID, agentID)
+	if err != nil {
+		return fmt.Errorf("update recipient: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrMailNotFound
+	}
+	return nil
+}
+
+// SearchMessages returns messages whose subject or body contains query,
+// newest first.
+func (m *MailStore) SearchMessages(projectID int64, query string, limit int) ([]MailMessage, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	pattern := "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(query) + "%"
+	return m.queryMessages(`
+		SELECT `+mailMessageColumns+`
+		FROM mail_messages m JOIN mail_agents s ON s.id = m.sender_id
+		WHERE m.project_id = ? AND (m.subject LIKE ? ESCAPE '\' OR m.body_md LIKE ? ESCAPE '\')
+		ORDER BY m.id DESC LIMIT ?`, projectID, pattern, pattern, limit)
+}
+
+// ThreadMessages returns a thread's messages oldest first. A numeric thread
+// ID also matches the message that started the thread.
+func (m *MailStore) ThreadMessages(projectID int64, threadID string) ([]MailMessage, error) {
+	msgs, err := m.queryMessages(`
+		SELECT `+mailMessageColumns+`
+		FROM mail_messages m JOIN mail_agents s ON s.id = m.sender_id
+		WHERE m.project_id = ? AND (m.thread_id = ? OR CAST(m.id AS TEXT) = ?)
+		ORDER BY m.id`, projectID, threadID, threadID)
+	if err != nil {
+		return nil, err
+	}
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+	for i := range msgs {
+		if err := m.loadRecipients(&msgs[i]); err != nil {
+			return nil, err
+		}
+	}
+	return msgs, nil
+}
+
+func (m *MailStore) queryMessages(query string, args ...any) ([]MailMessage, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	rows, err := m.store.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query messages: %w", err)
+	}
+	defer rows.Close()
+
+	var msgs []MailMessage
+	for rows.Next() {
+		msg, err := scanMailMessage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan message: %w", err)
+		}
+		msgs = append(msgs, *msg)
+	}
+	return msgs, rows.Err()
+}
+
+// ========================
+// File reservations
+// ========================
+
+const mailReservationColumns = `r.id, r.project_id, r.agent_id, a.name, r.path_pattern, r.exclusive,
+	COALESCE(r.reason, ''), r.created_ts, r.expires_ts, r.released_ts`
+
+func scanMailReservation(row interface{ Scan(...any) error }) (*MailReservation, error) {
+	var (
+		r        MailReservation
+		released sql.NullTime
+	)
+	if err := row.Scan(&r.ID, &r.ProjectID, &r.AgentID, &r.AgentName, &r.PathPattern, &r.Exclusive,
+		&r.Reason, &r.CreatedTS, &r.ExpiresTS, &released); err != nil {
+		return nil, err
+	}
+	r.ReleasedTS = nullTimePtr(released)
+	return &r, nil
+}
+
+// CreateReservation stores a reservation, filling in ID and CreatedTS.
+func (m *MailStore) CreateReservation(r *MailReservation) error {
+	if r == nil || r.PathPattern == "" {
+		return errors.New("path pattern is required")
+	}
+	if r.CreatedTS.IsZero() {
+		r.CreatedTS = time.Now().UTC()
+	}
+
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+	return insertReservation(m.store.db, r)
+}
+
+func insertReservation(db interface {
+	Exec(string, ...any) (sql.Result, error)
+}, r *MailReservation) error {
+	res, err := db.Exec(`
+		INSERT INTO mail_file_reservations (project_id, agent_id, path_pattern, exclusive, reason, created_ts, expires_ts)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		r.ProjectID, r.AgentID, r.PathPattern, r.Exclusive, nullString(r.Reason), r.CreatedTS, r.ExpiresTS)
+	if err != nil {
+		return fmt.Errorf("insert reservation: %w", err)
+	}
+	r.ID, err = res.LastInsertId()
+	return err
+}
+
+// MailReservationTx writes reservations inside MailStore.Reserve.
+type MailReservationTx struct {
+	tx *sql.Tx
+}
+
+// Create stores a reservation, filling in ID and CreatedTS.
+func (t *MailReservationTx) Create(r *MailReservation) error {
+	if r == nil || r.PathPattern == "" {
+		return errors.New("path pattern is required")
+	}
+	if r.CreatedTS.IsZero() {
+		r.CreatedTS = time.Now().UTC()
+	}
+	return insertReservation(t.tx, r)
+}
+
+// Extend moves a reservation's expiry.
+func (t *MailReservationTx) Extend(id int64, expires time.Time) error {
+	if _, err := t.tx.Exec(`UPDATE mail_file_reservations SET expires_ts = ? WHERE id = ?`, expires, id); err != nil {
+		return fmt.Errorf("extend reservation %d: %w", id, err)
+	}
+	return nil
+}
+
+// Reserve calls fn with the project's active reservations and commits what
+// fn writes through tx in one transaction. The store's write lock is held
+// throughout, so no conflicting reservation can be granted between fn's
+// check and its writes. If fn returns an error nothing is written.
+func (m *MailStore) Reserve(projectID int64, now time.Time, fn func(active []MailReservation, tx *MailReservationTx) error) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	tx, err := m.store.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	if err := func() error {
+		active, err := activeReservations(tx, projectID, now)
+		if err != nil {
+			return err
+		}
+		return fn(active, &MailReservationTx{tx: tx})
+	}(); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// ActiveReservations returns the project's unreleased, unexpired
+// reservations ordered by ID.
+func (m *MailStore) ActiveReservations(projectID int64, now time.Time) ([]MailReservation, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+	return activeReservations(m.store.db, projectID, now)
+}
+
+func activeReservations(db interface {
+	Query(string, ...any) (*sql.Rows, error)
+}, projectID int64, now time.Time) ([]MailReservation, error) {
+	rows, err := db.Query(`
+		SELECT `+mailReservationColumns+`
+		FROM mail_file_reservations r JOIN mail_agents a ON a.id = r.agent_id
+		WHERE r.project_id = ? AND r.released_ts IS NULL
+		ORDER BY r.id`, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("query reservations: %w", err)
+	}
+	defer rows.Close()
+
+	var out []MailReservation
+	for rows.Next() {
+		r, err := scanMailReservation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan reservation: %w", err)
+		}
+		if r.Active(now) {
+			out = append(out, *r)
+		}
+	}
+	return out, rows.Err()
+}
+
+// GetReservation returns a reservation by ID regardless of its state.
+func (m *MailStore) GetReservation(projectID, id int64) (*MailReservation, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	r, err := scanMailReservation(m.store.db.QueryRow(`
+		SELECT `+mailReservationColumns+`
+		FROM mail_file_reservations r JOIN mail_agents a ON a.id = r.agent_id
+		WHERE r.project_id = ? AND r.id = ?`, projectID, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrMailNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get reservation: %w", err)
+	}
+	return r, nil
+}
+
+// ReleaseReservations marks reservations released at now.
+func (m *MailStore) ReleaseReservations(ids []int64, now time.Time) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	for _, id := range ids {
+		if _, err := m.store.db.Exec(`
+			UPDATE mail_file_reservations SET released_ts = ?
+			WHERE id = ? AND released_ts IS NULL`, now, id); err != nil {
+			return fmt.Errorf("release reservation %d: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// ExtendReservation moves a reservation's expiry to expires.
+func (m *MailStore) ExtendReservation(id int64, expires time.Time) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	_, err := m.store.db.Exec(`UPDATE mail_file_reservations SET expires_ts = ? WHERE id = ?`, expires, id)
+	if err != nil {
+		return fmt.Errorf("extend reservation %d: %w", id, err)
+	}
+	return nil
+}
+
+// ========================
+// Contacts
+// ========================
+
+// UpsertContact creates or updates the link from fromID to toID.
+func (m *MailStore) UpsertContact(projectID, fromID, toID int64, status, reason string, expires *time.Time) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	_, err := m.store.db.Exec(`
+		INSERT INTO mail_contacts (project_id, from_agent_id, to_agent_id, status, reason, updated_ts, expires_ts)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(from_agent_id, to_agent_id) DO UPDATE SET
+			status = excluded.status,
+			reason = COALESCE(NULLIF(excluded.reason, ''), reason),
+			updated_ts = excluded.updated_ts,
+			expires_ts = excluded.expires_ts`,
+		projectID, fromID, toID, status, reason, time.Now().UTC(), expires)
+	if err != nil {
+		return fmt.Errorf("upsert contact: %w", err)
+	}
+	return nil
+}
+
+// ListContacts returns links where agentID is either side.
+func (m *MailStore) ListContacts(agentID int64) ([]MailContact, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	rows, err := m.store.db.Query(`
+		SELECT c.id, c.project_id, f.name, t.name, c.status, COALESCE(c.reason, ''), c.updated_ts, c.expires_ts
+		FROM mail_contacts c
+		JOIN mail_agents f ON f.id = c.from_agent_id
+		JOIN mail_agents t ON t.id = c.to_agent_id
+		WHERE c.from_agent_id = ? OR c.to_agent_id = ?
+		ORDER BY c.id`, agentID, agentID)
+	if err != nil {
+		return nil, fmt.Errorf("list contacts: %w", err)
+	}
+	defer rows.Close()
+
+	var out []MailContact
+	for rows.Next() {
+		var (
+			c       MailContact
+			expires sql.NullTime
+		)
+		if err := rows.Scan(&c.ID, &c.ProjectID, &c.FromAgent, &c.ToAgent, &c.Status, &c.Reason, &c.UpdatedTS, &expires); err != nil {
+			return nil, fmt.Errorf("scan contact: %w", err)
+		}
+		c.ExpiresTS = nullTimePtr(expires)
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// ContactApproved reports whether an unexpired approved link exists between
+// the two agents in either direction.
+func (m *MailStore) ContactApproved(a, b int64, now time.Time) (bool, error) {
+	contacts, err := m.ListContacts(a)
+	if err != nil {
+		return false, err
+	}
+	m.store.mu.RLock()
+	var bName string
+	err = m.store.db.QueryRow(`SELECT name FROM mail_agents WHERE id = ?`, b).Scan(&bName)
+	m.store.mu.RUnlock()
+	if err != nil {
+		return false, fmt.Errorf("lookup agent: %w", err)
+	}
+	for _, c := range contacts {
+		if c.Status != "approved" || (c.FromAgent != bName && c.ToAgent != bName) {
+			continue
+		}
+		if c.ExpiresTS == nil || c.ExpiresTS.After(now) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	v := t.Time
+	return &v
+}
@@ -0,0 +1,150 @@
+package state
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMailStoreMessages(t *testing.T) {
+	t.Parallel()
+	m := NewMailStore(testStoreFile(t))
+
+	p, err := m.EnsureProject("/work/app", "app")
+	if err != nil {
+		t.Fatalf("EnsureProject: %v", err)
+	}
+	again, err := m.EnsureProject("/work/app", "app")
+	if err != nil || again.ID != p.ID {
+		t.Fatalf("EnsureProject not idempotent: %v, %d != %d", err, again.ID, p.ID)
+	}
+	if bySlug, err := m.FindProject("app"); err != nil || bySlug.ID != p.ID {
+		t.Fatalf("FindProject by slug = %v, %v", bySlug, err)
+	}
+
+	alice, err := m.RegisterAgent(&MailAgent{ProjectID: p.ID, Name: "BlueLake", Program: "claude"})
+	if err != nil {
+		t.Fatalf("RegisterAgent: %v", err)
+	}
+	bob, err := m.RegisterAgent(&MailAgent{ProjectID: p.ID, Name: "RedStone"})
+	if err != nil {
+		t.Fatalf("RegisterAgent: %v", err)
+	}
+	if alice.ContactPolicy != "auto" {
+		t.Errorf("default contact policy = %q, want auto", alice.ContactPolicy)
+	}
+	// Re-registering keeps fields that are not supplied.
+	if again, _ := m.RegisterAgent(&MailAgent{ProjectID: p.ID, Name: "BlueLake", Model: "opus"}); again.Program != "claude" || again.Model != "opus" {
+		t.Errorf("re-register = %+v", again)
+	}
+
+	base := time.Now().UTC().Add(-time.Hour)
+	for i, subject := range []string{"first", "second", "urgent"} {
+		msg := &MailMessage{
+			ProjectID:  p.ID,
+			SenderID:   alice.ID,
+			Subject:    subject,
+			BodyMD:     "body " + subject,
+			CreatedTS:  base.Add(time.Duration(i) * time.Minute),
+			Recipients: []MailRecipient{{AgentID: bob.ID, Kind: "to"}},
+		}
+		if subject == "urgent" {
+			msg.Importance = "urgent"
+		}
+		if err := m.CreateMessage(msg); err != nil {
+			t.Fatalf("CreateMessage: %v", err)
+		}
+	}
+
+	inbox, err := m.Inbox(bob.ID, MailInboxQuery{})
+	if err != nil {
+		t.Fatalf("Inbox: %v", err)
+	}
+	if len(inbox) != 3 || inbox[0].Subject != "urgent" || inbox[0].Sender != "BlueLake" {
+		t.Fatalf("Inbox = %+v", inbox)
+	}
+	if got, _ := m.Inbox(bob.ID, MailInboxQuery{UrgentOnly: true}); len(got) != 1 {
+		t.Errorf("urgent inbox len = %d, want 1", len(got))
+	}
+	if got, _ := m.Inbox(bob.ID, MailInboxQuery{Since: base.Add(30 * time.Second)}); len(got) != 2 {
+		t.Errorf("since inbox len = %d, want 2", len(got))
+	}
+	if got, _ := m.Inbox(bob.ID, MailInboxQuery{Limit: 1}); len(got) != 1 {
+		t.Errorf("limited inbox len = %d, want 1", len(got))
+	}
+
+	first := inbox[2]
+	if err := m.Acknowledge(first.ID, bob.ID); err != nil {
+		t.Fatalf("Acknowledge: %v", err)
+	}
+	if err := m.MarkRead(first.ID, alice.ID); !errors.Is(err, ErrMailNotFound) {
+		t.Errorf("MarkRead by non-recipient = %v, want ErrMailNotFound", err)
+	}
+	got, err := m.GetMessage(p.ID, first.ID)
+	if err != nil {
+		t.Fatalf("GetMessage: %v", err)
+	}
+	r := got.Recipient(bob.ID)
+	if r == nil || r.ReadTS == nil || r.AckTS == nil {
+		t.Errorf("recipient after ack = %+v", r)
+	}
+
+	found, err := m.SearchMessages(p.ID, "second", 10)
+	if err != nil || len(found) != 1 {
+		t.Errorf("SearchMessages = %v, %v", found, err)
+	}
+}
+
+func TestMailStoreReservationsAndContacts(t *testing.T) {
+	t.Parallel()
+	m := NewMailStore(testStoreFile(t))
+
+	p, _ := m.EnsureProject("/work/app", "app")
+	alice, _ := m.RegisterAgent(&MailAgent{ProjectID: p.ID, Name: "BlueLake"})
+	bob, _ := m.RegisterAgent(&MailAgent{ProjectID: p.ID, Name: "RedStone"})
+
+	now := time.Now().UTC()
+	live := &MailReservation{ProjectID: p.ID, AgentID: alice.ID, PathPattern: "internal/**", Exclusive: true, ExpiresTS: now.Add(time.Hour)}
+	expired := &MailReservation{ProjectID: p.ID, AgentID: bob.ID, PathPattern: "docs/*", ExpiresTS: now.Add(-time.Minute)}
+	for _, r := range []*MailReservation{live, expired} {
+		if err := m.CreateReservation(r); err != nil {
+			t.Fatalf("CreateReservation: %v", err)
+		}
+	}
+
+	active, err := m.ActiveReservations(p.ID, now)
+	if err != nil {
+		t.Fatalf("ActiveReservations: %v", err)
+	}
+	if len(active) != 1 || active[0].ID != live.ID || active[0].AgentName != "BlueLake" {
+		t.Fatalf("ActiveReservations = %+v", active)
+	}
+
+	if err := m.ReleaseReservations([]int64{live.ID}, now); err != nil {
+		t.Fatalf("ReleaseReservations: %v", err)
+	}
+	if active, _ := m.ActiveReservations(p.ID, now); len(active) != 0 {
+		t.Errorf("after release: %+v", active)
+	}
+	if r, err := m.GetReservation(p.ID, live.ID); err != nil || r.ReleasedTS == nil {
+		t.Errorf("GetReservation after release = %+v, %v", r, err)
+	}
+
+	if ok, _ := m.ContactApproved(alice.ID, bob.ID, now); ok {
+		t.Error("contact approved before any link")
+	}
+	if err := m.UpsertContact(p.ID, alice.ID, bob.ID, "pending", "review", nil); err != nil {
+		t.Fatalf("UpsertContact: %v", err)
+	}
+	if err := m.UpsertContact(p.ID, alice.ID, bob.ID, "approved", "", nil); err != nil {
+		t.Fatalf("UpsertContact: %v", err)
+	}
+	contacts, err := m.ListContacts(bob.ID)
+	if err != nil || len(contacts) != 1 || contacts[0].Status != "approved" || contacts[0].Reason != "review" {
+		t.Fatalf("ListContacts = %+v, %v", contacts, err)
+	}
+	// Approval is symmetric for messaging purposes.
+	if ok, _ := m.ContactApproved(bob.ID, alice.ID, now); !ok {
+		t.Error("ContactApproved(bob, alice) = false")
+	}
+}
@@ -0,0 +1,84 @@
+-- NTM State Store: Local Agent Mail Schema
+-- Version: 007
+-- Description: Backs the built-in Agent Mail server (ntm mail serve) used
+-- when the external MCP Agent Mail server is not installed.
+
+-- Projects are keyed by absolute path (human_key); slug is derived from it
+CREATE TABLE IF NOT EXISTS mail_projects (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    slug TEXT NOT NULL,
+    human_key TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_mail_projects_slug ON mail_projects(slug);
+
+-- Agents registered within a project
+CREATE TABLE IF NOT EXISTS mail_agents (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    project_id INTEGER NOT NULL REFERENCES mail_projects(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    program TEXT,
+    model TEXT,
+    task_description TEXT,
+    contact_policy TEXT NOT NULL DEFAULT 'auto',  -- open, auto, contacts_only, block_all
+    inception_ts TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_active_ts TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(project_id, name)
+);
+
+-- Messages; per-recipient state lives in mail_recipients
+CREATE TABLE IF NOT EXISTS mail_messages (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    project_id INTEGER NOT NULL REFERENCES mail_projects(id) ON DELETE CASCADE,
+    sender_id INTEGER NOT NULL REFERENCES mail_agents(id) ON DELETE CASCADE,
+    thread_id TEXT,
+    subject TEXT NOT NULL,
+    body_md TEXT NOT NULL DEFAULT '',
+    importance TEXT NOT NULL DEFAULT 'normal',  -- low, normal, high, urgent
+    ack_required INTEGER NOT NULL DEFAULT 0,
+    created_ts TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_mail_messages_project ON mail_messages(project_id, created_ts);
+CREATE INDEX IF NOT EXISTS idx_mail_messages_thread ON mail_messages(project_id, thread_id);
+
+CREATE TABLE IF NOT EXISTS mail_recipients (
+    message_id INTEGER NOT NULL REFERENCES mail_messages(id) ON DELETE CASCADE,
+    agent_id INTEGER NOT NULL REFERENCES mail_agents(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL DEFAULT 'to',  -- to, cc, bcc
+    read_ts TIMESTAMP,
+    ack_ts TIMESTAMP,
+    PRIMARY KEY (message_id, agent_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_mail_recipients_agent ON mail_recipients(agent_id);
+
+-- Advisory file reservations (path or glob patterns)
+CREATE TABLE IF NOT EXISTS mail_file_reservations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    project_id INTEGER NOT NULL REFERENCES mail_projects(id) ON DELETE CASCADE,
+    agent_id INTEGER NOT NULL REFERENCES mail_agents(id) ON DELETE CASCADE,
+    path_pattern TEXT NOT NULL,
+    exclusive INTEGER NOT NULL DEFAULT 1,
+    reason TEXT,
+    created_ts TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_ts TIMESTAMP NOT NULL,
+    released_ts TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_mail_reservations_active
+    ON mail_file_reservations(project_id, released_ts, expires_ts);
+
+-- Contact links between agents
+CREATE TABLE IF NOT EXISTS mail_contacts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    project_id INTEGER NOT NULL REFERENCES mail_projects(id) ON DELETE CASCADE,
+    from_agent_id INTEGER NOT NULL REFERENCES mail_agents(id) ON DELETE CASCADE,
+    to_agent_id INTEGER NOT NULL REFERENCES mail_agents(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending',  -- pending, approved, blocked
+    reason TEXT,
+    updated_ts TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_ts TIMESTAMP,
+    UNIQUE(from_agent_id, to_agent_id)
+);
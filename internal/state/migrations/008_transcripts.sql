@@ -0,0 +1,65 @@
+-- Agent transcript ingestion
+-- Normalized turns parsed from Claude Code, Codex and Gemini CLI transcripts,
+-- linked back to the ntm session, pane and bead that produced them.
+
+-- One row per transcript file
+CREATE TABLE transcripts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    path TEXT NOT NULL UNIQUE,
+    agent TEXT NOT NULL,                -- claude, codex, gemini
+    agent_session_id TEXT,              -- The agent's own session ID
+    session_name TEXT,                  -- ntm session, if linked
+    pane_index INTEGER,                 -- NULL when the pane could not be determined
+    bead_id TEXT,
+    cwd TEXT,
+    started_at TIMESTAMP,
+    ended_at TIMESTAMP,
+    file_size INTEGER NOT NULL DEFAULT 0,
+    file_mtime TIMESTAMP,
+    turn_count INTEGER NOT NULL DEFAULT 0,
+    ingested_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_transcripts_session ON transcripts(session_name, pane_index);
+CREATE INDEX idx_transcripts_bead ON transcripts(bead_id);
+
+-- User prompts and assistant responses
+CREATE TABLE transcript_turns (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    transcript_id INTEGER NOT NULL REFERENCES transcripts(id) ON DELETE CASCADE,
+    seq INTEGER NOT NULL,
+    role TEXT NOT NULL,                 -- user, assistant
+    text TEXT NOT NULL DEFAULT '',
+    model TEXT,
+    ts TIMESTAMP,
+    UNIQUE(transcript_id, seq)
+);
+
+CREATE INDEX idx_transcript_turns_ts ON transcript_turns(ts);
+
+-- Tool calls made during assistant turns
+CREATE TABLE transcript_tool_calls (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    turn_id INTEGER NOT NULL REFERENCES transcript_turns(id) ON DELETE CASCADE,
+    transcript_id INTEGER NOT NULL REFERENCES transcripts(id) ON DELETE CASCADE,
+    call_id TEXT,
+    name TEXT NOT NULL,
+    input TEXT,                         -- Arguments, usually JSON
+    output TEXT,
+    is_error INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX idx_transcript_tool_calls_turn ON transcript_tool_calls(turn_id);
+CREATE INDEX idx_transcript_tool_calls_name ON transcript_tool_calls(name);
+
+-- Files created, modified or deleted by tool calls
+CREATE TABLE transcript_file_edits (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tool_call_id INTEGER NOT NULL REFERENCES transcript_tool_calls(id) ON DELETE CASCADE,
+    transcript_id INTEGER NOT NULL REFERENCES transcripts(id) ON DELETE CASCADE,
+    path TEXT NOT NULL,
+    ts TIMESTAMP
+);
+
+CREATE INDEX idx_transcript_file_edits_path ON transcript_file_edits(path);
+CREATE INDEX idx_transcript_file_edits_transcript ON transcript_file_edits(transcript_id);
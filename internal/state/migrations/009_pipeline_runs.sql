@@ -0,0 +1,41 @@
+-- Pipeline run history
+-- One row per workflow run persisted under .ntm/pipelines/, indexed so runs
+-- can be listed, filtered and compared without loading every state file.
+
+CREATE TABLE pipeline_runs (
+    run_id TEXT PRIMARY KEY,
+    project_dir TEXT NOT NULL,
+    workflow_id TEXT NOT NULL,
+    workflow_file TEXT,
+    session_name TEXT,
+    status TEXT NOT NULL,               -- running, completed, failed, cancelled, ...
+    vars TEXT,                          -- Input variables, JSON object
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP,
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    step_count INTEGER NOT NULL DEFAULT 0,
+    failed_steps INTEGER NOT NULL DEFAULT 0,
+    retries INTEGER NOT NULL DEFAULT 0,
+    cost_usd REAL NOT NULL DEFAULT 0,   -- Estimated from prompt and output tokens
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_pipeline_runs_workflow ON pipeline_runs(workflow_id, started_at);
+CREATE INDEX idx_pipeline_runs_started ON pipeline_runs(started_at);
+
+-- Per-step outcomes of a run
+CREATE TABLE pipeline_run_steps (
+    run_id TEXT NOT NULL REFERENCES pipeline_runs(run_id) ON DELETE CASCADE,
+    step_id TEXT NOT NULL,
+    status TEXT NOT NULL,
+    agent_type TEXT,
+    pane TEXT,
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    cost_usd REAL NOT NULL DEFAULT 0,
+    output TEXT,
+    error TEXT,
+    PRIMARY KEY (run_id, step_id)
+);
@@ -0,0 +1,30 @@
+-- Prompt queue
+-- Prompts waiting for an agent pane to go idle. A prompt targets either one
+-- pane or, when pane_id is NULL, the first idle pane of an agent type.
+
+CREATE TABLE prompt_queue (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_name TEXT NOT NULL,
+    pane_id TEXT,                       -- tmux pane ID (%N); NULL for agent-type targets
+    pane_index INTEGER,                 -- For display; the pane ID is authoritative
+    agent_type TEXT,                    -- cc, cod, gmi, ...
+    prompt TEXT NOT NULL,
+    priority INTEGER NOT NULL DEFAULT 2, -- 0 (first) to 4 (last), like bead priorities
+    position INTEGER NOT NULL DEFAULT 0, -- Order within a priority; rewritten by reorder
+    dedup_key TEXT,
+    source TEXT,                        -- cli, robot, api, ...
+    status TEXT NOT NULL DEFAULT 'pending', -- pending, delivering, delivered, failed, expired
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    delivered_pane TEXT,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP,
+    delivered_at TIMESTAMP
+);
+
+CREATE INDEX idx_prompt_queue_pending ON prompt_queue(session_name, status, priority, position);
+
+-- At most one waiting prompt per dedup key and target in a session
+CREATE UNIQUE INDEX idx_prompt_queue_dedup
+    ON prompt_queue(session_name, COALESCE(pane_id, 'type:' || COALESCE(agent_type, '')), dedup_key)
+    WHERE dedup_key IS NOT NULL AND status IN ('pending', 'delivering');
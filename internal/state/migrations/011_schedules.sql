@@ -0,0 +1,41 @@
+-- Scheduled jobs
+-- Recurring sends, checkpoints, pipeline runs, scans and handoffs, fired by
+-- the session monitor from a cron expression or a fixed interval.
+
+CREATE TABLE schedules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    session_name TEXT NOT NULL,
+    project_dir TEXT,                   -- Working directory for the job
+    kind TEXT NOT NULL,                 -- send, checkpoint, pipeline, scan, handoff
+    args TEXT,                          -- Extra command arguments, JSON array
+    spec TEXT NOT NULL,                 -- Cron expression, @daily, or "@every 30m"
+    jitter_seconds INTEGER NOT NULL DEFAULT 0,
+    missed_policy TEXT NOT NULL DEFAULT 'skip', -- skip, catch-up
+    paused INTEGER NOT NULL DEFAULT 0,
+    next_run_at TIMESTAMP,
+    last_run_at TIMESTAMP,
+    last_status TEXT,                   -- ok, failed, skipped
+    last_error TEXT,
+    run_count INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_schedules_due ON schedules(session_name, paused, next_run_at);
+
+-- One row per firing (or skipped firing) of a schedule
+CREATE TABLE schedule_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    schedule_id INTEGER NOT NULL REFERENCES schedules(id) ON DELETE CASCADE,
+    scheduled_for TIMESTAMP NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP,
+    status TEXT NOT NULL,               -- ok, failed, skipped
+    trigger TEXT NOT NULL DEFAULT 'schedule', -- schedule, catch-up, manual
+    missed INTEGER NOT NULL DEFAULT 0,  -- Firings folded into this one by catch-up
+    error TEXT,
+    output TEXT                         -- Tail of the command's output
+);
+
+CREATE INDEX idx_schedule_runs_schedule ON schedule_runs(schedule_id, started_at);
@@ -0,0 +1,21 @@
+-- Spend ledger
+-- Token usage and its estimated cost, recorded by the cost tracker as prompts
+-- are sent and agents respond. Budgets and monthly reports are computed from
+-- these rows.
+
+CREATE TABLE spend_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_name TEXT NOT NULL,
+    pane_id TEXT,
+    agent_type TEXT,                    -- cc, cod, gmi, ...
+    model TEXT,
+    input_tokens INTEGER NOT NULL DEFAULT 0,
+    output_tokens INTEGER NOT NULL DEFAULT 0,
+    cost_usd REAL NOT NULL DEFAULT 0,
+    source TEXT NOT NULL,               -- prompt, response, tokens
+    day TEXT NOT NULL,                  -- Local date (YYYY-MM-DD) for daily budgets and rollups
+    recorded_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_spend_events_session ON spend_events(session_name, agent_type);
+CREATE INDEX idx_spend_events_day ON spend_events(day);
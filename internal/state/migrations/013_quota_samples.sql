@@ -0,0 +1,17 @@
+-- Quota snapshots
+-- Usage of each provider account, sampled by the session monitor from idle
+-- agents. Usage-limit forecasts are fitted to these rows together with the
+-- prompts sent from the spend ledger.
+
+CREATE TABLE quota_samples (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    provider TEXT NOT NULL,             -- claude, codex, gemini
+    account TEXT NOT NULL,
+    session_name TEXT,                  -- Session whose pane was sampled
+    usage_percent REAL NOT NULL DEFAULT 0, -- Highest of the provider's usage figures
+    reset_at TIMESTAMP,                 -- When the usage period resets, if known
+    is_limited INTEGER NOT NULL DEFAULT 0,
+    recorded_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_quota_samples_provider ON quota_samples(provider, recorded_at);
@@ -0,0 +1,5 @@
+-- Prompt queue claims
+-- When a prompt was claimed for delivery, so a claim left behind by a
+-- dispatcher that died mid-send can be returned to pending.
+
+ALTER TABLE prompt_queue ADD COLUMN claimed_at TIMESTAMP;
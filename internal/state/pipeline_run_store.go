@@ -0,0 +1,270 @@
+package state
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PipelineRun is the indexed summary of one workflow run.
+type PipelineRun struct {
+	RunID        string                 `json:"run_id"`
+	ProjectDir   string                 `json:"project_dir"`
+	WorkflowID   string                 `json:"workflow"`
+	WorkflowFile string                 `json:"workflow_file,omitempty"`
+	SessionName  string                 `json:"session,omitempty"`
+	Status       string                 `json:"status"`
+	Vars         map[string]interface{} `json:"vars,omitempty"`
+	StartedAt    time.Time              `json:"started_at"`
+	FinishedAt   *time.Time             `json:"finished_at,omitempty"`
+	DurationMs   int64                  `json:"duration_ms"`
+	StepCount    int                    `json:"step_count"`
+	FailedSteps  int                    `json:"failed_steps"`
+	Retries      int                    `json:"retries"`
+	CostUSD      float64                `json:"cost_usd"`
+	UpdatedAt    time.Time              `json:"updated_at"`
+	Steps        []PipelineRunStep      `json:"steps,omitempty"`
+}
+
+// PipelineRunStep is the outcome of one step within a run.
+type PipelineRunStep struct {
+	StepID     string     `json:"step_id"`
+	Status     string     `json:"status"`
+	AgentType  string     `json:"agent_type,omitempty"`
+	Pane       string     `json:"pane,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	DurationMs int64      `json:"duration_ms"`
+	Attempts   int        `json:"attempts,omitempty"`
+	CostUSD    float64    `json:"cost_usd"`
+	Output     string     `json:"output,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// PipelineRunFilter narrows List. Zero fields match everything.
+type PipelineRunFilter struct {
+	ProjectDir  string
+	WorkflowID  string
+	Status      string
+	SessionName string
+	Since       time.Time
+	Limit       int
+}
+
+// PipelineRunStore indexes pipeline runs in the state database.
+type PipelineRunStore struct {
+	store *Store
+}
+
+// NewPipelineRunStore creates a pipeline run store backed by store.
+func NewPipelineRunStore(store *Store) *PipelineRunStore {
+	if store == nil {
+		return nil
+	}
+	return &PipelineRunStore{store: store}
+}
+
+// Save inserts or replaces a run and its steps.
+func (ps *PipelineRunStore) Save(run *PipelineRun) error {
+	if run.RunID == "" {
+		return errors.New("run id is required")
+	}
+	if run.UpdatedAt.IsZero() {
+		run.UpdatedAt = time.Now().UTC()
+	}
+	var vars sql.NullString
+	if len(run.Vars) > 0 {
+		data, err := json.Marshal(run.Vars)
+		if err != nil {
+			return fmt.Errorf("marshal vars: %w", err)
+		}
+		vars = sql.NullString{String: string(data), Valid: true}
+	}
+
+	ps.store.mu.Lock()
+	defer ps.store.mu.Unlock()
+
+	tx, err := ps.store.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	if err := func() error {
+		if _, err := tx.Exec(`DELETE FROM pipeline_runs WHERE run_id = ?`, run.RunID); err != nil {
+			return fmt.Errorf("delete pipeline run: %w", err)
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO pipeline_runs (run_id, project_dir, workflow_id, workflow_file, session_name, status, vars,
+				started_at, finished_at, duration_ms, step_count, failed_steps, retries, cost_usd, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			run.RunID, run.ProjectDir, run.WorkflowID, nullString(run.WorkflowFile), nullString(run.SessionName),
+			run.Status, vars, run.StartedAt.UTC(), nullTime(run.FinishedAt), run.DurationMs,
+			run.StepCount, run.FailedSteps, run.Retries, run.CostUSD, run.UpdatedAt.UTC()); err != nil {
+			return fmt.Errorf("insert pipeline run: %w", err)
+		}
+		for _, step := range run.Steps {
+			if _, err := tx.Exec(`
+				INSERT INTO pipeline_run_steps (run_id, step_id, status, agent_type, pane, started_at, finished_at,
+					duration_ms, attempts, cost_usd, output, error)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				run.RunID, step.StepID, step.Status, nullString(step.AgentType), nullString(step.Pane),
+				nullTime(step.StartedAt), nullTime(step.FinishedAt), step.DurationMs, step.Attempts,
+				step.CostUSD, nullString(step.Output), nullString(step.Error)); err != nil {
+				return fmt.Errorf("insert step %s: %w", step.StepID, err)
+			}
+		}
+		return nil
+	}(); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+const pipelineRunColumns = `run_id, project_dir, workflow_id, COALESCE(workflow_file, ''), COALESCE(session_name, ''),
+	status, vars, started_at, finished_at, duration_ms, step_count, failed_steps, retries, cost_usd, updated_at`
+
+func scanPipelineRun(row interface{ Scan(...any) error }) (*PipelineRun, error) {
+	var (
+		run      PipelineRun
+		vars     sql.NullString
+		finished sql.NullTime
+	)
+	if err := row.Scan(&run.RunID, &run.ProjectDir, &run.WorkflowID, &run.WorkflowFile, &run.SessionName,
+		&run.Status, &vars, &run.StartedAt, &finished, &run.DurationMs, &run.StepCount, &run.FailedSteps,
+		&run.Retries, &run.CostUSD, &run.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if vars.Valid && vars.String != "" {
+		if err := json.Unmarshal([]byte(vars.String), &run.Vars); err != nil {
+			return nil, fmt.Errorf("parse vars of %s: %w", run.RunID, err)
+		}
+	}
+	run.FinishedAt = nullTimePtr(finished)
+	return &run, nil
+}
+
+// Get returns a run with its steps, or nil if it is not indexed.
+func (ps *PipelineRunStore) Get(runID string) (*PipelineRun, error) {
+	ps.store.mu.RLock()
+	defer ps.store.mu.RUnlock()
+
+	run, err := scanPipelineRun(ps.store.db.QueryRow(`SELECT `+pipelineRunColumns+` FROM pipeline_runs WHERE run_id = ?`, runID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get pipeline run: %w", err)
+	}
+
+	rows, err := ps.store.db.Query(`
+		SELECT step_id, status, COALESCE(agent_type, ''), COALESCE(pane, ''), started_at, finished_at,
+			duration_ms, attempts, cost_usd, COALESCE(output, ''), COALESCE(error, '')
+		FROM pipeline_run_steps WHERE run_id = ?
+		ORDER BY started_at IS NULL, started_at, step_id`, runID)
+	if err != nil {
+		return nil, fmt.Errorf("get pipeline run steps: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			step              PipelineRunStep
+			started, finished sql.NullTime
+		)
+		if err := rows.Scan(&step.StepID, &step.Status, &step.AgentType, &step.Pane, &started, &finished,
+			&step.DurationMs, &step.Attempts, &step.CostUSD, &step.Output, &step.Error); err != nil {
+			return nil, fmt.Errorf("scan pipeline run step: %w", err)
+		}
+		step.StartedAt = nullTimePtr(started)
+		step.FinishedAt = nullTimePtr(finished)
+		run.Steps = append(run.Steps, step)
+	}
+	return run, rows.Err()
+}
+
+// List returns runs matching f without their steps, most recent first.
+func (ps *PipelineRunStore) List(f PipelineRunFilter) ([]PipelineRun, error) {
+	var conds []string
+	var args []any
+	if f.ProjectDir != "" {
+		conds = append(conds, "project_dir = ?")
+		args = append(args, f.ProjectDir)
+	}
+	if f.WorkflowID != "" {
+		conds = append(conds, "workflow_id = ?")
+		args = append(args, f.WorkflowID)
+	}
+	if f.Status != "" {
+		conds = append(conds, "status = ?")
+		args = append(args, f.Status)
+	}
+	if f.SessionName != "" {
+		conds = append(conds, "session_name = ?")
+		args = append(args, f.SessionName)
+	}
+	if !f.Since.IsZero() {
+		conds = append(conds, "started_at >= ?")
+		args = append(args, f.Since.UTC())
+	}
+	query := `SELECT ` + pipelineRunColumns + ` FROM pipeline_runs`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY started_at DESC, run_id DESC"
+	if f.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", f.Limit)
+	}
+
+	ps.store.mu.RLock()
+	defer ps.store.mu.RUnlock()
+	rows, err := ps.store.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list pipeline runs: %w", err)
+	}
+	defer rows.Close()
+
+	var out []PipelineRun
+	for rows.Next() {
+		run, err := scanPipelineRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan pipeline run: %w", err)
+		}
+		out = append(out, *run)
+	}
+	return out, rows.Err()
+}
+
+// UpdatedTimes returns the indexed update time of every run in projectDir,
+// so callers can re-index only the runs whose state changed.
+func (ps *PipelineRunStore) UpdatedTimes(projectDir string) (map[string]time.Time, error) {
+	ps.store.mu.RLock()
+	defer ps.store.mu.RUnlock()
+	rows, err := ps.store.db.Query(`SELECT run_id, updated_at FROM pipeline_runs WHERE project_dir = ?`, projectDir)
+	if err != nil {
+		return nil, fmt.Errorf("list pipeline run times: %w", err)
+	}
+	defer rows.Close()
+
+	out := make(map[string]time.Time)
+	for rows.Next() {
+		var id string
+		var updated time.Time
+		if err := rows.Scan(&id, &updated); err != nil {
+			return nil, err
+		}
+		out[id] = updated
+	}
+	return out, rows.Err()
+}
+
+// Delete removes a run and its steps from the index.
+func (ps *PipelineRunStore) Delete(runID string) error {
+	ps.store.mu.Lock()
+	defer ps.store.mu.Unlock()
+	if _, err := ps.store.db.Exec(`DELETE FROM pipeline_runs WHERE run_id = ?`, runID); err != nil {
+		return fmt.Errorf("delete pipeline run: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPipelineRunStoreSaveListGet(t *testing.T) {
+	t.Parallel()
+	ps := NewPipelineRunStore(testStoreFile(t))
+
+	base := time.Date(2026, 4, 2, 9, 0, 0, 0, time.UTC)
+	at := func(sec int) *time.Time { v := base.Add(time.Duration(sec) * time.Second); return &v }
+
+	runs := []*PipelineRun{
+		{RunID: "run-a", ProjectDir: "/p", WorkflowID: "build", Status: "completed", StartedAt: base, FinishedAt: at(90),
+			DurationMs: 90000, StepCount: 2, Vars: map[string]interface{}{"env": "prod"}, CostUSD: 0.12,
+			Steps: []PipelineRunStep{
+				{StepID: "test", Status: "completed", StartedAt: at(30), FinishedAt: at(90), DurationMs: 60000, Output: "ok"},
+				{StepID: "lint", Status: "completed", StartedAt: at(0), FinishedAt: at(30), DurationMs: 30000, Attempts: 2},
+			}},
+		{RunID: "run-b", ProjectDir: "/p", WorkflowID: "build", Status: "failed", StartedAt: base.Add(time.Hour), FailedSteps: 1},
+		{RunID: "run-c", ProjectDir: "/other", WorkflowID: "deploy", Status: "completed", StartedAt: base.Add(2 * time.Hour)},
+	}
+	for _, run := range runs {
+		if err := ps.Save(run); err != nil {
+			t.Fatalf("Save(%s): %v", run.RunID, err)
+		}
+	}
+	// Re-saving replaces the run and its steps.
+	runs[0].Steps = runs[0].Steps[:2]
+	if err := ps.Save(runs[0]); err != nil {
+		t.Fatalf("re-Save: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		filter PipelineRunFilter
+		want   []string
+	}{
+		{"all newest first", PipelineRunFilter{}, []string{"run-c", "run-b", "run-a"}},
+		{"by project", PipelineRunFilter{ProjectDir: "/p"}, []string{"run-b", "run-a"}},
+		{"by status", PipelineRunFilter{Status: "failed"}, []string{"run-b"}},
+		{"by workflow and since", PipelineRunFilter{WorkflowID: "build", Since: base.Add(time.Minute)}, []string{"run-b"}},
+		{"limit", PipelineRunFilter{Limit: 1}, []string{"run-c"}},
+	}
+	for _, tt := range tests {
+		got, err := ps.List(tt.filter)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var ids []string
+		for _, r := range got {
+			ids = append(ids, r.RunID)
+			if len(r.Steps) != 0 {
+				t.Errorf("%s: List returned steps", tt.name)
+			}
+		}
+		if len(ids) != len(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, ids, tt.want)
+			continue
+		}
+		for i := range ids {
+			if ids[i] != tt.want[i] {
+				t.Errorf("%s: got %v, want %v", tt.name, ids, tt.want)
+				break
+			}
+		}
+	}
+
+	run, err := ps.Get("run-a")
+	if err != nil || run == nil {
+		t.Fatalf("Get = %v, %v", run, err)
+	}
+	if run.Vars["env"] != "prod" || run.FinishedAt == nil || !run.FinishedAt.Equal(*at(90)) || run.CostUSD != 0.12 {
+		t.Errorf("run = %+v", run)
+	}
+	if len(run.Steps) != 2 || run.Steps[0].StepID != "lint" || run.Steps[0].Attempts != 2 || run.Steps[1].Output != "ok" {
+		t.Errorf("steps = %+v", run.Steps)
+	}
+
+	times, err := ps.UpdatedTimes("/p")
+	if err != nil || len(times) != 2 {
+		t.Errorf("UpdatedTimes = %v, %v", times, err)
+	}
+	if err := ps.Delete("run-a"); err != nil {
+		t.Fatal(err)
+	}
+	if run, _ := ps.Get("run-a"); run != nil {
+		t.Error("run-a survived Delete")
+	}
+}
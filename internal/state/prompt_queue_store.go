@@ -0,0 +1,409 @@
+package state
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Prompt queue statuses.
+const (
+	QueueStatusPending    = "pending"
+	QueueStatusDelivering = "delivering"
+	QueueStatusDelivered  = "delivered"
+	QueueStatusFailed     = "failed"
+	QueueStatusExpired    = "expired"
+)
+
+// DefaultQueuePriority is used when a prompt is queued without a priority.
+const DefaultQueuePriority = 2
+
+// QueuedPrompt is a prompt waiting for an agent pane to go idle.
+type QueuedPrompt struct {
+	ID            int64      `json:"id"`
+	SessionName   string     `json:"session"`
+	PaneID        string     `json:"pane_id,omitempty"` // Empty when any pane of AgentType may take it
+	PaneIndex     *int       `json:"pane_index,omitempty"`
+	AgentType     string     `json:"agent_type,omitempty"`
+	Prompt        string     `json:"prompt"`
+	Priority      int        `json:"priority"`
+	Position      int        `json:"position"`
+	DedupKey      string     `json:"dedup_key,omitempty"`
+	Source        string     `json:"source,omitempty"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	DeliveredPane string     `json:"delivered_pane,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+}
+
+// PromptQueueFilter narrows List. Zero fields match everything; an empty
+// Status matches prompts still waiting (pending or being delivered).
+type PromptQueueFilter struct {
+	SessionName string
+	PaneID      string
+	AgentType   string
+	Status      string // A status, "all", or empty for waiting prompts
+	Limit       int
+}
+
+// QueueDepth counts waiting prompts in a session.
+type QueueDepth struct {
+	ByPane      map[string]int `json:"by_pane"`       // Pane ID -> prompts for that pane
+	ByAgentType map[string]int `json:"by_agent_type"` // Agent type -> prompts for any pane of it
+}
+
+// PromptQueueStore persists per-pane prompt queues in the state database.
+type PromptQueueStore struct {
+	store *Store
+}
+
+// NewPromptQueueStore creates a prompt queue store backed by store.
+func NewPromptQueueStore(store *Store) *PromptQueueStore {
+	if store == nil {
+		return nil
+	}
+	return &PromptQueueStore{store: store}
+}
+
+// Enqueue adds q to the end of its priority band. If a waiting prompt for the
+// same target (pane, or agent type when q has no pane) already has q's dedup
+// key, nothing is added and the existing prompt is returned with created=false.
+func (qs *PromptQueueStore) Enqueue(q *QueuedPrompt) (*QueuedPrompt, bool, error) {
+	if q.SessionName == "" {
+		return nil, false, errors.New("session is required")
+	}
+	if q.PaneID == "" && q.AgentType == "" {
+		return nil, false, errors.New("a pane or agent type is required")
+	}
+	if strings.TrimSpace(q.Prompt) == "" {
+		return nil, false, errors.New("prompt is empty")
+	}
+	if q.CreatedAt.IsZero() {
+		q.CreatedAt = time.Now().UTC()
+	}
+
+	qs.store.mu.Lock()
+	defer qs.store.mu.Unlock()
+
+	if q.DedupKey != "" {
+		existing, err := qs.getWhere(`session_name = ? AND `+queueTargetExpr+` = ? AND dedup_key = ? AND status IN (?, ?)`,
+			q.SessionName, queueTarget(q), q.DedupKey, QueueStatusPending, QueueStatusDelivering)
+		if err != nil {
+			return nil, false, err
+		}
+		if existing != nil {
+			return existing, false, nil
+		}
+	}
+
+	res, err := qs.store.db.Exec(`
+		INSERT INTO prompt_queue (session_name, pane_id, pane_index, agent_type, prompt, priority, position,
+			dedup_key, source, status, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?,
+			(SELECT COALESCE(MAX(position), 0) + 1 FROM prompt_queue WHERE session_name = ?),
+			?, ?, ?, ?, ?)`,
+		q.SessionName, nullString(q.PaneID), nullInt(q.PaneIndex), nullString(q.AgentType), q.Prompt, q.Priority,
+		q.SessionName, nullString(q.DedupKey), nullString(q.Source), QueueStatusPending,
+		q.CreatedAt.UTC(), nullTime(q.ExpiresAt))
+	if err != nil {
+		return nil, false, fmt.Errorf("enqueue prompt: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, false, err
+	}
+	created, err := qs.getWhere(`id = ?`, id)
+	if err != nil {
+		return nil, false, err
+	}
+	return created, true, nil
+}
+
+// queueTargetExpr identifies a prompt's target the same way as the dedup index.
+const queueTargetExpr = `COALESCE(pane_id, 'type:' || COALESCE(agent_type, ''))`
+
+func queueTarget(q *QueuedPrompt) string {
+	if q.PaneID != "" {
+		return q.PaneID
+	}
+	return "type:" + q.AgentType
+}
+
+const queueColumns = `id, session_name, COALESCE(pane_id, ''), pane_index, COALESCE(agent_type, ''), prompt,
+	priority, position, COALESCE(dedup_key, ''), COALESCE(source, ''), status, attempts,
+	COALESCE(last_error, ''), COALESCE(delivered_pane, ''), created_at, expires_at, delivered_at`
+
+// queueOrder is the delivery order: priority band, then queue position.
+const queueOrder = ` ORDER BY priority, position, id`
+
+func scanQueuedPrompt(row interface{ Scan(...any) error }) (*QueuedPrompt, error) {
+	var (
+		q                  QueuedPrompt
+		paneIndex          sql.NullInt64
+		expires, delivered sql.NullTime
+	)
+	if err := row.Scan(&q.ID, &q.SessionName, &q.PaneID, &paneIndex, &q.AgentType, &q.Prompt,
+		&q.Priority, &q.Position, &q.DedupKey, &q.Source, &q.Status, &q.Attempts,
+		&q.LastError, &q.DeliveredPane, &q.CreatedAt, &expires, &delivered); err != nil {
+		return nil, err
+	}
+	q.PaneIndex = intPtr(paneIndex)
+	q.ExpiresAt = nullTimePtr(expires)
+	q.DeliveredAt = nullTimePtr(delivered)
+	return &q, nil
+}
+
+// getWhere returns the first prompt matching cond. The caller holds the lock.
+func (qs *PromptQueueStore) getWhere(cond string, args ...any) (*QueuedPrompt, error) {
+	q, err := scanQueuedPrompt(qs.store.db.QueryRow(`SELECT `+queueColumns+` FROM prompt_queue WHERE `+cond+queueOrder+` LIMIT 1`, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get queued prompt: %w", err)
+	}
+	return q, nil
+}
+
+// Get returns a queued prompt by ID, or nil if it does not exist.
+func (qs *PromptQueueStore) Get(id int64) (*QueuedPrompt, error) {
+	qs.store.mu.RLock()
+	defer qs.store.mu.RUnlock()
+	return qs.getWhere(`id = ?`, id)
+}
+
+// List returns prompts matching f in delivery order.
+func (qs *PromptQueueStore) List(f PromptQueueFilter) ([]QueuedPrompt, error) {
+	var conds []string
+	var args []any
+	if f.SessionName != "" {
+		conds = append(conds, "session_name = ?")
+		args = append(args, f.SessionName)
+	}
+	if f.PaneID != "" {
+		conds = append(conds, "pane_id = ?")
+		args = append(args, f.PaneID)
+	}
+	if f.AgentType != "" {
+		conds = append(conds, "agent_type = ?")
+		args = append(args, f.AgentType)
+	}
+	switch f.Status {
+	case "all":
+	case "":
+		conds = append(conds, "status IN (?, ?)")
+		args = append(args, QueueStatusPending, QueueStatusDelivering)
+	default:
+		conds = append(conds, "status = ?")
+		args = append(args, f.Status)
+	}
+	query := `SELECT ` + queueColumns + ` FROM prompt_queue`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += queueOrder
+	if f.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", f.Limit)
+	}
+
+	qs.store.mu.RLock()
+	defer qs.store.mu.RUnlock()
+	rows, err := qs.store.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list queued prompts: %w", err)
+	}
+	defer rows.Close()
+
+	var out []QueuedPrompt
+	for rows.Next() {
+		q, err := scanQueuedPrompt(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan queued prompt: %w", err)
+		}
+		out = append(out, *q)
+	}
+	return out, rows.Err()
+}
+
+// Next returns the first pending prompt a pane can take: one addressed to the
+// pane itself or to any pane of its agent type. Expired prompts are skipped.
+func (qs *PromptQueueStore) Next(session, paneID, agentType string, now time.Time) (*QueuedPrompt, error) {
+	qs.store.mu.RLock()
+	defer qs.store.mu.RUnlock()
+	return qs.getWhere(`session_name = ? AND status = ?
+		AND (pane_id = ? OR (pane_id IS NULL AND agent_type = ?))
+		AND (expires_at IS NULL OR expires_at > ?)`,
+		session, QueueStatusPending, paneID, agentType, now.UTC())
+}
+
+// Claim marks a pending prompt as being delivered. It reports false if the
+// prompt was already claimed, so concurrent dispatchers deliver it once.
+func (qs *PromptQueueStore) Claim(id int64) (bool, error) {
+	qs.store.mu.Lock()
+	defer qs.store.mu.Unlock()
+	res, err := qs.store.db.Exec(`UPDATE prompt_queue SET status = ?, attempts = attempts + 1, claimed_at = ? WHERE id = ? AND status = ?`,
+		QueueStatusDelivering, time.Now().UTC(), id, QueueStatusPending)
+	if err != nil {
+		return false, fmt.Errorf("claim queued prompt: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// Complete records the outcome of delivering a claimed prompt. A failed
+// delivery goes back to pending until it has been attempted maxAttempts
+// times, then is marked failed.
+func (qs *PromptQueueStore) Complete(id int64, paneID string, deliverErr error, maxAttempts int) error {
+	qs.store.mu.Lock()
+	defer qs.store.mu.Unlock()
+	var err error
+	if deliverErr == nil {
+		_, err = qs.store.db.Exec(`UPDATE prompt_queue SET status = ?, delivered_pane = ?, delivered_at = ?, last_error = NULL WHERE id = ?`,
+			QueueStatusDelivered, paneID, time.Now().UTC(), id)
+	} else {
+		_, err = qs.store.db.Exec(`UPDATE prompt_queue SET last_error = ?,
+			status = CASE WHEN attempts >= ? THEN ? ELSE ? END WHERE id = ?`,
+			deliverErr.Error(), maxAttempts, QueueStatusFailed, QueueStatusPending, id)
+	}
+	if err != nil {
+		return fmt.Errorf("complete queued prompt: %w", err)
+	}
+	return nil
+}
+
+// ExpireStale marks pending prompts past their expiry as expired and returns
+// how many were expired. Prompts claimed more than claimTimeout before now
+// were left behind by a dispatcher that stopped mid-delivery; they go back
+// to pending first, keeping the attempt their claim used.
+func (qs *PromptQueueStore) ExpireStale(now time.Time, claimTimeout time.Duration) (int, error) {
+	qs.store.mu.Lock()
+	defer qs.store.mu.Unlock()
+	if _, err := qs.store.db.Exec(`UPDATE prompt_queue SET status = ?, last_error = ?
+		WHERE status = ? AND (claimed_at IS NULL OR claimed_at <= ?)`,
+		QueueStatusPending, "delivery interrupted", QueueStatusDelivering, now.Add(-claimTimeout).UTC()); err != nil {
+		return 0, fmt.Errorf("release stale claims: %w", err)
+	}
+	res, err := qs.store.db.Exec(`UPDATE prompt_queue SET status = ? WHERE status = ? AND expires_at IS NOT NULL AND expires_at <= ?`,
+		QueueStatusExpired, QueueStatusPending, now.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("expire queued prompts: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// Remove deletes a prompt from the queue. It reports false if no prompt has
+// that ID.
+func (qs *PromptQueueStore) Remove(id int64) (bool, error) {
+	qs.store.mu.Lock()
+	defer qs.store.mu.Unlock()
+	res, err := qs.store.db.Exec(`DELETE FROM prompt_queue WHERE id = ?`, id)
+	if err != nil {
+		return false, fmt.Errorf("remove queued prompt: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// Move places a pending prompt at index (0-based) of its session's pending
+// queue. The prompt takes the priority of the prompt it lands next to so the
+// new order holds across priority bands.
+func (qs *PromptQueueStore) Move(id int64, index int) error {
+	qs.store.mu.Lock()
+	defer qs.store.mu.Unlock()
+
+	moved, err := qs.getWhere(`id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if moved == nil {
+		return fmt.Errorf("queued prompt %d not found", id)
+	}
+	if moved.Status != QueueStatusPending {
+		return fmt.Errorf("queued prompt %d is %s, not pending", id, moved.Status)
+	}
+
+	rows, err := qs.store.db.Query(`SELECT id, priority FROM prompt_queue WHERE session_name = ? AND status = ? AND id != ?`+queueOrder,
+		moved.SessionName, QueueStatusPending, id)
+	if err != nil {
+		return fmt.Errorf("list queue for reorder: %w", err)
+	}
+	type entry struct {
+		id       int64
+		priority int
+	}
+	var order []entry
+	for rows.Next() {
+		var e entry
+		if err := rows.Scan(&e.id, &e.priority); err != nil {
+			rows.Close()
+			return err
+		}
+		order = append(order, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if index < 0 {
+		index = 0
+	}
+	if index > len(order) {
+		index = len(order)
+	}
+	priority := moved.Priority
+	switch {
+	case index < len(order):
+		priority = order[index].priority
+	case len(order) > 0:
+		priority = order[len(order)-1].priority
+	}
+	order = append(order[:index], append([]entry{{id: id, priority: priority}}, order[index:]...)...)
+
+	tx, err := qs.store.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	for pos, e := range order {
+		if _, err := tx.Exec(`UPDATE prompt_queue SET position = ?, priority = ? WHERE id = ?`, pos+1, e.priority, e.id); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("reorder queue: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Depth counts the prompts waiting in a session's queue.
+func (qs *PromptQueueStore) Depth(session string) (QueueDepth, error) {
+	depth := QueueDepth{ByPane: map[string]int{}, ByAgentType: map[string]int{}}
+
+	qs.store.mu.RLock()
+	defer qs.store.mu.RUnlock()
+	rows, err := qs.store.db.Query(`
+		SELECT COALESCE(pane_id, ''), COALESCE(agent_type, ''), COUNT(*) FROM prompt_queue
+		WHERE session_name = ? AND status IN (?, ?)
+		GROUP BY pane_id, agent_type`, session, QueueStatusPending, QueueStatusDelivering)
+	if err != nil {
+		return depth, fmt.Errorf("count queued prompts: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var paneID, agentType string
+		var n int
+		if err := rows.Scan(&paneID, &agentType, &n); err != nil {
+			return depth, err
+		}
+		if paneID != "" {
+			depth.ByPane[paneID] += n
+		} else {
+			depth.ByAgentType[agentType] += n
+		}
+	}
+	return depth, rows.Err()
+}
@@ -0,0 +1,153 @@
+package state
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPromptQueueStore(t *testing.T) {
+	t.Parallel()
+	qs := NewPromptQueueStore(testStoreFile(t))
+	now := time.Now().UTC()
+	past := now.Add(-time.Minute)
+	idx := 1
+
+	enqueue := func(q QueuedPrompt) *QueuedPrompt {
+		t.Helper()
+		q.SessionName = "proj"
+		if q.Priority == 0 {
+			q.Priority = DefaultQueuePriority
+		}
+		got, created, err := qs.Enqueue(&q)
+		if err != nil || !created {
+			t.Fatalf("Enqueue(%q) = %v, %v", q.Prompt, created, err)
+		}
+		return got
+	}
+
+	first := enqueue(QueuedPrompt{PaneID: "%1", PaneIndex: &idx, AgentType: "cc", Prompt: "first", DedupKey: "lint"})
+	second := enqueue(QueuedPrompt{PaneID: "%1", AgentType: "cc", Prompt: "second"})
+	urgent := enqueue(QueuedPrompt{PaneID: "%1", AgentType: "cc", Prompt: "urgent", Priority: 1})
+	anyCC := enqueue(QueuedPrompt{AgentType: "cc", Prompt: "any claude"})
+	enqueue(QueuedPrompt{PaneID: "%2", AgentType: "cod", Prompt: "stale", ExpiresAt: &past})
+
+	dup, created, err := qs.Enqueue(&QueuedPrompt{SessionName: "proj", PaneID: "%1", Prompt: "again", DedupKey: "lint"})
+	if err != nil || created || dup.ID != first.ID {
+		t.Fatalf("dedup enqueue = %+v, %v, %v; want existing prompt", dup, created, err)
+	}
+	if other, created, _ := qs.Enqueue(&QueuedPrompt{SessionName: "proj", PaneID: "%9", Prompt: "lint", DedupKey: "lint"}); !created {
+		t.Error("dedup key blocked a prompt for a different pane")
+	} else {
+		_, _ = qs.Remove(other.ID)
+	}
+	if first.PaneIndex == nil || *first.PaneIndex != 1 {
+		t.Errorf("pane index = %v", first.PaneIndex)
+	}
+
+	depth, err := qs.Depth("proj")
+	if err != nil || depth.ByPane["%1"] != 3 || depth.ByAgentType["cc"] != 1 || depth.ByPane["%2"] != 1 {
+		t.Fatalf("Depth = %+v, %v", depth, err)
+	}
+
+	next, err := qs.Next("proj", "%1", "cc", now)
+	if err != nil || next == nil || next.ID != urgent.ID {
+		t.Fatalf("Next = %+v, %v; want the priority 1 prompt", next, err)
+	}
+	if next, _ := qs.Next("proj", "%3", "cc", now); next == nil || next.ID != anyCC.ID {
+		t.Errorf("Next for another claude pane = %+v, want the agent-type prompt", next)
+	}
+	if next, _ := qs.Next("proj", "%2", "cod", now); next != nil {
+		t.Errorf("Next returned expired prompt %+v", next)
+	}
+
+	if ok, err := qs.Claim(urgent.ID); !ok || err != nil {
+		t.Fatalf("Claim = %v, %v", ok, err)
+	}
+	if ok, _ := qs.Claim(urgent.ID); ok {
+		t.Error("second Claim succeeded")
+	}
+	if err := qs.Complete(urgent.ID, "%1", nil, 3); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := qs.Get(urgent.ID); got.Status != QueueStatusDelivered || got.DeliveredAt == nil || got.DeliveredPane != "%1" {
+		t.Errorf("delivered prompt = %+v", got)
+	}
+
+	// A failed delivery returns to pending until attempts run out.
+	if ok, _ := qs.Claim(second.ID); !ok {
+		t.Fatal("claim second")
+	}
+	_ = qs.Complete(second.ID, "%1", errors.New("pane gone"), 2)
+	if got, _ := qs.Get(second.ID); got.Status != QueueStatusPending || got.LastError != "pane gone" {
+		t.Errorf("after first failure = %+v", got)
+	}
+	_, _ = qs.Claim(second.ID)
+	_ = qs.Complete(second.ID, "%1", errors.New("pane gone"), 2)
+	if got, _ := qs.Get(second.ID); got.Status != QueueStatusFailed || got.Attempts != 2 {
+		t.Errorf("after second failure = %+v", got)
+	}
+
+	if n, err := qs.ExpireStale(now, time.Hour); n != 1 || err != nil {
+		t.Errorf("ExpireStale = %d, %v", n, err)
+	}
+
+	// Move the agent-type prompt ahead of the dedup'd one.
+	if err := qs.Move(anyCC.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	pending, err := qs.List(PromptQueueFilter{SessionName: "proj"})
+	if err != nil || len(pending) != 2 || pending[0].ID != anyCC.ID || pending[1].ID != first.ID {
+		t.Fatalf("pending after Move = %+v, %v", pending, err)
+	}
+	if err := qs.Move(urgent.ID, 0); err == nil {
+		t.Error("Move of a delivered prompt succeeded")
+	}
+
+	all, _ := qs.List(PromptQueueFilter{SessionName: "proj", Status: "all"})
+	if len(all) != 5 {
+		t.Errorf("List(all) returned %d prompts, want 5", len(all))
+	}
+	if ok, err := qs.Remove(first.ID); !ok || err != nil {
+		t.Errorf("Remove = %v, %v", ok, err)
+	}
+	if ok, _ := qs.Remove(first.ID); ok {
+		t.Error("second Remove succeeded")
+	}
+	// With the dedup'd prompt gone its key is free again.
+	if _, created, _ := qs.Enqueue(&QueuedPrompt{SessionName: "proj", PaneID: "%1", Prompt: "again", DedupKey: "lint"}); !created {
+		t.Error("dedup key still blocked after removal")
+	}
+}
+
+func TestPromptQueueStoreReleasesStaleClaims(t *testing.T) {
+	t.Parallel()
+	qs := NewPromptQueueStore(testStoreFile(t))
+	q, _, err := qs.Enqueue(&QueuedPrompt{SessionName: "proj", PaneID: "%1", Prompt: "hi", Priority: DefaultQueuePriority})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := qs.Claim(q.ID); !ok || err != nil {
+		t.Fatalf("Claim = %v, %v", ok, err)
+	}
+
+	// A fresh claim is left alone.
+	if _, err := qs.ExpireStale(time.Now(), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := qs.Get(q.ID); got.Status != QueueStatusDelivering {
+		t.Fatalf("fresh claim = %+v, want delivering", got)
+	}
+
+	// One older than the timeout goes back to pending, attempt kept.
+	if _, err := qs.ExpireStale(time.Now().Add(2*time.Minute), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := qs.Get(q.ID)
+	if got.Status != QueueStatusPending || got.Attempts != 1 || got.LastError == "" {
+		t.Fatalf("stale claim = %+v, want pending after 1 attempt", got)
+	}
+	if next, _ := qs.Next("proj", "%1", "", time.Now()); next == nil || next.ID != q.ID {
+		t.Errorf("Next = %+v, want the released prompt", next)
+	}
+}
@@ -0,0 +1,196 @@
+package state
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/quota"
+)
+
+// QuotaSample is one snapshot of a provider account's usage.
+type QuotaSample struct {
+	ID           int64      `json:"id"`
+	Provider     string     `json:"provider"`
+	Account      string     `json:"account"`
+	SessionName  string     `json:"session,omitempty"`
+	UsagePercent float64    `json:"usage_percent"`
+	ResetAt      *time.Time `json:"reset_at,omitempty"`
+	IsLimited    bool       `json:"is_limited"`
+	RecordedAt   time.Time  `json:"recorded_at"`
+}
+
+// QuotaStore persists quota snapshots in the state database.
+type QuotaStore struct {
+	store *Store
+}
+
+// NewQuotaStore creates a quota store backed by store.
+func NewQuotaStore(store *Store) *QuotaStore {
+	if store == nil {
+		return nil
+	}
+	return &QuotaStore{store: store}
+}
+
+// Record appends a quota sample, filling in RecordedAt when unset.
+func (qs *QuotaStore) Record(s *QuotaSample) error {
+	if s.Provider == "" || s.Account == "" {
+		return errors.New("provider and account are required")
+	}
+	if s.RecordedAt.IsZero() {
+		s.RecordedAt = time.Now()
+	}
+
+	qs.store.mu.Lock()
+	defer qs.store.mu.Unlock()
+	res, err := qs.store.db.Exec(`
+		INSERT INTO quota_samples (provider, account, session_name, usage_percent, reset_at, is_limited, recorded_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		s.Provider, s.Account, nullString(s.SessionName), s.UsagePercent, nullTime(s.ResetAt), s.IsLimited,
+		s.RecordedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("record quota sample: %w", err)
+	}
+	s.ID, err = res.LastInsertId()
+	return err
+}
+
+// Samples returns provider's samples for all accounts since the given time,
+// oldest first.
+func (qs *QuotaStore) Samples(provider string, since time.Time) ([]QuotaSample, error) {
+	qs.store.mu.RLock()
+	defer qs.store.mu.RUnlock()
+	rows, err := qs.store.db.Query(`SELECT id, provider, account, COALESCE(session_name, ''), usage_percent,
+		reset_at, is_limited, recorded_at FROM quota_samples
+		WHERE provider = ? AND recorded_at >= ? ORDER BY recorded_at, id`, provider, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("list quota samples: %w", err)
+	}
+	defer rows.Close()
+
+	var out []QuotaSample
+	for rows.Next() {
+		var (
+			s     QuotaSample
+			reset sql.NullTime
+		)
+		if err := rows.Scan(&s.ID, &s.Provider, &s.Account, &s.SessionName, &s.UsagePercent,
+			&reset, &s.IsLimited, &s.RecordedAt); err != nil {
+			return nil, fmt.Errorf("scan quota sample: %w", err)
+		}
+		s.ResetAt = nullTimePtr(reset)
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// Histories gathers the usage history of each of provider's accounts since
+// the given time for forecasting: its quota samples, the prompts sent to
+// agentType agents and the rate limit hits in limits. Sends and limits are
+// attributed to the account that was sampled last before them; current is
+// the account in use now, which owns activity after the last sample and,
+// when there are no samples, all of it. Histories are ordered by account.
+func (qs *QuotaStore) Histories(provider quota.Provider, agentType, current string, since time.Time, limits []time.Time) ([]quota.History, error) {
+	samples, err := qs.Samples(string(provider), since)
+	if err != nil {
+		return nil, err
+	}
+	sends, err := NewSpendStore(qs.store).PromptTimes(agentType, since)
+	if err != nil {
+		return nil, err
+	}
+
+	byAccount := make(map[string]*quota.History)
+	get := func(account string) *quota.History {
+		h, ok := byAccount[account]
+		if !ok {
+			h = &quota.History{Provider: provider, Account: account}
+			byAccount[account] = h
+		}
+		return h
+	}
+	if current != "" {
+		get(current)
+	}
+	for _, s := range samples {
+		us := quota.UsageSample{At: s.RecordedAt, Usage: s.UsagePercent, Limited: s.IsLimited}
+		if s.ResetAt != nil {
+			us.ResetAt = *s.ResetAt
+		}
+		h := get(s.Account)
+		h.Samples = append(h.Samples, us)
+	}
+
+	owner := func(t time.Time) string {
+		i := sort.Search(len(samples), func(i int) bool { return samples[i].RecordedAt.After(t) })
+		switch {
+		case len(samples) == 0:
+			return current
+		case i == 0:
+			return samples[0].Account
+		case i == len(samples) && current != "":
+			return current
+		}
+		return samples[i-1].Account
+	}
+	for _, t := range sends {
+		if a := owner(t); a != "" {
+			get(a).Sends = append(get(a).Sends, t)
+		}
+	}
+	for _, t := range limits {
+		if t.Before(since) {
+			continue
+		}
+		if a := owner(t); a != "" {
+			get(a).Limits = append(get(a).Limits, t)
+		}
+	}
+
+	out := make([]quota.History, 0, len(byAccount))
+	for _, h := range byAccount {
+		out = append(out, *h)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Account < out[j].Account })
+	return out, nil
+}
+
+// Forecasts predicts the time to limit of each of provider's accounts from
+// its history over cfg.Window; see Histories for the arguments.
+func (qs *QuotaStore) Forecasts(provider quota.Provider, current string, limits []time.Time, cfg quota.ForecastConfig, now time.Time) ([]quota.Forecast, error) {
+	if cfg.Window <= 0 {
+		cfg.Window = quota.DefaultForecastConfig().Window
+	}
+	hs, err := qs.Histories(provider, provider.AgentType(), current, now.Add(-cfg.Window), limits)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]quota.Forecast, 0, len(hs))
+	for _, h := range hs {
+		out = append(out, quota.PredictLimit(h, cfg, now))
+	}
+	return out, nil
+}
+
+// ActiveForecasts forecasts every account of every provider that has any
+// recorded activity: quota samples, sends or rate limits. limits returns a
+// provider's recent rate limit hits. Activity after the last sample is
+// attributed to the account sampled last.
+func (qs *QuotaStore) ActiveForecasts(limits func(quota.Provider) []time.Time, cfg quota.ForecastConfig, now time.Time) ([]quota.Forecast, error) {
+	out := []quota.Forecast{}
+	for _, provider := range quota.Providers {
+		fs, err := qs.Forecasts(provider, "", limits(provider), cfg, now)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range fs {
+			if f.Samples > 0 || f.SendsPerHour > 0 || f.RecentLimits > 0 {
+				out = append(out, f)
+			}
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,68 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/quota"
+)
+
+func TestQuotaStore_Histories(t *testing.T) {
+	t.Parallel()
+	store := testStoreFile(t)
+	qs := NewQuotaStore(store)
+	ss := NewSpendStore(store)
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	reset := now.Add(2 * time.Hour)
+
+	for _, s := range []QuotaSample{
+		{Provider: "claude", Account: "main", UsagePercent: 40, RecordedAt: now.Add(-2 * time.Hour)},
+		{Provider: "claude", Account: "main", UsagePercent: 60, ResetAt: &reset, RecordedAt: now.Add(-time.Hour)},
+		{Provider: "claude", Account: "backup", UsagePercent: 5, RecordedAt: now.Add(-30 * time.Minute)},
+		{Provider: "codex", Account: "main", UsagePercent: 90, RecordedAt: now.Add(-time.Hour)},
+	} {
+		if err := qs.Record(&s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := qs.Record(&QuotaSample{Provider: "claude"}); err == nil {
+		t.Error("sample without an account accepted")
+	}
+	for _, at := range []time.Duration{-150, -90, -45, -20, -10} {
+		ev := &SpendEvent{SessionName: "proj", AgentType: "cc", Source: "prompt", RecordedAt: now.Add(at * time.Minute)}
+		if err := ss.Record(ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = ss.Record(&SpendEvent{SessionName: "proj", AgentType: "cc", Source: "response", RecordedAt: now})
+
+	hs, err := qs.Histories(quota.ProviderClaude, "cc", "backup", now.Add(-5*time.Hour), []time.Time{now.Add(-40 * time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hs) != 2 || hs[0].Account != "backup" || hs[1].Account != "main" {
+		t.Fatalf("histories = %+v", hs)
+	}
+	backup, main := hs[0], hs[1]
+	if len(main.Samples) != 2 || !main.Samples[1].ResetAt.Equal(reset) || len(backup.Samples) != 1 {
+		t.Errorf("samples: main %+v, backup %+v", main.Samples, backup.Samples)
+	}
+	// The send before the first sample goes to the first sampled account.
+	if len(main.Sends) != 3 || len(backup.Sends) != 2 {
+		t.Errorf("sends: main %d, backup %d", len(main.Sends), len(backup.Sends))
+	}
+	if len(main.Limits) != 1 || len(backup.Limits) != 0 {
+		t.Errorf("limits: main %v, backup %v", main.Limits, backup.Limits)
+	}
+
+	// Without samples everything belongs to the current account.
+	hs, err = qs.Histories(quota.ProviderGemini, "gmi", "solo", now.Add(-time.Hour), nil)
+	if err != nil || len(hs) != 1 || hs[0].Account != "solo" {
+		t.Errorf("gemini histories = %+v, %v", hs, err)
+	}
+
+	fs, err := qs.Forecasts(quota.ProviderClaude, "backup", nil, quota.DefaultForecastConfig(), now)
+	if err != nil || len(fs) != 2 || fs[1].Account != "main" || fs[1].Usage != 60 || fs[1].Basis != quota.BasisQuota {
+		t.Errorf("forecasts = %+v, %v", fs, err)
+	}
+}
@@ -0,0 +1,324 @@
+package state
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Schedule run statuses.
+const (
+	ScheduleRunOK      = "ok"
+	ScheduleRunFailed  = "failed"
+	ScheduleRunSkipped = "skipped"
+)
+
+// Missed-run policies: what to do when a firing was due while nothing was
+// running to fire it.
+const (
+	MissedSkip    = "skip"     // Drop missed firings and wait for the next one
+	MissedCatchUp = "catch-up" // Fire once for all missed firings
+)
+
+// Schedule is a recurring job fired by the session monitor.
+type Schedule struct {
+	ID            int64      `json:"id"`
+	Name          string     `json:"name"`
+	SessionName   string     `json:"session"`
+	ProjectDir    string     `json:"project_dir,omitempty"`
+	Kind          string     `json:"kind"`
+	Args          []string   `json:"args,omitempty"`
+	Spec          string     `json:"spec"`
+	JitterSeconds int        `json:"jitter_seconds,omitempty"`
+	MissedPolicy  string     `json:"missed_policy"`
+	Paused        bool       `json:"paused"`
+	NextRunAt     *time.Time `json:"next_run_at,omitempty"`
+	LastRunAt     *time.Time `json:"last_run_at,omitempty"`
+	LastStatus    string     `json:"last_status,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+	RunCount      int        `json:"run_count"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// ScheduleRun records one firing of a schedule.
+type ScheduleRun struct {
+	ID           int64      `json:"id"`
+	ScheduleID   int64      `json:"schedule_id"`
+	ScheduledFor time.Time  `json:"scheduled_for"`
+	StartedAt    time.Time  `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+	Status       string     `json:"status"`
+	Trigger      string     `json:"trigger"` // schedule, catch-up, manual
+	Missed       int        `json:"missed,omitempty"`
+	Error        string     `json:"error,omitempty"`
+	Output       string     `json:"output,omitempty"`
+}
+
+// ScheduleStore persists schedules and their run history in the state database.
+type ScheduleStore struct {
+	store *Store
+}
+
+// NewScheduleStore creates a schedule store backed by store.
+func NewScheduleStore(store *Store) *ScheduleStore {
+	if store == nil {
+		return nil
+	}
+	return &ScheduleStore{store: store}
+}
+
+// Create inserts a schedule and sets its ID. Names are unique.
+func (ss *ScheduleStore) Create(s *Schedule) error {
+	if s.Name == "" || s.SessionName == "" || s.Kind == "" || s.Spec == "" {
+		return errors.New("name, session, kind and spec are required")
+	}
+	if s.MissedPolicy == "" {
+		s.MissedPolicy = MissedSkip
+	}
+	now := time.Now().UTC()
+	if s.CreatedAt.IsZero() {
+		s.CreatedAt = now
+	}
+	s.UpdatedAt = now
+	var args sql.NullString
+	if len(s.Args) > 0 {
+		data, err := json.Marshal(s.Args)
+		if err != nil {
+			return fmt.Errorf("marshal schedule args: %w", err)
+		}
+		args = sql.NullString{String: string(data), Valid: true}
+	}
+
+	ss.store.mu.Lock()
+	defer ss.store.mu.Unlock()
+	res, err := ss.store.db.Exec(`
+		INSERT INTO schedules (name, session_name, project_dir, kind, args, spec, jitter_seconds,
+			missed_policy, paused, next_run_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		s.Name, s.SessionName, nullString(s.ProjectDir), s.Kind, args, s.Spec, s.JitterSeconds,
+		s.MissedPolicy, s.Paused, nullTime(s.NextRunAt), s.CreatedAt, s.UpdatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			return fmt.Errorf("a schedule named %q already exists", s.Name)
+		}
+		return fmt.Errorf("create schedule: %w", err)
+	}
+	s.ID, err = res.LastInsertId()
+	return err
+}
+
+const scheduleColumns = `id, name, session_name, COALESCE(project_dir, ''), kind, args, spec, jitter_seconds,
+	missed_policy, paused, next_run_at, last_run_at, COALESCE(last_status, ''), COALESCE(last_error, ''),
+	run_count, created_at, updated_at`
+
+func scanSchedule(row interface{ Scan(...any) error }) (*Schedule, error) {
+	var (
+		s             Schedule
+		args          sql.NullString
+		next, lastRun sql.NullTime
+	)
+	if err := row.Scan(&s.ID, &s.Name, &s.SessionName, &s.ProjectDir, &s.Kind, &args, &s.Spec, &s.JitterSeconds,
+		&s.MissedPolicy, &s.Paused, &next, &lastRun, &s.LastStatus, &s.LastError,
+		&s.RunCount, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if args.Valid && args.String != "" {
+		if err := json.Unmarshal([]byte(args.String), &s.Args); err != nil {
+			return nil, fmt.Errorf("decode args of schedule %q: %w", s.Name, err)
+		}
+	}
+	s.NextRunAt = nullTimePtr(next)
+	s.LastRunAt = nullTimePtr(lastRun)
+	return &s, nil
+}
+
+func (ss *ScheduleStore) getWhere(cond string, args ...any) (*Schedule, error) {
+	s, err := scanSchedule(ss.store.db.QueryRow(`SELECT `+scheduleColumns+` FROM schedules WHERE `+cond, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get schedule: %w", err)
+	}
+	return s, nil
+}
+
+// Get returns a schedule by ID, or nil if it does not exist.
+func (ss *ScheduleStore) Get(id int64) (*Schedule, error) {
+	ss.store.mu.RLock()
+	defer ss.store.mu.RUnlock()
+	return ss.getWhere(`id = ?`, id)
+}
+
+// GetByName returns a schedule by name, or nil if it does not exist.
+func (ss *ScheduleStore) GetByName(name string) (*Schedule, error) {
+	ss.store.mu.RLock()
+	defer ss.store.mu.RUnlock()
+	return ss.getWhere(`name = ?`, name)
+}
+
+func (ss *ScheduleStore) list(cond string, args ...any) ([]Schedule, error) {
+	query := `SELECT ` + scheduleColumns + ` FROM schedules`
+	if cond != "" {
+		query += " WHERE " + cond
+	}
+	query += ` ORDER BY next_run_at IS NULL, next_run_at, name`
+
+	ss.store.mu.RLock()
+	defer ss.store.mu.RUnlock()
+	rows, err := ss.store.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list schedules: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Schedule
+	for rows.Next() {
+		s, err := scanSchedule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan schedule: %w", err)
+		}
+		out = append(out, *s)
+	}
+	return out, rows.Err()
+}
+
+// List returns the schedules of a session, or of every session when session
+// is empty, soonest first.
+func (ss *ScheduleStore) List(session string) ([]Schedule, error) {
+	if session == "" {
+		return ss.list("")
+	}
+	return ss.list(`session_name = ?`, session)
+}
+
+// Due returns the session's unpaused schedules whose next run is at or
+// before now.
+func (ss *ScheduleStore) Due(session string, now time.Time) ([]Schedule, error) {
+	return ss.list(`session_name = ? AND paused = 0 AND next_run_at IS NOT NULL AND next_run_at <= ?`, session, now.UTC())
+}
+
+// SetPaused pauses or resumes a schedule, setting its next run. It reports
+// false if no schedule has that ID.
+func (ss *ScheduleStore) SetPaused(id int64, paused bool, next *time.Time) (bool, error) {
+	ss.store.mu.Lock()
+	defer ss.store.mu.Unlock()
+	res, err := ss.store.db.Exec(`UPDATE schedules SET paused = ?, next_run_at = ?, updated_at = ? WHERE id = ?`,
+		paused, nullTime(next), time.Now().UTC(), id)
+	if err != nil {
+		return false, fmt.Errorf("update schedule: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// Advance moves a schedule's next run from prev to next. It reports false if
+// the next run is no longer prev, meaning another runner already took this
+// firing.
+func (ss *ScheduleStore) Advance(id int64, prev time.Time, next *time.Time) (bool, error) {
+	ss.store.mu.Lock()
+	defer ss.store.mu.Unlock()
+	res, err := ss.store.db.Exec(`UPDATE schedules SET next_run_at = ?, updated_at = ? WHERE id = ? AND next_run_at = ?`,
+		nullTime(next), time.Now().UTC(), id, prev.UTC())
+	if err != nil {
+		return false, fmt.Errorf("advance schedule: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// Delete removes a schedule and its run history. It reports false if no
+// schedule has that ID.
+func (ss *ScheduleStore) Delete(id int64) (bool, error) {
+	ss.store.mu.Lock()
+	defer ss.store.mu.Unlock()
+	tx, err := ss.store.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("begin transaction: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM schedule_runs WHERE schedule_id = ?`, id); err != nil {
+		_ = tx.Rollback()
+		return false, fmt.Errorf("delete schedule runs: %w", err)
+	}
+	res, err := tx.Exec(`DELETE FROM schedules WHERE id = ?`, id)
+	if err != nil {
+		_ = tx.Rollback()
+		return false, fmt.Errorf("delete schedule: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, tx.Commit()
+}
+
+// RecordRun stores a firing and updates the schedule's last-run summary.
+// Skipped firings are recorded but do not count as runs.
+func (ss *ScheduleStore) RecordRun(run *ScheduleRun) error {
+	if run.Trigger == "" {
+		run.Trigger = "schedule"
+	}
+	ss.store.mu.Lock()
+	defer ss.store.mu.Unlock()
+	tx, err := ss.store.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	res, err := tx.Exec(`
+		INSERT INTO schedule_runs (schedule_id, scheduled_for, started_at, finished_at, status, trigger, missed, error, output)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		run.ScheduleID, run.ScheduledFor.UTC(), run.StartedAt.UTC(), nullTime(run.FinishedAt), run.Status,
+		run.Trigger, run.Missed, nullString(run.Error), nullString(run.Output))
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("record schedule run: %w", err)
+	}
+	if run.ID, err = res.LastInsertId(); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	counted := 1
+	if run.Status == ScheduleRunSkipped {
+		counted = 0
+	}
+	if _, err := tx.Exec(`UPDATE schedules SET last_run_at = ?, last_status = ?, last_error = ?,
+		run_count = run_count + ?, updated_at = ? WHERE id = ?`,
+		run.StartedAt.UTC(), run.Status, nullString(run.Error), counted, time.Now().UTC(), run.ScheduleID); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("update schedule after run: %w", err)
+	}
+	return tx.Commit()
+}
+
+// Runs returns a schedule's most recent firings, newest first.
+func (ss *ScheduleStore) Runs(scheduleID int64, limit int) ([]ScheduleRun, error) {
+	query := `SELECT id, schedule_id, scheduled_for, started_at, finished_at, status, trigger, missed,
+		COALESCE(error, ''), COALESCE(output, '') FROM schedule_runs WHERE schedule_id = ? ORDER BY started_at DESC, id DESC`
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+
+	ss.store.mu.RLock()
+	defer ss.store.mu.RUnlock()
+	rows, err := ss.store.db.Query(query, scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("list schedule runs: %w", err)
+	}
+	defer rows.Close()
+
+	var out []ScheduleRun
+	for rows.Next() {
+		var (
+			r        ScheduleRun
+			finished sql.NullTime
+		)
+		if err := rows.Scan(&r.ID, &r.ScheduleID, &r.ScheduledFor, &r.StartedAt, &finished, &r.Status,
+			&r.Trigger, &r.Missed, &r.Error, &r.Output); err != nil {
+			return nil, fmt.Errorf("scan schedule run: %w", err)
+		}
+		r.FinishedAt = nullTimePtr(finished)
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
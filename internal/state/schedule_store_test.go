@@ -0,0 +1,86 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleStore(t *testing.T) {
+	t.Parallel()
+	ss := NewScheduleStore(testStoreFile(t))
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	due := now.Add(-time.Minute)
+	later := now.Add(time.Hour)
+
+	standup := &Schedule{Name: "standup", SessionName: "proj", Kind: "send", Args: []string{"--cc", "post your stand-up"},
+		Spec: "0 9 * * 1-5", JitterSeconds: 30, NextRunAt: &due}
+	if err := ss.Create(standup); err != nil {
+		t.Fatal(err)
+	}
+	if err := ss.Create(&Schedule{Name: "standup", SessionName: "proj", Kind: "send", Spec: "@daily"}); err == nil {
+		t.Error("duplicate name accepted")
+	}
+	nightly := &Schedule{Name: "nightly", SessionName: "proj", Kind: "checkpoint", Spec: "@daily", NextRunAt: &later, MissedPolicy: MissedCatchUp}
+	if err := ss.Create(nightly); err != nil {
+		t.Fatal(err)
+	}
+	if err := ss.Create(&Schedule{Name: "scan", SessionName: "other", Kind: "scan", Spec: "@every 1h", NextRunAt: &due}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ss.GetByName("standup")
+	if err != nil || got == nil || len(got.Args) != 2 || got.MissedPolicy != MissedSkip || got.NextRunAt == nil {
+		t.Fatalf("GetByName = %+v, %v", got, err)
+	}
+
+	dueList, err := ss.Due("proj", now)
+	if err != nil || len(dueList) != 1 || dueList[0].Name != "standup" {
+		t.Fatalf("Due = %+v, %v", dueList, err)
+	}
+
+	// Only one runner can take a firing.
+	next := now.Add(24 * time.Hour)
+	if ok, err := ss.Advance(standup.ID, *got.NextRunAt, &next); !ok || err != nil {
+		t.Fatalf("Advance = %v, %v", ok, err)
+	}
+	if ok, _ := ss.Advance(standup.ID, *got.NextRunAt, &next); ok {
+		t.Error("second Advance from the same firing succeeded")
+	}
+
+	finished := now.Add(time.Second)
+	if err := ss.RecordRun(&ScheduleRun{ScheduleID: standup.ID, ScheduledFor: due, StartedAt: now, FinishedAt: &finished, Status: ScheduleRunOK, Output: "sent"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ss.RecordRun(&ScheduleRun{ScheduleID: standup.ID, ScheduledFor: now, StartedAt: finished, Status: ScheduleRunSkipped}); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = ss.Get(standup.ID)
+	if got.RunCount != 1 || got.LastStatus != ScheduleRunSkipped || got.LastRunAt == nil {
+		t.Errorf("after runs = %+v", got)
+	}
+	runs, err := ss.Runs(standup.ID, 10)
+	if err != nil || len(runs) != 2 || runs[0].Status != ScheduleRunSkipped || runs[1].Output != "sent" || runs[1].Trigger != "schedule" {
+		t.Fatalf("Runs = %+v, %v", runs, err)
+	}
+
+	if ok, _ := ss.SetPaused(nightly.ID, true, nil); !ok {
+		t.Fatal("SetPaused failed")
+	}
+	if got, _ := ss.Get(nightly.ID); !got.Paused || got.NextRunAt != nil {
+		t.Errorf("paused schedule = %+v", got)
+	}
+
+	all, _ := ss.List("")
+	if len(all) != 3 {
+		t.Errorf("List(all) = %d schedules, want 3", len(all))
+	}
+	if ok, err := ss.Delete(standup.ID); !ok || err != nil {
+		t.Errorf("Delete = %v, %v", ok, err)
+	}
+	if runs, _ := ss.Runs(standup.ID, 0); len(runs) != 0 {
+		t.Errorf("runs survived delete: %+v", runs)
+	}
+	if list, _ := ss.List("proj"); len(list) != 1 {
+		t.Errorf("List(proj) after delete = %+v", list)
+	}
+}
@@ -0,0 +1,216 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/cost"
+)
+
+// SpendDayLayout is the format of SpendEvent.Day.
+const SpendDayLayout = "2006-01-02"
+
+// SpendEvent records token usage and its estimated cost.
+type SpendEvent struct {
+	ID           int64     `json:"id"`
+	SessionName  string    `json:"session"`
+	PaneID       string    `json:"pane_id,omitempty"`
+	AgentType    string    `json:"agent_type,omitempty"`
+	Model        string    `json:"model,omitempty"`
+	InputTokens  int64     `json:"input_tokens"`
+	OutputTokens int64     `json:"output_tokens"`
+	CostUSD      float64   `json:"cost_usd"`
+	Source       string    `json:"source"` // prompt, response, tokens
+	Day          string    `json:"day"`    // Local date, YYYY-MM-DD
+	RecordedAt   time.Time `json:"recorded_at"`
+}
+
+// SpendFilter selects spend events. Empty fields match everything.
+type SpendFilter struct {
+	SessionName string
+	AgentType   string
+	Day         string // Exact local date
+	Month       string // Local month, YYYY-MM
+}
+
+// SpendTotals sums token usage and cost.
+type SpendTotals struct {
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+}
+
+// Tokens returns input plus output tokens.
+func (t SpendTotals) Tokens() int64 {
+	return t.InputTokens + t.OutputTokens
+}
+
+// SpendRollupRow is one session, agent type and day in a spend rollup.
+type SpendRollupRow struct {
+	SessionName string `json:"session"`
+	AgentType   string `json:"agent_type,omitempty"`
+	Day         string `json:"day"`
+	SpendTotals
+}
+
+// SpendStore persists the spend ledger in the state database.
+type SpendStore struct {
+	store *Store
+}
+
+// NewSpendStore creates a spend store backed by store.
+func NewSpendStore(store *Store) *SpendStore {
+	if store == nil {
+		return nil
+	}
+	return &SpendStore{store: store}
+}
+
+// Record appends a spend event, filling in Day and RecordedAt when unset.
+func (ss *SpendStore) Record(ev *SpendEvent) error {
+	if ev.SessionName == "" || ev.Source == "" {
+		return errors.New("session and source are required")
+	}
+	if ev.RecordedAt.IsZero() {
+		ev.RecordedAt = time.Now()
+	}
+	if ev.Day == "" {
+		ev.Day = ev.RecordedAt.Local().Format(SpendDayLayout)
+	}
+
+	ss.store.mu.Lock()
+	defer ss.store.mu.Unlock()
+	res, err := ss.store.db.Exec(`
+		INSERT INTO spend_events (session_name, pane_id, agent_type, model, input_tokens, output_tokens,
+			cost_usd, source, day, recorded_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		ev.SessionName, nullString(ev.PaneID), nullString(ev.AgentType), nullString(ev.Model),
+		ev.InputTokens, ev.OutputTokens, ev.CostUSD, ev.Source, ev.Day, ev.RecordedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("record spend: %w", err)
+	}
+	ev.ID, err = res.LastInsertId()
+	return err
+}
+
+func spendWhere(f SpendFilter) (string, []any) {
+	var (
+		conds []string
+		args  []any
+	)
+	if f.SessionName != "" {
+		conds = append(conds, "session_name = ?")
+		args = append(args, f.SessionName)
+	}
+	if f.AgentType != "" {
+		conds = append(conds, "agent_type = ?")
+		args = append(args, f.AgentType)
+	}
+	if f.Day != "" {
+		conds = append(conds, "day = ?")
+		args = append(args, f.Day)
+	}
+	if f.Month != "" {
+		conds = append(conds, "day LIKE ?")
+		args = append(args, f.Month+"-%")
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// Totals sums the spend events matching f.
+func (ss *SpendStore) Totals(f SpendFilter) (SpendTotals, error) {
+	where, args := spendWhere(f)
+	var t SpendTotals
+
+	ss.store.mu.RLock()
+	defer ss.store.mu.RUnlock()
+	err := ss.store.db.QueryRow(`SELECT COALESCE(SUM(input_tokens), 0), COALESCE(SUM(output_tokens), 0),
+		COALESCE(SUM(cost_usd), 0) FROM spend_events`+where, args...).Scan(&t.InputTokens, &t.OutputTokens, &t.CostUSD)
+	if err != nil {
+		return t, fmt.Errorf("sum spend: %w", err)
+	}
+	return t, nil
+}
+
+// Rollup sums the spend events matching f by session, agent type and day,
+// ordered by day and then session.
+func (ss *SpendStore) Rollup(f SpendFilter) ([]SpendRollupRow, error) {
+	where, args := spendWhere(f)
+
+	ss.store.mu.RLock()
+	defer ss.store.mu.RUnlock()
+	rows, err := ss.store.db.Query(`SELECT session_name, COALESCE(agent_type, ''), day,
+		SUM(input_tokens), SUM(output_tokens), SUM(cost_usd) FROM spend_events`+where+`
+		GROUP BY session_name, agent_type, day ORDER BY day, session_name, agent_type`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("roll up spend: %w", err)
+	}
+	defer rows.Close()
+
+	var out []SpendRollupRow
+	for rows.Next() {
+		var r SpendRollupRow
+		if err := rows.Scan(&r.SessionName, &r.AgentType, &r.Day, &r.InputTokens, &r.OutputTokens, &r.CostUSD); err != nil {
+			return nil, fmt.Errorf("scan spend rollup: %w", err)
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// RecordUsage records a cost tracker usage; it backs the tracker's sink.
+func (ss *SpendStore) RecordUsage(u cost.Usage) error {
+	return ss.Record(&SpendEvent{
+		SessionName:  u.Session,
+		PaneID:       u.Pane,
+		AgentType:    u.AgentType,
+		Model:        u.Model,
+		InputTokens:  int64(u.InputTokens),
+		OutputTokens: int64(u.OutputTokens),
+		CostUSD:      u.CostUSD,
+		Source:       u.Source,
+		RecordedAt:   u.At,
+	})
+}
+
+// BudgetSpend reports ledger spend to cost.Evaluate. Daily budgets count
+// today's local date across all sessions.
+func (ss *SpendStore) BudgetSpend(scope cost.BudgetScope, session, agentType string) (cost.Spent, error) {
+	f := SpendFilter{SessionName: session, AgentType: agentType}
+	if scope == cost.ScopeDaily {
+		f.Day = time.Now().Format(SpendDayLayout)
+	}
+	t, err := ss.Totals(f)
+	if err != nil {
+		return cost.Spent{}, err
+	}
+	return cost.Spent{Tokens: t.Tokens(), USD: t.CostUSD}, nil
+}
+
+// PromptTimes returns when prompts were sent to agents of agentType since
+// the given time, oldest first; they measure send volume.
+func (ss *SpendStore) PromptTimes(agentType string, since time.Time) ([]time.Time, error) {
+	ss.store.mu.RLock()
+	defer ss.store.mu.RUnlock()
+	rows, err := ss.store.db.Query(`SELECT recorded_at FROM spend_events
+		WHERE source = 'prompt' AND agent_type = ? AND recorded_at >= ? ORDER BY recorded_at`, agentType, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("list prompt times: %w", err)
+	}
+	defer rows.Close()
+
+	var out []time.Time
+	for rows.Next() {
+		var t time.Time
+		if err := rows.Scan(&t); err != nil {
+			return nil, fmt.Errorf("scan prompt time: %w", err)
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,45 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSpendStore(t *testing.T) {
+	t.Parallel()
+	ss := NewSpendStore(testStoreFile(t))
+	at := time.Date(2026, 10, 2, 12, 0, 0, 0, time.Local)
+
+	for _, ev := range []SpendEvent{
+		{SessionName: "proj", PaneID: "%1", AgentType: "cc", InputTokens: 1000, CostUSD: 0.5, Source: "prompt", RecordedAt: at},
+		{SessionName: "proj", PaneID: "%1", AgentType: "cc", OutputTokens: 2000, CostUSD: 1.5, Source: "response", RecordedAt: at},
+		{SessionName: "proj", PaneID: "%2", AgentType: "cod", OutputTokens: 500, CostUSD: 0.25, Source: "response", RecordedAt: at.AddDate(0, 0, 1)},
+		{SessionName: "other", AgentType: "cc", InputTokens: 100, CostUSD: 0.1, Source: "prompt", RecordedAt: at.AddDate(0, 1, 0)},
+	} {
+		if err := ss.Record(&ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ss.Record(&SpendEvent{Source: "prompt"}); err == nil {
+		t.Error("event without a session accepted")
+	}
+
+	got, err := ss.Totals(SpendFilter{SessionName: "proj"})
+	if err != nil || got.Tokens() != 3500 || got.CostUSD != 2.25 {
+		t.Errorf("session totals = %+v, %v", got, err)
+	}
+	if got, _ := ss.Totals(SpendFilter{SessionName: "proj", AgentType: "cc"}); got.CostUSD != 2 {
+		t.Errorf("agent totals = %+v", got)
+	}
+	if got, _ := ss.Totals(SpendFilter{Day: "2026-10-02"}); got.InputTokens != 1000 || got.OutputTokens != 2000 {
+		t.Errorf("day totals = %+v", got)
+	}
+
+	rows, err := ss.Rollup(SpendFilter{Month: "2026-10"})
+	if err != nil || len(rows) != 2 {
+		t.Fatalf("Rollup = %+v, %v", rows, err)
+	}
+	if rows[0].Day != "2026-10-02" || rows[0].AgentType != "cc" || rows[0].CostUSD != 2 || rows[1].AgentType != "cod" {
+		t.Errorf("rollup rows = %+v", rows)
+	}
+}
@@ -684,6 +684,28 @@ func (s *Store) ListExpiredPendingApprovals() ([]Approval, error) {
 	return approvals, rows.Err()
 }
 
+// LatestApproval returns the most recent approval request for action on
+// resource, or nil if there is none.
+func (s *Store) LatestApproval(action, resource string) (*Approval, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	appr := &Approval{}
+	err := s.db.QueryRow(`
+		SELECT id, action, resource, COALESCE(reason, ''), requested_by, COALESCE(correlation_id, ''), requires_slb, created_at, expires_at, status, COALESCE(approved_by, ''), approved_at, COALESCE(denied_reason, '')
+		FROM approvals WHERE action = ? AND resource = ?
+		ORDER BY created_at DESC LIMIT 1`, action, resource,
+	).Scan(&appr.ID, &appr.Action, &appr.Resource, &appr.Reason, &appr.RequestedBy, &appr.CorrelationID, &appr.RequiresSLB, &appr.CreatedAt, &appr.ExpiresAt, &appr.Status, &appr.ApprovedBy, &appr.ApprovedAt, &appr.DeniedReason)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get latest approval: %w", err)
+	}
+	return appr, nil
+}
+
 // ========================
 // Tool Health Operations
 // ========================
@@ -46,6 +46,10 @@ const (
 	MarkerStart MarkerType = "start"
 	// MarkerStop indicates session/agent stop (◆).
 	MarkerStop MarkerType = "stop"
+	// MarkerCheckpoint indicates a session checkpoint was created (⚑).
+	MarkerCheckpoint MarkerType = "checkpoint"
+	// MarkerRotation indicates the agent's context was rotated (↻).
+	MarkerRotation MarkerType = "rotation"
 )
 
 // String returns the string representation of MarkerType.
@@ -64,6 +68,10 @@ func (m MarkerType) Symbol() string {
 		return "✗"
 	case MarkerStart, MarkerStop:
 		return "◆"
+	case MarkerCheckpoint:
+		return "⚑"
+	case MarkerRotation:
+		return "↻"
 	default:
 		return "•"
 	}
@@ -777,6 +777,8 @@ func TestMarkerTypeSymbol(t *testing.T) {
 		{MarkerError, "✗"},
 		{MarkerStart, "◆"},
 		{MarkerStop, "◆"},
+		{MarkerCheckpoint, "⚑"},
+		{MarkerRotation, "↻"},
 		{MarkerType("unknown"), "•"},
 	}
 
@@ -0,0 +1,554 @@
+package state
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TranscriptRecord is an ingested agent transcript file.
+type TranscriptRecord struct {
+	ID             int64      `json:"id"`
+	Path           string     `json:"path"`
+	Agent          string     `json:"agent"`
+	AgentSessionID string     `json:"agent_session_id,omitempty"`
+	SessionName    string     `json:"session,omitempty"`
+	PaneIndex      *int       `json:"pane,omitempty"`
+	BeadID         string     `json:"bead_id,omitempty"`
+	Cwd            string     `json:"cwd,omitempty"`
+	StartedAt      *time.Time `json:"started_at,omitempty"`
+	EndedAt        *time.Time `json:"ended_at,omitempty"`
+	FileSize       int64      `json:"file_size"`
+	FileMTime      time.Time  `json:"file_mtime"`
+	TurnCount      int        `json:"turn_count"`
+	IngestedAt     time.Time  `json:"ingested_at"`
+}
+
+// TranscriptTurn is a stored user prompt or assistant response.
+type TranscriptTurn struct {
+	ID           int64                `json:"id"`
+	TranscriptID int64                `json:"transcript_id"`
+	Seq          int                  `json:"seq"`
+	Role         string               `json:"role"`
+	Text         string               `json:"text,omitempty"`
+	Model        string               `json:"model,omitempty"`
+	Timestamp    *time.Time           `json:"timestamp,omitempty"`
+	ToolCalls    []TranscriptToolCall `json:"tool_calls,omitempty"`
+}
+
+// TranscriptToolCall is a stored tool invocation.
+type TranscriptToolCall struct {
+	ID      int64    `json:"id"`
+	CallID  string   `json:"call_id,omitempty"`
+	Name    string   `json:"name"`
+	Input   string   `json:"input,omitempty"`
+	Output  string   `json:"output,omitempty"`
+	IsError bool     `json:"is_error,omitempty"`
+	Files   []string `json:"files,omitempty"`
+}
+
+// TranscriptTurnMatch is a turn returned by QueryTurns along with the
+// transcript it belongs to.
+type TranscriptTurnMatch struct {
+	TranscriptTurn
+	Agent       string `json:"agent"`
+	SessionName string `json:"session,omitempty"`
+	PaneIndex   *int   `json:"pane,omitempty"`
+	BeadID      string `json:"bead_id,omitempty"`
+	Path        string `json:"path"`
+}
+
+// TranscriptFileEdit is one file touched by an agent's tool call.
+type TranscriptFileEdit struct {
+	Path         string     `json:"path"`
+	Timestamp    *time.Time `json:"timestamp,omitempty"`
+	Tool         string     `json:"tool"`
+	TranscriptID int64      `json:"transcript_id"`
+	Agent        string     `json:"agent"`
+	SessionName  string     `json:"session,omitempty"`
+	PaneIndex    *int       `json:"pane,omitempty"`
+	BeadID       string     `json:"bead_id,omitempty"`
+}
+
+// TranscriptFilter narrows transcripts, turns and file edits. Zero fields
+// match everything.
+type TranscriptFilter struct {
+	SessionName string
+	PaneIndex   *int
+	BeadID      string
+	Agent       string
+	Role        string    // Turns only
+	Text        string    // Substring of the turn text or a tool call's input/output
+	Tool        string    // Turns with a call to this tool
+	File        string    // Turns (or edits) touching a path ending in this suffix
+	Since       time.Time // Turns and edits at or after this time
+	Limit       int       // 0 means no limit
+}
+
+// TranscriptStore persists parsed agent transcripts.
+type TranscriptStore struct {
+	store *Store
+}
+
+// NewTranscriptStore returns a new TranscriptStore bound to the provided Store.
+func NewTranscriptStore(store *Store) *TranscriptStore {
+	if store == nil {
+		return nil
+	}
+	return &TranscriptStore{store: store}
+}
+
+// Save inserts or replaces a transcript and all of its turns. Re-saving a
+// path discards the turns stored for it before, so re-ingesting a transcript
+// that has grown is idempotent.
+func (ts *TranscriptStore) Save(rec *TranscriptRecord, turns []TranscriptTurn) error {
+	if rec.Path == "" {
+		return errors.New("transcript path is required")
+	}
+	if rec.IngestedAt.IsZero() {
+		rec.IngestedAt = time.Now().UTC()
+	}
+	rec.TurnCount = len(turns)
+
+	ts.store.mu.Lock()
+	defer ts.store.mu.Unlock()
+
+	tx, err := ts.store.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	if err := func() error {
+		if _, err := tx.Exec(`DELETE FROM transcripts WHERE path = ?`, rec.Path); err != nil {
+			return fmt.Errorf("delete transcript: %w", err)
+		}
+		res, err := tx.Exec(`
+			INSERT INTO transcripts (path, agent, agent_session_id, session_name, pane_index, bead_id, cwd,
+				started_at, ended_at, file_size, file_mtime, turn_count, ingested_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			rec.Path, rec.Agent, nullString(rec.AgentSessionID), nullString(rec.SessionName), nullInt(rec.PaneIndex),
+			nullString(rec.BeadID), nullString(rec.Cwd), nullTime(rec.StartedAt), nullTime(rec.EndedAt),
+			rec.FileSize, rec.FileMTime, rec.TurnCount, rec.IngestedAt)
+		if err != nil {
+			return fmt.Errorf("insert transcript: %w", err)
+		}
+		if rec.ID, err = res.LastInsertId(); err != nil {
+			return err
+		}
+
+		for i := range turns {
+			turn := &turns[i]
+			turn.TranscriptID = rec.ID
+			res, err := tx.Exec(`
+				INSERT INTO transcript_turns (transcript_id, seq, role, text, model, ts)
+				VALUES (?, ?, ?, ?, ?, ?)`,
+				rec.ID, turn.Seq, turn.Role, turn.Text, nullString(turn.Model), nullTime(turn.Timestamp))
+			if err != nil {
+				return fmt.Errorf("insert turn %d: %w", turn.Seq, err)
+			}
+			if turn.ID, err = res.LastInsertId(); err != nil {
+				return err
+			}
+			for j := range turn.ToolCalls {
+				tc := &turn.ToolCalls[j]
+				res, err := tx.Exec(`
+					INSERT INTO transcript_tool_calls (turn_id, transcript_id, call_id, name, input, output, is_error)
+					VALUES (?, ?, ?, ?, ?, ?, ?)`,
+					turn.ID, rec.ID, nullString(tc.CallID), tc.Name, nullString(tc.Input), nullString(tc.Output), tc.IsError)
+				if err != nil {
+					return fmt.Errorf("insert tool call: %w", err)
+				}
+				if tc.ID, err = res.LastInsertId(); err != nil {
+					return err
+				}
+				for _, path := range tc.Files {
+					if _, err := tx.Exec(`
+						INSERT INTO transcript_file_edits (tool_call_id, transcript_id, path, ts)
+						VALUES (?, ?, ?, ?)`,
+						tc.ID, rec.ID, path, nullTime(turn.Timestamp)); err != nil {
+						return fmt.Errorf("insert file edit: %w", err)
+					}
+				}
+			}
+		}
+		return nil
+	}(); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+const transcriptColumns = `t.id, t.path, t.agent, COALESCE(t.agent_session_id, ''), COALESCE(t.session_name, ''),
+	t.pane_index, COALESCE(t.bead_id, ''), COALESCE(t.cwd, ''), t.started_at, t.ended_at,
+	t.file_size, t.file_mtime, t.turn_count, t.ingested_at`
+
+func scanTranscript(row interface{ Scan(...any) error }) (*TranscriptRecord, error) {
+	var (
+		rec            TranscriptRecord
+		pane           sql.NullInt64
+		started, ended sql.NullTime
+		mtime          sql.NullTime
+	)
+	if err := row.Scan(&rec.ID, &rec.Path, &rec.Agent, &rec.AgentSessionID, &rec.SessionName,
+		&pane, &rec.BeadID, &rec.Cwd, &started, &ended,
+		&rec.FileSize, &mtime, &rec.TurnCount, &rec.IngestedAt); err != nil {
+		return nil, err
+	}
+	rec.PaneIndex = intPtr(pane)
+	rec.StartedAt = nullTimePtr(started)
+	rec.EndedAt = nullTimePtr(ended)
+	rec.FileMTime = mtime.Time
+	return &rec, nil
+}
+
+// Get returns a transcript by ID, or nil if there is none.
+func (ts *TranscriptStore) Get(id int64) (*TranscriptRecord, error) {
+	ts.store.mu.RLock()
+	defer ts.store.mu.RUnlock()
+	rec, err := scanTranscript(ts.store.db.QueryRow(`SELECT `+transcriptColumns+` FROM transcripts t WHERE t.id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get transcript: %w", err)
+	}
+	return rec, nil
+}
+
+// GetByPath returns the transcript ingested from path, or nil if there is none.
+func (ts *TranscriptStore) GetByPath(path string) (*TranscriptRecord, error) {
+	ts.store.mu.RLock()
+	defer ts.store.mu.RUnlock()
+	rec, err := scanTranscript(ts.store.db.QueryRow(`SELECT `+transcriptColumns+` FROM transcripts t WHERE t.path = ?`, path))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get transcript: %w", err)
+	}
+	return rec, nil
+}
+
+// List returns transcripts matching the session, pane, bead and agent
+// fields of f, most recently active first.
+func (ts *TranscriptStore) List(f TranscriptFilter) ([]TranscriptRecord, error) {
+	where, args := transcriptWhere(f)
+	query := `SELECT ` + transcriptColumns + ` FROM transcripts t` + where +
+		` ORDER BY COALESCE(t.ended_at, t.file_mtime) DESC, t.id DESC`
+	if f.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", f.Limit)
+	}
+
+	ts.store.mu.RLock()
+	defer ts.store.mu.RUnlock()
+	rows, err := ts.store.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list transcripts: %w", err)
+	}
+	defer rows.Close()
+
+	var out []TranscriptRecord
+	for rows.Next() {
+		rec, err := scanTranscript(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan transcript: %w", err)
+		}
+		out = append(out, *rec)
+	}
+	return out, rows.Err()
+}
+
+// Turns returns every turn of a transcript, in order, with tool calls.
+func (ts *TranscriptStore) Turns(transcriptID int64) ([]TranscriptTurn, error) {
+	ts.store.mu.RLock()
+	defer ts.store.mu.RUnlock()
+
+	rows, err := ts.store.db.Query(`
+		SELECT id, transcript_id, seq, role, text, COALESCE(model, ''), ts
+		FROM transcript_turns WHERE transcript_id = ? ORDER BY seq`, transcriptID)
+	if err != nil {
+		return nil, fmt.Errorf("list turns: %w", err)
+	}
+	var turns []TranscriptTurn
+	for rows.Next() {
+		var turn TranscriptTurn
+		var at sql.NullTime
+		if err := rows.Scan(&turn.ID, &turn.TranscriptID, &turn.Seq, &turn.Role, &turn.Text, &turn.Model, &at); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan turn: %w", err)
+		}
+		turn.Timestamp = nullTimePtr(at)
+		turns = append(turns, turn)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := ts.attachToolCalls(turns, "transcript_id = ?", transcriptID); err != nil {
+		return nil, err
+	}
+	return turns, nil
+}
+
+// QueryTurns searches turns across transcripts, newest first.
+func (ts *TranscriptStore) QueryTurns(f TranscriptFilter) ([]TranscriptTurnMatch, error) {
+	where, args := transcriptWhere(f)
+	var conds []string
+	if where != "" {
+		conds = append(conds, strings.TrimPrefix(where, " WHERE "))
+	}
+	if f.Role != "" {
+		conds = append(conds, "u.role = ?")
+		args = append(args, f.Role)
+	}
+	if !f.Since.IsZero() {
+		conds = append(conds, "u.ts >= ?")
+		args = append(args, f.Since.UTC())
+	}
+	if f.Text != "" {
+		conds = append(conds, `(u.text LIKE ? ESCAPE '\' OR EXISTS (
+			SELECT 1 FROM transcript_tool_calls c WHERE c.turn_id = u.id
+			AND (c.input LIKE ? ESCAPE '\' OR c.output LIKE ? ESCAPE '\')))`)
+		pattern := "%" + escapeLike(f.Text) + "%"
+		args = append(args, pattern, pattern, pattern)
+	}
+	if f.Tool != "" {
+		conds = append(conds, "EXISTS (SELECT 1 FROM transcript_tool_calls c WHERE c.turn_id = u.id AND c.name = ?)")
+		args = append(args, f.Tool)
+	}
+	if f.File != "" {
+		conds = append(conds, `EXISTS (SELECT 1 FROM transcript_file_edits e
+			JOIN transcript_tool_calls c ON c.id = e.tool_call_id
+			WHERE c.turn_id = u.id AND e.path LIKE ? ESCAPE '\')`)
+		args = append(args, "%"+escapeLike(f.File))
+	}
+
+	query := `
+		SELECT u.id, u.transcript_id, u.seq, u.role, u.text, COALESCE(u.model, ''), u.ts,
+			t.agent, COALESCE(t.session_name, ''), t.pane_index, COALESCE(t.bead_id, ''), t.path
+		FROM transcript_turns u JOIN transcripts t ON t.id = u.transcript_id`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY u.ts DESC, u.id DESC"
+	if f.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", f.Limit)
+	}
+
+	ts.store.mu.RLock()
+	defer ts.store.mu.RUnlock()
+
+	rows, err := ts.store.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query turns: %w", err)
+	}
+	var matches []TranscriptTurnMatch
+	for rows.Next() {
+		var m TranscriptTurnMatch
+		var at sql.NullTime
+		var pane sql.NullInt64
+		if err := rows.Scan(&m.ID, &m.TranscriptID, &m.Seq, &m.Role, &m.Text, &m.Model, &at,
+			&m.Agent, &m.SessionName, &pane, &m.BeadID, &m.Path); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan turn: %w", err)
+		}
+		m.Timestamp = nullTimePtr(at)
+		m.PaneIndex = intPtr(pane)
+		matches = append(matches, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, nil
+	}
+
+	turns := make([]TranscriptTurn, len(matches))
+	ids := make([]any, len(matches))
+	for i := range matches {
+		turns[i] = matches[i].TranscriptTurn
+		ids[i] = matches[i].ID
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	if err := ts.attachToolCalls(turns, "turn_id IN ("+placeholders+")", ids...); err != nil {
+		return nil, err
+	}
+	for i := range matches {
+		matches[i].ToolCalls = turns[i].ToolCalls
+	}
+	return matches, nil
+}
+
+// FileEdits returns the files agents edited, oldest first. Only the session,
+// pane, bead, agent, file and since fields of f apply.
+func (ts *TranscriptStore) FileEdits(f TranscriptFilter) ([]TranscriptFileEdit, error) {
+	where, args := transcriptWhere(f)
+	var conds []string
+	if where != "" {
+		conds = append(conds, strings.TrimPrefix(where, " WHERE "))
+	}
+	if f.File != "" {
+		conds = append(conds, `e.path LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+escapeLike(f.File))
+	}
+	if !f.Since.IsZero() {
+		conds = append(conds, "e.ts >= ?")
+		args = append(args, f.Since.UTC())
+	}
+	query := `
+		SELECT e.path, e.ts, c.name, t.id, t.agent, COALESCE(t.session_name, ''), t.pane_index, COALESCE(t.bead_id, '')
+		FROM transcript_file_edits e
+		JOIN transcript_tool_calls c ON c.id = e.tool_call_id
+		JOIN transcripts t ON t.id = e.transcript_id`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY e.ts, e.id"
+	if f.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", f.Limit)
+	}
+
+	ts.store.mu.RLock()
+	defer ts.store.mu.RUnlock()
+	rows, err := ts.store.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query file edits: %w", err)
+	}
+	defer rows.Close()
+
+	var edits []TranscriptFileEdit
+	for rows.Next() {
+		var e TranscriptFileEdit
+		var at sql.NullTime
+		var pane sql.NullInt64
+		if err := rows.Scan(&e.Path, &at, &e.Tool, &e.TranscriptID, &e.Agent, &e.SessionName, &pane, &e.BeadID); err != nil {
+			return nil, fmt.Errorf("scan file edit: %w", err)
+		}
+		e.Timestamp = nullTimePtr(at)
+		e.PaneIndex = intPtr(pane)
+		edits = append(edits, e)
+	}
+	return edits, rows.Err()
+}
+
+// attachToolCalls loads the tool calls (and their files) for turns selected
+// by cond. The caller must hold the read lock.
+func (ts *TranscriptStore) attachToolCalls(turns []TranscriptTurn, cond string, args ...any) error {
+	if len(turns) == 0 {
+		return nil
+	}
+	index := make(map[int64]int, len(turns))
+	for i := range turns {
+		index[turns[i].ID] = i
+	}
+
+	rows, err := ts.store.db.Query(`
+		SELECT id, turn_id, COALESCE(call_id, ''), name, COALESCE(input, ''), COALESCE(output, ''), is_error
+		FROM transcript_tool_calls WHERE `+cond+` ORDER BY id`, args...)
+	if err != nil {
+		return fmt.Errorf("list tool calls: %w", err)
+	}
+	calls := make(map[int64]*TranscriptToolCall)
+	var order []int64
+	for rows.Next() {
+		var tc TranscriptToolCall
+		var turnID int64
+		if err := rows.Scan(&tc.ID, &turnID, &tc.CallID, &tc.Name, &tc.Input, &tc.Output, &tc.IsError); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan tool call: %w", err)
+		}
+		i, ok := index[turnID]
+		if !ok {
+			continue
+		}
+		turns[i].ToolCalls = append(turns[i].ToolCalls, tc)
+		order = append(order, tc.ID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(order) == 0 {
+		return nil
+	}
+	for i := range turns {
+		for j := range turns[i].ToolCalls {
+			calls[turns[i].ToolCalls[j].ID] = &turns[i].ToolCalls[j]
+		}
+	}
+
+	rows, err = ts.store.db.Query(`
+		SELECT e.tool_call_id, e.path FROM transcript_file_edits e
+		JOIN transcript_tool_calls c ON c.id = e.tool_call_id
+		WHERE c.`+cond+` ORDER BY e.id`, args...)
+	if err != nil {
+		return fmt.Errorf("list file edits: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var callID int64
+		var path string
+		if err := rows.Scan(&callID, &path); err != nil {
+			return fmt.Errorf("scan file edit: %w", err)
+		}
+		if tc := calls[callID]; tc != nil {
+			tc.Files = append(tc.Files, path)
+		}
+	}
+	return rows.Err()
+}
+
+// transcriptWhere builds the transcript-level conditions of f against alias t.
+func transcriptWhere(f TranscriptFilter) (string, []any) {
+	var conds []string
+	var args []any
+	if f.SessionName != "" {
+		conds = append(conds, "t.session_name = ?")
+		args = append(args, f.SessionName)
+	}
+	if f.PaneIndex != nil {
+		conds = append(conds, "t.pane_index = ?")
+		args = append(args, *f.PaneIndex)
+	}
+	if f.BeadID != "" {
+		conds = append(conds, "t.bead_id = ?")
+		args = append(args, f.BeadID)
+	}
+	if f.Agent != "" {
+		conds = append(conds, "t.agent = ?")
+		args = append(args, f.Agent)
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func nullInt(p *int) sql.NullInt64 {
+	if p == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(*p), Valid: true}
+}
+
+func nullTime(p *time.Time) sql.NullTime {
+	if p == nil || p.IsZero() {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: p.UTC(), Valid: true}
+}
+
+func intPtr(n sql.NullInt64) *int {
+	if !n.Valid {
+		return nil
+	}
+	v := int(n.Int64)
+	return &v
+}
@@ -0,0 +1,148 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTranscriptStoreSaveAndQuery(t *testing.T) {
+	t.Parallel()
+	ts := NewTranscriptStore(testStoreFile(t))
+
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	at := func(min int) *time.Time { v := base.Add(time.Duration(min) * time.Minute); return &v }
+	pane := 2
+
+	rec := &TranscriptRecord{
+		Path:        "/home/u/.claude/projects/-work-app/abc.jsonl",
+		Agent:       "claude",
+		SessionName: "app",
+		PaneIndex:   &pane,
+		BeadID:      "bd-12",
+		StartedAt:   at(0),
+		EndedAt:     at(5),
+		FileSize:    100,
+		FileMTime:   base,
+	}
+	turns := []TranscriptTurn{
+		{Seq: 0, Role: "user", Text: "fix the 100% failing test", Timestamp: at(0)},
+		{Seq: 1, Role: "assistant", Text: "Done.", Model: "opus", Timestamp: at(1), ToolCalls: []TranscriptToolCall{
+			{CallID: "t1", Name: "Read", Input: `{"file_path":"/work/app/main.go"}`, Output: "package main"},
+			{CallID: "t2", Name: "Edit", Input: `{"file_path":"/work/app/main.go"}`, Files: []string{"/work/app/main.go"}},
+			{CallID: "t3", Name: "Bash", Input: `{"command":"go test"}`, Output: "FAIL", IsError: true},
+		}},
+		{Seq: 2, Role: "user", Text: "thanks", Timestamp: at(5)},
+	}
+	if err := ts.Save(rec, turns); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if rec.ID == 0 || rec.TurnCount != 3 {
+		t.Fatalf("Save did not fill record: %+v", rec)
+	}
+
+	got, err := ts.GetByPath(rec.Path)
+	if err != nil || got == nil {
+		t.Fatalf("GetByPath = %v, %v", got, err)
+	}
+	if got.PaneIndex == nil || *got.PaneIndex != 2 || got.BeadID != "bd-12" || got.EndedAt == nil || !got.EndedAt.Equal(*at(5)) {
+		t.Errorf("GetByPath = %+v", got)
+	}
+
+	stored, err := ts.Turns(rec.ID)
+	if err != nil {
+		t.Fatalf("Turns: %v", err)
+	}
+	if len(stored) != 3 || len(stored[1].ToolCalls) != 3 {
+		t.Fatalf("Turns = %+v", stored)
+	}
+	if tc := stored[1].ToolCalls[1]; tc.Name != "Edit" || len(tc.Files) != 1 || tc.Files[0] != "/work/app/main.go" {
+		t.Errorf("edit call = %+v", tc)
+	}
+	if !stored[1].ToolCalls[2].IsError {
+		t.Errorf("Bash call should be an error")
+	}
+
+	tests := []struct {
+		name   string
+		filter TranscriptFilter
+		want   []int // seqs, newest first
+	}{
+		{"all", TranscriptFilter{}, []int{2, 1, 0}},
+		{"role", TranscriptFilter{Role: "user"}, []int{2, 0}},
+		{"text literal percent", TranscriptFilter{Text: "100%"}, []int{0}},
+		{"text in tool output", TranscriptFilter{Text: "package main"}, []int{1}},
+		{"tool", TranscriptFilter{Tool: "Edit"}, []int{1}},
+		{"file suffix", TranscriptFilter{File: "main.go"}, []int{1}},
+		{"file miss", TranscriptFilter{File: "other.go"}, nil},
+		{"pane", TranscriptFilter{PaneIndex: &pane, Limit: 1}, []int{2}},
+		{"bead miss", TranscriptFilter{BeadID: "bd-99"}, nil},
+		{"since", TranscriptFilter{Since: base.Add(2 * time.Minute)}, []int{2}},
+	}
+	for _, tt := range tests {
+		matches, err := ts.QueryTurns(tt.filter)
+		if err != nil {
+			t.Fatalf("%s: QueryTurns: %v", tt.name, err)
+		}
+		var seqs []int
+		for _, m := range matches {
+			seqs = append(seqs, m.Seq)
+			if m.SessionName != "app" || m.Agent != "claude" {
+				t.Errorf("%s: match missing transcript fields: %+v", tt.name, m)
+			}
+		}
+		if len(seqs) != len(tt.want) {
+			t.Errorf("%s: seqs = %v, want %v", tt.name, seqs, tt.want)
+			continue
+		}
+		for i := range seqs {
+			if seqs[i] != tt.want[i] {
+				t.Errorf("%s: seqs = %v, want %v", tt.name, seqs, tt.want)
+				break
+			}
+		}
+	}
+
+	edits, err := ts.FileEdits(TranscriptFilter{SessionName: "app"})
+	if err != nil {
+		t.Fatalf("FileEdits: %v", err)
+	}
+	if len(edits) != 1 || edits[0].Tool != "Edit" || edits[0].BeadID != "bd-12" || edits[0].PaneIndex == nil {
+		t.Errorf("FileEdits = %+v", edits)
+	}
+}
+
+func TestTranscriptStoreResave(t *testing.T) {
+	t.Parallel()
+	ts := NewTranscriptStore(testStoreFile(t))
+
+	rec := &TranscriptRecord{Path: "/tmp/rollout.jsonl", Agent: "codex", FileSize: 10}
+	turns := []TranscriptTurn{{Seq: 0, Role: "user", Text: "one"}}
+	if err := ts.Save(rec, turns); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	rec2 := &TranscriptRecord{Path: "/tmp/rollout.jsonl", Agent: "codex", FileSize: 20}
+	turns = append(turns, TranscriptTurn{Seq: 1, Role: "assistant", Text: "two",
+		ToolCalls: []TranscriptToolCall{{Name: "apply_patch", Files: []string{"/tmp/a.go"}}}})
+	if err := ts.Save(rec2, turns); err != nil {
+		t.Fatalf("re-Save: %v", err)
+	}
+
+	list, err := ts.List(TranscriptFilter{Agent: "codex"})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list) != 1 || list[0].FileSize != 20 || list[0].TurnCount != 2 {
+		t.Fatalf("List after re-save = %+v", list)
+	}
+	if old, _ := ts.Get(rec.ID); old != nil && rec.ID != rec2.ID {
+		t.Errorf("old record still present: %+v", old)
+	}
+	edits, err := ts.FileEdits(TranscriptFilter{})
+	if err != nil || len(edits) != 1 {
+		t.Errorf("FileEdits after re-save = %+v, %v", edits, err)
+	}
+	if missing, err := ts.Get(9999); err != nil || missing != nil {
+		t.Errorf("Get(missing) = %+v, %v", missing, err)
+	}
+}
@@ -3,7 +3,11 @@
 // by analyzing tmux pane activity and output patterns.
 package status
 
-import "time"
+import (
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/agent"
+)
 
 // AgentState represents the current state of an agent
 type AgentState string
@@ -17,6 +21,9 @@ const (
 	StateError AgentState = "error"
 	// StateUnknown indicates the state cannot be determined
 	StateUnknown AgentState = "unknown"
+	// StateAwaitingPermission indicates the agent is blocked on a
+	// tool-permission prompt (see agent.DetectPermissionPrompt)
+	StateAwaitingPermission AgentState = "awaiting_permission"
 )
 
 // Icon returns the visual indicator for a state
@@ -28,6 +35,8 @@ func (s AgentState) Icon() string {
 		return "\U0001f7e2" // green circle
 	case StateError:
 		return "\U0001f534" // red circle
+	case StateAwaitingPermission:
+		return "\U0001f7e1" // yellow circle
 	default:
 		return "\u26ab" // black circle
 	}
@@ -99,6 +108,8 @@ type AgentStatus struct {
 	ContextUsage float64 `json:"context_usage,omitempty"`
 	// TokensUsed is the estimated token count
 	TokensUsed int64 `json:"tokens_used,omitempty"`
+	// Permission is the prompt the agent waits on if State == StateAwaitingPermission
+	Permission *agent.PermissionRequest `json:"permission,omitempty"`
 	// UpdatedAt is when this status was computed
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -51,22 +51,35 @@ func (d *UnifiedDetector) Analyze(paneID, paneName, agentType string, output str
 	status.State = state
 	status.ErrorType = errType
 
-	// Extract metrics using agent parser
-	if isKnownAgentType(agentType) {
-		parser := agent.NewParser()
-		if parsed, err := parser.ParseWithHint(output, agent.AgentType(agentType)); err == nil {
-			if parsed.ContextRemaining != nil {
-				status.ContextUsage = 100.0 - *parsed.ContextRemaining
-			}
-			if parsed.TokensUsed != nil {
-				status.TokensUsed = *parsed.TokensUsed
-			}
-		}
-	}
+	applyAgentParse(&status, output)
 
 	return status
 }
 
+// applyAgentParse extracts metrics using the agent parser for known agent
+// types, and marks panes blocked on a tool-permission prompt.
+func applyAgentParse(status *AgentStatus, output string) {
+	if !isKnownAgentType(status.AgentType) {
+		return
+	}
+	parser := agent.NewParser()
+	parsed, err := parser.ParseWithHint(output, agent.AgentType(status.AgentType))
+	if err != nil {
+		return
+	}
+	if parsed.ContextRemaining != nil {
+		status.ContextUsage = 100.0 - *parsed.ContextRemaining
+	}
+	if parsed.TokensUsed != nil {
+		status.TokensUsed = *parsed.TokensUsed
+	}
+	if parsed.IsAwaitingPermission {
+		status.State = StateAwaitingPermission
+		status.ErrorType = ErrorNone
+		status.Permission = parsed.Permission
+	}
+}
+
 // determineState calculates state based on output and activity
 func (d *UnifiedDetector) determineState(output, agentType string, lastActivity time.Time) (AgentState, ErrorType) {
 	// Detection priority:
@@ -258,18 +271,7 @@ func (d *UnifiedDetector) Detect(paneID string) (AgentStatus, error) {
 	status.State = state
 	status.ErrorType = errType
 
-	// Extract metrics using agent parser
-	if isKnownAgentType(status.AgentType) {
-		parser := agent.NewParser()
-		if parsed, err := parser.ParseWithHint(output, agent.AgentType(status.AgentType)); err == nil {
-			if parsed.ContextRemaining != nil {
-				status.ContextUsage = 100.0 - *parsed.ContextRemaining
-			}
-			if parsed.TokensUsed != nil {
-				status.TokensUsed = *parsed.TokensUsed
-			}
-		}
-	}
+	applyAgentParse(&status, output)
 
 	return status, nil
 }
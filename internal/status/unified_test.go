@@ -941,3 +941,24 @@ func TestIsKnownAgentType(t *testing.T) {
 		})
 	}
 }
+
+func TestAnalyze_AwaitingPermission(t *testing.T) {
+	out := `• I need to run the migrations.
+
+▌ Allow command?
+▌
+▌ $ make migrate-up
+▌
+▌ › Yes   Always   No, provide feedback
+▌
+▌ Press Enter to confirm or Esc to cancel`
+	st := NewDetector().Analyze("%1", "proj__cod_1", "cod", out, time.Now().Add(-time.Minute))
+	if st.State != StateAwaitingPermission || st.Permission == nil || st.Permission.Command != "make migrate-up" {
+		t.Errorf("status = %+v", st)
+	}
+
+	// User panes are never treated as agents waiting on a prompt.
+	if st := NewDetector().Analyze("%2", "proj__user_1", "user", out, time.Now()); st.State == StateAwaitingPermission {
+		t.Errorf("user pane state = %s", st.State)
+	}
+}
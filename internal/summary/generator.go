@@ -13,6 +13,7 @@ import (
 
 	"github.com/shahbajlive/ntm/internal/agentmail"
 	"github.com/shahbajlive/ntm/internal/handoff"
+	"github.com/shahbajlive/ntm/internal/segment"
 	"github.com/shahbajlive/ntm/internal/util"
 	"gopkg.in/yaml.v3"
 )
@@ -42,6 +43,16 @@ type AgentOutput struct {
 	Output    string
 }
 
+// AgentActivity is what an agent did last, read from its segmented output.
+type AgentActivity struct {
+	AgentID      string `json:"agent_id"`
+	AgentType    string `json:"agent_type,omitempty"`
+	LastPrompt   string `json:"last_prompt,omitempty"`
+	LastToolCall string `json:"last_tool_call,omitempty"`
+	LastDiff     string `json:"last_diff,omitempty"`
+	LastError    string `json:"last_error,omitempty"`
+}
+
 // FileChange describes a file touched during the session.
 type FileChange struct {
 	Path    string `json:"path"`
@@ -61,6 +72,7 @@ type SessionSummary struct {
 	Errors          []string                  `json:"errors,omitempty"`
 	Decisions       []string                  `json:"decisions,omitempty"`
 	ThreadSummaries []agentmail.ThreadSummary `json:"thread_summaries,omitempty"`
+	Activity        []AgentActivity           `json:"activity,omitempty"`
 	TokenEstimate   int                       `json:"token_estimate"`
 	Text            string                    `json:"text"`
 	Handoff         *handoff.Handoff          `json:"handoff,omitempty"`
@@ -142,6 +154,7 @@ func SummarizeSession(ctx context.Context, opts Options) (*SessionSummary, error
 		Errors:          data.errors,
 		Decisions:       data.decisions,
 		ThreadSummaries: threadSummaries,
+		Activity:        data.activity,
 	}
 
 	// Optional LLM summarization for brief/detailed formats
@@ -183,6 +196,7 @@ type summaryData struct {
 	pending         []string
 	errors          []string
 	decisions       []string
+	activity        []AgentActivity
 }
 
 func aggregateOutputs(outputs []AgentOutput) summaryData {
@@ -193,6 +207,12 @@ func aggregateOutputs(outputs []AgentOutput) summaryData {
 			continue
 		}
 
+		blocks := segment.Parse(out.AgentType, text)
+		if act, ok := lastActivity(out, blocks); ok {
+			data.activity = append(data.activity, act)
+		}
+		data.files = mergeFileChanges(data.files, diffFileChanges(data.files, blocks))
+
 		structured := parseStructuredJSON(text)
 		data.accomplishments = appendUniqueList(data.accomplishments, structured.accomplishments)
 		data.changes = appendUniqueList(data.changes, structured.changes)
@@ -221,6 +241,45 @@ func aggregateOutputs(outputs []AgentOutput) summaryData {
 	return data
 }
 
+// lastActivity returns the agent's last prompt, tool call, diff and error,
+// if its output has any of them.
+func lastActivity(out AgentOutput, blocks []segment.Block) (AgentActivity, bool) {
+	latest := segment.Summarize(blocks)
+	act := AgentActivity{AgentID: out.AgentID, AgentType: out.AgentType}
+	if latest.Prompt != nil {
+		act.LastPrompt = latest.Prompt.Headline()
+	}
+	if latest.ToolCall != nil {
+		act.LastToolCall = latest.ToolCall.Headline()
+	}
+	if latest.Diff != nil {
+		act.LastDiff = latest.Diff.Headline()
+	}
+	if latest.Error != nil {
+		act.LastError = latest.Error.Headline()
+	}
+	ok := act.LastPrompt != "" || act.LastToolCall != "" || act.LastDiff != "" || act.LastError != ""
+	return act, ok
+}
+
+// diffFileChanges returns the files the output's diffs modified that are
+// not already in files.
+func diffFileChanges(files []FileChange, blocks []segment.Block) []FileChange {
+	known := make(map[string]bool, len(files))
+	for _, fc := range files {
+		known[fc.Path] = true
+	}
+	var changes []FileChange
+	for _, b := range segment.Filter(blocks, segment.KindDiff) {
+		if b.File == "" || known[b.File] {
+			continue
+		}
+		known[b.File] = true
+		changes = append(changes, FileChange{Path: b.File, Action: FileActionModified, Context: b.Headline()})
+	}
+	return changes
+}
+
 // Structured parsing
 
 var (
@@ -803,6 +862,7 @@ func formatDetailed(summary *SessionSummary) string {
 	writeSectionList(&sb, "Pending", summary.Pending)
 	writeSectionList(&sb, "Errors", summary.Errors)
 	writeSectionList(&sb, "Decisions", summary.Decisions)
+	writeSectionActivity(&sb, "Last Activity", summary.Activity)
 
 	if len(summary.ThreadSummaries) > 0 {
 		sb.WriteString("## Thread Summaries\n")
@@ -883,6 +943,15 @@ func buildHandoffSummary(summary *SessionSummary) *handoff.Handoff {
 		h.Blockers = appendUnique(h.Blockers, e)
 	}
 
+	for _, a := range summary.Activity {
+		if a.LastToolCall != "" {
+			h.AddFinding(a.AgentID+" last tool call", a.LastToolCall)
+		}
+		if a.LastDiff != "" {
+			h.AddFinding(a.AgentID+" last diff", a.LastDiff)
+		}
+	}
+
 	for _, fc := range summary.Files {
 		switch fc.Action {
 		case FileActionCreated:
@@ -962,6 +1031,30 @@ func writeSectionList(sb *strings.Builder, title string, items []string) {
 	sb.WriteString("\n")
 }
 
+func writeSectionActivity(sb *strings.Builder, title string, activity []AgentActivity) {
+	if len(activity) == 0 {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("## %s\n", title))
+	for _, a := range activity {
+		var parts []string
+		if a.LastToolCall != "" {
+			parts = append(parts, "tool: "+a.LastToolCall)
+		}
+		if a.LastDiff != "" {
+			parts = append(parts, "diff: "+a.LastDiff)
+		}
+		if a.LastError != "" {
+			parts = append(parts, "error: "+a.LastError)
+		}
+		if len(parts) == 0 {
+			parts = append(parts, "prompt: "+a.LastPrompt)
+		}
+		sb.WriteString(fmt.Sprintf("- %s: %s\n", a.AgentID, strings.Join(parts, "; ")))
+	}
+	sb.WriteString("\n")
+}
+
 func writeSectionFiles(sb *strings.Builder, title string, files []FileChange) {
 	if len(files) == 0 {
 		return
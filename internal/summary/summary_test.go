@@ -54,6 +54,40 @@ Some more text.
 	}
 }
 
+func TestAggregateOutputsActivity(t *testing.T) {
+	outputs := []AgentOutput{{
+		AgentID:   "cc_1",
+		AgentType: "cc",
+		Output: `> rename the handler
+
+⏺ Update(api/handler.go)
+  ⎿  Updated api/handler.go with 1 addition and 1 removal
+       8 -  func handle() {}
+       8 +  func serve() {}
+
+⏺ Bash(go test ./api)
+  ⎿  ok  example/api  0.2s
+`,
+	}}
+
+	data := aggregateOutputs(outputs)
+	if len(data.activity) != 1 {
+		t.Fatalf("activity = %+v", data.activity)
+	}
+	a := data.activity[0]
+	if a.LastPrompt != "rename the handler" || a.LastToolCall != "Bash go test ./api" || a.LastDiff != "api/handler.go +1 -1" {
+		t.Errorf("activity = %+v", a)
+	}
+	if len(data.files) != 1 || data.files[0].Path != "api/handler.go" || data.files[0].Action != FileActionModified {
+		t.Errorf("files = %+v", data.files)
+	}
+
+	h := buildHandoffSummary(&SessionSummary{Session: "s", Activity: data.activity})
+	if h.Findings["cc_1 last diff"] != "api/handler.go +1 -1" {
+		t.Errorf("handoff findings = %v", h.Findings)
+	}
+}
+
 func elementsMatch(a, b []string) bool {
 	if len(a) != len(b) {
 		return false
@@ -631,14 +631,7 @@ func DefaultSpecs() []DaemonSpec {
 			PortFlag:    "--port",
 			DefaultPort: 8200,
 		},
-		{
-			Name:        "am",
-			Command:     "am",
-			Args:        []string{"serve"},
-			HealthURL:   "http://127.0.0.1:8765/health/liveness",
-			PortFlag:    "--port",
-			DefaultPort: 8765,
-		},
+		agentMailSpec(),
 		{
 			Name:      "bd",
 			Command:   "bd",
@@ -647,3 +640,25 @@ func DefaultSpecs() []DaemonSpec {
 		},
 	}
 }
+
+// agentMailSpec runs the external Agent Mail server when "am" is installed
+// and otherwise falls back to ntm's built-in server ("ntm mail serve"),
+// which speaks the same protocol on the same port.
+func agentMailSpec() DaemonSpec {
+	spec := DaemonSpec{
+		Name:        "am",
+		Command:     "am",
+		Args:        []string{"serve"},
+		HealthURL:   "http://127.0.0.1:8765/health/liveness",
+		PortFlag:    "--port",
+		DefaultPort: 8765,
+	}
+	if _, err := exec.LookPath("am"); err == nil {
+		return spec
+	}
+	if exe, err := os.Executable(); err == nil {
+		spec.Command = exe
+		spec.Args = []string{"mail", "serve"}
+	}
+	return spec
+}
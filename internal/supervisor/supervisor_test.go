@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -385,6 +386,32 @@ func TestDefaultSpecs(t *testing.T) {
 	}
 }
 
+func TestAgentMailSpecFallback(t *testing.T) {
+	// Without "am" on PATH the built-in server is used on the same port.
+	t.Setenv("PATH", t.TempDir())
+	spec := agentMailSpec()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatalf("os.Executable: %v", err)
+	}
+	if spec.Command != exe || strings.Join(spec.Args, " ") != "mail serve" {
+		t.Errorf("fallback spec = %s %v, want %s mail serve", spec.Command, spec.Args, exe)
+	}
+	if spec.DefaultPort != 8765 || spec.PortFlag != "--port" {
+		t.Errorf("fallback port = %s %d", spec.PortFlag, spec.DefaultPort)
+	}
+
+	// With "am" installed it is preferred.
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "am"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+	if spec := agentMailSpec(); spec.Command != "am" || strings.Join(spec.Args, " ") != "serve" {
+		t.Errorf("spec with am installed = %s %v", spec.Command, spec.Args)
+	}
+}
+
 // TestHealthCheck tests the HTTP health check functionality
 func TestHealthCheck(t *testing.T) {
 	tmpDir := t.TempDir()
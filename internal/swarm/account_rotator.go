@@ -12,6 +12,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/shahbajlive/ntm/internal/quota"
 	"github.com/shahbajlive/ntm/internal/tools"
 )
 
@@ -46,7 +47,7 @@ type RotationRecord struct {
 	ToAccount      string        `json:"to_account"`
 	RotatedAt      time.Time     `json:"rotated_at"`
 	SessionPane    string        `json:"session_pane"`
-	TriggeredBy    string        `json:"triggered_by"` // "limit_hit", "manual"
+	TriggeredBy    string        `json:"triggered_by"` // "limit_hit", "forecast", "manual"
 	TriggerPattern string        `json:"trigger_pattern,omitempty"`
 	TimeSinceLast  time.Duration `json:"time_since_last,omitempty"`
 }
@@ -779,7 +780,89 @@ func (r *AccountRotator) OnLimitHit(event LimitHitEvent) (*RotationRecord, error
 		TriggerPattern: event.Pattern,
 	}
 
-	// Update per-pane state
+	r.recordPaneRotation(state, record)
+
+	r.logger().Info("[AccountRotator] rotation_complete_on_limit_hit",
+		"session_pane", event.SessionPane,
+		"from_account", record.FromAccount,
+		"to_account", record.ToAccount,
+		"total_rotations", state.RotationCount)
+
+	return record, nil
+}
+
+// OnForecast handles a forecast that an idle pane's account will soon reach
+// its usage limit by switching the provider to the candidate account with
+// the most headroom. If the provider has already moved off the forecast
+// account, for another pane, the pane follows it without a second switch.
+// Cooldown applies as for OnLimitHit. Returns an error, and leaves the
+// account alone, when no candidate has headroom.
+func (r *AccountRotator) OnForecast(event ForecastEvent) (*RotationRecord, error) {
+	r.mu.Lock()
+	state := r.getOrCreateState(event.SessionPane)
+	r.mu.Unlock()
+
+	r.logger().Info("[AccountRotator] forecast_received",
+		"session_pane", event.SessionPane,
+		"agent_type", event.AgentType,
+		"account", event.Forecast.Account,
+		"time_to_limit", event.Forecast.TimeToLimit,
+		"candidates", len(event.Candidates))
+
+	if r.isCooldownActive(state) {
+		return nil, fmt.Errorf("cooldown active for pane %s: %v remaining",
+			event.SessionPane, r.CooldownDuration-time.Since(state.LastRotation))
+	}
+	if !r.IsAvailable() {
+		return nil, fmt.Errorf("caam CLI not available")
+	}
+
+	provider := normalizeProvider(event.AgentType)
+	from := event.Forecast.Account
+	to := r.CurrentAccount(event.AgentType)
+	if to == "" || to == from {
+		target := quota.PickHeadroom(event.Candidates, from)
+		if target == nil {
+			return nil, fmt.Errorf("no %s account with headroom", provider)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), r.CommandTimeout)
+		defer cancel()
+		if _, stderr, err := r.runCaamCommand(ctx, "switch", target.Account); err != nil {
+			r.logger().Error("[AccountRotator] rotation_failed_on_forecast",
+				"session_pane", event.SessionPane,
+				"account", target.Account,
+				"error", err,
+				"stderr", stderr)
+			return nil, fmt.Errorf("caam switch failed: %w", err)
+		}
+		to = target.Account
+	}
+
+	record := &RotationRecord{
+		Provider:       provider,
+		AgentType:      event.AgentType,
+		Project:        event.Project,
+		FromAccount:    from,
+		ToAccount:      to,
+		RotatedAt:      time.Now(),
+		SessionPane:    event.SessionPane,
+		TriggeredBy:    "forecast",
+		TriggerPattern: event.Forecast.Summary(),
+	}
+	r.recordPaneRotation(state, record)
+
+	r.logger().Info("[AccountRotator] rotation_complete_on_forecast",
+		"session_pane", event.SessionPane,
+		"from_account", record.FromAccount,
+		"to_account", record.ToAccount)
+
+	return record, nil
+}
+
+// recordPaneRotation updates a pane's rotation state and the rotation
+// history with record.
+func (r *AccountRotator) recordPaneRotation(state *RotationState, record *RotationRecord) {
 	r.mu.Lock()
 	timeSinceLast := time.Duration(0)
 	if state.RotationCount > 0 && !state.LastRotation.IsZero() {
@@ -813,14 +896,6 @@ func (r *AccountRotator) OnLimitHit(event LimitHitEvent) (*RotationRecord, error
 			)
 		}
 	}
-
-	r.logger().Info("[AccountRotator] rotation_complete_on_limit_hit",
-		"session_pane", event.SessionPane,
-		"from_account", record.FromAccount,
-		"to_account", record.ToAccount,
-		"total_rotations", state.RotationCount)
-
-	return record, nil
 }
 
 func (r *AccountRotator) switchNext(ctx context.Context, provider string) (tools.SwitchResult, string, string, error) {
@@ -7,8 +7,11 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/shahbajlive/ntm/internal/quota"
 )
 
 func writeFakeCAAM(t *testing.T, dir, stateFile string) string {
@@ -712,6 +715,49 @@ func TestAccountRotatorSwitchAccount_UsesJSONSwitchResult(t *testing.T) {
 	}
 }
 
+func TestAccountRotatorOnForecast_SwitchesToHeadroom(t *testing.T) {
+	dir := t.TempDir()
+	stateFile := filepath.Join(dir, "state")
+	if err := os.WriteFile(stateFile, []byte("claude-a"), 0o644); err != nil {
+		t.Fatalf("write state: %v", err)
+	}
+
+	caamPath := writeFakeCAAM(t, dir, stateFile)
+	rotator := NewAccountRotator().WithCaamPath(caamPath)
+
+	event := ForecastEvent{
+		SessionPane: "test:1.1",
+		AgentType:   "cc",
+		DetectedAt:  time.Now(),
+		Forecast:    quota.Forecast{Account: "claude-a", Usage: 85, Basis: quota.BasisQuota, TimeToLimit: 10 * time.Minute, AtRisk: true},
+		Candidates: []quota.Forecast{
+			{Account: "claude-a", Usage: 85, Basis: quota.BasisQuota, TimeToLimit: 10 * time.Minute, AtRisk: true},
+			{Account: "claude-b", Usage: 5},
+		},
+	}
+	record, err := rotator.OnForecast(event)
+	if err != nil {
+		t.Fatalf("OnForecast error: %v", err)
+	}
+	if record.FromAccount != "claude-a" || record.ToAccount != "claude-b" {
+		t.Fatalf("rotation = %s -> %s, want claude-a -> claude-b", record.FromAccount, record.ToAccount)
+	}
+	if record.TriggeredBy != "forecast" || record.TriggerPattern != "limit in 10m" {
+		t.Errorf("trigger = %q %q", record.TriggeredBy, record.TriggerPattern)
+	}
+	if got, _ := os.ReadFile(stateFile); strings.TrimSpace(string(got)) != "claude-b" {
+		t.Errorf("active account = %q, want claude-b", got)
+	}
+
+	// Without an account with headroom nothing is switched.
+	event.SessionPane = "test:1.2"
+	event.Forecast.Account = "claude-b"
+	event.Candidates = event.Candidates[:1]
+	if _, err := rotator.OnForecast(event); err == nil || !contains(err.Error(), "headroom") {
+		t.Errorf("expected no headroom error, got %v", err)
+	}
+}
+
 func TestAccountRotatorListAvailableAccounts_FiltersRateLimited(t *testing.T) {
 	dir := t.TempDir()
 	stateFile := filepath.Join(dir, "state")
@@ -28,6 +28,10 @@ type AccountRotatorI interface {
 	// OnLimitHit handles a limit detection event and performs rotation with cooldown.
 	// Returns a rotation record or an error if rotation was skipped or failed.
 	OnLimitHit(event LimitHitEvent) (*RotationRecord, error)
+
+	// OnForecast moves a pane off an account forecast to reach its limit.
+	// Returns a rotation record or an error if rotation was skipped or failed.
+	OnForecast(event ForecastEvent) (*RotationRecord, error)
 }
 
 // ProjectPathLookup is a callback to resolve project path from session:pane.
@@ -383,8 +387,58 @@ func (r *AutoRespawner) recordRetryAttempt(sessionPane string) {
 
 // Respawn performs the full respawn sequence for an agent after a limit hit.
 func (r *AutoRespawner) Respawn(event LimitEvent) *RespawnResult {
-	sessionPane := event.SessionPane
-	agentType := event.AgentType
+	var rotate func() (*RotationRecord, error)
+	if r.Config.AutoRotateAccounts && r.AccountRotator != nil {
+		rotate = func() (*RotationRecord, error) {
+			return r.AccountRotator.OnLimitHit(LimitHitEvent{
+				SessionPane: event.SessionPane,
+				AgentType:   event.AgentType,
+				Pattern:     event.Pattern,
+				DetectedAt:  event.DetectedAt,
+				Project:     r.projectForPane(event.SessionPane),
+			})
+		}
+	}
+	return r.respawn(event.SessionPane, event.AgentType, rotate)
+}
+
+// Preempt moves an idle agent whose account is forecast to reach its usage
+// limit onto an account with headroom. The account is switched first and
+// the agent restarted only if that succeeds, so an agent is never stopped
+// without somewhere better to go. Callers are responsible for only
+// preempting agents at a natural pause.
+func (r *AutoRespawner) Preempt(event ForecastEvent) *RespawnResult {
+	if r.AccountRotator == nil {
+		return &RespawnResult{
+			SessionPane: event.SessionPane,
+			AgentType:   event.AgentType,
+			Error:       "no account rotator configured",
+			RespawnedAt: time.Now(),
+		}
+	}
+	if event.Project == "" {
+		event.Project = r.projectForPane(event.SessionPane)
+	}
+	record, err := r.AccountRotator.OnForecast(event)
+	if err != nil {
+		r.logger().Info("[AutoRespawner] preempt_skipped",
+			"session_pane", event.SessionPane,
+			"agent_type", event.AgentType,
+			"error", err)
+		return &RespawnResult{
+			SessionPane: event.SessionPane,
+			AgentType:   event.AgentType,
+			Error:       fmt.Sprintf("rotation skipped: %v", err),
+			RespawnedAt: time.Now(),
+		}
+	}
+	return r.respawn(event.SessionPane, event.AgentType, func() (*RotationRecord, error) {
+		return record, nil
+	})
+}
+
+// respawn kills, optionally rotates, and restarts the agent in sessionPane.
+func (r *AutoRespawner) respawn(sessionPane, agentType string, rotate func() (*RotationRecord, error)) *RespawnResult {
 	start := time.Now()
 	result := &RespawnResult{
 		SessionPane: sessionPane,
@@ -412,15 +466,8 @@ func (r *AutoRespawner) Respawn(event LimitEvent) *RespawnResult {
 	}
 
 	// Step 2: (Optional) Rotate account
-	if r.Config.AutoRotateAccounts && r.AccountRotator != nil {
-		hit := LimitHitEvent{
-			SessionPane: sessionPane,
-			AgentType:   agentType,
-			Pattern:     event.Pattern,
-			DetectedAt:  event.DetectedAt,
-			Project:     r.projectForPane(sessionPane),
-		}
-		record, err := r.AccountRotator.OnLimitHit(hit)
+	if rotate != nil {
+		record, err := rotate()
 		if err != nil {
 			r.logger().Warn("[AutoRespawner] account_rotation_failed",
 				"session_pane", sessionPane,
@@ -2,6 +2,7 @@ package swarm
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"testing"
@@ -143,6 +144,10 @@ func (m *mockAccountRotator) OnLimitHit(event LimitHitEvent) (*RotationRecord, e
 	}, nil
 }
 
+func (m *mockAccountRotator) OnForecast(event ForecastEvent) (*RotationRecord, error) {
+	return m.OnLimitHit(LimitHitEvent{SessionPane: event.SessionPane, AgentType: event.AgentType})
+}
+
 // mockTmuxClient implements a minimal mock for tmux.Client operations.
 type mockTmuxClient struct {
 	mu            sync.Mutex
@@ -489,6 +494,44 @@ func TestAutoRespawnerRespawnAccountRotationSetsResultFields(t *testing.T) {
 	}
 }
 
+func TestAutoRespawnerPreemptRotatesBeforeKill(t *testing.T) {
+	mock := &mockTmuxClient{
+		captureSeq: []string{
+			"user@host:~$ ",
+			"Codex> ",
+		},
+	}
+	ar := newMockAccountRotator()
+	ar.rotateErr = errors.New("no codex account with headroom")
+
+	r := NewAutoRespawner().WithTmuxClient(mock).WithAccountRotator(ar)
+	r.Config.ExitWaitTimeout = 20 * time.Millisecond
+	r.Config.ExitPollInterval = 1 * time.Millisecond
+	r.Config.ClearPaneDelay = 0
+
+	event := ForecastEvent{SessionPane: "test:1.1", AgentType: "cod", DetectedAt: time.Now()}
+
+	t.Log("[TEST] Preempt without a target account should leave the agent running")
+	result := r.Preempt(event)
+	if result.Success || result.Error == "" {
+		t.Fatalf("expected Preempt to fail, got %+v", result)
+	}
+	if len(mock.sendKeysCalls) != 0 {
+		t.Fatalf("expected no keys sent, got %d calls", len(mock.sendKeysCalls))
+	}
+
+	t.Log("[TEST] Preempt with a target account should respawn on it")
+	ar.rotateErr = nil
+	ar.nextAccount["cod"] = "account_99"
+	result = r.Preempt(event)
+	if !result.Success {
+		t.Fatalf("expected Preempt success, got error=%q", result.Error)
+	}
+	if !result.AccountRotated || result.NewAccount != "account_99" {
+		t.Errorf("rotation mismatch: rotated=%v new=%q", result.AccountRotated, result.NewAccount)
+	}
+}
+
 func TestAutoRespawnerRespawnSpawnFailureDoesNotEmitEvent(t *testing.T) {
 	mock := &mockTmuxClient{
 		captureSeq: []string{
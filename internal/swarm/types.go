@@ -1,6 +1,10 @@
 package swarm
 
-import "time"
+import (
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/quota"
+)
 
 // ProjectBeadCount represents a project and its open bead count.
 type ProjectBeadCount struct {
@@ -103,6 +107,17 @@ type LimitHitEvent struct {
 	Pattern     string    `json:"pattern"` // Which pattern matched
 }
 
+// ForecastEvent asks for an idle agent to be moved off an account that is
+// forecast to reach its usage limit soon.
+type ForecastEvent struct {
+	SessionPane string           `json:"session_pane"`
+	AgentType   string           `json:"agent_type"`
+	Project     string           `json:"project"`
+	DetectedAt  time.Time        `json:"detected_at"`
+	Forecast    quota.Forecast   `json:"forecast"`   // The account the agent is on
+	Candidates  []quota.Forecast `json:"candidates"` // The provider's other accounts
+}
+
 // RespawnEvent records agent respawns.
 type RespawnEvent struct {
 	SessionPane     string    `json:"session_pane"`
@@ -119,6 +119,37 @@ func (l *Loader) Load(name string) (*Template, error) {
 	return nil, &TemplateNotFoundError{Name: name}
 }
 
+// LoadPartial finds a template included with {{> name}}. A partials/
+// subdirectory is checked before the template directory itself, so shared
+// snippets can be kept out of 'ntm template list'.
+// Search order: project > user > builtin
+func (l *Loader) LoadPartial(name string) (*Template, error) {
+	name = strings.TrimSuffix(name, ".md")
+	for _, dir := range []struct {
+		path   string
+		source TemplateSource
+	}{{l.projectDir, SourceProject}, {l.userDir, SourceUser}} {
+		if dir.path == "" {
+			continue
+		}
+		for _, candidate := range []string{filepath.Join("partials", name), name} {
+			tmpl, err := l.loadFromDir(dir.path, candidate, dir.source)
+			if err == nil {
+				tmpl.Name = name
+				return tmpl, nil
+			}
+			if !os.IsNotExist(err) {
+				return nil, err
+			}
+		}
+	}
+
+	if tmpl := GetBuiltin(name); tmpl != nil {
+		return tmpl, nil
+	}
+	return nil, &TemplateNotFoundError{Name: name}
+}
+
 // loadFromDir attempts to load a template from a directory.
 func (l *Loader) loadFromDir(dir, name string, source TemplateSource) (*Template, error) {
 	path := filepath.Join(dir, name+".md")
@@ -28,6 +28,10 @@ var (
 //	    required: true
 //	---
 //	The template body with {{variable}} placeholders.
+//
+// Bodies may also use {{> partial}}, {{#each list}}...{{/each}},
+// {{^var}}...{{/var}}, filters such as {{var | default "x" | upper}}, and
+// live context providers such as {{@git_diff}}; see ExecuteWithReport.
 func Parse(content string) (*Template, error) {
 	tmpl := &Template{}
 
@@ -52,13 +56,15 @@ func Parse(content string) (*Template, error) {
 	return tmpl, nil
 }
 
-// Execute substitutes variables in the template body.
+// Execute renders the template body: partials, sections, loops, filters,
+// context providers and variable substitution.
 func (t *Template) Execute(ctx ExecutionContext) (string, error) {
-	// Validate required variables
-	if err := t.Validate(ctx); err != nil {
-		return "", err
-	}
+	out, _, err := t.ExecuteWithReport(ctx)
+	return out, err
+}
 
+// buildVars assembles the variables visible to the template body.
+func (t *Template) buildVars(ctx ExecutionContext) map[string]string {
 	// Build variable map: defaults < builtins < user vars < special vars
 	vars := make(map[string]string)
 
@@ -138,16 +144,7 @@ func (t *Template) Execute(ctx ExecutionContext) (string, error) {
 		vars["send_num"] = fmt.Sprintf("%d", ctx.SendIndex+1) // 1-indexed for human readability
 	}
 
-	// Perform substitution
-	result := t.Body
-
-	// First, expand conditionals {{#var}}...{{/var}}
-	result = expandConditionals(result, vars)
-
-	// Then, substitute simple variables {{var}}
-	result = substituteVariables(result, vars)
-
-	return result, nil
+	return vars
 }
 
 // substituteVariables replaces {{variable}} placeholders with values.
@@ -0,0 +1,401 @@
+package templates
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/prompt"
+	"github.com/shahbajlive/ntm/internal/tokens"
+)
+
+const (
+	// providerTimeout bounds git and file providers.
+	providerTimeout = 30 * time.Second
+	// testCommandTimeout bounds @test_failures when it runs the test command.
+	testCommandTimeout = 10 * time.Minute
+	// maxGlobFiles caps how many files a single @files glob can inject.
+	maxGlobFiles = 50
+
+	// TestOutputFile is where @test_failures looks for the last test run,
+	// relative to the working directory.
+	TestOutputFile = ".ntm/test-output.log"
+)
+
+// ProviderRequest is passed to a provider when a {{@name args}} tag is rendered.
+type ProviderRequest struct {
+	Args    []string
+	Budget  int // Token budget; 0 means unlimited
+	Context ExecutionContext
+}
+
+// Dir returns the directory providers run in.
+func (req ProviderRequest) Dir() string {
+	if req.Context.WorkDir != "" {
+		return req.Context.WorkDir
+	}
+	cwd, _ := os.Getwd()
+	return cwd
+}
+
+// Provider supplies live context that is resolved at render time, such as the
+// current git diff. Providers are referenced as {{@name}} or {{@name "arg"}}.
+type Provider struct {
+	Name        string
+	Description string
+	// Budget is the default token budget; templates override it per
+	// provider with context_budgets in their frontmatter.
+	Budget int
+	// KeepTail keeps the end of over-budget output instead of the start.
+	KeepTail bool
+	Resolve  func(req ProviderRequest) (string, error)
+}
+
+// DefaultProviders returns the providers that need nothing beyond the
+// working directory. Callers add session-aware providers (bead, cass) via
+// ExecutionContext.Providers.
+func DefaultProviders() map[string]Provider {
+	return map[string]Provider{
+		"git_diff": {
+			Name:        "git_diff",
+			Description: `Uncommitted changes (git diff HEAD); "staged" or a ref/path narrows it`,
+			Budget:      4000,
+			Resolve:     resolveGitDiff,
+		},
+		"test_failures": {
+			Name:        "test_failures",
+			Description: "Failures from the last test run (" + TestOutputFile + `), or from running the given command`,
+			Budget:      2000,
+			KeepTail:    true,
+			Resolve:     resolveTestFailures,
+		},
+		"files": {
+			Name:        "files",
+			Description: `Contents of files matching the given globs (supports **)`,
+			Budget:      6000,
+			Resolve:     resolveFiles,
+		},
+	}
+}
+
+// ProviderNames returns the sorted provider names in a set.
+func ProviderNames(providers map[string]Provider) []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func estimateTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+	return tokens.SmartEstimate(text)
+}
+
+// TrimToTokenBudget cuts text at line boundaries so its estimated token
+// count fits budget, noting how much was dropped. keepTail keeps the last
+// lines instead of the first. A budget of 0 or less disables trimming.
+func TrimToTokenBudget(text string, budget int, keepTail bool) (string, bool) {
+	if budget <= 0 || estimateTokens(text) <= budget {
+		return text, false
+	}
+
+	lines := strings.Split(text, "\n")
+	take := func(n int) string {
+		if keepTail {
+			return strings.Join(lines[len(lines)-n:], "\n")
+		}
+		return strings.Join(lines[:n], "\n")
+	}
+
+	// Largest line count that fits, leaving room for the marker.
+	limit := budget - 20
+	lo, hi := 0, len(lines)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if estimateTokens(take(mid)) <= limit {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+
+	kept := take(lo)
+	if lo == 0 {
+		// A single line is over budget: cut it by characters.
+		runes := []rune(lines[0])
+		if keepTail {
+			runes = []rune(lines[len(lines)-1])
+		}
+		n := len(runes)
+		for n > 0 && estimateTokens(string(runes[:n])) > limit {
+			n = n * 3 / 4
+		}
+		if keepTail {
+			kept = string(runes[len(runes)-n:])
+		} else {
+			kept = string(runes[:n])
+		}
+	}
+
+	marker := fmt.Sprintf("[... %d of %d lines trimmed to fit ~%d tokens ...]", len(lines)-lo, len(lines), budget)
+	if keepTail {
+		return marker + "\n" + kept, true
+	}
+	return kept + "\n" + marker, true
+}
+
+// resolveGitDiff runs git diff. Arguments naming an existing path are passed
+// after "--"; the rest are refs. Arguments that look like options are
+// refused so a template cannot make git write files or run helpers.
+func resolveGitDiff(req ProviderRequest) (string, error) {
+	args := []string{"diff"}
+	rest := req.Args
+	switch {
+	case len(rest) == 0:
+		args = append(args, "HEAD")
+	case rest[0] == "staged":
+		args = append(args, "--cached")
+		rest = rest[1:]
+	}
+
+	var paths []string
+	for _, arg := range rest {
+		if strings.HasPrefix(arg, "-") {
+			return "", fmt.Errorf("git_diff argument %q: options are not allowed", arg)
+		}
+		if _, err := os.Stat(filepath.Join(req.Dir(), arg)); err == nil {
+			paths = append(paths, arg)
+		} else {
+			args = append(args, arg)
+		}
+	}
+	if len(paths) > 0 {
+		args = append(append(args, "--"), paths...)
+	}
+
+	out, err := runProviderCommand(req.Dir(), providerTimeout, false, "git", args...)
+	if err != nil && len(req.Args) == 0 {
+		// No HEAD yet (fresh repository): fall back to the working tree diff.
+		out, err = runProviderCommand(req.Dir(), providerTimeout, false, "git", "diff")
+	}
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return out, nil
+}
+
+func resolveTestFailures(req ProviderRequest) (string, error) {
+	var output string
+	if argv := testCommandArgv(req.Args); len(argv) > 0 {
+		command := strings.Join(argv, " ")
+		out, err := runProviderCommand(req.Dir(), testCommandTimeout, true, argv[0], argv[1:]...)
+		if err == nil {
+			return "", nil // Tests passed: nothing to report.
+		}
+		if _, ok := err.(*exec.ExitError); !ok {
+			return "", fmt.Errorf("running %q: %w", command, err)
+		}
+		output = out
+	} else {
+		logPath := filepath.Join(req.Dir(), TestOutputFile)
+		data, err := os.ReadFile(logPath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return "", fmt.Errorf("no test output at %s (tee your test run there, or pass a command: {{@test_failures \"go test ./...\"}})", TestOutputFile)
+			}
+			return "", err
+		}
+		output = string(data)
+	}
+	return ExtractTestFailures(output), nil
+}
+
+// testCommandArgv turns @test_failures arguments into the argv to execute.
+// The command runs without a shell, so pipes, redirects and substitutions
+// are passed through as literal arguments. A single argument is split on
+// whitespace; several arguments are used as-is, for words that contain
+// spaces: {{@test_failures "go" "test" "-run" "TestA|TestB" "./..."}}.
+func testCommandArgv(args []string) []string {
+	if len(args) == 1 {
+		return strings.Fields(args[0])
+	}
+	return args
+}
+
+// ExtractTestFailures reduces test output to its failures. Go test output is
+// cut down to failing tests, panics and FAIL lines; output in other formats
+// is returned unchanged for budget trimming to handle.
+func ExtractTestFailures(output string) string {
+	var kept []string
+	inFailure, inPanic := false, false
+	sc := bufio.NewScanner(strings.NewReader(output))
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "FAIL"):
+			inFailure, inPanic = false, false
+			kept = append(kept, line)
+		case strings.HasPrefix(line, "panic:"):
+			inPanic = true
+			kept = append(kept, line)
+		case inPanic:
+			// Stack traces run until the package FAIL line.
+			kept = append(kept, line)
+		case strings.HasPrefix(strings.TrimSpace(line), "--- FAIL"):
+			inFailure = true
+			kept = append(kept, line)
+		case inFailure && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")):
+			kept = append(kept, line)
+		default:
+			inFailure = false
+		}
+	}
+	if len(kept) == 0 {
+		return output
+	}
+	return strings.Join(kept, "\n")
+}
+
+func resolveFiles(req ProviderRequest) (string, error) {
+	if len(req.Args) == 0 {
+		return "", fmt.Errorf(`@files needs at least one glob, e.g. {{@files "internal/**/*.go"}}`)
+	}
+	dir := req.Dir()
+
+	var matches []string
+	seen := make(map[string]bool)
+	for _, pattern := range req.Args {
+		found, err := globFiles(dir, pattern)
+		if err != nil {
+			return "", err
+		}
+		for _, m := range found {
+			if !seen[m] {
+				seen[m] = true
+				matches = append(matches, m)
+			}
+		}
+	}
+	if len(matches) == 0 {
+		return "", fmt.Errorf("no files match %s", strings.Join(req.Args, " "))
+	}
+
+	// Whole files are dropped once the budget is spent so no code fence is
+	// cut in half.
+	var blocks, skipped []string
+	used := 0
+	for i, rel := range matches {
+		if i >= maxGlobFiles {
+			skipped = append(skipped, matches[i:]...)
+			break
+		}
+		block, err := prompt.InjectFiles([]prompt.FileSpec{{Path: filepath.Join(dir, rel)}}, "")
+		if err != nil {
+			skipped = append(skipped, rel)
+			continue
+		}
+		block = strings.TrimSuffix(block, "\n\n---\n\n")
+		block = strings.Replace(block, "# File: "+filepath.Join(dir, rel), "# File: "+rel, 1)
+		n := estimateTokens(block)
+		if req.Budget > 0 && used+n > req.Budget && len(blocks) > 0 {
+			skipped = append(skipped, rel)
+			continue
+		}
+		used += n
+		blocks = append(blocks, block)
+	}
+	if len(skipped) > 0 {
+		blocks = append(blocks, fmt.Sprintf("[... %d file(s) omitted: %s]", len(skipped), strings.Join(skipped, ", ")))
+	}
+	return strings.Join(blocks, "\n\n"), nil
+}
+
+// globFiles returns regular files under dir matching pattern, relative to
+// dir. Unlike filepath.Glob, "**" matches any number of directories.
+func globFiles(dir, pattern string) ([]string, error) {
+	pattern = filepath.ToSlash(filepath.Clean(pattern))
+	if strings.HasPrefix(pattern, "../") || path.IsAbs(pattern) {
+		return nil, fmt.Errorf("glob %q must stay inside %s", pattern, dir)
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid glob %q: %w", pattern, err)
+	}
+
+	var matches []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, p)
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if rel != "." && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules" || d.Name() == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() && matchGlob(strings.Split(pattern, "/"), strings.Split(rel, "/")) {
+			matches = append(matches, rel)
+		}
+		return nil
+	})
+	sort.Strings(matches)
+	return matches, err
+}
+
+func matchGlob(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchGlob(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	ok, _ := path.Match(pattern[0], parts[0])
+	return ok && matchGlob(pattern[1:], parts[1:])
+}
+
+// runProviderCommand runs a command in dir. With combined set, stderr is
+// interleaved into the returned output (test runners report failures there).
+func runProviderCommand(dir string, timeout time.Duration, combined bool, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if combined {
+		cmd.Stderr = &out
+	}
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return out.String(), fmt.Errorf("timed out after %s", timeout)
+	}
+	if err != nil && !combined {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out.String(), err
+}
@@ -0,0 +1,213 @@
+package templates
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTrimToTokenBudget(t *testing.T) {
+	text := strings.Repeat("some fairly ordinary words on a line\n", 200)
+
+	if out, trimmed := TrimToTokenBudget(text, 0, false); trimmed || out != text {
+		t.Error("budget 0 should not trim")
+	}
+	if out, trimmed := TrimToTokenBudget("short", 100, false); trimmed || out != "short" {
+		t.Error("text under budget should not be trimmed")
+	}
+
+	head, trimmed := TrimToTokenBudget(text, 100, false)
+	if !trimmed || estimateTokens(head) > 100 || !strings.HasPrefix(head, "some") || !strings.Contains(head, "trimmed to fit ~100 tokens") {
+		t.Errorf("head trim = %q", head)
+	}
+	tail, _ := TrimToTokenBudget("first\n"+text+"last", 100, true)
+	if !strings.HasPrefix(tail, "[...") || !strings.HasSuffix(tail, "last") || estimateTokens(tail) > 100 {
+		t.Errorf("tail trim = %q", tail)
+	}
+
+	long, _ := TrimToTokenBudget(strings.Repeat("x", 10000), 50, false)
+	if estimateTokens(long) > 50 {
+		t.Errorf("single long line not cut: %d tokens", estimateTokens(long))
+	}
+}
+
+func TestExtractTestFailures(t *testing.T) {
+	output := `=== RUN   TestOK
+--- PASS: TestOK (0.00s)
+=== RUN   TestBad
+    bad_test.go:12: got 1, want 2
+--- FAIL: TestBad (0.00s)
+    bad_test.go:14: second failure
+    --- FAIL: TestBad/sub (0.00s)
+        bad_test.go:20: sub failure
+ok  	example.com/other	0.1s
+FAIL
+FAIL	example.com/pkg	0.2s`
+
+	got := ExtractTestFailures(output)
+	for _, want := range []string{"--- FAIL: TestBad", "second failure", "sub failure", "FAIL\texample.com/pkg"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "TestOK") || strings.Contains(got, "example.com/other") {
+		t.Errorf("passing output kept:\n%s", got)
+	}
+
+	panicOut := "panic: boom\n\ngoroutine 1 [running]:\nmain.f()\n\t/x/main.go:3\nFAIL\tpkg\t0.1s"
+	if got := ExtractTestFailures(panicOut); got != panicOut {
+		t.Errorf("panic trace not kept:\n%s", got)
+	}
+
+	other := "  1 failing\n  AssertionError: expected 1"
+	if got := ExtractTestFailures(other); got != other {
+		t.Errorf("non-Go output should pass through, got %q", got)
+	}
+}
+
+func TestResolveTestFailures(t *testing.T) {
+	dir := t.TempDir()
+	req := ProviderRequest{Context: ExecutionContext{WorkDir: dir}}
+
+	if _, err := resolveTestFailures(req); err == nil || !strings.Contains(err.Error(), TestOutputFile) {
+		t.Errorf("expected missing log error, got %v", err)
+	}
+
+	writeFile(t, filepath.Join(dir, TestOutputFile), "--- FAIL: TestX (0s)\n    x_test.go:1: nope\nFAIL\n", 0644)
+	if got, err := resolveTestFailures(req); err != nil || !strings.Contains(got, "x_test.go:1: nope") {
+		t.Errorf("log = %q, %v", got, err)
+	}
+
+	req.Args = []string{"true"}
+	if got, err := resolveTestFailures(req); err != nil || got != "" {
+		t.Errorf("passing command = %q, %v", got, err)
+	}
+	writeFile(t, filepath.Join(dir, "fail.sh"), "echo '--- FAIL: TestY (0s)'\necho \"$1\"\nexit 1\n", 0755)
+	req.Args = []string{"sh", "fail.sh", "a; touch pwned"}
+	if got, err := resolveTestFailures(req); err != nil || !strings.Contains(got, "TestY") {
+		t.Errorf("failing command = %q, %v", got, err)
+	}
+
+	// Shell syntax is not interpreted.
+	req.Args = []string{"true ; touch pwned"}
+	if _, err := resolveTestFailures(req); err != nil {
+		t.Errorf("command with metacharacters: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pwned")); !os.IsNotExist(err) {
+		t.Error("test command was run through a shell")
+	}
+}
+
+func TestResolveFiles(t *testing.T) {
+	dir := t.TempDir()
+	for path, content := range map[string]string{
+		"main.go":              "package main",
+		"internal/a/a.go":      "package a",
+		"internal/a/b/b.go":    "package b",
+		"internal/a/notes.txt": "notes",
+		".git/config.go":       "hidden",
+	} {
+		writeFile(t, filepath.Join(dir, path), content, 0644)
+	}
+
+	matches, err := globFiles(dir, "internal/**/*.go")
+	if err != nil || strings.Join(matches, ",") != "internal/a/a.go,internal/a/b/b.go" {
+		t.Errorf("globFiles = %v, %v", matches, err)
+	}
+	if matches, _ := globFiles(dir, "**/*.go"); len(matches) != 3 {
+		t.Errorf("**/*.go = %v (hidden dirs should be skipped)", matches)
+	}
+	if _, err := globFiles(dir, "../*.go"); err == nil {
+		t.Error("expected error for glob escaping the directory")
+	}
+
+	req := ProviderRequest{Args: []string{"main.go", "internal/**/*.go"}, Context: ExecutionContext{WorkDir: dir}}
+	got, err := resolveFiles(req)
+	if err != nil {
+		t.Fatalf("resolveFiles: %v", err)
+	}
+	if !strings.Contains(got, "# File: main.go\n```go\npackage main\n```") || !strings.Contains(got, "# File: internal/a/b/b.go") {
+		t.Errorf("resolveFiles output:\n%s", got)
+	}
+	if strings.Contains(got, "---") {
+		t.Errorf("prompt separator leaked into output:\n%s", got)
+	}
+
+	req.Budget = 1
+	if got, _ := resolveFiles(req); !strings.Contains(got, "2 file(s) omitted") {
+		t.Errorf("budget should drop whole files:\n%s", got)
+	}
+
+	req.Args = []string{"*.rs"}
+	if _, err := resolveFiles(req); err == nil {
+		t.Error("expected error when nothing matches")
+	}
+}
+
+func TestResolveGitDiff(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.email=t@example.com", "-c", "user.name=t"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	writeFile(t, filepath.Join(dir, "f.txt"), "one\n", 0644)
+	req := ProviderRequest{Context: ExecutionContext{WorkDir: dir}}
+
+	// No commits yet: falls back to the working tree diff.
+	if _, err := resolveGitDiff(req); err != nil {
+		t.Errorf("fresh repo: %v", err)
+	}
+
+	git("add", ".")
+	git("commit", "-q", "-m", "init")
+	writeFile(t, filepath.Join(dir, "f.txt"), "two\n", 0644)
+	got, err := resolveGitDiff(req)
+	if err != nil || !strings.Contains(got, "+two") {
+		t.Errorf("git diff = %q, %v", got, err)
+	}
+
+	req.Args = []string{"staged"}
+	if got, err := resolveGitDiff(req); err != nil || got != "" {
+		t.Errorf("staged diff = %q, %v", got, err)
+	}
+
+	// Existing paths go after "--"; other arguments are refs.
+	writeFile(t, filepath.Join(dir, "g.txt"), "other\n", 0644)
+	git("add", "g.txt")
+	req.Args = []string{"HEAD", "f.txt"}
+	if got, err := resolveGitDiff(req); err != nil || !strings.Contains(got, "+two") || strings.Contains(got, "g.txt") {
+		t.Errorf("diff of f.txt = %q, %v", got, err)
+	}
+
+	// Options are never passed through to git.
+	out := filepath.Join(dir, "written")
+	for _, args := range [][]string{{"--output=" + out}, {"staged", "-U1000"}} {
+		req.Args = args
+		if _, err := resolveGitDiff(req); err == nil {
+			t.Errorf("resolveGitDiff(%q) accepted an option", args)
+		}
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Error("git diff wrote --output")
+	}
+}
+
+func writeFile(t *testing.T, path, content string, perm os.FileMode) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), perm); err != nil {
+		t.Fatal(err)
+	}
+}
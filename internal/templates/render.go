@@ -0,0 +1,561 @@
+package templates
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxPartialDepth bounds {{> partial}} nesting.
+const maxPartialDepth = 8
+
+// identRe matches names usable in variable, section and loop tags.
+// "." refers to the current loop item; @-names are loop metadata or providers.
+var identRe = regexp.MustCompile(`^(\.|@?[a-zA-Z_][a-zA-Z0-9_]*)$`)
+
+// PartialLoader resolves {{> name}} includes.
+type PartialLoader interface {
+	LoadPartial(name string) (*Template, error)
+}
+
+// builtinPartials resolves partials against builtin templates only.
+type builtinPartials struct{}
+
+func (builtinPartials) LoadPartial(name string) (*Template, error) {
+	if tmpl := GetBuiltin(name); tmpl != nil {
+		return tmpl, nil
+	}
+	return nil, &TemplateNotFoundError{Name: name}
+}
+
+// RenderReport describes what went into a rendered template.
+type RenderReport struct {
+	Partials  []string        `json:"partials,omitempty"`
+	Providers []ProviderUsage `json:"providers,omitempty"`
+	Tokens    int             `json:"tokens"`
+}
+
+// ProviderUsage records one context provider resolution.
+type ProviderUsage struct {
+	Name    string   `json:"name"`
+	Args    []string `json:"args,omitempty"`
+	Tokens  int      `json:"tokens"`
+	Budget  int      `json:"budget,omitempty"`
+	Trimmed bool     `json:"trimmed,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// loopScope is the innermost {{#each}} iteration.
+type loopScope struct {
+	item  string
+	index int
+	total int
+}
+
+type renderer struct {
+	tmpl      *Template
+	ctx       ExecutionContext
+	vars      map[string]string
+	providers map[string]Provider
+	partials  PartialLoader
+	report    *RenderReport
+	stack     []string
+	resolved  map[string]string
+}
+
+// ExecuteWithReport renders the template like Execute and also reports the
+// partials and context providers that were used.
+func (t *Template) ExecuteWithReport(ctx ExecutionContext) (string, *RenderReport, error) {
+	if err := t.Validate(ctx); err != nil {
+		return "", nil, err
+	}
+
+	r := &renderer{
+		tmpl:      t,
+		ctx:       ctx,
+		vars:      t.buildVars(ctx),
+		providers: DefaultProviders(),
+		partials:  ctx.Partials,
+		report:    &RenderReport{},
+		stack:     []string{t.Name},
+		resolved:  make(map[string]string),
+	}
+	for name, p := range ctx.Providers {
+		r.providers[name] = p
+	}
+	if r.partials == nil {
+		r.partials = builtinPartials{}
+	}
+
+	out, err := r.render(t.Body, nil)
+	if err != nil {
+		if t.Name != "" {
+			return "", nil, fmt.Errorf("template %s: %w", t.Name, err)
+		}
+		return "", nil, err
+	}
+	r.report.Tokens = estimateTokens(out)
+	return out, r.report, nil
+}
+
+// render expands every tag in body. Tags that do not parse are left as-is.
+func (r *renderer) render(body string, scope *loopScope) (string, error) {
+	var sb strings.Builder
+	pos := 0
+	for {
+		start := strings.Index(body[pos:], "{{")
+		if start < 0 {
+			sb.WriteString(body[pos:])
+			break
+		}
+		start += pos
+		end := strings.Index(body[start+2:], "}}")
+		if end < 0 {
+			sb.WriteString(body[pos:])
+			break
+		}
+		end += start + 2
+		sb.WriteString(body[pos:start])
+		tagEnd := end + 2
+		raw := body[start:tagEnd]
+		tag := strings.TrimSpace(body[start+2 : end])
+
+		switch {
+		case strings.HasPrefix(tag, "!"):
+			// Comment.
+		case strings.HasPrefix(tag, ">"):
+			out, err := r.renderPartial(strings.TrimSpace(tag[1:]), scope)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(out)
+		case strings.HasPrefix(tag, "#each "):
+			name := strings.TrimSpace(tag[len("#each "):])
+			inner, next, ok := findSectionEnd(body, tagEnd, "{{/each}}", "{{#each ")
+			if !ok || !identRe.MatchString(name) {
+				sb.WriteString(raw)
+				break
+			}
+			out, err := r.renderEach(name, inner, scope)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(out)
+			tagEnd = next
+		case strings.HasPrefix(tag, "#") || strings.HasPrefix(tag, "^"):
+			name := strings.TrimSpace(tag[1:])
+			if !identRe.MatchString(name) {
+				sb.WriteString(raw)
+				break
+			}
+			inner, next, ok := findSectionEnd(body, tagEnd, "{{/"+name+"}}", "{{#"+name+"}}", "{{^"+name+"}}")
+			if !ok {
+				sb.WriteString(raw)
+				break
+			}
+			val, _, err := r.lookup(name, nil, scope)
+			if err != nil {
+				return "", err
+			}
+			if (strings.TrimSpace(val) != "") == (tag[0] == '#') {
+				out, err := r.render(inner, scope)
+				if err != nil {
+					return "", err
+				}
+				sb.WriteString(out)
+			}
+			tagEnd = next
+		default:
+			out, ok, err := r.evalExpr(tag, scope)
+			if err != nil {
+				return "", err
+			}
+			if ok {
+				sb.WriteString(out)
+			} else {
+				sb.WriteString(raw)
+			}
+		}
+		pos = tagEnd
+	}
+	return sb.String(), nil
+}
+
+// findSectionEnd finds the close tag matching an open tag that ends at from,
+// skipping nested sections opened by any of opens. It returns the section
+// body and the offset just past the close tag.
+func findSectionEnd(body string, from int, close string, opens ...string) (string, int, bool) {
+	depth := 1
+	pos := from
+	for {
+		c := strings.Index(body[pos:], close)
+		if c < 0 {
+			return "", 0, false
+		}
+		c += pos
+		for _, open := range opens {
+			depth += strings.Count(body[pos:c], open)
+		}
+		depth--
+		if depth == 0 {
+			return body[from:c], c + len(close), true
+		}
+		pos = c + len(close)
+	}
+}
+
+func (r *renderer) renderPartial(name string, scope *loopScope) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("empty partial name")
+	}
+	for _, n := range r.stack {
+		if n == name {
+			return "", fmt.Errorf("partial cycle: %s -> %s", strings.Join(r.stack, " -> "), name)
+		}
+	}
+	if len(r.stack) > maxPartialDepth {
+		return "", fmt.Errorf("partials nested deeper than %d", maxPartialDepth)
+	}
+	partial, err := r.partials.LoadPartial(name)
+	if err != nil {
+		return "", fmt.Errorf("partial %s: %w", name, err)
+	}
+	for _, v := range partial.Variables {
+		if _, ok := r.vars[v.Name]; !ok && v.Default != "" {
+			r.vars[v.Name] = v.Default
+		}
+	}
+	r.report.Partials = appendUnique(r.report.Partials, name)
+
+	r.stack = append(r.stack, name)
+	defer func() { r.stack = r.stack[:len(r.stack)-1] }()
+	return r.render(partial.Body, scope)
+}
+
+func (r *renderer) renderEach(name, inner string, scope *loopScope) (string, error) {
+	var items []string
+	if list, ok := r.ctx.Lists[name]; ok {
+		items = list
+	} else {
+		val, _, err := r.lookup(name, nil, scope)
+		if err != nil {
+			return "", err
+		}
+		items = SplitList(val)
+	}
+
+	var sb strings.Builder
+	for i, item := range items {
+		out, err := r.render(inner, &loopScope{item: item, index: i, total: len(items)})
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(out)
+	}
+	return sb.String(), nil
+}
+
+// lookup resolves a name to its value. ok is false for unknown variables.
+func (r *renderer) lookup(name string, args []string, scope *loopScope) (string, bool, error) {
+	if scope != nil {
+		switch name {
+		case ".", "this":
+			return scope.item, true, nil
+		case "@index":
+			return strconv.Itoa(scope.index), true, nil
+		case "@number":
+			return strconv.Itoa(scope.index + 1), true, nil
+		case "@first":
+			return boolString(scope.index == 0), true, nil
+		case "@last":
+			return boolString(scope.index == scope.total-1), true, nil
+		}
+	}
+	if strings.HasPrefix(name, "@") {
+		val, err := r.resolveProvider(name[1:], args)
+		return val, true, err
+	}
+	val, ok := r.vars[name]
+	return val, ok, nil
+}
+
+// evalExpr evaluates "name args... | filter args | ...". ok is false when the
+// tag is not an expression this package understands, so it is kept verbatim.
+func (r *renderer) evalExpr(expr string, scope *loopScope) (string, bool, error) {
+	stages := splitPipeline(expr)
+	head, err := tokenize(stages[0])
+	if err != nil || len(head) == 0 || head[0].quoted || !identRe.MatchString(head[0].text) {
+		return "", false, nil
+	}
+	name := head[0].text
+	if len(head) > 1 && !strings.HasPrefix(name, "@") {
+		return "", false, nil
+	}
+
+	args := r.argValues(head[1:], scope)
+	val, ok, err := r.lookup(name, args, scope)
+	if err != nil {
+		return "", false, err
+	}
+	if !ok && len(stages) == 1 {
+		return "", false, nil
+	}
+
+	for _, stage := range stages[1:] {
+		toks, err := tokenize(stage)
+		if err != nil {
+			return "", false, fmt.Errorf("filter %q: %w", strings.TrimSpace(stage), err)
+		}
+		if len(toks) == 0 {
+			return "", false, fmt.Errorf("empty filter in {{%s}}", expr)
+		}
+		val, err = applyFilter(toks[0].text, r.argValues(toks[1:], scope), val)
+		if err != nil {
+			return "", false, err
+		}
+	}
+	return val, true, nil
+}
+
+// argValues resolves bare arguments that name a variable; quoted arguments
+// and unknown names are used literally.
+func (r *renderer) argValues(toks []token, scope *loopScope) []string {
+	out := make([]string, 0, len(toks))
+	for _, tok := range toks {
+		if !tok.quoted && identRe.MatchString(tok.text) && !strings.HasPrefix(tok.text, "@") {
+			if v, ok, _ := r.lookup(tok.text, nil, scope); ok {
+				out = append(out, v)
+				continue
+			}
+		}
+		out = append(out, tok.text)
+	}
+	return out
+}
+
+func (r *renderer) resolveProvider(name string, args []string) (string, error) {
+	p, ok := r.providers[name]
+	if !ok {
+		return "", fmt.Errorf("unknown context provider @%s (available: %s)", name, strings.Join(ProviderNames(r.providers), ", "))
+	}
+
+	key := name + "\x00" + strings.Join(args, "\x00")
+	if val, ok := r.resolved[key]; ok {
+		return val, nil
+	}
+
+	budget := p.Budget
+	if b, ok := r.tmpl.ContextBudgets[name]; ok {
+		budget = b
+	}
+	usage := ProviderUsage{Name: name, Args: args, Budget: budget}
+
+	val, err := p.Resolve(ProviderRequest{Args: args, Budget: budget, Context: r.ctx})
+	if err != nil {
+		// Live context is best effort: a missing tool or empty repo should
+		// not block the prompt. The report carries the error.
+		usage.Error = err.Error()
+		val = ""
+	}
+	val = strings.TrimRight(val, "\n")
+	val, usage.Trimmed = TrimToTokenBudget(val, budget, p.KeepTail)
+	usage.Tokens = estimateTokens(val)
+
+	r.report.Providers = append(r.report.Providers, usage)
+	r.resolved[key] = val
+	return val, nil
+}
+
+// SplitList splits a variable value into list items for {{#each}}: one item
+// per line, or per comma for single-line values. Items are trimmed and empty
+// items dropped.
+func SplitList(val string) []string {
+	sep := "\n"
+	if !strings.Contains(val, "\n") {
+		sep = ","
+	}
+	var items []string
+	for _, item := range strings.Split(val, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Filters lists the filters available in {{var | filter}} expressions.
+var Filters = []string{"default", "upper", "lower", "trim", "indent", "truncate", "head", "tail", "join", "code", "budget"}
+
+func applyFilter(name string, args []string, val string) (string, error) {
+	intArg := func(def int) (int, error) {
+		if len(args) == 0 {
+			return def, nil
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("filter %s: invalid number %q", name, args[0])
+		}
+		return n, nil
+	}
+	strArg := func(def string) string {
+		if len(args) == 0 {
+			return def
+		}
+		return args[0]
+	}
+
+	switch name {
+	case "default":
+		if strings.TrimSpace(val) == "" {
+			return strArg(""), nil
+		}
+		return val, nil
+	case "upper":
+		return strings.ToUpper(val), nil
+	case "lower":
+		return strings.ToLower(val), nil
+	case "trim":
+		return strings.TrimSpace(val), nil
+	case "indent":
+		n, err := intArg(2)
+		if err != nil {
+			return "", err
+		}
+		pad := strings.Repeat(" ", n)
+		lines := strings.Split(val, "\n")
+		for i, line := range lines {
+			if line != "" {
+				lines[i] = pad + line
+			}
+		}
+		return strings.Join(lines, "\n"), nil
+	case "truncate":
+		n, err := intArg(80)
+		if err != nil {
+			return "", err
+		}
+		if utf8.RuneCountInString(val) <= n {
+			return val, nil
+		}
+		return string([]rune(val)[:n]) + "...", nil
+	case "head", "tail":
+		n, err := intArg(10)
+		if err != nil {
+			return "", err
+		}
+		lines := strings.Split(val, "\n")
+		if len(lines) <= n {
+			return val, nil
+		}
+		if name == "head" {
+			return strings.Join(lines[:n], "\n"), nil
+		}
+		return strings.Join(lines[len(lines)-n:], "\n"), nil
+	case "join":
+		return strings.Join(SplitList(val), strArg(", ")), nil
+	case "code":
+		if strings.TrimSpace(val) == "" {
+			return "", nil
+		}
+		return "```" + strArg("") + "\n" + val + "\n```", nil
+	case "budget":
+		n, err := intArg(0)
+		if err != nil {
+			return "", err
+		}
+		out, _ := TrimToTokenBudget(val, n, false)
+		return out, nil
+	default:
+		return "", fmt.Errorf("unknown filter %q (available: %s)", name, strings.Join(Filters, ", "))
+	}
+}
+
+type token struct {
+	text   string
+	quoted bool
+}
+
+// tokenize splits on whitespace, honouring "double" and 'single' quotes.
+func tokenize(s string) ([]token, error) {
+	var toks []token
+	i := 0
+	for i < len(s) {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '"' || c == '\'':
+			var sb strings.Builder
+			j := i + 1
+			for ; j < len(s) && s[j] != c; j++ {
+				if s[j] == '\\' && j+1 < len(s) {
+					j++
+					switch s[j] {
+					case 'n':
+						sb.WriteByte('\n')
+					case 't':
+						sb.WriteByte('\t')
+					default:
+						sb.WriteByte(s[j])
+					}
+					continue
+				}
+				sb.WriteByte(s[j])
+			}
+			if j >= len(s) {
+				return nil, fmt.Errorf("unterminated quote in %q", s)
+			}
+			toks = append(toks, token{text: sb.String(), quoted: true})
+			i = j + 1
+		default:
+			j := i
+			for j < len(s) && s[j] != ' ' && s[j] != '\t' {
+				j++
+			}
+			toks = append(toks, token{text: s[i:j]})
+			i = j
+		}
+	}
+	return toks, nil
+}
+
+// splitPipeline splits an expression on '|' outside quotes.
+func splitPipeline(expr string) []string {
+	var parts []string
+	var quote byte
+	last := 0
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '|':
+			parts = append(parts, expr[last:i])
+			last = i + 1
+		}
+	}
+	return append(parts, expr[last:])
+}
+
+func boolString(b bool) string {
+	if b {
+		return "true"
+	}
+	return ""
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
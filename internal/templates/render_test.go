@@ -0,0 +1,258 @@
+package templates
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExecute_TemplateSyntax(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		vars  map[string]string
+		lists map[string][]string
+		want  string
+	}{
+		{
+			name: "each over lines",
+			body: "{{#each files}}- {{.}}\n{{/each}}",
+			vars: map[string]string{"files": "a.go\nb.go\n\n"},
+			want: "- a.go\n- b.go\n",
+		},
+		{
+			name: "each over commas with metadata",
+			body: "{{#each steps}}{{@number}}. {{this}}{{^@last}}; {{/@last}}{{/each}}",
+			vars: map[string]string{"steps": "plan, build ,test"},
+			want: "1. plan; 2. build; 3. test",
+		},
+		{
+			name:  "each from Lists",
+			body:  "{{#each items}}[{{.}}]{{/each}}",
+			lists: map[string][]string{"items": {"x, y", "z"}},
+			want:  "[x, y][z]",
+		},
+		{
+			name: "nested each",
+			body: "{{#each a}}{{#each b}}{{.}}{{/each}}|{{/each}}",
+			vars: map[string]string{"a": "1,2", "b": "x,y"},
+			want: "xy|xy|",
+		},
+		{
+			name: "inverted section",
+			body: "{{#focus}}Focus: {{focus}}{{/focus}}{{^focus}}General review{{/focus}}",
+			want: "General review",
+		},
+		{
+			name: "nested same-name sections",
+			body: "{{#x}}a{{#x}}b{{/x}}c{{/x}}",
+			vars: map[string]string{"x": "1"},
+			want: "abc",
+		},
+		{
+			name: "default filter",
+			body: `{{focus | default "everything"}} / {{set | default "unused"}}`,
+			vars: map[string]string{"set": "given"},
+			want: "everything / given",
+		},
+		{
+			name: "filter chain",
+			body: `{{name | trim | upper}}`,
+			vars: map[string]string{"name": "  bob "},
+			want: "BOB",
+		},
+		{
+			name: "indent head tail",
+			body: "{{log | tail 2 | indent 4}}\n{{log | head 1}}",
+			vars: map[string]string{"log": "one\ntwo\nthree"},
+			want: "    two\n    three\none",
+		},
+		{
+			name: "truncate join code",
+			body: `{{s | truncate 3}} {{l | join " + "}} {{c | code "go"}}{{empty | code}}`,
+			vars: map[string]string{"s": "abcdef", "l": "a,b", "c": "x := 1", "empty": ""},
+			want: "abc... a + b ```go\nx := 1\n```",
+		},
+		{
+			name: "default from variable",
+			body: `{{title | default fallback}}`,
+			vars: map[string]string{"fallback": "untitled"},
+			want: "untitled",
+		},
+		{
+			name: "comments and spacing",
+			body: "{{! internal note }}{{ name }}",
+			vars: map[string]string{"name": "ok"},
+			want: "ok",
+		},
+		{
+			name: "unknown and foreign syntax left as-is",
+			body: "{{missing}} {{.Field}} {{#unclosed}} {{a b}} {{/stray}}",
+			want: "{{missing}} {{.Field}} {{#unclosed}} {{a b}} {{/stray}}",
+		},
+		{
+			name: "pipe inside quotes",
+			body: `{{x | default "a|b"}}`,
+			want: "a|b",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpl := &Template{Body: tc.body}
+			got, err := tmpl.Execute(ExecutionContext{Variables: tc.vars, Lists: tc.lists})
+			if err != nil {
+				t.Fatalf("Execute: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestExecute_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"unknown filter", "{{x | shout}}", "unknown filter"},
+		{"bad number", "{{x | head many}}", "invalid number"},
+		{"unterminated quote", `{{x | default "oops}}`, "unterminated quote"},
+		{"unknown provider", "{{@weather}}", "unknown context provider @weather"},
+		{"missing partial", "{{> nope_not_here}}", "partial nope_not_here"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := (&Template{Name: "t", Body: tc.body}).Execute(ExecutionContext{})
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("err = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+type mapPartials map[string]string
+
+func (m mapPartials) LoadPartial(name string) (*Template, error) {
+	body, ok := m[name]
+	if !ok {
+		return nil, &TemplateNotFoundError{Name: name}
+	}
+	return Parse(body)
+}
+
+func TestExecute_Partials(t *testing.T) {
+	partials := mapPartials{
+		"header": "---\nvariables:\n  - name: team\n    default: core\n---\n# {{title}} ({{team}})",
+		"item":   "* {{.}}",
+		"loop_a": "{{> loop_b}}",
+		"loop_b": "{{> loop_a}}",
+	}
+	tmpl := &Template{Name: "main", Body: "{{> header}}\n{{#each files}}{{> item}}\n{{/each}}"}
+	got, report, err := tmpl.ExecuteWithReport(ExecutionContext{
+		Variables: map[string]string{"title": "Review", "files": "a.go,b.go"},
+		Partials:  partials,
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if want := "# Review (core)\n* a.go\n* b.go\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if strings.Join(report.Partials, ",") != "header,item" {
+		t.Errorf("Partials = %v", report.Partials)
+	}
+
+	_, err = (&Template{Name: "main", Body: "{{> loop_a}}"}).Execute(ExecutionContext{Partials: partials})
+	if err == nil || !strings.Contains(err.Error(), "partial cycle: main -> loop_a -> loop_b -> loop_a") {
+		t.Errorf("cycle err = %v", err)
+	}
+}
+
+func TestExecute_Providers(t *testing.T) {
+	calls := 0
+	providers := map[string]Provider{
+		"echo": {
+			Name:   "echo",
+			Budget: 0,
+			Resolve: func(req ProviderRequest) (string, error) {
+				calls++
+				return "echo:" + strings.Join(req.Args, "+") + "\n", nil
+			},
+		},
+		"broken": {
+			Name:    "broken",
+			Resolve: func(ProviderRequest) (string, error) { return "", errors.New("tool missing") },
+		},
+		"big": {
+			Name:   "big",
+			Budget: 1000,
+			Resolve: func(ProviderRequest) (string, error) {
+				return strings.Repeat("line of provider output\n", 500), nil
+			},
+		},
+	}
+
+	tmpl := &Template{
+		Body:           `{{@echo "a b" q}} {{@echo "a b" q}} [{{@broken | default "n/a"}}]{{#@echo}} yes{{/@echo}}`,
+		ContextBudgets: map[string]int{"big": 50},
+	}
+	got, report, err := tmpl.ExecuteWithReport(ExecutionContext{
+		Variables: map[string]string{"q": "query"},
+		Providers: providers,
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if want := "echo:a b+query echo:a b+query [n/a] yes"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if calls != 2 {
+		t.Errorf("provider resolved %d times, want 2 (identical calls are cached)", calls)
+	}
+	if len(report.Providers) != 3 || report.Providers[1].Error != "tool missing" {
+		t.Errorf("report = %+v", report.Providers)
+	}
+
+	tmpl.Body = "{{@big}}"
+	got, report, err = tmpl.ExecuteWithReport(ExecutionContext{Providers: providers})
+	if err != nil {
+		t.Fatal(err)
+	}
+	usage := report.Providers[0]
+	if !usage.Trimmed || usage.Budget != 50 || usage.Tokens > 50 {
+		t.Errorf("usage = %+v", usage)
+	}
+	if !strings.Contains(got, "lines trimmed to fit ~50 tokens") {
+		t.Errorf("missing trim marker:\n%s", got)
+	}
+}
+
+func TestLoader_LoadPartial(t *testing.T) {
+	projectDir := t.TempDir()
+	userDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(projectDir, "partials"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(projectDir, "partials", "rules.md"), []byte("project partial"), 0644)
+	os.WriteFile(filepath.Join(projectDir, "rules.md"), []byte("project template"), 0644)
+	os.WriteFile(filepath.Join(userDir, "sig.md"), []byte("user template"), 0644)
+
+	l := &Loader{projectDir: projectDir, userDir: userDir}
+	for name, want := range map[string]string{"rules": "project partial", "sig": "user template"} {
+		tmpl, err := l.LoadPartial(name)
+		if err != nil || tmpl.Body != want {
+			t.Errorf("LoadPartial(%s) = %v, %v", name, tmpl, err)
+		}
+	}
+	if _, err := l.LoadPartial("missing"); err == nil {
+		t.Error("expected error for missing partial")
+	}
+	if _, err := l.LoadPartial("../escape"); err == nil {
+		t.Error("expected error for path traversal")
+	}
+}
@@ -13,9 +13,11 @@ type Template struct {
 	Description string         `yaml:"description"`
 	Variables   []VariableSpec `yaml:"variables"`
 	Tags        []string       `yaml:"tags,omitempty"`
-	Body        string         `yaml:"-"` // The template body (not in frontmatter)
-	Source      TemplateSource `yaml:"-"` // Where this template came from
-	SourcePath  string         `yaml:"-"` // File path if from file
+	// ContextBudgets overrides provider token budgets, e.g. {git_diff: 2000}.
+	ContextBudgets map[string]int `yaml:"context_budgets,omitempty"`
+	Body           string         `yaml:"-"` // The template body (not in frontmatter)
+	Source         TemplateSource `yaml:"-"` // Where this template came from
+	SourcePath     string         `yaml:"-"` // File path if from file
 }
 
 // VariableSpec describes a template variable.
@@ -91,6 +93,20 @@ type ExecutionContext struct {
 	// Index in a multi-send operation for {{send_index}}, {{send_total}}
 	SendIndex int // 0-indexed position in send batch
 	SendTotal int // total number of targets in send batch
+
+	// Lists supplies {{#each name}} items directly; otherwise the variable
+	// value is split into items (see SplitList).
+	Lists map[string][]string
+
+	// WorkDir is where context providers run (default: current directory).
+	WorkDir string
+
+	// Partials resolves {{> name}}; builtin templates only when nil.
+	Partials PartialLoader
+
+	// Providers adds or replaces {{@name}} context providers on top of
+	// DefaultProviders.
+	Providers map[string]Provider
 }
 
 // WithBead sets bead context on an ExecutionContext and returns the modified context.
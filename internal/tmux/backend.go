@@ -0,0 +1,49 @@
+package tmux
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Backend executes tmux commands in place of a tmux server.
+//
+// Client methods build tmux argument lists and parse the command output, so a
+// Backend that answers the same commands (list-panes -F, send-keys,
+// capture-pane, respawn-pane, ...) replaces tmux beneath every call site
+// without changing what callers see. The headless package provides a
+// PTY-based Backend for environments without tmux, such as CI containers.
+type Backend interface {
+	// Name identifies the backend in errors and status output (e.g. "pty").
+	Name() string
+	// Run executes a tmux command and returns its standard output.
+	Run(ctx context.Context, args ...string) (string, error)
+}
+
+// ErrNotSupported is returned for operations a backend cannot provide, such
+// as attaching an interactive client.
+var ErrNotSupported = errors.New("not supported by this backend")
+
+// NewClientWithBackend creates a client that sends commands to b instead of a
+// tmux server.
+func NewClientWithBackend(b Backend) *Client {
+	return &Client{backend: b}
+}
+
+// Backend returns the client's backend, or nil when it drives tmux directly.
+func (c *Client) Backend() Backend {
+	return c.backend
+}
+
+// BackendName returns "tmux" for tmux-backed clients, otherwise the backend's name.
+func (c *Client) BackendName() string {
+	if c.backend == nil {
+		return "tmux"
+	}
+	return c.backend.Name()
+}
+
+// backendUnsupported wraps ErrNotSupported with the operation and backend name.
+func (c *Client) backendUnsupported(op string) error {
+	return fmt.Errorf("%s: %w (%s backend)", op, ErrNotSupported, c.backend.Name())
+}
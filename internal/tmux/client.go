@@ -14,6 +14,8 @@ import (
 // Client handles tmux operations, optionally on a remote host
 type Client struct {
 	Remote string // "user@host" or empty for local
+
+	backend Backend // Replaces the tmux server when set (see NewClientWithBackend)
 }
 
 // NewClient creates a new tmux client
@@ -86,6 +88,10 @@ func (c *Client) RunContext(ctx context.Context, args ...string) (string, error)
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	if c.backend != nil {
+		out, err := c.backend.Run(ctx, args...)
+		return strings.TrimSpace(out), err
+	}
 	if c.Remote == "" {
 		return runLocalContext(ctx, args...)
 	}
@@ -187,6 +193,9 @@ func (c *Client) RunSilentContext(ctx context.Context, args ...string) error {
 
 // IsInstalled checks if tmux is available on the target host
 func (c *Client) IsInstalled() bool {
+	if c.backend != nil {
+		return true
+	}
 	if c.Remote == "" {
 		return binaryExists(BinaryPath())
 	}
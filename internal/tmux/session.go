@@ -912,7 +912,12 @@ func (c *Client) SendBufferWithDelay(target, content string, enter bool, enterDe
 
 	// Load content into a tmux buffer
 	// We use 'load-buffer' with stdin to handle arbitrary content including special characters
-	if c.Remote == "" {
+	if c.backend != nil {
+		// Backends take the content as an argument; there is no stdin to pipe.
+		if err := c.RunSilent("set-buffer", "-b", bufferName, "--", content); err != nil {
+			return fmt.Errorf("set buffer: %w", err)
+		}
+	} else if c.Remote == "" {
 		// Local: use load-buffer with a pipe
 		if err := c.loadBufferLocal(bufferName, content); err != nil {
 			return fmt.Errorf("load buffer: %w", err)
@@ -1087,6 +1092,9 @@ func BuildPaneCommand(projectDir, agentCommand string) (string, error) {
 
 // AttachOrSwitch attaches to a session or switches if already in tmux
 func (c *Client) AttachOrSwitch(session string) error {
+	if c.backend != nil {
+		return c.backendUnsupported("attach")
+	}
 	if c.Remote == "" {
 		if InTmux() {
 			return c.RunSilent("switch-client", "-t", session)
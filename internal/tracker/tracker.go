@@ -623,6 +623,23 @@ func gitCheckIgnored(root string, entries []fileEntry) (map[string]bool, error)
 	return result, nil
 }
 
+// GitIgnored returns the subset of paths that git ignores in the repository
+// at root. Best-effort: outside a repository the result is empty.
+func GitIgnored(root string, paths []string) map[string]bool {
+	if root == "" || len(paths) == 0 {
+		return map[string]bool{}
+	}
+	entries := make([]fileEntry, len(paths))
+	for i, p := range paths {
+		entries[i] = fileEntry{path: p}
+	}
+	ignored, err := gitCheckIgnored(root, entries)
+	if err != nil {
+		return map[string]bool{}
+	}
+	return ignored
+}
+
 // scanNullTerminated is a split function for bufio.Scanner that splits on null bytes.
 func scanNullTerminated(data []byte, atEOF bool) (advance int, token []byte, err error) {
 	if atEOF && len(data) == 0 {
@@ -728,6 +745,13 @@ type RecordedFileChange struct {
 	Session   string     `json:"session"`
 	Agents    []string   `json:"agents,omitempty"`
 	Change    FileChange `json:"change"`
+
+	// Attribution is how Agents was determined. Empty means the change was
+	// attributed to the agents targeted by a send; the filesystem watcher sets
+	// open_file, disk_write, ambiguous or none.
+	Attribution string `json:"attribution,omitempty"`
+	// Candidates lists the agents that could have made an ambiguous change.
+	Candidates []string `json:"candidates,omitempty"`
 }
 
 // FileChangeStore keeps a bounded buffer of recent file changes.
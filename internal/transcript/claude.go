@@ -0,0 +1,159 @@
+package transcript
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// claudeEditTools maps Claude Code's file-editing tools to their path argument.
+var claudeEditTools = map[string]string{
+	"Edit":         "file_path",
+	"MultiEdit":    "file_path",
+	"Write":        "file_path",
+	"NotebookEdit": "notebook_path",
+}
+
+type claudeEntry struct {
+	Type      string `json:"type"`
+	Timestamp string `json:"timestamp"`
+	Cwd       string `json:"cwd"`
+	SessionID string `json:"sessionId"`
+	IsMeta    bool   `json:"isMeta"`
+	Message   *struct {
+		Role    string          `json:"role"`
+		Model   string          `json:"model"`
+		Content json.RawMessage `json:"content"`
+	} `json:"message"`
+}
+
+type claudeBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text"`
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Input     json.RawMessage `json:"input"`
+	ToolUseID string          `json:"tool_use_id"`
+	Content   json.RawMessage `json:"content"`
+	IsError   bool            `json:"is_error"`
+}
+
+// parseClaude reads a Claude Code session file (~/.claude/projects/<dir>/<id>.jsonl).
+// Each assistant content block is its own line; everything the assistant does
+// between two user prompts is folded into one assistant turn, and tool results
+// (which Claude records as user entries) are attached to their calls.
+func parseClaude(data []byte) (*Transcript, error) {
+	t := &Transcript{}
+	calls := make(map[string]*ToolCall)
+	var current *Turn
+
+	flush := func() {
+		if current != nil {
+			current.Text = strings.TrimSpace(current.Text)
+			t.Turns = append(t.Turns, *current)
+			current = nil
+		}
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+	for sc.Scan() {
+		var e claudeEntry
+		if json.Unmarshal(sc.Bytes(), &e) != nil || e.Message == nil {
+			continue
+		}
+		if t.SessionID == "" {
+			t.SessionID = e.SessionID
+		}
+		if t.Cwd == "" {
+			t.Cwd = e.Cwd
+		}
+		ts := parseTime(e.Timestamp)
+		if t.StartedAt.IsZero() {
+			t.StartedAt = ts
+		}
+
+		var blocks []claudeBlock
+		var text string
+		if json.Unmarshal(e.Message.Content, &text) != nil {
+			_ = json.Unmarshal(e.Message.Content, &blocks)
+		}
+
+		switch e.Type {
+		case "user":
+			// Tool results ride on user entries; a prompt has text.
+			var prompt []string
+			if text != "" {
+				prompt = append(prompt, text)
+			}
+			for _, b := range blocks {
+				switch b.Type {
+				case "tool_result":
+					if tc := calls[b.ToolUseID]; tc != nil {
+						tc.Output = claudeResultText(b.Content)
+						tc.IsError = b.IsError
+					}
+				case "text":
+					prompt = append(prompt, b.Text)
+				}
+			}
+			if e.IsMeta || len(prompt) == 0 {
+				continue
+			}
+			flush()
+			t.Turns = append(t.Turns, Turn{Role: RoleUser, Text: strings.TrimSpace(strings.Join(prompt, "\n")), Timestamp: ts})
+
+		case "assistant":
+			if current == nil {
+				current = &Turn{Role: RoleAssistant, Timestamp: ts}
+			}
+			if e.Message.Model != "" && !strings.HasPrefix(e.Message.Model, "<") {
+				current.Model = e.Message.Model
+			}
+			if text != "" {
+				current.Text += text + "\n\n"
+			}
+			for _, b := range blocks {
+				switch b.Type {
+				case "text":
+					current.Text += b.Text + "\n\n"
+				case "tool_use":
+					tc := ToolCall{ID: b.ID, Name: b.Name, Input: compactJSON(b.Input)}
+					if key, ok := claudeEditTools[b.Name]; ok {
+						if p := stringField(b.Input, key); p != "" {
+							tc.Files = []string{p}
+						}
+					}
+					current.ToolCalls = append(current.ToolCalls, tc)
+				}
+			}
+			// Re-index: appending may have moved the slice. Flushed turns
+			// share the backing array, so late results still land.
+			for i := range current.ToolCalls {
+				calls[current.ToolCalls[i].ID] = &current.ToolCalls[i]
+			}
+		}
+	}
+	flush()
+	return t, sc.Err()
+}
+
+// claudeResultText flattens a tool_result content (string or text blocks).
+func claudeResultText(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var blocks []claudeBlock
+	if json.Unmarshal(raw, &blocks) != nil {
+		return string(raw)
+	}
+	var parts []string
+	for _, b := range blocks {
+		if b.Type == "text" {
+			parts = append(parts, b.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
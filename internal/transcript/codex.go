@@ -0,0 +1,211 @@
+package transcript
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+type codexLine struct {
+	Timestamp string          `json:"timestamp"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+type codexItem struct {
+	Type      string          `json:"type"`
+	ID        string          `json:"id"`
+	Cwd       string          `json:"cwd"`
+	Timestamp string          `json:"timestamp"`
+	Model     string          `json:"model"`
+	Role      string          `json:"role"`
+	Content   json.RawMessage `json:"content"`
+	Name      string          `json:"name"`
+	Arguments string          `json:"arguments"`
+	Input     string          `json:"input"`
+	CallID    string          `json:"call_id"`
+	Output    json.RawMessage `json:"output"`
+}
+
+// parseCodex reads a Codex rollout file (~/.codex/sessions/YYYY/MM/DD/rollout-*.jsonl).
+// Current rollouts wrap each item as {"type": ..., "payload": ...}; older
+// ones write the items directly after a header line.
+func parseCodex(data []byte) (*Transcript, error) {
+	t := &Transcript{}
+	calls := make(map[string]*ToolCall)
+	var current *Turn
+	var model string
+
+	flush := func() {
+		if current != nil {
+			current.Text = strings.TrimSpace(current.Text)
+			t.Turns = append(t.Turns, *current)
+			current = nil
+		}
+	}
+	addCall := func(tc ToolCall, ts string) {
+		if current == nil {
+			current = &Turn{Role: RoleAssistant, Model: model, Timestamp: parseTime(ts)}
+		}
+		current.ToolCalls = append(current.ToolCalls, tc)
+		for i := range current.ToolCalls {
+			calls[current.ToolCalls[i].ID] = &current.ToolCalls[i]
+		}
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+	for sc.Scan() {
+		var line codexLine
+		if json.Unmarshal(sc.Bytes(), &line) != nil {
+			continue
+		}
+		var item codexItem
+		raw := line.Payload
+		if len(raw) == 0 {
+			raw = sc.Bytes()
+		}
+		if json.Unmarshal(raw, &item) != nil {
+			continue
+		}
+		ts := line.Timestamp
+		if ts == "" {
+			ts = item.Timestamp
+		}
+
+		switch {
+		case line.Type == "session_meta" || (line.Type == "" && item.Type == "" && item.ID != ""):
+			t.SessionID = item.ID
+			if item.Cwd != "" {
+				t.Cwd = item.Cwd
+			}
+			t.StartedAt = parseTime(ts)
+			continue
+		case line.Type == "turn_context":
+			if item.Cwd != "" && t.Cwd == "" {
+				t.Cwd = item.Cwd
+			}
+			if item.Model != "" {
+				model = item.Model
+			}
+			continue
+		case line.Type != "" && line.Type != "response_item":
+			// event_msg and friends duplicate response items for the UI.
+			continue
+		}
+
+		switch item.Type {
+		case "message":
+			text := codexContentText(item.Content)
+			if item.Role == "user" {
+				if text == "" || codexInjected(text) {
+					continue
+				}
+				flush()
+				t.Turns = append(t.Turns, Turn{Role: RoleUser, Text: text, Timestamp: parseTime(ts)})
+				continue
+			}
+			if item.Role == "assistant" {
+				if current == nil {
+					current = &Turn{Role: RoleAssistant, Model: model, Timestamp: parseTime(ts)}
+				}
+				current.Text += text + "\n\n"
+			}
+
+		case "function_call":
+			tc := ToolCall{ID: item.CallID, Name: item.Name, Input: item.Arguments}
+			tc.Files = codexCallFiles(item.Name, item.Arguments)
+			addCall(tc, ts)
+
+		case "custom_tool_call":
+			tc := ToolCall{ID: item.CallID, Name: item.Name, Input: item.Input}
+			if item.Name == "apply_patch" {
+				tc.Files = patchFiles(item.Input)
+			}
+			addCall(tc, ts)
+
+		case "function_call_output", "custom_tool_call_output":
+			if tc := calls[item.CallID]; tc != nil {
+				tc.Output, tc.IsError = codexOutput(item.Output)
+			}
+		}
+	}
+	flush()
+	return t, sc.Err()
+}
+
+// codexInjected reports whether a user message is context Codex injects
+// itself rather than something the user typed.
+func codexInjected(text string) bool {
+	for _, prefix := range []string{"<environment_context>", "<user_instructions>", "# AGENTS.md instructions"} {
+		if strings.HasPrefix(text, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func codexContentText(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return strings.TrimSpace(s)
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	_ = json.Unmarshal(raw, &parts)
+	var texts []string
+	for _, p := range parts {
+		if p.Text != "" {
+			texts = append(texts, p.Text)
+		}
+	}
+	return strings.TrimSpace(strings.Join(texts, "\n"))
+}
+
+// codexCallFiles finds files edited by a shell call that runs apply_patch.
+func codexCallFiles(name, arguments string) []string {
+	if name != "shell" && name != "apply_patch" && name != "container.exec" {
+		return nil
+	}
+	var args struct {
+		Command []string `json:"command"`
+		Input   string   `json:"input"`
+	}
+	if json.Unmarshal([]byte(arguments), &args) != nil {
+		return nil
+	}
+	text := args.Input + "\n" + strings.Join(args.Command, "\n")
+	if !strings.Contains(text, "*** Begin Patch") {
+		return nil
+	}
+	return patchFiles(text)
+}
+
+// codexOutput unwraps a tool result, which is either plain text or a JSON
+// string holding {"output": ..., "metadata": {"exit_code": N}}.
+func codexOutput(raw json.RawMessage) (string, bool) {
+	var s string
+	if json.Unmarshal(raw, &s) != nil {
+		var obj struct {
+			Content string `json:"content"`
+			Success *bool  `json:"success"`
+		}
+		if json.Unmarshal(raw, &obj) == nil && obj.Content != "" {
+			return obj.Content, obj.Success != nil && !*obj.Success
+		}
+		return string(raw), false
+	}
+	var wrapped struct {
+		Output   string `json:"output"`
+		Metadata struct {
+			ExitCode *int `json:"exit_code"`
+		} `json:"metadata"`
+	}
+	if json.Unmarshal([]byte(s), &wrapped) == nil && wrapped.Metadata.ExitCode != nil {
+		return wrapped.Output, *wrapped.Metadata.ExitCode != 0
+	}
+	return s, false
+}
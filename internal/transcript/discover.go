@@ -0,0 +1,148 @@
+package transcript
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// File is a transcript found on disk.
+type File struct {
+	Agent   Agent     `json:"agent"`
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// Roots locates each agent's transcript directory. Zero values use the
+// agents' defaults (honouring CLAUDE_CONFIG_DIR and CODEX_HOME).
+type Roots struct {
+	Claude string // Contains projects/
+	Codex  string // Contains sessions/
+	Gemini string // Contains tmp/
+}
+
+// DefaultRoots returns the standard transcript locations.
+func DefaultRoots() Roots {
+	home, _ := os.UserHomeDir()
+	r := Roots{
+		Claude: filepath.Join(home, ".claude"),
+		Codex:  filepath.Join(home, ".codex"),
+		Gemini: filepath.Join(home, ".gemini"),
+	}
+	if dir := os.Getenv("CLAUDE_CONFIG_DIR"); dir != "" {
+		r.Claude = dir
+	}
+	if dir := os.Getenv("CODEX_HOME"); dir != "" {
+		r.Codex = dir
+	}
+	return r
+}
+
+var claudeSlugRe = regexp.MustCompile(`[^a-zA-Z0-9]`)
+
+// ClaudeProjectDir is where Claude Code keeps transcripts for projectDir.
+func (r Roots) ClaudeProjectDir(projectDir string) string {
+	return filepath.Join(r.Claude, "projects", claudeSlugRe.ReplaceAllString(projectDir, "-"))
+}
+
+// GeminiChatDir is where Gemini CLI keeps chats for projectDir.
+func (r Roots) GeminiChatDir(projectDir string) string {
+	sum := sha256.Sum256([]byte(projectDir))
+	return filepath.Join(r.Gemini, "tmp", hex.EncodeToString(sum[:]), "chats")
+}
+
+// Discover lists the transcripts the given agents wrote for projectDir that
+// were modified at or after since, oldest first.
+func (r Roots) Discover(projectDir string, agents []Agent, since time.Time) ([]File, error) {
+	projectDir = filepath.Clean(projectDir)
+	var files []File
+	add := func(agent Agent, path string, info fs.FileInfo) {
+		if info.ModTime().Before(since) {
+			return
+		}
+		files = append(files, File{Agent: agent, Path: path, Size: info.Size(), ModTime: info.ModTime()})
+	}
+
+	for _, agent := range agents {
+		switch agent {
+		case AgentClaude:
+			matches, _ := filepath.Glob(filepath.Join(r.ClaudeProjectDir(projectDir), "*.jsonl"))
+			for _, m := range matches {
+				if info, err := os.Stat(m); err == nil {
+					add(agent, m, info)
+				}
+			}
+
+		case AgentCodex:
+			root := filepath.Join(r.Codex, "sessions")
+			_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+				if err != nil || d.IsDir() || !strings.HasSuffix(path, ".jsonl") {
+					return nil
+				}
+				info, err := d.Info()
+				if err != nil || info.ModTime().Before(since) {
+					return nil
+				}
+				if cwd := codexCwd(path); cwd != "" && withinDir(cwd, projectDir) {
+					add(agent, path, info)
+				}
+				return nil
+			})
+
+		case AgentGemini:
+			matches, _ := filepath.Glob(filepath.Join(r.GeminiChatDir(projectDir), "*.json"))
+			for _, m := range matches {
+				if info, err := os.Stat(m); err == nil {
+					add(agent, m, info)
+				}
+			}
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].ModTime.Before(files[j].ModTime) })
+	return files, nil
+}
+
+// codexCwd reads the working directory from a rollout's first lines.
+func codexCwd(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for i := 0; i < 10 && sc.Scan(); i++ {
+		var line struct {
+			Payload struct {
+				Cwd string `json:"cwd"`
+			} `json:"payload"`
+			Cwd string `json:"cwd"`
+		}
+		if json.Unmarshal(sc.Bytes(), &line) != nil {
+			continue
+		}
+		if line.Payload.Cwd != "" {
+			return line.Payload.Cwd
+		}
+		if line.Cwd != "" {
+			return line.Cwd
+		}
+	}
+	return ""
+}
+
+func withinDir(path, dir string) bool {
+	rel, err := filepath.Rel(dir, filepath.Clean(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
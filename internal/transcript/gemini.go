@@ -0,0 +1,113 @@
+package transcript
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// geminiEditTools maps Gemini CLI's file-editing tools to their path argument.
+var geminiEditTools = map[string]string{
+	"write_file": "file_path",
+	"replace":    "file_path",
+	"edit":       "file_path",
+}
+
+type geminiChat struct {
+	SessionID   string `json:"sessionId"`
+	ProjectHash string `json:"projectHash"`
+	StartTime   string `json:"startTime"`
+	Messages    []struct {
+		Timestamp string          `json:"timestamp"`
+		Type      string          `json:"type"` // user, gemini, info, error
+		Content   json.RawMessage `json:"content"`
+		Model     string          `json:"model"`
+		ToolCalls []struct {
+			ID            string          `json:"id"`
+			Name          string          `json:"name"`
+			Args          json.RawMessage `json:"args"`
+			Result        json.RawMessage `json:"result"`
+			ResultDisplay json.RawMessage `json:"resultDisplay"`
+			Status        string          `json:"status"`
+		} `json:"toolCalls"`
+	} `json:"messages"`
+}
+
+// parseGemini reads a Gemini CLI chat file (~/.gemini/tmp/<hash>/chats/session-*.json).
+// The chat does not record the working directory; discovery fills it in
+// from the project the hash was derived from.
+func parseGemini(data []byte) (*Transcript, error) {
+	var chat geminiChat
+	if err := json.Unmarshal(data, &chat); err != nil {
+		return nil, err
+	}
+	t := &Transcript{SessionID: chat.SessionID, StartedAt: parseTime(chat.StartTime)}
+
+	for _, m := range chat.Messages {
+		text := geminiText(m.Content)
+		switch m.Type {
+		case "user":
+			if text == "" {
+				continue
+			}
+			t.Turns = append(t.Turns, Turn{Role: RoleUser, Text: text, Timestamp: parseTime(m.Timestamp)})
+		case "gemini":
+			// Consecutive model messages are one response; merge them.
+			var turn *Turn
+			if n := len(t.Turns); n > 0 && t.Turns[n-1].Role == RoleAssistant {
+				turn = &t.Turns[n-1]
+			} else {
+				t.Turns = append(t.Turns, Turn{Role: RoleAssistant, Timestamp: parseTime(m.Timestamp)})
+				turn = &t.Turns[len(t.Turns)-1]
+			}
+			if m.Model != "" {
+				turn.Model = m.Model
+			}
+			if text != "" {
+				if turn.Text != "" {
+					turn.Text += "\n\n"
+				}
+				turn.Text += text
+			}
+			for _, c := range m.ToolCalls {
+				tc := ToolCall{
+					ID:      c.ID,
+					Name:    c.Name,
+					Input:   compactJSON(c.Args),
+					Output:  geminiText(c.ResultDisplay),
+					IsError: c.Status == "error",
+				}
+				if tc.Output == "" {
+					tc.Output = compactJSON(c.Result)
+				}
+				if key, ok := geminiEditTools[c.Name]; ok {
+					if p := stringField(c.Args, key); p != "" {
+						tc.Files = []string{p}
+					}
+				}
+				turn.ToolCalls = append(turn.ToolCalls, tc)
+			}
+		}
+	}
+	return t, nil
+}
+
+// geminiText flattens a content value: a string or a list of {text} parts.
+func geminiText(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return strings.TrimSpace(s)
+	}
+	var parts []struct {
+		Text string `json:"text"`
+	}
+	if json.Unmarshal(raw, &parts) != nil {
+		return ""
+	}
+	var texts []string
+	for _, p := range parts {
+		if p.Text != "" {
+			texts = append(texts, p.Text)
+		}
+	}
+	return strings.TrimSpace(strings.Join(texts, "\n"))
+}
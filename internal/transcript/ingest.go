@@ -0,0 +1,331 @@
+package transcript
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/assignment"
+	"github.com/shahbajlive/ntm/internal/history"
+	"github.com/shahbajlive/ntm/internal/state"
+)
+
+// SentPrompt is a prompt ntm delivered to one or more panes. Matching a
+// transcript's user turns against sent prompts tells which pane it came from.
+type SentPrompt struct {
+	Text  string
+	Panes []int
+	At    time.Time
+}
+
+// BeadAssignment records which pane worked on a bead and when.
+type BeadAssignment struct {
+	BeadID string
+	Pane   int
+	Prompt string    // The prompt that assigned the bead, if recorded
+	Start  time.Time // When it was assigned
+	End    time.Time // When it completed or failed; zero while active
+}
+
+// Linker attributes transcripts to the pane and bead that produced them.
+type Linker struct {
+	Prompts     []SentPrompt
+	Assignments []BeadAssignment
+}
+
+// SessionLinker builds a Linker from ntm's send history and bead assignments
+// for session. Either may be missing; the linker then infers less.
+func SessionLinker(session string) Linker {
+	var l Linker
+	if entries, err := history.ReadForSession(session); err == nil {
+		for _, e := range entries {
+			if !e.Success {
+				continue
+			}
+			p := SentPrompt{Text: e.Prompt, At: e.Timestamp}
+			for _, target := range e.Targets {
+				if idx, err := strconv.Atoi(strings.TrimSpace(target)); err == nil {
+					p.Panes = append(p.Panes, idx)
+				}
+			}
+			if len(p.Panes) > 0 {
+				l.Prompts = append(l.Prompts, p)
+			}
+		}
+	}
+	if store, err := assignment.LoadStore(session); err == nil {
+		for _, a := range store.GetAll() {
+			ba := BeadAssignment{BeadID: a.BeadID, Pane: a.Pane, Prompt: a.PromptSent, Start: a.AssignedAt}
+			switch {
+			case a.CompletedAt != nil:
+				ba.End = *a.CompletedAt
+			case a.FailedAt != nil:
+				ba.End = *a.FailedAt
+			}
+			l.Assignments = append(l.Assignments, ba)
+		}
+	}
+	return l
+}
+
+// promptWindow bounds the clock skew between ntm sending a prompt and the
+// agent recording it.
+const promptWindow = 15 * time.Minute
+
+// minPrefixMatch is the shortest prompt that may match by prefix alone;
+// agents sometimes record a truncated or annotated copy of what was sent.
+const minPrefixMatch = 32
+
+// Link returns the pane (-1 if it cannot be determined) and bead for t.
+// The pane is the one every matched prompt was sent to; the bead is the
+// assignment whose prompt appears in t, or else the one the pane held
+// while t was active.
+func (l Linker) Link(t *Transcript) (int, string) {
+	pane := -1
+	var candidates map[int]bool
+	for _, turn := range t.Turns {
+		if turn.Role != RoleUser {
+			continue
+		}
+		panes := make(map[int]bool)
+		for _, p := range l.Prompts {
+			if promptMatches(turn, p.Text, p.At) {
+				for _, idx := range p.Panes {
+					panes[idx] = true
+				}
+			}
+		}
+		if len(panes) == 0 {
+			continue
+		}
+		if candidates == nil {
+			candidates = panes
+			continue
+		}
+		for idx := range candidates {
+			if !panes[idx] {
+				delete(candidates, idx)
+			}
+		}
+	}
+	if len(candidates) == 1 {
+		for idx := range candidates {
+			pane = idx
+		}
+	}
+
+	// An assignment prompt found in the transcript is the strongest signal
+	// and also pins the pane when prompt history was inconclusive.
+	for _, a := range l.Assignments {
+		if a.Prompt == "" || (pane >= 0 && a.Pane != pane) {
+			continue
+		}
+		for _, turn := range t.Turns {
+			if turn.Role == RoleUser && promptMatches(turn, a.Prompt, a.Start) {
+				return a.Pane, a.BeadID
+			}
+		}
+	}
+	if pane < 0 {
+		return pane, ""
+	}
+
+	start, end := t.StartedAt, t.EndedAt()
+	var best *BeadAssignment
+	for i := range l.Assignments {
+		a := &l.Assignments[i]
+		if a.Pane != pane || (!end.IsZero() && a.Start.After(end)) || (!a.End.IsZero() && !start.IsZero() && a.End.Before(start)) {
+			continue
+		}
+		if best == nil || a.Start.After(best.Start) {
+			best = a
+		}
+	}
+	if best == nil {
+		return pane, ""
+	}
+	return pane, best.BeadID
+}
+
+// promptMatches reports whether a user turn is the prompt sent at sentAt.
+func promptMatches(turn Turn, prompt string, sentAt time.Time) bool {
+	if !turn.Timestamp.IsZero() && !sentAt.IsZero() {
+		d := turn.Timestamp.Sub(sentAt)
+		if d < -promptWindow || d > promptWindow {
+			return false
+		}
+	}
+	a, b := normalizePrompt(turn.Text), normalizePrompt(prompt)
+	if a == "" || b == "" {
+		return false
+	}
+	if a == b {
+		return true
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	return len(a) >= minPrefixMatch && strings.HasPrefix(b, a)
+}
+
+func normalizePrompt(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// IngestOptions controls Ingest.
+type IngestOptions struct {
+	Session    string // ntm session the transcripts belong to
+	ProjectDir string // Working directory for transcripts that do not record one
+	Linker     Linker
+	Pane       *int   // Force the pane instead of inferring it
+	BeadID     string // Force the bead instead of inferring it
+	Force      bool   // Re-ingest files that have not changed
+}
+
+// IngestResult reports what happened to one transcript file.
+type IngestResult struct {
+	Path         string `json:"path"`
+	Agent        Agent  `json:"agent"`
+	TranscriptID int64  `json:"transcript_id,omitempty"`
+	Turns        int    `json:"turns"`
+	ToolCalls    int    `json:"tool_calls"`
+	FilesEdited  int    `json:"files_edited"`
+	Pane         *int   `json:"pane,omitempty"`
+	BeadID       string `json:"bead_id,omitempty"`
+	Unchanged    bool   `json:"unchanged,omitempty"` // Already ingested at this size and mtime
+	Error        string `json:"error,omitempty"`
+}
+
+// Ingest parses files and stores them. A file that fails to parse is
+// reported in its result rather than aborting the rest; only store errors
+// are returned.
+func Ingest(ts *state.TranscriptStore, files []File, opts IngestOptions) ([]IngestResult, error) {
+	results := make([]IngestResult, 0, len(files))
+	for _, f := range files {
+		res := IngestResult{Path: f.Path, Agent: f.Agent}
+		if f.Size == 0 && f.ModTime.IsZero() {
+			if info, err := os.Stat(f.Path); err == nil {
+				f.Size, f.ModTime = info.Size(), info.ModTime()
+			}
+		}
+
+		if !opts.Force {
+			existing, err := ts.GetByPath(f.Path)
+			if err != nil {
+				return results, err
+			}
+			if existing != nil && existing.FileSize == f.Size && existing.FileMTime.Equal(f.ModTime.UTC()) {
+				res.TranscriptID = existing.ID
+				res.Turns = existing.TurnCount
+				res.Pane = existing.PaneIndex
+				res.BeadID = existing.BeadID
+				res.Unchanged = true
+				results = append(results, res)
+				continue
+			}
+		}
+
+		t, err := ParseFile(f.Agent, f.Path)
+		if err != nil {
+			res.Error = err.Error()
+			results = append(results, res)
+			continue
+		}
+		if t.Cwd == "" && opts.ProjectDir != "" {
+			t.Cwd = opts.ProjectDir
+			for i := range t.Turns {
+				for j := range t.Turns[i].ToolCalls {
+					t.Turns[i].ToolCalls[j].Files = resolvePaths(t.Turns[i].ToolCalls[j].Files, t.Cwd)
+				}
+			}
+		}
+
+		pane, bead := opts.Linker.Link(t)
+		if opts.Pane != nil {
+			pane = *opts.Pane
+		}
+		if opts.BeadID != "" {
+			bead = opts.BeadID
+		}
+
+		rec, turns := toRecord(t, f)
+		rec.SessionName = opts.Session
+		rec.BeadID = bead
+		if pane >= 0 {
+			rec.PaneIndex = &pane
+		}
+		if err := ts.Save(rec, turns); err != nil {
+			return results, fmt.Errorf("saving %s: %w", f.Path, err)
+		}
+
+		res.TranscriptID = rec.ID
+		res.Turns = len(turns)
+		for _, turn := range t.Turns {
+			res.ToolCalls += len(turn.ToolCalls)
+		}
+		res.FilesEdited = len(t.FilesEdited())
+		res.Pane = rec.PaneIndex
+		res.BeadID = bead
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// toRecord converts a parsed transcript into its stored form.
+func toRecord(t *Transcript, f File) (*state.TranscriptRecord, []state.TranscriptTurn) {
+	rec := &state.TranscriptRecord{
+		Path:           t.Path,
+		Agent:          string(t.Agent),
+		AgentSessionID: t.SessionID,
+		Cwd:            t.Cwd,
+		StartedAt:      timePtr(t.StartedAt),
+		EndedAt:        timePtr(t.EndedAt()),
+		FileSize:       f.Size,
+		FileMTime:      f.ModTime.UTC(),
+	}
+	turns := make([]state.TranscriptTurn, 0, len(t.Turns))
+	for _, turn := range t.Turns {
+		st := state.TranscriptTurn{
+			Seq:       turn.Seq,
+			Role:      string(turn.Role),
+			Text:      turn.Text,
+			Model:     turn.Model,
+			Timestamp: timePtr(turn.Timestamp),
+		}
+		for _, tc := range turn.ToolCalls {
+			st.ToolCalls = append(st.ToolCalls, state.TranscriptToolCall{
+				CallID:  tc.ID,
+				Name:    tc.Name,
+				Input:   tc.Input,
+				Output:  tc.Output,
+				IsError: tc.IsError,
+				Files:   tc.Files,
+			})
+		}
+		turns = append(turns, st)
+	}
+	return rec, turns
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// SyncSession discovers every agent transcript written for projectDir since
+// the given time and ingests the new or changed ones for session.
+func SyncSession(ts *state.TranscriptStore, session, projectDir string, since time.Time) ([]IngestResult, error) {
+	files, err := DefaultRoots().Discover(projectDir, Agents, since)
+	if err != nil {
+		return nil, err
+	}
+	return Ingest(ts, files, IngestOptions{
+		Session:    session,
+		ProjectDir: projectDir,
+		Linker:     SessionLinker(session),
+	})
+}
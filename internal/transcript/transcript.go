@@ -0,0 +1,235 @@
+// Package transcript parses the on-disk conversation transcripts written by
+// Claude Code, Codex and Gemini CLI into normalized turns: user prompts,
+// assistant text, tool calls with their arguments and results, and the files
+// those tool calls edited.
+package transcript
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Agent identifies the CLI that wrote a transcript.
+type Agent string
+
+const (
+	AgentClaude Agent = "claude"
+	AgentCodex  Agent = "codex"
+	AgentGemini Agent = "gemini"
+)
+
+// Agents lists the supported transcript formats.
+var Agents = []Agent{AgentClaude, AgentCodex, AgentGemini}
+
+// ParseAgent normalizes an agent name, accepting ntm's pane type aliases.
+func ParseAgent(s string) (Agent, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "claude", "cc", "claude-code":
+		return AgentClaude, nil
+	case "codex", "cod":
+		return AgentCodex, nil
+	case "gemini", "gmi":
+		return AgentGemini, nil
+	}
+	return "", fmt.Errorf("unknown agent %q (expected claude, codex or gemini)", s)
+}
+
+// Role is who produced a turn.
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+)
+
+// Transcript is one agent conversation.
+type Transcript struct {
+	Agent     Agent     `json:"agent"`
+	Path      string    `json:"path"`
+	SessionID string    `json:"session_id,omitempty"` // The agent's own session ID
+	Cwd       string    `json:"cwd,omitempty"`
+	StartedAt time.Time `json:"started_at,omitempty"`
+	Turns     []Turn    `json:"turns"`
+}
+
+// Turn is a user prompt or one assistant response with its tool calls.
+type Turn struct {
+	Seq       int        `json:"seq"`
+	Role      Role       `json:"role"`
+	Text      string     `json:"text,omitempty"`
+	Model     string     `json:"model,omitempty"`
+	Timestamp time.Time  `json:"timestamp,omitempty"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+}
+
+// ToolCall is a tool invocation and its result.
+type ToolCall struct {
+	ID      string `json:"id,omitempty"`
+	Name    string `json:"name"`
+	Input   string `json:"input,omitempty"` // Arguments as JSON (or raw text)
+	Output  string `json:"output,omitempty"`
+	IsError bool   `json:"is_error,omitempty"`
+	// Files are the paths this call created, modified or deleted.
+	Files []string `json:"files,omitempty"`
+}
+
+// EndedAt returns the timestamp of the last turn.
+func (t *Transcript) EndedAt() time.Time {
+	for i := len(t.Turns) - 1; i >= 0; i-- {
+		if !t.Turns[i].Timestamp.IsZero() {
+			return t.Turns[i].Timestamp
+		}
+	}
+	return t.StartedAt
+}
+
+// FilesEdited returns every path edited in the transcript, in first-edit order.
+func (t *Transcript) FilesEdited() []string {
+	seen := make(map[string]bool)
+	var files []string
+	for _, turn := range t.Turns {
+		for _, tc := range turn.ToolCalls {
+			for _, f := range tc.Files {
+				if !seen[f] {
+					seen[f] = true
+					files = append(files, f)
+				}
+			}
+		}
+	}
+	return files
+}
+
+// ParseFile parses a transcript in the given agent's format.
+func ParseFile(agent Agent, path string) (*Transcript, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var t *Transcript
+	switch agent {
+	case AgentClaude:
+		t, err = parseClaude(data)
+	case AgentCodex:
+		t, err = parseCodex(data)
+	case AgentGemini:
+		t, err = parseGemini(data)
+	default:
+		return nil, fmt.Errorf("unsupported agent %q", agent)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s transcript %s: %w", agent, path, err)
+	}
+	t.Agent = agent
+	t.Path = path
+	for i := range t.Turns {
+		t.Turns[i].Seq = i
+		for j := range t.Turns[i].ToolCalls {
+			t.Turns[i].ToolCalls[j].Files = resolvePaths(t.Turns[i].ToolCalls[j].Files, t.Cwd)
+		}
+	}
+	if t.StartedAt.IsZero() && len(t.Turns) > 0 {
+		t.StartedAt = t.Turns[0].Timestamp
+	}
+	return t, nil
+}
+
+// DetectAgent guesses a transcript's format from its location and content.
+func DetectAgent(path string) (Agent, error) {
+	slashed := filepath.ToSlash(path)
+	switch {
+	case strings.Contains(slashed, "/.claude/"):
+		return AgentClaude, nil
+	case strings.Contains(slashed, "/.codex/"):
+		return AgentCodex, nil
+	case strings.Contains(slashed, "/.gemini/"):
+		return AgentGemini, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	head := make([]byte, 4096)
+	n, _ := f.Read(head)
+	s := string(head[:n])
+	switch {
+	case strings.Contains(s, `"sessionId"`) && strings.Contains(s, `"messages"`):
+		return AgentGemini, nil
+	case strings.Contains(s, `"session_meta"`) || strings.Contains(s, `"response_item"`):
+		return AgentCodex, nil
+	case strings.Contains(s, `"parentUuid"`) || strings.Contains(s, `"sessionId"`):
+		return AgentClaude, nil
+	}
+	return "", fmt.Errorf("cannot tell which agent wrote %s; pass --agent", path)
+}
+
+// resolvePaths makes edited paths absolute against the transcript's cwd.
+func resolvePaths(files []string, cwd string) []string {
+	out := files[:0]
+	for _, f := range files {
+		if f == "" {
+			continue
+		}
+		if !filepath.IsAbs(f) && cwd != "" {
+			f = filepath.Join(cwd, f)
+		}
+		out = append(out, filepath.Clean(f))
+	}
+	return out
+}
+
+// compactJSON re-encodes raw JSON without insignificant whitespace.
+func compactJSON(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return string(raw)
+	}
+	out, _ := json.Marshal(v)
+	return string(out)
+}
+
+// parseTime accepts RFC 3339 timestamps with or without fractional seconds.
+func parseTime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// stringField pulls a string argument out of a JSON object.
+func stringField(raw json.RawMessage, keys ...string) string {
+	var m map[string]interface{}
+	if json.Unmarshal(raw, &m) != nil {
+		return ""
+	}
+	for _, k := range keys {
+		if s, ok := m[k].(string); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// patchFiles extracts the paths from an apply_patch envelope.
+func patchFiles(patch string) []string {
+	var files []string
+	for _, line := range strings.Split(patch, "\n") {
+		line = strings.TrimSpace(line)
+		for _, prefix := range []string{"*** Update File: ", "*** Add File: ", "*** Delete File: ", "*** Move to: "} {
+			if strings.HasPrefix(line, prefix) {
+				files = append(files, strings.TrimSpace(strings.TrimPrefix(line, prefix)))
+			}
+		}
+	}
+	return files
+}
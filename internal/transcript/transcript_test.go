@@ -0,0 +1,319 @@
+package transcript
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/state"
+)
+
+const claudeFixture = `{"type":"queue-operation","operation":"enqueue","timestamp":"2026-03-01T10:00:00Z"}
+{"type":"user","isMeta":true,"cwd":"/work/app","sessionId":"c-1","timestamp":"2026-03-01T10:00:00Z","message":{"role":"user","content":"<local-command-caveat>ignored</local-command-caveat>"}}
+{"type":"user","cwd":"/work/app","sessionId":"c-1","timestamp":"2026-03-01T10:00:01Z","message":{"role":"user","content":"Fix the failing parser test in internal/parse"}}
+{"type":"assistant","cwd":"/work/app","sessionId":"c-1","timestamp":"2026-03-01T10:00:05Z","message":{"role":"assistant","model":"claude-opus","content":[{"type":"text","text":"Looking at the test."}]}}
+{"type":"assistant","cwd":"/work/app","sessionId":"c-1","timestamp":"2026-03-01T10:00:06Z","message":{"role":"assistant","model":"claude-opus","content":[{"type":"tool_use","id":"tu1","name":"Read","input":{"file_path":"/work/app/internal/parse/parse.go"}}]}}
+{"type":"user","cwd":"/work/app","sessionId":"c-1","timestamp":"2026-03-01T10:00:07Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"tu1","content":"package parse"}]}}
+{"type":"assistant","cwd":"/work/app","sessionId":"c-1","timestamp":"2026-03-01T10:00:08Z","message":{"role":"assistant","model":"claude-opus","content":[{"type":"tool_use","id":"tu2","name":"Edit","input":{"file_path":"internal/parse/parse.go","old_string":"a","new_string":"b"}}]}}
+{"type":"user","cwd":"/work/app","sessionId":"c-1","timestamp":"2026-03-01T10:00:09Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"tu2","content":[{"type":"text","text":"edit failed"}],"is_error":true}]}}
+{"type":"assistant","cwd":"/work/app","sessionId":"c-1","timestamp":"2026-03-01T10:00:10Z","message":{"role":"assistant","model":"claude-opus","content":[{"type":"text","text":"Fixed."}]}}
+{"type":"user","cwd":"/work/app","sessionId":"c-1","timestamp":"2026-03-01T10:01:00Z","message":{"role":"user","content":[{"type":"text","text":"thanks"}]}}
+`
+
+const codexFixture = `{"timestamp":"2026-03-01T11:00:00Z","type":"session_meta","payload":{"id":"x-1","cwd":"/work/app","timestamp":"2026-03-01T11:00:00Z"}}
+{"timestamp":"2026-03-01T11:00:00Z","type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"<environment_context>cwd</environment_context>"}]}}
+{"timestamp":"2026-03-01T11:00:00Z","type":"turn_context","payload":{"cwd":"/work/app","model":"gpt-5-codex"}}
+{"timestamp":"2026-03-01T11:00:01Z","type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"Add a --verbose flag"}]}}
+{"timestamp":"2026-03-01T11:00:01Z","type":"event_msg","payload":{"type":"user_message","message":"Add a --verbose flag"}}
+{"timestamp":"2026-03-01T11:00:02Z","type":"response_item","payload":{"type":"function_call","name":"shell","arguments":"{\"command\":[\"bash\",\"-lc\",\"ls\"]}","call_id":"c1"}}
+{"timestamp":"2026-03-01T11:00:03Z","type":"response_item","payload":{"type":"function_call_output","call_id":"c1","output":"{\"output\":\"main.go\\n\",\"metadata\":{\"exit_code\":0}}"}}
+{"timestamp":"2026-03-01T11:00:04Z","type":"response_item","payload":{"type":"custom_tool_call","name":"apply_patch","call_id":"c2","input":"*** Begin Patch\n*** Update File: cmd/main.go\n@@\n-a\n+b\n*** Add File: cmd/flags.go\n+package cmd\n*** End Patch"}}
+{"timestamp":"2026-03-01T11:00:05Z","type":"response_item","payload":{"type":"custom_tool_call_output","call_id":"c2","output":"{\"output\":\"error: context mismatch\",\"metadata\":{\"exit_code\":1}}"}}
+{"timestamp":"2026-03-01T11:00:06Z","type":"response_item","payload":{"type":"message","role":"assistant","content":[{"type":"output_text","text":"Added the flag."}]}}
+`
+
+const geminiFixture = `{
+  "sessionId": "g-1",
+  "projectHash": "abc",
+  "startTime": "2026-03-01T12:00:00Z",
+  "messages": [
+    {"timestamp": "2026-03-01T12:00:01Z", "type": "user", "content": "Write a README"},
+    {"timestamp": "2026-03-01T12:00:02Z", "type": "gemini", "model": "gemini-2.5-pro", "content": "Sure.",
+     "toolCalls": [{"id": "w1", "name": "write_file", "args": {"file_path": "README.md", "content": "# App"}, "status": "success", "resultDisplay": "Wrote README.md"}]},
+    {"timestamp": "2026-03-01T12:00:03Z", "type": "gemini", "content": [{"text": "Done."}]},
+    {"timestamp": "2026-03-01T12:00:04Z", "type": "info", "content": "ignored"}
+  ]
+}`
+
+func writeFixture(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseClaude(t *testing.T) {
+	t.Parallel()
+	path := writeFixture(t, t.TempDir(), "c-1.jsonl", claudeFixture)
+	tr, err := ParseFile(AgentClaude, path)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	if tr.SessionID != "c-1" || tr.Cwd != "/work/app" {
+		t.Errorf("header = %q %q", tr.SessionID, tr.Cwd)
+	}
+	if len(tr.Turns) != 3 {
+		t.Fatalf("turns = %d, want 3: %+v", len(tr.Turns), tr.Turns)
+	}
+	if tr.Turns[0].Role != RoleUser || !strings.HasPrefix(tr.Turns[0].Text, "Fix the failing") {
+		t.Errorf("turn 0 = %+v", tr.Turns[0])
+	}
+	a := tr.Turns[1]
+	if a.Role != RoleAssistant || a.Model != "claude-opus" || a.Text != "Looking at the test.\n\nFixed." {
+		t.Errorf("assistant turn = %+v", a)
+	}
+	if len(a.ToolCalls) != 2 {
+		t.Fatalf("tool calls = %+v", a.ToolCalls)
+	}
+	if a.ToolCalls[0].Output != "package parse" || a.ToolCalls[0].Files != nil {
+		t.Errorf("Read call = %+v", a.ToolCalls[0])
+	}
+	edit := a.ToolCalls[1]
+	if !edit.IsError || edit.Output != "edit failed" || len(edit.Files) != 1 || edit.Files[0] != "/work/app/internal/parse/parse.go" {
+		t.Errorf("Edit call = %+v", edit)
+	}
+	if tr.Turns[2].Text != "thanks" || tr.Turns[2].Seq != 2 {
+		t.Errorf("turn 2 = %+v", tr.Turns[2])
+	}
+	if want := time.Date(2026, 3, 1, 10, 1, 0, 0, time.UTC); !tr.EndedAt().Equal(want) {
+		t.Errorf("EndedAt = %v, want %v", tr.EndedAt(), want)
+	}
+}
+
+func TestParseCodex(t *testing.T) {
+	t.Parallel()
+	path := writeFixture(t, t.TempDir(), "rollout.jsonl", codexFixture)
+	tr, err := ParseFile(AgentCodex, path)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	if tr.SessionID != "x-1" || tr.Cwd != "/work/app" {
+		t.Errorf("header = %q %q", tr.SessionID, tr.Cwd)
+	}
+	if len(tr.Turns) != 2 || tr.Turns[0].Text != "Add a --verbose flag" {
+		t.Fatalf("turns = %+v", tr.Turns)
+	}
+	a := tr.Turns[1]
+	if a.Model != "gpt-5-codex" || a.Text != "Added the flag." || len(a.ToolCalls) != 2 {
+		t.Fatalf("assistant turn = %+v", a)
+	}
+	if sh := a.ToolCalls[0]; sh.Output != "main.go\n" || sh.IsError {
+		t.Errorf("shell call = %+v", sh)
+	}
+	patch := a.ToolCalls[1]
+	if !patch.IsError || patch.Output != "error: context mismatch" {
+		t.Errorf("apply_patch result = %+v", patch)
+	}
+	want := []string{"/work/app/cmd/main.go", "/work/app/cmd/flags.go"}
+	if strings.Join(patch.Files, ",") != strings.Join(want, ",") {
+		t.Errorf("apply_patch files = %v, want %v", patch.Files, want)
+	}
+}
+
+func TestParseGemini(t *testing.T) {
+	t.Parallel()
+	path := writeFixture(t, t.TempDir(), "session-1.json", geminiFixture)
+	tr, err := ParseFile(AgentGemini, path)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	if len(tr.Turns) != 2 {
+		t.Fatalf("turns = %+v", tr.Turns)
+	}
+	a := tr.Turns[1]
+	if a.Text != "Sure.\n\nDone." || a.Model != "gemini-2.5-pro" || len(a.ToolCalls) != 1 {
+		t.Fatalf("assistant turn = %+v", a)
+	}
+	// Gemini does not record a cwd, so edited paths stay relative until ingest.
+	if tc := a.ToolCalls[0]; tc.Output != "Wrote README.md" || len(tc.Files) != 1 || tc.Files[0] != "README.md" {
+		t.Errorf("write_file call = %+v", tc)
+	}
+}
+
+func TestDetectAgent(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	tests := []struct {
+		path string
+		want Agent
+	}{
+		{writeFixture(t, dir, "a.jsonl", claudeFixture), AgentClaude},
+		{writeFixture(t, dir, "b.jsonl", codexFixture), AgentCodex},
+		{writeFixture(t, dir, "c.json", geminiFixture), AgentGemini},
+		{"/home/u/.codex/sessions/2026/03/01/rollout.jsonl", AgentCodex},
+	}
+	for _, tt := range tests {
+		got, err := DetectAgent(tt.path)
+		if err != nil || got != tt.want {
+			t.Errorf("DetectAgent(%s) = %q, %v; want %q", tt.path, got, err, tt.want)
+		}
+	}
+	if _, err := DetectAgent(writeFixture(t, dir, "d.txt", "hello")); err == nil {
+		t.Error("DetectAgent(plain text) should fail")
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	t.Parallel()
+	home := t.TempDir()
+	roots := Roots{
+		Claude: filepath.Join(home, ".claude"),
+		Codex:  filepath.Join(home, ".codex"),
+		Gemini: filepath.Join(home, ".gemini"),
+	}
+	project := "/work/app"
+
+	writeFixture(t, roots.ClaudeProjectDir(project), "c-1.jsonl", claudeFixture)
+	writeFixture(t, roots.ClaudeProjectDir("/work/other"), "c-2.jsonl", claudeFixture)
+	writeFixture(t, filepath.Join(roots.Codex, "sessions", "2026", "03", "01"), "rollout-a.jsonl", codexFixture)
+	writeFixture(t, filepath.Join(roots.Codex, "sessions", "2026", "03", "01"), "rollout-b.jsonl",
+		strings.Replace(codexFixture, "/work/app", "/elsewhere", -1))
+	writeFixture(t, roots.GeminiChatDir(project), "session-1.json", geminiFixture)
+
+	files, err := roots.Discover(project, Agents, time.Time{})
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	got := make(map[Agent][]string)
+	for _, f := range files {
+		got[f.Agent] = append(got[f.Agent], filepath.Base(f.Path))
+	}
+	if len(files) != 3 || got[AgentClaude][0] != "c-1.jsonl" || got[AgentCodex][0] != "rollout-a.jsonl" || got[AgentGemini][0] != "session-1.json" {
+		t.Errorf("Discover = %v", got)
+	}
+
+	files, _ = roots.Discover(project, []Agent{AgentCodex}, time.Now().Add(time.Hour))
+	if len(files) != 0 {
+		t.Errorf("Discover with future since = %v", files)
+	}
+}
+
+func TestLinker(t *testing.T) {
+	t.Parallel()
+	at := func(min int) time.Time { return time.Date(2026, 3, 1, 10, min, 0, 0, time.UTC) }
+	tr := &Transcript{
+		StartedAt: at(0),
+		Turns: []Turn{
+			{Role: RoleUser, Text: "Fix the failing parser test in internal/parse", Timestamp: at(0)},
+			{Role: RoleAssistant, Text: "ok", Timestamp: at(1)},
+			{Role: RoleUser, Text: "thanks", Timestamp: at(5)},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		linker   Linker
+		wantPane int
+		wantBead string
+	}{
+		{"no data", Linker{}, -1, ""},
+		{"single target", Linker{Prompts: []SentPrompt{{Text: "Fix the failing  parser test in internal/parse\n", Panes: []int{3}, At: at(0)}}}, 3, ""},
+		{"broadcast narrowed by later prompt", Linker{Prompts: []SentPrompt{
+			{Text: "Fix the failing parser test in internal/parse", Panes: []int{1, 2}, At: at(0)},
+			{Text: "thanks", Panes: []int{2}, At: at(5)},
+		}}, 2, ""},
+		{"broadcast stays ambiguous", Linker{Prompts: []SentPrompt{
+			{Text: "Fix the failing parser test in internal/parse", Panes: []int{1, 2}, At: at(0)},
+		}}, -1, ""},
+		{"outside time window", Linker{Prompts: []SentPrompt{{Text: "thanks", Panes: []int{4}, At: at(0).Add(-time.Hour)}}}, -1, ""},
+		{"prefix match", Linker{Prompts: []SentPrompt{{Text: "Fix the failing parser test in internal/parse and run go vet", Panes: []int{5}}}}, 5, ""},
+		{"assignment by prompt", Linker{Assignments: []BeadAssignment{
+			{BeadID: "bd-1", Pane: 7, Prompt: "Fix the failing parser test in internal/parse", Start: at(0)},
+		}}, 7, "bd-1"},
+		{"assignment by pane and time", Linker{
+			Prompts: []SentPrompt{{Text: "thanks", Panes: []int{3}, At: at(5)}},
+			Assignments: []BeadAssignment{
+				{BeadID: "bd-old", Pane: 3, Start: at(0).Add(-2 * time.Hour), End: at(0).Add(-time.Hour)},
+				{BeadID: "bd-cur", Pane: 3, Start: at(0).Add(-time.Minute)},
+				{BeadID: "bd-later", Pane: 3, Start: at(30)},
+				{BeadID: "bd-other", Pane: 4, Start: at(0)},
+			},
+		}, 3, "bd-cur"},
+	}
+	for _, tt := range tests {
+		pane, bead := tt.linker.Link(tr)
+		if pane != tt.wantPane || bead != tt.wantBead {
+			t.Errorf("%s: Link = %d, %q; want %d, %q", tt.name, pane, bead, tt.wantPane, tt.wantBead)
+		}
+	}
+}
+
+func TestIngest(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	store, err := state.Open(filepath.Join(dir, "state.db"))
+	if err != nil {
+		t.Fatalf("state.Open: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	if err := store.Migrate(); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	ts := state.NewTranscriptStore(store)
+
+	files := []File{
+		{Agent: AgentClaude, Path: writeFixture(t, dir, "c-1.jsonl", claudeFixture)},
+		{Agent: AgentGemini, Path: writeFixture(t, dir, "session-1.json", geminiFixture)},
+		{Agent: AgentGemini, Path: writeFixture(t, dir, "empty.json", "")}, // Not valid JSON
+	}
+
+	opts := IngestOptions{
+		Session:    "app",
+		ProjectDir: "/work/app",
+		Linker: Linker{Prompts: []SentPrompt{
+			{Text: "Fix the failing parser test in internal/parse", Panes: []int{1}},
+			{Text: "Write a README", Panes: []int{2}},
+		}},
+	}
+	results, err := Ingest(ts, files, opts)
+	if err != nil {
+		t.Fatalf("Ingest: %v", err)
+	}
+	if len(results) != 3 || results[2].Error == "" {
+		t.Fatalf("results = %+v", results)
+	}
+	if r := results[0]; r.Turns != 3 || r.ToolCalls != 2 || r.FilesEdited != 1 || r.Pane == nil || *r.Pane != 1 {
+		t.Errorf("claude result = %+v", r)
+	}
+	if r := results[1]; r.Pane == nil || *r.Pane != 2 {
+		t.Errorf("gemini result = %+v", r)
+	}
+
+	edits, err := ts.FileEdits(state.TranscriptFilter{SessionName: "app", Agent: "gemini"})
+	if err != nil || len(edits) != 1 || edits[0].Path != "/work/app/README.md" {
+		t.Errorf("gemini edits = %+v, %v (want path resolved against project dir)", edits, err)
+	}
+
+	// Unchanged files are skipped; forced overrides apply on re-ingest.
+	results, err = Ingest(ts, files[:1], opts)
+	if err != nil || !results[0].Unchanged {
+		t.Fatalf("re-ingest = %+v, %v", results, err)
+	}
+	pane := 9
+	opts.Force, opts.Pane, opts.BeadID = true, &pane, "bd-9"
+	results, err = Ingest(ts, files[:1], opts)
+	if err != nil || results[0].Unchanged || *results[0].Pane != 9 || results[0].BeadID != "bd-9" {
+		t.Fatalf("forced re-ingest = %+v, %v", results, err)
+	}
+	list, _ := ts.List(state.TranscriptFilter{BeadID: "bd-9"})
+	if len(list) != 1 || list[0].TurnCount != 3 {
+		t.Errorf("List(bead) = %+v", list)
+	}
+}
@@ -13,9 +13,12 @@ import (
 	"github.com/shahbajlive/ntm/internal/alerts"
 	"github.com/shahbajlive/ntm/internal/bv"
 	"github.com/shahbajlive/ntm/internal/cass"
+	"github.com/shahbajlive/ntm/internal/cost"
 	"github.com/shahbajlive/ntm/internal/handoff"
 	"github.com/shahbajlive/ntm/internal/history"
 	"github.com/shahbajlive/ntm/internal/integrations/pt"
+	"github.com/shahbajlive/ntm/internal/quota"
+	"github.com/shahbajlive/ntm/internal/ratelimit"
 	"github.com/shahbajlive/ntm/internal/robot"
 	"github.com/shahbajlive/ntm/internal/state"
 	"github.com/shahbajlive/ntm/internal/tracker"
@@ -156,6 +159,76 @@ func (m *Model) fetchTimelineCmd() tea.Cmd {
 	}
 }
 
+// fetchPromptQueueCmd counts the prompts waiting for each pane of the session.
+func (m *Model) fetchPromptQueueCmd() tea.Cmd {
+	gen := m.nextGen(refreshPromptQueue)
+	session := m.session
+	return func() tea.Msg {
+		store, err := state.Open("")
+		if err != nil {
+			return PromptQueueUpdateMsg{Err: err, Gen: gen}
+		}
+		defer store.Close()
+		if err := store.Migrate(); err != nil {
+			return PromptQueueUpdateMsg{Err: err, Gen: gen}
+		}
+		depth, err := state.NewPromptQueueStore(store).Depth(session)
+		return PromptQueueUpdateMsg{Depth: depth, Err: err, Gen: gen}
+	}
+}
+
+// fetchBudgetsCmd measures the session's spend against configured budgets.
+func (m *Model) fetchBudgetsCmd() tea.Cmd {
+	gen := m.nextGen(refreshBudgets)
+	session := m.session
+	var budgets []cost.Budget
+	if m.cfg != nil {
+		budgets = m.cfg.Budgets.Budgets()
+	}
+	return func() tea.Msg {
+		store, err := state.Open("")
+		if err != nil {
+			return BudgetUpdateMsg{Err: err, Gen: gen}
+		}
+		defer store.Close()
+		if err := store.Migrate(); err != nil {
+			return BudgetUpdateMsg{Err: err, Gen: gen}
+		}
+		ss := state.NewSpendStore(store)
+		statuses, err := cost.Evaluate(budgets, session, nil, ss.BudgetSpend)
+		if err != nil {
+			return BudgetUpdateMsg{Err: err, Gen: gen}
+		}
+		daily, err := ss.BudgetSpend(cost.ScopeDaily, "", "")
+		return BudgetUpdateMsg{Statuses: statuses, DailyUSD: daily.USD, Err: err, Gen: gen}
+	}
+}
+
+// fetchQuotaForecastsCmd forecasts when each active account will hit its limit.
+func (m *Model) fetchQuotaForecastsCmd() tea.Cmd {
+	gen := m.nextGen(refreshQuotaForecasts)
+	projectDir := m.projectDir
+	fc := quota.DefaultForecastConfig()
+	if m.cfg != nil {
+		fc = quota.ForecastConfigFor(m.cfg.Rotation.Forecast.WindowHours, m.cfg.Rotation.Forecast.PreemptMinutes)
+	}
+	return func() tea.Msg {
+		store, err := state.Open("")
+		if err != nil {
+			return QuotaForecastUpdateMsg{Err: err, Gen: gen}
+		}
+		defer store.Close()
+		if err := store.Migrate(); err != nil {
+			return QuotaForecastUpdateMsg{Err: err, Gen: gen}
+		}
+		limits := func(p quota.Provider) []time.Time {
+			return ratelimit.RecentLimitTimes(projectDir, string(p))
+		}
+		forecasts, err := state.NewQuotaStore(store).ActiveForecasts(limits, fc, time.Now())
+		return QuotaForecastUpdateMsg{Forecasts: forecasts, Err: err, Gen: gen}
+	}
+}
+
 // fetchHandoffCmd fetches the latest handoff goal/now + metadata for the session.
 func (m *Model) fetchHandoffCmd() tea.Cmd {
 	gen := m.nextGen(refreshHandoff)
@@ -33,8 +33,10 @@ import (
 	"github.com/shahbajlive/ntm/internal/history"
 	"github.com/shahbajlive/ntm/internal/integrations/pt"
 	"github.com/shahbajlive/ntm/internal/integrations/rano"
+	"github.com/shahbajlive/ntm/internal/quota"
 	"github.com/shahbajlive/ntm/internal/robot"
 	"github.com/shahbajlive/ntm/internal/scanner"
+	"github.com/shahbajlive/ntm/internal/segment"
 	sessionPkg "github.com/shahbajlive/ntm/internal/session"
 	"github.com/shahbajlive/ntm/internal/state"
 	"github.com/shahbajlive/ntm/internal/status"
@@ -273,6 +275,28 @@ type PendingRotationsUpdateMsg struct {
 	Gen     uint64
 }
 
+// PromptQueueUpdateMsg is sent when prompt queue depths are fetched
+type PromptQueueUpdateMsg struct {
+	Depth state.QueueDepth
+	Err   error
+	Gen   uint64
+}
+
+// BudgetUpdateMsg is sent when spend budget status is fetched
+type BudgetUpdateMsg struct {
+	Statuses []cost.BudgetStatus
+	DailyUSD float64 // Today's recorded spend across all sessions
+	Err      error
+	Gen      uint64
+}
+
+// QuotaForecastUpdateMsg is sent when per-account limit forecasts are computed
+type QuotaForecastUpdateMsg struct {
+	Forecasts []quota.Forecast
+	Err       error
+	Gen       uint64
+}
+
 // RoutingScore holds routing info for a single agent
 type RoutingScore struct {
 	Score         float64 // 0-100 composite routing score
@@ -326,6 +350,9 @@ const (
 	refreshDCG
 	refreshPendingRotations
 	refreshPTHealth
+	refreshPromptQueue
+	refreshBudgets
+	refreshQuotaForecasts
 	refreshSourceCount
 )
 
@@ -491,6 +518,7 @@ type Model struct {
 	beadsPanel           *panels.BeadsPanel
 	alertsPanel          *panels.AlertsPanel
 	costPanel            *panels.CostPanel
+	quotaPanel           *panels.QuotaPanel
 	ranoNetworkPanel     *panels.RanoNetworkPanel
 	rchPanel             *panels.RCHPanel
 	metricsPanel         *panels.MetricsPanel
@@ -525,6 +553,16 @@ type Model struct {
 	costSnapshots           []costSnapshot     // rolling window for last-hour computations
 	costDailyBudgetUSD      float64            // 0 disables budget display
 
+	// Configured [budgets] measured against the spend ledger
+	costBudgets       []cost.BudgetStatus
+	costDailySpentUSD float64 // Today's ledger spend across all sessions
+	lastBudgetFetch   time.Time
+	fetchingBudgets   bool
+
+	// Per-account limit forecasts from the quota sample history
+	lastQuotaForecastFetch time.Time
+	fetchingQuotaForecasts bool
+
 	// Process triage health states (from pt.HealthMonitor)
 	healthStates map[string]*pt.AgentState // pane -> health state
 
@@ -534,6 +572,10 @@ type Model struct {
 	lastPendingFetch    time.Time
 	fetchingPendingRot  bool
 
+	// Prompts queued for idle panes ('ntm send --queue')
+	lastQueueFetch time.Time
+	fetchingQueue  bool
+
 	// Checkpoint status
 	checkpointCount     int                    // Number of checkpoints for this session
 	latestCheckpoint    *checkpoint.Checkpoint // Most recent checkpoint
@@ -621,6 +663,10 @@ type PaneStatus struct {
 	MailUnread int
 	MailUrgent int
 
+	// Prompt queue tracking
+	QueuedPrompts int // Waiting for this pane
+	QueuedShared  int // Waiting for any idle pane of this agent type
+
 	TokenVelocity float64 // Estimated tokens/sec
 
 	// Local agent performance (Ollama) - best-effort estimates.
@@ -639,6 +685,10 @@ type PaneStatus struct {
 	// Rotation tracking
 	IsRotating bool       // True when agent rotation is in progress
 	RotatedAt  *time.Time // When agent was last rotated (nil if never)
+
+	// Latest activity from the segmented output
+	LastToolCall string // e.g. "Bash go test ./..."
+	LastDiff     string // e.g. "main.go +2 -1"
 }
 
 type costSnapshot struct {
@@ -709,6 +759,9 @@ const (
 	SpawnIdleRefreshInterval   = 2 * time.Second        // Poll slowly when no spawn is active
 	MailInboxRefreshInterval   = 30 * time.Second
 	CostPromptRefreshInterval  = 5 * time.Second // Poll ~/.ntm/sessions/<session>/prompts.json
+	PromptQueueRefreshInterval = 5 * time.Second
+	BudgetRefreshInterval      = 30 * time.Second
+	QuotaRefreshInterval       = 30 * time.Second
 )
 
 func (m *Model) initRenderer(width int) {
@@ -809,6 +862,7 @@ func New(session, projectDir string) Model {
 		beadsPanel:           panels.NewBeadsPanel(),
 		alertsPanel:          panels.NewAlertsPanel(),
 		costPanel:            panels.NewCostPanel(),
+		quotaPanel:           panels.NewQuotaPanel(),
 		ranoNetworkPanel:     panels.NewRanoNetworkPanel(),
 		rchPanel:             panels.NewRCHPanel(),
 		metricsPanel:         panels.NewMetricsPanel(),
@@ -838,6 +892,7 @@ func New(session, projectDir string) Model {
 		fetchingRanoNetwork: true,
 		fetchingRCH:         true,
 		fetchingDCG:         true,
+		fetchingQueue:       true,
 	}
 
 	// Initialize last-fetch timestamps to start cadence after the initial fetches from Init.
@@ -855,6 +910,9 @@ func New(session, projectDir string) Model {
 	m.lastHandoffFetch = now
 	m.lastSpawnFetch = now
 	m.lastMailInboxFetch = now
+	m.lastQueueFetch = now
+	m.lastBudgetFetch = now
+	m.lastQuotaForecastFetch = now
 
 	// Initialize activity tracking for adaptive tick rate (fixes #32)
 	m.lastActivity = now
@@ -927,6 +985,9 @@ func (m Model) Init() tea.Cmd {
 		m.fetchRCHStatus(),
 		m.fetchDCGStatus(),
 		m.fetchPendingRotations(),
+		m.fetchPromptQueueCmd(),
+		m.fetchBudgetsCmd(),
+		m.fetchQuotaForecastsCmd(),
 		m.fetchPTHealthStatesCmd(),
 		m.subscribeToConfig(),
 	)
@@ -1976,6 +2037,21 @@ func (m *Model) fullRefresh(cancelInFlight bool) []tea.Cmd {
 		m.lastPendingFetch = now
 		cmds = append(cmds, m.fetchPendingRotations())
 	}
+	if !m.fetchingQueue {
+		m.fetchingQueue = true
+		m.lastQueueFetch = now
+		cmds = append(cmds, m.fetchPromptQueueCmd())
+	}
+	if !m.fetchingBudgets {
+		m.fetchingBudgets = true
+		m.lastBudgetFetch = now
+		cmds = append(cmds, m.fetchBudgetsCmd())
+	}
+	if !m.fetchingQuotaForecasts {
+		m.fetchingQuotaForecasts = true
+		m.lastQuotaForecastFetch = now
+		cmds = append(cmds, m.fetchQuotaForecastsCmd())
+	}
 
 	// Agent mail status is light enough to refresh on demand.
 	cmds = append(cmds, m.fetchAgentMailStatus())
@@ -2832,7 +2908,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// Rate limit check
 				if st.State == status.StateError && st.ErrorType == status.ErrorRateLimit {
 					state = "rate_limited"
-				} else if ps.LastCompaction != nil && state != string(status.StateError) {
+				} else if ps.LastCompaction != nil && state != string(status.StateError) && st.State != status.StateAwaitingPermission {
 					state = "compacted"
 				}
 				ps.State = state
@@ -2851,6 +2927,15 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					ps.ContextModel = modelName
 				}
 
+				// Last tool call and diff; kept when they scroll out of the capture
+				latest := segment.Summarize(segment.Parse(data.AgentType, data.Output))
+				if latest.ToolCall != nil {
+					ps.LastToolCall = latest.ToolCall.Headline()
+				}
+				if latest.Diff != nil {
+					ps.LastDiff = latest.Diff.Headline()
+				}
+
 				// Compaction check
 				event, recoverySent, _ := m.compaction.CheckAndRecover(data.Output, statusAgentType, m.session, data.PaneIndex)
 
@@ -2914,8 +2999,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Rate limit should be shown with special indicator
 			if st.State == status.StateError && st.ErrorType == status.ErrorRateLimit {
 				state = "rate_limited"
-			} else if ps.LastCompaction != nil && state != string(status.StateError) {
+			} else if ps.LastCompaction != nil && state != string(status.StateError) && st.State != status.StateAwaitingPermission {
 				// Compaction warning should override idle/working but not errors
+				// or permission prompts
 				state = "compacted"
 			}
 			ps.State = state
@@ -3220,6 +3306,50 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Refresh the pending rotations list
 		return m, m.fetchPendingRotations()
 
+	case PromptQueueUpdateMsg:
+		if !m.acceptUpdate(refreshPromptQueue, msg.Gen) {
+			return m, nil
+		}
+		m.fetchingQueue = false
+		m.lastQueueFetch = time.Now()
+		if msg.Err == nil {
+			for _, p := range m.panes {
+				ps := m.paneStatus[p.Index]
+				ps.QueuedPrompts = msg.Depth.ByPane[p.ID]
+				ps.QueuedShared = msg.Depth.ByAgentType[string(p.Type)]
+				m.paneStatus[p.Index] = ps
+			}
+			m.markUpdated(refreshPromptQueue, time.Now())
+		}
+		return m, nil
+
+	case BudgetUpdateMsg:
+		if !m.acceptUpdate(refreshBudgets, msg.Gen) {
+			return m, nil
+		}
+		m.fetchingBudgets = false
+		m.lastBudgetFetch = time.Now()
+		if msg.Err == nil {
+			m.costBudgets = msg.Statuses
+			m.costDailySpentUSD = msg.DailyUSD
+			m.refreshCostPanel(time.Now())
+			m.markUpdated(refreshBudgets, time.Now())
+		}
+		return m, nil
+
+	case QuotaForecastUpdateMsg:
+		if !m.acceptUpdate(refreshQuotaForecasts, msg.Gen) {
+			return m, nil
+		}
+		m.fetchingQuotaForecasts = false
+		m.lastQuotaForecastFetch = time.Now()
+		if msg.Err == nil && m.quotaPanel != nil {
+			m.quotaPanel.Refresh()
+			m.quotaPanel.SetForecasts(msg.Forecasts)
+			m.markUpdated(refreshQuotaForecasts, time.Now())
+		}
+		return m, nil
+
 	case HandoffUpdateMsg:
 		if !m.acceptUpdate(refreshHandoff, msg.Gen) {
 			return m, nil
@@ -3431,6 +3561,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if cmd != nil {
 					cmds = append(cmds, cmd)
 				}
+			} else if m.quotaPanel != nil && m.quotaPanel.IsFocused() {
+				var cmd tea.Cmd
+				_, cmd = m.quotaPanel.Update(keyMsg)
+				if cmd != nil {
+					cmds = append(cmds, cmd)
+				}
 			} else if m.metricsPanel != nil && m.metricsPanel.IsFocused() {
 				var cmd tea.Cmd
 				_, cmd = m.metricsPanel.Update(keyMsg)
@@ -3700,6 +3836,13 @@ func (m Model) renderHeaderSection() string {
 		b.WriteString(alert + "\n\n")
 	}
 
+	// ═══════════════════════════════════════════════════════════════
+	// PERMISSION ALERT (if any agent is waiting on a permission prompt)
+	// ═══════════════════════════════════════════════════════════════
+	if alert := m.renderPermissionAlert(); alert != "" {
+		b.WriteString(alert + "\n\n")
+	}
+
 	return b.String()
 }
 
@@ -4142,6 +4285,49 @@ func (m Model) renderRateLimitAlert() string {
 	return "  " + alertStyle.Render(msg)
 }
 
+// renderPermissionAlert renders an alert banner if any agent is waiting on
+// a tool-permission prompt
+func (m Model) renderPermissionAlert() string {
+	t := m.theme
+
+	var waiting []tmux.Pane
+	for _, p := range m.panes {
+		if ps, ok := m.paneStatus[p.Index]; ok && ps.State == "awaiting_permission" {
+			waiting = append(waiting, p)
+		}
+	}
+
+	if len(waiting) == 0 {
+		return ""
+	}
+
+	var msg string
+	if len(waiting) == 1 {
+		p := waiting[0]
+		what := ""
+		if req := m.agentStatuses[p.ID].Permission; req != nil {
+			what = fmt.Sprintf(" (%s %s)", req.Kind, truncate(req.Target(), 50))
+		}
+		msg = fmt.Sprintf("? Pane %d is waiting on a permission prompt%s. Run: ntm permissions approve %s %d",
+			p.Index, what, m.session, p.Index)
+	} else {
+		var idx []int
+		for _, p := range waiting {
+			idx = append(idx, p.Index)
+		}
+		msg = fmt.Sprintf("? Panes %v are waiting on permission prompts. Run: ntm permissions ls %s", idx, m.session)
+	}
+
+	alertStyle := lipgloss.NewStyle().
+		Background(t.Mauve).
+		Foreground(t.Base).
+		Bold(true).
+		Padding(0, 2).
+		Width(m.width - 6)
+
+	return "  " + alertStyle.Render(msg)
+}
+
 // renderContextBar renders a progress bar showing context usage percentage
 // High context (>80%) uses shimmer effect on warning indicators
 func (m Model) renderContextBar(percent float64, width int) string {
@@ -4756,6 +4942,9 @@ func (m Model) renderPaneGrid() string {
 		case "rate_limited":
 			statusIcon = "⏳"
 			statusColor = t.Maroon
+		case "awaiting_permission":
+			statusIcon = "?"
+			statusColor = t.Mauve
 		}
 		statusStyled := lipgloss.NewStyle().Foreground(statusColor).Bold(true).Render(statusIcon)
 
@@ -4849,6 +5038,18 @@ func (m Model) renderPaneGrid() string {
 			cardContent.WriteString(mailBadge + "\n")
 		}
 
+		// Prompt queue badge
+		if ps, ok := m.paneStatus[p.Index]; ok && ps.QueuedPrompts+ps.QueuedShared > 0 {
+			label := fmt.Sprintf("⏳ %d queued", ps.QueuedPrompts)
+			if ps.QueuedShared > 0 {
+				label = fmt.Sprintf("⏳ %d queued (+%d shared)", ps.QueuedPrompts, ps.QueuedShared)
+			}
+			cardContent.WriteString(styles.TextBadge(label, t.Yellow, t.Base, styles.BadgeOptions{
+				Style:    styles.BadgeStyleCompact,
+				ShowIcon: false,
+			}) + "\n")
+		}
+
 		// Health badges - show warning/error status and restart count
 		if ps, ok := m.paneStatus[p.Index]; ok {
 			// Health status badge
@@ -5096,6 +5297,9 @@ func (m *Model) resizeSidebarPanels(width, height int) {
 	if m.costPanel != nil {
 		m.costPanel.SetSize(width, height)
 	}
+	if m.quotaPanel != nil {
+		m.quotaPanel.SetSize(width, height)
+	}
 	if m.rchPanel != nil {
 		m.rchPanel.SetSize(width, height)
 	}
@@ -5172,66 +5376,9 @@ func refreshDue(last time.Time, interval time.Duration) bool {
 	return time.Since(last) >= interval
 }
 
+// tailDelta returns the output added since prev; see cost.OutputDelta.
 func tailDelta(prev, current string) string {
-	if current == "" {
-		return ""
-	}
-	if prev == "" {
-		curLines := strings.Split(current, "\n")
-		if len(curLines) > 0 && curLines[len(curLines)-1] == "" {
-			curLines = curLines[:len(curLines)-1]
-		}
-		if len(curLines) == 0 {
-			return ""
-		}
-		return strings.Join(curLines, "\n")
-	}
-	if prev == current {
-		return ""
-	}
-
-	prevLines := strings.Split(prev, "\n")
-	curLines := strings.Split(current, "\n")
-	if len(prevLines) > 0 && prevLines[len(prevLines)-1] == "" {
-		prevLines = prevLines[:len(prevLines)-1]
-	}
-	if len(curLines) > 0 && curLines[len(curLines)-1] == "" {
-		curLines = curLines[:len(curLines)-1]
-	}
-	if len(curLines) == 0 {
-		return ""
-	}
-
-	maxOverlap := len(prevLines)
-	if len(curLines) < maxOverlap {
-		maxOverlap = len(curLines)
-	}
-
-	overlap := 0
-	for k := maxOverlap; k > 0; k-- {
-		if slicesEqual(prevLines[len(prevLines)-k:], curLines[:k]) {
-			overlap = k
-			break
-		}
-	}
-
-	deltaLines := curLines[overlap:]
-	if len(deltaLines) == 0 {
-		return ""
-	}
-	return strings.Join(deltaLines, "\n")
-}
-
-func slicesEqual(a, b []string) bool {
-	if len(a) != len(b) {
-		return false
-	}
-	for i := range a {
-		if a[i] != b[i] {
-			return false
-		}
-	}
-	return true
+	return cost.OutputDelta(prev, current)
 }
 
 func (m *Model) recordCostOutputDelta(paneID, modelName, prevOutput, currentOutput string) {
@@ -5443,6 +5590,12 @@ func (m *Model) refreshCostPanel(now time.Time) {
 		LastHourUSD:     lastHour,
 		DailyBudgetUSD:  m.costDailyBudgetUSD,
 		BudgetUsedUSD:   total,
+		Budgets:         m.costBudgets,
+	}
+	// A configured daily budget is measured against the spend ledger
+	if data.DailyBudgetUSD == 0 && m.cfg != nil && m.cfg.Budgets.Enabled && m.cfg.Budgets.Daily.HardUSD > 0 {
+		data.DailyBudgetUSD = m.cfg.Budgets.Daily.HardUSD
+		data.BudgetUsedUSD = m.costDailySpentUSD
 	}
 
 	m.costData = data
@@ -5708,6 +5861,24 @@ func (m *Model) scheduleRefreshes(now time.Time) []tea.Cmd {
 		cmds = append(cmds, m.fetchDCGStatus())
 	}
 
+	if refreshDue(m.lastQueueFetch, PromptQueueRefreshInterval) && !m.fetchingQueue {
+		m.fetchingQueue = true
+		m.lastQueueFetch = now
+		cmds = append(cmds, m.fetchPromptQueueCmd())
+	}
+
+	if refreshDue(m.lastBudgetFetch, BudgetRefreshInterval) && !m.fetchingBudgets {
+		m.fetchingBudgets = true
+		m.lastBudgetFetch = now
+		cmds = append(cmds, m.fetchBudgetsCmd())
+	}
+
+	if refreshDue(m.lastQuotaForecastFetch, QuotaRefreshInterval) && !m.fetchingQuotaForecasts {
+		m.fetchingQuotaForecasts = true
+		m.lastQuotaForecastFetch = now
+		cmds = append(cmds, m.fetchQuotaForecastsCmd())
+	}
+
 	return cmds
 }
 
@@ -5951,6 +6122,26 @@ func (m Model) renderSidebar(width, height int) string {
 		}
 	}
 
+	// Quota usage and limit forecasts (best-effort, height-gated)
+	if m.quotaPanel != nil && height > 0 && m.quotaPanel.HasData() {
+		used := lipgloss.Height(strings.Join(lines, "\n"))
+		spacer := 1
+		panelHeight := height - used - spacer
+		if panelHeight >= m.quotaPanel.Config().MinHeight {
+			if panelHeight > 14 {
+				panelHeight = 14
+			}
+
+			if m.focusedPanel == PanelSidebar {
+				m.quotaPanel.Focus()
+			} else {
+				m.quotaPanel.Blur()
+			}
+			m.quotaPanel.SetSize(width, panelHeight)
+			lines = append(lines, "", m.quotaPanel.View())
+		}
+	}
+
 	// Metrics (best-effort, height-gated)
 	if m.metricsPanel != nil && height > 0 && (m.metricsError != nil || hasMetricsData(m.metricsData)) {
 		used := lipgloss.Height(strings.Join(lines, "\n"))
@@ -6408,12 +6599,28 @@ func (m Model) renderPaneDetail(width int) string {
 	case "compacted":
 		statusIcon = "⚠"
 		statusColor = t.Peach
+	case "awaiting_permission":
+		statusIcon = "?"
+		statusColor = t.Mauve
 	default:
 		statusIcon = "•"
 		statusColor = t.Overlay
 	}
 	lines = append(lines, "  "+lipgloss.NewStyle().Foreground(statusColor).Render(statusIcon+" "+statusText))
 
+	// Last tool call and diff
+	if ps.LastToolCall != "" || ps.LastDiff != "" {
+		lines = append(lines, "")
+		lines = append(lines, lipgloss.NewStyle().Bold(true).Foreground(t.Lavender).Render("Activity"))
+		lines = append(lines, "")
+		if ps.LastToolCall != "" {
+			lines = append(lines, "  "+labelStyle.Render("Tool:")+valueStyle.Render(layout.TruncateWidthDefault(ps.LastToolCall, width-16)))
+		}
+		if ps.LastDiff != "" {
+			lines = append(lines, "  "+labelStyle.Render("Diff:")+valueStyle.Render(layout.TruncateWidthDefault(ps.LastDiff, width-16)))
+		}
+	}
+
 	// Project Health (if warning/critical)
 	if m.healthStatus == "warning" || m.healthStatus == "critical" {
 		lines = append(lines, "")
@@ -6533,6 +6740,7 @@ func activitySummaryLine(rows []PaneTableRow, t theme.Theme) string {
 	badges = append(badges, activityCountBadge("error", counts["error"], t))
 	badges = append(badges, activityCountBadge("compacted", counts["compacted"], t))
 	badges = append(badges, activityCountBadge("rate_limited", counts["rate_limited"], t))
+	badges = append(badges, activityCountBadge("awaiting_permission", counts["awaiting_permission"], t))
 	badges = append(badges, activityCountBadge("unknown", counts["unknown"], t))
 
 	var compactBadges []string
@@ -13,6 +13,7 @@ import (
 	"github.com/shahbajlive/ntm/internal/cass"
 	"github.com/shahbajlive/ntm/internal/ensemble"
 	"github.com/shahbajlive/ntm/internal/history"
+	"github.com/shahbajlive/ntm/internal/quota"
 	"github.com/shahbajlive/ntm/internal/status"
 	"github.com/shahbajlive/ntm/internal/tmux"
 	"github.com/shahbajlive/ntm/internal/tracker"
@@ -457,6 +458,24 @@ func TestSidebarRendersFileChanges(t *testing.T) {
 	}
 }
 
+func TestSidebarRendersQuotaForecasts(t *testing.T) {
+	t.Parallel()
+
+	m := newTestModel(layout.UltraWideViewThreshold)
+
+	updated, _ := m.Update(QuotaForecastUpdateMsg{
+		Forecasts: []quota.Forecast{
+			{Provider: quota.ProviderClaude, Account: "main", Usage: 88, Basis: quota.BasisQuota, TimeToLimit: 20 * time.Minute, AtRisk: true},
+		},
+	})
+	m = updated.(Model)
+
+	out := status.StripANSI(m.renderSidebar(60, 25))
+	if !strings.Contains(out, "claude/main") {
+		t.Fatalf("expected sidebar to include quota forecast; got:\n%s", out)
+	}
+}
+
 func TestRenderSidebar_FillsExactHeight(t *testing.T) {
 	t.Parallel()
 
@@ -146,39 +146,6 @@ func TestHasMetricsData(t *testing.T) {
 	}
 }
 
-// ---------------------------------------------------------------------------
-// slicesEqual — pure function
-// ---------------------------------------------------------------------------
-
-func TestSlicesEqual(t *testing.T) {
-	t.Parallel()
-
-	tests := []struct {
-		name string
-		a, b []string
-		want bool
-	}{
-		{"both nil", nil, nil, true},
-		{"both empty", []string{}, []string{}, true},
-		{"nil vs empty", nil, []string{}, true},
-		{"equal single", []string{"a"}, []string{"a"}, true},
-		{"equal multi", []string{"a", "b", "c"}, []string{"a", "b", "c"}, true},
-		{"different length", []string{"a"}, []string{"a", "b"}, false},
-		{"same length different content", []string{"a", "b"}, []string{"a", "c"}, false},
-		{"order matters", []string{"a", "b"}, []string{"b", "a"}, false},
-	}
-
-	for _, tc := range tests {
-		t.Run(tc.name, func(t *testing.T) {
-			t.Parallel()
-			got := slicesEqual(tc.a, tc.b)
-			if got != tc.want {
-				t.Errorf("slicesEqual(%v, %v) = %v, want %v", tc.a, tc.b, got, tc.want)
-			}
-		})
-	}
-}
-
 // ---------------------------------------------------------------------------
 // recordCostOutputDelta — method with pure dependencies
 // ---------------------------------------------------------------------------
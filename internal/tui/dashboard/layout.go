@@ -415,6 +415,8 @@ func activityLabelAndColor(state string, t theme.Theme) (string, lipgloss.Color)
 		return "CMP", t.Peach
 	case "rate_limited":
 		return "RATE", t.Maroon
+	case "awaiting_permission":
+		return "PERM", t.Mauve
 	default:
 		return "UNK", t.Overlay
 	}
@@ -567,6 +569,9 @@ func RenderPaneRow(row PaneTableRow, dims LayoutDimensions, t theme.Theme) strin
 		case "rate_limited":
 			statusIcon = "⏳"
 			statusStyle = statusStyle.Foreground(t.Maroon).Bold(true)
+		case "awaiting_permission":
+			statusIcon = "?"
+			statusStyle = statusStyle.Foreground(t.Mauve).Bold(true)
 		default:
 			statusIcon = "•"
 			statusStyle = statusStyle.Foreground(t.Overlay)
@@ -930,6 +935,8 @@ func getStatusIconAndColor(state string, t theme.Theme, tick int) (string, lipgl
 		return "✗", t.Red
 	case "compacted":
 		return "⚠", t.Peach
+	case "awaiting_permission":
+		return "?", t.Mauve
 	default:
 		return "•", t.Overlay
 	}
@@ -50,6 +50,9 @@ type CostPanelData struct {
 
 	DailyBudgetUSD float64
 	BudgetUsedUSD  float64
+
+	// Budgets are the configured [budgets], worst first.
+	Budgets []cost.BudgetStatus
 }
 
 type CostPanel struct {
@@ -111,7 +114,7 @@ func (c *CostPanel) HasData() bool {
 	if c.data.SessionTotalUSD > 0 {
 		return true
 	}
-	if c.data.DailyBudgetUSD > 0 {
+	if c.data.DailyBudgetUSD > 0 || len(c.data.Budgets) > 0 {
 		return true
 	}
 	return false
@@ -136,6 +139,14 @@ func (c *CostPanel) View() string {
 			borderColor = t.Yellow
 		}
 	}
+	switch cost.WorstLevel(c.data.Budgets) {
+	case cost.LevelHard:
+		borderColor = t.Red
+	case cost.LevelSoft:
+		if borderColor != t.Red {
+			borderColor = t.Yellow
+		}
+	}
 
 	boxStyle := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
@@ -297,6 +308,17 @@ func (c *CostPanel) View() string {
 		content.WriteString(lipgloss.NewStyle().Foreground(budgetColor).Bold(true).Render(budgetLine) + "\n")
 	}
 
+	for _, b := range c.data.Budgets {
+		budgetColor := t.Subtext
+		switch b.Level {
+		case cost.LevelHard:
+			budgetColor = t.Red
+		case cost.LevelSoft:
+			budgetColor = t.Yellow
+		}
+		content.WriteString(lipgloss.NewStyle().Foreground(budgetColor).Render(layout.TruncateWidthDefault(b.String(), w-4)) + "\n")
+	}
+
 	if footer := components.RenderFreshnessFooter(components.FreshnessOptions{
 		LastUpdate:      c.LastUpdate(),
 		RefreshInterval: c.Config().RefreshInterval,
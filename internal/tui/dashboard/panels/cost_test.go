@@ -1,6 +1,11 @@
 package panels
 
-import "testing"
+import (
+	"strings"
+	"testing"
+
+	"github.com/shahbajlive/ntm/internal/cost"
+)
 
 func TestNewCostPanel(t *testing.T) {
 	panel := NewCostPanel()
@@ -82,3 +87,21 @@ func TestCostPanel_HasData(t *testing.T) {
 		t.Fatal("expected HasData=true when budget is set")
 	}
 }
+
+func TestCostPanel_Budgets(t *testing.T) {
+	panel := NewCostPanel()
+	panel.SetSize(80, 16)
+	panel.SetData(CostPanelData{
+		Agents: []CostAgentRow{{PaneTitle: "proj__cc_1", InputTokens: 1000, CostUSD: 1.0}},
+		Budgets: []cost.BudgetStatus{
+			{Budget: cost.Budget{Scope: cost.ScopeSession, Unit: cost.UnitUSD, Hard: 5}, Name: "session", Used: 5, Percent: 100, Level: cost.LevelHard},
+		},
+	}, nil)
+
+	if view := panel.View(); !strings.Contains(view, "session budget: $5.00 of $5.00") {
+		t.Errorf("budget line missing from view:\n%s", view)
+	}
+	if !(&CostPanel{data: CostPanelData{Budgets: panel.data.Budgets}}).HasData() {
+		t.Error("expected HasData=true with budgets")
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 
 	"github.com/shahbajlive/ntm/internal/integrations/caut"
+	"github.com/shahbajlive/ntm/internal/quota"
 	"github.com/shahbajlive/ntm/internal/tools"
 	"github.com/shahbajlive/ntm/internal/tui/components"
 	"github.com/shahbajlive/ntm/internal/tui/styles"
@@ -20,6 +21,7 @@ import (
 type QuotaData struct {
 	Status    *tools.CautStatus
 	Usages    []tools.CautUsage
+	Forecasts []quota.Forecast // Per-account time-to-limit forecasts
 	Available bool
 	Error     error
 }
@@ -79,7 +81,8 @@ func (q *QuotaPanel) Refresh() {
 	q.data = QuotaData{
 		Status:    status,
 		Usages:    usages,
-		Available: status != nil || len(usages) > 0,
+		Forecasts: q.data.Forecasts,
+		Available: status != nil || len(usages) > 0 || len(q.data.Forecasts) > 0,
 		Error:     err,
 	}
 
@@ -96,6 +99,19 @@ func (q *QuotaPanel) SetData(data QuotaData) {
 	}
 }
 
+// SetForecasts updates the per-account limit forecasts
+func (q *QuotaPanel) SetForecasts(forecasts []quota.Forecast) {
+	q.data.Forecasts = forecasts
+	if len(forecasts) > 0 {
+		q.data.Available = true
+	}
+}
+
+// HasData returns true if there is usage or forecast data to render
+func (q *QuotaPanel) HasData() bool {
+	return q.data.Available || q.data.Status != nil || len(q.data.Usages) > 0 || len(q.data.Forecasts) > 0
+}
+
 // HasError returns true if there's an active error
 func (q *QuotaPanel) HasError() bool {
 	return q.data.Error != nil
@@ -167,7 +183,7 @@ func (q *QuotaPanel) View() string {
 	}
 
 	// Empty state: no data available
-	if !q.data.Available && q.data.Status == nil && len(q.data.Usages) == 0 {
+	if !q.data.Available && q.data.Status == nil && len(q.data.Usages) == 0 && len(q.data.Forecasts) == 0 {
 		content.WriteString("\n" + components.RenderEmptyState(components.EmptyStateOptions{
 			Icon:        components.IconWaiting,
 			Title:       "No usage data",
@@ -269,6 +285,34 @@ func (q *QuotaPanel) View() string {
 		providerCount++
 	}
 
+	// Per-account limit forecasts
+	if len(q.data.Forecasts) > 0 {
+		content.WriteString("\n" + lipgloss.NewStyle().Foreground(t.Subtext).Bold(true).Render("Forecast") + "\n")
+		for i, f := range q.data.Forecasts {
+			if i >= availHeight {
+				content.WriteString(lipgloss.NewStyle().Foreground(t.Overlay).Render(fmt.Sprintf("+%d more", len(q.data.Forecasts)-i)) + "\n")
+				break
+			}
+			summaryColor := t.Overlay
+			switch {
+			case f.Limited || f.AtRisk:
+				summaryColor = t.Red
+			case f.Known() && f.Usage >= 80:
+				summaryColor = t.Yellow
+			case f.Known():
+				summaryColor = t.Green
+			}
+			name := lipgloss.NewStyle().Foreground(t.Text).Render(fmt.Sprintf("%s/%s", f.Provider, f.Account))
+			info := lipgloss.NewStyle().Foreground(summaryColor).Render(fmt.Sprintf("%.0f%% %s", f.Usage, f.Summary()))
+
+			gap := w - 6 - lipgloss.Width(name) - lipgloss.Width(info)
+			if gap < 1 {
+				gap = 1
+			}
+			content.WriteString(name + strings.Repeat(" ", gap) + info + "\n")
+		}
+	}
+
 	// Add freshness indicator at the bottom
 	if footer := components.RenderFreshnessFooter(components.FreshnessOptions{
 		LastUpdate:      q.LastUpdate(),
@@ -1,8 +1,11 @@
 package panels
 
 import (
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/shahbajlive/ntm/internal/quota"
 	"github.com/shahbajlive/ntm/internal/tools"
 )
 
@@ -195,6 +198,23 @@ func TestQuotaPanel_ViewCritical(t *testing.T) {
 	}
 }
 
+func TestQuotaPanel_ViewForecasts(t *testing.T) {
+	panel := NewQuotaPanel()
+	panel.SetSize(60, 20)
+
+	panel.SetForecasts([]quota.Forecast{
+		{Provider: quota.ProviderClaude, Account: "main", Usage: 88, Basis: quota.BasisQuota, TimeToLimit: 20 * time.Minute, AtRisk: true},
+		{Provider: quota.ProviderClaude, Account: "backup", Usage: 12},
+	})
+
+	view := panel.View()
+	for _, want := range []string{"Forecast", "claude/main", "limit in 20m", "claude/backup", "no forecast"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View missing %q:\n%s", want, view)
+		}
+	}
+}
+
 func TestQuotaPanel_Init(t *testing.T) {
 	panel := NewQuotaPanel()
 	cmd := panel.Init()
@@ -928,6 +928,10 @@ func (m *TimelinePanel) markerColor(mt state.MarkerType) lipgloss.Color {
 		return t.Teal
 	case state.MarkerStop:
 		return t.Peach
+	case state.MarkerCheckpoint:
+		return t.Yellow
+	case state.MarkerRotation:
+		return t.Mauve
 	default:
 		return t.Overlay
 	}
@@ -1016,10 +1020,12 @@ func (m *TimelinePanel) isMarkerSelected(marker state.TimelineMarker) bool {
 }
 
 func (m *TimelinePanel) highestPriorityMarker(markers []state.TimelineMarker) state.TimelineMarker {
-	// Priority: error > completion > prompt > start > stop
+	// Priority: error > rotation > completion > checkpoint > prompt > start > stop
 	priority := map[state.MarkerType]int{
-		state.MarkerError:      5,
-		state.MarkerCompletion: 4,
+		state.MarkerError:      7,
+		state.MarkerRotation:   6,
+		state.MarkerCompletion: 5,
+		state.MarkerCheckpoint: 4,
 		state.MarkerPrompt:     3,
 		state.MarkerStart:      2,
 		state.MarkerStop:       1,
@@ -0,0 +1,521 @@
+// Package fleet implements the fleet overview TUI: one row per ntm session
+// with agent states, context pressure, spend, alerts, pipelines and bead
+// throughput, plus drill-down and fleet-wide bulk actions.
+package fleet
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/shahbajlive/ntm/internal/fleet"
+	"github.com/shahbajlive/ntm/internal/status"
+	"github.com/shahbajlive/ntm/internal/tui/theme"
+)
+
+const (
+	refreshInterval = 5 * time.Second
+	collectTimeout  = 15 * time.Second
+)
+
+// Result is what the user chose when leaving the fleet view. Both fields
+// empty means quit.
+type Result struct {
+	// DrillDown names the session to open in the per-session dashboard.
+	DrillDown string
+	// Broadcast is a prompt to send to every idle Claude agent in the fleet.
+	Broadcast string
+}
+
+// Options configures the fleet view.
+type Options struct {
+	// Collect gathers a fleet snapshot; it is called on start, every
+	// refresh interval and after bulk actions.
+	Collect func(ctx context.Context) (*fleet.Overview, error)
+	// Select pre-selects a session, e.g. the one just drilled into.
+	Select string
+	// Flash is a status message shown until the next action.
+	Flash string
+}
+
+type mode int
+
+const (
+	modeList mode = iota
+	modeConfirm
+	modeBroadcast
+)
+
+type overviewMsg struct {
+	overview *fleet.Overview
+	err      error
+}
+
+type refreshTickMsg struct{}
+
+type actionDoneMsg struct {
+	label   string
+	results []fleet.ActionResult
+}
+
+// Model is the bubbletea model for the fleet overview.
+type Model struct {
+	opts     Options
+	overview *fleet.Overview
+	err      error
+	loading  bool
+	cursor   int
+	selected string
+	flash    string
+
+	mode    mode
+	confirm string
+	input   textinput.Model
+	result  *Result
+
+	width  int
+	height int
+	theme  theme.Theme
+}
+
+// New creates a fleet view; the first snapshot is collected by Init.
+func New(opts Options) Model {
+	in := textinput.New()
+	in.Placeholder = "prompt for every idle cc agent"
+	in.CharLimit = 4000
+	return Model{
+		opts:     opts,
+		selected: opts.Select,
+		flash:    opts.Flash,
+		loading:  true,
+		input:    in,
+		width:    100,
+		height:   24,
+		theme:    theme.Current(),
+	}
+}
+
+// Init collects the first snapshot.
+func (m Model) Init() tea.Cmd {
+	return m.collect()
+}
+
+func (m Model) collect() tea.Cmd {
+	collect := m.opts.Collect
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+		defer cancel()
+		ov, err := collect(ctx)
+		return overviewMsg{overview: ov, err: err}
+	}
+}
+
+func scheduleRefresh() tea.Cmd {
+	return tea.Tick(refreshInterval, func(time.Time) tea.Msg { return refreshTickMsg{} })
+}
+
+// Update handles input, snapshots and bulk action results.
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.input.Width = maxInt(m.width-12, 20)
+		return m, nil
+
+	case overviewMsg:
+		m.loading = false
+		m.err = msg.err
+		if msg.err == nil {
+			m.overview = msg.overview
+			m.restoreCursor()
+		}
+		return m, scheduleRefresh()
+
+	case refreshTickMsg:
+		if m.loading {
+			return m, nil
+		}
+		m.loading = true
+		return m, m.collect()
+
+	case actionDoneMsg:
+		m.flash = summarizeResults(msg.label, msg.results)
+		m.loading = true
+		return m, m.collect()
+
+	case tea.KeyMsg:
+		switch m.mode {
+		case modeConfirm:
+			return m.handleConfirmKey(msg)
+		case modeBroadcast:
+			return m.handleBroadcastKey(msg)
+		}
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	n := m.sessionCount()
+	switch msg.String() {
+	case "q", "esc", "ctrl+c":
+		return m, tea.Quit
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < n-1 {
+			m.cursor++
+		}
+	case "home", "g":
+		m.cursor = 0
+	case "end", "G":
+		m.cursor = maxInt(n-1, 0)
+	case "enter":
+		if s := m.current(); s != nil {
+			m.result = &Result{DrillDown: s.Name}
+			return m, tea.Quit
+		}
+	case "r":
+		if !m.loading {
+			m.loading = true
+			return m, m.collect()
+		}
+	case "p":
+		if n > 0 {
+			m.mode, m.confirm = modeConfirm, "p"
+		}
+	case "c":
+		if n > 0 {
+			m.mode, m.confirm = modeConfirm, "c"
+		}
+	case "u":
+		if names := m.pausedSessions(); len(names) > 0 {
+			return m, runAction("resume sends", func() []fleet.ActionResult { return fleet.ResumeSends(names) })
+		}
+		m.flash = "no paused sessions"
+	case "b":
+		if m.overview == nil || m.overview.Totals.IdleClaude == 0 {
+			m.flash = "no idle cc agents to broadcast to"
+			return m, nil
+		}
+		m.mode = modeBroadcast
+		m.input.SetValue("")
+		return m, m.input.Focus()
+	}
+	m.syncSelected()
+	return m, nil
+}
+
+func (m Model) handleConfirmKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	action := m.confirm
+	m.mode, m.confirm = modeList, ""
+	if msg.String() != "y" && msg.String() != "Y" {
+		m.flash = "cancelled"
+		return m, nil
+	}
+	names := m.sessionNames()
+	switch action {
+	case "p":
+		return m, runAction("pause sends", func() []fleet.ActionResult { return fleet.PauseSends(names, fleet.PauseReason) })
+	case "c":
+		return m, runAction("checkpoint", func() []fleet.ActionResult { return fleet.CheckpointAll(names, "fleet checkpoint") })
+	}
+	return m, nil
+}
+
+func (m Model) handleBroadcastKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlC:
+		m.mode = modeList
+		m.input.Blur()
+		m.flash = "broadcast cancelled"
+		return m, nil
+	case tea.KeyEnter:
+		prompt := strings.TrimSpace(m.input.Value())
+		m.mode = modeList
+		m.input.Blur()
+		if prompt == "" {
+			m.flash = "broadcast cancelled: empty prompt"
+			return m, nil
+		}
+		m.result = &Result{Broadcast: prompt}
+		return m, tea.Quit
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func runAction(label string, fn func() []fleet.ActionResult) tea.Cmd {
+	return func() tea.Msg {
+		return actionDoneMsg{label: label, results: fn()}
+	}
+}
+
+func summarizeResults(label string, results []fleet.ActionResult) string {
+	failed := fleet.Failed(results)
+	if failed == 0 {
+		return fmt.Sprintf("%s: %d session(s) done", label, len(results))
+	}
+	var first string
+	for _, r := range results {
+		if r.Error != "" {
+			first = r.Session + ": " + r.Error
+			break
+		}
+	}
+	return fmt.Sprintf("%s: %d of %d session(s) failed (%s)", label, failed, len(results), first)
+}
+
+// Result returns the user's exit choice, or nil when they quit.
+func (m Model) Result() *Result {
+	return m.result
+}
+
+func (m Model) sessionCount() int {
+	if m.overview == nil {
+		return 0
+	}
+	return len(m.overview.Sessions)
+}
+
+func (m Model) current() *fleet.SessionSummary {
+	if m.cursor < 0 || m.cursor >= m.sessionCount() {
+		return nil
+	}
+	return &m.overview.Sessions[m.cursor]
+}
+
+func (m Model) sessionNames() []string {
+	if m.overview == nil {
+		return nil
+	}
+	names := make([]string, 0, len(m.overview.Sessions))
+	for _, s := range m.overview.Sessions {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func (m Model) pausedSessions() []string {
+	if m.overview == nil {
+		return nil
+	}
+	var names []string
+	for _, s := range m.overview.Sessions {
+		if s.SendsPaused {
+			names = append(names, s.Name)
+		}
+	}
+	return names
+}
+
+func (m *Model) syncSelected() {
+	if s := m.current(); s != nil {
+		m.selected = s.Name
+	}
+}
+
+// restoreCursor keeps the selection on the same session across refreshes.
+func (m *Model) restoreCursor() {
+	for i, s := range m.overview.Sessions {
+		if s.Name == m.selected {
+			m.cursor = i
+			return
+		}
+	}
+	if m.cursor >= len(m.overview.Sessions) {
+		m.cursor = maxInt(len(m.overview.Sessions)-1, 0)
+	}
+	m.syncSelected()
+}
+
+// View renders the header, session table and footer.
+func (m Model) View() string {
+	t := m.theme
+	title := lipgloss.NewStyle().Bold(true).Foreground(t.Primary).Render("NTM Fleet")
+	dim := lipgloss.NewStyle().Foreground(t.Overlay)
+
+	var b strings.Builder
+	b.WriteString(title)
+	if m.overview != nil {
+		tot := m.overview.Totals
+		b.WriteString(dim.Render(fmt.Sprintf("  %d sessions · %d agents · %d working · %d idle cc · est. $%.2f · updated %s",
+			tot.Sessions, tot.Agents, tot.States[string(status.StateWorking)], tot.IdleClaude, tot.CostUSD,
+			m.overview.GeneratedAt.Format("15:04:05"))))
+	}
+	if m.loading {
+		b.WriteString(dim.Render("  refreshing…"))
+	}
+	b.WriteString("\n\n")
+
+	switch {
+	case m.err != nil && m.overview == nil:
+		b.WriteString(lipgloss.NewStyle().Foreground(t.Error).Render("Error: " + m.err.Error()))
+		b.WriteString("\n")
+	case m.overview == nil:
+		b.WriteString(dim.Render("Collecting fleet status…\n"))
+	case len(m.overview.Sessions) == 0:
+		b.WriteString(dim.Render("No ntm sessions running. Start one with: ntm spawn <session>\n"))
+	default:
+		b.WriteString(m.renderTable())
+	}
+
+	b.WriteString("\n")
+	b.WriteString(m.renderFooter())
+	return b.String()
+}
+
+var columns = []struct {
+	title string
+	width int
+}{
+	{"SESSION", 20}, {"AGENTS", 7}, {"WORK", 5}, {"IDLE", 5}, {"ERR", 5},
+	{"CTX", 6}, {"COST", 8}, {"ALERTS", 7}, {"PIPES", 6}, {"BEADS 1h/24h", 13}, {"SENDS", 7},
+}
+
+func (m Model) renderTable() string {
+	t := m.theme
+	header := lipgloss.NewStyle().Bold(true).Foreground(t.Subtext)
+	cells := make([]string, len(columns))
+	for i, c := range columns {
+		cells[i] = pad(c.title, c.width)
+	}
+	lines := []string{header.Render(strings.Join(cells, " "))}
+
+	for i, s := range m.overview.Sessions {
+		row := m.renderRow(s)
+		if i == m.cursor {
+			row = lipgloss.NewStyle().Background(t.Surface0).Render(ansi.Strip(row))
+		}
+		lines = append(lines, row)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func (m Model) renderRow(s fleet.SessionSummary) string {
+	t := m.theme
+	color := func(c lipgloss.Color, text string) string {
+		return lipgloss.NewStyle().Foreground(c).Render(text)
+	}
+
+	name := s.Name
+	if s.Attached {
+		name += " *"
+	}
+	errs := s.States[string(status.StateError)] + s.States[fleet.StateRateLimited]
+
+	ctx := "-"
+	ctxColor := t.Text
+	if s.ContextMax > 0 {
+		ctx = fmt.Sprintf("%.0f%%", s.ContextMax)
+		if s.ContextMax >= fleet.ContextHotPercent {
+			ctxColor = t.Error
+		} else if s.ContextMax >= 60 {
+			ctxColor = t.Warning
+		}
+	}
+
+	alertColor := t.Text
+	if s.CriticalAlerts > 0 {
+		alertColor = t.Error
+	} else if s.Alerts > 0 {
+		alertColor = t.Warning
+	}
+
+	pipes := fmt.Sprintf("%d", s.PipelinesRunning)
+	if s.PipelinesFailed > 0 {
+		pipes += fmt.Sprintf("/%d✗", s.PipelinesFailed)
+	}
+
+	sends := color(t.Success, pad("on", columns[10].width))
+	if s.SendsPaused {
+		sends = color(t.Warning, pad("paused", columns[10].width))
+	}
+
+	errColor := t.Text
+	if errs > 0 {
+		errColor = t.Error
+	}
+
+	cells := []string{
+		pad(name, columns[0].width),
+		pad(fmt.Sprintf("%d", s.Agents), columns[1].width),
+		color(t.Success, pad(fmt.Sprintf("%d", s.States[string(status.StateWorking)]), columns[2].width)),
+		pad(fmt.Sprintf("%d", s.States[string(status.StateIdle)]), columns[3].width),
+		color(errColor, pad(fmt.Sprintf("%d", errs), columns[4].width)),
+		color(ctxColor, pad(ctx, columns[5].width)),
+		pad(fmt.Sprintf("$%.2f", s.CostUSD), columns[6].width),
+		color(alertColor, pad(fmt.Sprintf("%d", s.Alerts), columns[7].width)),
+		pad(pipes, columns[8].width),
+		pad(fmt.Sprintf("%d/%d", s.BeadsCompletedHour, s.BeadsCompletedDay), columns[9].width),
+		sends,
+	}
+	return strings.Join(cells, " ")
+}
+
+func (m Model) renderFooter() string {
+	t := m.theme
+	var b strings.Builder
+	switch m.mode {
+	case modeConfirm:
+		what := "Pause sends to"
+		if m.confirm == "c" {
+			what = "Checkpoint"
+		}
+		b.WriteString(lipgloss.NewStyle().Foreground(t.Warning).Bold(true).
+			Render(fmt.Sprintf("%s all %d sessions? [y/N]", what, m.sessionCount())))
+	case modeBroadcast:
+		b.WriteString(fmt.Sprintf("Broadcast to %d idle cc agents: ", m.overview.Totals.IdleClaude))
+		b.WriteString(m.input.View())
+	default:
+		b.WriteString(lipgloss.NewStyle().Foreground(t.Overlay).Render(
+			"↑/↓ select · enter drill down · p pause all sends · u resume · c checkpoint all · b broadcast to idle cc · r refresh · q quit"))
+	}
+	if s := m.current(); s != nil && s.Error != "" && m.mode == modeList {
+		b.WriteString("\n")
+		b.WriteString(lipgloss.NewStyle().Foreground(t.Warning).Render(ansi.Truncate(s.Name+": "+s.Error, maxInt(m.width, 20), "…")))
+	}
+	if m.flash != "" {
+		b.WriteString("\n")
+		b.WriteString(lipgloss.NewStyle().Foreground(t.Info).Render(m.flash))
+	}
+	return b.String()
+}
+
+func pad(s string, width int) string {
+	s = ansi.Truncate(s, width, "…")
+	if w := ansi.StringWidth(s); w < width {
+		s += strings.Repeat(" ", width-w)
+	}
+	return s
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// Run shows the fleet view in the alternate screen and returns the user's
+// exit choice (nil on plain quit).
+func Run(opts Options) (*Result, error) {
+	p := tea.NewProgram(New(opts), tea.WithAltScreen())
+	final, err := p.Run()
+	if err != nil {
+		return nil, err
+	}
+	if m, ok := final.(Model); ok {
+		return m.Result(), nil
+	}
+	return nil, nil
+}
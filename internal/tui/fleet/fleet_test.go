@@ -0,0 +1,130 @@
+package fleet
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/shahbajlive/ntm/internal/fleet"
+)
+
+func testOverview() *fleet.Overview {
+	return &fleet.Overview{
+		GeneratedAt: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		Sessions: []fleet.SessionSummary{
+			{Name: "alpha", Agents: 3, States: map[string]int{"working": 2, "idle": 1}, IdleClaude: 1, ContextMax: 91, Alerts: 1, CriticalAlerts: 1},
+			{Name: "beta", Agents: 1, States: map[string]int{"unknown": 1}, SendsPaused: true, Error: "status: capture timed out"},
+		},
+		Totals: fleet.Totals{Sessions: 2, Agents: 4, IdleClaude: 1, States: map[string]int{"working": 2}},
+	}
+}
+
+func loaded(t *testing.T, opts Options) Model {
+	t.Helper()
+	if opts.Collect == nil {
+		opts.Collect = func(context.Context) (*fleet.Overview, error) { return testOverview(), nil }
+	}
+	m := New(opts)
+	next, _ := m.Update(m.Init()())
+	return next.(Model)
+}
+
+func press(m Model, keys ...string) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	for _, k := range keys {
+		var msg tea.KeyMsg
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		var next tea.Model
+		next, cmd = m.Update(msg)
+		m = next.(Model)
+	}
+	return m, cmd
+}
+
+func TestViewListsSessions(t *testing.T) {
+	m := loaded(t, Options{})
+	view := m.View()
+	for _, want := range []string{"NTM Fleet", "alpha", "beta", "91%", "paused", "2 sessions"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+	// Source errors are shown for the selected session only.
+	m, _ = press(m, "j")
+	if !strings.Contains(m.View(), "beta: status: capture timed out") {
+		t.Errorf("selected session error not shown:\n%s", m.View())
+	}
+}
+
+func TestDrillDownAndSelectionKeptAcrossRefresh(t *testing.T) {
+	m := loaded(t, Options{Select: "beta"})
+	if m.cursor != 1 {
+		t.Fatalf("cursor = %d, want preselected beta", m.cursor)
+	}
+
+	// A refresh that reorders sessions keeps beta selected.
+	ov := testOverview()
+	ov.Sessions[0], ov.Sessions[1] = ov.Sessions[1], ov.Sessions[0]
+	next, _ := m.Update(overviewMsg{overview: ov})
+	m = next.(Model)
+	if m.current().Name != "beta" {
+		t.Fatalf("selection after refresh = %s, want beta", m.current().Name)
+	}
+
+	m, cmd := press(m, "enter")
+	if cmd == nil || m.Result() == nil || m.Result().DrillDown != "beta" {
+		t.Errorf("enter result = %+v, want drill-down into beta", m.Result())
+	}
+}
+
+func TestBroadcastPrompt(t *testing.T) {
+	m := loaded(t, Options{})
+	m, _ = press(m, "b")
+	if m.mode != modeBroadcast {
+		t.Fatalf("mode = %v, want broadcast input", m.mode)
+	}
+	m, _ = press(m, "r", "u", "n", " ", "t", "e", "s", "t", "s")
+	m, cmd := press(m, "enter")
+	if cmd == nil || m.Result() == nil || m.Result().Broadcast != "run tests" {
+		t.Errorf("broadcast result = %+v", m.Result())
+	}
+
+	// Esc abandons the prompt without a result.
+	m = loaded(t, Options{})
+	m, _ = press(m, "b", "x", "esc")
+	if m.mode != modeList || m.Result() != nil {
+		t.Errorf("after esc mode = %v, result = %+v", m.mode, m.Result())
+	}
+}
+
+func TestBulkActionsNeedConfirmation(t *testing.T) {
+	m := loaded(t, Options{})
+
+	m, cmd := press(m, "c", "n")
+	if cmd != nil || m.flash != "cancelled" {
+		t.Errorf("declined checkpoint: cmd = %v, flash = %q", cmd != nil, m.flash)
+	}
+
+	m, _ = press(m, "p")
+	if m.mode != modeConfirm || !strings.Contains(m.View(), "Pause sends to all 2 sessions?") {
+		t.Fatalf("pause prompt not shown:\n%s", m.View())
+	}
+
+	next, _ := m.Update(actionDoneMsg{label: "pause sends", results: []fleet.ActionResult{
+		{Session: "alpha"}, {Session: "beta", Error: "disk full"},
+	}})
+	m = next.(Model)
+	if !strings.Contains(m.flash, "1 of 2 session(s) failed (beta: disk full)") {
+		t.Errorf("flash = %q", m.flash)
+	}
+}
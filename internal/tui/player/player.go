@@ -0,0 +1,380 @@
+// Package player implements a TUI for replaying ntm session recordings.
+// All panes are tiled and driven by one shared clock; a scrubber at the
+// bottom shows the timeline markers captured with the recording.
+package player
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/shahbajlive/ntm/internal/recording"
+	"github.com/shahbajlive/ntm/internal/state"
+	"github.com/shahbajlive/ntm/internal/tui/theme"
+)
+
+const (
+	tickInterval = 100 * time.Millisecond
+	seekStep     = 5 * time.Second
+	fineSeekStep = 1 * time.Second
+)
+
+var speeds = []float64{0.25, 0.5, 1, 2, 4, 8, 16}
+
+type tickMsg time.Time
+
+// Model is the bubbletea model for the recording player.
+type Model struct {
+	rec      *recording.Recording
+	screens  []*recording.Screen
+	duration time.Duration
+
+	pos      time.Duration
+	playing  bool
+	speedIdx int
+	lastTick time.Time
+
+	width  int
+	height int
+	theme  theme.Theme
+}
+
+// New creates a player for rec, paused at the start.
+func New(rec *recording.Recording) Model {
+	screens := make([]*recording.Screen, len(rec.Casts))
+	for i, c := range rec.Casts {
+		screens[i] = recording.NewScreen(c)
+	}
+	return Model{
+		rec:      rec,
+		screens:  screens,
+		duration: rec.Duration(),
+		speedIdx: 2,
+		width:    80,
+		height:   24,
+		theme:    theme.Current(),
+	}
+}
+
+// Init starts playback.
+func (m Model) Init() tea.Cmd {
+	return func() tea.Msg { return playMsg{} }
+}
+
+type playMsg struct{}
+
+func tick() tea.Cmd {
+	return tea.Tick(tickInterval, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
+
+// Update handles input and the playback clock.
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+
+	case playMsg:
+		m.playing = true
+		m.lastTick = time.Now()
+		return m, tick()
+
+	case tickMsg:
+		if !m.playing {
+			return m, nil
+		}
+		now := time.Time(msg)
+		elapsed := now.Sub(m.lastTick)
+		m.lastTick = now
+		m.seek(m.pos + time.Duration(float64(elapsed)*speeds[m.speedIdx]))
+		if m.pos >= m.duration {
+			m.playing = false
+			return m, nil
+		}
+		return m, tick()
+
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "esc", "ctrl+c":
+		return m, tea.Quit
+	case " ", "p":
+		if m.playing {
+			m.playing = false
+			return m, nil
+		}
+		if m.pos >= m.duration {
+			m.seek(0)
+		}
+		return m, func() tea.Msg { return playMsg{} }
+	case "left", "h":
+		m.seek(m.pos - seekStep)
+	case "right", "l":
+		m.seek(m.pos + seekStep)
+	case "shift+left", "H":
+		m.seek(m.pos - fineSeekStep)
+	case "shift+right", "L":
+		m.seek(m.pos + fineSeekStep)
+	case "[":
+		if mk, ok := m.prevMarker(); ok {
+			m.seek(m.rec.MarkerOffset(mk))
+		}
+	case "]":
+		if mk, ok := m.nextMarker(); ok {
+			m.seek(m.rec.MarkerOffset(mk))
+		}
+	case "+", "=":
+		if m.speedIdx < len(speeds)-1 {
+			m.speedIdx++
+		}
+	case "-", "_":
+		if m.speedIdx > 0 {
+			m.speedIdx--
+		}
+	case "home", "g":
+		m.seek(0)
+	case "end", "G":
+		m.seek(m.duration)
+	}
+	return m, nil
+}
+
+// seek clamps d to the recording and moves every pane screen there.
+func (m *Model) seek(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	if d > m.duration {
+		d = m.duration
+	}
+	m.pos = d
+	for _, s := range m.screens {
+		s.Seek(d)
+	}
+}
+
+// Position returns the current playback offset.
+func (m Model) Position() time.Duration {
+	return m.pos
+}
+
+// Playing reports whether the clock is running.
+func (m Model) Playing() bool {
+	return m.playing
+}
+
+func (m Model) prevMarker() (state.TimelineMarker, bool) {
+	// Small tolerance so repeated presses step past the marker just jumped to
+	cutoff := m.pos - 500*time.Millisecond
+	for i := len(m.rec.Manifest.Markers) - 1; i >= 0; i-- {
+		mk := m.rec.Manifest.Markers[i]
+		if m.rec.MarkerOffset(mk) < cutoff {
+			return mk, true
+		}
+	}
+	return state.TimelineMarker{}, false
+}
+
+func (m Model) nextMarker() (state.TimelineMarker, bool) {
+	for _, mk := range m.rec.Manifest.Markers {
+		if m.rec.MarkerOffset(mk) > m.pos {
+			return mk, true
+		}
+	}
+	return state.TimelineMarker{}, false
+}
+
+// currentMarker returns the latest marker at or before the playhead.
+func (m Model) currentMarker() (state.TimelineMarker, bool) {
+	var found state.TimelineMarker
+	ok := false
+	for _, mk := range m.rec.Manifest.Markers {
+		if m.rec.MarkerOffset(mk) > m.pos {
+			break
+		}
+		found, ok = mk, true
+	}
+	return found, ok
+}
+
+// View renders tiled panes above the scrubber.
+func (m Model) View() string {
+	if len(m.screens) == 0 {
+		return "recording has no panes\n"
+	}
+	footer := m.renderScrubber()
+	footerHeight := lipgloss.Height(footer)
+	return lipgloss.JoinVertical(lipgloss.Left, m.renderTiles(m.height-footerHeight), footer)
+}
+
+func (m Model) renderTiles(height int) string {
+	n := len(m.screens)
+	cols := int(math.Ceil(math.Sqrt(float64(n))))
+	rows := (n + cols - 1) / cols
+	tileW := maxInt(m.width/cols, 10)
+	tileH := maxInt(height/rows, 4)
+
+	var rowViews []string
+	for r := 0; r < rows; r++ {
+		var tiles []string
+		for c := 0; c < cols; c++ {
+			i := r*cols + c
+			if i >= n {
+				break
+			}
+			tiles = append(tiles, m.renderTile(i, tileW, tileH))
+		}
+		rowViews = append(rowViews, lipgloss.JoinHorizontal(lipgloss.Top, tiles...))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, rowViews...)
+}
+
+func (m Model) renderTile(i, w, h int) string {
+	t := m.theme
+	track := m.rec.Manifest.Panes[i]
+	innerW := maxInt(w-2, 1)
+	innerH := maxInt(h-3, 1)
+
+	borderColor := t.Surface1
+	if last := m.screens[i].LastOutput(); last > 0 && m.pos-last < time.Second {
+		borderColor = t.Green // Pane produced output within the last second
+	}
+
+	title := lipgloss.NewStyle().Bold(true).Foreground(t.Text).
+		Render(ansi.Truncate(fmt.Sprintf("%d %s", track.Index, track.Title), innerW, "…"))
+
+	lines := m.screens[i].Tail(innerH)
+	body := make([]string, innerH)
+	offset := innerH - len(lines)
+	for j := range body {
+		if j >= offset {
+			body[j] = ansi.Truncate(lines[j-offset], innerW, "")
+		}
+	}
+
+	content := title + "\n" + strings.Join(body, "\n")
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(borderColor).
+		Width(innerW).
+		Height(innerH + 1).
+		Render(content)
+}
+
+func (m Model) renderScrubber() string {
+	t := m.theme
+	barW := maxInt(m.width-2, 10)
+
+	// Marker row: one glyph per marker, colored by type
+	markerRow := []rune(strings.Repeat(" ", barW))
+	markerTypes := make([]state.MarkerType, barW)
+	for _, mk := range m.rec.Manifest.Markers {
+		col := m.column(m.rec.MarkerOffset(mk), barW)
+		markerRow[col] = []rune(mk.Type.Symbol())[0]
+		markerTypes[col] = mk.Type
+	}
+	var markers strings.Builder
+	for col, r := range markerRow {
+		if r == ' ' {
+			markers.WriteRune(' ')
+			continue
+		}
+		markers.WriteString(lipgloss.NewStyle().Foreground(m.markerColor(markerTypes[col])).Render(string(r)))
+	}
+
+	// Progress bar with playhead
+	head := m.column(m.pos, barW)
+	bar := lipgloss.NewStyle().Foreground(t.Blue).Render(strings.Repeat("━", head)) +
+		lipgloss.NewStyle().Foreground(t.Text).Bold(true).Render("●") +
+		lipgloss.NewStyle().Foreground(t.Surface1).Render(strings.Repeat("─", maxInt(barW-head-1, 0)))
+
+	indicator := "⏸"
+	if m.playing {
+		indicator = "▶"
+	}
+	status := fmt.Sprintf("%s %s / %s  %gx  %s", indicator, formatOffset(m.pos), formatOffset(m.duration),
+		speeds[m.speedIdx], m.rec.Manifest.Session)
+	if mk, ok := m.currentMarker(); ok {
+		label := mk.Message
+		if mk.AgentID != "" {
+			label = mk.AgentID + ": " + label
+		}
+		status += fmt.Sprintf("  %s %s @%s", mk.Type.Symbol(), label, formatOffset(m.rec.MarkerOffset(mk)))
+	}
+	help := "space play/pause  ←/→ ±5s  H/L ±1s  [/] markers  +/- speed  g/G start/end  q quit"
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		" "+markers.String(),
+		" "+bar,
+		" "+ansi.Truncate(status, barW, "…"),
+		" "+lipgloss.NewStyle().Foreground(t.Overlay).Render(ansi.Truncate(help, barW, "…")),
+	)
+}
+
+// column maps an offset to a scrubber column.
+func (m Model) column(d time.Duration, width int) int {
+	if m.duration <= 0 || width <= 1 {
+		return 0
+	}
+	col := int(float64(d) / float64(m.duration) * float64(width-1))
+	if col < 0 {
+		return 0
+	}
+	if col > width-1 {
+		return width - 1
+	}
+	return col
+}
+
+func (m Model) markerColor(mt state.MarkerType) lipgloss.Color {
+	t := m.theme
+	switch mt {
+	case state.MarkerPrompt:
+		return t.Blue
+	case state.MarkerCompletion:
+		return t.Green
+	case state.MarkerError:
+		return t.Red
+	case state.MarkerCheckpoint:
+		return t.Yellow
+	case state.MarkerRotation:
+		return t.Mauve
+	default:
+		return t.Overlay
+	}
+}
+
+func formatOffset(d time.Duration) string {
+	d = d.Truncate(time.Second)
+	h := int(d.Hours())
+	mi := int(d.Minutes()) % 60
+	s := int(d.Seconds()) % 60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, mi, s)
+	}
+	return fmt.Sprintf("%02d:%02d", mi, s)
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// Run plays rec in the alternate screen until the user quits.
+func Run(rec *recording.Recording) error {
+	p := tea.NewProgram(New(rec), tea.WithAltScreen())
+	_, err := p.Run()
+	return err
+}
@@ -0,0 +1,149 @@
+package player
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/shahbajlive/ntm/internal/recording"
+	"github.com/shahbajlive/ntm/internal/state"
+)
+
+func testRecording() *recording.Recording {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	return &recording.Recording{
+		Manifest: recording.Manifest{
+			Session:   "proj",
+			StartedAt: start,
+			EndedAt:   start.Add(60 * time.Second),
+			Panes: []recording.PaneTrack{
+				{Index: 1, Title: "proj__cc_1"},
+				{Index: 2, Title: "proj__cod_1"},
+			},
+			Markers: []state.TimelineMarker{
+				{ID: "a", Type: state.MarkerPrompt, Timestamp: start.Add(10 * time.Second), Message: "prompt sent"},
+				{ID: "b", Type: state.MarkerCheckpoint, Timestamp: start.Add(30 * time.Second), Message: "checkpoint cp1"},
+			},
+		},
+		Casts: []*recording.Cast{
+			{Events: []recording.Event{
+				{Time: 5, Code: recording.CodeOutput, Data: "claude says hi\r\n"},
+				{Time: 40, Code: recording.CodeOutput, Data: "claude later\r\n"},
+			}},
+			{Events: []recording.Event{
+				{Time: 20, Code: recording.CodeOutput, Data: "codex output\r\n"},
+			}},
+		},
+	}
+}
+
+func press(m Model, key string) Model {
+	var msg tea.KeyMsg
+	switch key {
+	case "left":
+		msg = tea.KeyMsg{Type: tea.KeyLeft}
+	case "right":
+		msg = tea.KeyMsg{Type: tea.KeyRight}
+	case " ":
+		msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+	default:
+		msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+	}
+	next, _ := m.Update(msg)
+	return next.(Model)
+}
+
+func TestSeekKeys(t *testing.T) {
+	m := New(testRecording())
+
+	m = press(m, "right")
+	if m.Position() != seekStep {
+		t.Errorf("after right: pos = %v, want %v", m.Position(), seekStep)
+	}
+	m = press(m, "left")
+	m = press(m, "left")
+	if m.Position() != 0 {
+		t.Errorf("seek before start not clamped: %v", m.Position())
+	}
+	m = press(m, "G")
+	if m.Position() != 60*time.Second {
+		t.Errorf("end: pos = %v, want 60s", m.Position())
+	}
+}
+
+func TestMarkerNavigation(t *testing.T) {
+	m := New(testRecording())
+
+	m = press(m, "]")
+	if m.Position() != 10*time.Second {
+		t.Fatalf("next marker: pos = %v, want 10s", m.Position())
+	}
+	m = press(m, "]")
+	if m.Position() != 30*time.Second {
+		t.Fatalf("next marker: pos = %v, want 30s", m.Position())
+	}
+	m = press(m, "[")
+	if m.Position() != 10*time.Second {
+		t.Errorf("prev marker: pos = %v, want 10s", m.Position())
+	}
+
+	mk, ok := m.currentMarker()
+	if !ok || mk.ID != "a" {
+		t.Errorf("currentMarker() = %+v, %v", mk, ok)
+	}
+}
+
+func TestPlaybackTick(t *testing.T) {
+	m := New(testRecording())
+	next, cmd := m.Update(playMsg{})
+	m = next.(Model)
+	if !m.Playing() || cmd == nil {
+		t.Fatal("playMsg should start the clock")
+	}
+
+	m = press(m, "+") // 2x
+	next, _ = m.Update(tickMsg(m.lastTick.Add(time.Second)))
+	m = next.(Model)
+	if m.Position() != 2*time.Second {
+		t.Errorf("pos after 1s at 2x = %v, want 2s", m.Position())
+	}
+
+	m = press(m, " ")
+	if m.Playing() {
+		t.Error("space should pause")
+	}
+}
+
+func TestViewRendersPanesAndMarkers(t *testing.T) {
+	m := New(testRecording())
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	m = next.(Model)
+	m = press(m, "]")
+	m = press(m, "]")
+	m = press(m, "right")
+
+	view := m.View()
+	for _, want := range []string{"proj__cc_1", "proj__cod_1", "claude says hi", "codex output", "checkpoint cp1", "⚑", "▶"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q", want)
+		}
+	}
+	if strings.Contains(view, "claude later") {
+		t.Error("view shows output from after the playhead")
+	}
+}
+
+func TestFormatOffset(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                             "00:00",
+		75 * time.Second:              "01:15",
+		time.Hour + 2*time.Minute + 3: "1:02:00",
+	}
+	for d, want := range tests {
+		if got := formatOffset(d); got != want {
+			t.Errorf("formatOffset(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
@@ -0,0 +1,727 @@
+// Package watcher provides file watching with debouncing using fsnotify.
+// attribution.go attributes filesystem changes in a project to the agent pane
+// whose process tree made them.
+package watcher
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/tmux"
+	"github.com/shahbajlive/ntm/internal/tracker"
+)
+
+const (
+	// DefaultAttributionSampleInterval is how often process counters are sampled.
+	DefaultAttributionSampleInterval = time.Second
+
+	// DefaultAttributionPaneRefresh is how often the pane list is reloaded.
+	DefaultAttributionPaneRefresh = 10 * time.Second
+
+	// DefaultAttributionDebounce coalesces bursts of writes to the same files.
+	DefaultAttributionDebounce = 200 * time.Millisecond
+
+	// maxAttributionSamples bounds the process sample history.
+	maxAttributionSamples = 16
+
+	// maxAncestorDepth bounds the PPID walk from a process to its pane shell.
+	maxAncestorDepth = 64
+)
+
+// DefaultAttributionIgnores are directory and file names never attributed.
+// Git-ignored paths are filtered separately.
+var DefaultAttributionIgnores = []string{".git", ".ntm", ".beads", "node_modules", "*.swp", "*~", ".#*"}
+
+// AttributionMethod describes how a change was tied to a pane.
+type AttributionMethod string
+
+const (
+	// AttributedOpenFile means a process in the pane's tree held the file open.
+	AttributedOpenFile AttributionMethod = "open_file"
+	// AttributedDiskWrite means only one pane's processes wrote to disk.
+	AttributedDiskWrite AttributionMethod = "disk_write"
+	// AttributedAmbiguous means several panes wrote, or panes only used CPU;
+	// see Candidates.
+	AttributedAmbiguous AttributionMethod = "ambiguous"
+	// Unattributed means no agent pane was active (e.g. a human edit).
+	Unattributed AttributionMethod = "none"
+)
+
+// AgentPane is an agent pane whose shell process anchors attribution.
+type AgentPane struct {
+	Session   string
+	PaneID    string
+	PaneIndex int
+	Title     string
+	AgentType string
+	ShellPID  int
+}
+
+// Name returns the agent name used in tracker records: the pane title when
+// set, otherwise the pane ID.
+func (p AgentPane) Name() string {
+	if p.Title != "" {
+		return p.Title
+	}
+	return p.PaneID
+}
+
+// FileAttribution is a change to a project file and the pane that made it.
+type FileAttribution struct {
+	Path       string // Absolute path
+	RelPath    string // Path relative to the project directory
+	Type       tracker.FileChangeType
+	Time       time.Time
+	Pane       *AgentPane // nil unless Method is open_file or disk_write
+	Method     AttributionMethod
+	Candidates []AgentPane // Active panes when Method is ambiguous
+}
+
+// PaneLister returns the agent panes whose process trees are attributed.
+type PaneLister func(ctx context.Context) ([]AgentPane, error)
+
+// SessionPaneLister lists the agent panes of a tmux session.
+func SessionPaneLister(session string) PaneLister {
+	return func(ctx context.Context) ([]AgentPane, error) {
+		panes, err := tmux.GetPanesContext(ctx, session)
+		if err != nil {
+			return nil, err
+		}
+		result := make([]AgentPane, 0, len(panes))
+		for _, p := range panes {
+			if p.Type == tmux.AgentUser || p.PID <= 0 {
+				continue
+			}
+			result = append(result, AgentPane{
+				Session:   session,
+				PaneID:    p.ID,
+				PaneIndex: p.Index,
+				Title:     p.Title,
+				AgentType: string(p.Type),
+				ShellPID:  p.PID,
+			})
+		}
+		return result, nil
+	}
+}
+
+// ProcessAttributionAvailable reports whether process attribution can work on
+// this system. It needs a Linux-style /proc.
+func ProcessAttributionAvailable() bool {
+	_, err := os.Stat("/proc/self/stat")
+	return err == nil
+}
+
+// FileAttributionWatcher watches a project tree and attributes every change to
+// the agent pane that made it.
+//
+// fsnotify does not report the writing PID, so attribution uses the process
+// table: each process is mapped to a pane by walking its PPID ancestry up to
+// the pane's shell PID (as rano.PIDMap does for network traffic). A change is
+// attributed, in order of confidence, to the pane with a process holding the
+// file open, or to the only pane whose processes wrote to disk since just
+// before the change. When several panes qualify, or the only evidence is that
+// panes' processes used CPU, the change is recorded as ambiguous rather than
+// guessed.
+type FileAttributionWatcher struct {
+	session        string
+	projectDir     string
+	lister         PaneLister
+	procRoot       string
+	store          *tracker.FileChangeStore
+	handler        func([]FileAttribution)
+	sampleInterval time.Duration
+	paneRefresh    time.Duration
+	debounce       time.Duration
+	ignores        []string
+	gitAware       bool
+	debug          bool
+
+	mu      sync.Mutex
+	panes   []AgentPane
+	shells  map[int]int // shell PID -> index into panes
+	samples []procSample
+	fs      *Watcher
+	stopCh  chan struct{}
+	wg      sync.WaitGroup
+}
+
+// AttributionOption configures a FileAttributionWatcher.
+type AttributionOption func(*FileAttributionWatcher)
+
+// WithPaneLister overrides how agent panes are discovered.
+func WithPaneLister(lister PaneLister) AttributionOption {
+	return func(w *FileAttributionWatcher) {
+		if lister != nil {
+			w.lister = lister
+		}
+	}
+}
+
+// WithProcRoot overrides the /proc mount used for process attribution.
+func WithProcRoot(root string) AttributionOption {
+	return func(w *FileAttributionWatcher) {
+		if root != "" {
+			w.procRoot = root
+		}
+	}
+}
+
+// WithChangeStore records changes into store instead of tracker.GlobalFileChanges.
+func WithChangeStore(store *tracker.FileChangeStore) AttributionOption {
+	return func(w *FileAttributionWatcher) {
+		w.store = store
+	}
+}
+
+// WithAttributionHandler sets a callback invoked with each batch of attributed changes.
+func WithAttributionHandler(fn func([]FileAttribution)) AttributionOption {
+	return func(w *FileAttributionWatcher) {
+		w.handler = fn
+	}
+}
+
+// WithSampleInterval sets how often process counters are sampled.
+func WithSampleInterval(d time.Duration) AttributionOption {
+	return func(w *FileAttributionWatcher) {
+		if d > 0 {
+			w.sampleInterval = d
+		}
+	}
+}
+
+// WithAttributionDebounce sets the debounce applied to filesystem events.
+func WithAttributionDebounce(d time.Duration) AttributionOption {
+	return func(w *FileAttributionWatcher) {
+		if d > 0 {
+			w.debounce = d
+		}
+	}
+}
+
+// WithAttributionIgnores replaces the ignored file name patterns.
+func WithAttributionIgnores(patterns []string) AttributionOption {
+	return func(w *FileAttributionWatcher) {
+		w.ignores = patterns
+	}
+}
+
+// WithGitAware controls whether git-ignored paths are skipped (default true).
+func WithGitAware(enabled bool) AttributionOption {
+	return func(w *FileAttributionWatcher) {
+		w.gitAware = enabled
+	}
+}
+
+// WithAttributionDebug enables debug logging.
+func WithAttributionDebug(debug bool) AttributionOption {
+	return func(w *FileAttributionWatcher) {
+		w.debug = debug
+	}
+}
+
+// NewFileAttributionWatcher creates a watcher attributing changes under
+// projectDir to the agent panes of session.
+func NewFileAttributionWatcher(session, projectDir string, opts ...AttributionOption) *FileAttributionWatcher {
+	w := &FileAttributionWatcher{
+		session:        session,
+		projectDir:     projectDir,
+		lister:         SessionPaneLister(session),
+		procRoot:       "/proc",
+		store:          tracker.GlobalFileChanges,
+		sampleInterval: DefaultAttributionSampleInterval,
+		paneRefresh:    DefaultAttributionPaneRefresh,
+		debounce:       DefaultAttributionDebounce,
+		ignores:        DefaultAttributionIgnores,
+		gitAware:       true,
+		shells:         make(map[int]int),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	if abs, err := filepath.Abs(projectDir); err == nil {
+		w.projectDir = abs
+	}
+	return w
+}
+
+// Start begins watching the project tree. It returns an error when the tree
+// cannot be watched.
+func (w *FileAttributionWatcher) Start(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	w.mu.Lock()
+	if w.stopCh != nil {
+		w.mu.Unlock()
+		return nil
+	}
+	w.mu.Unlock()
+
+	w.refreshPanes(ctx)
+	w.sample(time.Now())
+
+	fsw, err := New(w.handleEvents,
+		WithRecursive(true),
+		WithIgnorePaths(w.ignores),
+		WithDebounceDuration(w.debounce),
+		WithEventFilter(Create|Write|Remove|Rename),
+		WithErrorHandler(func(err error) {
+			if w.debug {
+				log.Printf("[FileAttribution] watch error: %v", err)
+			}
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("creating watcher: %w", err)
+	}
+	if err := fsw.Add(w.projectDir); err != nil {
+		fsw.Close()
+		return fmt.Errorf("watching %s: %w", w.projectDir, err)
+	}
+
+	stopCh := make(chan struct{})
+	w.mu.Lock()
+	w.fs = fsw
+	w.stopCh = stopCh
+	w.mu.Unlock()
+
+	w.wg.Add(1)
+	go w.run(ctx, stopCh)
+
+	if w.debug {
+		log.Printf("[FileAttribution] Watching %s for session %s (%d agent panes)", w.projectDir, w.session, len(w.panes))
+	}
+	return nil
+}
+
+// Stop halts the watcher.
+func (w *FileAttributionWatcher) Stop() {
+	w.mu.Lock()
+	stopCh := w.stopCh
+	fsw := w.fs
+	w.stopCh = nil
+	w.fs = nil
+	w.mu.Unlock()
+
+	if stopCh != nil {
+		close(stopCh)
+	}
+	w.wg.Wait()
+	if fsw != nil {
+		fsw.Close()
+	}
+}
+
+// run samples process counters and refreshes panes until stopped.
+func (w *FileAttributionWatcher) run(ctx context.Context, stopCh <-chan struct{}) {
+	defer w.wg.Done()
+
+	sampleTicker := time.NewTicker(w.sampleInterval)
+	defer sampleTicker.Stop()
+	paneTicker := time.NewTicker(w.paneRefresh)
+	defer paneTicker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ctx.Done():
+			return
+		case now := <-sampleTicker.C:
+			w.sample(now)
+		case <-paneTicker.C:
+			w.refreshPanes(ctx)
+		}
+	}
+}
+
+// refreshPanes reloads the agent pane list.
+func (w *FileAttributionWatcher) refreshPanes(ctx context.Context) {
+	panes, err := w.lister(ctx)
+	if err != nil {
+		if w.debug {
+			log.Printf("[FileAttribution] Error listing panes: %v", err)
+		}
+		return
+	}
+	w.setPanes(panes)
+}
+
+// setPanes replaces the agent pane list.
+func (w *FileAttributionWatcher) setPanes(panes []AgentPane) {
+	shells := make(map[int]int, len(panes))
+	for i, p := range panes {
+		if p.ShellPID > 0 {
+			shells[p.ShellPID] = i
+		}
+	}
+	w.mu.Lock()
+	w.panes = panes
+	w.shells = shells
+	w.mu.Unlock()
+}
+
+// sample appends a process table snapshot to the bounded sample history.
+func (w *FileAttributionWatcher) sample(now time.Time) {
+	table := readProcTable(w.procRoot)
+	w.mu.Lock()
+	w.samples = append(w.samples, procSample{at: now, procs: table})
+	if len(w.samples) > maxAttributionSamples {
+		w.samples = w.samples[len(w.samples)-maxAttributionSamples:]
+	}
+	w.mu.Unlock()
+}
+
+// baseline returns the newest sample taken at or before t, or the oldest
+// sample when none is that old.
+func (w *FileAttributionWatcher) baseline(t time.Time) procTable {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.samples) == 0 {
+		return procTable{}
+	}
+	for i := len(w.samples) - 1; i >= 0; i-- {
+		if !w.samples[i].at.After(t) {
+			return w.samples[i].procs
+		}
+	}
+	return w.samples[0].procs
+}
+
+// handleEvents is the debounced fsnotify handler.
+func (w *FileAttributionWatcher) handleEvents(events []Event) {
+	now := time.Now()
+	// Events are delivered after the debounce window, and the write itself may
+	// predate the most recent sample, so compare against a sample taken at
+	// least one full interval before the window opened.
+	changes := w.attribute(events, now, now.Add(-w.debounce-w.sampleInterval))
+	if len(changes) == 0 {
+		return
+	}
+	w.record(changes)
+	if w.handler != nil {
+		w.handler(changes)
+	}
+}
+
+// attribute turns a batch of events into attributed changes, comparing the
+// current process table with the sample taken at or before since.
+func (w *FileAttributionWatcher) attribute(events []Event, now, since time.Time) []FileAttribution {
+	types := make(map[string]EventType)
+	var order []string
+	for _, ev := range events {
+		if ev.IsDir || ev.Path == "" {
+			continue
+		}
+		if _, seen := types[ev.Path]; !seen {
+			order = append(order, ev.Path)
+		}
+		types[ev.Path] |= ev.Type
+	}
+	if len(order) == 0 {
+		return nil
+	}
+
+	if w.gitAware {
+		ignored := tracker.GitIgnored(w.projectDir, order)
+		kept := order[:0]
+		for _, p := range order {
+			if !ignored[p] {
+				kept = append(kept, p)
+			}
+		}
+		order = kept
+		if len(order) == 0 {
+			return nil
+		}
+	}
+
+	w.mu.Lock()
+	panes := append([]AgentPane(nil), w.panes...)
+	shells := make(map[int]int, len(w.shells))
+	for pid, idx := range w.shells {
+		shells[pid] = idx
+	}
+	w.mu.Unlock()
+
+	before := w.baseline(since)
+	current := readProcTable(w.procRoot)
+	owners := current.paneOwners(shells)
+	activity := paneActivity(before, current, owners)
+
+	// Open file descriptors are only read for processes inside agent panes.
+	openBy := make(map[string]map[int]bool)
+	for pid, pane := range owners {
+		for _, f := range readOpenFiles(w.procRoot, pid) {
+			if _, wanted := types[f]; !wanted {
+				continue
+			}
+			if openBy[f] == nil {
+				openBy[f] = make(map[int]bool)
+			}
+			openBy[f][pane] = true
+		}
+	}
+
+	changes := make([]FileAttribution, 0, len(order))
+	for _, path := range order {
+		change := FileAttribution{
+			Path: path,
+			Type: changeTypeFor(path, types[path]),
+			Time: now,
+		}
+		if rel, err := filepath.Rel(w.projectDir, path); err == nil {
+			change.RelPath = rel
+		} else {
+			change.RelPath = path
+		}
+
+		// CPU time alone does not show who wrote the file: a pane busy
+		// compiling or thinking may not be the one that saved it.
+		method, chosen, certain := Unattributed, []int(nil), true
+		switch {
+		case len(openBy[path]) > 0:
+			method, chosen = AttributedOpenFile, sortedKeys(openBy[path])
+		case len(activity.wrote) > 0:
+			method, chosen = AttributedDiskWrite, sortedKeys(activity.wrote)
+		case len(activity.ran) > 0:
+			chosen, certain = sortedKeys(activity.ran), false
+		}
+		switch {
+		case len(chosen) == 1 && certain:
+			pane := panes[chosen[0]]
+			change.Pane = &pane
+			change.Method = method
+		case len(chosen) > 0:
+			change.Method = AttributedAmbiguous
+			for _, idx := range chosen {
+				change.Candidates = append(change.Candidates, panes[idx])
+			}
+		default:
+			change.Method = Unattributed
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// record adds attributed changes to the tracker store so conflict detection
+// and the dashboard files panel see them.
+func (w *FileAttributionWatcher) record(changes []FileAttribution) {
+	if w.store == nil {
+		return
+	}
+	for _, c := range changes {
+		entry := tracker.RecordedFileChange{
+			Timestamp:   c.Time,
+			Session:     w.session,
+			Attribution: string(c.Method),
+			Change: tracker.FileChange{
+				Path: c.Path,
+				Type: c.Type,
+			},
+		}
+		if c.Type != tracker.FileDeleted {
+			if info, err := os.Stat(c.Path); err == nil {
+				entry.Change.After = &tracker.FileState{ModTime: info.ModTime(), Size: info.Size()}
+			}
+		}
+		if c.Pane != nil {
+			entry.Agents = []string{c.Pane.Name()}
+		}
+		for _, p := range c.Candidates {
+			entry.Candidates = append(entry.Candidates, p.Name())
+		}
+		w.store.Add(entry)
+		if w.debug {
+			log.Printf("[FileAttribution] %s %s -> %v (%s)", c.Type, c.RelPath, entry.Agents, c.Method)
+		}
+	}
+}
+
+// changeTypeFor maps coalesced event bits to a tracker change type.
+func changeTypeFor(path string, t EventType) tracker.FileChangeType {
+	_, err := os.Stat(path)
+	exists := err == nil
+	switch {
+	case !exists:
+		return tracker.FileDeleted
+	case t&(Create|Rename) != 0:
+		// Editors commonly save by renaming a temp file over the original,
+		// which surfaces as a create of the final name.
+		return tracker.FileAdded
+	default:
+		return tracker.FileModified
+	}
+}
+
+// sortedKeys returns the keys of a pane index set in ascending order.
+func sortedKeys(set map[int]bool) []int {
+	keys := make([]int, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// procStat holds the per-process counters used for attribution.
+type procStat struct {
+	ppid       int
+	cpuTicks   uint64 // utime + stime
+	writeBytes uint64 // Bytes sent to the storage layer (/proc/<pid>/io)
+}
+
+// procTable is a snapshot of the process table keyed by PID.
+type procTable map[int]procStat
+
+// procSample is a timestamped process table snapshot.
+type procSample struct {
+	at    time.Time
+	procs procTable
+}
+
+// paneOwners maps every process descending from a pane shell to that pane.
+func (t procTable) paneOwners(shells map[int]int) map[int]int {
+	owners := make(map[int]int)
+	for pid := range t {
+		cur := pid
+		for depth := 0; depth < maxAncestorDepth && cur > 1; depth++ {
+			if pane, ok := shells[cur]; ok {
+				owners[pid] = pane
+				break
+			}
+			st, ok := t[cur]
+			if !ok {
+				break
+			}
+			cur = st.ppid
+		}
+	}
+	return owners
+}
+
+// activeSets records which panes did work between two samples.
+type activeSets struct {
+	wrote map[int]bool
+	ran   map[int]bool
+}
+
+// paneActivity compares two process tables and reports the panes whose
+// processes wrote to disk or consumed CPU in between. Processes that appeared
+// since before count in full.
+func paneActivity(before, after procTable, owners map[int]int) activeSets {
+	sets := activeSets{wrote: make(map[int]bool), ran: make(map[int]bool)}
+	for pid, pane := range owners {
+		cur := after[pid]
+		prev, existed := before[pid]
+		if existed && prev.ppid != cur.ppid {
+			existed = false // PID reuse
+		}
+		if cur.writeBytes > 0 && (!existed || cur.writeBytes > prev.writeBytes) {
+			sets.wrote[pane] = true
+		}
+		if cur.cpuTicks > 0 && (!existed || cur.cpuTicks > prev.cpuTicks) {
+			sets.ran[pane] = true
+		}
+	}
+	return sets
+}
+
+// readProcTable reads the PPID and counters of every process under root.
+// Missing or unreadable entries are skipped; on systems without /proc the
+// table is empty and every change is unattributed.
+func readProcTable(root string) procTable {
+	table := make(procTable)
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return table
+	}
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil || pid <= 0 {
+			continue
+		}
+		dir := filepath.Join(root, e.Name())
+		st, err := readProcStat(filepath.Join(dir, "stat"))
+		if err != nil {
+			continue
+		}
+		st.writeBytes = readProcWriteBytes(filepath.Join(dir, "io"))
+		table[pid] = st
+	}
+	return table
+}
+
+// readProcStat parses the PPID and CPU ticks from /proc/<pid>/stat.
+func readProcStat(path string) (procStat, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return procStat{}, err
+	}
+	line := string(data)
+	// comm may contain spaces and parentheses; fields resume after the last ')'.
+	end := strings.LastIndex(line, ")")
+	if end == -1 {
+		return procStat{}, fmt.Errorf("malformed stat line")
+	}
+	// Fields after comm: state(0) ppid(1) ... utime(11) stime(12)
+	fields := strings.Fields(line[end+1:])
+	if len(fields) < 13 {
+		return procStat{}, fmt.Errorf("short stat line")
+	}
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return procStat{}, err
+	}
+	utime, _ := strconv.ParseUint(fields[11], 10, 64)
+	stime, _ := strconv.ParseUint(fields[12], 10, 64)
+	return procStat{ppid: ppid, cpuTicks: utime + stime}, nil
+}
+
+// readProcWriteBytes returns write_bytes from /proc/<pid>/io, or 0 when the
+// file is unreadable (other users' processes, kernels without task I/O accounting).
+func readProcWriteBytes(path string) uint64 {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if v, ok := strings.CutPrefix(scanner.Text(), "write_bytes:"); ok {
+			n, _ := strconv.ParseUint(strings.TrimSpace(v), 10, 64)
+			return n
+		}
+	}
+	return 0
+}
+
+// readOpenFiles returns the absolute paths a process holds open.
+func readOpenFiles(root string, pid int) []string {
+	dir := filepath.Join(root, strconv.Itoa(pid), "fd")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	files := make([]string, 0, len(entries))
+	for _, e := range entries {
+		target, err := os.Readlink(filepath.Join(dir, e.Name()))
+		if err != nil || !filepath.IsAbs(target) {
+			continue // sockets, pipes, anon inodes
+		}
+		files = append(files, strings.TrimSuffix(target, " (deleted)"))
+	}
+	return files
+}
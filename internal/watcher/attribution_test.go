@@ -0,0 +1,287 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/tracker"
+)
+
+// fakeProc builds a /proc-like tree for attribution tests.
+type fakeProc struct {
+	t    *testing.T
+	root string
+}
+
+func newFakeProc(t *testing.T) *fakeProc {
+	t.Helper()
+	return &fakeProc{t: t, root: t.TempDir()}
+}
+
+// set writes the stat and io files of pid and replaces its open files.
+func (p *fakeProc) set(pid, ppid int, cpu, writeBytes uint64, open ...string) {
+	p.t.Helper()
+	dir := filepath.Join(p.root, strconv.Itoa(pid))
+	fdDir := filepath.Join(dir, "fd")
+	if err := os.RemoveAll(fdDir); err != nil {
+		p.t.Fatal(err)
+	}
+	if err := os.MkdirAll(fdDir, 0o755); err != nil {
+		p.t.Fatal(err)
+	}
+	// comm with spaces and parentheses exercises the last-')' parsing.
+	stat := fmt.Sprintf("%d (node (v8) x) S %d 1 1 0 -1 4194304 0 0 0 0 %d 0 0 0 20 0 1 0\n", pid, ppid, cpu)
+	if err := os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0o644); err != nil {
+		p.t.Fatal(err)
+	}
+	io := fmt.Sprintf("rchar: 10\nwchar: 99\nwrite_bytes: %d\n", writeBytes)
+	if err := os.WriteFile(filepath.Join(dir, "io"), []byte(io), 0o644); err != nil {
+		p.t.Fatal(err)
+	}
+	for i, f := range open {
+		if err := os.Symlink(f, filepath.Join(fdDir, strconv.Itoa(i+3))); err != nil {
+			p.t.Fatal(err)
+		}
+	}
+	_ = os.Symlink("socket:[12345]", filepath.Join(fdDir, "0"))
+}
+
+var testPanes = []AgentPane{
+	{Session: "proj", PaneID: "%1", PaneIndex: 1, Title: "proj__cc_1", AgentType: "cc", ShellPID: 100},
+	{Session: "proj", PaneID: "%2", PaneIndex: 2, Title: "proj__cod_1", AgentType: "cod", ShellPID: 200},
+}
+
+// idleTree sets up two pane shells with an agent process each; pane 1's agent
+// has a tool subprocess.
+func idleTree(p *fakeProc) {
+	p.set(1, 0, 0, 0)
+	p.set(100, 1, 5, 0)
+	p.set(101, 100, 50, 1000)
+	p.set(102, 101, 1, 0)
+	p.set(200, 1, 5, 0)
+	p.set(201, 200, 50, 2000)
+	p.set(300, 1, 70, 500) // unrelated process outside any pane
+}
+
+func TestReadProcStat(t *testing.T) {
+	t.Parallel()
+	p := newFakeProc(t)
+	p.set(42, 7, 33, 4096, "/tmp/a.go")
+
+	table := readProcTable(p.root)
+	st, ok := table[42]
+	if !ok {
+		t.Fatalf("pid 42 missing from %v", table)
+	}
+	if st.ppid != 7 || st.cpuTicks != 33 || st.writeBytes != 4096 {
+		t.Errorf("procStat = %+v", st)
+	}
+	files := readOpenFiles(p.root, 42)
+	if len(files) != 1 || files[0] != "/tmp/a.go" {
+		t.Errorf("readOpenFiles = %v", files)
+	}
+}
+
+func TestAttribute(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		activity   func(p *fakeProc, file string)
+		wantMethod AttributionMethod
+		wantPane   string
+		wantCands  int
+	}{
+		{
+			name:       "grandchild holds file open",
+			activity:   func(p *fakeProc, file string) { p.set(102, 101, 1, 0, file) },
+			wantMethod: AttributedOpenFile,
+			wantPane:   "%1",
+		},
+		{
+			name: "open file beats disk writes elsewhere",
+			activity: func(p *fakeProc, file string) {
+				p.set(201, 200, 60, 9000, file)
+				p.set(101, 100, 60, 5000)
+			},
+			wantMethod: AttributedOpenFile,
+			wantPane:   "%2",
+		},
+		{
+			name:       "only one pane wrote to disk",
+			activity:   func(p *fakeProc, _ string) { p.set(201, 200, 51, 2500) },
+			wantMethod: AttributedDiskWrite,
+			wantPane:   "%2",
+		},
+		{
+			name:       "short-lived subprocess counts in full",
+			activity:   func(p *fakeProc, _ string) { p.set(103, 101, 1, 8) },
+			wantMethod: AttributedDiskWrite,
+			wantPane:   "%1",
+		},
+		{
+			name: "both panes wrote",
+			activity: func(p *fakeProc, _ string) {
+				p.set(101, 100, 51, 1100)
+				p.set(201, 200, 51, 2100)
+			},
+			wantMethod: AttributedAmbiguous,
+			wantCands:  2,
+		},
+		{
+			name:       "only cpu activity",
+			activity:   func(p *fakeProc, _ string) { p.set(101, 100, 80, 1000) },
+			wantMethod: AttributedAmbiguous,
+			wantCands:  1,
+		},
+		{
+			name:       "outside process only",
+			activity:   func(p *fakeProc, _ string) { p.set(300, 1, 90, 900) },
+			wantMethod: Unattributed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			file := filepath.Join(dir, "pkg", "main.go")
+			if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(file, []byte("package main\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			p := newFakeProc(t)
+			idleTree(p)
+			store := tracker.NewFileChangeStore(10)
+			w := NewFileAttributionWatcher("proj", dir, WithProcRoot(p.root), WithChangeStore(store), WithGitAware(false))
+			w.setPanes(testPanes)
+
+			t0 := time.Now()
+			w.sample(t0)
+			tt.activity(p, file)
+
+			changes := w.attribute([]Event{{Path: file, Type: Write}, {Path: file, Type: Write}}, t0.Add(time.Second), t0)
+			if len(changes) != 1 {
+				t.Fatalf("changes = %+v", changes)
+			}
+			c := changes[0]
+			if c.Method != tt.wantMethod {
+				t.Errorf("Method = %q, want %q", c.Method, tt.wantMethod)
+			}
+			if c.RelPath != filepath.Join("pkg", "main.go") || c.Type != tracker.FileModified {
+				t.Errorf("change = %+v", c)
+			}
+			switch {
+			case tt.wantPane == "" && c.Pane != nil:
+				t.Errorf("Pane = %+v, want none", c.Pane)
+			case tt.wantPane != "" && (c.Pane == nil || c.Pane.PaneID != tt.wantPane):
+				t.Errorf("Pane = %+v, want %s", c.Pane, tt.wantPane)
+			}
+			if len(c.Candidates) != tt.wantCands {
+				t.Errorf("Candidates = %+v, want %d", c.Candidates, tt.wantCands)
+			}
+
+			w.record(changes)
+			recorded := store.All()
+			if len(recorded) != 1 || recorded[0].Attribution != string(tt.wantMethod) || recorded[0].Session != "proj" {
+				t.Fatalf("recorded = %+v", recorded)
+			}
+			if tt.wantPane != "" && (len(recorded[0].Agents) != 1 || recorded[0].Agents[0] != c.Pane.Title) {
+				t.Errorf("recorded agents = %v", recorded[0].Agents)
+			}
+			if len(recorded[0].Candidates) != tt.wantCands {
+				t.Errorf("recorded candidates = %v", recorded[0].Candidates)
+			}
+		})
+	}
+}
+
+func TestAttributeChangeTypes(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	created := filepath.Join(dir, "new.go")
+	if err := os.WriteFile(created, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	deleted := filepath.Join(dir, "gone.go")
+
+	w := NewFileAttributionWatcher("proj", dir, WithProcRoot(t.TempDir()), WithChangeStore(nil), WithGitAware(false))
+	changes := w.attribute([]Event{
+		{Path: created, Type: Create},
+		{Path: created, Type: Write},
+		{Path: deleted, Type: Remove},
+		{Path: filepath.Join(dir, "sub"), Type: Create, IsDir: true},
+	}, time.Now(), time.Now())
+
+	if len(changes) != 2 {
+		t.Fatalf("changes = %+v", changes)
+	}
+	if changes[0].Type != tracker.FileAdded || changes[1].Type != tracker.FileDeleted {
+		t.Errorf("types = %s, %s", changes[0].Type, changes[1].Type)
+	}
+	for _, c := range changes {
+		if c.Method != Unattributed {
+			t.Errorf("%s attributed without /proc: %s", c.RelPath, c.Method)
+		}
+	}
+}
+
+func TestFileAttributionWatcherEndToEnd(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	file := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(file, []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	p := newFakeProc(t)
+	idleTree(p)
+	p.set(102, 101, 1, 0, file)
+
+	got := make(chan []FileAttribution, 4)
+	w := NewFileAttributionWatcher("proj", dir,
+		WithProcRoot(p.root),
+		WithChangeStore(tracker.NewFileChangeStore(10)),
+		WithGitAware(false),
+		WithAttributionDebounce(20*time.Millisecond),
+		WithPaneLister(func(context.Context) ([]AgentPane, error) { return testPanes, nil }),
+		WithAttributionHandler(func(c []FileAttribution) { got <- c }),
+	)
+	if err := w.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer w.Stop()
+
+	if err := os.WriteFile(filepath.Join(dir, ".git", "index"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case changes := <-got:
+		for _, c := range changes {
+			if c.RelPath != "main.go" {
+				t.Errorf("unexpected change %s", c.RelPath)
+				continue
+			}
+			if c.Method != AttributedOpenFile || c.Pane == nil || c.Pane.PaneID != "%1" {
+				t.Errorf("main.go attributed to %+v via %s", c.Pane, c.Method)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for attributed change")
+	}
+}
@@ -8,6 +8,13 @@ import (
 	"github.com/shahbajlive/ntm/internal/agentmail"
 )
 
+// Sources for file edit detection.
+const (
+	DetectFromFilesystem = "filesystem" // fsnotify plus process attribution
+	DetectFromOutput     = "output"     // Regex over captured pane output
+	DetectFromBoth       = "both"
+)
+
 // FileReservationConfigValues holds the values needed to configure a FileReservationWatcher.
 // This struct avoids import cycles by using primitive types instead of config.FileReservationConfig.
 type FileReservationConfigValues struct {
@@ -19,6 +26,7 @@ type FileReservationConfigValues struct {
 	DefaultTTLMin         int
 	PollIntervalSec       int
 	CaptureLinesForDetect int
+	DetectFrom            string // "filesystem", "output" or "both"
 	Debug                 bool
 }
 
@@ -62,6 +70,11 @@ func NewFileReservationWatcherFromConfig(
 		opts = append(opts, WithCaptureLines(cfg.CaptureLinesForDetect))
 	}
 
+	// Filesystem-only detection replaces pane output scanning
+	if cfg.DetectFrom == DetectFromFilesystem {
+		opts = append(opts, WithOutputDetection(false))
+	}
+
 	// Apply conflict callback if notification is enabled
 	if cfg.NotifyOnConflict && conflictCallback != nil {
 		opts = append(opts, WithConflictCallback(conflictCallback))
@@ -82,6 +95,7 @@ func DefaultFileReservationConfigValues() FileReservationConfigValues {
 		DefaultTTLMin:         15,
 		PollIntervalSec:       10,
 		CaptureLinesForDetect: 100,
+		DetectFrom:            DetectFromBoth,
 		Debug:                 false,
 	}
 }
@@ -13,6 +13,7 @@ import (
 
 	"github.com/shahbajlive/ntm/internal/agentmail"
 	"github.com/shahbajlive/ntm/internal/tmux"
+	"github.com/shahbajlive/ntm/internal/tracker"
 )
 
 const (
@@ -54,6 +55,7 @@ type FileReservationWatcher struct {
 	wg                 sync.WaitGroup
 	debug              bool
 	conflictCallback   ConflictCallback // Called when conflicts are detected
+	detectFromOutput   bool             // Scan pane output for edits; off with detect_from = "filesystem"
 }
 
 // FileReservationWatcherOption configures a FileReservationWatcher.
@@ -130,6 +132,14 @@ func WithCaptureLines(lines int) FileReservationWatcherOption {
 	}
 }
 
+// WithOutputDetection controls whether pane output is scanned for file edits.
+// Disable it only when a FileAttributionWatcher is the sole source of edits.
+func WithOutputDetection(enabled bool) FileReservationWatcherOption {
+	return func(w *FileReservationWatcher) {
+		w.detectFromOutput = enabled
+	}
+}
+
 // NewFileReservationWatcher creates a new FileReservationWatcher.
 func NewFileReservationWatcher(opts ...FileReservationWatcherOption) *FileReservationWatcher {
 	w := &FileReservationWatcher{
@@ -138,6 +148,7 @@ func NewFileReservationWatcher(opts ...FileReservationWatcherOption) *FileReserv
 		reservationTTL:     DefaultReservationTTL,
 		captureLines:       DefaultCaptureLinesReservation,
 		activeReservations: make(map[string]*PaneReservation),
+		detectFromOutput:   true,
 	}
 
 	for _, opt := range opts {
@@ -208,7 +219,9 @@ func (w *FileReservationWatcher) run(ctx context.Context, stopCh <-chan struct{}
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			w.checkPaneOutputs(ctx)
+			if w.detectFromOutput {
+				w.checkPaneOutputs(ctx)
+			}
 			w.releaseIdleReservations(ctx)
 		}
 	}
@@ -271,6 +284,33 @@ func mapAgentTypeToPatternAgent(agentType tmux.AgentType) string {
 	}
 }
 
+// OnAttributedChanges reserves files that a FileAttributionWatcher attributed
+// to a single pane from an open file or disk writes. Deletions and ambiguous
+// or unattributed changes are skipped.
+func (w *FileReservationWatcher) OnAttributedChanges(ctx context.Context, changes []FileAttribution) {
+	byPane := make(map[string][]string)
+	panes := make(map[string]AgentPane)
+	var order []string
+	for _, c := range changes {
+		if c.Pane == nil || c.Type == tracker.FileDeleted {
+			continue
+		}
+		if c.Method != AttributedOpenFile && c.Method != AttributedDiskWrite {
+			continue
+		}
+		if _, seen := panes[c.Pane.PaneID]; !seen {
+			order = append(order, c.Pane.PaneID)
+			panes[c.Pane.PaneID] = *c.Pane
+		}
+		byPane[c.Pane.PaneID] = append(byPane[c.Pane.PaneID], c.RelPath)
+	}
+	for _, id := range order {
+		p := panes[id]
+		pane := tmux.Pane{ID: p.PaneID, Index: p.PaneIndex, Title: p.Title, Type: tmux.AgentType(p.AgentType), PID: p.ShellPID}
+		w.OnFileEdit(ctx, p.Session, pane, byPane[id])
+	}
+}
+
 // OnFileEdit handles detected file edits by reserving files.
 func (w *FileReservationWatcher) OnFileEdit(ctx context.Context, sessionName string, pane tmux.Pane, files []string) {
 	w.mu.Lock()
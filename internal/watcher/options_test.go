@@ -38,6 +38,9 @@ func TestDefaultFileReservationConfigValues(t *testing.T) {
 	if cfg.CaptureLinesForDetect != 100 {
 		t.Errorf("CaptureLinesForDetect = %d, want 100", cfg.CaptureLinesForDetect)
 	}
+	if cfg.DetectFrom != DetectFromBoth {
+		t.Errorf("DetectFrom = %q, want %q", cfg.DetectFrom, DetectFromBoth)
+	}
 	if cfg.Debug {
 		t.Error("Debug should be false by default")
 	}
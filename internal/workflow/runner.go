@@ -0,0 +1,1040 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/events"
+	"github.com/shahbajlive/ntm/internal/templates"
+)
+
+// ParallelStage is the single implicit stage of workflows without a flow.
+const ParallelStage = "parallel"
+
+// Runner defaults.
+const (
+	DefaultPollInterval    = 5 * time.Second
+	DefaultCommandInterval = 30 * time.Second
+	// handoffLines bounds the previous stage's output quoted in handoff prompts.
+	handoffLines = 40
+)
+
+// AgentState is the coarse activity state the runner needs from an agent.
+type AgentState string
+
+const (
+	AgentIdle    AgentState = "idle"
+	AgentWorking AgentState = "working"
+	AgentError   AgentState = "error"
+	AgentCrashed AgentState = "crashed"
+	AgentUnknown AgentState = "unknown"
+)
+
+// Agent is a pane bound to a workflow role.
+type Agent struct {
+	PaneID  string `json:"pane_id"`
+	Role    string `json:"role"`
+	Profile string `json:"profile,omitempty"`
+	Title   string `json:"title,omitempty"`
+}
+
+// AgentStatus is a point-in-time view of one agent.
+type AgentStatus struct {
+	State      AgentState
+	LastActive time.Time
+}
+
+// Message is an Agent Mail message addressed to the session.
+type Message struct {
+	PaneID  string // sender pane, empty when the sender is not a session agent
+	From    string
+	Subject string
+	Body    string
+	At      time.Time
+}
+
+// FileEvent is a change in the project directory.
+type FileEvent struct {
+	Path    string // relative to the project directory
+	Created bool
+}
+
+// Runtime is everything the runner needs from the outside world. The CLI
+// backs it with tmux, the status detector, the file watcher and Agent Mail;
+// tests use a fake.
+type Runtime interface {
+	// LaunchAgents starts (or finds) the panes for every role of t.
+	LaunchAgents(ctx context.Context, t *WorkflowTemplate) ([]Agent, error)
+	// WatchFiles delivers project file events to fn until ctx is done.
+	WatchFiles(ctx context.Context, fn func(FileEvent)) error
+	SendPrompt(ctx context.Context, a Agent, prompt string) error
+	CaptureOutput(ctx context.Context, a Agent) (string, error)
+	// AgentStatuses returns the status of every agent keyed by pane ID.
+	AgentStatuses(ctx context.Context) (map[string]AgentStatus, error)
+	// RunCommand runs a shell command in the project directory and reports
+	// whether it exited successfully. err is reserved for failures to run it.
+	RunCommand(ctx context.Context, command string) (bool, error)
+	RestartAgent(ctx context.Context, a Agent) error
+	Messages(ctx context.Context, since time.Time) ([]Message, error)
+	Notify(ctx context.Context, subject, body string)
+}
+
+// RunState is the lifecycle state of a workflow run.
+type RunState string
+
+const (
+	RunRunning   RunState = "running"
+	RunPaused    RunState = "paused"
+	RunCompleted RunState = "completed"
+	RunFailed    RunState = "failed"
+	RunCancelled RunState = "cancelled"
+)
+
+// StageVisit records entry into a stage.
+type StageVisit struct {
+	Stage   string    `json:"stage"`
+	From    string    `json:"from,omitempty"`
+	Trigger string    `json:"trigger"`
+	At      time.Time `json:"at"`
+}
+
+// RunStatus is a snapshot of a workflow run.
+type RunStatus struct {
+	RunID          string            `json:"run_id"`
+	Workflow       string            `json:"workflow"`
+	Session        string            `json:"session"`
+	State          RunState          `json:"state"`
+	Stage          string            `json:"stage"`
+	StageEnteredAt time.Time         `json:"stage_entered_at"`
+	StartedAt      time.Time         `json:"started_at"`
+	FinishedAt     *time.Time        `json:"finished_at,omitempty"`
+	PauseReason    string            `json:"pause_reason,omitempty"`
+	Error          string            `json:"error,omitempty"`
+	Vars           map[string]string `json:"vars,omitempty"`
+	History        []StageVisit      `json:"history"`
+	Agents         []Agent           `json:"agents"`
+}
+
+// Done reports whether the run has finished.
+func (s RunStatus) Done() bool {
+	return s.State == RunCompleted || s.State == RunFailed || s.State == RunCancelled
+}
+
+// RunConfig configures a Runner.
+type RunConfig struct {
+	Session         string
+	RunID           string
+	Vars            map[string]string
+	PollInterval    time.Duration
+	CommandInterval time.Duration
+	Bus             *events.EventBus // defaults to events.DefaultBus
+	Now             func() time.Time
+	// OnUpdate is called with a fresh snapshot whenever the run changes.
+	OnUpdate func(RunStatus)
+}
+
+type signalKind int
+
+const (
+	signalAdvance signalKind = iota
+	signalPause
+	signalResume
+	signalAbort
+)
+
+type signal struct {
+	kind signalKind
+	arg  string
+}
+
+// Runner executes a workflow template's flow as a state machine: it
+// launches the roles, hands each stage to its role with a templated prompt,
+// watches the stage's transition triggers and applies error handling.
+type Runner struct {
+	tmpl    *WorkflowTemplate
+	rt      Runtime
+	cfg     RunConfig
+	vars    map[string]string
+	signals chan signal
+	files   chan FileEvent
+
+	mu     sync.Mutex
+	status RunStatus
+
+	// Per-stage state, reset on every stage entry.
+	stagePrompts map[string]string          // pane -> prompt sent on entry
+	baseline     map[string]map[string]bool // pane -> output lines at entry
+	lastCommand  map[string]time.Time
+	seenWorking  map[string]bool
+	errored      map[string]bool
+	timedOut     bool
+	retries      int
+	pendingFiles []FileEvent
+}
+
+// NewRunner validates the template and setup variables and returns a runner.
+func NewRunner(tmpl *WorkflowTemplate, rt Runtime, cfg RunConfig) (*Runner, error) {
+	if err := tmpl.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid workflow %q: %w", tmpl.Name, err)
+	}
+	vars, err := ResolveVars(tmpl, cfg.Vars)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = DefaultPollInterval
+	}
+	if cfg.CommandInterval <= 0 {
+		cfg.CommandInterval = DefaultCommandInterval
+	}
+	if cfg.Bus == nil {
+		cfg.Bus = events.DefaultBus
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	if cfg.RunID == "" {
+		cfg.RunID = fmt.Sprintf("%s-%d", tmpl.Name, cfg.Now().UnixNano())
+	}
+	return &Runner{
+		tmpl:    tmpl,
+		rt:      rt,
+		cfg:     cfg,
+		vars:    vars,
+		signals: make(chan signal, 16),
+		files:   make(chan FileEvent, 256),
+		status: RunStatus{
+			RunID:    cfg.RunID,
+			Workflow: tmpl.Name,
+			Session:  cfg.Session,
+			Vars:     vars,
+		},
+	}, nil
+}
+
+// ResolveVars applies setup prompt defaults to given and checks required
+// values and validation patterns.
+func ResolveVars(tmpl *WorkflowTemplate, given map[string]string) (map[string]string, error) {
+	vars := make(map[string]string, len(given)+len(tmpl.Prompts))
+	for k, v := range given {
+		vars[k] = v
+	}
+	for _, p := range tmpl.Prompts {
+		v := strings.TrimSpace(vars[p.Key])
+		if v == "" {
+			v = p.Default
+		}
+		if v == "" {
+			if p.Required {
+				return nil, fmt.Errorf("missing required variable %q (%s)", p.Key, p.Question)
+			}
+			continue
+		}
+		if p.Validation != "" {
+			re, err := regexp.Compile(p.Validation)
+			if err != nil {
+				return nil, fmt.Errorf("variable %q: invalid validation regex: %w", p.Key, err)
+			}
+			if !re.MatchString(v) {
+				return nil, fmt.Errorf("variable %q: %q does not match %s", p.Key, v, p.Validation)
+			}
+		}
+		vars[p.Key] = v
+	}
+	return vars, nil
+}
+
+// Status returns a snapshot of the run.
+func (r *Runner) Status() RunStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.snapshot()
+}
+
+func (r *Runner) snapshot() RunStatus {
+	s := r.status
+	s.History = append([]StageVisit(nil), r.status.History...)
+	s.Agents = append([]Agent(nil), r.status.Agents...)
+	return s
+}
+
+// Advance fires a manual transition out of the current stage. label selects
+// the transition by trigger label or target stage; empty picks the first
+// manual transition. Naming a target stage forces that transition whatever
+// its trigger type.
+func (r *Runner) Advance(label string) { r.signal(signal{kind: signalAdvance, arg: label}) }
+
+// Pause stops trigger evaluation until Resume.
+func (r *Runner) Pause(reason string) { r.signal(signal{kind: signalPause, arg: reason}) }
+
+// Resume continues a paused run.
+func (r *Runner) Resume() { r.signal(signal{kind: signalResume}) }
+
+// Abort stops the run as failed.
+func (r *Runner) Abort(reason string) { r.signal(signal{kind: signalAbort, arg: reason}) }
+
+func (r *Runner) signal(s signal) {
+	select {
+	case r.signals <- s:
+	default:
+	}
+}
+
+// Start launches the roles, starts file watching for the lifetime of ctx
+// and enters the initial stage. Run calls it; callers driving the runner
+// themselves call Tick afterwards.
+func (r *Runner) Start(ctx context.Context) error {
+	now := r.cfg.Now()
+	r.mu.Lock()
+	r.status.State = RunRunning
+	r.status.StartedAt = now
+	r.mu.Unlock()
+
+	agents, err := r.rt.LaunchAgents(ctx, r.tmpl)
+	if err != nil {
+		r.finish(RunFailed, fmt.Sprintf("launching agents: %v", err))
+		return err
+	}
+	r.mu.Lock()
+	r.status.Agents = agents
+	r.mu.Unlock()
+
+	labels := make([]string, 0, len(agents))
+	for _, a := range agents {
+		labels = append(labels, a.Role+":"+a.PaneID)
+	}
+	r.cfg.Bus.Publish(events.NewWorkflowStartedEvent(r.cfg.Session, r.tmpl.Name, r.cfg.RunID, labels))
+
+	if r.needsFileEvents() {
+		if err := r.rt.WatchFiles(ctx, func(ev FileEvent) {
+			select {
+			case r.files <- ev:
+			default:
+			}
+		}); err != nil {
+			r.rt.Notify(ctx, r.tmpl.Name+": file triggers disabled", err.Error())
+		}
+	}
+
+	r.enter(ctx, r.initialStage(), "", "start")
+	return nil
+}
+
+// Run starts the workflow and drives the flow until it reaches a terminal
+// stage, is aborted, or ctx is cancelled.
+func (r *Runner) Run(ctx context.Context) (RunStatus, error) {
+	watchCtx, stopWatch := context.WithCancel(ctx)
+	defer stopWatch()
+	if err := r.Start(watchCtx); err != nil {
+		return r.Status(), err
+	}
+
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+	for !r.Status().Done() {
+		select {
+		case <-ctx.Done():
+			r.finish(RunCancelled, ctx.Err().Error())
+		case s := <-r.signals:
+			r.handleSignal(ctx, s)
+		case ev := <-r.files:
+			r.pendingFiles = append(r.pendingFiles, ev)
+		case <-ticker.C:
+			r.Tick(ctx)
+		}
+	}
+	st := r.Status()
+	if st.State == RunFailed {
+		return st, fmt.Errorf("workflow %s failed: %s", r.tmpl.Name, st.Error)
+	}
+	return st, nil
+}
+
+// Tick applies pending signals, then evaluates error handling, timeouts and
+// the current stage's triggers once. Run calls it on every poll interval.
+func (r *Runner) Tick(ctx context.Context) {
+	for pending := true; pending; {
+		select {
+		case s := <-r.signals:
+			r.handleSignal(ctx, s)
+		default:
+			pending = false
+		}
+	}
+	st := r.Status()
+	if st.State != RunRunning {
+		return
+	}
+	r.drainFiles()
+	now := r.cfg.Now()
+
+	statuses, err := r.rt.AgentStatuses(ctx)
+	if err != nil {
+		statuses = nil
+	}
+	if r.handleAgentErrors(ctx, statuses) {
+		return
+	}
+
+	stage := st.Stage
+	agents := r.stageAgents(stage)
+	for _, a := range agents {
+		if statuses[a.PaneID].State == AgentWorking {
+			r.seenWorking[a.PaneID] = true
+		}
+	}
+
+	if r.tmpl.Flow == nil {
+		// Parallel workflows finish once every agent has worked and gone idle.
+		if allIdle(agents, statuses) && len(r.seenWorking) >= len(agents) {
+			r.transition(ctx, "complete", "all agents finished")
+			return
+		}
+	} else {
+		var msgs []Message
+		msgsLoaded := false
+		for _, tr := range r.outgoing(stage) {
+			if tr.Trigger.Type == TriggerAgentSays && !msgsLoaded {
+				msgs, _ = r.rt.Messages(ctx, st.StageEnteredAt)
+				msgsLoaded = true
+			}
+			if r.fired(ctx, tr, statuses, msgs, now) {
+				r.transition(ctx, tr.To, describeTrigger(tr.Trigger))
+				r.pendingFiles = nil
+				return
+			}
+		}
+	}
+	r.pendingFiles = nil
+
+	if r.stageTimeout() > 0 && !r.timedOut && now.Sub(st.StageEnteredAt) >= r.stageTimeout() {
+		r.timedOut = true
+		reason := fmt.Sprintf("stage %q exceeded %d minute timeout", stage, r.errorConfig().StageTimeoutMinutes)
+		r.apply(ctx, r.errorConfig().OnTimeout, reason, agents)
+	}
+}
+
+func (r *Runner) drainFiles() {
+	for {
+		select {
+		case ev := <-r.files:
+			r.pendingFiles = append(r.pendingFiles, ev)
+		default:
+			return
+		}
+	}
+}
+
+func (r *Runner) handleSignal(ctx context.Context, s signal) {
+	st := r.Status()
+	switch s.kind {
+	case signalAdvance:
+		if st.Done() {
+			return
+		}
+		tr, ok := r.manualTransition(st.Stage, s.arg)
+		if !ok {
+			r.rt.Notify(ctx, r.tmpl.Name+": cannot advance", fmt.Sprintf("no transition out of %q matches %q", st.Stage, s.arg))
+			return
+		}
+		trigger := describeTrigger(tr.Trigger)
+		if tr.Trigger.Type != TriggerManual {
+			trigger = "advanced by operator"
+		}
+		if st.State == RunPaused {
+			r.setPaused(false, "")
+		}
+		r.transition(ctx, tr.To, trigger)
+	case signalPause:
+		if st.State == RunRunning {
+			r.pause(s.arg)
+		}
+	case signalResume:
+		if st.State == RunPaused {
+			r.setPaused(false, "")
+			// Give the stage a fresh timeout window after a pause.
+			r.mu.Lock()
+			r.status.StageEnteredAt = r.cfg.Now()
+			r.mu.Unlock()
+			r.timedOut = false
+			r.errored = make(map[string]bool)
+			r.notifyUpdate()
+		}
+	case signalAbort:
+		if !st.Done() {
+			r.finish(RunFailed, "aborted: "+s.arg)
+		}
+	}
+}
+
+func (r *Runner) manualTransition(stage, label string) (Transition, bool) {
+	out := r.outgoing(stage)
+	for _, tr := range out {
+		if label != "" && strings.EqualFold(tr.To, label) {
+			return tr, true
+		}
+	}
+	for _, tr := range out {
+		if tr.Trigger.Type != TriggerManual {
+			continue
+		}
+		if label == "" || strings.EqualFold(tr.Trigger.Label, label) {
+			return tr, true
+		}
+	}
+	return Transition{}, false
+}
+
+func (r *Runner) fired(ctx context.Context, tr Transition, statuses map[string]AgentStatus, msgs []Message, now time.Time) bool {
+	trig := tr.Trigger
+	st := r.Status()
+	switch trig.Type {
+	case TriggerFileCreated, TriggerFileModified:
+		for _, ev := range r.pendingFiles {
+			if trig.Type == TriggerFileCreated && !ev.Created {
+				continue
+			}
+			if matchFile(r.expand(trig.Pattern), ev.Path) {
+				return true
+			}
+		}
+	case TriggerCommandSuccess, TriggerCommandFailure:
+		// Only check once the stage's agents have stopped working, and not
+		// more often than the command interval.
+		if !allIdle(r.stageAgents(st.Stage), statuses) {
+			return false
+		}
+		command := r.expand(trig.Command)
+		if last, ok := r.lastCommand[command]; ok && now.Sub(last) < r.cfg.CommandInterval {
+			return false
+		}
+		r.lastCommand[command] = now
+		ok, err := r.rt.RunCommand(ctx, command)
+		if err != nil {
+			return false
+		}
+		return ok == (trig.Type == TriggerCommandSuccess)
+	case TriggerAgentSays:
+		re, err := regexp.Compile("(?i)" + trig.Pattern)
+		if err != nil {
+			return false
+		}
+		agents := r.roleAgents(trig.Role)
+		panes := make(map[string]bool, len(agents))
+		for _, a := range agents {
+			panes[a.PaneID] = true
+			out, err := r.rt.CaptureOutput(ctx, a)
+			if err != nil {
+				continue
+			}
+			for _, line := range r.newLines(a.PaneID, out) {
+				if re.MatchString(line) {
+					return true
+				}
+			}
+		}
+		for _, m := range msgs {
+			if trig.Role != "" && !panes[m.PaneID] {
+				continue
+			}
+			if re.MatchString(m.Subject) || re.MatchString(m.Body) {
+				return true
+			}
+		}
+	case TriggerAllAgentsIdle:
+		agents := r.roleAgents(trig.Role)
+		if !allIdle(agents, statuses) {
+			return false
+		}
+		need := time.Duration(trig.IdleMinutes) * time.Minute
+		for _, a := range agents {
+			since := statuses[a.PaneID].LastActive
+			if since.Before(st.StageEnteredAt) {
+				since = st.StageEnteredAt
+			}
+			if now.Sub(since) < need {
+				return false
+			}
+		}
+		return true
+	case TriggerTimeElapsed:
+		return now.Sub(st.StageEnteredAt) >= time.Duration(trig.Minutes)*time.Minute
+	}
+	return false
+}
+
+// newLines returns the lines of out that were not on screen when the stage
+// began and are not an echo of the prompt the runner sent.
+func (r *Runner) newLines(paneID, out string) []string {
+	base := r.baseline[paneID]
+	prompt := normalize(r.stagePrompts[paneID])
+	var lines []string
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || base[line] {
+			continue
+		}
+		if n := normalize(line); n == "" || strings.Contains(prompt, n) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// normalize strips pane decorations and collapses whitespace so wrapped
+// prompt echoes can be recognised as substrings of the prompt.
+func normalize(s string) string {
+	s = strings.TrimLeftFunc(s, func(c rune) bool {
+		return !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9')
+	})
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func (r *Runner) handleAgentErrors(ctx context.Context, statuses map[string]AgentStatus) bool {
+	cfg := r.errorConfig()
+	stage := r.Status().Stage
+	for _, a := range r.stageAgents(stage) {
+		state := statuses[a.PaneID].State
+		if state != AgentError && state != AgentCrashed {
+			delete(r.errored, a.PaneID)
+			continue
+		}
+		if r.errored[a.PaneID] {
+			continue
+		}
+		r.errored[a.PaneID] = true
+		action := cfg.OnAgentError
+		what := "error"
+		if state == AgentCrashed {
+			what = "crash"
+			if cfg.OnAgentCrash != "" {
+				action = cfg.OnAgentCrash
+			}
+		}
+		reason := fmt.Sprintf("agent %s (%s) %s in stage %q", a.PaneID, a.Role, what, stage)
+		r.apply(ctx, action, reason, []Agent{a})
+		if r.Status().Stage != stage || r.Status().State != RunRunning {
+			return true
+		}
+	}
+	return false
+}
+
+// apply performs an error_handling action. Unset actions notify.
+func (r *Runner) apply(ctx context.Context, action ErrorAction, reason string, agents []Agent) {
+	st := r.Status()
+	switch action {
+	case ErrorActionPause:
+		r.pause(reason)
+	case ErrorActionAbort:
+		r.finish(RunFailed, reason)
+	case ErrorActionSkipStage:
+		out := r.outgoing(st.Stage)
+		if len(out) == 0 {
+			r.finish(RunCompleted, "")
+			return
+		}
+		r.transition(ctx, out[0].To, "skip_stage: "+reason)
+	case ErrorActionRestartAgent:
+		limit := r.errorConfig().MaxRetriesPerStage
+		if limit == 0 {
+			limit = 1
+		}
+		if r.retries >= limit {
+			r.pause(fmt.Sprintf("%s (retries exhausted after %d)", reason, r.retries))
+			return
+		}
+		r.retries++
+		for _, a := range agents {
+			if err := r.rt.RestartAgent(ctx, a); err != nil {
+				r.pause(fmt.Sprintf("%s; restart %s failed: %v", reason, a.PaneID, err))
+				return
+			}
+			delete(r.errored, a.PaneID)
+			if prompt := r.stagePrompts[a.PaneID]; prompt != "" {
+				_ = r.rt.SendPrompt(ctx, a, prompt)
+			}
+		}
+		r.rt.Notify(ctx, r.tmpl.Name+": restarted agent", reason)
+	default:
+		r.rt.Notify(ctx, r.tmpl.Name+": "+st.Stage, reason)
+	}
+}
+
+func (r *Runner) transition(ctx context.Context, to, trigger string) {
+	from := r.Status().Stage
+	r.cfg.Bus.Publish(events.NewStageTransitionEvent(r.cfg.Session, r.tmpl.Name, r.cfg.RunID, from, to, trigger))
+	r.enter(ctx, to, from, trigger)
+}
+
+func (r *Runner) enter(ctx context.Context, stage, from, trigger string) {
+	handoff := ""
+	if from != "" {
+		handoff = r.handoff(ctx, from)
+	}
+
+	now := r.cfg.Now()
+	r.mu.Lock()
+	r.status.Stage = stage
+	r.status.StageEnteredAt = now
+	r.status.History = append(r.status.History, StageVisit{Stage: stage, From: from, Trigger: trigger, At: now})
+	r.mu.Unlock()
+
+	r.stagePrompts = make(map[string]string)
+	r.baseline = make(map[string]map[string]bool)
+	r.lastCommand = make(map[string]time.Time)
+	r.seenWorking = make(map[string]bool)
+	r.errored = make(map[string]bool)
+	r.timedOut = false
+	r.retries = 0
+	r.pendingFiles = nil
+
+	if r.isTerminal(stage) {
+		r.finish(RunCompleted, "")
+		return
+	}
+
+	for _, a := range r.roleAgents("") {
+		out, err := r.rt.CaptureOutput(ctx, a)
+		if err != nil {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, line := range strings.Split(out, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				seen[line] = true
+			}
+		}
+		r.baseline[a.PaneID] = seen
+	}
+
+	for _, a := range r.stageAgents(stage) {
+		prompt, err := r.renderPrompt(a, stage, from, trigger, handoff)
+		if err != nil {
+			r.rt.Notify(ctx, r.tmpl.Name+": prompt error", err.Error())
+			continue
+		}
+		r.stagePrompts[a.PaneID] = prompt
+		if err := r.rt.SendPrompt(ctx, a, prompt); err != nil {
+			r.rt.Notify(ctx, r.tmpl.Name+": send failed", fmt.Sprintf("%s: %v", a.PaneID, err))
+		}
+	}
+	r.notifyUpdate()
+}
+
+// handoff returns the tail of what the previous stage's agents produced.
+func (r *Runner) handoff(ctx context.Context, from string) string {
+	var parts []string
+	for _, a := range r.stageAgents(from) {
+		out, err := r.rt.CaptureOutput(ctx, a)
+		if err != nil {
+			continue
+		}
+		lines := r.newLines(a.PaneID, out)
+		if len(lines) > handoffLines {
+			lines = lines[len(lines)-handoffLines:]
+		}
+		if len(lines) > 0 {
+			parts = append(parts, strings.Join(lines, "\n"))
+		}
+	}
+	return strings.Join(parts, "\n---\n")
+}
+
+// defaultStagePrompt is used for stages without a flow.stage_prompts entry.
+const defaultStagePrompt = `[workflow {{workflow}}] You are the {{role}} agent.{{#role_description}} {{role_description}}.{{/role_description}}
+Stage: {{stage}}{{#from_stage}} (handed off from {{from_stage}} after {{trigger}}){{/from_stage}}.
+{{#context}}{{context}}
+{{/context}}{{#handoff}}
+Latest output from the previous stage:
+{{handoff}}
+{{/handoff}}{{#exit}}
+This stage ends when {{exit}}.{{/exit}}`
+
+func (r *Runner) renderPrompt(a Agent, stage, from, trigger, handoff string) (string, error) {
+	vars := make(map[string]string, len(r.vars)+8)
+	for k, v := range r.vars {
+		vars[k] = v
+	}
+	var ctxLines []string
+	for _, p := range r.tmpl.Prompts {
+		if v := r.vars[p.Key]; v != "" {
+			ctxLines = append(ctxLines, fmt.Sprintf("%s %s", p.Question, v))
+		}
+	}
+	var exits []string
+	for _, tr := range r.outgoing(stage) {
+		exits = append(exits, describeTrigger(tr.Trigger)+" (next: "+tr.To+")")
+	}
+	vars["workflow"] = r.tmpl.Name
+	vars["description"] = r.tmpl.Description
+	vars["stage"] = stage
+	vars["from_stage"] = from
+	vars["trigger"] = trigger
+	vars["role"] = a.Role
+	vars["role_description"] = strings.TrimSuffix(r.roleDescription(a.Role), ".")
+	vars["context"] = strings.Join(ctxLines, "\n")
+	vars["handoff"] = handoff
+	vars["exit"] = strings.Join(exits, ", or ")
+
+	body := defaultStagePrompt
+	if r.tmpl.Flow != nil && r.tmpl.Flow.StagePrompts[stage] != "" {
+		body = r.tmpl.Flow.StagePrompts[stage]
+	}
+	tmpl := &templates.Template{Name: r.tmpl.Name + "/" + stage, Body: body}
+	out, err := tmpl.Execute(templates.ExecutionContext{Variables: vars, Session: r.cfg.Session})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func (r *Runner) expand(s string) string {
+	for k, v := range r.vars {
+		s = strings.ReplaceAll(s, "{{"+k+"}}", v)
+	}
+	return s
+}
+
+func (r *Runner) roleDescription(role string) string {
+	for _, a := range r.tmpl.Agents {
+		if a.Role == role && a.Description != "" {
+			return a.Description
+		}
+	}
+	return ""
+}
+
+func (r *Runner) pause(reason string) {
+	r.setPaused(true, reason)
+	r.cfg.Bus.Publish(events.NewWorkflowPausedEvent(r.cfg.Session, r.tmpl.Name, r.cfg.RunID, reason))
+}
+
+func (r *Runner) setPaused(paused bool, reason string) {
+	r.mu.Lock()
+	if r.status.Done() {
+		r.mu.Unlock()
+		return
+	}
+	if paused {
+		r.status.State = RunPaused
+	} else {
+		r.status.State = RunRunning
+	}
+	r.status.PauseReason = reason
+	r.mu.Unlock()
+	r.notifyUpdate()
+}
+
+func (r *Runner) finish(state RunState, reason string) {
+	now := r.cfg.Now()
+	r.mu.Lock()
+	if r.status.Done() {
+		r.mu.Unlock()
+		return
+	}
+	r.status.State = state
+	r.status.Error = reason
+	r.status.PauseReason = ""
+	r.status.FinishedAt = &now
+	duration := int(now.Sub(r.status.StartedAt).Seconds())
+	stages := len(r.status.History)
+	r.mu.Unlock()
+
+	r.cfg.Bus.Publish(events.NewWorkflowCompletedEvent(r.cfg.Session, r.tmpl.Name, r.cfg.RunID,
+		duration, stages, state == RunCompleted, reason))
+	r.notifyUpdate()
+}
+
+func (r *Runner) notifyUpdate() {
+	if r.cfg.OnUpdate != nil {
+		r.cfg.OnUpdate(r.Status())
+	}
+}
+
+func (r *Runner) errorConfig() ErrorConfig {
+	if r.tmpl.ErrorHandling == nil {
+		return ErrorConfig{}
+	}
+	return *r.tmpl.ErrorHandling
+}
+
+func (r *Runner) stageTimeout() time.Duration {
+	return time.Duration(r.errorConfig().StageTimeoutMinutes) * time.Minute
+}
+
+func (r *Runner) needsFileEvents() bool {
+	if r.tmpl.Flow == nil {
+		return false
+	}
+	for _, tr := range r.tmpl.Flow.Transitions {
+		if tr.Trigger.Type == TriggerFileCreated || tr.Trigger.Type == TriggerFileModified {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Runner) initialStage() string {
+	f := r.tmpl.Flow
+	switch {
+	case f == nil:
+		return ParallelStage
+	case f.Initial != "":
+		return f.Initial
+	case len(f.Stages) > 0:
+		return f.Stages[0]
+	default:
+		return f.Transitions[0].From
+	}
+}
+
+func (r *Runner) outgoing(stage string) []Transition {
+	if r.tmpl.Flow == nil {
+		return nil
+	}
+	var out []Transition
+	for _, tr := range r.tmpl.Flow.Transitions {
+		if tr.From == stage {
+			out = append(out, tr)
+		}
+	}
+	return out
+}
+
+// isTerminal reports whether stage ends the run: a stage with no way out.
+func (r *Runner) isTerminal(stage string) bool {
+	if r.tmpl.Flow == nil {
+		return stage != ParallelStage
+	}
+	return len(r.outgoing(stage)) == 0
+}
+
+// StageRoles returns the roles that work during stage: the stage itself
+// when it names a role, otherwise the roles its triggers watch, otherwise
+// the roles no trigger watches (the ones driving the flow).
+func (t *WorkflowTemplate) StageRoles(stage string) []string {
+	roles := t.GetRoles()
+	if t.Flow == nil {
+		return roles
+	}
+	for _, role := range roles {
+		if role == stage {
+			return []string{role}
+		}
+	}
+	var watched []string
+	seen := make(map[string]bool)
+	for _, tr := range t.Flow.Transitions {
+		if tr.From == stage && tr.Trigger.Role != "" && !seen[tr.Trigger.Role] {
+			seen[tr.Trigger.Role] = true
+			watched = append(watched, tr.Trigger.Role)
+		}
+	}
+	if len(watched) > 0 {
+		return watched
+	}
+	referenced := make(map[string]bool)
+	for _, tr := range t.Flow.Transitions {
+		if tr.Trigger.Role != "" {
+			referenced[tr.Trigger.Role] = true
+		}
+	}
+	var drivers []string
+	for _, role := range roles {
+		if !referenced[role] {
+			drivers = append(drivers, role)
+		}
+	}
+	if len(drivers) > 0 {
+		return drivers
+	}
+	return roles[:1]
+}
+
+func (r *Runner) stageAgents(stage string) []Agent {
+	if r.isTerminal(stage) {
+		return nil
+	}
+	var out []Agent
+	for _, role := range r.tmpl.StageRoles(stage) {
+		out = append(out, r.roleAgents(role)...)
+	}
+	return out
+}
+
+// roleAgents returns the agents of role, or every agent when role is empty.
+func (r *Runner) roleAgents(role string) []Agent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []Agent
+	for _, a := range r.status.Agents {
+		if role == "" || a.Role == role {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+func allIdle(agents []Agent, statuses map[string]AgentStatus) bool {
+	if len(agents) == 0 {
+		return false
+	}
+	for _, a := range agents {
+		if statuses[a.PaneID].State != AgentIdle {
+			return false
+		}
+	}
+	return true
+}
+
+// matchFile matches pattern against the file's base name, or against the
+// whole relative path when the pattern contains a separator.
+func matchFile(pattern, path string) bool {
+	path = filepath.ToSlash(path)
+	if strings.Contains(pattern, "/") {
+		ok, _ := filepath.Match(pattern, path)
+		return ok
+	}
+	ok, _ := filepath.Match(pattern, filepath.Base(path))
+	return ok
+}
+
+func describeTrigger(t Trigger) string {
+	switch t.Type {
+	case TriggerFileCreated:
+		return fmt.Sprintf("a file matching %s is created", t.Pattern)
+	case TriggerFileModified:
+		return fmt.Sprintf("a file matching %s is modified", t.Pattern)
+	case TriggerCommandSuccess:
+		return fmt.Sprintf("`%s` succeeds", t.Command)
+	case TriggerCommandFailure:
+		return fmt.Sprintf("`%s` fails", t.Command)
+	case TriggerAgentSays:
+		who := "an agent"
+		if t.Role != "" {
+			who = "the " + t.Role + " agent"
+		}
+		return fmt.Sprintf("%s says %q", who, t.Pattern)
+	case TriggerAllAgentsIdle:
+		who := "all agents"
+		if t.Role != "" {
+			who = "all " + t.Role + " agents"
+		}
+		return fmt.Sprintf("%s are idle for %d min", who, t.IdleMinutes)
+	case TriggerManual:
+		if t.Label != "" {
+			return fmt.Sprintf("the operator confirms %q", t.Label)
+		}
+		return "the operator advances the workflow"
+	case TriggerTimeElapsed:
+		return fmt.Sprintf("%d min have elapsed", t.Minutes)
+	}
+	return string(t.Type)
+}
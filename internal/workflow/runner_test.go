@@ -0,0 +1,338 @@
+package workflow
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shahbajlive/ntm/internal/events"
+)
+
+type fakeRuntime struct {
+	agents   []Agent
+	sent     map[string][]string
+	output   map[string]string
+	statuses map[string]AgentStatus
+	commands map[string]bool
+	ran      []string
+	restarts []string
+	messages []Message
+	notes    []string
+	watch    func(FileEvent)
+}
+
+func newFakeRuntime(agents ...Agent) *fakeRuntime {
+	return &fakeRuntime{
+		agents:   agents,
+		sent:     make(map[string][]string),
+		output:   make(map[string]string),
+		statuses: make(map[string]AgentStatus),
+		commands: make(map[string]bool),
+	}
+}
+
+func (f *fakeRuntime) LaunchAgents(context.Context, *WorkflowTemplate) ([]Agent, error) {
+	return f.agents, nil
+}
+
+func (f *fakeRuntime) WatchFiles(_ context.Context, fn func(FileEvent)) error {
+	f.watch = fn
+	return nil
+}
+
+func (f *fakeRuntime) SendPrompt(_ context.Context, a Agent, prompt string) error {
+	f.sent[a.PaneID] = append(f.sent[a.PaneID], prompt)
+	return nil
+}
+
+func (f *fakeRuntime) CaptureOutput(_ context.Context, a Agent) (string, error) {
+	return f.output[a.PaneID], nil
+}
+
+func (f *fakeRuntime) AgentStatuses(context.Context) (map[string]AgentStatus, error) {
+	return f.statuses, nil
+}
+
+func (f *fakeRuntime) RunCommand(_ context.Context, command string) (bool, error) {
+	f.ran = append(f.ran, command)
+	return f.commands[command], nil
+}
+
+func (f *fakeRuntime) RestartAgent(_ context.Context, a Agent) error {
+	f.restarts = append(f.restarts, a.PaneID)
+	return nil
+}
+
+func (f *fakeRuntime) Messages(context.Context, time.Time) ([]Message, error) {
+	return f.messages, nil
+}
+
+func (f *fakeRuntime) Notify(_ context.Context, subject, body string) {
+	f.notes = append(f.notes, subject+": "+body)
+}
+
+type testClock struct{ t time.Time }
+
+func (c *testClock) now() time.Time          { return c.t }
+func (c *testClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func builtin(t *testing.T, name string) *WorkflowTemplate {
+	t.Helper()
+	tmpl, err := NewLoader().Get(name)
+	if err != nil {
+		t.Fatalf("load %s: %v", name, err)
+	}
+	return tmpl
+}
+
+func startRunner(t *testing.T, tmpl *WorkflowTemplate, rt *fakeRuntime, vars map[string]string) (*Runner, *testClock, *events.EventBus) {
+	t.Helper()
+	clock := &testClock{t: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+	bus := events.NewEventBus(50)
+	r, err := NewRunner(tmpl, rt, RunConfig{
+		Session: "proj",
+		RunID:   "run-1",
+		Vars:    vars,
+		Bus:     bus,
+		Now:     clock.now,
+	})
+	if err != nil {
+		t.Fatalf("NewRunner: %v", err)
+	}
+	if err := r.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	return r, clock, bus
+}
+
+// eventTypes returns the bus history oldest first.
+func eventTypes(bus *events.EventBus) []string {
+	hist := bus.History(0)
+	types := make([]string, 0, len(hist))
+	for i := len(hist) - 1; i >= 0; i-- {
+		types = append(types, hist[i].EventType())
+	}
+	return types
+}
+
+func TestRedGreenPingPong(t *testing.T) {
+	t.Parallel()
+	rt := newFakeRuntime(Agent{PaneID: "%1", Role: "red"}, Agent{PaneID: "%2", Role: "green"})
+	r, clock, bus := startRunner(t, builtin(t, "red-green"), rt, map[string]string{"feature": "login rate limiting"})
+
+	if got := r.Status().Stage; got != "red" {
+		t.Fatalf("initial stage = %q, want red", got)
+	}
+	if len(rt.sent["%1"]) != 1 || len(rt.sent["%2"]) != 0 {
+		t.Fatalf("prompts sent = %v, want only the red agent", rt.sent)
+	}
+	prompt := rt.sent["%1"][0]
+	for _, want := range []string{"red agent", "login rate limiting", "*_test.go"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("red prompt missing %q:\n%s", want, prompt)
+		}
+	}
+
+	// A non-test file does nothing; a new test file hands off to green.
+	rt.watch(FileEvent{Path: "auth/limit.go", Created: true})
+	r.Tick(context.Background())
+	if r.Status().Stage != "red" {
+		t.Fatalf("stage moved on a non-matching file")
+	}
+	rt.output["%1"] = "Wrote auth/limit_test.go with 3 failing cases"
+	rt.watch(FileEvent{Path: "auth/limit_test.go", Created: true})
+	r.Tick(context.Background())
+	if r.Status().Stage != "green" {
+		t.Fatalf("stage = %q, want green after test file created", r.Status().Stage)
+	}
+	if len(rt.sent["%2"]) != 1 || !strings.Contains(rt.sent["%2"][0], "3 failing cases") {
+		t.Errorf("green handoff prompt should quote red's output: %v", rt.sent["%2"])
+	}
+
+	// The command only runs once green is idle, and is throttled.
+	rt.statuses["%2"] = AgentStatus{State: AgentWorking}
+	r.Tick(context.Background())
+	if len(rt.ran) != 0 {
+		t.Fatalf("command ran while agent working: %v", rt.ran)
+	}
+	rt.statuses["%2"] = AgentStatus{State: AgentIdle}
+	r.Tick(context.Background())
+	r.Tick(context.Background())
+	if len(rt.ran) != 1 || r.Status().Stage != "green" {
+		t.Fatalf("ran = %v stage = %s, want one failing run", rt.ran, r.Status().Stage)
+	}
+	rt.commands["go test ./..."] = true
+	clock.advance(DefaultCommandInterval)
+	r.Tick(context.Background())
+	if r.Status().Stage != "red" {
+		t.Fatalf("stage = %q, want red after tests pass", r.Status().Stage)
+	}
+
+	types := eventTypes(bus)
+	if types[0] != "workflow_started" || strings.Count(strings.Join(types, ","), "stage_transition") != 2 {
+		t.Errorf("events = %v", types)
+	}
+}
+
+func TestReviewPipelineCompletes(t *testing.T) {
+	t.Parallel()
+	rt := newFakeRuntime(
+		Agent{PaneID: "%1", Role: "author"},
+		Agent{PaneID: "%2", Role: "reviewer"},
+		Agent{PaneID: "%3", Role: "reviewer"},
+	)
+	r, _, bus := startRunner(t, builtin(t, "review-pipeline"), rt, map[string]string{"feature": "csv export"})
+
+	if len(rt.sent["%1"]) != 1 || len(rt.sent["%2"]) != 0 {
+		t.Fatalf("implement stage should prompt the author only: %v", rt.sent)
+	}
+	r.Advance("Submit for review")
+	r.Tick(context.Background())
+	if r.Status().Stage != "review" || len(rt.sent["%2"]) != 1 || len(rt.sent["%3"]) != 1 {
+		t.Fatalf("stage = %q sent = %v, want both reviewers prompted", r.Status().Stage, rt.sent)
+	}
+
+	// The review prompt itself mentions the verdict words; echoes of it
+	// in the pane must not fire the trigger.
+	rt.output["%2"] = rt.sent["%2"][0]
+	r.Tick(context.Background())
+	if r.Status().Stage != "review" {
+		t.Fatalf("prompt echo fired a transition to %q", r.Status().Stage)
+	}
+
+	// An Agent Mail message from a non-reviewer is ignored; one from a
+	// reviewer's pane counts.
+	rt.messages = []Message{{PaneID: "%1", Subject: "LGTM from me"}}
+	r.Tick(context.Background())
+	if r.Status().Stage != "review" {
+		t.Fatalf("author message advanced the review")
+	}
+	rt.messages = []Message{{PaneID: "%3", Subject: "Review", Body: "Looks good, ship it"}}
+	r.Tick(context.Background())
+
+	st := r.Status()
+	if st.State != RunCompleted || st.Stage != "complete" || st.FinishedAt == nil {
+		t.Fatalf("status = %+v, want completed", st)
+	}
+	types := eventTypes(bus)
+	if types[len(types)-1] != "workflow_completed" {
+		t.Errorf("last event = %s, want workflow_completed", types[len(types)-1])
+	}
+}
+
+func TestStageTimeoutAndAgentErrors(t *testing.T) {
+	t.Parallel()
+	rt := newFakeRuntime(
+		Agent{PaneID: "%1", Role: "design"},
+		Agent{PaneID: "%2", Role: "build"},
+		Agent{PaneID: "%3", Role: "build"},
+		Agent{PaneID: "%4", Role: "qa"},
+	)
+	r, clock, _ := startRunner(t, builtin(t, "specialist-team"), rt, map[string]string{"project": "billing"})
+	if r.Status().Stage != "design" {
+		t.Fatalf("pipeline should start at its first stage, got %q", r.Status().Stage)
+	}
+
+	// Timeout notifies once per stage.
+	clock.advance(61 * time.Minute)
+	r.Tick(context.Background())
+	r.Tick(context.Background())
+	if len(rt.notes) != 1 || !strings.Contains(rt.notes[0], "timeout") {
+		t.Fatalf("notes = %v, want one timeout notification", rt.notes)
+	}
+
+	// Crashes restart the agent (max 2 per stage) and re-send the prompt.
+	r.Advance("build")
+	r.Tick(context.Background())
+	if r.Status().Stage != "build" {
+		t.Fatalf("stage = %q, want build", r.Status().Stage)
+	}
+	for i := 0; i < 3; i++ {
+		rt.statuses["%2"] = AgentStatus{State: AgentCrashed}
+		r.Tick(context.Background())
+		rt.statuses["%2"] = AgentStatus{State: AgentWorking}
+		r.Tick(context.Background())
+	}
+	if len(rt.restarts) != 2 || len(rt.sent["%2"]) != 3 {
+		t.Errorf("restarts = %v, prompts to %%2 = %d; want 2 restarts and resent prompts", rt.restarts, len(rt.sent["%2"]))
+	}
+	if st := r.Status(); st.State != RunPaused || !strings.Contains(st.PauseReason, "retries exhausted") {
+		t.Fatalf("status = %s %q, want paused after retries exhausted", st.State, st.PauseReason)
+	}
+
+	// Paused runs ignore triggers until resumed.
+	rt.statuses = map[string]AgentStatus{"%2": {State: AgentIdle}, "%3": {State: AgentIdle}}
+	clock.advance(10 * time.Minute)
+	r.Tick(context.Background())
+	if r.Status().Stage != "build" {
+		t.Fatalf("paused run transitioned")
+	}
+	r.Resume()
+	r.Tick(context.Background())
+	clock.advance(5 * time.Minute)
+	r.Tick(context.Background())
+	if r.Status().Stage != "qa" {
+		t.Fatalf("stage = %q, want qa once build agents idle for 5m", r.Status().Stage)
+	}
+}
+
+func TestParallelWorkflowCompletesWhenAgentsFinish(t *testing.T) {
+	t.Parallel()
+	rt := newFakeRuntime(Agent{PaneID: "%1", Role: "approach-a"}, Agent{PaneID: "%2", Role: "approach-b"})
+	r, _, _ := startRunner(t, builtin(t, "parallel-explore"), rt,
+		map[string]string{"problem": "slow search", "approach_a": "index", "approach_b": "cache"})
+
+	if len(rt.sent["%1"]) != 1 || len(rt.sent["%2"]) != 1 {
+		t.Fatalf("all roles should be prompted: %v", rt.sent)
+	}
+	rt.statuses = map[string]AgentStatus{"%1": {State: AgentIdle}, "%2": {State: AgentIdle}}
+	r.Tick(context.Background())
+	if r.Status().Done() {
+		t.Fatal("completed before agents did any work")
+	}
+	rt.statuses = map[string]AgentStatus{"%1": {State: AgentWorking}, "%2": {State: AgentWorking}}
+	r.Tick(context.Background())
+	rt.statuses = map[string]AgentStatus{"%1": {State: AgentIdle}, "%2": {State: AgentIdle}}
+	r.Tick(context.Background())
+	if r.Status().State != RunCompleted {
+		t.Fatalf("state = %s, want completed", r.Status().State)
+	}
+}
+
+func TestResolveVars(t *testing.T) {
+	t.Parallel()
+	tmpl := &WorkflowTemplate{Prompts: []SetupPrompt{
+		{Key: "feature", Question: "Feature?", Required: true},
+		{Key: "pattern", Question: "Pattern?", Default: "*_test.go"},
+		{Key: "ticket", Question: "Ticket?", Validation: `^[A-Z]+-\d+$`},
+	}}
+
+	if _, err := ResolveVars(tmpl, nil); err == nil || !strings.Contains(err.Error(), "feature") {
+		t.Errorf("missing required var: err = %v", err)
+	}
+	if _, err := ResolveVars(tmpl, map[string]string{"feature": "x", "ticket": "nope"}); err == nil {
+		t.Error("expected validation error")
+	}
+	vars, err := ResolveVars(tmpl, map[string]string{"feature": "x", "ticket": "NTM-12", "extra": "kept"})
+	if err != nil {
+		t.Fatalf("ResolveVars: %v", err)
+	}
+	if vars["pattern"] != "*_test.go" || vars["ticket"] != "NTM-12" || vars["extra"] != "kept" {
+		t.Errorf("vars = %v", vars)
+	}
+}
+
+func TestStageRoles(t *testing.T) {
+	t.Parallel()
+	review := builtin(t, "review-pipeline")
+	cases := map[string]string{"implement": "author", "review": "reviewer", "revise": "author"}
+	for stage, want := range cases {
+		if got := review.StageRoles(stage); len(got) != 1 || got[0] != want {
+			t.Errorf("StageRoles(%s) = %v, want [%s]", stage, got, want)
+		}
+	}
+	if got := builtin(t, "red-green").StageRoles("green"); len(got) != 1 || got[0] != "green" {
+		t.Errorf("red-green green roles = %v", got)
+	}
+}
@@ -63,6 +63,10 @@ type FlowConfig struct {
 	ApprovalMode        string       `toml:"approval_mode,omitempty"` // any, all, quorum
 	Quorum              int          `toml:"quorum,omitempty"`
 	ParallelWithinStage bool         `toml:"parallel_within_stage,omitempty"`
+	// StagePrompts overrides the handoff prompt sent on entering a stage.
+	// Bodies use {{var}} and {{#var}}...{{/var}} with the setup prompt keys
+	// plus workflow, stage, from_stage, trigger, role, handoff and exit.
+	StagePrompts map[string]string `toml:"stage_prompts,omitempty"`
 }
 
 // Transition defines a state change in the workflow.